@@ -1,19 +1,25 @@
 package main
 
 import (
-	"bufio"
+	"flag"
 	"fmt"
 	"log"
-	"os"
 	"strings"
 
 	"backend/configs"
 	"backend/internal/database"
+	"backend/internal/seed"
 
 	"github.com/joho/godotenv"
 )
 
+// expectedPermissions is the number of permissions defined by the seed file
+const expectedPermissions = 65
+
 func main() {
+	sqlFile := flag.String("file", "seed_permissions.sql", "path to the permissions seed SQL file")
+	flag.Parse()
+
 	// Load .env file
 	if err := godotenv.Load(); err != nil {
 		log.Println("Warning: .env file not found")
@@ -32,68 +38,40 @@ func main() {
 
 	// Read SQL file and execute INSERT statements ONLY (no DELETE)
 	fmt.Println("\n📝 Reading and executing seed file...")
-	file, err := os.Open("seed_permissions.sql")
+	statements, err := seed.ParseFile(*sqlFile)
 	if err != nil {
-		log.Fatal("Error reading file:", err)
+		log.Fatal("Error reading seed file:", err)
 	}
-	defer file.Close()
-
-	scanner := bufio.NewScanner(file)
-	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
-
-	var currentInsert strings.Builder
-	insertCount := 0
-
-	for scanner.Scan() {
-		line := scanner.Text()
-		trimmed := strings.TrimSpace(line)
 
-		// Skip comments, empty lines, TRUNCATE, and DELETE
-		if trimmed == "" ||
-		   strings.HasPrefix(trimmed, "--") ||
-		   strings.Contains(strings.ToUpper(trimmed), "TRUNCATE") ||
-		   strings.Contains(strings.ToUpper(trimmed), "DELETE") {
+	var inserts []seed.Statement
+	for _, stmt := range statements {
+		if stmt.Keyword() != "INSERT" {
 			continue
 		}
-
-		currentInsert.WriteString(line)
-		currentInsert.WriteString("\n")
-
-		// Execute when we hit a semicolon
-		if strings.HasSuffix(trimmed, ";") {
-			sql := currentInsert.String()
-			if strings.Contains(strings.ToUpper(sql), "INSERT") {
-				// Convert to INSERT ... ON CONFLICT to handle duplicates
-				sql = strings.Replace(sql, "INSERT INTO", "INSERT INTO", 1)
-				sql = strings.TrimSuffix(strings.TrimSpace(sql), ";")
-				sql += " ON CONFLICT (id) DO UPDATE SET " +
-					"code = EXCLUDED.code, " +
-					"name = EXCLUDED.name, " +
-					"resource = EXCLUDED.resource, " +
-					"action = EXCLUDED.action, " +
-					"scope = EXCLUDED.scope, " +
-					"description = EXCLUDED.description, " +
-					"is_system_permission = EXCLUDED.is_system_permission, " +
-					"is_active = EXCLUDED.is_active, " +
-					"category = EXCLUDED.category, " +
-					"group_name = EXCLUDED.group_name, " +
-					"group_icon = EXCLUDED.group_icon, " +
-					"group_sort_order = EXCLUDED.group_sort_order, " +
-					"updated_at = NOW();"
-
-				result := db.Exec(sql)
-				if result.Error != nil {
-					log.Printf("Error: %v", result.Error)
-					fmt.Println("SQL:", sql[:100])
-				} else {
-					insertCount++
-					if insertCount%10 == 0 {
-						fmt.Printf("  Processed %d statements...\n", insertCount)
-					}
-				}
-			}
-			currentInsert.Reset()
+		// Convert to INSERT ... ON CONFLICT to handle duplicates
+		if !strings.Contains(strings.ToUpper(stmt.SQL), "ON CONFLICT") {
+			stmt.SQL += " ON CONFLICT (id) DO UPDATE SET " +
+				"code = EXCLUDED.code, " +
+				"name = EXCLUDED.name, " +
+				"resource = EXCLUDED.resource, " +
+				"action = EXCLUDED.action, " +
+				"scope = EXCLUDED.scope, " +
+				"description = EXCLUDED.description, " +
+				"is_system_permission = EXCLUDED.is_system_permission, " +
+				"is_active = EXCLUDED.is_active, " +
+				"category = EXCLUDED.category, " +
+				"group_name = EXCLUDED.group_name, " +
+				"group_icon = EXCLUDED.group_icon, " +
+				"group_sort_order = EXCLUDED.group_sort_order, " +
+				"updated_at = NOW()"
 		}
+		inserts = append(inserts, stmt)
+	}
+
+	// All inserts run in one transaction; any failure rolls everything back
+	insertCount, err := seed.Execute(db, inserts)
+	if err != nil {
+		log.Fatal("Seed rolled back: ", err)
 	}
 
 	fmt.Printf("✅ Processed %d INSERT statements\n", insertCount)
@@ -121,9 +99,8 @@ func main() {
 
 	fmt.Printf("\n✅ Total: %d permissions\n", total)
 
-	if total == 65 {
-		fmt.Println("🎉 SUCCESS!")
-	} else {
-		fmt.Printf("⚠️  Expected 65, got %d\n", total)
+	if total != expectedPermissions {
+		log.Fatalf("⚠️  Expected %d, got %d", expectedPermissions, total)
 	}
+	fmt.Println("🎉 SUCCESS!")
 }
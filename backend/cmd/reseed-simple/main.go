@@ -1,19 +1,25 @@
 package main
 
 import (
-	"bufio"
+	"flag"
 	"fmt"
 	"log"
-	"os"
-	"strings"
 
 	"backend/configs"
 	"backend/internal/database"
+	"backend/internal/seed"
 
 	"github.com/joho/godotenv"
+	"gorm.io/gorm"
 )
 
+// expectedPermissions is the number of permissions defined by the seed file
+const expectedPermissions = 65
+
 func main() {
+	sqlFile := flag.String("file", "seed_permissions.sql", "path to the permissions seed SQL file")
+	flag.Parse()
+
 	// Load .env file
 	if err := godotenv.Load(); err != nil {
 		log.Println("Warning: .env file not found")
@@ -30,58 +36,37 @@ func main() {
 	db := database.GetDB()
 	fmt.Println("✅ Connected to database")
 
-	// Step 1: Clean existing data (fast DELETE, not TRUNCATE)
-	fmt.Println("\n🗑️  Cleaning...")
-
-	db.Exec("DELETE FROM public.role_permissions")
-	db.Exec("DELETE FROM public.user_permissions")
-	db.Exec("DELETE FROM public.permissions")
-
-	fmt.Println("✅ Cleaned")
-
-	// Step 2: Read SQL file and execute INSERT statements only
+	// Step 1: Read SQL file and keep INSERT statements only
 	fmt.Println("\n📝 Reading seed file...")
-	file, err := os.Open("seed_permissions.sql")
+	statements, err := seed.ParseFile(*sqlFile)
 	if err != nil {
-		log.Fatal("Error reading file:", err)
+		log.Fatal("Error reading seed file:", err)
 	}
-	defer file.Close()
-
-	scanner := bufio.NewScanner(file)
-	scanner.Buffer(make([]byte, 1024*1024), 1024*1024) // 1MB buffer for large lines
 
-	var currentInsert strings.Builder
-	insertCount := 0
-
-	for scanner.Scan() {
-		line := scanner.Text()
-		trimmed := strings.TrimSpace(line)
-
-		// Skip comments, empty lines, and TRUNCATE
-		if trimmed == "" || strings.HasPrefix(trimmed, "--") || strings.HasPrefix(trimmed, "TRUNCATE") {
-			continue
+	var inserts []seed.Statement
+	for _, stmt := range statements {
+		if stmt.Keyword() == "INSERT" {
+			inserts = append(inserts, stmt)
 		}
+	}
 
-		// Build INSERT statement
-		currentInsert.WriteString(line)
-		currentInsert.WriteString("\n")
-
-		// Execute when we hit a semicolon
-		if strings.HasSuffix(trimmed, ";") {
-			sql := currentInsert.String()
-			if strings.Contains(sql, "INSERT") {
-				result := db.Exec(sql)
-				if result.Error != nil {
-					log.Printf("Error executing INSERT: %v", result.Error)
-				} else {
-					insertCount++
-					if insertCount%10 == 0 {
-						fmt.Printf("  Inserted %d...\n", insertCount)
-					}
-				}
+	// Step 2: Clean existing data (fast DELETE, not TRUNCATE) and insert in one
+	// transaction, so a failing statement leaves the existing permissions intact
+	fmt.Println("\n🗑️  Cleaning and inserting...")
+	insertCount := 0
+	err = db.Transaction(func(tx *gorm.DB) error {
+		for _, table := range []string{"public.role_permissions", "public.user_permissions", "public.permissions"} {
+			if err := tx.Exec("DELETE FROM " + table).Error; err != nil {
+				return fmt.Errorf("failed to clean %s: %w", table, err)
 			}
-			currentInsert.Reset()
 		}
+
+		var err error
+		insertCount, err = seed.ExecuteTx(tx, inserts)
+		return err
+	})
+	if err != nil {
+		log.Fatal("Reseed rolled back: ", err)
 	}
 
 	fmt.Printf("✅ Executed %d INSERT statements\n", insertCount)
@@ -109,9 +94,8 @@ func main() {
 
 	fmt.Printf("\n✅ Total: %d permissions\n", total)
 
-	if total == 65 {
-		fmt.Println("🎉 SUCCESS!")
-	} else {
-		fmt.Printf("⚠️  Expected 65, got %d\n", total)
+	if total != expectedPermissions {
+		log.Fatalf("⚠️  Expected %d, got %d", expectedPermissions, total)
 	}
+	fmt.Println("🎉 SUCCESS!")
 }
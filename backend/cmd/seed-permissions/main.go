@@ -1,111 +1,73 @@
 package main
 
 import (
-	"database/sql"
+	"flag"
 	"fmt"
 	"log"
 	"os"
+	"regexp"
 
-	_ "github.com/lib/pq"
-)
-
-func main() {
-	// Database connection string
-	connStr := "host=localhost port=3479 user=postgres password=testing123 dbname=gloria_v2 sslmode=disable"
+	"backend/configs"
+	"backend/internal/database"
 
-	// Connect to database
-	db, err := sql.Open("postgres", connStr)
-	if err != nil {
-		log.Fatalf("Error connecting to database: %v", err)
-	}
-	defer db.Close()
+	"github.com/joho/godotenv"
+)
 
-	// Test connection
-	err = db.Ping()
-	if err != nil {
-		log.Fatalf("Error ping database: %v", err)
-	}
+var (
+	insertInto = regexp.MustCompile(`(?i)\bINSERT\s+INTO\b`)
+	onConflict = regexp.MustCompile(`(?i)\bON\s+CONFLICT\b`)
+)
 
-	fmt.Println("✅ Connected to database successfully")
+func main() {
+	sqlFile := flag.String("file", "seed_permissions.sql", "path to the permissions seed SQL file")
+	builtin := flag.Bool("builtin", false, "seed the built-in permission list instead of a SQL file")
+	flag.Parse()
 
-	// Read SQL file
-	sqlFile := "seed_permissions.sql"
-	sqlBytes, err := os.ReadFile(sqlFile)
-	if err != nil {
-		log.Fatalf("Error reading SQL file: %v", err)
+	// Load .env file
+	if err := godotenv.Load(); err != nil {
+		log.Println("Warning: .env file not found, using system environment variables")
 	}
 
-	sqlContent := string(sqlBytes)
+	// Load configuration
+	cfg := configs.LoadConfig()
 
-	// Execute SQL
-	fmt.Println("🔄 Executing permissions seed SQL...")
-	_, err = db.Exec(sqlContent)
-	if err != nil {
-		log.Fatalf("Error executing SQL: %v", err)
+	// Initialize database
+	if err := database.InitDB(cfg); err != nil {
+		log.Fatal("Failed to connect to database:", err)
 	}
 
-	fmt.Println("✅ Permissions seed data executed successfully!")
-
-	// Verify data
-	var count int
-	err = db.QueryRow("SELECT COUNT(*) FROM public.permissions").Scan(&count)
-	if err != nil {
-		log.Fatalf("Error counting permissions: %v", err)
-	}
+	db := database.GetDB()
 
-	fmt.Printf("✅ Total permissions in database: %d\n", count)
-
-	// Count by category
-	fmt.Println("\n📊 Permissions per Category:")
-	rows, err := db.Query(`
-		SELECT category, COUNT(*) as total
-		FROM public.permissions
-		GROUP BY category
-		ORDER BY category
-	`)
-	if err != nil {
-		log.Fatalf("Error querying permissions: %v", err)
-	}
-	defer rows.Close()
+	fmt.Println("✅ Connected to database successfully")
 
-	for rows.Next() {
-		var category string
-		var total int
-		err = rows.Scan(&category, &total)
+	if *builtin {
+		seedBuiltinPermissions(db)
+	} else {
+		// Read SQL file
+		sqlBytes, err := os.ReadFile(*sqlFile)
 		if err != nil {
-			log.Printf("Error scanning row: %v", err)
-			continue
+			log.Fatalf("Error reading SQL file: %v", err)
 		}
-		fmt.Printf("  - %-15s: %d permissions\n", category, total)
-	}
 
-	// Count by action
-	fmt.Println("\n📋 Permissions per Action:")
-	actionRows, err := db.Query(`
-		SELECT action, COUNT(*) as total
-		FROM public.permissions
-		GROUP BY action
-		ORDER BY total DESC
-	`)
-	if err != nil {
-		log.Fatalf("Error querying permissions by action: %v", err)
-	}
-	defer actionRows.Close()
+		// Every INSERT must be an upsert so reseeding never needs manual DELETEs
+		if !isIdempotent(string(sqlBytes)) {
+			log.Fatalf("Seed file %s has INSERT statements without ON CONFLICT; use INSERT ... ON CONFLICT (code) DO UPDATE or DO NOTHING so it can be re-run", *sqlFile)
+		}
 
-	for actionRows.Next() {
-		var action string
-		var total int
-		err = actionRows.Scan(&action, &total)
-		if err != nil {
-			log.Printf("Error scanning row: %v", err)
-			continue
+		// Execute SQL; a multi-statement query runs in one implicit transaction
+		fmt.Printf("🔄 Executing permissions seed SQL from %s...\n", *sqlFile)
+		if err := db.Exec(string(sqlBytes)).Error; err != nil {
+			log.Fatalf("Error executing SQL: %v", err)
 		}
-		fmt.Printf("  - %-10s: %d permissions\n", action, total)
+
+		fmt.Println("✅ Permissions seed data executed successfully!")
 	}
 
-	// System permissions count
-	var systemPerms int
-	db.QueryRow("SELECT COUNT(*) FROM public.permissions WHERE is_system_permission = true").Scan(&systemPerms)
-	fmt.Printf("\n🔒 System Permissions: %d\n", systemPerms)
-	fmt.Printf("👥 Regular Permissions: %d\n", count-systemPerms)
+	// Verify data
+	printPermissionStats(db)
+}
+
+// isIdempotent reports whether every INSERT in the seed SQL has an ON CONFLICT clause
+func isIdempotent(content string) bool {
+	return len(onConflict.FindAllStringIndex(content, -1)) >= len(insertInto.FindAllStringIndex(content, -1))
 }
@@ -5,69 +5,42 @@ import (
 	"log"
 	"time"
 
-	"backend/configs"
-	"backend/internal/database"
 	"backend/internal/models"
 
-	"github.com/joho/godotenv"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-func main() {
-	// Load .env file
-	if err := godotenv.Load(); err != nil {
-		log.Println("Warning: .env file not found")
-	}
-
-	// Load configuration
-	cfg := configs.LoadConfig()
-
-	// Initialize database
-	if err := database.InitDB(cfg); err != nil {
-		log.Fatal("Failed to connect to database:", err)
-	}
-
-	db := database.GetDB()
-
-	fmt.Println("✅ Connected to database successfully")
-
-	// Delete existing permissions with CASCADE
-	fmt.Println("🗑️  Deleting existing permissions...")
-
-	// First delete dependent data
-	fmt.Println("  Deleting role_permissions...")
-	if err := db.Exec("DELETE FROM public.role_permissions").Error; err != nil {
-		log.Printf("Warning: Error deleting role_permissions: %v", err)
-	}
-
-	fmt.Println("  Deleting user_permissions...")
-	if err := db.Exec("DELETE FROM public.user_permissions").Error; err != nil {
-		log.Printf("Warning: Error deleting user_permissions: %v", err)
-	}
-
-	fmt.Println("  Deleting permissions...")
-	if err := db.Exec("DELETE FROM public.permissions").Error; err != nil {
-		log.Fatal("Error deleting permissions:", err)
-	}
-
+// seedBuiltinPermissions upserts the permissions defined in getPermissions, keyed by code,
+// so it can be re-run without deleting existing permissions or their role assignments
+func seedBuiltinPermissions(db *gorm.DB) {
 	// Seed permissions
 	permissions := getPermissions()
 
-	fmt.Printf("📝 Creating %d permissions...\n", len(permissions))
+	fmt.Printf("📝 Upserting %d permissions...\n", len(permissions))
 
+	upsert := clause.OnConflict{
+		Columns: []clause.Column{{Name: "code"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"name", "description", "resource", "action", "scope", "is_system_permission",
+			"category", "group_name", "group_icon", "group_sort_order", "updated_at",
+		}),
+	}
 	for i, perm := range permissions {
-		if err := db.Create(&perm).Error; err != nil {
-			log.Printf("Error creating permission %d (%s): %v", i+1, perm.Code, err)
-			continue
+		if err := db.Clauses(upsert).Create(&perm).Error; err != nil {
+			log.Fatalf("Error upserting permission %d (%s): %v", i+1, perm.Code, err)
 		}
 
 		if (i+1)%10 == 0 {
-			fmt.Printf("  Created %d/%d permissions...\n", i+1, len(permissions))
+			fmt.Printf("  Upserted %d/%d permissions...\n", i+1, len(permissions))
 		}
 	}
 
 	fmt.Println("✅ Permissions seed completed!")
+}
 
-	// Verify
+// printPermissionStats prints permission totals by category, action and scope
+func printPermissionStats(db *gorm.DB) {
 	var count int64
 	db.Model(&models.Permission{}).Count(&count)
 	fmt.Printf("✅ Total permissions in database: %d\n", count)
@@ -149,6 +122,7 @@ func getPermissions() []models.Permission {
 		{ID: "650e8400-e29b-41d4-a716-446655440004", Code: "PERM_USERS_UPDATE", Name: "Update User", Resource: "users", Action: models.PermissionActionUpdate, Scope: &scopeAll, Description: strPtr("Mengubah data pengguna"), IsSystemPermission: true, IsActive: true, Category: &catSystem, GroupName: strPtr("Pengguna"), GroupIcon: strPtr("UserCog"), GroupSortOrder: intPtr(3), CreatedAt: now, UpdatedAt: now},
 		{ID: "650e8400-e29b-41d4-a716-446655440005", Code: "PERM_USERS_DELETE", Name: "Delete User", Resource: "users", Action: models.PermissionActionDelete, Scope: &scopeAll, Description: strPtr("Menghapus pengguna"), IsSystemPermission: true, IsActive: true, Category: &catSystem, GroupName: strPtr("Pengguna"), GroupIcon: strPtr("UserCog"), GroupSortOrder: intPtr(3), CreatedAt: now, UpdatedAt: now},
 		{ID: "650e8400-e29b-41d4-a716-446655440056", Code: "PERM_USERS_EXPORT", Name: "Export Users", Resource: "users", Action: models.PermissionActionExport, Scope: &scopeAll, Description: strPtr("Export data pengguna ke Excel/CSV"), IsSystemPermission: false, IsActive: true, Category: &catSystem, GroupName: strPtr("Pengguna"), GroupIcon: strPtr("UserCog"), GroupSortOrder: intPtr(3), CreatedAt: now, UpdatedAt: now},
+		{ID: "650e8400-e29b-41d4-a716-446655440066", Code: "PERM_USERS_IMPERSONATE", Name: "Impersonate Users", Resource: "users", Action: models.PermissionActionImpersonate, Scope: &scopeAll, Description: strPtr("Masuk sebagai pengguna lain untuk keperluan dukungan"), IsSystemPermission: false, IsActive: true, Category: &catSystem, GroupName: strPtr("Pengguna"), GroupIcon: strPtr("UserCog"), GroupSortOrder: intPtr(3), CreatedAt: now, UpdatedAt: now},
 
 		// Roles
 		{ID: "650e8400-e29b-41d4-a716-446655440006", Code: "PERM_ROLES_CREATE", Name: "Create Role", Resource: "roles", Action: models.PermissionActionCreate, Scope: &scopeAll, Description: strPtr("Membuat role baru"), IsSystemPermission: true, IsActive: true, Category: &catSystem, GroupName: strPtr("Akses & Roles"), GroupIcon: strPtr("Shield"), GroupSortOrder: intPtr(5), CreatedAt: now, UpdatedAt: now},
@@ -232,6 +206,9 @@ func getPermissions() []models.Permission {
 		{ID: "650e8400-e29b-41d4-a716-446655440054", Code: "PERM_AUDIT_READ_OWN", Name: "View Own Audit Logs", Resource: "audit", Action: models.PermissionActionRead, Scope: &scopeOwn, Description: strPtr("Melihat audit logs aktivitas sendiri"), IsSystemPermission: false, IsActive: true, Category: &catQuality, GroupName: strPtr("Audit Logs"), GroupIcon: strPtr("FileText"), GroupSortOrder: intPtr(8), CreatedAt: now, UpdatedAt: now},
 		{ID: "650e8400-e29b-41d4-a716-446655440065", Code: "PERM_AUDIT_READ_DEPT", Name: "View Department Audit Logs", Resource: "audit", Action: models.PermissionActionRead, Scope: &scopeDept, Description: strPtr("Melihat audit logs dalam departemen yang sama"), IsSystemPermission: false, IsActive: true, Category: &catQuality, GroupName: strPtr("Audit Logs"), GroupIcon: strPtr("FileText"), GroupSortOrder: intPtr(8), CreatedAt: now, UpdatedAt: now},
 		{ID: "650e8400-e29b-41d4-a716-446655440055", Code: "PERM_AUDIT_EXPORT", Name: "Export Audit Logs", Resource: "audit", Action: models.PermissionActionExport, Scope: &scopeAll, Description: strPtr("Export audit logs ke Excel/CSV"), IsSystemPermission: true, IsActive: true, Category: &catQuality, GroupName: strPtr("Audit Logs"), GroupIcon: strPtr("FileText"), GroupSortOrder: intPtr(8), CreatedAt: now, UpdatedAt: now},
+
+		// System
+		{ID: "650e8400-e29b-41d4-a716-446655440067", Code: "PERM_SYSTEM_UPDATE", Name: "Manage System Settings", Resource: "system", Action: models.PermissionActionUpdate, Scope: &scopeAll, Description: strPtr("Mengelola dan menguji konfigurasi sistem (misalnya email)"), IsSystemPermission: true, IsActive: true, Category: &catSystem, GroupName: strPtr("Sistem"), GroupIcon: strPtr("Settings"), GroupSortOrder: intPtr(9), CreatedAt: now, UpdatedAt: now},
 	}
 }
 
@@ -1,8 +1,13 @@
+//go:build ignore
+
+// Standalone variant of the permission seeder; run with: go run seed_simple.go
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
+	"os"
 
 	"backend/configs"
 	"backend/internal/database"
@@ -12,6 +17,9 @@ import (
 )
 
 func main() {
+	sqlFile := flag.String("file", "seed_permissions.sql", "path to the permissions seed SQL file")
+	flag.Parse()
+
 	// Load .env file
 	if err := godotenv.Load(); err != nil {
 		log.Println("Warning: .env file not found")
@@ -45,14 +53,14 @@ func main() {
 
 	// Seed permissions using SQL file
 	fmt.Println("📝 Reading seed SQL file...")
-	sqlFile := "seed_permissions.sql"
+	sqlBytes, err := os.ReadFile(*sqlFile)
+	if err != nil {
+		log.Fatalf("Error reading SQL file: %v", err)
+	}
 
 	// Use raw SQL execution
 	fmt.Println("🔄 Executing seed SQL...")
-	result := db.Exec(`
-		-- Insert all permissions directly
-		-- Copy the INSERT statements from seed_permissions.sql here
-	`)
+	result := db.Exec(string(sqlBytes))
 
 	if result.Error != nil {
 		log.Fatal("Error executing seed SQL:", result.Error)
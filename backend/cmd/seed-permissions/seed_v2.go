@@ -1,18 +1,32 @@
+//go:build ignore
+
+// Standalone statement-by-statement variant of the permission seeder; run with: go run seed_v2.go
 package main
 
 import (
 	"database/sql"
+	"flag"
 	"fmt"
 	"log"
 	"os"
 	"strings"
 
+	"backend/configs"
+
+	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 )
 
 func main() {
-	// Database connection string
-	connStr := "host=localhost port=3479 user=postgres password=testing123 dbname=gloria_v2 sslmode=disable"
+	sqlFile := flag.String("file", "seed_permissions.sql", "path to the permissions seed SQL file")
+	flag.Parse()
+
+	// Database connection from configuration (.env or environment)
+	if err := godotenv.Load(); err != nil {
+		log.Println("Warning: .env file not found, using system environment variables")
+	}
+	cfg := configs.LoadConfig()
+	connStr := cfg.Database.DSN()
 
 	// Connect to database
 	db, err := sql.Open("postgres", connStr)
@@ -30,8 +44,7 @@ func main() {
 	fmt.Println("✅ Connected to database successfully")
 
 	// Read SQL file
-	sqlFile := "seed_permissions.sql"
-	sqlBytes, err := os.ReadFile(sqlFile)
+	sqlBytes, err := os.ReadFile(*sqlFile)
 	if err != nil {
 		log.Fatalf("Error reading SQL file: %v", err)
 	}
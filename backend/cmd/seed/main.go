@@ -2,16 +2,28 @@ package main
 
 import (
 	"database/sql"
+	"flag"
 	"fmt"
 	"log"
 	"os"
 
+	"backend/configs"
+
+	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 )
 
 func main() {
-	// Database connection string
-	connStr := "host=localhost port=3479 user=postgres password=testing123 dbname=gloria_v2 sslmode=disable"
+	// Read SQL file (Version 3 - Match with sidebar menu labels)
+	sqlFile := flag.String("file", "seed_modules_v3.sql", "path to the modules seed SQL file")
+	flag.Parse()
+
+	// Database connection string from configuration (.env or environment)
+	if err := godotenv.Load(); err != nil {
+		log.Println("Warning: .env file not found, using system environment variables")
+	}
+	cfg := configs.LoadConfig()
+	connStr := cfg.Database.DSN()
 
 	// Connect to database
 	db, err := sql.Open("postgres", connStr)
@@ -28,9 +40,7 @@ func main() {
 
 	fmt.Println("✅ Connected to database successfully")
 
-	// Read SQL file (Version 3 - Match with sidebar menu labels)
-	sqlFile := "seed_modules_v3.sql"
-	sqlBytes, err := os.ReadFile(sqlFile)
+	sqlBytes, err := os.ReadFile(*sqlFile)
 	if err != nil {
 		log.Fatalf("Error reading SQL file: %v", err)
 	}
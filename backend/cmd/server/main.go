@@ -1,13 +1,23 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"backend/configs"
 	"backend/internal/auth"
+	"backend/internal/clock"
 	"backend/internal/database"
+	"backend/internal/email"
 	"backend/internal/handlers"
+	"backend/internal/helpers"
+	"backend/internal/logger"
 	"backend/internal/middleware"
 	"backend/internal/models"
 	"backend/internal/services"
@@ -27,6 +37,14 @@ func main() {
 	// Load configuration
 	cfg := configs.LoadConfig()
 
+	// Switch to structured JSON logging
+	logger.Init(cfg.Log.Level)
+
+	// Evaluate effective dates in the application timezone
+	if err := clock.SetTimezone(cfg.Server.Timezone); err != nil {
+		log.Fatal("Failed to load APP_TIMEZONE:", err)
+	}
+
 	// Initialize database
 	log.Println("Connecting to database...")
 	if err := database.InitDB(cfg); err != nil {
@@ -43,10 +61,30 @@ func main() {
 
 	// Initialize JWT
 	log.Println("Initializing JWT authentication...")
-	auth.InitJWT(cfg.JWT.Secret)
+	auth.InitJWT(cfg.JWT.Secret, auth.TokenOptions{
+		AccessTokenTTL: time.Duration(cfg.JWT.AccessTokenTTLMinutes) * time.Minute,
+		RememberMeTTL:  time.Duration(cfg.JWT.RememberMeDays) * 24 * time.Hour,
+		ResetTokenTTL:  time.Duration(cfg.JWT.ResetTokenTTLMinutes) * time.Minute,
+		Issuer:         cfg.JWT.Issuer,
+		Audience:       cfg.JWT.Audience,
+	})
+	auth.InitLockout(auth.LockoutOptions{
+		MaxFailedAttempts:   cfg.Lockout.MaxFailedAttempts,
+		AccountLockDuration: time.Duration(cfg.Lockout.LockDurationMinutes) * time.Minute,
+	})
+	auth.InitPasswordHashing(auth.PasswordHashOptions{
+		Time:          uint32(cfg.Password.Time),
+		MemoryKiB:     uint32(cfg.Password.MemoryKiB),
+		Threads:       uint8(cfg.Password.Threads),
+		MigrateBcrypt: cfg.Password.MigrateBcrypt,
+	})
 
 	// Initialize Permission Services
 	log.Println("Initializing permission services...")
+	cacheConfig := services.DefaultCacheConfig()
+	cacheConfig.TTL = time.Duration(cfg.Permission.CacheTTLSeconds) * time.Second
+	cacheConfig.StaleWhileRevalidate = time.Duration(cfg.Permission.CacheStaleSeconds) * time.Second
+	middleware.ConfigurePermissionCache(cacheConfig)
 	middleware.InitPermissionServices()
 
 	// Initialize CSRF protection
@@ -57,8 +95,18 @@ func main() {
 	log.Println("Initializing API Key service...")
 	middleware.InitApiKeyService()
 
+	// Start the email delivery queue (retries and records undeliverable emails)
+	log.Println("Starting email queue...")
+	emailQueue := email.InitQueue(database.GetDB())
+
+	// Start background audit log retention
+	log.Println("Starting audit log retention...")
+	auditRetentionService := services.NewAuditRetentionService(database.GetDB(), cfg.Audit.RetentionDays, cfg.Audit.RetentionMode)
+	auditRetentionService.Start(services.DefaultAuditRetentionInterval)
+
 	// Setup router
-	router := setupRouter()
+	router, backgroundServices := setupRouter(cfg)
+	backgroundServices = append(backgroundServices, auditRetentionService, emailQueue)
 
 	// Start server
 	port := cfg.Server.Port
@@ -66,18 +114,85 @@ func main() {
 		port = "8080"
 	}
 
-	log.Printf("Server starting on port %s (environment: %s)", port, cfg.Server.Env)
-	if err := router.Run(":" + port); err != nil {
-		log.Fatal("Failed to start server:", err)
+	server := &http.Server{
+		Addr:    ":" + port,
+		Handler: router,
+	}
+	// End open permission event streams so Shutdown does not wait on them
+	server.RegisterOnShutdown(middleware.GetPermissionCache().Events().Close)
+
+	serverErr := make(chan error, 1)
+	go func() {
+		log.Printf("Server starting on port %s (environment: %s)", port, cfg.Server.Env)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+
+	// Wait for a shutdown signal or a listener failure
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	select {
+	case sig := <-quit:
+		log.Printf("Received %s, shutting down...", sig)
+	case err := <-serverErr:
+		log.Printf("Server error: %v, shutting down...", err)
+	}
+	signal.Stop(quit)
+
+	// Stop accepting new requests and let in-flight requests finish
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.Server.ShutdownTimeout)*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("Warning: server shutdown did not complete cleanly: %v", err)
+	}
+
+	// Stop background jobs, waiting for a run in progress to finish
+	log.Println("Stopping background services...")
+	for _, service := range backgroundServices {
+		service.Stop()
+	}
+
+	// Close the database connection last, once nothing uses it anymore
+	log.Println("Closing database connection...")
+	if err := database.Close(); err != nil {
+		log.Printf("Warning: failed to close database connection: %v", err)
 	}
+
+	log.Println("Server stopped")
 }
 
-func setupRouter() *gin.Engine {
-	router := gin.Default()
+// backgroundService is a periodic job that must be stopped on shutdown
+type backgroundService interface {
+	Stop()
+}
+
+func setupRouter(cfg *configs.Config) (*gin.Engine, []backgroundService) {
+	router := gin.New()
+
+	// Report binding errors by json field name so clients can map them to form fields
+	helpers.RegisterValidationFieldNames()
+
+	// Share auth cookies with a frontend on a sibling subdomain when COOKIE_DOMAIN is set
+	helpers.ConfigureCookies(helpers.CookieOptions{
+		Domain:   cfg.Cookie.Domain,
+		SameSite: cfg.Cookie.SameSiteMode(),
+		Secure:   cfg.Cookie.Secure,
+	})
+
+	// Tag every request with an ID, log it as JSON, and recover from panics
+	router.Use(middleware.RequestID())
+	router.Use(middleware.RequestLogger(cfg.Log.RequestBodies))
+	router.Use(gin.Recovery())
 
 	// Apply security headers middleware to all routes
 	router.Use(middleware.SecurityHeaders())
 
+	// Record request count and latency per route when metrics are enabled
+	if cfg.Metrics.Enabled {
+		router.Use(middleware.Metrics())
+	}
+
 	// Apply locale detection middleware for i18n support
 	// This reads NEXT_LOCALE cookie or Accept-Language header
 	router.Use(middleware.LocaleMiddleware())
@@ -89,36 +204,101 @@ func setupRouter() *gin.Engine {
 	departmentService := services.NewDepartmentService(db)
 	karyawanService := services.NewKaryawanService(db)
 	workflowRuleService := services.NewWorkflowRuleService(db)
+	workflowInstanceService := services.NewWorkflowInstanceService(db, workflowRuleService)
+	delegationService := services.NewDelegationService(db)
 	roleService := services.NewRoleService(db)
 	permissionService := services.NewPermissionService(db)
 	moduleService := services.NewModuleService(db)
 	userService := services.NewUserService(db)
 	apiKeyService := services.NewApiKeyService(db)
+	auditService := services.NewAuditService(db)
+	emailFailureService := services.NewEmailFailureService(db)
+	loginAttemptService := services.NewLoginAttemptService(db)
+	settingsService := services.NewSettingsService(db, services.DefaultSettingsCacheTTL)
+
+	// Make sure every runtime setting exists so operators can see and flip it
+	if err := settingsService.SeedDefaults(); err != nil {
+		log.Printf("Warning: default settings not seeded: %v", err)
+	}
+
+	// Start background SLA escalation for pending workflow steps
+	workflowEscalationService := services.NewWorkflowEscalationService(db, workflowInstanceService)
+	workflowEscalationService.Start(services.DefaultEscalationInterval)
+
+	// Start background sync of user accounts with employee status
+	karyawanSyncService := services.NewKaryawanSyncService(db, cfg.Employee.Reactivate)
+	karyawanSyncService.Start(services.DefaultKaryawanSyncInterval)
 
 	// Inject RBAC services into services for escalation prevention and cache invalidation
 	escalationPrevention := middleware.GetEscalationPrevention()
 	permissionCache := middleware.GetPermissionCache()
 	userService.SetRBACServices(escalationPrevention, permissionCache)
+	// Configured position scope rules replace the defaults
+	if len(cfg.Permission.PositionScopeRules) > 0 {
+		rules, err := services.ParsePositionScopeRules(cfg.Permission.PositionScopeRules)
+		if err != nil {
+			log.Fatal("Invalid POSITION_SCOPE_RULES: ", err)
+		}
+		userService.SetPositionScopeRules(rules)
+	}
 	roleService.SetRBACServices(escalationPrevention, permissionCache)
 	moduleService.SetRBACServices(permissionCache, escalationPrevention)
+	delegationService.SetRBACServices(permissionCache)
+	rbacConsistencyService := services.NewRBACConsistencyService(db, permissionCache)
+
+	// Warm the permission cache for users active in the last 24h, in the background
+	if cfg.Permission.WarmupOnStartup {
+		if userIDs, err := permissionCache.RecentlyActiveUserIDs(24 * time.Hour); err != nil {
+			log.Printf("Warning: permission cache warmup skipped: %v", err)
+		} else {
+			log.Printf("Warming permission cache for %d recently active users...", len(userIDs))
+			permissionCache.Warmup(userIDs)
+		}
+	}
+
+	// Underlying connection pool for metrics and health checks
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Fatal("Failed to get database connection pool:", err)
+	}
+
+	// Prometheus metrics endpoint (outside /api, optionally behind basic auth)
+	if cfg.Metrics.Enabled {
+		metricsHandler := handlers.NewMetricsHandler(sqlDB, permissionCache)
+		if cfg.Metrics.Username != "" {
+			router.GET("/metrics", gin.BasicAuth(gin.Accounts{cfg.Metrics.Username: cfg.Metrics.Password}), metricsHandler.GetMetrics)
+		} else {
+			router.GET("/metrics", metricsHandler.GetMetrics)
+		}
+	}
 	permissionService.SetRBACServices(permissionCache)
 
 	// Initialize handlers
 	schoolHandler := handlers.NewSchoolHandler(schoolService)
 	positionHandler := handlers.NewPositionHandler(positionService)
 	departmentHandler := handlers.NewDepartmentHandler(departmentService)
-	karyawanHandler := handlers.NewKaryawanHandler(karyawanService)
+	karyawanHandler := handlers.NewKaryawanHandler(karyawanService, karyawanSyncService)
 	workflowRuleHandler := handlers.NewWorkflowRuleHandler(workflowRuleService)
+	workflowInstanceHandler := handlers.NewWorkflowInstanceHandler(workflowInstanceService)
+	delegationHandler := handlers.NewDelegationHandler(delegationService)
 	roleHandler := handlers.NewRoleHandler(roleService)
 	permissionHandler := handlers.NewPermissionHandler(permissionService)
 	moduleHandler := handlers.NewModuleHandler(moduleService)
 	userHandler := handlers.NewUserHandler(userService)
 	accessHandler := handlers.NewAccessHandler()
 	apiKeyHandler := handlers.NewApiKeyHandler(apiKeyService)
+	auditHandler := handlers.NewAuditHandler(auditService)
+	emailFailureHandler := handlers.NewEmailFailureHandler(emailFailureService)
+	loginAttemptHandler := handlers.NewLoginAttemptHandler(loginAttemptService)
+	accessCheckLogHandler := handlers.NewAccessCheckLogHandler(permissionCache.AccessChecks())
+	emailAdminHandler := handlers.NewEmailAdminHandler()
+	adminConfigHandler := handlers.NewAdminConfigHandler()
+	settingsHandler := handlers.NewSettingsHandler(settingsService)
+	rbacConsistencyHandler := handlers.NewRBACConsistencyHandler(rbacConsistencyService)
 
 	// Configure CORS
 	// In development: Allow localhost origins for testing
-	// In production: Should be configured with specific frontend origin via environment variable
+	// In production: Use origins from CORS_ALLOWED_ORIGINS (or FRONTEND_URL)
 	corsConfig := cors.Config{
 		AllowOrigins: []string{
 			"http://localhost:3000",  // Next.js default
@@ -137,11 +317,15 @@ func setupRouter() *gin.Engine {
 			"Authorization",
 			"Content-Type",
 			"Accept",
-			"X-CSRF-Token", // CSRF protection header
-			"X-API-Key",    // API Key authentication header for external access (n8n, etc.)
+			"X-CSRF-Token",    // CSRF protection header
+			"X-API-Key",       // API Key authentication header for external access (n8n, etc.)
+			"X-Request-ID",    // Client-supplied request ID for tracing
+			"Idempotency-Key", // Makes create/bulk mutations safe to retry
 		},
 		ExposeHeaders: []string{
 			"Content-Length",
+			"X-Request-ID",
+			"Idempotent-Replayed",
 		},
 		AllowCredentials: true, // Enable credentials for cookie-based auth and CSRF protection
 		MaxAge:           12 * time.Hour,
@@ -149,20 +333,63 @@ func setupRouter() *gin.Engine {
 
 	// In production, override with environment-specific origins
 	if gin.Mode() == gin.ReleaseMode {
-		// TODO: Configure via environment variable
-		// Example: corsConfig.AllowOrigins = []string{os.Getenv("FRONTEND_URL")}
-		log.Println("WARNING: Using default CORS origins in production. Configure FRONTEND_URL environment variable.")
+		if len(cfg.CORS.AllowedOrigins) == 0 {
+			log.Fatal("CORS_ALLOWED_ORIGINS (or FRONTEND_URL) must be set in release mode, e.g. https://app.example.com,https://*.preview.example.com")
+		}
+
+		// Exact origins are matched by the CORS middleware, wildcard-subdomain patterns
+		// (staging previews) by a matcher function
+		exactOrigins, originPatterns := middleware.SplitOriginPatterns(cfg.CORS.AllowedOrigins)
+		corsConfig.AllowOrigins = exactOrigins
+		if len(originPatterns) > 0 {
+			corsConfig.AllowOriginFunc = middleware.WildcardOriginMatcher(originPatterns)
+		}
+		log.Printf("CORS allowed origins: %v", cfg.CORS.AllowedOrigins)
 	}
 
 	router.Use(cors.New(corsConfig))
 
-	// Health check
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{"status": "ok", "message": "Server is running"})
-	})
+	// Health checks: /health is the liveness probe, /health/ready verifies dependencies
+	healthHandler := handlers.NewHealthHandler(sqlDB)
+	router.GET("/health", healthHandler.Liveness)
+	router.GET("/health/ready", healthHandler.Readiness)
 
 	// API v1 routes
 	v1 := router.Group("/api/v1")
+	// Cap request bodies and put a deadline on every API request. Uploads get a larger
+	// body cap, the batch permission check a tighter one, and streaming endpoints run
+	// without a deadline.
+	v1.Use(middleware.BodySizeLimit(int64(cfg.Request.MaxBodyBytes), middleware.RouteLimits[int64]{
+		"/api/v1/users/import":       int64(cfg.Request.MaxUploadBytes),
+		"/api/v1/access/check-batch": 64 << 10,
+	}))
+	v1.Use(middleware.RequestTimeout(time.Duration(cfg.Request.TimeoutSeconds)*time.Second, middleware.RouteLimits[time.Duration]{
+		"/api/v1/users/import":     0,
+		"/api/v1/users/export":     0,
+		"/api/v1/employees/export": 0,
+		"/api/v1/audit/export":     0,
+		"/api/v1/access/events":    0,
+	}))
+	// Create and bulk endpoints replay their first response to retries carrying the
+	// same Idempotency-Key instead of executing twice
+	idempotent := middleware.Idempotent(middleware.NewIdempotencyStore(time.Duration(cfg.Request.IdempotencyTTLMinutes) * time.Minute))
+	// Permission checks are cheap for the client but can fan out into several queries each;
+	// all check routes share one per-user allowance
+	checkRateLimit := middleware.RateLimit(middleware.NewRateLimiter(cfg.Permission.CheckRatePerMinute, cfg.Permission.CheckRateBurst))
+	// Maintenance mode is switched with the maintenance.* settings; holders of the bypass
+	// permission keep working so they can finish the job and switch it off again
+	bypassResource, bypassAction := cfg.Permission.MaintenanceBypassPermission()
+	maintenance := middleware.MaintenanceMode(settingsService, middleware.PermissionCheck{
+		Resource: bypassResource,
+		Action:   models.PermissionAction(bypassAction),
+	})
+
+	// Object routes answer callers who may not read the object with the same 404 as a missing
+	// id, so ids cannot be enumerated (see middleware.HideUnreadableObject)
+	userObject := middleware.HideUnreadableObject("users", "id", middleware.UserVisibleInScope, middleware.UserNotFound)
+	roleObject := middleware.HideUnreadableObject("roles", "id", nil, middleware.RoleNotFound)
+	permissionObject := middleware.HideUnreadableObject("permissions", "id", nil, middleware.PermissionNotFound)
+	workflowInstanceObject := middleware.HideUnreadableObject("workflow_instances", "id", middleware.WorkflowInstanceVisibleInScope, middleware.WorkflowInstanceNotFound)
 	{
 		// Public routes
 		authPublic := v1.Group("/auth")
@@ -179,41 +406,63 @@ func setupRouter() *gin.Engine {
 		protected := v1.Group("/")
 		protected.Use(middleware.AuthRequiredHybrid()) // Hybrid SSR support - checks auth first
 		protected.Use(middleware.CSRFProtection())     // CSRF protection for state-changing requests
+		protected.Use(maintenance)                     // 503 for everyone but admins while maintenance mode is on
 		{
 			// Auth routes (protected)
 			authProtected := protected.Group("/auth")
 			{
 				authProtected.GET("/me", handlers.GetMe)
+				authProtected.PATCH("/me", userHandler.UpdateMe)  // Self-service: own username and preferences only
+				authProtected.GET("/csrf", handlers.GetCSRFToken) // Re-issues the CSRF cookie for SPA bootstrapping
 				authProtected.POST("/change-password", handlers.ChangePassword)
+				authProtected.POST("/stop-impersonation", userHandler.StopImpersonation)
 			}
 			// User routes
 			users := protected.Group("/users")
 			{
 				users.GET("", middleware.RequirePermission("users", models.PermissionActionRead), userHandler.GetUsers)
-				users.GET("/:id", middleware.RequirePermission("users", models.PermissionActionRead), userHandler.GetUser)
-				users.PUT("/:id", middleware.RequirePermission("users", models.PermissionActionUpdate), userHandler.UpdateUser)
-				users.DELETE("/:id", middleware.RequirePermission("users", models.PermissionActionDelete), userHandler.DeleteUser)
+				users.GET("/export", middleware.RequirePermission("users", models.PermissionActionExport), userHandler.ExportUsers)
+				users.GET("/password-resets", middleware.RequirePermissionWithScope("users", models.PermissionActionRead, models.PermissionScopeAll), userHandler.GetOutstandingPasswordResets)
+				users.POST("/import", middleware.RequirePermission("users", models.PermissionActionCreate), idempotent, userHandler.ImportUsers)
+				// Offboarding is limited to users the caller may read, like the single-user route
+				users.POST("/deactivate-bulk", middleware.RequirePermission("users", models.PermissionActionUpdate), middleware.ResolveDataScope("users", models.PermissionActionRead), idempotent, userHandler.BulkDeactivateUsers)
+				users.GET("/preferences/schema", userHandler.GetUserPreferencesSchema)
+				users.GET("/:id", userObject, middleware.RequirePermission("users", models.PermissionActionRead), userHandler.GetUser)
+				users.PUT("/:id", userObject, middleware.RequirePermission("users", models.PermissionActionUpdate), userHandler.UpdateUser)
+				users.DELETE("/:id", userObject, middleware.RequirePermission("users", models.PermissionActionDelete), userHandler.DeleteUser)
+				users.POST("/:id/deactivate", userObject, middleware.RequirePermission("users", models.PermissionActionUpdate), userHandler.DeactivateUser)
+				users.POST("/:id/transfer", userObject, middleware.RequirePermission("users", models.PermissionActionUpdate), idempotent, userHandler.TransferUserAssignments)
+				users.GET("/:id/activity", userObject, middleware.RequirePermission("users", models.PermissionActionRead), userHandler.GetUserActivity)
+				users.POST("/:id/impersonate", userObject, middleware.RequirePermission("users", models.PermissionActionImpersonate), userHandler.ImpersonateUser)
 
 				// User role assignment routes
-				users.GET("/:id/roles", middleware.RequirePermission("users", models.PermissionActionRead), userHandler.GetUserRoles)
-				users.POST("/:id/roles", middleware.RequirePermission("users", models.PermissionActionUpdate), userHandler.AssignRoleToUser)
-				users.DELETE("/:id/roles/:role_id", middleware.RequirePermission("users", models.PermissionActionUpdate), userHandler.RevokeRoleFromUser)
+				users.GET("/:id/roles", userObject, middleware.RequirePermission("users", models.PermissionActionRead), userHandler.GetUserRoles)
+				users.GET("/:id/roles/scheduled", userObject, middleware.RequirePermission("users", models.PermissionActionRead), userHandler.GetScheduledUserRoles)
+				users.POST("/:id/roles", userObject, middleware.RequirePermission("users", models.PermissionActionUpdate), idempotent, userHandler.AssignRoleToUser)
+				users.POST("/:id/roles/bulk", userObject, middleware.RequirePermission("users", models.PermissionActionUpdate), idempotent, userHandler.BulkAssignRolesToUser)
+				users.DELETE("/:id/roles/:role_id", userObject, middleware.RequirePermission("users", models.PermissionActionUpdate), userHandler.RevokeRoleFromUser)
 
 				// User position assignment routes
-				users.GET("/:id/positions", middleware.RequirePermission("users", models.PermissionActionRead), userHandler.GetUserPositions)
-				users.POST("/:id/positions", middleware.RequirePermission("users", models.PermissionActionUpdate), userHandler.AssignPositionToUser)
-				users.DELETE("/:id/positions/:position_id", middleware.RequirePermission("users", models.PermissionActionUpdate), userHandler.RevokePositionFromUser)
+				users.GET("/:id/positions", userObject, middleware.RequirePermission("users", models.PermissionActionRead), userHandler.GetUserPositions)
+				users.GET("/:id/positions/history", userObject, middleware.RequirePermission("users", models.PermissionActionRead), userHandler.GetUserPositionHistory)
+				users.POST("/:id/positions", userObject, middleware.RequirePermission("users", models.PermissionActionUpdate), idempotent, userHandler.AssignPositionToUser)
+				users.DELETE("/:id/positions/:position_id", userObject, middleware.RequirePermission("users", models.PermissionActionUpdate), userHandler.RevokePositionFromUser)
 
 				// User direct permission assignment routes
-				users.GET("/:id/permissions", middleware.RequirePermission("users", models.PermissionActionRead), userHandler.GetUserPermissions)
-				users.POST("/:id/permissions", middleware.RequirePermission("users", models.PermissionActionUpdate), userHandler.AssignPermissionToUser)
-				users.DELETE("/:id/permissions/:permission_id", middleware.RequirePermission("users", models.PermissionActionUpdate), userHandler.RevokePermissionFromUser)
+				users.GET("/:id/permissions", userObject, middleware.RequirePermission("users", models.PermissionActionRead), userHandler.GetUserPermissions)
+				users.GET("/:id/permissions/conflicts", userObject, middleware.RequirePermission("users", models.PermissionActionRead), userHandler.GetUserPermissionConflicts)
+				users.POST("/:id/access/check", userObject, middleware.RequireAllPermissions(
+					middleware.PermissionCheck{Resource: "users", Action: models.PermissionActionRead},
+					middleware.PermissionCheck{Resource: "system", Action: models.PermissionActionRead},
+				), accessHandler.CheckUserPermission)
+				users.POST("/:id/permissions", userObject, middleware.RequirePermission("users", models.PermissionActionUpdate), idempotent, userHandler.AssignPermissionToUser)
+				users.DELETE("/:id/permissions/:permission_id", userObject, middleware.RequirePermission("users", models.PermissionActionUpdate), userHandler.RevokePermissionFromUser)
 			}
 
 			// School routes
 			schools := protected.Group("/schools")
 			{
-				schools.POST("", middleware.RequirePermission("schools", models.PermissionActionCreate), schoolHandler.CreateSchool)
+				schools.POST("", middleware.RequirePermission("schools", models.PermissionActionCreate), idempotent, schoolHandler.CreateSchool)
 				schools.GET("", middleware.RequirePermission("schools", models.PermissionActionRead), schoolHandler.GetSchools)
 				schools.GET("/available-codes", middleware.RequirePermission("schools", models.PermissionActionRead), schoolHandler.GetAvailableSchoolCodes)
 				schools.GET("/:id", middleware.RequirePermission("schools", models.PermissionActionRead), schoolHandler.GetSchoolByID)
@@ -224,21 +473,25 @@ func setupRouter() *gin.Engine {
 			// Department routes
 			departments := protected.Group("/departments")
 			{
-				departments.POST("", middleware.RequirePermission("departments", models.PermissionActionCreate), departmentHandler.CreateDepartment)
+				departments.POST("", middleware.RequirePermission("departments", models.PermissionActionCreate), idempotent, departmentHandler.CreateDepartment)
 				departments.GET("", middleware.RequirePermission("departments", models.PermissionActionRead), departmentHandler.GetDepartments)
 				departments.GET("/tree", middleware.RequirePermission("departments", models.PermissionActionRead), departmentHandler.GetDepartmentTree)
 				departments.GET("/available-codes", middleware.RequirePermission("departments", models.PermissionActionRead), departmentHandler.GetAvailableDepartmentCodes)
 				departments.GET("/:id", middleware.RequirePermission("departments", models.PermissionActionRead), departmentHandler.GetDepartmentByID)
 				departments.PUT("/:id", middleware.RequirePermission("departments", models.PermissionActionUpdate), departmentHandler.UpdateDepartment)
+				departments.PATCH("/:id/move", middleware.RequirePermission("departments", models.PermissionActionUpdate), departmentHandler.MoveDepartment)
 				departments.DELETE("/:id", middleware.RequirePermission("departments", models.PermissionActionDelete), departmentHandler.DeleteDepartment)
 			}
 
 			// Position routes
 			positions := protected.Group("/positions")
 			{
-				positions.POST("", middleware.RequirePermission("positions", models.PermissionActionCreate), positionHandler.CreatePosition)
+				positions.POST("", middleware.RequirePermission("positions", models.PermissionActionCreate), idempotent, positionHandler.CreatePosition)
 				positions.GET("", middleware.RequirePermission("positions", models.PermissionActionRead), positionHandler.GetPositions)
+				positions.GET("/org-chart", middleware.RequirePermission("positions", models.PermissionActionRead), positionHandler.GetOrgChart)
+				positions.GET("/available-codes", middleware.RequirePermission("positions", models.PermissionActionRead), positionHandler.GetAvailablePositionCodes)
 				positions.GET("/:id", middleware.RequirePermission("positions", models.PermissionActionRead), positionHandler.GetPositionByID)
+				positions.GET("/:id/users", middleware.RequirePermission("positions", models.PermissionActionRead), positionHandler.GetPositionHolders)
 				positions.PUT("/:id", middleware.RequirePermission("positions", models.PermissionActionUpdate), positionHandler.UpdatePosition)
 				positions.DELETE("/:id", middleware.RequirePermission("positions", models.PermissionActionDelete), positionHandler.DeletePosition)
 			}
@@ -247,57 +500,110 @@ func setupRouter() *gin.Engine {
 			employees := protected.Group("/employees")
 			{
 				employees.GET("/filter-options", middleware.RequirePermission("employees", models.PermissionActionRead), karyawanHandler.GetFilterOptions)
-				employees.GET("", middleware.RequirePermission("employees", models.PermissionActionRead), karyawanHandler.GetKaryawans)
+				employees.GET("", middleware.RequirePermission("employees", models.PermissionActionRead), middleware.ResolveDataScope("employees", models.PermissionActionRead), karyawanHandler.GetKaryawans)
+				employees.GET("/export", middleware.RequirePermission("employees", models.PermissionActionExport), middleware.ResolveDataScope("employees", models.PermissionActionExport), karyawanHandler.ExportKaryawans)
 				employees.GET("/:nip", middleware.RequirePermission("employees", models.PermissionActionRead), karyawanHandler.GetKaryawanByNIP)
+				employees.POST("/sync", middleware.RequirePermission("users", models.PermissionActionUpdate), karyawanHandler.SyncStatuses)
 			}
 
 			// Workflow Rules routes
 			workflowRules := protected.Group("/workflow-rules")
 			{
-				workflowRules.POST("", middleware.RequirePermission("workflow_rules", models.PermissionActionCreate), workflowRuleHandler.CreateWorkflowRule)
-				workflowRules.POST("/bulk", middleware.RequirePermission("workflow_rules", models.PermissionActionCreate), workflowRuleHandler.BulkCreateWorkflowRules)
+				workflowRules.POST("", middleware.RequirePermission("workflow_rules", models.PermissionActionCreate), idempotent, workflowRuleHandler.CreateWorkflowRule)
+				workflowRules.POST("/bulk", middleware.RequirePermission("workflow_rules", models.PermissionActionCreate), idempotent, workflowRuleHandler.BulkCreateWorkflowRules)
+				workflowRules.POST("/copy", middleware.RequirePermission("workflow_rules", models.PermissionActionCreate), idempotent, workflowRuleHandler.CopyWorkflowRules)
 				workflowRules.GET("", middleware.RequirePermission("workflow_rules", models.PermissionActionRead), workflowRuleHandler.GetWorkflowRules)
 				workflowRules.GET("/types", middleware.RequirePermission("workflow_rules", models.PermissionActionRead), workflowRuleHandler.GetWorkflowTypes)
 				workflowRules.GET("/lookup", middleware.RequirePermission("workflow_rules", models.PermissionActionRead), workflowRuleHandler.GetWorkflowRuleByPositionAndType)
+				workflowRules.GET("/approval-chain", workflowRuleHandler.GetApprovalChain) // Self-service like starting an instance: requesters preview their approvers
 				workflowRules.GET("/:id", middleware.RequirePermission("workflow_rules", models.PermissionActionRead), workflowRuleHandler.GetWorkflowRuleByID)
+				workflowRules.GET("/:id/versions", middleware.RequirePermission("workflow_rules", models.PermissionActionRead), workflowRuleHandler.GetWorkflowRuleVersions)
 				workflowRules.PUT("/:id", middleware.RequirePermission("workflow_rules", models.PermissionActionUpdate), workflowRuleHandler.UpdateWorkflowRule)
 				workflowRules.DELETE("/:id", middleware.RequirePermission("workflow_rules", models.PermissionActionDelete), workflowRuleHandler.DeleteWorkflowRule)
 			}
 
+			// Workflow Instances routes
+			// Starting an instance is self-service for any authenticated user (e.g. submitting a leave request)
+			workflowInstances := protected.Group("/workflow-instances")
+			{
+				workflowInstances.POST("", idempotent, workflowInstanceHandler.StartWorkflowInstance)
+				workflowInstances.GET("/:id", workflowInstanceObject, middleware.RequirePermission("workflow_instances", models.PermissionActionRead), workflowInstanceHandler.GetWorkflowInstanceByID)
+				workflowInstances.POST("/:id/approve", middleware.RequirePermission("workflow_instances", models.PermissionActionApprove), workflowInstanceHandler.ApproveWorkflowInstance)
+				workflowInstances.POST("/:id/reject", middleware.RequirePermission("workflow_instances", models.PermissionActionApprove), workflowInstanceHandler.RejectWorkflowInstance)
+			}
+
+			// Delegation routes
+			delegations := protected.Group("/delegations")
+			{
+				delegations.POST("", middleware.RequirePermission("delegations", models.PermissionActionCreate), idempotent, delegationHandler.CreateDelegation)
+				delegations.GET("", middleware.RequirePermission("delegations", models.PermissionActionRead), delegationHandler.GetDelegations)
+				delegations.GET("/:id", middleware.RequirePermission("delegations", models.PermissionActionRead), delegationHandler.GetDelegationByID)
+				delegations.PUT("/:id", middleware.RequirePermission("delegations", models.PermissionActionUpdate), delegationHandler.UpdateDelegation)
+				delegations.POST("/:id/revoke", middleware.RequirePermission("delegations", models.PermissionActionUpdate), middleware.ResolveDataScope("delegations", models.PermissionActionUpdate), delegationHandler.RevokeDelegation)
+				delegations.DELETE("/:id", middleware.RequirePermission("delegations", models.PermissionActionDelete), middleware.ResolveDataScope("delegations", models.PermissionActionDelete), delegationHandler.DeleteDelegation)
+			}
+
+			// Audit log routes (visibility follows the audit:read scope)
+			protected.GET("/audit", middleware.RequirePermission("audit", models.PermissionActionRead), auditHandler.GetAuditLogs)
+			protected.GET("/audit/export", middleware.RequirePermission("audit", models.PermissionActionExport), auditHandler.ExportAuditLogs)
+
+			// Permission checks on sensitive resources (requires audit:read with ALL scope)
+			protected.GET("/audit/access-checks", middleware.RequirePermissionWithScope("audit", models.PermissionActionRead, models.PermissionScopeAll), accessCheckLogHandler.GetAccessCheckLogs)
+
+			// Email delivery failures for operations (requires audit:read with ALL scope)
+			protected.GET("/admin/email-failures", middleware.RequirePermissionWithScope("audit", models.PermissionActionRead, models.PermissionScopeAll), emailFailureHandler.GetEmailFailures)
+			protected.GET("/admin/login-attempts", middleware.RequirePermissionWithScope("audit", models.PermissionActionRead, models.PermissionScopeAll), loginAttemptHandler.GetLoginAttempts)
+			protected.GET("/admin/login-attempts/summary", middleware.RequirePermissionWithScope("audit", models.PermissionActionRead, models.PermissionScopeAll), loginAttemptHandler.GetLoginAttemptSummary)
+			protected.POST("/admin/email/test", middleware.RequirePermission("system", models.PermissionActionUpdate), emailAdminHandler.SendTestEmail)
+			protected.GET("/admin/config/security", middleware.RequirePermission("system", models.PermissionActionUpdate), adminConfigHandler.GetSecurityConfig)
+			protected.GET("/admin/settings", middleware.RequirePermission("system", models.PermissionActionUpdate), settingsHandler.GetSettings)
+			protected.PUT("/admin/settings", middleware.RequirePermission("system", models.PermissionActionUpdate), settingsHandler.UpdateSettings)
+			protected.GET("/admin/rbac/consistency", middleware.RequirePermission("system", models.PermissionActionUpdate), rbacConsistencyHandler.GetConsistency)
+			protected.POST("/admin/rbac/consistency/repair", middleware.RequirePermission("system", models.PermissionActionUpdate), rbacConsistencyHandler.RepairConsistency)
+
 			// Role routes
 			roles := protected.Group("/roles")
 			{
-				roles.POST("", middleware.RequirePermission("roles", models.PermissionActionCreate), roleHandler.CreateRole)
+				roles.POST("", middleware.RequirePermission("roles", models.PermissionActionCreate), idempotent, roleHandler.CreateRole)
 				roles.GET("", middleware.RequirePermission("roles", models.PermissionActionRead), roleHandler.GetRoles)
-				roles.GET("/:id", middleware.RequirePermission("roles", models.PermissionActionRead), roleHandler.GetRoleByID)
-				roles.GET("/:id/permissions", middleware.RequirePermission("roles", models.PermissionActionRead), roleHandler.GetRoleWithPermissions)
-				roles.PUT("/:id", middleware.RequirePermission("roles", models.PermissionActionUpdate), roleHandler.UpdateRole)
-				roles.DELETE("/:id", middleware.RequirePermission("roles", models.PermissionActionDelete), roleHandler.DeleteRole)
-				roles.POST("/:id/permissions", middleware.RequirePermission("roles", models.PermissionActionUpdate), roleHandler.AssignPermissionToRole)
-				roles.DELETE("/:id/permissions/:permission_id", middleware.RequirePermission("roles", models.PermissionActionUpdate), roleHandler.RevokePermissionFromRole)
+				roles.GET("/available-codes", middleware.RequirePermission("roles", models.PermissionActionRead), roleHandler.GetAvailableRoleCodes)
+				roles.POST("/import", middleware.RequirePermission("roles", models.PermissionActionCreate), idempotent, roleHandler.ImportRole)
+				roles.GET("/:id", roleObject, middleware.RequirePermission("roles", models.PermissionActionRead), roleHandler.GetRoleByID)
+				roles.GET("/:id/permissions", roleObject, middleware.RequirePermission("roles", models.PermissionActionRead), roleHandler.GetRoleWithPermissions)
+				roles.GET("/:id/export", roleObject, middleware.RequirePermission("roles", models.PermissionActionRead), roleHandler.ExportRole)
+				roles.PUT("/:id", roleObject, middleware.RequirePermission("roles", models.PermissionActionUpdate), roleHandler.UpdateRole)
+				roles.DELETE("/:id", roleObject, middleware.RequirePermission("roles", models.PermissionActionDelete), roleHandler.DeleteRole)
+				roles.POST("/:id/restore", roleObject, middleware.RequirePermission("roles", models.PermissionActionUpdate), roleHandler.RestoreRole)
+				roles.POST("/:id/permissions", roleObject, middleware.RequirePermission("roles", models.PermissionActionUpdate), idempotent, roleHandler.AssignPermissionToRole)
+				roles.DELETE("/:id/permissions", roleObject, middleware.RequirePermission("roles", models.PermissionActionUpdate), roleHandler.RevokeAllPermissionsFromRole)
+				roles.DELETE("/:id/permissions/:permission_id", roleObject, middleware.RequirePermission("roles", models.PermissionActionUpdate), roleHandler.RevokePermissionFromRole)
 				// Role Module Access routes
-				roles.GET("/:id/modules", middleware.RequirePermission("roles", models.PermissionActionRead), moduleHandler.GetRoleModuleAccesses)
-				roles.POST("/:id/modules", middleware.RequirePermission("roles", models.PermissionActionUpdate), moduleHandler.AssignModuleToRole)
-				roles.DELETE("/:id/modules/:access_id", middleware.RequirePermission("roles", models.PermissionActionUpdate), moduleHandler.RevokeModuleFromRole)
+				roles.GET("/:id/modules", roleObject, middleware.RequirePermission("roles", models.PermissionActionRead), moduleHandler.GetRoleModuleAccesses)
+				roles.GET("/:id/modules/preview", roleObject, middleware.RequirePermission("roles", models.PermissionActionRead), accessHandler.PreviewRoleModules)
+				roles.POST("/:id/modules", roleObject, middleware.RequirePermission("roles", models.PermissionActionUpdate), idempotent, moduleHandler.AssignModuleToRole)
+				roles.DELETE("/:id/modules/:access_id", roleObject, middleware.RequirePermission("roles", models.PermissionActionUpdate), moduleHandler.RevokeModuleFromRole)
 			}
 
 			// Permission routes
 			permissions := protected.Group("/permissions")
 			{
-				permissions.POST("", middleware.RequirePermission("permissions", models.PermissionActionCreate), permissionHandler.CreatePermission)
+				permissions.POST("", middleware.RequirePermission("permissions", models.PermissionActionCreate), idempotent, permissionHandler.CreatePermission)
 				permissions.GET("", middleware.RequirePermission("permissions", models.PermissionActionRead), permissionHandler.GetPermissions)
 				permissions.GET("/groups", middleware.RequirePermission("permissions", models.PermissionActionRead), permissionHandler.GetPermissionGroups)
+				permissions.PUT("/groups/:name", middleware.RequirePermission("permissions", models.PermissionActionUpdate), permissionHandler.UpdatePermissionGroup)
 				permissions.GET("/scopes", middleware.RequirePermission("permissions", models.PermissionActionRead), permissionHandler.GetPermissionScopes)
 				permissions.GET("/actions", middleware.RequirePermission("permissions", models.PermissionActionRead), permissionHandler.GetPermissionActions)
-				permissions.GET("/:id", middleware.RequirePermission("permissions", models.PermissionActionRead), permissionHandler.GetPermissionByID)
-				permissions.PUT("/:id", middleware.RequirePermission("permissions", models.PermissionActionUpdate), permissionHandler.UpdatePermission)
-				permissions.DELETE("/:id", middleware.RequirePermission("permissions", models.PermissionActionDelete), permissionHandler.DeletePermission)
+				permissions.GET("/:id", permissionObject, middleware.RequirePermission("permissions", models.PermissionActionRead), permissionHandler.GetPermissionByID)
+				permissions.GET("/:id/usage", permissionObject, middleware.RequirePermission("permissions", models.PermissionActionRead), permissionHandler.GetPermissionUsage)
+				permissions.PUT("/:id", permissionObject, middleware.RequirePermission("permissions", models.PermissionActionUpdate), permissionHandler.UpdatePermission)
+				permissions.PUT("/:id/group", permissionObject, middleware.RequirePermission("permissions", models.PermissionActionUpdate), permissionHandler.MovePermissionToGroup)
+				permissions.DELETE("/:id", permissionObject, middleware.RequirePermission("permissions", models.PermissionActionDelete), permissionHandler.DeletePermission)
 			}
 
 			// Module routes
 			modules := protected.Group("/modules")
 			{
-				modules.POST("", middleware.RequirePermission("modules", models.PermissionActionCreate), moduleHandler.CreateModule)
+				modules.POST("", middleware.RequirePermission("modules", models.PermissionActionCreate), idempotent, moduleHandler.CreateModule)
 				modules.GET("", middleware.RequirePermission("modules", models.PermissionActionRead), moduleHandler.GetModules)
 				modules.GET("/tree", middleware.RequirePermission("modules", models.PermissionActionRead), moduleHandler.GetModuleTree)
 				modules.GET("/:id", middleware.RequirePermission("modules", models.PermissionActionRead), moduleHandler.GetModuleByID)
@@ -308,10 +614,16 @@ func setupRouter() *gin.Engine {
 			// Access/Permission checking routes
 			access := protected.Group("/access")
 			{
-				access.POST("/check", accessHandler.CheckPermission)
-				access.POST("/check-batch", accessHandler.CheckPermissionBatch)
+				access.POST("/check", checkRateLimit, accessHandler.CheckPermission)
+				access.POST("/check-batch", checkRateLimit, accessHandler.CheckPermissionBatch)
+				access.POST("/check-code", checkRateLimit, accessHandler.CheckPermissionByCode)
 				access.GET("/modules", accessHandler.GetUserModules)
 				access.GET("/permissions", accessHandler.GetUserPermissions)
+				access.GET("/me", accessHandler.GetMyAccess)
+				access.GET("/events", accessHandler.StreamPermissionEvents)
+				access.GET("/resolution-info", accessHandler.GetResolutionInfo)
+				access.GET("/my-changes", auditHandler.GetMyAccessChanges)
+				access.GET("/capable", middleware.RequirePermissionWithScope("permissions", models.PermissionActionRead, models.PermissionScopeAll), accessHandler.GetCapableUsers)
 
 				// Admin-only cache management
 				access.GET("/cache/stats", accessHandler.GetCacheStats)
@@ -336,6 +648,7 @@ func setupRouter() *gin.Engine {
 		// =============================================================
 		external := v1.Group("/external")
 		external.Use(middleware.ApiKeyAuth())
+		external.Use(maintenance)
 		{
 			// Schools endpoints for external access
 			external.GET("/schools", schoolHandler.GetSchools)
@@ -357,5 +670,5 @@ func setupRouter() *gin.Engine {
 		}
 	}
 
-	return router
+	return router, []backgroundService{workflowEscalationService, karyawanSyncService, permissionCache}
 }
@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"log"
 
+	"backend/configs"
+
+	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 )
 
@@ -20,8 +23,12 @@ type Module struct {
 }
 
 func main() {
-	// Database connection
-	connStr := "host=localhost port=3479 user=postgres password=testing123 dbname=gloria_v2 sslmode=disable"
+	// Database connection from configuration (.env or environment)
+	if err := godotenv.Load(); err != nil {
+		log.Println("Warning: .env file not found, using system environment variables")
+	}
+	cfg := configs.LoadConfig()
+	connStr := cfg.Database.DSN()
 	db, err := sql.Open("postgres", connStr)
 	if err != nil {
 		log.Fatalf("Error connecting: %v", err)
@@ -1,21 +1,62 @@
 package configs
 
 import (
+	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type Config struct {
-	Database DatabaseConfig
-	JWT      JWTConfig
-	CSRF     CSRFConfig
-	Server   ServerConfig
+	Database   DatabaseConfig
+	JWT        JWTConfig
+	CSRF       CSRFConfig
+	CORS       CORSConfig
+	Cookie     CookieConfig
+	Server     ServerConfig
+	Audit      AuditConfig
+	Employee   EmployeeSyncConfig
+	Metrics    MetricsConfig
+	Log        LogConfig
+	Permission PermissionConfig
+	Request    RequestConfig
+	Lockout    LockoutConfig
+	Password   PasswordHashConfig
 }
 
 type CSRFConfig struct {
 	Secret string
 }
 
+type CORSConfig struct {
+	AllowedOrigins []string // exact origins or wildcard-subdomain patterns like https://*.preview.example.com
+}
+
+// CookieConfig controls the attributes of the auth and CSRF cookies. Setting Domain to a parent
+// domain (e.g. .example.com) shares the cookies between the API and a frontend on a sibling
+// subdomain, so server-side rendering receives them too.
+type CookieConfig struct {
+	Domain   string // empty = host-only cookie for the API host
+	SameSite string // lax, strict or none; empty omits the attribute
+	Secure   *bool  // nil = secure in release mode only
+}
+
+// SameSiteMode returns the SameSite value to send with cookies
+func (c CookieConfig) SameSiteMode() http.SameSite {
+	switch c.SameSite {
+	case "lax":
+		return http.SameSiteLaxMode
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	}
+	return http.SameSiteDefaultMode
+}
+
 type DatabaseConfig struct {
 	Host     string
 	Port     string
@@ -23,16 +64,112 @@ type DatabaseConfig struct {
 	Password string
 	DBName   string
 	SSLMode  string
+	// StatementTimeoutMS makes Postgres cancel any statement running longer than this;
+	// 0 leaves the server default in place. Unset, it follows REQUEST_TIMEOUT_SECONDS so a
+	// query does not keep running after its request has timed out
+	StatementTimeoutMS int
+	// ReadReplicaDSN is an optional connection string for a streaming read replica; when set,
+	// SELECTs outside a transaction read from it while writes stay on the primary
+	ReadReplicaDSN string
+	// ConnectAttempts is how often the initial connection is tried before startup fails (>= 1);
+	// the wait between attempts starts at ConnectRetrySeconds and doubles, up to 30 seconds
+	ConnectAttempts     int
+	ConnectRetrySeconds int
+	// Connection pool limits, applied to the primary and the replica alike
+	MaxOpenConns           int // 0 = unlimited
+	MaxIdleConns           int
+	ConnMaxLifetimeMinutes int // 0 = connections are never recycled for age
+}
+
+// DSN returns the PostgreSQL connection string for this configuration
+func (c DatabaseConfig) DSN() string {
+	dsn := fmt.Sprintf(
+		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+		c.Host, c.Port, c.User, c.Password, c.DBName, c.SSLMode,
+	)
+	if c.StatementTimeoutMS > 0 {
+		dsn += fmt.Sprintf(" statement_timeout=%d", c.StatementTimeoutMS)
+	}
+	return dsn
 }
 
 type JWTConfig struct {
-	Secret      string
-	ExpireHours int
+	Secret                string
+	AccessTokenTTLMinutes int
+	RememberMeDays        int // refresh-token lifetime for logins with remember me
+	ResetTokenTTLMinutes  int // forgot-password token lifetime (>= 5)
+	// Issuer and Audience are embedded in access tokens and required on validation when set,
+	// so backends sharing a secret do not accept each other's tokens
+	Issuer   string
+	Audience string
 }
 
 type ServerConfig struct {
-	Port string
-	Env  string
+	Port            string
+	Env             string
+	ShutdownTimeout int    // seconds to drain in-flight requests on SIGINT/SIGTERM
+	Timezone        string // IANA zone for effective-date checks and calendar dates, e.g. Asia/Jakarta; empty = server local time
+}
+
+type AuditConfig struct {
+	RetentionDays int    // audit logs older than this are purged; 0 disables retention
+	RetentionMode string // "archive" moves rows to audit_log_archives, "delete" drops them
+}
+
+type EmployeeSyncConfig struct {
+	Reactivate bool // reactivate auto-deactivated users whose employee record is active again
+}
+
+type MetricsConfig struct {
+	Enabled  bool   // expose GET /metrics
+	Username string // basic auth for /metrics; empty disables auth
+	Password string
+}
+
+type LogConfig struct {
+	Level         string // debug, info, warn or error
+	RequestBodies bool   // log redacted JSON request and response bodies; keep off in production
+}
+
+type PermissionConfig struct {
+	WarmupOnStartup    bool // pre-resolve permissions for users active in the last 24h
+	CacheTTLSeconds    int  // how long a cached permission check is fresh (>= 1)
+	CacheStaleSeconds  int  // how long past its TTL an entry may be served while it refreshes; 0 disables
+	CheckRatePerMinute int  // per-user requests per minute on the /access/check* routes; 0 disables
+	CheckRateBurst     int  // requests a user may make in a row before the per-minute rate applies (>= 1)
+	// MaintenanceBypass is the permission, as resource:ACTION, that keeps access while
+	// maintenance mode is on
+	MaintenanceBypass string
+	// PositionScopeRules replace the default position scope rules when set, each as
+	// org_level:max_hierarchy_level:SCOPE (see services.ParsePositionScopeRules)
+	PositionScopeRules []string
+}
+
+// MaintenanceBypassPermission splits MaintenanceBypass into its resource and action
+func (c PermissionConfig) MaintenanceBypassPermission() (resource, action string) {
+	resource, action, _ = strings.Cut(c.MaintenanceBypass, ":")
+	return resource, strings.ToUpper(action)
+}
+
+type LockoutConfig struct {
+	MaxFailedAttempts   int // consecutive failed logins that lock an account (>= 3)
+	LockDurationMinutes int // how long a locked account stays locked (>= 1)
+}
+
+// PasswordHashConfig sets the Argon2id parameters of new password hashes. Raising them makes
+// logins rehash older, weaker hashes, so the whole user base moves over without resets.
+type PasswordHashConfig struct {
+	Time          int  // Argon2id iterations (>= 1)
+	MemoryKiB     int  // Argon2id memory in KiB (>= 19456, the OWASP minimum)
+	Threads       int  // Argon2id parallelism (1-255)
+	MigrateBcrypt bool // rehash bcrypt hashes (e.g. imported accounts) to Argon2id on login
+}
+
+type RequestConfig struct {
+	MaxBodyBytes          int // default cap for request bodies
+	MaxUploadBytes        int // cap for file upload routes such as the user import
+	TimeoutSeconds        int // per-request deadline; 0 disables it
+	IdempotencyTTLMinutes int // how long an Idempotency-Key response is replayed (>= 1)
 }
 
 func LoadConfig() *Config {
@@ -44,20 +181,89 @@ func LoadConfig() *Config {
 			Password: getEnv("DB_PASSWORD", ""),
 			DBName:   getEnv("DB_NAME", ""),
 			SSLMode:  getEnv("DB_SSLMODE", ""),
+
+			StatementTimeoutMS: getEnvInt("DB_STATEMENT_TIMEOUT_MS", -1),
+			ReadReplicaDSN:     getEnv("DB_READ_REPLICA_DSN", ""),
+
+			ConnectAttempts:        getEnvInt("DB_CONNECT_ATTEMPTS", 10),
+			ConnectRetrySeconds:    getEnvInt("DB_CONNECT_RETRY_SECONDS", 1),
+			MaxOpenConns:           getEnvInt("DB_MAX_OPEN_CONNS", 25),
+			MaxIdleConns:           getEnvInt("DB_MAX_IDLE_CONNS", 10),
+			ConnMaxLifetimeMinutes: getEnvInt("DB_CONN_MAX_LIFETIME_MINUTES", 30),
 		},
 		JWT: JWTConfig{
-			Secret:      getEnv("JWT_SECRET", ""),
-			ExpireHours: 24,
+			Secret:                getEnv("JWT_SECRET", ""),
+			AccessTokenTTLMinutes: getEnvInt("JWT_ACCESS_TOKEN_TTL_MINUTES", 15),
+			RememberMeDays:        getEnvInt("JWT_REMEMBER_ME_DAYS", 30),
+			ResetTokenTTLMinutes:  getEnvInt("PASSWORD_RESET_TTL_MINUTES", 60),
+			Issuer:                getEnv("JWT_ISSUER", ""),
+			Audience:              getEnv("JWT_AUDIENCE", ""),
 		},
 		CSRF: CSRFConfig{
 			Secret: getEnv("CSRF_SECRET", ""),
 		},
+		CORS: CORSConfig{
+			AllowedOrigins: getEnvList("CORS_ALLOWED_ORIGINS", getEnv("FRONTEND_URL", "")),
+		},
+		Cookie: CookieConfig{
+			Domain:   getEnv("COOKIE_DOMAIN", ""),
+			SameSite: strings.ToLower(getEnv("COOKIE_SAMESITE", "")),
+			Secure:   getEnvOptionalBool("COOKIE_SECURE"),
+		},
 		Server: ServerConfig{
-			Port: getEnv("PORT", "8080"),
-			Env:  getEnv("ENV", "development"),
+			Port:            getEnv("PORT", "8080"),
+			Env:             getEnv("ENV", "development"),
+			ShutdownTimeout: getEnvInt("SHUTDOWN_TIMEOUT_SECONDS", 30),
+			Timezone:        getEnv("APP_TIMEZONE", ""),
+		},
+		Audit: AuditConfig{
+			RetentionDays: getEnvInt("AUDIT_RETENTION_DAYS", 365),
+			RetentionMode: getEnv("AUDIT_RETENTION_MODE", "archive"),
+		},
+		Employee: EmployeeSyncConfig{
+			Reactivate: getEnvBool("EMPLOYEE_SYNC_REACTIVATE", false),
+		},
+		Metrics: MetricsConfig{
+			Enabled:  getEnvBool("METRICS_ENABLED", false),
+			Username: getEnv("METRICS_USERNAME", ""),
+			Password: getEnv("METRICS_PASSWORD", ""),
+		},
+		Log: LogConfig{
+			Level:         getEnv("LOG_LEVEL", "info"),
+			RequestBodies: getEnvBool("LOG_REQUEST_BODIES", false),
+		},
+		Permission: PermissionConfig{
+			WarmupOnStartup:    getEnvBool("PERMISSION_CACHE_WARMUP", false),
+			CacheTTLSeconds:    getEnvInt("PERMISSION_CACHE_TTL_SECONDS", 300),
+			CacheStaleSeconds:  getEnvInt("PERMISSION_CACHE_STALE_SECONDS", 0),
+			CheckRatePerMinute: getEnvInt("PERMISSION_CHECK_RATE_PER_MINUTE", 300),
+			CheckRateBurst:     getEnvInt("PERMISSION_CHECK_RATE_BURST", 60),
+			MaintenanceBypass:  getEnv("MAINTENANCE_BYPASS_PERMISSION", "system:UPDATE"),
+			PositionScopeRules: getEnvList("POSITION_SCOPE_RULES", ""),
+		},
+		Lockout: LockoutConfig{
+			MaxFailedAttempts:   getEnvInt("AUTH_MAX_FAILED_ATTEMPTS", 5),
+			LockDurationMinutes: getEnvInt("AUTH_LOCK_DURATION_MINUTES", 15),
+		},
+		Password: PasswordHashConfig{
+			Time:          getEnvInt("PASSWORD_HASH_TIME", 1),
+			MemoryKiB:     getEnvInt("PASSWORD_HASH_MEMORY_KIB", 64*1024),
+			Threads:       getEnvInt("PASSWORD_HASH_THREADS", 4),
+			MigrateBcrypt: getEnvBool("PASSWORD_HASH_MIGRATE_BCRYPT", false),
+		},
+		Request: RequestConfig{
+			MaxBodyBytes:          getEnvInt("REQUEST_MAX_BODY_BYTES", 1<<20),
+			MaxUploadBytes:        getEnvInt("REQUEST_MAX_UPLOAD_BYTES", 10<<20),
+			TimeoutSeconds:        getEnvInt("REQUEST_TIMEOUT_SECONDS", 30),
+			IdempotencyTTLMinutes: getEnvInt("REQUEST_IDEMPOTENCY_TTL_MINUTES", 60),
 		},
 	}
 
+	// Without an explicit DB_STATEMENT_TIMEOUT_MS, statements get the request's time budget
+	if cfg.Database.StatementTimeoutMS < 0 {
+		cfg.Database.StatementTimeoutMS = cfg.Request.TimeoutSeconds * 1000
+	}
+
 	// Validate required configuration
 	validateConfig(cfg)
 
@@ -111,6 +317,76 @@ func validateConfig(cfg *Config) {
 	if len(cfg.CSRF.Secret) < 32 {
 		log.Fatal("CSRF_SECRET must be at least 32 characters long for security")
 	}
+
+	// Validate lockout bounds so a typo cannot disable brute-force protection
+	if cfg.JWT.ResetTokenTTLMinutes < 5 {
+		log.Fatal("PASSWORD_RESET_TTL_MINUTES must be at least 5")
+	}
+	if cfg.Lockout.MaxFailedAttempts < 3 {
+		log.Fatal("AUTH_MAX_FAILED_ATTEMPTS must be at least 3")
+	}
+	if cfg.Lockout.LockDurationMinutes < 1 {
+		log.Fatal("AUTH_LOCK_DURATION_MINUTES must be at least 1")
+	}
+	if cfg.Password.Time < 1 {
+		log.Fatal("PASSWORD_HASH_TIME must be at least 1")
+	}
+	if cfg.Password.MemoryKiB < 19456 {
+		log.Fatal("PASSWORD_HASH_MEMORY_KIB must be at least 19456")
+	}
+	if cfg.Password.Threads < 1 || cfg.Password.Threads > 255 {
+		log.Fatal("PASSWORD_HASH_THREADS must be between 1 and 255")
+	}
+	if cfg.Database.ConnectAttempts < 1 {
+		log.Fatal("DB_CONNECT_ATTEMPTS must be at least 1")
+	}
+	if cfg.Database.ConnectRetrySeconds < 1 {
+		log.Fatal("DB_CONNECT_RETRY_SECONDS must be at least 1")
+	}
+	if cfg.Database.MaxOpenConns < 0 || cfg.Database.MaxIdleConns < 0 || cfg.Database.ConnMaxLifetimeMinutes < 0 {
+		log.Fatal("DB_MAX_OPEN_CONNS, DB_MAX_IDLE_CONNS and DB_CONN_MAX_LIFETIME_MINUTES must not be negative")
+	}
+	if cfg.Request.IdempotencyTTLMinutes < 1 {
+		log.Fatal("REQUEST_IDEMPOTENCY_TTL_MINUTES must be at least 1")
+	}
+	if cfg.Permission.CacheTTLSeconds < 1 {
+		log.Fatal("PERMISSION_CACHE_TTL_SECONDS must be at least 1")
+	}
+	if cfg.Permission.CacheStaleSeconds < 0 {
+		log.Fatal("PERMISSION_CACHE_STALE_SECONDS must not be negative")
+	}
+	if cfg.Permission.CheckRatePerMinute < 0 {
+		log.Fatal("PERMISSION_CHECK_RATE_PER_MINUTE must not be negative")
+	}
+	if cfg.Permission.CheckRatePerMinute > 0 && cfg.Permission.CheckRateBurst < 1 {
+		log.Fatal("PERMISSION_CHECK_RATE_BURST must be at least 1")
+	}
+	if resource, action := cfg.Permission.MaintenanceBypassPermission(); resource == "" || action == "" {
+		log.Fatalf("MAINTENANCE_BYPASS_PERMISSION %q must have the form resource:ACTION", cfg.Permission.MaintenanceBypass)
+	}
+
+	if cfg.Server.Timezone != "" {
+		if _, err := time.LoadLocation(cfg.Server.Timezone); err != nil {
+			log.Fatalf("APP_TIMEZONE %q is not a valid IANA timezone: %v", cfg.Server.Timezone, err)
+		}
+	}
+
+	switch cfg.Cookie.SameSite {
+	case "", "lax", "strict":
+	case "none":
+		// Browsers drop SameSite=None cookies that are not Secure
+		if cfg.Cookie.Secure != nil && !*cfg.Cookie.Secure {
+			log.Fatal("COOKIE_SAMESITE=none requires COOKIE_SECURE=true")
+		}
+		secure := true
+		cfg.Cookie.Secure = &secure
+	default:
+		log.Fatal("COOKIE_SAMESITE must be one of lax, strict or none")
+	}
+
+	if cfg.Log.RequestBodies && cfg.Server.Env != "development" {
+		log.Println("Warning: LOG_REQUEST_BODIES is enabled outside development; request and response bodies will be logged")
+	}
 }
 
 // MustLoadConfig loads configuration and panics if validation fails
@@ -124,3 +400,48 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil {
+			return parsed
+		}
+		log.Printf("Warning: invalid integer for %s, using default %d", key, defaultValue)
+	}
+	return defaultValue
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.ParseBool(value); err == nil {
+			return parsed
+		}
+		log.Printf("Warning: invalid boolean for %s, using default %t", key, defaultValue)
+	}
+	return defaultValue
+}
+
+// getEnvOptionalBool reads a boolean that has no default, returning nil when it is unset or invalid
+func getEnvOptionalBool(key string) *bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return nil
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("Warning: invalid boolean for %s, ignoring it", key)
+		return nil
+	}
+	return &parsed
+}
+
+// getEnvList reads a comma-separated list, trimming spaces and dropping empty entries
+func getEnvList(key, defaultValue string) []string {
+	var list []string
+	for _, item := range strings.Split(getEnv(key, defaultValue), ",") {
+		if item = strings.TrimRight(strings.TrimSpace(item), "/"); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -9,23 +9,98 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
-var jwtSecret []byte
+var (
+	jwtSecret      []byte
+	accessTokenTTL = AccessTokenExpiry
+	rememberMeTTL  = RememberMeRefreshTokenExpiry
+	resetTokenTTL  = PasswordResetExpiry
+	tokenIssuer    string
+	tokenAudience  string
+)
+
+// TokenOptions configures the access tokens issued and accepted by this backend
+type TokenOptions struct {
+	AccessTokenTTL time.Duration // zero keeps AccessTokenExpiry
+	RememberMeTTL  time.Duration // zero keeps RememberMeRefreshTokenExpiry
+	ResetTokenTTL  time.Duration // zero keeps PasswordResetExpiry
+	Issuer         string        // empty disables the iss claim and its check
+	Audience       string        // empty disables the aud claim and its check
+}
 
-// InitJWT initializes JWT secret from config
-func InitJWT(secret string) {
+// InitJWT initializes JWT secret and token options from config
+func InitJWT(secret string, options TokenOptions) {
 	jwtSecret = []byte(secret)
+	accessTokenTTL = AccessTokenExpiry
+	if options.AccessTokenTTL > 0 {
+		accessTokenTTL = options.AccessTokenTTL
+	}
+	rememberMeTTL = RememberMeRefreshTokenExpiry
+	if options.RememberMeTTL > 0 {
+		rememberMeTTL = options.RememberMeTTL
+	}
+	resetTokenTTL = PasswordResetExpiry
+	if options.ResetTokenTTL > 0 {
+		resetTokenTTL = options.ResetTokenTTL
+	}
+	tokenIssuer = options.Issuer
+	tokenAudience = options.Audience
+}
+
+// PasswordResetTTL returns how long a forgot-password token stays valid
+func PasswordResetTTL() time.Duration {
+	return resetTokenTTL
+}
+
+// AccessTokenTTL returns the configured lifetime of regular access tokens
+func AccessTokenTTL() time.Duration {
+	return accessTokenTTL
+}
+
+// RefreshTokenTTL returns the lifetime of a new refresh token: the remember-me lifetime when
+// the user asked to stay signed in, RefreshTokenExpiry otherwise
+func RefreshTokenTTL(rememberMe bool) time.Duration {
+	if rememberMe {
+		return rememberMeTTL
+	}
+	return RefreshTokenExpiry
+}
+
+// registeredClaims builds the standard claims for a token that expires after ttl
+func registeredClaims(ttl time.Duration) jwt.RegisteredClaims {
+	now := time.Now()
+	claims := jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		IssuedAt:  jwt.NewNumericDate(now),
+		NotBefore: jwt.NewNumericDate(now),
+		Issuer:    tokenIssuer,
+	}
+	if tokenAudience != "" {
+		claims.Audience = jwt.ClaimStrings{tokenAudience}
+	}
+	return claims
 }
 
-// GenerateAccessToken generates a short-lived access token (15 minutes)
+// GenerateAccessToken generates a short-lived access token (AccessTokenTTL)
 func GenerateAccessToken(userID, email string) (string, error) {
 	claims := &Claims{
-		UserID: userID,
-		Email:  email,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(AccessTokenExpiry)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
-		},
+		UserID:           userID,
+		Email:            email,
+		RegisteredClaims: registeredClaims(accessTokenTTL),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(jwtSecret)
+}
+
+// GenerateImpersonationToken generates a short-lived access token that lets
+// impersonatorID act as userID. The token is never refreshed: once it expires,
+// the refresh cookie (which still belongs to the impersonator) issues their own token.
+func GenerateImpersonationToken(userID, email, impersonatorID string) (string, error) {
+	claims := &Claims{
+		UserID:           userID,
+		Email:            email,
+		ImpersonatorID:   impersonatorID,
+		RegisteredClaims: registeredClaims(ImpersonationTokenExpiry),
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -52,8 +127,17 @@ func GenerateRefreshToken() (string, string, error) {
 	return plainToken, hashedToken, nil
 }
 
-// ValidateToken validates JWT token and returns claims
+// ValidateToken validates JWT token and returns claims.
+// When an issuer or audience is configured, tokens without a matching claim are rejected.
 func ValidateToken(tokenString string) (*Claims, error) {
+	var parserOptions []jwt.ParserOption
+	if tokenIssuer != "" {
+		parserOptions = append(parserOptions, jwt.WithIssuer(tokenIssuer))
+	}
+	if tokenAudience != "" {
+		parserOptions = append(parserOptions, jwt.WithAudience(tokenAudience))
+	}
+
 	token, err := jwt.ParseWithClaims(
 		tokenString,
 		&Claims{},
@@ -64,6 +148,7 @@ func ValidateToken(tokenString string) (*Claims, error) {
 			}
 			return jwtSecret, nil
 		},
+		parserOptions...,
 	)
 
 	if err != nil {
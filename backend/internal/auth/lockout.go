@@ -0,0 +1,36 @@
+package auth
+
+import "time"
+
+var (
+	maxFailedAttempts   = MaxFailedAttempts
+	accountLockDuration = AccountLockDuration
+)
+
+// LockoutOptions configures when repeated failed logins lock an account
+type LockoutOptions struct {
+	MaxFailedAttempts   int           // zero keeps MaxFailedAttempts
+	AccountLockDuration time.Duration // zero keeps AccountLockDuration
+}
+
+// InitLockout sets the account lockout thresholds from config
+func InitLockout(options LockoutOptions) {
+	maxFailedAttempts = MaxFailedAttempts
+	if options.MaxFailedAttempts > 0 {
+		maxFailedAttempts = options.MaxFailedAttempts
+	}
+	accountLockDuration = AccountLockDuration
+	if options.AccountLockDuration > 0 {
+		accountLockDuration = options.AccountLockDuration
+	}
+}
+
+// MaxFailedLoginAttempts returns how many consecutive failed logins lock an account
+func MaxFailedLoginAttempts() int {
+	return maxFailedAttempts
+}
+
+// LockDuration returns how long an account stays locked after too many failed logins
+func LockDuration() time.Duration {
+	return accountLockDuration
+}
@@ -8,10 +8,11 @@ import (
 	"strings"
 
 	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
 )
 
 const (
-	// Argon2 parameters (OWASP recommended)
+	// Argon2 parameters (OWASP recommended), overridable through InitPasswordHashing
 	argon2Time      = 1
 	argon2Memory    = 64 * 1024 // 64 MB
 	argon2Threads   = 4
@@ -19,7 +20,40 @@ const (
 	saltLength      = 16
 )
 
-// HashPassword hashes a password using Argon2id
+var (
+	hashTime      uint32 = argon2Time
+	hashMemory    uint32 = argon2Memory
+	hashThreads   uint8  = argon2Threads
+	migrateBcrypt bool
+)
+
+// PasswordHashOptions configures the Argon2id parameters of new hashes
+type PasswordHashOptions struct {
+	Time          uint32 // iterations; zero keeps argon2Time
+	MemoryKiB     uint32 // zero keeps argon2Memory
+	Threads       uint8  // zero keeps argon2Threads
+	MigrateBcrypt bool   // rehash bcrypt hashes (e.g. imported accounts) to Argon2id on login
+}
+
+// InitPasswordHashing sets the hashing parameters from config. Existing hashes keep verifying
+// with the parameters encoded in them; NeedsRehash reports the ones below the new target.
+func InitPasswordHashing(options PasswordHashOptions) {
+	hashTime = argon2Time
+	if options.Time > 0 {
+		hashTime = options.Time
+	}
+	hashMemory = argon2Memory
+	if options.MemoryKiB > 0 {
+		hashMemory = options.MemoryKiB
+	}
+	hashThreads = argon2Threads
+	if options.Threads > 0 {
+		hashThreads = options.Threads
+	}
+	migrateBcrypt = options.MigrateBcrypt
+}
+
+// HashPassword hashes a password using Argon2id with the configured parameters
 // Returns: $argon2id$v=19$m=65536,t=1,p=4$<salt>$<hash>
 func HashPassword(password string) (string, error) {
 	// Generate random salt
@@ -32,9 +66,9 @@ func HashPassword(password string) (string, error) {
 	hash := argon2.IDKey(
 		[]byte(password),
 		salt,
-		argon2Time,
-		argon2Memory,
-		argon2Threads,
+		hashTime,
+		hashMemory,
+		hashThreads,
 		argon2KeyLength,
 	)
 
@@ -45,55 +79,102 @@ func HashPassword(password string) (string, error) {
 	return fmt.Sprintf(
 		"$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
 		argon2.Version,
-		argon2Memory,
-		argon2Time,
-		argon2Threads,
+		hashMemory,
+		hashTime,
+		hashThreads,
 		encodedSalt,
 		encodedHash,
 	), nil
 }
 
-// VerifyPassword verifies a password against a hash
-func VerifyPassword(password, encodedHash string) bool {
+// argon2Hash is a parsed $argon2id$ hash
+type argon2Hash struct {
+	version int
+	memory  uint32
+	time    uint32
+	threads uint8
+	salt    []byte
+	hash    []byte
+}
+
+// parseArgon2Hash splits an encoded Argon2id hash into its parameters, salt and key
+func parseArgon2Hash(encodedHash string) (*argon2Hash, bool) {
 	// Parse the encoded hash
 	parts := strings.Split(encodedHash, "$")
-	if len(parts) != 6 {
-		return false
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return nil, false
 	}
 
 	// Extract parameters
-	var version int
-	var memory, time uint32
-	var threads uint8
-	_, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &time, &threads)
+	var h argon2Hash
+	_, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &h.memory, &h.time, &h.threads)
 	if err != nil {
-		return false
+		return nil, false
 	}
-	_, err = fmt.Sscanf(parts[2], "v=%d", &version)
+	_, err = fmt.Sscanf(parts[2], "v=%d", &h.version)
 	if err != nil {
-		return false
+		return nil, false
 	}
 
 	// Decode salt and hash
-	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	h.salt, err = base64.RawStdEncoding.DecodeString(parts[4])
 	if err != nil {
-		return false
+		return nil, false
 	}
-	hash, err := base64.RawStdEncoding.DecodeString(parts[5])
+	h.hash, err = base64.RawStdEncoding.DecodeString(parts[5])
 	if err != nil {
+		return nil, false
+	}
+	return &h, true
+}
+
+// isBcryptHash reports whether encodedHash is a bcrypt hash ($2a$, $2b$ or $2y$)
+func isBcryptHash(encodedHash string) bool {
+	return strings.HasPrefix(encodedHash, "$2a$") ||
+		strings.HasPrefix(encodedHash, "$2b$") ||
+		strings.HasPrefix(encodedHash, "$2y$")
+}
+
+// VerifyPassword verifies a password against an Argon2id or bcrypt hash
+func VerifyPassword(password, encodedHash string) bool {
+	if isBcryptHash(encodedHash) {
+		return bcrypt.CompareHashAndPassword([]byte(encodedHash), []byte(password)) == nil
+	}
+
+	h, ok := parseArgon2Hash(encodedHash)
+	if !ok {
 		return false
 	}
 
 	// Generate hash from input password
 	computedHash := argon2.IDKey(
 		[]byte(password),
-		salt,
-		time,
-		memory,
-		threads,
-		uint32(len(hash)),
+		h.salt,
+		h.time,
+		h.memory,
+		h.threads,
+		uint32(len(h.hash)),
 	)
 
 	// Constant-time comparison
-	return subtle.ConstantTimeCompare(hash, computedHash) == 1
+	return subtle.ConstantTimeCompare(h.hash, computedHash) == 1
+}
+
+// NeedsRehash reports whether a hash that just verified should be replaced by a fresh
+// HashPassword result: an Argon2id hash weaker than the configured parameters, or a bcrypt
+// hash while bcrypt migration is enabled. Stronger hashes are left alone.
+func NeedsRehash(encodedHash string) bool {
+	if isBcryptHash(encodedHash) {
+		return migrateBcrypt
+	}
+
+	h, ok := parseArgon2Hash(encodedHash)
+	if !ok {
+		return false
+	}
+	return h.version < argon2.Version ||
+		h.memory < hashMemory ||
+		h.time < hashTime ||
+		h.threads < hashThreads ||
+		len(h.hash) < argon2KeyLength
 }
@@ -0,0 +1,161 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// useHashing applies options for one test and restores the defaults afterwards
+func useHashing(t *testing.T, options PasswordHashOptions) {
+	t.Helper()
+	InitPasswordHashing(options)
+	t.Cleanup(func() { InitPasswordHashing(PasswordHashOptions{}) })
+}
+
+// cheap keeps test hashes fast; the parameters are still encoded in and read from each hash
+var cheap = PasswordHashOptions{Time: 1, MemoryKiB: 1024, Threads: 1}
+
+func mustHash(t *testing.T) string {
+	t.Helper()
+	hash, err := HashPassword("correct horse battery staple")
+	if err != nil {
+		t.Fatalf("HashPassword: %v", err)
+	}
+	return hash
+}
+
+func TestHashPasswordEncodesConfiguredParameters(t *testing.T) {
+	useHashing(t, PasswordHashOptions{Time: 2, MemoryKiB: 2048, Threads: 2})
+
+	hash := mustHash(t)
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=2048,t=2,p=2$") {
+		t.Errorf("hash %q does not encode the configured parameters", hash)
+	}
+	if other := mustHash(t); other == hash {
+		t.Error("two hashes of the same password are equal; the salt is not random")
+	}
+}
+
+func TestInitPasswordHashingZeroKeepsDefaults(t *testing.T) {
+	useHashing(t, PasswordHashOptions{})
+
+	want := fmt.Sprintf("$argon2id$v=19$m=%d,t=%d,p=%d$", argon2Memory, argon2Time, argon2Threads)
+	if hash := mustHash(t); !strings.HasPrefix(hash, want) {
+		t.Errorf("hash %q, want the default parameters %s", hash, want)
+	}
+}
+
+func TestVerifyPassword(t *testing.T) {
+	useHashing(t, cheap)
+	argonHash := mustHash(t)
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("correct horse battery staple"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("bcrypt: %v", err)
+	}
+	// Hashes made with other parameters keep verifying after the target changes
+	useHashing(t, PasswordHashOptions{Time: 2, MemoryKiB: 2048, Threads: 2})
+
+	tests := []struct {
+		name     string
+		password string
+		hash     string
+		want     bool
+	}{
+		{"argon2id, right password", "correct horse battery staple", argonHash, true},
+		{"argon2id, wrong password", "Correct horse battery staple", argonHash, false},
+		{"argon2id, empty password", "", argonHash, false},
+		{"bcrypt, right password", "correct horse battery staple", string(bcryptHash), true},
+		{"bcrypt, wrong password", "wrong", string(bcryptHash), false},
+		{"argon2i is not accepted", "correct horse battery staple", strings.Replace(argonHash, "$argon2id$", "$argon2i$", 1), false},
+		{"truncated hash", "correct horse battery staple", argonHash[:strings.LastIndex(argonHash, "$")], false},
+		{"corrupted salt", "correct horse battery staple", strings.Replace(argonHash, "$m=1024,t=1,p=1$", "$m=1024,t=1,p=1$!", 1), false},
+		{"empty hash", "correct horse battery staple", "", false},
+		{"plain text", "correct horse battery staple", "correct horse battery staple", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := VerifyPassword(tt.password, tt.hash); got != tt.want {
+				t.Errorf("VerifyPassword = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	useHashing(t, cheap)
+	weak := mustHash(t)
+	useHashing(t, PasswordHashOptions{Time: 2, MemoryKiB: 2048, Threads: 2})
+	current := mustHash(t)
+	useHashing(t, PasswordHashOptions{Time: 3, MemoryKiB: 4096, Threads: 4})
+	strong := mustHash(t)
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("correct horse battery staple"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("bcrypt: %v", err)
+	}
+
+	target := PasswordHashOptions{Time: 2, MemoryKiB: 2048, Threads: 2}
+	tests := []struct {
+		name    string
+		options PasswordHashOptions
+		hash    string
+		want    bool
+	}{
+		{"weaker than the target", target, weak, true},
+		{"at the target", target, current, false},
+		{"stronger than the target", target, strong, false},
+		{"only memory below the target", PasswordHashOptions{Time: 2, MemoryKiB: 4096, Threads: 2}, current, true},
+		{"only time below the target", PasswordHashOptions{Time: 3, MemoryKiB: 2048, Threads: 2}, current, true},
+		{"only threads below the target", PasswordHashOptions{Time: 2, MemoryKiB: 2048, Threads: 4}, current, true},
+		{"older argon2 version", target, strings.Replace(current, "$v=19$", "$v=16$", 1), true},
+		{"bcrypt without migration", target, string(bcryptHash), false},
+		{"bcrypt with migration", PasswordHashOptions{Time: 2, MemoryKiB: 2048, Threads: 2, MigrateBcrypt: true}, string(bcryptHash), true},
+		{"unparseable hash", target, "not-a-hash", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useHashing(t, tt.options)
+			if got := NeedsRehash(tt.hash); got != tt.want {
+				t.Errorf("NeedsRehash = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestRehashOnLogin walks the login flow: a weak hash verifies, is flagged, and its
+// replacement verifies the same password and is no longer flagged
+func TestRehashOnLogin(t *testing.T) {
+	for name, oldHash := range map[string]func(t *testing.T) string{
+		"argon2id below target": func(t *testing.T) string {
+			useHashing(t, cheap)
+			return mustHash(t)
+		},
+		"bcrypt": func(t *testing.T) string {
+			hash, err := bcrypt.GenerateFromPassword([]byte("correct horse battery staple"), bcrypt.MinCost)
+			if err != nil {
+				t.Fatalf("bcrypt: %v", err)
+			}
+			return string(hash)
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			stored := oldHash(t)
+			useHashing(t, PasswordHashOptions{Time: 2, MemoryKiB: 2048, Threads: 2, MigrateBcrypt: true})
+
+			if !VerifyPassword("correct horse battery staple", stored) || !NeedsRehash(stored) {
+				t.Fatal("old hash should verify and need a rehash")
+			}
+			rehashed := mustHash(t)
+			if !VerifyPassword("correct horse battery staple", rehashed) {
+				t.Error("rehashed password does not verify")
+			}
+			if NeedsRehash(rehashed) {
+				t.Error("rehashed password still needs a rehash")
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+const (
+	resetSelectorBytes = 16
+	resetVerifierBytes = 32
+)
+
+// ResetToken is a password reset/setup token split into a selector, stored in plain text
+// and indexed so the owning user is found with one lookup, and a verifier, stored only as
+// a hash. The user receives "<selector>.<verifier>".
+type ResetToken struct {
+	Token        string // sent to the user, never stored
+	Selector     string
+	VerifierHash string
+}
+
+// NewResetToken generates a reset token and hashes its verifier
+func NewResetToken() (*ResetToken, error) {
+	selector, err := randomHex(resetSelectorBytes)
+	if err != nil {
+		return nil, err
+	}
+	verifier, err := randomHex(resetVerifierBytes)
+	if err != nil {
+		return nil, err
+	}
+	verifierHash, err := HashPassword(verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ResetToken{
+		Token:        selector + "." + verifier,
+		Selector:     selector,
+		VerifierHash: verifierHash,
+	}, nil
+}
+
+// SplitResetToken returns the selector and verifier of a token issued by NewResetToken
+func SplitResetToken(token string) (selector, verifier string, ok bool) {
+	selector, verifier, ok = strings.Cut(token, ".")
+	if !ok || len(selector) != resetSelectorBytes*2 || len(verifier) != resetVerifierBytes*2 {
+		return "", "", false
+	}
+	return selector, verifier, true
+}
+
+// randomHex returns n random bytes hex-encoded
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -10,6 +10,8 @@ import (
 type Claims struct {
 	UserID string `json:"user_id"`
 	Email  string `json:"email"`
+	// ImpersonatorID is set when an admin is acting as UserID
+	ImpersonatorID string `json:"impersonator_id,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -17,9 +19,15 @@ type Claims struct {
 const (
 	AccessTokenExpiry  = 15 * time.Minute   // 15 minutes
 	RefreshTokenExpiry = 7 * 24 * time.Hour // 7 days
+
+	RememberMeRefreshTokenExpiry = 30 * 24 * time.Hour // 30 days, used when the user asks to be remembered
+
+	ImpersonationTokenExpiry = 10 * time.Minute // 10 minutes, not refreshable
+
+	PasswordResetExpiry = time.Hour // lifetime of a forgot-password token
 )
 
-// Account locking constants
+// Account locking defaults, overridable through InitLockout
 const (
 	MaxFailedAttempts    = 5
 	AccountLockDuration  = 15 * time.Minute
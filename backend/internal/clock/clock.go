@@ -0,0 +1,60 @@
+// Package clock is the single source of "now" for effective-date checks, evaluated in the
+// application timezone so calendar-day boundaries match the school's local time.
+package clock
+
+import (
+	"sync/atomic"
+	"time"
+
+	// Embed the zone database so APP_TIMEZONE works on images without /usr/share/zoneinfo
+	_ "time/tzdata"
+)
+
+// location is the application timezone; it defaults to the server's local time
+var location atomic.Pointer[time.Location]
+
+// SetTimezone sets the application timezone from an IANA name such as "Asia/Jakarta".
+// An empty name keeps the server's local time.
+func SetTimezone(name string) error {
+	if name == "" {
+		location.Store(time.Local)
+		return nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return err
+	}
+	location.Store(loc)
+	return nil
+}
+
+// Location returns the application timezone
+func Location() *time.Location {
+	if loc := location.Load(); loc != nil {
+		return loc
+	}
+	return time.Local
+}
+
+// Now returns the current time in the application timezone
+func Now() time.Time {
+	return time.Now().In(Location())
+}
+
+// StartOfDay returns midnight at the start of t's calendar day in the application timezone
+func StartOfDay(t time.Time) time.Time {
+	loc := Location()
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, loc)
+}
+
+// EndOfDay returns the last instant of t's calendar day in the application timezone, so a
+// grant ending "on" a date stays effective until local midnight rather than UTC midnight
+func EndOfDay(t time.Time) time.Time {
+	return StartOfDay(t).AddDate(0, 0, 1).Add(-time.Nanosecond)
+}
+
+// ParseDate parses a plain YYYY-MM-DD date as midnight in the application timezone
+func ParseDate(value string) (time.Time, error) {
+	return time.ParseInLocation("2006-01-02", value, Location())
+}
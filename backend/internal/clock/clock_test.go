@@ -0,0 +1,93 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+// useTimezone switches the application timezone for one test
+func useTimezone(t *testing.T, name string) {
+	t.Helper()
+	previous := Location()
+	if err := SetTimezone(name); err != nil {
+		t.Fatalf("SetTimezone(%q): %v", name, err)
+	}
+	t.Cleanup(func() { location.Store(previous) })
+}
+
+func TestDayBoundariesFollowApplicationTimezone(t *testing.T) {
+	useTimezone(t, "Asia/Jakarta") // UTC+7, no DST
+
+	tests := []struct {
+		name      string
+		at        time.Time
+		wantStart time.Time
+		wantEnd   time.Time
+	}{
+		{
+			name:      "afternoon in Jakarta",
+			at:        time.Date(2026, 3, 6, 8, 0, 0, 0, time.UTC), // 15:00 WIB
+			wantStart: time.Date(2026, 3, 5, 17, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2026, 3, 6, 16, 59, 59, 999999999, time.UTC),
+		},
+		{
+			name:      "UTC evening is already the next local day",
+			at:        time.Date(2026, 3, 6, 20, 0, 0, 0, time.UTC), // 03:00 WIB on the 7th
+			wantStart: time.Date(2026, 3, 6, 17, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2026, 3, 7, 16, 59, 59, 999999999, time.UTC),
+		},
+		{
+			name:      "local midnight starts its own day",
+			at:        time.Date(2026, 3, 6, 17, 0, 0, 0, time.UTC), // 00:00 WIB on the 7th
+			wantStart: time.Date(2026, 3, 6, 17, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2026, 3, 7, 16, 59, 59, 999999999, time.UTC),
+		},
+		{
+			name:      "last local instant ends its own day",
+			at:        time.Date(2026, 3, 6, 16, 59, 59, 999999999, time.UTC), // 23:59:59.999999999 WIB
+			wantStart: time.Date(2026, 3, 5, 17, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2026, 3, 6, 16, 59, 59, 999999999, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StartOfDay(tt.at); !got.Equal(tt.wantStart) {
+				t.Errorf("StartOfDay(%s) = %s, want %s", tt.at, got.UTC(), tt.wantStart)
+			}
+			if got := EndOfDay(tt.at); !got.Equal(tt.wantEnd) {
+				t.Errorf("EndOfDay(%s) = %s, want %s", tt.at, got.UTC(), tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestParseDateIsLocalMidnight(t *testing.T) {
+	useTimezone(t, "Asia/Jakarta")
+
+	got, err := ParseDate("2026-03-06")
+	if err != nil {
+		t.Fatalf("ParseDate: %v", err)
+	}
+	if want := time.Date(2026, 3, 5, 17, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("ParseDate(2026-03-06) = %s, want %s", got.UTC(), want)
+	}
+	if got.Location() != Location() {
+		t.Errorf("ParseDate returned a time in %s, want the application timezone", got.Location())
+	}
+
+	if _, err := ParseDate("06/03/2026"); err == nil {
+		t.Error("ParseDate accepted a date not in YYYY-MM-DD form")
+	}
+}
+
+func TestNowIsInApplicationTimezone(t *testing.T) {
+	useTimezone(t, "Asia/Jakarta")
+
+	if got := Now().Location(); got != Location() {
+		t.Errorf("Now() is in %s, want %s", got, Location())
+	}
+	if err := SetTimezone("Mars/Olympus_Mons"); err == nil {
+		t.Error("SetTimezone accepted an unknown zone")
+	}
+}
@@ -1,12 +1,17 @@
 package database
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"strings"
+	"time"
 
 	"backend/configs"
 	"backend/internal/models"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -14,79 +19,138 @@ import (
 
 var DB *gorm.DB
 
-// InitDB initializes the database connection
-func InitDB(cfg *configs.Config) error {
-	dsn := fmt.Sprintf(
-		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
-		cfg.Database.Host,
-		cfg.Database.Port,
-		cfg.Database.User,
-		cfg.Database.Password,
-		cfg.Database.DBName,
-		cfg.Database.SSLMode,
-	)
+// replicaDB is the read-replica connection the resolver on DB routes SELECTs to, or nil
+// when no replica is configured
+var replicaDB *gorm.DB
 
+// InitDB initializes the database connection, plus the read replica when one is configured.
+// With a replica, DB sends SELECTs made outside a transaction to it; see readResolver.
+// The initial connection is retried with backoff so the app can start before Postgres is ready.
+func InitDB(cfg *configs.Config) error {
 	var err error
-	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
-	})
+	DB, err = openWithRetry("database", cfg.Database.DSN(), cfg.Database)
 	if err != nil {
 		return fmt.Errorf("failed to connect to database: %w", err)
 	}
 
 	log.Println("Database connection established successfully")
+
+	if cfg.Database.ReadReplicaDSN != "" {
+		replicaDB, err = openWithRetry("read replica", cfg.Database.ReadReplicaDSN, cfg.Database)
+		if err != nil {
+			return fmt.Errorf("failed to connect to read replica: %w", err)
+		}
+		if err := DB.Use(&readResolver{replica: replicaDB.ConnPool}); err != nil {
+			return fmt.Errorf("failed to register read replica resolver: %w", err)
+		}
+		log.Println("Read replica connection established successfully")
+	}
 	return nil
 }
 
+// maxConnectRetryWait caps the doubling wait between connection attempts
+const maxConnectRetryWait = 30 * time.Second
+
+// openWithRetry opens dsn and configures its pool, retrying up to cfg.ConnectAttempts times.
+// Authentication errors are returned at once since waiting will not fix them.
+func openWithRetry(name, dsn string, cfg configs.DatabaseConfig) (*gorm.DB, error) {
+	wait := time.Duration(cfg.ConnectRetrySeconds) * time.Second
+	for attempt := 1; ; attempt++ {
+		db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+			Logger: logger.Default.LogMode(logger.Info),
+		})
+		if err == nil {
+			if err := configurePool(db, cfg); err != nil {
+				return nil, err
+			}
+			return db, nil
+		}
+		if attempt >= cfg.ConnectAttempts || isAuthError(err) {
+			return nil, err
+		}
+
+		log.Printf("Connecting to %s failed (attempt %d/%d), retrying in %s: %v", name, attempt, cfg.ConnectAttempts, wait, err)
+		time.Sleep(wait)
+		wait = min(wait*2, maxConnectRetryWait)
+	}
+}
+
+// configurePool applies the configured pool limits to db
+func configurePool(db *gorm.DB, cfg configs.DatabaseConfig) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(time.Duration(cfg.ConnMaxLifetimeMinutes) * time.Minute)
+	return nil
+}
+
+// isAuthError reports whether err is a Postgres invalid-authorization error (SQLSTATE class 28),
+// such as a wrong password
+func isAuthError(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && strings.HasPrefix(pgErr.Code, "28")
+}
+
+// migrationModels lists the models managed by AutoMigrate, in migration order
+var migrationModels = []struct {
+	name  string
+	model interface{}
+}{
+	// Core entities
+	{"User", &models.User{}},
+	{"RefreshToken", &models.RefreshToken{}},
+	{"LoginAttempt", &models.LoginAttempt{}},
+
+	// Organization entities (no foreign keys)
+	{"School", &models.School{}},
+	{"Department", &models.Department{}},
+	{"Position", &models.Position{}},
+	{"DataKaryawan", &models.DataKaryawan{}},
+
+	// Permission system (base models first)
+	{"Module", &models.Module{}},
+	{"Permission", &models.Permission{}},
+	{"Role", &models.Role{}},
+
+	// Junction tables and relationships
+	{"ModulePermission", &models.ModulePermission{}},
+	{"RolePermission", &models.RolePermission{}},
+	{"RoleHierarchy", &models.RoleHierarchy{}},
+	{"RoleModuleAccess", &models.RoleModuleAccess{}},
+	{"UserRole", &models.UserRole{}},
+	{"UserPosition", &models.UserPosition{}},
+	{"UserPermission", &models.UserPermission{}},
+	{"UserModuleAccess", &models.UserModuleAccess{}},
+
+	// System entities
+	{"ApiKey", &models.ApiKey{}},
+	{"AuditLog", &models.AuditLog{}},
+	{"AuditLogArchive", &models.AuditLogArchive{}},
+	{"AccessCheckLog", &models.AccessCheckLog{}},
+	{"EmailFailure", &models.EmailFailure{}},
+	{"Delegation", &models.Delegation{}},
+	{"FeatureFlag", &models.FeatureFlag{}},
+	{"FeatureFlagEvaluation", &models.FeatureFlagEvaluation{}},
+	{"SystemConfiguration", &models.SystemConfiguration{}},
+	{"Workflow", &models.Workflow{}},
+	{"BulkOperationProgress", &models.BulkOperationProgress{}},
+	{"WorkflowRule", &models.WorkflowRule{}},
+	{"WorkflowRuleStep", &models.WorkflowRuleStep{}},
+	{"WorkflowRuleVersion", &models.WorkflowRuleVersion{}},
+	{"WorkflowInstance", &models.WorkflowInstance{}},
+	{"WorkflowInstanceStep", &models.WorkflowInstanceStep{}},
+	{"WorkflowInstanceStepHistory", &models.WorkflowInstanceStepHistory{}},
+}
+
 // AutoMigrate runs database migrations for all models
 func AutoMigrate() error {
 	log.Println("Running database migrations...")
 
 	// Migrate models individually to isolate issues
-	models := []struct {
-		name  string
-		model interface{}
-	}{
-		// Core entities
-		{"User", &models.User{}},
-		{"RefreshToken", &models.RefreshToken{}},
-		{"LoginAttempt", &models.LoginAttempt{}},
-
-		// Organization entities (no foreign keys)
-		{"School", &models.School{}},
-		{"Department", &models.Department{}},
-		{"Position", &models.Position{}},
-		{"DataKaryawan", &models.DataKaryawan{}},
-
-		// Permission system (base models first)
-		{"Module", &models.Module{}},
-		{"Permission", &models.Permission{}},
-		{"Role", &models.Role{}},
-
-		// Junction tables and relationships
-		{"ModulePermission", &models.ModulePermission{}},
-		{"RolePermission", &models.RolePermission{}},
-		{"RoleHierarchy", &models.RoleHierarchy{}},
-		{"RoleModuleAccess", &models.RoleModuleAccess{}},
-		{"UserRole", &models.UserRole{}},
-		{"UserPosition", &models.UserPosition{}},
-		{"UserPermission", &models.UserPermission{}},
-		{"UserModuleAccess", &models.UserModuleAccess{}},
-
-		// System entities
-		{"ApiKey", &models.ApiKey{}},
-		{"AuditLog", &models.AuditLog{}},
-		{"Delegation", &models.Delegation{}},
-		{"FeatureFlag", &models.FeatureFlag{}},
-		{"FeatureFlagEvaluation", &models.FeatureFlagEvaluation{}},
-		{"SystemConfiguration", &models.SystemConfiguration{}},
-		{"Workflow", &models.Workflow{}},
-		{"BulkOperationProgress", &models.BulkOperationProgress{}},
-		{"WorkflowRule", &models.WorkflowRule{}},
-		{"WorkflowRuleStep", &models.WorkflowRuleStep{}},
-	}
-
-	for _, m := range models {
+	for _, m := range migrationModels {
 		log.Printf("Migrating %s...", m.name)
 		if err := DB.AutoMigrate(m.model); err != nil {
 			return fmt.Errorf("failed to migrate %s: %w", m.name, err)
@@ -108,3 +172,39 @@ func AutoMigrate() error {
 func GetDB() *gorm.DB {
 	return DB
 }
+
+// Close closes the read replica and primary connection pools
+func Close() error {
+	if err := closePool(replicaDB); err != nil {
+		return err
+	}
+	return closePool(DB)
+}
+
+// closePool closes the connection pool behind db, if any
+func closePool(db *gorm.DB) error {
+	if db == nil {
+		return nil
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+// PendingMigrations returns the models whose tables do not exist yet
+func PendingMigrations(ctx context.Context) ([]string, error) {
+	migrator := DB.WithContext(ctx).Migrator()
+
+	var pending []string
+	for _, m := range migrationModels {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if !migrator.HasTable(m.model) {
+			pending = append(pending, m.name)
+		}
+	}
+	return pending, nil
+}
@@ -159,6 +159,13 @@ var rbacIndexes = []RBACIndex{
 	},
 
 	// ===== Roles =====
+	{
+		// Same name as the uniqueIndex AutoMigrate creates, so it is only added when missing
+		Name:    "idx_public_roles_code",
+		Table:   "public.roles",
+		Columns: "code",
+		Unique:  true,
+	},
 	{
 		Name:    "idx_roles_hierarchy_level",
 		Table:   "public.roles",
@@ -172,6 +179,12 @@ var rbacIndexes = []RBACIndex{
 	},
 
 	// ===== Modules =====
+	{
+		Name:    "idx_public_modules_code",
+		Table:   "public.modules",
+		Columns: "code",
+		Unique:  true,
+	},
 	{
 		Name:    "idx_modules_code_active",
 		Table:   "public.modules",
@@ -194,6 +207,14 @@ var rbacIndexes = []RBACIndex{
 		Table:   "public.positions",
 		Columns: "school_id, department_id, is_active",
 	},
+
+	// ===== Audit Logs =====
+	{
+		// Recent access changes of one user (/access/my-changes)
+		Name:    "idx_audit_logs_target_user_created",
+		Table:   "public.audit_logs",
+		Columns: "target_user_id, created_at",
+	},
 }
 
 // MigrateRBACIndexes creates all RBAC performance indexes
@@ -0,0 +1,117 @@
+package database
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Operation pins a statement to the primary or the read replica, the way dbresolver's
+// Write and Read clauses do:
+//
+//	db.Clauses(database.Write).First(&user)
+//
+// Without a replica configured both are no-ops.
+type Operation string
+
+const (
+	// Write keeps a read on the primary; use it when the result feeds a write or must
+	// reflect a write that was just made
+	Write Operation = "write"
+	// Read sends a statement to the replica even where the resolver would not, such as a
+	// raw WITH query or a read-only transaction begun from it
+	Read Operation = "read"
+)
+
+const (
+	// operationKey is the statement setting an Operation is stored under
+	operationKey = "database:resolver_operation"
+	// resolverCallback is the name the routing callback is registered under
+	resolverCallback = "database:read_resolver"
+)
+
+// ModifyStatement records the operation on the statement and routes it right away, so a
+// transaction begun from it, as in db.Clauses(database.Read).Transaction(fn), runs on the
+// chosen connection. Write wins over Read: code handed a session from Primary stays on the
+// primary even where it asks for Read.
+func (op Operation) ModifyStatement(stmt *gorm.Statement) {
+	if current, _ := stmt.Settings.Load(operationKey); current == Write {
+		return
+	}
+	stmt.Settings.Store(operationKey, op)
+	if route := stmt.DB.Callback().Query().Get(resolverCallback); route != nil {
+		route(stmt.DB)
+	}
+}
+
+// Build implements clause.Expression; an operation adds nothing to the SQL
+func (op Operation) Build(clause.Builder) {}
+
+// Primary returns a session on db whose statements all run on the primary, for code where
+// nearly every read feeds a write or must see one, such as the auth handlers
+func Primary(db *gorm.DB) *gorm.DB {
+	return db.Clauses(Write).Session(&gorm.Session{})
+}
+
+// readResolver is a gorm plugin that sends SELECTs to a read replica and leaves everything
+// else on the primary, following gorm.io/plugin/dbresolver:
+//   - statements inside a transaction stay on the transaction's connection
+//   - locking reads (FOR UPDATE/SHARE) and reads marked Write stay on the primary
+//   - raw SQL goes to the replica only when it is a SELECT without FOR UPDATE, or is marked Read
+type readResolver struct {
+	primary gorm.ConnPool
+	replica gorm.ConnPool
+}
+
+// Name implements gorm.Plugin
+func (r *readResolver) Name() string {
+	return resolverCallback
+}
+
+// Initialize registers the routing callback ahead of gorm's query and row callbacks
+func (r *readResolver) Initialize(db *gorm.DB) error {
+	r.primary = db.ConnPool
+	if err := db.Callback().Query().Before("gorm:query").Register(resolverCallback, r.route); err != nil {
+		return err
+	}
+	return db.Callback().Row().Before("gorm:row").Register(resolverCallback, r.route)
+}
+
+// route picks the pool a statement runs on. Statements on any other connection, such as a
+// transaction, are left where they are.
+func (r *readResolver) route(db *gorm.DB) {
+	stmt := db.Statement
+	if db.Error != nil || (stmt.ConnPool != r.primary && stmt.ConnPool != r.replica) {
+		return
+	}
+	if readsFromReplica(stmt) {
+		stmt.ConnPool = r.replica
+	} else {
+		stmt.ConnPool = r.primary
+	}
+}
+
+// readsFromReplica reports whether stmt may be served by the replica
+func readsFromReplica(stmt *gorm.Statement) bool {
+	op, _ := stmt.Settings.Load(operationKey)
+	switch op {
+	case Write:
+		return false
+	case Read:
+		return true
+	}
+
+	if rawSQL := strings.TrimSpace(stmt.SQL.String()); rawSQL != "" {
+		return isSelectSQL(rawSQL)
+	}
+	_, locking := stmt.Clauses["FOR"]
+	return !locking
+}
+
+// isSelectSQL reports whether raw SQL is a plain SELECT that does not lock rows
+func isSelectSQL(rawSQL string) bool {
+	lower := strings.ToLower(rawSQL)
+	return strings.HasPrefix(lower, "select") &&
+		!strings.HasSuffix(lower, "for update") && !strings.HasSuffix(lower, "for share")
+}
@@ -0,0 +1,96 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+)
+
+// namedPool is a gorm.ConnPool that fails every statement with its own name, so a test can
+// tell which pool a statement was sent to
+type namedPool string
+
+func (p namedPool) PrepareContext(context.Context, string) (*sql.Stmt, error) {
+	return nil, errors.New(string(p))
+}
+
+func (p namedPool) ExecContext(context.Context, string, ...interface{}) (sql.Result, error) {
+	return nil, errors.New(string(p))
+}
+
+func (p namedPool) QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error) {
+	return nil, errors.New(string(p))
+}
+
+func (p namedPool) QueryRowContext(context.Context, string, ...interface{}) *sql.Row {
+	return nil
+}
+
+type resolverRow struct {
+	ID string
+}
+
+func TestReadResolverRouting(t *testing.T) {
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: namedPool("primary")}), &gorm.Config{
+		Logger:                 logger.Discard,
+		SkipDefaultTransaction: true,
+	})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	if err := db.Use(&readResolver{replica: namedPool("replica")}); err != nil {
+		t.Fatalf("register resolver: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		run  func(db *gorm.DB) error
+		want string
+	}{
+		{"find", func(db *gorm.DB) error {
+			return db.Find(&[]resolverRow{}).Error
+		}, "replica"},
+		{"find marked write", func(db *gorm.DB) error {
+			return db.Clauses(Write).Find(&[]resolverRow{}).Error
+		}, "primary"},
+		{"locking read", func(db *gorm.DB) error {
+			return db.Clauses(clause.Locking{Strength: "UPDATE"}).Find(&[]resolverRow{}).Error
+		}, "primary"},
+		{"primary session", func(db *gorm.DB) error {
+			return Primary(db).Find(&[]resolverRow{}).Error
+		}, "primary"},
+		{"primary session marked read", func(db *gorm.DB) error {
+			return Primary(db).Clauses(Read).Find(&[]resolverRow{}).Error
+		}, "primary"},
+		{"raw select", func(db *gorm.DB) error {
+			return db.Raw("SELECT id FROM resolver_rows").Scan(&[]resolverRow{}).Error
+		}, "replica"},
+		{"raw select for update", func(db *gorm.DB) error {
+			return db.Raw("SELECT id FROM resolver_rows FOR UPDATE").Scan(&[]resolverRow{}).Error
+		}, "primary"},
+		{"raw with", func(db *gorm.DB) error {
+			return db.Raw("WITH r AS (SELECT id FROM resolver_rows) SELECT id FROM r").Scan(&[]resolverRow{}).Error
+		}, "primary"},
+		{"raw with marked read", func(db *gorm.DB) error {
+			return db.Clauses(Read).Raw("WITH r AS (SELECT id FROM resolver_rows) SELECT id FROM r").Scan(&[]resolverRow{}).Error
+		}, "replica"},
+		{"create", func(db *gorm.DB) error {
+			return db.Create(&resolverRow{ID: "1"}).Error
+		}, "primary"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.run(db)
+			if err == nil || err.Error() != tt.want {
+				t.Fatalf("statement ran on %v, want %s", err, tt.want)
+			}
+		})
+	}
+}
@@ -1,7 +1,17 @@
 package email
 
 import (
+	"log/slog"
 	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Email providers selectable via EMAIL_PROVIDER
+const (
+	ProviderSMTP     = "smtp"
+	ProviderSendGrid = "sendgrid"
 )
 
 // SMTPConfig holds SMTP configuration
@@ -28,6 +38,46 @@ func GetSMTPConfig() *SMTPConfig {
 	}
 }
 
+// SendGridConfig holds SendGrid (HTTP API) configuration
+type SendGridConfig struct {
+	APIKey   string
+	From     string
+	Endpoint string
+}
+
+// GetSendGridConfig returns SendGrid configuration
+// IMPORTANT: The API key MUST be set via environment variables
+func GetSendGridConfig() *SendGridConfig {
+	return &SendGridConfig{
+		APIKey:   getEnv("SENDGRID_API_KEY", ""),
+		From:     getEnv("EMAIL_FROM", getEnv("SMTP_FROM", "noreply@gloriaschool.org")),
+		Endpoint: getEnv("SENDGRID_API_URL", "https://api.sendgrid.com/v3/mail/send"),
+	}
+}
+
+// QueueConfig holds email queue configuration
+type QueueConfig struct {
+	Workers     int           // number of concurrent senders
+	BufferSize  int           // emails that can wait in the queue
+	MaxAttempts int           // delivery attempts before an email is recorded as failed
+	BaseBackoff time.Duration // wait before the first retry; doubles on each retry
+}
+
+// GetQueueConfig returns email queue configuration
+func GetQueueConfig() *QueueConfig {
+	return &QueueConfig{
+		Workers:     getEnvInt("EMAIL_QUEUE_WORKERS", 2),
+		BufferSize:  getEnvInt("EMAIL_QUEUE_SIZE", 100),
+		MaxAttempts: getEnvInt("EMAIL_MAX_ATTEMPTS", 5),
+		BaseBackoff: time.Duration(getEnvInt("EMAIL_RETRY_BACKOFF_SECONDS", 2)) * time.Second,
+	}
+}
+
+// GetEmailProvider returns the configured email provider name (smtp or sendgrid)
+func GetEmailProvider() string {
+	return strings.ToLower(strings.TrimSpace(getEnv("EMAIL_PROVIDER", ProviderSMTP)))
+}
+
 // GetDevelopmentEmail returns the email for development environment
 // All emails in development will be sent to this address
 func GetDevelopmentEmail() string {
@@ -46,3 +96,13 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil && parsed > 0 {
+			return parsed
+		}
+		slog.Warn("invalid email config value, using default", "key", key, "default", defaultValue)
+	}
+	return defaultValue
+}
@@ -0,0 +1,61 @@
+package email
+
+import (
+	"time"
+)
+
+// ProviderDiagnostics describes a provider's connection settings. It never contains credentials.
+type ProviderDiagnostics struct {
+	Provider       string `json:"provider"`
+	Host           string `json:"host,omitempty"`
+	Port           string `json:"port,omitempty"`
+	Encryption     string `json:"encryption,omitempty"`
+	Endpoint       string `json:"endpoint,omitempty"`
+	From           string `json:"from"`
+	AuthConfigured bool   `json:"auth_configured"`
+}
+
+// diagnosable is implemented by providers that can describe their configuration
+type diagnosable interface {
+	Diagnostics() ProviderDiagnostics
+}
+
+// TestEmailResult represents the outcome of a test email
+type TestEmailResult struct {
+	Success     bool                `json:"success"`
+	Recipient   string              `json:"recipient"`
+	DurationMs  int64               `json:"duration_ms"`
+	Error       string              `json:"error,omitempty"`
+	Diagnostics ProviderDiagnostics `json:"diagnostics"`
+}
+
+// SendTestEmail sends a test message to the given address synchronously, bypassing the
+// queue and the development redirect, using the provider built from the current configuration
+func SendTestEmail(toEmail string) *TestEmailResult {
+	provider := NewProvider()
+
+	result := &TestEmailResult{Recipient: toEmail}
+	if d, ok := provider.(diagnosable); ok {
+		result.Diagnostics = d.Diagnostics()
+	}
+
+	htmlBody, textBody, err := renderEmail(templateTestEmail, templateData{
+		Provider: result.Diagnostics.Provider,
+		SentAt:   time.Now().Format(time.RFC1123Z),
+	})
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	start := time.Now()
+	err = provider.Send(toEmail, "Gloria School Test Email", htmlBody, textBody)
+	result.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Success = true
+	return result
+}
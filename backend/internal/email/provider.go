@@ -0,0 +1,22 @@
+package email
+
+import "log/slog"
+
+// EmailProvider delivers a single email. textBody is the plain-text alternative
+// of htmlBody and may be empty.
+type EmailProvider interface {
+	Send(to, subject, htmlBody, textBody string) error
+}
+
+// NewProvider returns the provider selected by EMAIL_PROVIDER, defaulting to SMTP
+func NewProvider() EmailProvider {
+	switch provider := GetEmailProvider(); provider {
+	case ProviderSendGrid:
+		return NewSendGridProvider(GetSendGridConfig())
+	case ProviderSMTP, "":
+		return NewSMTPProvider(GetSMTPConfig())
+	default:
+		slog.Warn("unknown EMAIL_PROVIDER, falling back to smtp", "provider", provider)
+		return NewSMTPProvider(GetSMTPConfig())
+	}
+}
@@ -0,0 +1,173 @@
+package email
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrQueueFull is returned when the queue buffer has no room for another email
+	ErrQueueFull = errors.New("email queue is full")
+	// ErrQueueStopped is returned when an email is enqueued after Stop
+	ErrQueueStopped = errors.New("email queue is stopped")
+)
+
+// defaultQueue is used by NewEmailSender once InitQueue has been called
+var defaultQueue *Queue
+
+// queuedEmail is a rendered email waiting for delivery
+type queuedEmail struct {
+	to       string
+	subject  string
+	template string
+	htmlBody string
+	textBody string
+}
+
+// Queue delivers emails from a buffered channel with a pool of workers, retrying failed
+// sends with exponential backoff and recording emails that still fail as EmailFailure rows
+type Queue struct {
+	provider EmailProvider
+	db       *gorm.DB
+	config   *QueueConfig
+
+	jobs     chan queuedEmail
+	quit     chan struct{}
+	wg       sync.WaitGroup
+	stopOnce sync.Once
+}
+
+// NewQueue creates a new email queue. db may be nil, in which case permanent
+// failures are only logged.
+func NewQueue(provider EmailProvider, db *gorm.DB, config *QueueConfig) *Queue {
+	return &Queue{
+		provider: provider,
+		db:       db,
+		config:   config,
+		jobs:     make(chan queuedEmail, config.BufferSize),
+		quit:     make(chan struct{}),
+	}
+}
+
+// InitQueue starts the default email queue using the configured provider.
+// Emails sent through NewEmailSender are delivered by this queue afterwards.
+func InitQueue(db *gorm.DB) *Queue {
+	queue := NewQueue(NewProvider(), db, GetQueueConfig())
+	queue.Start()
+	defaultQueue = queue
+	return queue
+}
+
+// Start launches the worker goroutines
+func (q *Queue) Start() {
+	for i := 0; i < q.config.Workers; i++ {
+		q.wg.Add(1)
+		go q.worker()
+	}
+}
+
+// Stop stops accepting emails, gives every queued email one last delivery attempt
+// and waits for the workers to exit
+func (q *Queue) Stop() {
+	q.stopOnce.Do(func() {
+		close(q.quit)
+		q.wg.Wait()
+	})
+}
+
+// Enqueue queues an email for delivery without blocking
+func (q *Queue) Enqueue(to, subject, template, htmlBody, textBody string) error {
+	select {
+	case <-q.quit:
+		return ErrQueueStopped
+	default:
+	}
+
+	job := queuedEmail{to: to, subject: subject, template: template, htmlBody: htmlBody, textBody: textBody}
+	select {
+	case q.jobs <- job:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+func (q *Queue) worker() {
+	defer q.wg.Done()
+
+	for {
+		select {
+		case job := <-q.jobs:
+			q.deliver(job)
+		case <-q.quit:
+			// Drain what is left; retries are cut short by quit
+			for {
+				select {
+				case job := <-q.jobs:
+					q.deliver(job)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// deliver sends an email, retrying with exponential backoff up to MaxAttempts
+func (q *Queue) deliver(job queuedEmail) {
+	backoff := q.config.BaseBackoff
+	for attempt := 1; ; attempt++ {
+		err := q.provider.Send(job.to, job.subject, job.htmlBody, job.textBody)
+		if err == nil {
+			return
+		}
+
+		if attempt >= q.config.MaxAttempts {
+			q.recordFailure(job, attempt, err)
+			return
+		}
+
+		slog.Warn("email send failed, retrying",
+			"template", job.template, "attempt", attempt, "max_attempts", q.config.MaxAttempts, "backoff", backoff, "error", err)
+
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+		case <-q.quit:
+			q.recordFailure(job, attempt, fmt.Errorf("%w (retry cancelled by shutdown)", err))
+			return
+		}
+	}
+}
+
+// recordFailure persists an email that could not be delivered
+func (q *Queue) recordFailure(job queuedEmail, attempts int, err error) {
+	failureID := uuid.New().String()
+	slog.Error("email send failed permanently",
+		"template", job.template, "attempts", attempts, "failure_id", failureID, "error", err)
+
+	if q.db == nil {
+		return
+	}
+
+	failure := models.EmailFailure{
+		ID:        failureID,
+		Recipient: job.to,
+		Subject:   job.subject,
+		Template:  job.template,
+		Attempts:  attempts,
+		LastError: err.Error(),
+		CreatedAt: time.Now(),
+	}
+	if dbErr := q.db.Create(&failure).Error; dbErr != nil {
+		slog.Error("failed to record email failure", "template", job.template, "failure_id", failureID, "error", dbErr)
+	}
+}
@@ -1,226 +1,105 @@
 package email
 
 import (
-	"crypto/tls"
 	"fmt"
-	"net/smtp"
-	"strings"
+	"log/slog"
 )
 
-// EmailSender handles sending emails
+// EmailSender renders application emails and hands them to the queue, or directly
+// to an EmailProvider when no queue is set
 type EmailSender struct {
-	config *SMTPConfig
+	provider EmailProvider
+	queue    *Queue
 }
 
-// NewEmailSender creates a new email sender
+// NewEmailSender creates a new email sender using the provider selected by EMAIL_PROVIDER.
+// Once InitQueue has been called, emails are delivered through the default queue.
 func NewEmailSender() *EmailSender {
-	return &EmailSender{
-		config: GetSMTPConfig(),
+	if defaultQueue != nil {
+		return &EmailSender{provider: defaultQueue.provider, queue: defaultQueue}
 	}
+	return NewEmailSenderWithProvider(NewProvider())
 }
 
-// SendWelcomeEmail sends a welcome email after successful registration
-func (s *EmailSender) SendWelcomeEmail(toEmail, name string) error {
-	// In development, override recipient email
-	recipient := toEmail
-	if IsDevelopment() {
-		recipient = GetDevelopmentEmail()
+// NewEmailSenderWithProvider creates a new email sender that sends synchronously through the given provider
+func NewEmailSenderWithProvider(provider EmailProvider) *EmailSender {
+	return &EmailSender{
+		provider: provider,
 	}
-
-	subject := "Selamat Datang di Gloria School"
-	body := s.buildWelcomeEmailBody(toEmail, name)
-
-	return s.sendEmail(recipient, subject, body)
 }
 
-// buildWelcomeEmailBody creates the HTML email body for welcome email
-func (s *EmailSender) buildWelcomeEmailBody(originalEmail, name string) string {
-	devNote := ""
-	if IsDevelopment() {
-		devNote = fmt.Sprintf(`
-		<div style="background-color: #FEF3C7; border: 1px solid #F59E0B; padding: 12px; margin-bottom: 20px; border-radius: 4px;">
-			<strong>Development Mode:</strong> This email was intended for <strong>%s</strong> but sent to development inbox.
-		</div>
-		`, originalEmail)
-	}
-
-	loginURL := "http://localhost:3000/login"
-
-	return fmt.Sprintf(`
-<!DOCTYPE html>
-<html>
-<head>
-	<meta charset="UTF-8">
-	<title>Selamat Datang</title>
-</head>
-<body style="font-family: Arial, sans-serif; line-height: 1.6; color: #333; max-width: 600px; margin: 0 auto; padding: 20px;">
-	%s
-	<div style="background-color: #f4f4f4; padding: 20px; border-radius: 5px;">
-		<h2 style="color: #2563EB;">Selamat Datang di Gloria School! 🎉</h2>
-		<p>Halo <strong>%s</strong>,</p>
-		<p>Akun Anda telah berhasil dibuat. Anda sekarang dapat mengakses sistem Gloria School menggunakan email dan password yang telah Anda daftarkan.</p>
-		<div style="text-align: center; margin: 30px 0;">
-			<a href="%s" style="background-color: #2563EB; color: white; padding: 12px 30px; text-decoration: none; border-radius: 5px; display: inline-block;">Masuk ke Sistem</a>
-		</div>
-		<p style="font-size: 14px; color: #666;">Jika Anda mengalami kesulitan, silakan hubungi administrator.</p>
-		<hr style="border: none; border-top: 1px solid #ddd; margin: 20px 0;">
-		<p style="font-size: 12px; color: #999;">
-			Gloria School<br>
-			Email: support@gloriaschool.org
-		</p>
-	</div>
-</body>
-</html>
-	`, devNote, name, loginURL)
+// SendWelcomeEmail sends a welcome email after successful registration
+func (s *EmailSender) SendWelcomeEmail(toEmail, name string) error {
+	return s.send(toEmail, "Selamat Datang di Gloria School", templateWelcome, templateData{
+		Name: name,
+		URL:  "http://localhost:3000/login",
+	})
 }
 
 // SendPasswordResetEmail sends a password reset email
 func (s *EmailSender) SendPasswordResetEmail(toEmail, resetToken string) error {
-	// In development, override recipient email
-	recipient := toEmail
-	if IsDevelopment() {
-		recipient = GetDevelopmentEmail()
-	}
-
 	// Build reset URL - this will be the frontend URL
 	resetURL := fmt.Sprintf("http://localhost:3000/reset-password?token=%s", resetToken)
 
-	subject := "Password Reset Request"
-	body := s.buildPasswordResetEmailBody(toEmail, resetURL)
-
-	return s.sendEmail(recipient, subject, body)
+	return s.send(toEmail, "Password Reset Request", templatePasswordReset, templateData{
+		URL: resetURL,
+	})
 }
 
-// buildPasswordResetEmailBody creates the HTML email body for password reset
-func (s *EmailSender) buildPasswordResetEmailBody(originalEmail, resetURL string) string {
-	devNote := ""
-	if IsDevelopment() {
-		devNote = fmt.Sprintf(`
-		<div style="background-color: #FEF3C7; border: 1px solid #F59E0B; padding: 12px; margin-bottom: 20px; border-radius: 4px;">
-			<strong>Development Mode:</strong> This email was requested for <strong>%s</strong> but sent to development inbox.
-		</div>
-		`, originalEmail)
-	}
+// SendPasswordSetupEmail sends an account invitation with a link to set the initial password
+func (s *EmailSender) SendPasswordSetupEmail(toEmail, name, setupToken string) error {
+	// Setup uses the same frontend page as password reset
+	setupURL := fmt.Sprintf("http://localhost:3000/reset-password?token=%s", setupToken)
 
-	return fmt.Sprintf(`
-<!DOCTYPE html>
-<html>
-<head>
-	<meta charset="UTF-8">
-	<title>Password Reset</title>
-</head>
-<body style="font-family: Arial, sans-serif; line-height: 1.6; color: #333; max-width: 600px; margin: 0 auto; padding: 20px;">
-	%s
-	<div style="background-color: #f4f4f4; padding: 20px; border-radius: 5px;">
-		<h2 style="color: #2563EB;">Password Reset Request</h2>
-		<p>You have requested to reset your password for your Gloria School account.</p>
-		<p>Click the button below to reset your password:</p>
-		<div style="text-align: center; margin: 30px 0;">
-			<a href="%s" style="background-color: #2563EB; color: white; padding: 12px 30px; text-decoration: none; border-radius: 5px; display: inline-block;">Reset Password</a>
-		</div>
-		<p style="font-size: 14px; color: #666;">Or copy and paste this link in your browser:</p>
-		<p style="font-size: 12px; word-break: break-all; background-color: #fff; padding: 10px; border: 1px solid #ddd; border-radius: 3px;">%s</p>
-		<hr style="border: none; border-top: 1px solid #ddd; margin: 20px 0;">
-		<p style="font-size: 12px; color: #999;">
-			This link will expire in 1 hour. If you didn't request this password reset, please ignore this email.
-		</p>
-		<p style="font-size: 12px; color: #999;">
-			Gloria School<br>
-			Email: support@gloriaschool.org
-		</p>
-	</div>
-</body>
-</html>
-	`, devNote, resetURL, resetURL)
+	return s.send(toEmail, "Aktivasi Akun Gloria School", templatePasswordSetup, templateData{
+		Name: name,
+		URL:  setupURL,
+	})
 }
 
-// sendEmail sends an email using SMTP
-func (s *EmailSender) sendEmail(to, subject, htmlBody string) error {
-	// Build email message
-	headers := make(map[string]string)
-	headers["From"] = s.config.From
-	headers["To"] = to
-	headers["Subject"] = subject
-	headers["MIME-Version"] = "1.0"
-	headers["Content-Type"] = "text/html; charset=UTF-8"
-
-	message := ""
-	for k, v := range headers {
-		message += fmt.Sprintf("%s: %s\r\n", k, v)
-	}
-	message += "\r\n" + htmlBody
-
-	// Setup authentication
-	auth := smtp.PlainAuth(
-		"",
-		s.config.Username,
-		s.config.Password,
-		s.config.Host,
-	)
-
-	// Connect to SMTP server
-	addr := fmt.Sprintf("%s:%s", s.config.Host, s.config.Port)
-
-	// Handle TLS encryption
-	if s.config.Encryption == "tls" || s.config.Encryption == "starttls" {
-		// Use STARTTLS
-		return s.sendEmailWithStartTLS(addr, auth, s.config.From, []string{to}, []byte(message))
-	}
+// SendApprovalPendingEmail notifies a position holder that a workflow instance awaits their approval
+func (s *EmailSender) SendApprovalPendingEmail(toEmail, workflowType, instanceID string) error {
+	// Build instance URL - this will be the frontend URL
+	instanceURL := fmt.Sprintf("http://localhost:3000/workflow-instances/%s", instanceID)
 
-	// Use plain SMTP (not recommended for production)
-	return smtp.SendMail(addr, auth, s.config.From, []string{to}, []byte(message))
+	return s.send(toEmail, fmt.Sprintf("Persetujuan %s Menunggu Anda", workflowType), templateApprovalPending, templateData{
+		WorkflowType: workflowType,
+		URL:          instanceURL,
+	})
 }
 
-// sendEmailWithStartTLS sends email with STARTTLS encryption
-func (s *EmailSender) sendEmailWithStartTLS(addr string, auth smtp.Auth, from string, to []string, msg []byte) error {
-	// Parse host from addr
-	host := strings.Split(addr, ":")[0]
-
-	// Connect to server
-	client, err := smtp.Dial(addr)
-	if err != nil {
-		return fmt.Errorf("failed to connect to SMTP server: %w", err)
-	}
-	defer client.Close()
-
-	// Start TLS
-	tlsConfig := &tls.Config{
-		ServerName:         host,
-		InsecureSkipVerify: false,
-	}
-
-	if err = client.StartTLS(tlsConfig); err != nil {
-		return fmt.Errorf("failed to start TLS: %w", err)
-	}
-
-	// Authenticate
-	if err = client.Auth(auth); err != nil {
-		return fmt.Errorf("failed to authenticate: %w", err)
-	}
-
-	// Set sender
-	if err = client.Mail(from); err != nil {
-		return fmt.Errorf("failed to set sender: %w", err)
-	}
+// SendWorkflowEscalationEmail notifies a user that a workflow approval step passed its SLA timeout
+func (s *EmailSender) SendWorkflowEscalationEmail(toEmail, workflowType, stepName, note string) error {
+	return s.send(toEmail, fmt.Sprintf("Eskalasi Persetujuan %s", workflowType), templateWorkflowEscalation, templateData{
+		WorkflowType: workflowType,
+		StepName:     stepName,
+		Note:         note,
+		URL:          "http://localhost:3000/workflow-instances",
+	})
+}
 
-	// Set recipients
-	for _, recipient := range to {
-		if err = client.Rcpt(recipient); err != nil {
-			return fmt.Errorf("failed to set recipient: %w", err)
-		}
+// send renders the template and queues it, or delivers it through the provider when
+// there is no queue or the queue cannot take it.
+// In development, the email is redirected to the development inbox.
+func (s *EmailSender) send(toEmail, subject, templateName string, data templateData) error {
+	recipient := toEmail
+	if IsDevelopment() {
+		recipient = GetDevelopmentEmail()
+		data.DevRecipient = toEmail
 	}
 
-	// Send message
-	writer, err := client.Data()
+	htmlBody, textBody, err := renderEmail(templateName, data)
 	if err != nil {
-		return fmt.Errorf("failed to get data writer: %w", err)
+		return err
 	}
-	defer writer.Close()
 
-	_, err = writer.Write(msg)
-	if err != nil {
-		return fmt.Errorf("failed to write message: %w", err)
+	if s.queue != nil {
+		err := s.queue.Enqueue(recipient, subject, templateName, htmlBody, textBody)
+		if err == nil {
+			return nil
+		}
+		slog.Warn("email queue unavailable, sending directly", "template", templateName, "error", err)
 	}
 
-	return nil
+	return s.provider.Send(recipient, subject, htmlBody, textBody)
 }
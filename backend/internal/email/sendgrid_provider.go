@@ -0,0 +1,106 @@
+package email
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// SendGridProvider sends emails through the SendGrid v3 mail send HTTP API
+type SendGridProvider struct {
+	config *SendGridConfig
+	client *http.Client
+}
+
+// NewSendGridProvider creates a new SendGrid provider
+func NewSendGridProvider(config *SendGridConfig) *SendGridProvider {
+	return &SendGridProvider{
+		config: config,
+		client: &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+type sendGridAddress struct {
+	Email string `json:"email"`
+}
+
+type sendGridContent struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type sendGridPersonalization struct {
+	To []sendGridAddress `json:"to"`
+}
+
+type sendGridRequest struct {
+	Personalizations []sendGridPersonalization `json:"personalizations"`
+	From             sendGridAddress           `json:"from"`
+	Subject          string                    `json:"subject"`
+	Content          []sendGridContent         `json:"content"`
+}
+
+// Diagnostics returns the SendGrid API settings without the API key
+func (p *SendGridProvider) Diagnostics() ProviderDiagnostics {
+	endpoint := p.config.Endpoint
+	if parsed, err := url.Parse(endpoint); err == nil {
+		parsed.User, parsed.RawQuery = nil, ""
+		endpoint = parsed.String()
+	}
+	return ProviderDiagnostics{
+		Provider:       ProviderSendGrid,
+		Endpoint:       endpoint,
+		Encryption:     "https",
+		From:           p.config.From,
+		AuthConfigured: p.config.APIKey != "",
+	}
+}
+
+// Send sends an email using the SendGrid API
+func (p *SendGridProvider) Send(to, subject, htmlBody, textBody string) error {
+	if p.config.APIKey == "" {
+		return errors.New("SENDGRID_API_KEY is not configured")
+	}
+
+	payload := sendGridRequest{
+		Personalizations: []sendGridPersonalization{{To: []sendGridAddress{{Email: to}}}},
+		From:             sendGridAddress{Email: p.config.From},
+		Subject:          subject,
+	}
+
+	// SendGrid requires text/plain to precede text/html
+	if textBody != "" {
+		payload.Content = append(payload.Content, sendGridContent{Type: "text/plain", Value: textBody})
+	}
+	payload.Content = append(payload.Content, sendGridContent{Type: "text/html", Value: htmlBody})
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode email request: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, p.config.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build email request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+p.config.APIKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call SendGrid API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("SendGrid API returned %s: %s", resp.Status, bytes.TrimSpace(detail))
+	}
+
+	return nil
+}
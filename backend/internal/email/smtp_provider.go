@@ -0,0 +1,189 @@
+package email
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// smtpDialTimeout bounds how long connecting to the SMTP server may take
+const smtpDialTimeout = 10 * time.Second
+
+// SMTPProvider sends emails through an SMTP server
+type SMTPProvider struct {
+	config *SMTPConfig
+}
+
+// NewSMTPProvider creates a new SMTP provider
+func NewSMTPProvider(config *SMTPConfig) *SMTPProvider {
+	return &SMTPProvider{config: config}
+}
+
+// Send sends an email using SMTP
+func (p *SMTPProvider) Send(to, subject, htmlBody, textBody string) error {
+	message, err := buildMIMEMessage(p.config.From, to, subject, htmlBody, textBody)
+	if err != nil {
+		return err
+	}
+
+	// Setup authentication
+	auth := smtp.PlainAuth(
+		"",
+		p.config.Username,
+		p.config.Password,
+		p.config.Host,
+	)
+
+	// Connect to SMTP server
+	addr := fmt.Sprintf("%s:%s", p.config.Host, p.config.Port)
+
+	// Handle TLS encryption
+	if p.config.Encryption == "tls" || p.config.Encryption == "starttls" {
+		// Use STARTTLS
+		return p.sendWithStartTLS(addr, auth, p.config.From, []string{to}, message)
+	}
+
+	// Use plain SMTP (not recommended for production)
+	return smtp.SendMail(addr, auth, p.config.From, []string{to}, message)
+}
+
+// Diagnostics returns the SMTP connection settings without credentials
+func (p *SMTPProvider) Diagnostics() ProviderDiagnostics {
+	return ProviderDiagnostics{
+		Provider:       ProviderSMTP,
+		Host:           p.config.Host,
+		Port:           p.config.Port,
+		Encryption:     p.config.Encryption,
+		From:           p.config.From,
+		AuthConfigured: p.config.Username != "" && p.config.Password != "",
+	}
+}
+
+// buildMIMEMessage builds an RFC 5322 message: text/html only, or multipart/alternative
+// when a plain-text body is given
+func buildMIMEMessage(from, to, subject, htmlBody, textBody string) ([]byte, error) {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", from)
+	fmt.Fprintf(&msg, "To: %s\r\n", to)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("UTF-8", subject))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+
+	if textBody == "" {
+		msg.WriteString("Content-Type: text/html; charset=UTF-8\r\n")
+		msg.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+		if err := writeQuotedPrintable(&msg, htmlBody); err != nil {
+			return nil, err
+		}
+		return msg.Bytes(), nil
+	}
+
+	var parts bytes.Buffer
+	writer := multipart.NewWriter(&parts)
+	fmt.Fprintf(&msg, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", writer.Boundary())
+
+	// Plain text first: clients show the last part they support
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=UTF-8", textBody},
+		{"text/html; charset=UTF-8", htmlBody},
+	} {
+		w, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to build email: %w", err)
+		}
+		if err := writeQuotedPrintable(w, part.body); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to build email: %w", err)
+	}
+
+	msg.Write(parts.Bytes())
+	return msg.Bytes(), nil
+}
+
+// writeQuotedPrintable writes body to w in quoted-printable encoding
+func writeQuotedPrintable(w interface{ Write([]byte) (int, error) }, body string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(body)); err != nil {
+		return fmt.Errorf("failed to encode email body: %w", err)
+	}
+	if err := qp.Close(); err != nil {
+		return fmt.Errorf("failed to encode email body: %w", err)
+	}
+	return nil
+}
+
+// sendWithStartTLS sends email with STARTTLS encryption
+func (p *SMTPProvider) sendWithStartTLS(addr string, auth smtp.Auth, from string, to []string, msg []byte) error {
+	// Parse host from addr
+	host := strings.Split(addr, ":")[0]
+
+	// Connect to server
+	conn, err := net.DialTimeout("tcp", addr, smtpDialTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	defer client.Close()
+
+	// Start TLS
+	tlsConfig := &tls.Config{
+		ServerName:         host,
+		InsecureSkipVerify: false,
+	}
+
+	if err = client.StartTLS(tlsConfig); err != nil {
+		return fmt.Errorf("failed to start TLS: %w", err)
+	}
+
+	// Authenticate
+	if err = client.Auth(auth); err != nil {
+		return fmt.Errorf("failed to authenticate: %w", err)
+	}
+
+	// Set sender
+	if err = client.Mail(from); err != nil {
+		return fmt.Errorf("failed to set sender: %w", err)
+	}
+
+	// Set recipients
+	for _, recipient := range to {
+		if err = client.Rcpt(recipient); err != nil {
+			return fmt.Errorf("failed to set recipient: %w", err)
+		}
+	}
+
+	// Send message
+	writer, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("failed to get data writer: %w", err)
+	}
+
+	if _, err = writer.Write(msg); err != nil {
+		writer.Close()
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+
+	// Closing the data writer submits the message; the server may still reject it
+	if err = writer.Close(); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+
+	return client.Quit()
+}
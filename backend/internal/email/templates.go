@@ -0,0 +1,71 @@
+package email
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	texttemplate "text/template"
+)
+
+//go:embed templates/*.html templates/*.txt
+var templateFS embed.FS
+
+// Email template names; each has a .html and a .txt file under templates/
+const (
+	templateWelcome            = "welcome"
+	templatePasswordReset      = "password_reset"
+	templatePasswordSetup      = "password_setup"
+	templateApprovalPending    = "approval_pending"
+	templateWorkflowEscalation = "workflow_escalation"
+	templateTestEmail          = "test_email"
+)
+
+// templateData holds the values available to email templates
+type templateData struct {
+	DevRecipient string // original recipient, shown when development mode redirects the email
+	Name         string
+	URL          string
+	WorkflowType string
+	StepName     string
+	Note         string
+	Provider     string
+	SentAt       string
+}
+
+var (
+	htmlTemplates = map[string]*htmltemplate.Template{}
+	textTemplates = map[string]*texttemplate.Template{}
+)
+
+func init() {
+	for _, name := range []string{
+		templateWelcome,
+		templatePasswordReset,
+		templatePasswordSetup,
+		templateApprovalPending,
+		templateWorkflowEscalation,
+		templateTestEmail,
+	} {
+		htmlTemplates[name] = htmltemplate.Must(htmltemplate.ParseFS(templateFS, "templates/layout.html", "templates/"+name+".html"))
+		textTemplates[name] = texttemplate.Must(texttemplate.ParseFS(templateFS, "templates/layout.txt", "templates/"+name+".txt"))
+	}
+}
+
+// renderEmail renders the HTML and plain-text bodies of the named template
+func renderEmail(name string, data templateData) (string, string, error) {
+	htmlTmpl, ok := htmlTemplates[name]
+	if !ok {
+		return "", "", fmt.Errorf("unknown email template %q", name)
+	}
+
+	var htmlBody, textBody bytes.Buffer
+	if err := htmlTmpl.ExecuteTemplate(&htmlBody, "layout", data); err != nil {
+		return "", "", fmt.Errorf("failed to render %s email: %w", name, err)
+	}
+	if err := textTemplates[name].ExecuteTemplate(&textBody, "layout", data); err != nil {
+		return "", "", fmt.Errorf("failed to render %s email: %w", name, err)
+	}
+
+	return htmlBody.String(), textBody.String(), nil
+}
@@ -1,17 +1,26 @@
 package handlers
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"sort"
 	"time"
 
+	"backend/internal/auth"
+	"backend/internal/clock"
 	"backend/internal/database"
+	"backend/internal/helpers"
 	"backend/internal/middleware"
 	"backend/internal/models"
 	"backend/internal/services"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // AccessHandler handles HTTP requests for access/permission checking
@@ -37,6 +46,11 @@ type PermissionCheckRequest struct {
 	Scope    *models.PermissionScope  `json:"scope,omitempty"`
 }
 
+// CodePermissionCheckRequest represents a permission check by seeded permission code
+type CodePermissionCheckRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
 // BatchPermissionCheckRequest represents the request for batch permission check
 type BatchPermissionCheckRequest struct {
 	Checks []PermissionCheckRequest `json:"checks" binding:"required,min=1,max=50"`
@@ -50,6 +64,21 @@ type PermissionCheckResponse struct {
 	SourceName string `json:"source_name,omitempty"`
 }
 
+// UserPermissionCheckResponse represents a permission check run for another user
+type UserPermissionCheckResponse struct {
+	UserID string `json:"user_id"`
+	PermissionCheckResponse
+}
+
+// CodePermissionCheckResponse represents a permission check by code, with what the code resolved to
+type CodePermissionCheckResponse struct {
+	Code     string                  `json:"code"`
+	Resource string                  `json:"resource"`
+	Action   models.PermissionAction `json:"action"`
+	Scope    *models.PermissionScope `json:"scope,omitempty"`
+	PermissionCheckResponse
+}
+
 // BatchPermissionCheckResponse represents the response for batch permission check
 type BatchPermissionCheckResponse struct {
 	Results map[string]PermissionCheckResponse `json:"results"`
@@ -78,6 +107,17 @@ type UserPermissionsResponse struct {
 	CheckedAt   time.Time                     `json:"checked_at"`
 }
 
+// MyAccessResponse combines the module tree and effective permissions for the current user
+type MyAccessResponse struct {
+	UserID      string                       `json:"user_id"`
+	Version     string                       `json:"version"`
+	Modules     []ModuleAccessResponse       `json:"modules"`
+	Permissions []ResolvedPermissionResponse `json:"permissions"`
+	Roles       []RoleAccessResponse         `json:"roles"`
+	Positions   []PositionAccessResponse     `json:"positions"`
+	CheckedAt   time.Time                    `json:"checked_at"`
+}
+
 // ResolvedPermissionResponse represents a resolved permission
 type ResolvedPermissionResponse struct {
 	ID         string                   `json:"id"`
@@ -135,11 +175,11 @@ func (h *AccessHandler) CheckPermission(c *gin.Context) {
 
 	var req PermissionCheckRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(helpers.BindErrorStatus(err), helpers.BindErrorBody(c, err))
 		return
 	}
 
-	result, err := h.cache.CheckPermission(userID.(string), services.PermissionCheckRequest{
+	result, err := h.cache.CheckPermission(c.Request.Context(), userID.(string), services.PermissionCheckRequest{
 		Resource: req.Resource,
 		Action:   req.Action,
 		Scope:    req.Scope,
@@ -157,6 +197,70 @@ func (h *AccessHandler) CheckPermission(c *gin.Context) {
 	})
 }
 
+// CheckPermissionByCode checks a permission identified by its code for the authenticated user
+// @Summary Check a permission by its code
+// @Description Looks the code up (e.g. PERM_EMPLOYEES_EXPORT) and runs the same check as /access/check
+// @Description on its resource, action and scope.
+// @Tags access
+// @Accept json
+// @Produce json
+// @Param request body CodePermissionCheckRequest true "Permission code to check"
+// @Success 200 {object} CodePermissionCheckResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /access/check-code [post]
+func (h *AccessHandler) CheckPermissionByCode(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req CodePermissionCheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(helpers.BindErrorStatus(err), helpers.BindErrorBody(c, err))
+		return
+	}
+
+	// Business logic: Resolve the code to the resource/action/scope it stands for
+	var permission models.Permission
+	if err := database.GetDB().Select("code", "resource", "action", "scope").
+		Where("code = ?", req.Code).First(&permission).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "permission code not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to look up permission code"})
+		return
+	}
+
+	result, err := h.cache.CheckPermission(c.Request.Context(), userID.(string), services.PermissionCheckRequest{
+		Resource: permission.Resource,
+		Action:   permission.Action,
+		Scope:    permission.Scope,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check permission"})
+		return
+	}
+
+	// HTTP: Format response
+	c.JSON(http.StatusOK, CodePermissionCheckResponse{
+		Code:     permission.Code,
+		Resource: permission.Resource,
+		Action:   permission.Action,
+		Scope:    permission.Scope,
+		PermissionCheckResponse: PermissionCheckResponse{
+			Allowed:    result.Allowed,
+			Source:     result.Source,
+			SourceID:   result.SourceID,
+			SourceName: result.SourceName,
+		},
+	})
+}
+
 // CheckPermissionBatch checks multiple permissions at once
 // @Summary Check multiple permissions in a single request
 // @Tags access
@@ -177,7 +281,7 @@ func (h *AccessHandler) CheckPermissionBatch(c *gin.Context) {
 
 	var req BatchPermissionCheckRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(helpers.BindErrorStatus(err), helpers.BindErrorBody(c, err))
 		return
 	}
 
@@ -191,7 +295,7 @@ func (h *AccessHandler) CheckPermissionBatch(c *gin.Context) {
 		}
 	}
 
-	results, err := h.cache.CheckPermissionBatch(userID.(string), serviceRequests)
+	results, err := h.cache.CheckPermissionBatch(c.Request.Context(), userID.(string), serviceRequests)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check permissions"})
 		return
@@ -213,6 +317,60 @@ func (h *AccessHandler) CheckPermissionBatch(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// CheckUserPermission checks a single permission for another user (admin help-desk view)
+// @Summary Check if a user has a specific permission
+// @Description Runs the same resolution as /access/check for the target user, uncached, so admins can
+// @Description test access without impersonating. The check is not recorded in the access-check log.
+// @Tags access
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param request body PermissionCheckRequest true "Permission to check"
+// @Success 200 {object} UserPermissionCheckResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} helpers.ErrorEnvelope
+// @Failure 500 {object} map[string]string
+// @Router /users/{id}/access/check [post]
+func (h *AccessHandler) CheckUserPermission(c *gin.Context) {
+	// HTTP: Get path parameter
+	targetUserID := c.Param("id")
+
+	var req PermissionCheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(helpers.BindErrorStatus(err), helpers.BindErrorBody(c, err))
+		return
+	}
+
+	var user models.User
+	if err := database.GetDB().Select("id").First(&user, "id = ?", targetUserID).Error; err != nil {
+		middleware.UserNotFound(c)
+		return
+	}
+
+	// Business logic: Resolve directly so the target's cache and access-check log are left untouched
+	result, err := h.resolver.CheckPermission(user.ID, services.PermissionCheckRequest{
+		Resource: req.Resource,
+		Action:   req.Action,
+		Scope:    req.Scope,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check permission"})
+		return
+	}
+
+	// HTTP: Format response
+	c.JSON(http.StatusOK, UserPermissionCheckResponse{
+		UserID: user.ID,
+		PermissionCheckResponse: PermissionCheckResponse{
+			Allowed:    result.Allowed,
+			Source:     result.Source,
+			SourceID:   result.SourceID,
+			SourceName: result.SourceName,
+		},
+	})
+}
+
 // GetUserModules returns all modules accessible to the authenticated user
 // @Summary Get all accessible modules for the user
 // @Tags access
@@ -232,7 +390,7 @@ func (h *AccessHandler) GetUserModules(c *gin.Context) {
 
 	// Get user's active role IDs
 	var userRoles []models.UserRole
-	now := time.Now()
+	now := clock.Now()
 	if err := db.Where("user_id = ? AND is_active = ?", userID, true).
 		Where("effective_from <= ?", now).
 		Where("(effective_until IS NULL OR effective_until >= ?)", now).
@@ -246,20 +404,80 @@ func (h *AccessHandler) GetUserModules(c *gin.Context) {
 		roleIDs = append(roleIDs, ur.RoleID)
 	}
 
+	// Resolve the user's permissions once instead of checking every action per module
+	resolved, err := h.resolver.GetEffectiveUserPermissions(userID.(string), "")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get permissions"})
+		return
+	}
+
+	accessibleModules, err := h.buildModuleTree(roleIDs, services.IndexAllowedActions(resolved))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch modules"})
+		return
+	}
+
+	c.JSON(http.StatusOK, accessibleModules)
+}
+
+// PreviewRoleModules returns the module tree a user would see holding only this role
+// @Summary Preview a role's sidebar menu
+// @Description Computes the module tree like /access/modules for a hypothetical user whose only role is this one, so admins can check a role's navigation before assigning it
+// @Tags roles
+// @Produce json
+// @Param id path string true "Role ID"
+// @Success 200 {array} ModuleAccessResponse
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /roles/{id}/modules/preview [get]
+func (h *AccessHandler) PreviewRoleModules(c *gin.Context) {
+	// HTTP: Get path parameter
+	roleID := c.Param("id")
+
+	var role models.Role
+	if err := database.GetDB().First(&role, "id = ?", roleID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "role not found"})
+		return
+	}
+
+	// Business logic: Resolve the role's own and inherited permissions
+	resolved, err := h.resolver.GetEffectiveRolePermissions(role.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get permissions"})
+		return
+	}
+
+	accessibleModules, err := h.buildModuleTree([]string{role.ID}, services.IndexAllowedActions(resolved))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch modules"})
+		return
+	}
+
+	// HTTP: Format response
+	c.JSON(http.StatusOK, accessibleModules)
+}
+
+// buildModuleTree returns the visible modules reachable through the given roles'
+// RoleModuleAccess or, for modules without one, through allowedActions, nested by parent
+func (h *AccessHandler) buildModuleTree(roleIDs []string, allowedActions map[string]map[models.PermissionAction]bool) ([]ModuleAccessResponse, error) {
+	db := database.GetDB()
+
 	// Get all active modules
 	var modules []models.Module
 	if err := db.Where("is_active = ?", true).
 		Where("is_visible = ?", true).
 		Order("sort_order ASC, name ASC").
 		Find(&modules).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch modules"})
-		return
+		return nil, err
 	}
 
-	// Get RoleModuleAccess for user's roles
+	// Get RoleModuleAccess for the roles, ignoring accesses outside their effective window
 	var roleModuleAccesses []models.RoleModuleAccess
 	if len(roleIDs) > 0 {
+		now := clock.Now()
 		db.Where("role_id IN ? AND is_active = ?", roleIDs, true).
+			Where("effective_from <= ?", now).
+			Where("(effective_until IS NULL OR effective_until >= ?)", now).
 			Find(&roleModuleAccesses)
 	}
 
@@ -302,7 +520,7 @@ func (h *AccessHandler) GetUserModules(c *gin.Context) {
 			}
 		} else {
 			// No RoleModuleAccess - fall back to permission-based access check
-			permissions = h.getModulePermissions(userID.(string), module.Code)
+			permissions = getModulePermissions(allowedActions, module.Code)
 			hasAccess = len(permissions) > 0
 		}
 
@@ -356,7 +574,7 @@ func (h *AccessHandler) GetUserModules(c *gin.Context) {
 		return accessibleModules[i].SortOrder < accessibleModules[j].SortOrder
 	})
 
-	c.JSON(http.StatusOK, accessibleModules)
+	return accessibleModules, nil
 }
 
 // parseModuleAccessPermissions parses permissions from JSONB field
@@ -405,7 +623,7 @@ func mergePermissions(existing, newPerms []string) []string {
 }
 
 // getModulePermissions returns list of permissions user has on a module
-func (h *AccessHandler) getModulePermissions(userID, moduleCode string) []string {
+func getModulePermissions(allowedActions map[string]map[models.PermissionAction]bool, moduleCode string) []string {
 	actions := []models.PermissionAction{
 		models.PermissionActionRead,
 		models.PermissionActionCreate,
@@ -418,11 +636,7 @@ func (h *AccessHandler) getModulePermissions(userID, moduleCode string) []string
 
 	var permissions []string
 	for _, action := range actions {
-		result, err := h.cache.CheckPermission(userID, services.PermissionCheckRequest{
-			Resource: moduleCode,
-			Action:   action,
-		})
-		if err == nil && result.Allowed {
+		if allowedActions[moduleCode][action] {
 			permissions = append(permissions, string(action))
 		}
 	}
@@ -432,9 +646,14 @@ func (h *AccessHandler) getModulePermissions(userID, moduleCode string) []string
 
 // GetUserPermissions returns all effective permissions for the authenticated user
 // @Summary Get all effective permissions for the user
+// @Description Pass resource (or module, the same value for module codes) to get only the permissions on that
+// @Description resource, e.g. for a single screen; roles and positions are always returned in full.
 // @Tags access
 // @Produce json
+// @Param resource query string false "Only permissions on this resource"
+// @Param module query string false "Alias of resource, for module codes"
 // @Success 200 {object} UserPermissionsResponse
+// @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /access/permissions [get]
@@ -445,8 +664,18 @@ func (h *AccessHandler) GetUserPermissions(c *gin.Context) {
 		return
 	}
 
-	// Get all effective permissions
-	resolved, err := h.resolver.GetEffectiveUserPermissions(userID.(string))
+	// Module codes double as the resource of their permissions
+	resource, module := c.Query("resource"), c.Query("module")
+	if resource != "" && module != "" && resource != module {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "use either resource or module, not both"})
+		return
+	}
+	if resource == "" {
+		resource = module
+	}
+
+	// Get effective permissions, only on the requested resource when one is given
+	resolved, err := h.resolver.GetEffectiveUserPermissions(userID.(string), resource)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get permissions"})
 		return
@@ -469,18 +698,122 @@ func (h *AccessHandler) GetUserPermissions(c *gin.Context) {
 	// Build response
 	response := UserPermissionsResponse{
 		UserID:      userID.(string),
-		Permissions: make([]ResolvedPermissionResponse, 0, len(resolved)),
-		Roles:       make([]RoleAccessResponse, 0, len(userRoles)),
-		Positions:   make([]PositionAccessResponse, 0, len(userPositions)),
+		Permissions: toResolvedPermissionResponses(resolved),
+		Roles:       toRoleAccessResponses(userRoles),
+		Positions:   toPositionAccessResponses(userPositions),
 		CheckedAt:   time.Now(),
 	}
 
-	// Convert permissions
+	c.JSON(http.StatusOK, response)
+}
+
+// GetMyAccess returns the module tree, effective permissions, roles and positions in one payload
+// @Summary Get the authenticated user's complete access
+// @Description Combines /access/modules and /access/permissions so the client needs one RBAC round-trip on load. The version (also sent as ETag) changes only when the access itself changes; send it back in If-None-Match to get 304
+// @Tags access
+// @Produce json
+// @Param If-None-Match header string false "Version from a previous response"
+// @Success 200 {object} MyAccessResponse
+// @Success 304 "Access unchanged"
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /access/me [get]
+func (h *AccessHandler) GetMyAccess(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	// Business logic: Resolve permissions once; modules and the flat list share the result
+	resolved, err := h.resolver.GetEffectiveUserPermissions(userID.(string), "")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get permissions"})
+		return
+	}
+
+	userRoles, err := h.resolver.GetEffectiveUserRoles(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get roles"})
+		return
+	}
+
+	userPositions, err := h.resolver.GetEffectiveUserPositions(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get positions"})
+		return
+	}
+
+	roleIDs := make([]string, 0, len(userRoles))
+	for _, ur := range userRoles {
+		roleIDs = append(roleIDs, ur.RoleID)
+	}
+
+	modules, err := h.buildModuleTree(roleIDs, services.IndexAllowedActions(resolved))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch modules"})
+		return
+	}
+
+	response := MyAccessResponse{
+		UserID:      userID.(string),
+		Modules:     modules,
+		Permissions: toResolvedPermissionResponses(resolved),
+		Roles:       toRoleAccessResponses(userRoles),
+		Positions:   toPositionAccessResponses(userPositions),
+	}
+
+	// The resolver queries are unordered; sort so the same access always hashes the same
+	sort.Slice(response.Permissions, func(i, j int) bool {
+		a, b := response.Permissions[i], response.Permissions[j]
+		if a.Code != b.Code {
+			return a.Code < b.Code
+		}
+		if a.Source != b.Source {
+			return a.Source < b.Source
+		}
+		return a.SourceID < b.SourceID
+	})
+	sort.Slice(response.Roles, func(i, j int) bool { return response.Roles[i].ID < response.Roles[j].ID })
+	sort.Slice(response.Positions, func(i, j int) bool { return response.Positions[i].ID < response.Positions[j].ID })
+
+	// HTTP: Version the payload before the timestamp is set so unchanged access hashes the same
+	version, err := accessVersion(response)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to build response"})
+		return
+	}
+	etag := `"` + version + `"`
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "private, no-cache")
+	if match := c.GetHeader("If-None-Match"); match == etag || match == version {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	response.Version = version
+	response.CheckedAt = time.Now()
+	c.JSON(http.StatusOK, response)
+}
+
+// accessVersion hashes the access payload; Version and CheckedAt must still be empty
+func accessVersion(response MyAccessResponse) (string, error) {
+	payload, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:16]), nil
+}
+
+// toResolvedPermissionResponses converts resolved permissions, skipping entries without a permission
+func toResolvedPermissionResponses(resolved []services.ResolvedPermission) []ResolvedPermissionResponse {
+	permissions := make([]ResolvedPermissionResponse, 0, len(resolved))
 	for _, rp := range resolved {
 		if rp.Permission == nil {
 			continue
 		}
-		response.Permissions = append(response.Permissions, ResolvedPermissionResponse{
+		permissions = append(permissions, ResolvedPermissionResponse{
 			ID:         rp.Permission.ID,
 			Code:       rp.Permission.Code,
 			Name:       rp.Permission.Name,
@@ -494,13 +827,17 @@ func (h *AccessHandler) GetUserPermissions(c *gin.Context) {
 			Priority:   rp.Priority,
 		})
 	}
+	return permissions
+}
 
-	// Convert roles
+// toRoleAccessResponses converts a user's role assignments
+func toRoleAccessResponses(userRoles []models.UserRole) []RoleAccessResponse {
+	roles := make([]RoleAccessResponse, 0, len(userRoles))
 	for _, ur := range userRoles {
 		if ur.Role == nil {
 			continue
 		}
-		response.Roles = append(response.Roles, RoleAccessResponse{
+		roles = append(roles, RoleAccessResponse{
 			ID:             ur.Role.ID,
 			Code:           ur.Role.Code,
 			Name:           ur.Role.Name,
@@ -509,8 +846,12 @@ func (h *AccessHandler) GetUserPermissions(c *gin.Context) {
 			EffectiveUntil: ur.EffectiveUntil,
 		})
 	}
+	return roles
+}
 
-	// Convert positions
+// toPositionAccessResponses converts a user's position assignments
+func toPositionAccessResponses(userPositions []models.UserPosition) []PositionAccessResponse {
+	positions := make([]PositionAccessResponse, 0, len(userPositions))
 	for _, up := range userPositions {
 		if up.Position == nil {
 			continue
@@ -522,7 +863,7 @@ func (h *AccessHandler) GetUserPermissions(c *gin.Context) {
 		if up.Position.School != nil {
 			schoolName = &up.Position.School.Name
 		}
-		response.Positions = append(response.Positions, PositionAccessResponse{
+		positions = append(positions, PositionAccessResponse{
 			ID:         up.Position.ID,
 			Code:       up.Position.Code,
 			Name:       up.Position.Name,
@@ -533,8 +874,128 @@ func (h *AccessHandler) GetUserPermissions(c *gin.Context) {
 			IsPlt:      up.IsPlt,
 		})
 	}
+	return positions
+}
 
-	c.JSON(http.StatusOK, response)
+// CapableUsersQuery represents the query parameters for the "who can do X" lookup
+type CapableUsersQuery struct {
+	Resource string                  `form:"resource" binding:"required"`
+	Action   models.PermissionAction `form:"action" binding:"required"`
+	Scope    *models.PermissionScope `form:"scope"`
+	Page     int                     `form:"page,default=1"`
+	Limit    int                     `form:"limit,default=50"`
+}
+
+// GetCapableUsers lists every active user who currently holds a permission, through any layer
+// @Summary List users who can perform an action
+// @Description Reverse permission lookup: users for whom a permission check on resource/action
+// @Description (and optional scope) currently allows, with the granting source per user.
+// @Tags access
+// @Produce json
+// @Param resource query string true "Resource"
+// @Param action query string true "Action"
+// @Param scope query string false "Scope"
+// @Param page query int false "Page number"
+// @Param limit query int false "Page size (max 200)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /access/capable [get]
+func (h *AccessHandler) GetCapableUsers(c *gin.Context) {
+	// HTTP: Parse query parameters
+	var query CapableUsersQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(helpers.BindErrorStatus(err), helpers.BindErrorBody(c, err))
+		return
+	}
+
+	// Business logic: Expand grants to users via resolver
+	result, err := h.resolver.GetCapableUsers(services.PermissionCheckRequest{
+		Resource: query.Resource,
+		Action:   query.Action,
+		Scope:    query.Scope,
+	}, query.Page, query.Limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// HTTP: Format response
+	c.JSON(http.StatusOK, gin.H{
+		"data":        result.Data,
+		"total":       result.Total,
+		"page":        result.Page,
+		"page_size":   result.PageSize,
+		"total_pages": result.TotalPages,
+	})
+}
+
+// GetResolutionInfo returns how permission checks are resolved
+// @Summary Get permission resolution rules
+// @Description Machine-readable layer order, scope hierarchy and priority semantics used by every
+// @Description permission check, so clients do not hard-code assumptions about precedence
+// @Tags access
+// @Produce json
+// @Success 200 {object} services.PermissionResolutionInfo
+// @Router /access/resolution-info [get]
+func (h *AccessHandler) GetResolutionInfo(c *gin.Context) {
+	c.JSON(http.StatusOK, services.GetResolutionInfo())
+}
+
+// permissionEventHeartbeat keeps idle event streams alive through proxies
+const permissionEventHeartbeat = 25 * time.Second
+
+// StreamPermissionEvents streams permission-change events for the current user (SSE)
+// @Summary Stream permission-change events
+// @Description Server-Sent Events stream. Emits "permissions_changed" whenever the user's permission
+// @Description cache is invalidated; clients should re-fetch /access/modules. The stream ends when the
+// @Description access token would expire so the client reconnects with fresh credentials.
+// @Tags access
+// @Produce text/event-stream
+// @Success 200 {string} string "event stream"
+// @Failure 401 {object} map[string]string
+// @Router /access/events [get]
+func (h *AccessHandler) StreamPermissionEvents(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	events, unsubscribe := h.cache.Events().Subscribe(userID.(string))
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // disable proxy buffering (nginx)
+
+	heartbeat := time.NewTicker(permissionEventHeartbeat)
+	defer heartbeat.Stop()
+	expiry := time.NewTimer(auth.AccessTokenTTL())
+	defer expiry.Stop()
+
+	c.Status(http.StatusOK)
+	c.SSEvent("ready", gin.H{"user_id": userID})
+	c.Writer.Flush()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-expiry.C:
+			return false
+		case event, ok := <-events:
+			if !ok {
+				return false
+			}
+			c.SSEvent("permissions_changed", event)
+			return true
+		case <-heartbeat.C:
+			_, err := fmt.Fprint(w, ": ping\n\n")
+			return err == nil
+		}
+	})
 }
 
 // GetCacheStats returns permission cache statistics (admin only)
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"net/http"
+
+	"backend/internal/helpers"
+	"backend/internal/models"
+	"backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AccessCheckLogHandler handles HTTP requests for recorded checks on sensitive resources
+type AccessCheckLogHandler struct {
+	accessCheckLogService *services.AccessCheckLogService
+}
+
+// NewAccessCheckLogHandler creates a new AccessCheckLogHandler instance
+func NewAccessCheckLogHandler(accessCheckLogService *services.AccessCheckLogService) *AccessCheckLogHandler {
+	return &AccessCheckLogHandler{
+		accessCheckLogService: accessCheckLogService,
+	}
+}
+
+// GetAccessCheckLogs handles listing permission checks recorded for sensitive resources
+// @Summary List access checks on sensitive resources
+// @Description Every allowed or denied permission check on a resource whose permission is flagged is_sensitive
+// @Tags audit
+// @Produce json
+// @Param user_id query string false "User ID"
+// @Param resource query string false "Resource"
+// @Param action query string false "Permission action"
+// @Param allowed query bool false "Filter by outcome"
+// @Param start_date query string false "Start date (RFC3339)"
+// @Param end_date query string false "End date (RFC3339)"
+// @Param page query int false "Page number"
+// @Param limit query int false "Page size"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Router /audit/access-checks [get]
+func (h *AccessCheckLogHandler) GetAccessCheckLogs(c *gin.Context) {
+	// HTTP: Parse query parameters
+	var filter models.AccessCheckLogFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(helpers.BindErrorStatus(err), helpers.BindErrorBody(c, err))
+		return
+	}
+	if filter.Action != nil && *filter.Action != "" && !filter.Action.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "action tidak valid"})
+		return
+	}
+
+	// Business logic: Get access checks via service
+	result, err := h.accessCheckLogService.GetAccessCheckLogs(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// HTTP: Format response
+	c.JSON(http.StatusOK, gin.H{
+		"data":        result.Data,
+		"total":       result.Total,
+		"page":        result.Page,
+		"page_size":   result.PageSize,
+		"total_pages": result.TotalPages,
+	})
+}
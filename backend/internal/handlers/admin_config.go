@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"net/http"
+
+	"backend/internal/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminConfigHandler exposes the runtime security configuration to administrators
+type AdminConfigHandler struct{}
+
+// NewAdminConfigHandler creates a new AdminConfigHandler instance
+func NewAdminConfigHandler() *AdminConfigHandler {
+	return &AdminConfigHandler{}
+}
+
+// SecurityConfigResponse lists the authentication settings currently in effect
+type SecurityConfigResponse struct {
+	MaxFailedAttempts          int `json:"max_failed_attempts"`
+	AccountLockDurationMinutes int `json:"account_lock_duration_minutes"`
+	AccessTokenTTLMinutes      int `json:"access_token_ttl_minutes"`
+	RefreshTokenTTLDays        int `json:"refresh_token_ttl_days"`
+	RememberMeTTLDays          int `json:"remember_me_ttl_days"`
+	PasswordResetTTLMinutes    int `json:"password_reset_ttl_minutes"`
+}
+
+// GetSecurityConfig handles retrieving the lockout and token settings currently in effect
+// @Summary Get security configuration
+// @Description Returns the account lockout thresholds and token lifetimes loaded from config (no secrets)
+// @Tags admin
+// @Produce json
+// @Success 200 {object} SecurityConfigResponse
+// @Router /admin/config/security [get]
+func (h *AdminConfigHandler) GetSecurityConfig(c *gin.Context) {
+	// Business logic: Read the values set at startup
+	resp := SecurityConfigResponse{
+		MaxFailedAttempts:          auth.MaxFailedLoginAttempts(),
+		AccountLockDurationMinutes: int(auth.LockDuration().Minutes()),
+		AccessTokenTTLMinutes:      int(auth.AccessTokenTTL().Minutes()),
+		RefreshTokenTTLDays:        int(auth.RefreshTokenTTL(false).Hours() / 24),
+		RememberMeTTLDays:          int(auth.RefreshTokenTTL(true).Hours() / 24),
+		PasswordResetTTLMinutes:    int(auth.PasswordResetTTL().Minutes()),
+	}
+
+	// HTTP: Format response
+	c.JSON(http.StatusOK, resp)
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"strconv"
 
+	"backend/internal/helpers"
 	"backend/internal/models"
 	"backend/internal/services"
 
@@ -38,7 +39,7 @@ func (h *ApiKeyHandler) CreateApiKey(c *gin.Context) {
 
 	// HTTP: Parse and validate request
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(helpers.BindErrorStatus(err), helpers.BindErrorBody(c, err))
 		return
 	}
 
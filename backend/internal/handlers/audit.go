@@ -0,0 +1,266 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/internal/clock"
+	"backend/internal/helpers"
+	"backend/internal/i18n"
+	"backend/internal/logger"
+	"backend/internal/models"
+	"backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuditHandler handles HTTP requests for audit logs
+type AuditHandler struct {
+	auditService *services.AuditService
+}
+
+// NewAuditHandler creates a new AuditHandler instance
+func NewAuditHandler(auditService *services.AuditService) *AuditHandler {
+	return &AuditHandler{
+		auditService: auditService,
+	}
+}
+
+// GetAuditLogs handles listing audit log entries visible to the authenticated user
+// @Summary List audit logs
+// @Description Entries are limited by the caller's audit:read scope: OWN shows entries the caller made or
+// @Description was affected by, DEPARTMENT/SCHOOL those whose actor or affected user shares a department/school
+// @Description with the caller, ALL everything.
+// @Tags audit
+// @Produce json
+// @Param actor_profile_id query string false "Actor user ID"
+// @Param entity_type query string false "Target type"
+// @Param entity_id query string false "Target ID"
+// @Param target_user_id query string false "Affected user ID"
+// @Param action query string false "Audit action"
+// @Param module query string false "Module"
+// @Param category query string false "Category"
+// @Param start_date query string false "Start of date range (RFC3339)"
+// @Param end_date query string false "End of date range (RFC3339)"
+// @Param page query int false "Page number"
+// @Param limit query int false "Page size"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Router /audit [get]
+func (h *AuditHandler) GetAuditLogs(c *gin.Context) {
+	// HTTP: Parse query parameters
+	var filter models.AuditLogFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(helpers.BindErrorStatus(err), helpers.BindErrorBody(c, err))
+		return
+	}
+	if filter.Action != nil && *filter.Action != "" && !filter.Action.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Action audit tidak valid"})
+		return
+	}
+
+	// HTTP: Get authenticated user
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// Business logic: Get audit logs via service (scoped to the viewer)
+	result, err := h.auditService.GetAuditLogs(userID.(string), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// HTTP: Format response
+	c.JSON(http.StatusOK, gin.H{
+		"data":        result.Data,
+		"total":       result.Total,
+		"page":        result.Page,
+		"page_size":   result.PageSize,
+		"total_pages": result.TotalPages,
+	})
+}
+
+// ExportAuditLogs handles streaming the filtered audit log as a CSV or JSON file
+// @Summary Export audit logs
+// @Tags audit
+// @Produce text/csv
+// @Produce json
+// @Param format query string false "Export format (csv/json)" default(csv)
+// @Param actor_profile_id query string false "Actor user ID"
+// @Param entity_type query string false "Target type"
+// @Param entity_id query string false "Target ID"
+// @Param target_user_id query string false "Affected user ID"
+// @Param action query string false "Audit action"
+// @Param module query string false "Module"
+// @Param category query string false "Category"
+// @Param start_date query string false "Start of date range (RFC3339)"
+// @Param end_date query string false "End of date range (RFC3339)"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /audit/export [get]
+func (h *AuditHandler) ExportAuditLogs(c *gin.Context) {
+	// HTTP: Parse query parameters
+	var filter models.AuditLogFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(helpers.BindErrorStatus(err), helpers.BindErrorBody(c, err))
+		return
+	}
+	if filter.Action != nil && *filter.Action != "" && !filter.Action.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Action audit tidak valid"})
+		return
+	}
+
+	format := strings.ToLower(c.DefaultQuery("format", services.AuditExportFormatCSV))
+	contentType := "text/csv; charset=utf-8"
+	switch format {
+	case services.AuditExportFormatCSV:
+	case services.AuditExportFormatJSON:
+		contentType = "application/json; charset=utf-8"
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format export harus csv atau json"})
+		return
+	}
+
+	// HTTP: Get authenticated user
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// HTTP: Stream export response
+	filename := fmt.Sprintf("audit-logs-%s.%s", time.Now().Format("20060102-150405"), format)
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	// Business logic: Export audit logs via service (scoped to the viewer)
+	if _, err := h.auditService.ExportAuditLogs(c.Request.Context(), userID.(string), filter, format, c.Writer, auditActorID(c), c.ClientIP(), c.Request.UserAgent()); err != nil {
+		if !c.Writer.Written() {
+			c.Header("Content-Disposition", "")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		// Headers are already sent; the client receives a truncated file
+		logger.FromContext(c.Request.Context()).Warn("audit log export interrupted", "error", err)
+	}
+}
+
+// Window for the "recent changes to my access" list
+const (
+	defaultAccessChangeDays = 30
+	maxAccessChangeDays     = 90
+	maxAccessChanges        = 50
+)
+
+// AccessChangeResponse represents one change to the caller's roles, positions or direct permissions
+type AccessChangeResponse struct {
+	ID          string             `json:"id"`
+	Kind        string             `json:"kind"`
+	Action      models.AuditAction `json:"action"`
+	Name        string             `json:"name"`
+	IsGranted   *bool              `json:"is_granted,omitempty"`
+	ActorName   string             `json:"actor_name,omitempty"`
+	Description string             `json:"description"`
+	CreatedAt   time.Time          `json:"created_at"`
+}
+
+// GetMyAccessChanges handles listing recent changes to the authenticated user's own access
+// @Summary List recent changes to my access
+// @Description Role, position and direct permission assignments and revocations affecting the caller,
+// @Description newest first, each with a readable description in the request language. At most 50 entries.
+// @Tags access
+// @Produce json
+// @Param days query int false "How many days back to look (1-90)" default(30)
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /access/my-changes [get]
+func (h *AuditHandler) GetMyAccessChanges(c *gin.Context) {
+	// HTTP: Parse query parameters
+	days := defaultAccessChangeDays
+	if raw := c.Query("days"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxAccessChangeDays {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("days harus antara 1 dan %d", maxAccessChangeDays)})
+			return
+		}
+		days = parsed
+	}
+
+	// HTTP: Get authenticated user
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// Business logic: Only the caller's own record is ever read
+	since := clock.Now().AddDate(0, 0, -days)
+	changes, err := h.auditService.GetAccessChanges(userID.(string), since, maxAccessChanges)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// HTTP: Format response
+	data := make([]AccessChangeResponse, len(changes))
+	for i, change := range changes {
+		data[i] = AccessChangeResponse{
+			ID:          change.ID,
+			Kind:        change.Kind,
+			Action:      change.Action,
+			Name:        change.Name,
+			IsGranted:   change.IsGranted,
+			ActorName:   change.ActorName,
+			Description: describeAccessChange(c, change),
+			CreatedAt:   change.CreatedAt,
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": data,
+		"days": days,
+	})
+}
+
+// describeAccessChange renders a change as a sentence in the request locale,
+// e.g. "Role Guru Senior ditambahkan oleh admin pada 2026-10-16 09:30"
+func describeAccessChange(c *gin.Context, change services.AccessChange) string {
+	key := i18n.MsgAccessChangeRoleAssigned
+	switch change.Kind {
+	case services.AccessChangeRole:
+		if change.Action == models.AuditActionRevoke {
+			key = i18n.MsgAccessChangeRoleRevoked
+		}
+	case services.AccessChangePosition:
+		key = i18n.MsgAccessChangePositionAssigned
+		if change.Action == models.AuditActionRevoke {
+			key = i18n.MsgAccessChangePositionRevoked
+		}
+	case services.AccessChangePermission:
+		switch {
+		case change.Action == models.AuditActionRevoke:
+			key = i18n.MsgAccessChangePermissionRevoked
+		case change.Action == models.AuditActionUpdate:
+			key = i18n.MsgAccessChangePermissionUpdated
+		case change.IsGranted != nil && !*change.IsGranted:
+			key = i18n.MsgAccessChangePermissionDenied
+		default:
+			key = i18n.MsgAccessChangePermissionGranted
+		}
+	}
+
+	actor := change.ActorName
+	if actor == "" {
+		actor = i18n.T(c, i18n.MsgAccessChangeSystemActor)
+	}
+	return i18n.TF(c, key, change.Name, actor, change.CreatedAt.In(clock.Location()).Format("2006-01-02 15:04"))
+}
@@ -1,19 +1,20 @@
 package handlers
 
 import (
-	"crypto/rand"
-	"encoding/hex"
-	"log"
 	"net/http"
 	"strings"
 	"time"
 
 	"backend/internal/auth"
+	"backend/internal/clock"
 	"backend/internal/database"
 	"backend/internal/email"
 	"backend/internal/helpers"
 	"backend/internal/i18n"
+	"backend/internal/logger"
+	"backend/internal/metrics"
 	"backend/internal/models"
+	"backend/internal/services"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -23,38 +24,38 @@ import (
 func Register(c *gin.Context) {
 	var req models.RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		helpers.RespondBindError(c, err)
 		return
 	}
 
-	db := database.GetDB()
+	db := database.Primary(database.GetDB())
 
 	// Validate email exists in active employee database
 	var employee models.DataKaryawan
 	if err := db.Where("email = ?", req.Email).First(&employee).Error; err != nil {
 		// Email not found in employee database
-		helpers.Forbidden(c, i18n.MsgAuthEmailNotRegistered)
+		helpers.RespondError(c, http.StatusForbidden, helpers.CodeAuthEmailNotRegistered, i18n.T(c, i18n.MsgAuthEmailNotRegistered), nil)
 		return
 	}
 
 	// Check if employee is active using existing helper method
 	if !employee.IsActiveEmployee() {
 		// Employee exists but status not active
-		helpers.Forbidden(c, i18n.MsgAuthAccountInactive)
+		helpers.RespondError(c, http.StatusForbidden, helpers.CodeAuthEmployeeInactive, i18n.T(c, i18n.MsgAuthAccountInactive), nil)
 		return
 	}
 
 	// Check email uniqueness in users table (prevent double registration)
 	var existingUser models.User
 	if err := db.Where("email = ?", req.Email).First(&existingUser).Error; err == nil {
-		helpers.BadRequest(c, i18n.MsgAuthEmailAlreadyExists)
+		helpers.RespondError(c, http.StatusBadRequest, helpers.CodeAuthEmailAlreadyExists, i18n.T(c, i18n.MsgAuthEmailAlreadyExists), nil)
 		return
 	}
 
 	// Hash password
 	hashedPassword, err := auth.HashPassword(req.Password)
 	if err != nil {
-		helpers.InternalError(c, i18n.MsgAuthPasswordHashFailed)
+		helpers.RespondError(c, http.StatusInternalServerError, helpers.CodeAuthPasswordHashFailed, i18n.T(c, i18n.MsgAuthPasswordHashFailed), nil)
 		return
 	}
 
@@ -74,20 +75,20 @@ func Register(c *gin.Context) {
 	}
 
 	if err := db.Create(&user).Error; err != nil {
-		helpers.InternalError(c, i18n.MsgCrudCreateFailed)
+		helpers.RespondError(c, http.StatusInternalServerError, helpers.CodeInternal, i18n.T(c, i18n.MsgCrudCreateFailed), nil)
 		return
 	}
 
 	// Generate tokens
 	accessToken, err := auth.GenerateAccessToken(user.ID, user.Email)
 	if err != nil {
-		helpers.InternalError(c, i18n.MsgAuthTokenGenerateFailed)
+		helpers.RespondError(c, http.StatusInternalServerError, helpers.CodeAuthTokenGenerationFailed, i18n.T(c, i18n.MsgAuthTokenGenerateFailed), nil)
 		return
 	}
 
 	refreshToken, refreshHash, err := auth.GenerateRefreshToken()
 	if err != nil {
-		helpers.InternalError(c, i18n.MsgAuthTokenGenerateFailed)
+		helpers.RespondError(c, http.StatusInternalServerError, helpers.CodeAuthTokenGenerationFailed, i18n.T(c, i18n.MsgAuthTokenGenerateFailed), nil)
 		return
 	}
 
@@ -95,38 +96,40 @@ func Register(c *gin.Context) {
 	ipAddress := c.ClientIP()
 	userAgent := c.Request.UserAgent()
 	rt := models.RefreshToken{
-		ID:            uuid.New().String(),
-		UserID: user.ID,
-		TokenHash:     refreshHash,
-		ExpiresAt:     time.Now().Add(auth.RefreshTokenExpiry),
-		IPAddress:     &ipAddress,
-		UserAgent:     &userAgent,
+		ID:              uuid.New().String(),
+		UserID:          user.ID,
+		TokenHash:       refreshHash,
+		ExpiresAt:       time.Now().Add(auth.RefreshTokenExpiry),
+		LifetimeSeconds: int64(auth.RefreshTokenExpiry.Seconds()),
+		IPAddress:       &ipAddress,
+		UserAgent:       &userAgent,
 	}
 
 	if err := db.Create(&rt).Error; err != nil {
-		helpers.InternalError(c, i18n.MsgAuthTokenGenerateFailed)
+		helpers.RespondError(c, http.StatusInternalServerError, helpers.CodeAuthTokenGenerationFailed, i18n.T(c, i18n.MsgAuthTokenGenerateFailed), nil)
 		return
 	}
 
 	// Preload DataKaryawan for user (only active employees)
 	if err := db.Preload("DataKaryawan", "status_aktif = ?", "Aktif").First(&user, "id = ?", user.ID).Error; err != nil {
-		helpers.InternalError(c, i18n.MsgCrudFetchFailed)
+		helpers.RespondError(c, http.StatusInternalServerError, helpers.CodeInternal, i18n.T(c, i18n.MsgCrudFetchFailed), nil)
 		return
 	}
 
 	// Generate CSRF token for this user session
 	csrfToken, err := auth.GenerateCSRFToken(user.ID)
 	if err != nil {
-		helpers.InternalError(c, i18n.MsgAuthTokenGenerateFailed)
+		helpers.RespondError(c, http.StatusInternalServerError, helpers.CodeAuthTokenGenerationFailed, i18n.T(c, i18n.MsgAuthTokenGenerateFailed), nil)
 		return
 	}
 
 	// Set httpOnly cookies (tokens ONLY in cookies, NOT in response body)
 	isProduction := gin.Mode() == gin.ReleaseMode
-	helpers.SetAuthCookies(c, accessToken, refreshToken, isProduction)
+	helpers.SetAuthCookies(c, accessToken, refreshToken, auth.RefreshTokenExpiry, isProduction)
 	helpers.SetCSRFCookie(c, csrfToken, isProduction)
 
 	// Send welcome email (async - don't block response)
+	reqLog := logger.FromContext(c.Request.Context())
 	go func() {
 		emailSender := email.NewEmailSender()
 		// Get display name from employee data or use username
@@ -135,25 +138,25 @@ func Register(c *gin.Context) {
 			displayName = *employee.Nama
 		}
 		if err := emailSender.SendWelcomeEmail(req.Email, displayName); err != nil {
-			log.Printf("[WELCOME_EMAIL_ERROR] Failed to send welcome email to %s: %v", req.Email, err)
+			reqLog.Error("failed to send welcome email", "user_id", user.ID, "error", err)
 		} else {
-			log.Printf("[WELCOME_EMAIL] Successfully sent welcome email to %s", req.Email)
+			reqLog.Info("queued welcome email", "user_id", user.ID)
 		}
 	}()
 
 	// Return success with user info only (NO TOKENS in body for security)
-	helpers.SuccessResponse(c, http.StatusCreated, i18n.MsgAuthRegisterSuccess, user.ToUserInfo())
+	tokenResponse(c, http.StatusCreated, i18n.MsgAuthRegisterSuccess, user.ToUserInfo())
 }
 
 // Login handles user authentication
 func Login(c *gin.Context) {
 	var req models.LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		helpers.RespondBindError(c, err)
 		return
 	}
 
-	db := database.GetDB()
+	db := database.Primary(database.GetDB())
 	ipAddress := c.ClientIP()
 	userAgent := c.Request.UserAgent()
 
@@ -170,20 +173,21 @@ func Login(c *gin.Context) {
 			attempt.FailureReason = &failureReason
 		}
 		db.Create(&attempt)
+		metrics.RecordLogin(success, failureReason)
 	}
 
 	// Find user
 	var user models.User
 	if err := db.Where("email = ?", req.Email).First(&user).Error; err != nil {
 		logAttempt(false, "invalid_credentials")
-		helpers.Unauthorized(c, i18n.MsgAuthCredentialsInvalid)
+		helpers.RespondError(c, http.StatusUnauthorized, helpers.CodeAuthInvalidCredentials, i18n.T(c, i18n.MsgAuthCredentialsInvalid), nil)
 		return
 	}
 
 	// Check if account is locked
 	if user.LockedUntil != nil && time.Now().Before(*user.LockedUntil) {
 		logAttempt(false, "account_locked")
-		helpers.ErrorResponseWithDetails(c, http.StatusUnauthorized, i18n.MsgAuthAccountInactive, gin.H{
+		helpers.RespondError(c, http.StatusUnauthorized, helpers.CodeAuthAccountLocked, i18n.T(c, i18n.MsgAuthAccountInactive), gin.H{
 			"locked_until": user.LockedUntil,
 		})
 		return
@@ -198,7 +202,7 @@ func Login(c *gin.Context) {
 	// Check if account is active
 	if !user.IsActive {
 		logAttempt(false, "account_inactive")
-		helpers.Unauthorized(c, i18n.MsgAuthAccountInactive)
+		helpers.RespondError(c, http.StatusUnauthorized, helpers.CodeAuthAccountInactive, i18n.T(c, i18n.MsgAuthAccountInactive), nil)
 		return
 	}
 
@@ -208,14 +212,18 @@ func Login(c *gin.Context) {
 		user.FailedLoginAttempts++
 
 		// Lock account if threshold reached
-		if user.FailedLoginAttempts >= auth.MaxFailedAttempts {
-			lockUntil := time.Now().Add(auth.AccountLockDuration)
+		if user.FailedLoginAttempts >= auth.MaxFailedLoginAttempts() {
+			lockUntil := time.Now().Add(auth.LockDuration())
 			user.LockedUntil = &lockUntil
+			recordAuthEvent(c, user.ID, models.AuditActionUpdate, "account_locked", map[string]interface{}{
+				"failed_attempts": user.FailedLoginAttempts,
+				"locked_until":    lockUntil,
+			})
 		}
 
 		db.Save(&user)
 		logAttempt(false, "invalid_credentials")
-		helpers.Unauthorized(c, i18n.MsgAuthCredentialsInvalid)
+		helpers.RespondError(c, http.StatusUnauthorized, helpers.CodeAuthInvalidCredentials, i18n.T(c, i18n.MsgAuthCredentialsInvalid), nil)
 		return
 	}
 
@@ -225,12 +233,21 @@ func Login(c *gin.Context) {
 		// Employee record exists, check if active
 		if !employee.IsActiveEmployee() {
 			logAttempt(false, "employee_inactive")
-			helpers.Forbidden(c, i18n.MsgAuthAccountInactive)
+			helpers.RespondError(c, http.StatusForbidden, helpers.CodeAuthEmployeeInactive, i18n.T(c, i18n.MsgAuthAccountInactive), nil)
 			return
 		}
 	}
 	// If employee record not found, continue (allow non-employee users to login)
 
+	// Upgrade a hash made with older or weaker parameters while the plain password is at hand
+	if auth.NeedsRehash(user.PasswordHash) {
+		if newHash, err := auth.HashPassword(req.Password); err != nil {
+			logger.FromContext(c.Request.Context()).Warn("failed to rehash password on login", "user_id", user.ID, "error", err)
+		} else {
+			user.PasswordHash = newHash
+		}
+	}
+
 	// Reset failed attempts on successful login
 	user.FailedLoginAttempts = 0
 	user.LockedUntil = nil
@@ -241,70 +258,61 @@ func Login(c *gin.Context) {
 	// Generate tokens
 	accessToken, err := auth.GenerateAccessToken(user.ID, user.Email)
 	if err != nil {
-		helpers.InternalError(c, i18n.MsgAuthTokenGenerateFailed)
+		helpers.RespondError(c, http.StatusInternalServerError, helpers.CodeAuthTokenGenerationFailed, i18n.T(c, i18n.MsgAuthTokenGenerateFailed), nil)
 		return
 	}
 
 	refreshToken, refreshHash, err := auth.GenerateRefreshToken()
 	if err != nil {
-		helpers.InternalError(c, i18n.MsgAuthTokenGenerateFailed)
+		helpers.RespondError(c, http.StatusInternalServerError, helpers.CodeAuthTokenGenerationFailed, i18n.T(c, i18n.MsgAuthTokenGenerateFailed), nil)
 		return
 	}
 
-	// Store refresh token
+	// Store refresh token; remember me selects the longer lifetime
+	refreshLifetime := auth.RefreshTokenTTL(req.RememberMe)
 	rt := models.RefreshToken{
-		ID:            uuid.New().String(),
-		UserID: user.ID,
-		TokenHash:     refreshHash,
-		ExpiresAt:     time.Now().Add(auth.RefreshTokenExpiry),
-		IPAddress:     &ipAddress,
-		UserAgent:     &userAgent,
+		ID:              uuid.New().String(),
+		UserID:          user.ID,
+		TokenHash:       refreshHash,
+		ExpiresAt:       time.Now().Add(refreshLifetime),
+		LifetimeSeconds: int64(refreshLifetime.Seconds()),
+		IPAddress:       &ipAddress,
+		UserAgent:       &userAgent,
 	}
 
 	if err := db.Create(&rt).Error; err != nil {
-		helpers.InternalError(c, i18n.MsgAuthTokenGenerateFailed)
+		helpers.RespondError(c, http.StatusInternalServerError, helpers.CodeAuthTokenGenerationFailed, i18n.T(c, i18n.MsgAuthTokenGenerateFailed), nil)
 		return
 	}
 
 	// Log successful attempt
 	logAttempt(true, "")
-
-	// Debug: Log user email before preload
-	println("DEBUG: Loading DataKaryawan for user email:", user.Email)
+	recordAuthEvent(c, user.ID, models.AuditActionLogin, "login", map[string]interface{}{
+		"remember_me": req.RememberMe,
+	})
 
 	// Preload DataKaryawan for user (only active employees)
 	if err := db.Preload("DataKaryawan", "status_aktif = ?", "Aktif").First(&user, "id = ?", user.ID).Error; err != nil {
-		println("DEBUG: Failed to preload user:", err.Error())
-		helpers.InternalError(c, i18n.MsgCrudFetchFailed)
+		logger.FromContext(c.Request.Context()).Error("failed to load user after login", "user_id", user.ID, "error", err)
+		helpers.RespondError(c, http.StatusInternalServerError, helpers.CodeInternal, i18n.T(c, i18n.MsgCrudFetchFailed), nil)
 		return
 	}
-
-	// Debug: Check if DataKaryawan loaded
-	if user.DataKaryawan != nil {
-		println("DEBUG: DataKaryawan loaded successfully - NIP:", user.DataKaryawan.NIP)
-		if user.DataKaryawan.Nama != nil {
-			println("DEBUG: DataKaryawan Nama:", *user.DataKaryawan.Nama)
-		} else {
-			println("DEBUG: DataKaryawan Nama is nil")
-		}
-	} else {
-		println("DEBUG: DataKaryawan is nil - no matching email in data_karyawan table")
-	}
+	logger.FromContext(c.Request.Context()).Debug("user logged in", "user_id", user.ID, "has_employee_record", user.DataKaryawan != nil)
 
 	// Generate CSRF token for this user session
 	csrfToken, err := auth.GenerateCSRFToken(user.ID)
 	if err != nil {
-		helpers.InternalError(c, i18n.MsgAuthTokenGenerateFailed)
+		helpers.RespondError(c, http.StatusInternalServerError, helpers.CodeAuthTokenGenerationFailed, i18n.T(c, i18n.MsgAuthTokenGenerateFailed), nil)
 		return
 	}
 
 	// Set httpOnly cookies (tokens ONLY in cookies, NOT in response body)
 	isProduction := gin.Mode() == gin.ReleaseMode
-	helpers.SetAuthCookies(c, accessToken, refreshToken, isProduction)
+	helpers.SetAuthCookies(c, accessToken, refreshToken, refreshLifetime, isProduction)
 	helpers.SetCSRFCookie(c, csrfToken, isProduction)
 
 	// Return success with user info only (NO TOKENS in body for security)
-	helpers.SuccessResponse(c, http.StatusOK, i18n.MsgAuthLoginSuccess, user.ToUserInfo())
+	tokenResponse(c, http.StatusOK, i18n.MsgAuthLoginSuccess, user.ToUserInfo())
 }
 
 // RefreshToken handles token refresh with rotation (security best practice)
@@ -312,18 +320,18 @@ func RefreshToken(c *gin.Context) {
 	// Get refresh token from httpOnly cookie (secure)
 	refreshTokenFromCookie, err := c.Cookie("gloria_refresh_token")
 	if err != nil || refreshTokenFromCookie == "" {
-		helpers.Unauthorized(c, i18n.MsgAuthTokenInvalid)
+		helpers.RespondError(c, http.StatusUnauthorized, helpers.CodeAuthTokenInvalid, i18n.T(c, i18n.MsgAuthTokenInvalid), nil)
 		return
 	}
 
-	db := database.GetDB()
+	db := database.Primary(database.GetDB())
 
 	// Find refresh token (need to check hash)
 	var refreshTokens []models.RefreshToken
 	if err := db.Where("expires_at > ?", time.Now()).
 		Preload("User").
 		Find(&refreshTokens).Error; err != nil {
-		helpers.Unauthorized(c, i18n.MsgAuthTokenInvalid)
+		helpers.RespondError(c, http.StatusUnauthorized, helpers.CodeAuthTokenInvalid, i18n.T(c, i18n.MsgAuthTokenInvalid), nil)
 		return
 	}
 
@@ -336,7 +344,7 @@ func RefreshToken(c *gin.Context) {
 	}
 
 	if oldRT == nil {
-		helpers.Unauthorized(c, i18n.MsgAuthTokenInvalid)
+		helpers.RespondError(c, http.StatusUnauthorized, helpers.CodeAuthTokenInvalid, i18n.T(c, i18n.MsgAuthTokenInvalid), nil)
 		return
 	}
 
@@ -348,26 +356,26 @@ func RefreshToken(c *gin.Context) {
 			Where("user_id = ?", oldRT.User.ID).
 			Update("revoked_at", time.Now())
 
-		helpers.Unauthorized(c, i18n.MsgAuthTokenInvalid)
+		helpers.RespondError(c, http.StatusUnauthorized, helpers.CodeAuthTokenInvalid, i18n.T(c, i18n.MsgAuthTokenInvalid), nil)
 		return
 	}
 
 	// Check expiry
 	if time.Now().After(oldRT.ExpiresAt) {
-		helpers.Unauthorized(c, i18n.MsgAuthTokenExpired)
+		helpers.RespondError(c, http.StatusUnauthorized, helpers.CodeAuthTokenExpired, i18n.T(c, i18n.MsgAuthTokenExpired), nil)
 		return
 	}
 
 	// Check user is active
 	if !oldRT.User.IsActive {
-		helpers.Unauthorized(c, i18n.MsgAuthAccountInactive)
+		helpers.RespondError(c, http.StatusUnauthorized, helpers.CodeAuthAccountInactive, i18n.T(c, i18n.MsgAuthAccountInactive), nil)
 		return
 	}
 
 	// TOKEN ROTATION: Start transaction for atomic operation
 	tx := db.Begin()
 	if tx.Error != nil {
-		helpers.InternalError(c, i18n.MsgErrorInternal)
+		helpers.RespondError(c, http.StatusInternalServerError, helpers.CodeInternal, i18n.T(c, i18n.MsgErrorInternal), nil)
 		return
 	}
 
@@ -377,7 +385,7 @@ func RefreshToken(c *gin.Context) {
 	oldRT.LastUsedAt = &now
 	if err := tx.Save(oldRT).Error; err != nil {
 		tx.Rollback()
-		helpers.InternalError(c, i18n.MsgAuthRefreshFailed)
+		helpers.RespondError(c, http.StatusInternalServerError, helpers.CodeAuthRefreshFailed, i18n.T(c, i18n.MsgAuthRefreshFailed), nil)
 		return
 	}
 
@@ -385,7 +393,7 @@ func RefreshToken(c *gin.Context) {
 	accessToken, err := auth.GenerateAccessToken(oldRT.User.ID, oldRT.User.Email)
 	if err != nil {
 		tx.Rollback()
-		helpers.InternalError(c, i18n.MsgAuthTokenGenerateFailed)
+		helpers.RespondError(c, http.StatusInternalServerError, helpers.CodeAuthTokenGenerationFailed, i18n.T(c, i18n.MsgAuthTokenGenerateFailed), nil)
 		return
 	}
 
@@ -393,25 +401,30 @@ func RefreshToken(c *gin.Context) {
 	newRefreshToken, newRefreshHash, err := auth.GenerateRefreshToken()
 	if err != nil {
 		tx.Rollback()
-		helpers.InternalError(c, i18n.MsgAuthTokenGenerateFailed)
+		helpers.RespondError(c, http.StatusInternalServerError, helpers.CodeAuthTokenGenerationFailed, i18n.T(c, i18n.MsgAuthTokenGenerateFailed), nil)
 		return
 	}
 
-	// Store new refresh token
+	// Store new refresh token, keeping the lifetime chosen at login
+	refreshLifetime := auth.RefreshTokenExpiry
+	if oldRT.LifetimeSeconds > 0 {
+		refreshLifetime = time.Duration(oldRT.LifetimeSeconds) * time.Second
+	}
 	ipAddress := c.ClientIP()
 	userAgent := c.Request.UserAgent()
 	newRT := models.RefreshToken{
-		ID:            uuid.New().String(),
-		UserID: oldRT.User.ID,
-		TokenHash:     newRefreshHash,
-		ExpiresAt:     time.Now().Add(auth.RefreshTokenExpiry),
-		IPAddress:     &ipAddress,
-		UserAgent:     &userAgent,
+		ID:              uuid.New().String(),
+		UserID:          oldRT.User.ID,
+		TokenHash:       newRefreshHash,
+		ExpiresAt:       time.Now().Add(refreshLifetime),
+		LifetimeSeconds: int64(refreshLifetime.Seconds()),
+		IPAddress:       &ipAddress,
+		UserAgent:       &userAgent,
 	}
 
 	if err := tx.Create(&newRT).Error; err != nil {
 		tx.Rollback()
-		helpers.InternalError(c, i18n.MsgAuthRefreshFailed)
+		helpers.RespondError(c, http.StatusInternalServerError, helpers.CodeAuthRefreshFailed, i18n.T(c, i18n.MsgAuthRefreshFailed), nil)
 		return
 	}
 
@@ -419,63 +432,76 @@ func RefreshToken(c *gin.Context) {
 	csrfToken, err := auth.GenerateCSRFToken(oldRT.User.ID)
 	if err != nil {
 		tx.Rollback()
-		helpers.InternalError(c, i18n.MsgAuthTokenGenerateFailed)
+		helpers.RespondError(c, http.StatusInternalServerError, helpers.CodeAuthTokenGenerationFailed, i18n.T(c, i18n.MsgAuthTokenGenerateFailed), nil)
 		return
 	}
 
 	// Commit transaction
 	if err := tx.Commit().Error; err != nil {
-		helpers.InternalError(c, i18n.MsgErrorInternal)
+		helpers.RespondError(c, http.StatusInternalServerError, helpers.CodeInternal, i18n.T(c, i18n.MsgErrorInternal), nil)
 		return
 	}
 
 	// Update cookies with new tokens (secure - httpOnly)
 	isProduction := gin.Mode() == gin.ReleaseMode
 	helpers.UpdateAccessTokenCookie(c, accessToken, isProduction)
-	helpers.SetAuthCookies(c, accessToken, newRefreshToken, isProduction) // Update both tokens
+	helpers.SetAuthCookies(c, accessToken, newRefreshToken, refreshLifetime, isProduction) // Update both tokens
 	helpers.SetCSRFCookie(c, csrfToken, isProduction)
 
 	// Log successful token rotation for audit
-	log.Printf("[TOKEN_ROTATION] User: %s | Old Token: %s | New Token: %s | IP: %s",
-		oldRT.User.Email, oldRT.ID, newRT.ID, ipAddress)
+	logger.FromContext(c.Request.Context()).Info("refresh token rotated",
+		"user_id", oldRT.UserID, "old_token_id", oldRT.ID, "new_token_id", newRT.ID, "client_ip", ipAddress)
 
 	// Return success only (NO TOKEN in body for security)
-	helpers.MessageOnlyResponse(c, http.StatusOK, i18n.MsgAuthRefreshSuccess)
+	tokenResponse(c, http.StatusOK, i18n.MsgAuthRefreshSuccess, nil)
+}
+
+// tokenResponse sends a success response for an endpoint that issued an access token cookie.
+// expires_in (seconds) lets clients schedule a refresh without reading the httpOnly token.
+func tokenResponse(c *gin.Context, status int, key string, data interface{}) {
+	body := gin.H{
+		"message":    i18n.T(c, key),
+		"expires_in": int64(auth.AccessTokenTTL().Seconds()),
+	}
+	if data != nil {
+		body["data"] = data
+	}
+	c.JSON(status, body)
 }
 
 // ChangePassword handles password change
 func ChangePassword(c *gin.Context) {
 	userID := c.GetString("user_id")
 	if userID == "" {
-		helpers.Unauthorized(c, i18n.MsgErrorUnauthorized)
+		helpers.RespondError(c, http.StatusUnauthorized, helpers.CodeUnauthorized, i18n.T(c, i18n.MsgErrorUnauthorized), nil)
 		return
 	}
 
 	var req models.ChangePasswordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		helpers.BadRequest(c, i18n.MsgErrorBadRequest)
+		helpers.RespondBindError(c, err)
 		return
 	}
 
-	db := database.GetDB()
+	db := database.Primary(database.GetDB())
 
 	// Get user
 	var user models.User
 	if err := db.First(&user, "id = ?", userID).Error; err != nil {
-		helpers.NotFound(c, i18n.MsgUserNotFound)
+		helpers.RespondError(c, http.StatusNotFound, helpers.CodeUserNotFound, i18n.T(c, i18n.MsgUserNotFound), nil)
 		return
 	}
 
 	// Verify current password
 	if !auth.VerifyPassword(req.CurrentPassword, user.PasswordHash) {
-		helpers.Unauthorized(c, i18n.MsgAuthOldPasswordIncorrect)
+		helpers.RespondError(c, http.StatusUnauthorized, helpers.CodeAuthOldPasswordIncorrect, i18n.T(c, i18n.MsgAuthOldPasswordIncorrect), nil)
 		return
 	}
 
 	// Hash new password
 	newHash, err := auth.HashPassword(req.NewPassword)
 	if err != nil {
-		helpers.InternalError(c, i18n.MsgAuthPasswordHashFailed)
+		helpers.RespondError(c, http.StatusInternalServerError, helpers.CodeAuthPasswordHashFailed, i18n.T(c, i18n.MsgAuthPasswordHashFailed), nil)
 		return
 	}
 
@@ -485,7 +511,7 @@ func ChangePassword(c *gin.Context) {
 	user.LastPasswordChange = &now
 
 	if err := db.Save(&user).Error; err != nil {
-		helpers.InternalError(c, i18n.MsgCrudUpdateFailed)
+		helpers.RespondError(c, http.StatusInternalServerError, helpers.CodeInternal, i18n.T(c, i18n.MsgCrudUpdateFailed), nil)
 		return
 	}
 
@@ -494,6 +520,10 @@ func ChangePassword(c *gin.Context) {
 		Where("user_id = ?", userID).
 		Update("revoked_at", time.Now())
 
+	recordAuthEvent(c, userID, models.AuditActionUpdate, "password_changed", map[string]interface{}{
+		"sessions_revoked": true,
+	})
+
 	helpers.MessageOnlyResponse(c, http.StatusOK, i18n.MsgAuthPasswordChanged)
 }
 
@@ -501,22 +531,59 @@ func ChangePassword(c *gin.Context) {
 func GetMe(c *gin.Context) {
 	userID := c.GetString("user_id")
 	if userID == "" {
-		helpers.Unauthorized(c, i18n.MsgErrorUnauthorized)
+		helpers.RespondError(c, http.StatusUnauthorized, helpers.CodeUnauthorized, i18n.T(c, i18n.MsgErrorUnauthorized), nil)
 		return
 	}
 
-	db := database.GetDB()
+	info, err := currentUserInfo(c, userID)
+	if err != nil {
+		helpers.RespondError(c, http.StatusNotFound, helpers.CodeUserNotFound, i18n.T(c, i18n.MsgUserNotFound), nil)
+		return
+	}
 
+	helpers.DataResponse(c, http.StatusOK, info)
+}
+
+// currentUserInfo loads the authenticated user as returned by GET /auth/me
+func currentUserInfo(c *gin.Context, userID string) (*models.UserInfo, error) {
 	var user models.User
-	if err := db.Preload("UserRoles.Role").
+	if err := database.GetDB().Preload("UserRoles.Role").
 		Preload("UserPositions.Position").
 		Preload("DataKaryawan", "status_aktif = ?", "Aktif").
 		First(&user, "id = ?", userID).Error; err != nil {
-		helpers.NotFound(c, i18n.MsgUserNotFound)
+		return nil, err
+	}
+
+	info := user.ToUserInfo()
+	if impersonatorID := c.GetString("impersonator_id"); impersonatorID != "" {
+		info.ImpersonatorID = &impersonatorID
+	}
+	return info, nil
+}
+
+// GetCSRFToken issues a fresh CSRF token for the authenticated user.
+// A client that reloads with a valid auth cookie but no readable CSRF cookie calls
+// this before its first state-changing request instead of waiting for a 403.
+// The token is set as the gloria_csrf_token cookie and also returned in the body
+// so the client can seed its X-CSRF-Token header straight away.
+func GetCSRFToken(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		helpers.RespondError(c, http.StatusUnauthorized, helpers.CodeUnauthorized, i18n.T(c, i18n.MsgErrorUnauthorized), nil)
+		return
+	}
+
+	csrfToken, err := auth.GenerateCSRFToken(userID)
+	if err != nil {
+		helpers.RespondError(c, http.StatusInternalServerError, helpers.CodeAuthTokenGenerationFailed, i18n.T(c, i18n.MsgAuthTokenGenerateFailed), nil)
 		return
 	}
 
-	helpers.DataResponse(c, http.StatusOK, user.ToUserInfo())
+	isProduction := gin.Mode() == gin.ReleaseMode
+	helpers.SetCSRFCookie(c, csrfToken, isProduction)
+
+	c.Header("Cache-Control", "no-store")
+	helpers.DataResponse(c, http.StatusOK, gin.H{"csrf_token": csrfToken})
 }
 
 // Logout revokes refresh token
@@ -525,19 +592,21 @@ func GetMe(c *gin.Context) {
 func Logout(c *gin.Context) {
 	// Try to validate CSRF if we have an access token (even if expired)
 	// This prevents logout CSRF attacks while allowing logout with expired JWT
+	var sessionUserID string
 	accessTokenFromCookie, _ := c.Cookie("gloria_access_token")
 	if accessTokenFromCookie != "" {
 		// Parse token without validation to get user_id (works even if expired)
 		claims, err := auth.ParseTokenClaims(accessTokenFromCookie)
 		if err == nil && claims.UserID != "" {
+			sessionUserID = claims.UserID
 			// We have user_id, validate CSRF token
 			csrfToken := c.GetHeader("X-CSRF-Token")
 			if csrfToken == "" {
-				c.JSON(http.StatusForbidden, gin.H{"error": "CSRF token is required"})
+				helpers.RespondError(c, http.StatusForbidden, helpers.CodeAuthCSRFRequired, "CSRF token is required", nil)
 				return
 			}
 			if err := auth.ValidateCSRFToken(csrfToken, claims.UserID); err != nil {
-				c.JSON(http.StatusForbidden, gin.H{"error": "CSRF validation failed"})
+				helpers.RespondError(c, http.StatusForbidden, helpers.CodeAuthCSRFInvalid, "CSRF validation failed", nil)
 				return
 			}
 		}
@@ -546,13 +615,17 @@ func Logout(c *gin.Context) {
 	// Get refresh token from httpOnly cookie (secure)
 	refreshTokenFromCookie, err := c.Cookie("gloria_refresh_token")
 	if err != nil || refreshTokenFromCookie == "" {
+		if sessionUserID != "" {
+			recordAuthEvent(c, sessionUserID, models.AuditActionLogout, "logout", nil)
+		}
+
 		// Even if no cookie, still clear cookies for logout
 		helpers.ClearAuthCookies(c)
 		helpers.MessageOnlyResponse(c, http.StatusOK, i18n.MsgAuthLogoutSuccess)
 		return
 	}
 
-	db := database.GetDB()
+	db := database.Primary(database.GetDB())
 
 	// Find and revoke refresh token
 	var refreshTokens []models.RefreshToken
@@ -568,6 +641,9 @@ func Logout(c *gin.Context) {
 			now := time.Now()
 			refreshTokens[i].RevokedAt = &now
 			db.Save(&refreshTokens[i])
+			recordAuthEvent(c, refreshTokens[i].UserID, models.AuditActionLogout, "logout", map[string]interface{}{
+				"session_revoked": true,
+			})
 
 			// Clear httpOnly cookies
 			helpers.ClearAuthCookies(c)
@@ -577,6 +653,10 @@ func Logout(c *gin.Context) {
 		}
 	}
 
+	if sessionUserID != "" {
+		recordAuthEvent(c, sessionUserID, models.AuditActionLogout, "logout", nil)
+	}
+
 	// Even if token not found in DB, still clear cookies (client-side logout)
 	helpers.ClearAuthCookies(c)
 
@@ -594,24 +674,15 @@ type ResetPasswordRequest struct {
 	NewPassword string `json:"new_password" binding:"required,min=8,max=100"`
 }
 
-// generateResetToken generates a secure random token
-func generateResetToken() (string, error) {
-	bytes := make([]byte, 32)
-	if _, err := rand.Read(bytes); err != nil {
-		return "", err
-	}
-	return hex.EncodeToString(bytes), nil
-}
-
 // ForgotPassword handles forgot password request
 func ForgotPassword(c *gin.Context) {
 	var req ForgotPasswordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		helpers.BadRequest(c, i18n.MsgErrorBadRequest)
+		helpers.RespondBindError(c, err)
 		return
 	}
 
-	db := database.GetDB()
+	db := database.Primary(database.GetDB())
 
 	// Find user by email
 	var user models.User
@@ -627,37 +698,41 @@ func ForgotPassword(c *gin.Context) {
 		return
 	}
 
-	// Generate reset token
-	resetToken, err := generateResetToken()
+	// Generate reset token; only the verifier's hash is stored
+	resetToken, err := auth.NewResetToken()
 	if err != nil {
-		helpers.InternalError(c, i18n.MsgAuthTokenGenerateFailed)
+		helpers.RespondError(c, http.StatusInternalServerError, helpers.CodeAuthTokenGenerationFailed, i18n.T(c, i18n.MsgAuthTokenGenerateFailed), nil)
 		return
 	}
 
-	// Hash the token before storing
-	tokenHash, err := auth.HashPassword(resetToken)
-	if err != nil {
-		helpers.InternalError(c, i18n.MsgAuthPasswordHashFailed)
-		return
-	}
-
-	// Set expiry to 1 hour from now
-	expiresAt := time.Now().Add(1 * time.Hour)
+	// A new request replaces any outstanding token: only the latest link works
+	requestedAt := clock.Now()
+	replacedOutstanding := user.PasswordResetToken != nil &&
+		user.PasswordResetExpiresAt != nil && requestedAt.Before(*user.PasswordResetExpiresAt)
+	expiresAt := requestedAt.Add(auth.PasswordResetTTL())
 
 	// Update user with reset token
-	user.PasswordResetToken = &tokenHash
+	user.PasswordResetSelector = &resetToken.Selector
+	user.PasswordResetToken = &resetToken.VerifierHash
 	user.PasswordResetExpiresAt = &expiresAt
+	user.PasswordResetRequestedAt = &requestedAt
 
 	if err := db.Save(&user).Error; err != nil {
-		helpers.InternalError(c, i18n.MsgErrorInternal)
+		helpers.RespondError(c, http.StatusInternalServerError, helpers.CodeInternal, i18n.T(c, i18n.MsgErrorInternal), nil)
 		return
 	}
 
+	// Unknown or inactive emails are not audited: the actor would be unidentifiable
+	recordAuthEvent(c, user.ID, models.AuditActionUpdate, "password_reset_requested", map[string]interface{}{
+		"expires_at":           expiresAt,
+		"replaced_outstanding": replacedOutstanding,
+	})
+
 	// Send email with reset token (not hash)
 	emailSender := email.NewEmailSender()
-	if err := emailSender.SendPasswordResetEmail(user.Email, resetToken); err != nil {
+	if err := emailSender.SendPasswordResetEmail(user.Email, resetToken.Token); err != nil {
 		// Log error but don't reveal to user
-		helpers.InternalError(c, i18n.MsgErrorInternal)
+		helpers.RespondError(c, http.StatusInternalServerError, helpers.CodeInternal, i18n.T(c, i18n.MsgErrorInternal), nil)
 		return
 	}
 
@@ -668,53 +743,83 @@ func ForgotPassword(c *gin.Context) {
 func ResetPassword(c *gin.Context) {
 	var req ResetPasswordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		helpers.BadRequest(c, i18n.MsgErrorBadRequest)
+		helpers.RespondBindError(c, err)
 		return
 	}
 
-	db := database.GetDB()
+	db := database.Primary(database.GetDB())
 
-	// Find user with non-expired reset token
-	var users []models.User
-	if err := db.Where("password_reset_token IS NOT NULL AND password_reset_expires_at > ?", time.Now()).Find(&users).Error; err != nil {
-		helpers.BadRequest(c, i18n.MsgAuthPasswordResetInvalid)
+	// Find the token's owner through the indexed selector, then check the verifier
+	selector, verifier, ok := auth.SplitResetToken(req.Token)
+	if !ok {
+		helpers.RespondError(c, http.StatusBadRequest, helpers.CodeAuthPasswordResetInvalid, i18n.T(c, i18n.MsgAuthPasswordResetInvalid), nil)
 		return
 	}
 
-	// Find user with matching token
-	var targetUser *models.User
-	for i := range users {
-		if users[i].PasswordResetToken != nil && auth.VerifyPassword(req.Token, *users[i].PasswordResetToken) {
-			targetUser = &users[i]
-			break
-		}
-	}
-
-	if targetUser == nil {
-		helpers.BadRequest(c, i18n.MsgAuthPasswordResetExpired)
+	var targetUser models.User
+	if err := db.Where("password_reset_selector = ? AND password_reset_expires_at > ?", selector, clock.Now()).
+		First(&targetUser).Error; err != nil ||
+		targetUser.PasswordResetToken == nil || !auth.VerifyPassword(verifier, *targetUser.PasswordResetToken) {
+		helpers.RespondError(c, http.StatusBadRequest, helpers.CodeAuthPasswordResetExpired, i18n.T(c, i18n.MsgAuthPasswordResetExpired), nil)
 		return
 	}
 
 	// Hash new password
 	hashedPassword, err := auth.HashPassword(req.NewPassword)
 	if err != nil {
-		helpers.InternalError(c, i18n.MsgAuthPasswordHashFailed)
+		helpers.RespondError(c, http.StatusInternalServerError, helpers.CodeAuthPasswordHashFailed, i18n.T(c, i18n.MsgAuthPasswordHashFailed), nil)
+		return
+	}
+
+	// Set the password and consume the token in one statement. The WHERE only matches while
+	// the verified token is still stored, so of two concurrent resets with the same token
+	// (or a reset racing a newer forgot-password request) exactly one can succeed.
+	now := clock.Now()
+	result := db.Model(&models.User{}).
+		Where("id = ? AND password_reset_selector = ? AND password_reset_token = ? AND password_reset_expires_at > ?",
+			targetUser.ID, selector, *targetUser.PasswordResetToken, now).
+		Updates(map[string]interface{}{
+			"password_hash":               hashedPassword,
+			"password_reset_selector":     nil,
+			"password_reset_token":        nil,
+			"password_reset_expires_at":   nil,
+			"password_reset_requested_at": nil,
+			"last_password_change":        now,
+			"failed_login_attempts":       0,
+			"locked_until":                nil,
+		})
+	if result.Error != nil {
+		helpers.RespondError(c, http.StatusInternalServerError, helpers.CodeInternal, i18n.T(c, i18n.MsgCrudUpdateFailed), nil)
 		return
 	}
-
-	// Update password and clear reset token
-	now := time.Now()
-	targetUser.PasswordHash = hashedPassword
-	targetUser.PasswordResetToken = nil
-	targetUser.PasswordResetExpiresAt = nil
-	targetUser.LastPasswordChange = &now
-	targetUser.FailedLoginAttempts = 0
-	targetUser.LockedUntil = nil
-
-	if err := db.Save(targetUser).Error; err != nil {
-		helpers.InternalError(c, i18n.MsgCrudUpdateFailed)
+	if result.RowsAffected == 0 {
+		helpers.RespondError(c, http.StatusBadRequest, helpers.CodeAuthPasswordResetExpired, i18n.T(c, i18n.MsgAuthPasswordResetExpired), nil)
 		return
 	}
 
+	recordAuthEvent(c, targetUser.ID, models.AuditActionUpdate, "password_reset", nil)
+
 	helpers.MessageOnlyResponse(c, http.StatusOK, i18n.MsgAuthPasswordResetSuccess)
 }
+
+// recordAuthEvent writes an authentication event for userID to the audit trail with the
+// request's IP and user agent. Metadata must not carry credentials; the audit service
+// redacts secret-looking keys as a safety net. Failures are logged, never surfaced.
+func recordAuthEvent(c *gin.Context, userID string, action models.AuditAction, event string, metadata map[string]interface{}) {
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+	metadata["event"] = event
+
+	services.NewAuditService(database.GetDB()).RecordEntry(services.AuditEntry{
+		ActorID:      userID,
+		Action:       action,
+		TargetType:   "auth",
+		TargetID:     userID,
+		TargetUserID: &userID,
+		Metadata:     metadata,
+		IPAddress:    c.ClientIP(),
+		UserAgent:    c.Request.UserAgent(),
+		RequestID:    logger.RequestID(c.Request.Context()),
+	})
+}
@@ -0,0 +1,273 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"backend/internal/helpers"
+	"backend/internal/middleware"
+	"backend/internal/models"
+	"backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DelegationHandler handles HTTP requests for delegations
+type DelegationHandler struct {
+	delegationService *services.DelegationService
+}
+
+// NewDelegationHandler creates a new DelegationHandler instance
+func NewDelegationHandler(delegationService *services.DelegationService) *DelegationHandler {
+	return &DelegationHandler{
+		delegationService: delegationService,
+	}
+}
+
+// CreateDelegation handles creating a new delegation from the authenticated user
+// @Summary Create a new delegation
+// @Tags delegations
+// @Accept json
+// @Produce json
+// @Param request body models.CreateDelegationRequest true "Delegation data"
+// @Success 201 {object} models.DelegationResponse
+// @Failure 400 {object} map[string]string
+// @Router /delegations [post]
+func (h *DelegationHandler) CreateDelegation(c *gin.Context) {
+	var req models.CreateDelegationRequest
+
+	// HTTP: Parse and validate request
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(helpers.BindErrorStatus(err), helpers.BindErrorBody(c, err))
+		return
+	}
+
+	// HTTP: Get authenticated user
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// Business logic: Create delegation via service
+	delegation, err := h.delegationService.CreateDelegation(req, userID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// HTTP: Format response
+	c.JSON(http.StatusCreated, delegation.ToResponse())
+}
+
+// GetDelegations handles getting list of delegations with pagination and filters
+// @Summary Get list of delegations
+// @Tags delegations
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Page size" default(10)
+// @Param type query string false "Filter by delegation type"
+// @Param delegator_id query string false "Filter by delegator user ID"
+// @Param delegate_id query string false "Filter by delegate user ID"
+// @Param is_active query bool false "Filter by active status"
+// @Param effective query bool false "Filter by whether the delegation is currently in effect"
+// @Param sort_by query string false "Sort by field" default(effective_from)
+// @Param sort_order query string false "Sort order (asc/desc)" default(desc)
+// @Success 200 {object} services.DelegationListResult
+// @Failure 500 {object} map[string]string
+// @Router /delegations [get]
+func (h *DelegationHandler) GetDelegations(c *gin.Context) {
+	// HTTP: Parse query parameters
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+	sortBy := c.DefaultQuery("sort_by", "effective_from")
+	sortOrder := c.DefaultQuery("sort_order", "desc")
+
+	// HTTP: Parse is_active filter
+	var isActive *bool
+	if isActiveStr := c.Query("is_active"); isActiveStr != "" {
+		val, _ := strconv.ParseBool(isActiveStr)
+		isActive = &val
+	}
+
+	// HTTP: Parse effective filter
+	var effective *bool
+	if effectiveStr := c.Query("effective"); effectiveStr != "" {
+		val, _ := strconv.ParseBool(effectiveStr)
+		effective = &val
+	}
+
+	// Build params
+	params := services.DelegationListParams{
+		Page:        page,
+		PageSize:    pageSize,
+		Type:        c.Query("type"),
+		DelegatorID: c.Query("delegator_id"),
+		DelegateID:  c.Query("delegate_id"),
+		IsActive:    isActive,
+		Effective:   effective,
+		SortBy:      sortBy,
+		SortOrder:   sortOrder,
+	}
+
+	// Business logic: Get delegations via service
+	result, err := h.delegationService.GetDelegations(params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// HTTP: Format response
+	c.JSON(http.StatusOK, gin.H{
+		"data":        result.Data,
+		"total":       result.Total,
+		"page":        result.Page,
+		"page_size":   result.PageSize,
+		"total_pages": result.TotalPages,
+	})
+}
+
+// GetDelegationByID handles getting a single delegation by ID
+// @Summary Get delegation by ID
+// @Tags delegations
+// @Produce json
+// @Param id path string true "Delegation ID"
+// @Success 200 {object} models.DelegationResponse
+// @Failure 404 {object} map[string]string
+// @Router /delegations/{id} [get]
+func (h *DelegationHandler) GetDelegationByID(c *gin.Context) {
+	// HTTP: Get ID from URL
+	id := c.Param("id")
+
+	// Business logic: Get delegation via service
+	delegation, err := h.delegationService.GetDelegationByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	// HTTP: Format response
+	c.JSON(http.StatusOK, delegation.ToResponse())
+}
+
+// UpdateDelegation handles updating a delegation
+// @Summary Update a delegation
+// @Tags delegations
+// @Accept json
+// @Produce json
+// @Param id path string true "Delegation ID"
+// @Param request body models.UpdateDelegationRequest true "Delegation data"
+// @Success 200 {object} models.DelegationResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /delegations/{id} [put]
+func (h *DelegationHandler) UpdateDelegation(c *gin.Context) {
+	// HTTP: Get ID from URL
+	id := c.Param("id")
+
+	// HTTP: Parse and validate request
+	var req models.UpdateDelegationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(helpers.BindErrorStatus(err), helpers.BindErrorBody(c, err))
+		return
+	}
+
+	// HTTP: Get authenticated user
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// Business logic: Update delegation via service
+	delegation, err := h.delegationService.UpdateDelegation(id, req, userID.(string))
+	if err != nil {
+		if err.Error() == "delegasi tidak ditemukan" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else if err.Error() == "hanya pemberi delegasi yang dapat mengubah delegasi ini" {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	// HTTP: Format response
+	c.JSON(http.StatusOK, delegation.ToResponse())
+}
+
+// RevokeDelegation handles ending a delegation immediately
+// @Summary Revoke a delegation
+// @Tags delegations
+// @Produce json
+// @Param id path string true "Delegation ID"
+// @Success 200 {object} models.DelegationResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /delegations/{id}/revoke [post]
+func (h *DelegationHandler) RevokeDelegation(c *gin.Context) {
+	// HTTP: Get ID from URL
+	id := c.Param("id")
+
+	// HTTP: Get authenticated user
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// Business logic: Revoke delegation via service
+	delegation, err := h.delegationService.RevokeDelegation(id, userID.(string), middleware.DataScopeFromContext(c))
+	if err != nil {
+		respondDelegationRevokeError(c, err)
+		return
+	}
+
+	// HTTP: Format response
+	c.JSON(http.StatusOK, delegation.ToResponse())
+}
+
+// DeleteDelegation handles withdrawing a delegation; it is revoked and kept for history
+// @Summary Delete a delegation
+// @Tags delegations
+// @Produce json
+// @Param id path string true "Delegation ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /delegations/{id} [delete]
+func (h *DelegationHandler) DeleteDelegation(c *gin.Context) {
+	// HTTP: Get ID from URL
+	id := c.Param("id")
+
+	// HTTP: Get authenticated user
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// Business logic: Delete delegation via service
+	if err := h.delegationService.DeleteDelegation(id, userID.(string), middleware.DataScopeFromContext(c)); err != nil {
+		respondDelegationRevokeError(c, err)
+		return
+	}
+
+	// HTTP: Format response
+	c.JSON(http.StatusOK, gin.H{"message": "Delegasi berhasil dihapus"})
+}
+
+// respondDelegationRevokeError maps revoke and delete errors to HTTP status codes
+func respondDelegationRevokeError(c *gin.Context, err error) {
+	switch err.Error() {
+	case "delegasi tidak ditemukan":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case "hanya pemberi delegasi yang dapat mencabut delegasi ini":
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
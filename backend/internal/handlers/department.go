@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"strconv"
 
+	"backend/internal/helpers"
 	"backend/internal/models"
 	"backend/internal/services"
 
@@ -37,7 +38,7 @@ func (h *DepartmentHandler) CreateDepartment(c *gin.Context) {
 
 	// HTTP: Parse and validate request
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(helpers.BindErrorStatus(err), helpers.BindErrorBody(c, err))
 		return
 	}
 
@@ -180,7 +181,7 @@ func (h *DepartmentHandler) UpdateDepartment(c *gin.Context) {
 	// HTTP: Parse and validate request
 	var req models.UpdateDepartmentRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(helpers.BindErrorStatus(err), helpers.BindErrorBody(c, err))
 		return
 	}
 
@@ -206,6 +207,51 @@ func (h *DepartmentHandler) UpdateDepartment(c *gin.Context) {
 	c.JSON(http.StatusOK, department.ToResponse())
 }
 
+// MoveDepartment handles moving a department and its subtree under a new parent
+// @Summary Move a department
+// @Description Reparents a department with its sub-departments; the new parent cannot be one of its descendants
+// @Tags departments
+// @Accept json
+// @Produce json
+// @Param id path string true "Department ID"
+// @Param request body models.MoveDepartmentRequest true "New parent (null moves to the root)"
+// @Success 200 {object} models.DepartmentResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /departments/{id}/move [patch]
+func (h *DepartmentHandler) MoveDepartment(c *gin.Context) {
+	// HTTP: Get ID from URL
+	id := c.Param("id")
+
+	// HTTP: Parse and validate request
+	var req models.MoveDepartmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(helpers.BindErrorStatus(err), helpers.BindErrorBody(c, err))
+		return
+	}
+
+	// HTTP: Get authenticated user
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// Business logic: Move department via service
+	department, err := h.departmentService.MoveDepartment(id, req, userID.(string))
+	if err != nil {
+		if err.Error() == "departemen tidak ditemukan" || err.Error() == "parent departemen tidak ditemukan" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	// HTTP: Format response
+	c.JSON(http.StatusOK, department.ToResponse())
+}
+
 // DeleteDepartment handles deleting a department
 // @Summary Delete a department
 // @Tags departments
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"net/http"
+
+	"backend/internal/email"
+	"backend/internal/helpers"
+	"backend/internal/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EmailAdminHandler handles administrative email operations
+type EmailAdminHandler struct{}
+
+// NewEmailAdminHandler creates a new EmailAdminHandler instance
+func NewEmailAdminHandler() *EmailAdminHandler {
+	return &EmailAdminHandler{}
+}
+
+// SendTestEmailRequest represents the request body for sending a test email
+type SendTestEmailRequest struct {
+	To string `json:"to" binding:"required,email"`
+}
+
+// SendTestEmail handles sending a test email synchronously through the current provider
+// @Summary Send a test email
+// @Description Sends a test message using the current provider configuration and reports the result with connection diagnostics (credentials are never included)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body SendTestEmailRequest true "Recipient address"
+// @Success 200 {object} email.TestEmailResult
+// @Failure 400 {object} map[string]string
+// @Failure 502 {object} email.TestEmailResult
+// @Router /admin/email/test [post]
+func (h *EmailAdminHandler) SendTestEmail(c *gin.Context) {
+	// HTTP: Parse request body
+	var req SendTestEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(helpers.BindErrorStatus(err), helpers.BindErrorBody(c, err))
+		return
+	}
+
+	// Business logic: Send the test email, bypassing the queue
+	result := email.SendTestEmail(req.To)
+
+	// HTTP: Format response
+	if !result.Success {
+		logger.FromContext(c.Request.Context()).Warn("test email failed",
+			"recipient", req.To, "provider", result.Diagnostics.Provider, "error", result.Error)
+		c.JSON(http.StatusBadGateway, result)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
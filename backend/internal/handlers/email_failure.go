@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"net/http"
+
+	"backend/internal/helpers"
+	"backend/internal/models"
+	"backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EmailFailureHandler handles HTTP requests for undeliverable emails
+type EmailFailureHandler struct {
+	emailFailureService *services.EmailFailureService
+}
+
+// NewEmailFailureHandler creates a new EmailFailureHandler instance
+func NewEmailFailureHandler(emailFailureService *services.EmailFailureService) *EmailFailureHandler {
+	return &EmailFailureHandler{
+		emailFailureService: emailFailureService,
+	}
+}
+
+// GetEmailFailures handles listing emails that could not be delivered after all retries
+// @Summary List email delivery failures
+// @Tags admin
+// @Produce json
+// @Param recipient query string false "Recipient (partial match)"
+// @Param template query string false "Email template"
+// @Param page query int false "Page number"
+// @Param limit query int false "Page size"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Router /admin/email-failures [get]
+func (h *EmailFailureHandler) GetEmailFailures(c *gin.Context) {
+	// HTTP: Parse query parameters
+	var filter models.EmailFailureFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(helpers.BindErrorStatus(err), helpers.BindErrorBody(c, err))
+		return
+	}
+
+	// Business logic: Get email failures via service
+	result, err := h.emailFailureService.GetEmailFailures(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// HTTP: Format response
+	c.JSON(http.StatusOK, gin.H{
+		"data":        result.Data,
+		"total":       result.Total,
+		"page":        result.Page,
+		"page_size":   result.PageSize,
+		"total_pages": result.TotalPages,
+	})
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"time"
+
+	"backend/internal/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readinessCheckTimeout bounds each dependency check of the readiness probe
+const readinessCheckTimeout = 2 * time.Second
+
+// HealthHandler serves liveness and readiness probes
+type HealthHandler struct {
+	db *sql.DB
+}
+
+// NewHealthHandler creates a new HealthHandler instance
+func NewHealthHandler(db *sql.DB) *HealthHandler {
+	return &HealthHandler{db: db}
+}
+
+// HealthCheck represents the result of one readiness check
+type HealthCheck struct {
+	Status    string   `json:"status"`
+	LatencyMs int64    `json:"latency_ms,omitempty"`
+	Error     string   `json:"error,omitempty"`
+	Pending   []string `json:"pending,omitempty"`
+}
+
+// Liveness handles the cheap liveness probe; it never touches dependencies
+// @Summary Liveness probe
+// @Tags system
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Router /health [get]
+func (h *HealthHandler) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "message": "Server is running"})
+}
+
+// Readiness handles the readiness probe: it pings the database and verifies that
+// all migrated tables exist, returning 503 with details when a check fails
+// @Summary Readiness probe
+// @Tags system
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 503 {object} map[string]interface{}
+// @Router /health/ready [get]
+func (h *HealthHandler) Readiness(c *gin.Context) {
+	checks := map[string]HealthCheck{}
+	ready := true
+
+	// Database connectivity
+	dbCheck := h.checkDatabase(c.Request.Context())
+	checks["database"] = dbCheck
+	if dbCheck.Status != "ok" {
+		ready = false
+	}
+
+	// Migration status, only meaningful when the database is reachable
+	if dbCheck.Status == "ok" {
+		migrationCheck := h.checkMigrations(c.Request.Context())
+		checks["migrations"] = migrationCheck
+		if migrationCheck.Status != "ok" {
+			ready = false
+		}
+	}
+
+	if !ready {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "unavailable", "checks": checks})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "checks": checks})
+}
+
+// checkDatabase pings the database with a short timeout
+func (h *HealthHandler) checkDatabase(ctx context.Context) HealthCheck {
+	ctx, cancel := context.WithTimeout(ctx, readinessCheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	if err := h.db.PingContext(ctx); err != nil {
+		return HealthCheck{Status: "error", LatencyMs: time.Since(start).Milliseconds(), Error: err.Error()}
+	}
+	return HealthCheck{Status: "ok", LatencyMs: time.Since(start).Milliseconds()}
+}
+
+// checkMigrations reports models whose tables have not been created yet
+func (h *HealthHandler) checkMigrations(ctx context.Context) HealthCheck {
+	ctx, cancel := context.WithTimeout(ctx, readinessCheckTimeout)
+	defer cancel()
+
+	pending, err := database.PendingMigrations(ctx)
+	if err != nil {
+		return HealthCheck{Status: "error", Error: err.Error()}
+	}
+	if len(pending) > 0 {
+		return HealthCheck{Status: "pending", Pending: pending}
+	}
+	return HealthCheck{Status: "ok"}
+}
@@ -1,9 +1,14 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
+	"backend/internal/logger"
+	"backend/internal/middleware"
 	"backend/internal/services"
 
 	"github.com/gin-gonic/gin"
@@ -12,12 +17,14 @@ import (
 // KaryawanHandler handles HTTP requests for employees
 type KaryawanHandler struct {
 	karyawanService *services.KaryawanService
+	syncService     *services.KaryawanSyncService
 }
 
 // NewKaryawanHandler creates a new KaryawanHandler instance
-func NewKaryawanHandler(karyawanService *services.KaryawanService) *KaryawanHandler {
+func NewKaryawanHandler(karyawanService *services.KaryawanService, syncService *services.KaryawanSyncService) *KaryawanHandler {
 	return &KaryawanHandler{
 		karyawanService: karyawanService,
+		syncService:     syncService,
 	}
 }
 
@@ -27,41 +34,140 @@ func NewKaryawanHandler(karyawanService *services.KaryawanService) *KaryawanHand
 // @Produce json
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Page size" default(10)
+// @Param page_size query int false "Page size (alias of limit)"
 // @Param search query string false "Search by name, email, or NIP"
-// @Param bagian_kerja query string false "Filter by bagian kerja"
+// @Param bagian_kerja query string false "Filter by bagian kerja (department)"
+// @Param bidang_kerja query string false "Filter by bidang kerja (position)"
 // @Param jenis_karyawan query string false "Filter by jenis karyawan"
-// @Param status_aktif query string false "Filter by status aktif"
-// @Success 200 {object} services.KaryawanListResult
+// @Param status_aktif query string false "Filter by exact status aktif"
+// @Param is_active query bool false "Filter by active status" default(true)
+// @Param cursor query string false "Keyset pagination cursor; when present (even empty) page is ignored and next_cursor is returned"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /employees [get]
 func (h *KaryawanHandler) GetKaryawans(c *gin.Context) {
 	// HTTP: Parse query parameters
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-	search := c.Query("search")
-	bagianKerja := c.Query("bagian_kerja")
-	jenisKaryawan := c.Query("jenis_karyawan")
-	statusAktif := c.Query("status_aktif")
-
-	// Build params
-	params := services.KaryawanListParams{
-		Page:          page,
-		Limit:         limit,
-		Search:        search,
-		BagianKerja:   bagianKerja,
-		JenisKaryawan: jenisKaryawan,
-		StatusAktif:   statusAktif,
+	params := parseKaryawanListFilters(c)
+	params.Page, _ = strconv.Atoi(c.DefaultQuery("page", "1"))
+	params.Limit, _ = strconv.Atoi(c.DefaultQuery("limit", c.DefaultQuery("page_size", "10")))
+
+	// HTTP: Keyset pagination is selected by the presence of cursor
+	if cursor, ok := c.GetQuery("cursor"); ok {
+		params.Cursor = &cursor
 	}
 
 	// Business logic: Get karyawans via service
 	result, err := h.karyawanService.GetKaryawans(params)
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	// HTTP: Format response
-	c.JSON(http.StatusOK, result)
+	if params.Cursor != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"data":        result.Data,
+			"total":       result.Total,
+			"page_size":   result.Limit,
+			"limit":       result.Limit,
+			"next_cursor": result.NextCursor,
+		})
+		return
+	}
+
+	// HTTP: Format response ("limit" is kept for existing clients)
+	c.JSON(http.StatusOK, gin.H{
+		"data":        result.Data,
+		"total":       result.Total,
+		"page":        result.Page,
+		"page_size":   result.Limit,
+		"limit":       result.Limit,
+		"total_pages": result.TotalPages,
+	})
+}
+
+// parseKaryawanListFilters reads the employee list filters and the caller's data scope
+// shared by GetKaryawans and ExportKaryawans
+func parseKaryawanListFilters(c *gin.Context) services.KaryawanListParams {
+	// HTTP: Parse is_active filter
+	var isActive *bool
+	if isActiveStr := c.Query("is_active"); isActiveStr != "" {
+		val, _ := strconv.ParseBool(isActiveStr)
+		isActive = &val
+	}
+
+	return services.KaryawanListParams{
+		Search:        c.Query("search"),
+		BagianKerja:   c.Query("bagian_kerja"),
+		BidangKerja:   c.Query("bidang_kerja"),
+		JenisKaryawan: c.Query("jenis_karyawan"),
+		StatusAktif:   c.Query("status_aktif"),
+		IsActive:      isActive,
+		Scope:         middleware.DataScopeFromContext(c),
+	}
+}
+
+// ExportKaryawans handles streaming the filtered employee directory as a CSV or XLSX file
+// @Summary Export employees
+// @Description Streams every employee matching the list filters within the caller's scope, without pagination
+// @Tags employees
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param format query string false "File format (csv or xlsx)" default(csv)
+// @Param search query string false "Search by name, email, or NIP"
+// @Param bagian_kerja query string false "Filter by bagian kerja (department)"
+// @Param bidang_kerja query string false "Filter by bidang kerja (position)"
+// @Param jenis_karyawan query string false "Filter by jenis karyawan"
+// @Param status_aktif query string false "Filter by exact status aktif"
+// @Param is_active query bool false "Filter by active status" default(true)
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /employees/export [get]
+func (h *KaryawanHandler) ExportKaryawans(c *gin.Context) {
+	// HTTP: Parse filters (same as GetKaryawans, without pagination)
+	params := parseKaryawanListFilters(c)
+
+	// HTTP: Pick the file format
+	format := c.DefaultQuery("format", services.ExportFormatCSV)
+	var contentType string
+	switch format {
+	case services.ExportFormatCSV:
+		contentType = "text/csv; charset=utf-8"
+	case services.ExportFormatXLSX:
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrInvalidExportFormat.Error()})
+		return
+	}
+
+	// HTTP: Get authenticated user (the real admin when impersonating)
+	actorID := auditActorID(c)
+	if actorID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// HTTP: Stream file response
+	filename := fmt.Sprintf("employees-%s.%s", time.Now().Format("20060102-150405"), format)
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	// Business logic: Export employees via service
+	if _, err := h.karyawanService.ExportKaryawans(c.Request.Context(), params, format, c.Writer, actorID, c.ClientIP(), c.Request.UserAgent()); err != nil {
+		if !c.Writer.Written() {
+			c.Header("Content-Disposition", "")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		// Headers are already sent; the client receives a truncated file
+		logger.FromContext(c.Request.Context()).Warn("employee export interrupted", "error", err)
+	}
 }
 
 // GetKaryawanByNIP handles getting a single employee by NIP
@@ -105,3 +211,22 @@ func (h *KaryawanHandler) GetFilterOptions(c *gin.Context) {
 	// HTTP: Format response
 	c.JSON(http.StatusOK, options)
 }
+
+// SyncStatuses handles syncing user account status with employee status on demand
+// @Summary Sync user accounts with employee status
+// @Tags employees
+// @Produce json
+// @Success 200 {object} services.KaryawanSyncResult
+// @Failure 500 {object} map[string]string
+// @Router /employees/sync [post]
+func (h *KaryawanHandler) SyncStatuses(c *gin.Context) {
+	// Business logic: Sync statuses via service
+	result, err := h.syncService.SyncStatuses(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// HTTP: Format response
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"net/http"
+
+	"backend/internal/helpers"
+	"backend/internal/models"
+	"backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LoginAttemptHandler handles HTTP requests for recorded login attempts
+type LoginAttemptHandler struct {
+	loginAttemptService *services.LoginAttemptService
+}
+
+// NewLoginAttemptHandler creates a new LoginAttemptHandler instance
+func NewLoginAttemptHandler(loginAttemptService *services.LoginAttemptService) *LoginAttemptHandler {
+	return &LoginAttemptHandler{
+		loginAttemptService: loginAttemptService,
+	}
+}
+
+// GetLoginAttempts handles listing recorded login attempts
+// @Summary List login attempts
+// @Tags admin
+// @Produce json
+// @Param email query string false "Email (exact, case-insensitive)"
+// @Param ip_address query string false "IP address"
+// @Param success query bool false "Filter by outcome"
+// @Param start_date query string false "Start date (RFC3339)"
+// @Param end_date query string false "End date (RFC3339)"
+// @Param page query int false "Page number"
+// @Param limit query int false "Page size"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Router /admin/login-attempts [get]
+func (h *LoginAttemptHandler) GetLoginAttempts(c *gin.Context) {
+	// HTTP: Parse query parameters
+	var filter models.LoginAttemptFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(helpers.BindErrorStatus(err), helpers.BindErrorBody(c, err))
+		return
+	}
+
+	// Business logic: Get login attempts via service
+	result, err := h.loginAttemptService.GetLoginAttempts(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// HTTP: Format response
+	c.JSON(http.StatusOK, gin.H{
+		"data":        result.Data,
+		"total":       result.Total,
+		"page":        result.Page,
+		"page_size":   result.PageSize,
+		"total_pages": result.TotalPages,
+	})
+}
+
+// GetLoginAttemptSummary handles summarizing failed logins per IP address and email
+// @Summary Summarize failed logins
+// @Tags admin
+// @Produce json
+// @Param window_hours query int false "Window in hours (default 24, max 720)"
+// @Param limit query int false "Entries per list (default 20, max 100)"
+// @Success 200 {object} models.LoginAttemptSummary
+// @Failure 400 {object} map[string]string
+// @Router /admin/login-attempts/summary [get]
+func (h *LoginAttemptHandler) GetLoginAttemptSummary(c *gin.Context) {
+	// HTTP: Parse query parameters
+	var filter models.LoginAttemptSummaryFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(helpers.BindErrorStatus(err), helpers.BindErrorBody(c, err))
+		return
+	}
+
+	// Business logic: Aggregate failed logins via service
+	summary, err := h.loginAttemptService.GetLoginAttemptSummary(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// HTTP: Return response
+	c.JSON(http.StatusOK, summary)
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+
+	"backend/internal/logger"
+	"backend/internal/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MetricsHandler serves service metrics in the Prometheus text format
+type MetricsHandler struct {
+	db    *sql.DB
+	cache metrics.CacheCounter
+}
+
+// NewMetricsHandler creates a new MetricsHandler instance
+func NewMetricsHandler(db *sql.DB, cache metrics.CacheCounter) *MetricsHandler {
+	return &MetricsHandler{
+		db:    db,
+		cache: cache,
+	}
+}
+
+// GetMetrics handles exposing metrics for Prometheus scraping
+// @Summary Prometheus metrics
+// @Tags system
+// @Produce plain
+// @Success 200 {string} string
+// @Router /metrics [get]
+func (h *MetricsHandler) GetMetrics(c *gin.Context) {
+	c.Status(http.StatusOK)
+	c.Header("Content-Type", metrics.ContentType)
+	if err := metrics.Write(c.Writer, h.db, h.cache); err != nil {
+		logger.FromContext(c.Request.Context()).Warn("failed to write metrics", "error", err)
+	}
+}
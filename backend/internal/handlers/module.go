@@ -1,9 +1,11 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
+	"backend/internal/helpers"
 	"backend/internal/models"
 	"backend/internal/services"
 
@@ -37,7 +39,7 @@ func (h *ModuleHandler) CreateModule(c *gin.Context) {
 
 	// HTTP: Parse and validate request
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(helpers.BindErrorStatus(err), helpers.BindErrorBody(c, err))
 		return
 	}
 
@@ -181,6 +183,7 @@ func (h *ModuleHandler) GetModuleTree(c *gin.Context) {
 // @Success 200 {object} models.ModuleResponse
 // @Failure 400 {object} map[string]string
 // @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
 // @Router /modules/{id} [put]
 func (h *ModuleHandler) UpdateModule(c *gin.Context) {
 	// HTTP: Get ID from URL
@@ -189,7 +192,7 @@ func (h *ModuleHandler) UpdateModule(c *gin.Context) {
 	// HTTP: Parse and validate request
 	var req models.UpdateModuleRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(helpers.BindErrorStatus(err), helpers.BindErrorBody(c, err))
 		return
 	}
 
@@ -205,6 +208,8 @@ func (h *ModuleHandler) UpdateModule(c *gin.Context) {
 	if err != nil {
 		if err.Error() == "module tidak ditemukan" {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else if errors.Is(err, services.ErrModuleVersionConflict) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
 		} else {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		}
@@ -291,19 +296,19 @@ func (h *ModuleHandler) AssignModuleToRole(c *gin.Context) {
 	// HTTP: Parse and validate request
 	var req models.AssignModuleAccessToRoleRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(helpers.BindErrorStatus(err), helpers.BindErrorBody(c, err))
 		return
 	}
 
 	// HTTP: Get authenticated user
-	userID, exists := c.Get("user_id")
-	if !exists {
+	userID := auditActorID(c)
+	if userID == "" {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
 	}
 
 	// Business logic: Assign module to role via service
-	access, err := h.moduleService.AssignModuleToRole(roleID, req, userID.(string))
+	access, err := h.moduleService.AssignModuleToRole(roleID, req, userID, c.GetString("user_id"))
 	if err != nil {
 		if err.Error() == "role tidak ditemukan" || err.Error() == "module tidak ditemukan" || err.Error() == "position tidak ditemukan" {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
@@ -335,14 +340,14 @@ func (h *ModuleHandler) RevokeModuleFromRole(c *gin.Context) {
 	accessID := c.Param("access_id")
 
 	// HTTP: Get authenticated user
-	userID, exists := c.Get("user_id")
-	if !exists {
+	userID := auditActorID(c)
+	if userID == "" {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
 	}
 
 	// Business logic: Revoke module from role via service
-	err := h.moduleService.RevokeModuleFromRole(roleID, accessID, userID.(string))
+	err := h.moduleService.RevokeModuleFromRole(roleID, accessID, userID, c.GetString("user_id"))
 	if err != nil {
 		if err.Error() == "module access tidak ditemukan" {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"strconv"
 
+	"backend/internal/helpers"
 	"backend/internal/models"
 	"backend/internal/services"
 
@@ -37,7 +38,7 @@ func (h *PermissionHandler) CreatePermission(c *gin.Context) {
 
 	// HTTP: Parse and validate request
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(helpers.BindErrorStatus(err), helpers.BindErrorBody(c, err))
 		return
 	}
 
@@ -70,6 +71,7 @@ func (h *PermissionHandler) CreatePermission(c *gin.Context) {
 // @Param action query string false "Filter by action"
 // @Param scope query string false "Filter by scope"
 // @Param category query string false "Filter by category"
+// @Param group_name query string false "Filter by group name"
 // @Param is_active query bool false "Filter by active status"
 // @Param is_system_permission query bool false "Filter by system permission status"
 // @Param sort_by query string false "Sort by field" default(code)
@@ -81,11 +83,18 @@ func (h *PermissionHandler) GetPermissions(c *gin.Context) {
 	// HTTP: Parse query parameters
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
 	search := c.Query("search")
 	resource := c.Query("resource")
 	action := c.Query("action")
 	scope := c.Query("scope")
 	category := c.Query("category")
+	groupName := c.Query("group_name")
 	sortBy := c.DefaultQuery("sort_by", "code")
 	sortOrder := c.DefaultQuery("sort_order", "asc")
 
@@ -112,6 +121,7 @@ func (h *PermissionHandler) GetPermissions(c *gin.Context) {
 		Action:             action,
 		Scope:              scope,
 		Category:           category,
+		GroupName:          groupName,
 		IsActive:           isActive,
 		IsSystemPermission: isSystemPermission,
 		SortBy:             sortBy,
@@ -158,6 +168,34 @@ func (h *PermissionHandler) GetPermissionByID(c *gin.Context) {
 	c.JSON(http.StatusOK, permission.ToResponse())
 }
 
+// GetPermissionUsage handles listing the roles and users that hold a permission
+// @Summary Get permission usage
+// @Tags permissions
+// @Produce json
+// @Param id path string true "Permission ID"
+// @Success 200 {object} models.PermissionUsageResponse
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /permissions/{id}/usage [get]
+func (h *PermissionHandler) GetPermissionUsage(c *gin.Context) {
+	// HTTP: Get ID from URL
+	id := c.Param("id")
+
+	// Business logic: Get permission usage via service
+	usage, err := h.permissionService.GetPermissionUsage(id)
+	if err != nil {
+		if err.Error() == "permission tidak ditemukan" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// HTTP: Format response
+	c.JSON(http.StatusOK, usage)
+}
+
 // UpdatePermission handles updating an existing permission
 // @Summary Update permission
 // @Tags permissions
@@ -177,7 +215,7 @@ func (h *PermissionHandler) UpdatePermission(c *gin.Context) {
 
 	// HTTP: Parse and validate request
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(helpers.BindErrorStatus(err), helpers.BindErrorBody(c, err))
 		return
 	}
 
@@ -197,6 +235,7 @@ func (h *PermissionHandler) UpdatePermission(c *gin.Context) {
 // @Tags permissions
 // @Produce json
 // @Param id path string true "Permission ID"
+// @Param force query bool false "Revoke the permission from roles and users that still hold it"
 // @Success 200 {object} map[string]string
 // @Failure 400 {object} map[string]string
 // @Failure 404 {object} map[string]string
@@ -204,9 +243,10 @@ func (h *PermissionHandler) UpdatePermission(c *gin.Context) {
 func (h *PermissionHandler) DeletePermission(c *gin.Context) {
 	// HTTP: Get ID from URL
 	id := c.Param("id")
+	force, _ := strconv.ParseBool(c.Query("force"))
 
 	// Business logic: Delete permission via service
-	if err := h.permissionService.DeletePermission(id); err != nil {
+	if err := h.permissionService.DeletePermission(id, force); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -238,6 +278,82 @@ func (h *PermissionHandler) GetPermissionGroups(c *gin.Context) {
 	})
 }
 
+// UpdatePermissionGroup handles renaming, re-iconing and reordering a permission group
+// @Summary Update permission group
+// @Tags permissions
+// @Accept json
+// @Produce json
+// @Param name path string true "Group name (Uncategorized for permissions without a group)"
+// @Param request body models.UpdatePermissionGroupRequest true "Group update data"
+// @Success 200 {object} models.PermissionGroupResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /permissions/groups/{name} [put]
+func (h *PermissionHandler) UpdatePermissionGroup(c *gin.Context) {
+	// HTTP: Get group name from URL
+	name := c.Param("name")
+
+	var req models.UpdatePermissionGroupRequest
+
+	// HTTP: Parse and validate request
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(helpers.BindErrorStatus(err), helpers.BindErrorBody(c, err))
+		return
+	}
+
+	// Business logic: Update every permission in the group via service
+	group, err := h.permissionService.UpdatePermissionGroup(name, req)
+	if err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "grup permission tidak ditemukan" {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	// HTTP: Format response
+	c.JSON(http.StatusOK, group)
+}
+
+// MovePermissionToGroup handles moving a permission to another group
+// @Summary Move permission to another group
+// @Tags permissions
+// @Accept json
+// @Produce json
+// @Param id path string true "Permission ID"
+// @Param request body models.MovePermissionGroupRequest true "Target group"
+// @Success 200 {object} models.PermissionResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /permissions/{id}/group [put]
+func (h *PermissionHandler) MovePermissionToGroup(c *gin.Context) {
+	// HTTP: Get ID from URL
+	id := c.Param("id")
+
+	var req models.MovePermissionGroupRequest
+
+	// HTTP: Parse and validate request
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(helpers.BindErrorStatus(err), helpers.BindErrorBody(c, err))
+		return
+	}
+
+	// Business logic: Move permission via service
+	permission, err := h.permissionService.MovePermissionToGroup(id, req)
+	if err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "permission tidak ditemukan" {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	// HTTP: Format response
+	c.JSON(http.StatusOK, permission.ToResponse())
+}
+
 // GetPermissionScopes handles getting all available permission scopes
 // @Summary Get all permission scopes
 // @Tags permissions
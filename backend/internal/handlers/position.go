@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"strconv"
 
+	"backend/internal/helpers"
 	"backend/internal/models"
 	"backend/internal/services"
 
@@ -37,7 +38,7 @@ func (h *PositionHandler) CreatePosition(c *gin.Context) {
 
 	// HTTP: Parse and validate request
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(helpers.BindErrorStatus(err), helpers.BindErrorBody(c, err))
 		return
 	}
 
@@ -129,6 +130,55 @@ func (h *PositionHandler) GetPositions(c *gin.Context) {
 	})
 }
 
+// GetOrgChart handles getting the organizational chart of departments, positions, and holders
+// @Summary Get organizational chart
+// @Tags positions
+// @Produce json
+// @Param school_id query string false "Limit the chart to a school"
+// @Success 200 {object} models.OrgChartResponse
+// @Failure 500 {object} map[string]string
+// @Router /positions/org-chart [get]
+func (h *PositionHandler) GetOrgChart(c *gin.Context) {
+	// HTTP: Parse query parameters
+	schoolID := c.Query("school_id")
+
+	// Business logic: Build org chart via service
+	chart, err := h.positionService.GetOrgChart(schoolID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// HTTP: Format response
+	c.JSON(http.StatusOK, chart)
+}
+
+// GetAvailablePositionCodes handles suggesting free position codes for a prefix
+// @Summary Suggest available position codes
+// @Description Returns the prefix itself when unused, then PREFIX_<n> codes numbered after the highest existing one
+// @Tags positions
+// @Produce json
+// @Param prefix query string true "Code prefix (normalized to upper case, spaces become underscores)"
+// @Param count query int false "Number of suggestions (default 5, max 20)"
+// @Success 200 {object} map[string][]string
+// @Failure 400 {object} map[string]string
+// @Router /positions/available-codes [get]
+func (h *PositionHandler) GetAvailablePositionCodes(c *gin.Context) {
+	// HTTP: Parse query parameters
+	prefix := c.Query("prefix")
+	count, _ := strconv.Atoi(c.DefaultQuery("count", "5"))
+
+	// Business logic: Suggest codes via service
+	codes, err := h.positionService.GetAvailablePositionCodes(prefix, count)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// HTTP: Format response
+	c.JSON(http.StatusOK, gin.H{"codes": codes})
+}
+
 // GetPositionByID handles getting a single position by ID
 // @Summary Get position by ID
 // @Tags positions
@@ -152,6 +202,37 @@ func (h *PositionHandler) GetPositionByID(c *gin.Context) {
 	c.JSON(http.StatusOK, position.ToResponse())
 }
 
+// GetPositionHolders handles listing the users assigned to a position
+// @Summary List users holding a position
+// @Description Returns currently effective holders; include_ended=true also returns ended and inactive assignments
+// @Tags positions
+// @Produce json
+// @Param id path string true "Position ID"
+// @Param include_ended query bool false "Include ended and inactive assignments"
+// @Success 200 {object} map[string][]models.PositionHolderResponse
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /positions/{id}/users [get]
+func (h *PositionHandler) GetPositionHolders(c *gin.Context) {
+	// HTTP: Get ID and query parameters
+	id := c.Param("id")
+	includeEnded, _ := strconv.ParseBool(c.Query("include_ended"))
+
+	// Business logic: Get holders via service
+	holders, err := h.positionService.GetPositionHolders(id, includeEnded)
+	if err != nil {
+		if err.Error() == "posisi tidak ditemukan" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// HTTP: Format response
+	c.JSON(http.StatusOK, gin.H{"data": holders})
+}
+
 // UpdatePosition handles updating a position
 // @Summary Update a position
 // @Tags positions
@@ -170,7 +251,7 @@ func (h *PositionHandler) UpdatePosition(c *gin.Context) {
 	// HTTP: Parse and validate request
 	var req models.UpdatePositionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(helpers.BindErrorStatus(err), helpers.BindErrorBody(c, err))
 		return
 	}
 
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RBACConsistencyHandler handles HTTP requests for the RBAC consistency check
+type RBACConsistencyHandler struct {
+	consistencyService *services.RBACConsistencyService
+}
+
+// NewRBACConsistencyHandler creates a new RBACConsistencyHandler instance
+func NewRBACConsistencyHandler(consistencyService *services.RBACConsistencyService) *RBACConsistencyHandler {
+	return &RBACConsistencyHandler{consistencyService: consistencyService}
+}
+
+// GetConsistency handles reporting RBAC rows with dangling references
+// @Summary Check RBAC consistency
+// @Description Reports role, user and module access rows that point at deleted or inactive records
+// @Tags admin
+// @Produce json
+// @Success 200 {object} services.RBACConsistencyReport
+// @Failure 500 {object} map[string]string
+// @Router /admin/rbac/consistency [get]
+func (h *RBACConsistencyHandler) GetConsistency(c *gin.Context) {
+	// Business logic: Run checks via service
+	report, err := h.consistencyService.Check()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// HTTP: Format response
+	c.JSON(http.StatusOK, report)
+}
+
+// RepairConsistency handles cleaning up RBAC rows with dangling references
+// @Summary Repair RBAC consistency
+// @Description Deletes orphaned rows and ends assignments of inactive roles in one transaction; dry_run reports without changing anything
+// @Tags admin
+// @Produce json
+// @Param dry_run query bool false "Report what would be repaired without changing anything"
+// @Success 200 {object} services.RBACConsistencyReport
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/rbac/consistency/repair [post]
+func (h *RBACConsistencyHandler) RepairConsistency(c *gin.Context) {
+	// HTTP: Parse query parameters
+	dryRun := false
+	if raw := c.Query("dry_run"); raw != "" {
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "dry_run harus berupa boolean"})
+			return
+		}
+		dryRun = parsed
+	}
+
+	// HTTP: Get authenticated user
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// Business logic: Repair via service
+	report, err := h.consistencyService.Repair(dryRun, userID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// HTTP: Format response
+	c.JSON(http.StatusOK, report)
+}
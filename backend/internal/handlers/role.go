@@ -1,10 +1,13 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 
+	"backend/internal/helpers"
+	"backend/internal/logger"
 	"backend/internal/models"
 	"backend/internal/services"
 
@@ -38,7 +41,7 @@ func (h *RoleHandler) CreateRole(c *gin.Context) {
 
 	// HTTP: Parse and validate request
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(helpers.BindErrorStatus(err), helpers.BindErrorBody(c, err))
 		return
 	}
 
@@ -72,6 +75,7 @@ func (h *RoleHandler) CreateRole(c *gin.Context) {
 // @Param hierarchy_level query int false "Filter by hierarchy level"
 // @Param sort_by query string false "Sort by field" default(created_at)
 // @Param sort_order query string false "Sort order (asc/desc)" default(desc)
+// @Param with_counts query bool false "Include effective_user_count per role"
 // @Success 200 {object} services.RoleListResult
 // @Failure 500 {object} map[string]string
 // @Router /roles [get]
@@ -106,6 +110,9 @@ func (h *RoleHandler) GetRoles(c *gin.Context) {
 		}
 	}
 
+	// HTTP: Parse with_counts flag
+	withCounts, _ := strconv.ParseBool(c.Query("with_counts"))
+
 	// Build params
 	params := services.RoleListParams{
 		Page:           page,
@@ -116,6 +123,7 @@ func (h *RoleHandler) GetRoles(c *gin.Context) {
 		HierarchyLevel: hierarchyLevel,
 		SortBy:         sortBy,
 		SortOrder:      sortOrder,
+		WithCounts:     withCounts,
 	}
 
 	// Business logic: Get roles via service
@@ -135,6 +143,32 @@ func (h *RoleHandler) GetRoles(c *gin.Context) {
 	})
 }
 
+// GetAvailableRoleCodes handles suggesting free role codes for a prefix
+// @Summary Suggest available role codes
+// @Description Returns the prefix itself when unused, then PREFIX_<n> codes numbered after the highest existing one
+// @Tags roles
+// @Produce json
+// @Param prefix query string true "Code prefix (normalized to upper case, spaces become underscores)"
+// @Param count query int false "Number of suggestions (default 5, max 20)"
+// @Success 200 {object} map[string][]string
+// @Failure 400 {object} map[string]string
+// @Router /roles/available-codes [get]
+func (h *RoleHandler) GetAvailableRoleCodes(c *gin.Context) {
+	// HTTP: Parse query parameters
+	prefix := c.Query("prefix")
+	count, _ := strconv.Atoi(c.DefaultQuery("count", "5"))
+
+	// Business logic: Suggest codes via service
+	codes, err := h.roleService.GetAvailableRoleCodes(prefix, count)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// HTTP: Format response
+	c.JSON(http.StatusOK, gin.H{"codes": codes})
+}
+
 // GetRoleByID handles getting a single role by ID
 // @Summary Get role by ID
 // @Tags roles
@@ -181,6 +215,87 @@ func (h *RoleHandler) GetRoleWithPermissions(c *gin.Context) {
 	c.JSON(http.StatusOK, roleWithPermissions)
 }
 
+// ExportRole handles exporting a role's complete definition for another environment
+// @Summary Export role definition
+// @Description Permissions, module access and parent roles are referenced by code so the document can be imported elsewhere
+// @Tags roles
+// @Produce json
+// @Param id path string true "Role ID"
+// @Success 200 {object} models.RoleExport
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /roles/{id}/export [get]
+func (h *RoleHandler) ExportRole(c *gin.Context) {
+	// HTTP: Get ID from URL
+	id := c.Param("id")
+
+	// Business logic: Export role via service
+	export, err := h.roleService.ExportRole(id)
+	if err != nil {
+		if err.Error() == "role tidak ditemukan" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// HTTP: Format response as a downloadable document
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "role-"+export.Role.Code+".json"))
+	c.JSON(http.StatusOK, export)
+}
+
+// ImportRole handles recreating a role from an exported definition
+// @Summary Import role definition
+// @Description Resolves codes to ids in this environment and creates the role in one transaction. Unresolved codes fail the import (422) unless skip_unresolved=true, which leaves those entries out.
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Param skip_unresolved query bool false "Import without the entries whose codes do not resolve"
+// @Param request body models.RoleExport true "Exported role definition"
+// @Success 201 {object} models.RoleImportResult
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 422 {object} map[string]interface{}
+// @Router /roles/import [post]
+func (h *RoleHandler) ImportRole(c *gin.Context) {
+	var doc models.RoleExport
+
+	// HTTP: Parse and validate request
+	if err := c.ShouldBindJSON(&doc); err != nil {
+		c.JSON(helpers.BindErrorStatus(err), helpers.BindErrorBody(c, err))
+		return
+	}
+	skipUnresolved, _ := strconv.ParseBool(c.Query("skip_unresolved"))
+
+	// HTTP: Get authenticated user
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// Business logic: Import role via service (audited as the real admin when impersonating)
+	result, err := h.roleService.ImportRole(doc, skipUnresolved, auditActorID(c), userID.(string))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrRoleImportUnresolved):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "unresolved": result.Unresolved})
+		case services.IsEscalationError(err):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case err.Error() == "kode role sudah digunakan":
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	// HTTP: Format response
+	c.JSON(http.StatusCreated, result)
+}
+
 // UpdateRole handles updating an existing role
 // @Summary Update role
 // @Tags roles
@@ -191,6 +306,7 @@ func (h *RoleHandler) GetRoleWithPermissions(c *gin.Context) {
 // @Success 200 {object} models.RoleResponse
 // @Failure 400 {object} map[string]string
 // @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
 // @Router /roles/{id} [put]
 func (h *RoleHandler) UpdateRole(c *gin.Context) {
 	// HTTP: Get ID from URL
@@ -199,13 +315,17 @@ func (h *RoleHandler) UpdateRole(c *gin.Context) {
 	// HTTP: Parse and validate request
 	var req models.UpdateRoleRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(helpers.BindErrorStatus(err), helpers.BindErrorBody(c, err))
 		return
 	}
 
 	// Business logic: Update role via service
 	role, err := h.roleService.UpdateRole(id, req)
 	if err != nil {
+		if errors.Is(err, services.ErrRoleVersionConflict) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -218,6 +338,7 @@ func (h *RoleHandler) UpdateRole(c *gin.Context) {
 // @Summary Delete role
 // @Tags roles
 // @Param id path string true "Role ID"
+// @Param force query bool false "End-date the role's current user assignments instead of refusing"
 // @Success 200 {object} map[string]string
 // @Failure 400 {object} map[string]string
 // @Failure 404 {object} map[string]string
@@ -225,9 +346,14 @@ func (h *RoleHandler) UpdateRole(c *gin.Context) {
 func (h *RoleHandler) DeleteRole(c *gin.Context) {
 	// HTTP: Get ID from URL
 	id := c.Param("id")
+	force, _ := strconv.ParseBool(c.Query("force"))
 
 	// Business logic: Delete role via service
-	if err := h.roleService.DeleteRole(id); err != nil {
+	if err := h.roleService.DeleteRole(id, force, auditActorID(c)); err != nil {
+		if err.Error() == "role tidak ditemukan" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -236,6 +362,34 @@ func (h *RoleHandler) DeleteRole(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Role berhasil dihapus"})
 }
 
+// RestoreRole handles reactivating a soft-deleted role
+// @Summary Restore role
+// @Tags roles
+// @Produce json
+// @Param id path string true "Role ID"
+// @Success 200 {object} models.RoleResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /roles/{id}/restore [post]
+func (h *RoleHandler) RestoreRole(c *gin.Context) {
+	// HTTP: Get ID from URL
+	id := c.Param("id")
+
+	// Business logic: Restore role via service
+	role, err := h.roleService.RestoreRole(id, auditActorID(c), c.GetString("user_id"))
+	if err != nil {
+		if err.Error() == "role tidak ditemukan" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// HTTP: Format response
+	c.JSON(http.StatusOK, role.ToResponse())
+}
+
 // AssignPermissionToRole handles assigning a permission to a role
 // @Summary Assign permission to role
 // @Tags roles
@@ -250,30 +404,27 @@ func (h *RoleHandler) DeleteRole(c *gin.Context) {
 func (h *RoleHandler) AssignPermissionToRole(c *gin.Context) {
 	// HTTP: Get role ID from URL
 	roleID := c.Param("id")
-	fmt.Printf("[DEBUG] AssignPermissionToRole: roleID=%s\n", roleID)
+	reqLog := logger.FromContext(c.Request.Context())
 
 	// HTTP: Parse and validate request
 	var req models.AssignPermissionToRoleRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		fmt.Printf("[DEBUG] AssignPermissionToRole: JSON bind error=%v\n", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		reqLog.Debug("assign permission to role: invalid request", "role_id", roleID, "error", err)
+		c.JSON(helpers.BindErrorStatus(err), helpers.BindErrorBody(c, err))
 		return
 	}
-	fmt.Printf("[DEBUG] AssignPermissionToRole: permissionID=%s\n", req.PermissionID)
 
-	// HTTP: Get authenticated user
-	userID, exists := c.Get("user_id")
-	if !exists {
-		fmt.Printf("[DEBUG] AssignPermissionToRole: user_id not found in context\n")
+	// HTTP: Get authenticated user (authorizes the change) and the real actor (audited)
+	userID := c.GetString("user_id")
+	if userID == "" {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
 	}
-	fmt.Printf("[DEBUG] AssignPermissionToRole: userID=%s\n", userID.(string))
 
 	// Business logic: Assign permission via service
-	rolePermission, err := h.roleService.AssignPermissionToRole(roleID, req, userID.(string))
+	rolePermission, err := h.roleService.AssignPermissionToRole(roleID, req, auditActorID(c), userID)
 	if err != nil {
-		fmt.Printf("[DEBUG] AssignPermissionToRole: service error=%v\n", err)
+		reqLog.Debug("assign permission to role failed", "role_id", roleID, "permission_id", req.PermissionID, "user_id", userID, "error", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -297,7 +448,7 @@ func (h *RoleHandler) RevokePermissionFromRole(c *gin.Context) {
 	permissionAssignmentID := c.Param("permission_id")
 
 	// Business logic: Revoke permission via service
-	if err := h.roleService.RevokePermissionFromRole(roleID, permissionAssignmentID); err != nil {
+	if err := h.roleService.RevokePermissionFromRole(roleID, permissionAssignmentID, auditActorID(c)); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -305,3 +456,33 @@ func (h *RoleHandler) RevokePermissionFromRole(c *gin.Context) {
 	// HTTP: Format response
 	c.JSON(http.StatusOK, gin.H{"message": "Permission berhasil dicabut dari role"})
 }
+
+// RevokeAllPermissionsFromRole handles revoking every permission from a role
+// @Summary Revoke all permissions from role
+// @Tags roles
+// @Param id path string true "Role ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /roles/{id}/permissions [delete]
+func (h *RoleHandler) RevokeAllPermissionsFromRole(c *gin.Context) {
+	// HTTP: Get ID from URL
+	roleID := c.Param("id")
+
+	// Business logic: Revoke all permissions via service
+	revoked, err := h.roleService.RevokeAllPermissionsFromRole(roleID, auditActorID(c), c.GetString("user_id"))
+	if err != nil {
+		if err.Error() == "role tidak ditemukan" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// HTTP: Format response
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Semua permission berhasil dicabut dari role",
+		"revoked": revoked,
+	})
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"net/http"
+
+	"backend/internal/helpers"
+	"backend/internal/models"
+	"backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SettingsHandler handles HTTP requests for runtime system settings
+type SettingsHandler struct {
+	settingsService *services.SettingsService
+}
+
+// NewSettingsHandler creates a new SettingsHandler instance
+func NewSettingsHandler(settingsService *services.SettingsService) *SettingsHandler {
+	return &SettingsHandler{settingsService: settingsService}
+}
+
+// GetSettings handles listing the runtime settings and their current values
+// @Summary Get runtime settings
+// @Tags admin
+// @Produce json
+// @Success 200 {object} map[string][]models.SystemConfigResponse
+// @Failure 500 {object} map[string]string
+// @Router /admin/settings [get]
+func (h *SettingsHandler) GetSettings(c *gin.Context) {
+	// Business logic: Get settings via service
+	settings, err := h.settingsService.GetSettings()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// HTTP: Format response
+	c.JSON(http.StatusOK, gin.H{"data": toSettingResponses(settings)})
+}
+
+// UpdateSettings handles changing one or more runtime settings at once
+// @Summary Update runtime settings
+// @Description Sets the given settings in one transaction; unknown keys or mistyped values reject the whole request
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body models.UpdateSettingsRequest true "Settings to change"
+// @Success 200 {object} map[string][]models.SystemConfigResponse
+// @Failure 400 {object} map[string]string
+// @Router /admin/settings [put]
+func (h *SettingsHandler) UpdateSettings(c *gin.Context) {
+	var req models.UpdateSettingsRequest
+
+	// HTTP: Parse and validate request
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(helpers.BindErrorStatus(err), helpers.BindErrorBody(c, err))
+		return
+	}
+
+	// HTTP: Get authenticated user
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// Business logic: Update settings via service
+	settings, err := h.settingsService.UpdateSettings(req.Settings, userID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// HTTP: Format response
+	c.JSON(http.StatusOK, gin.H{"data": toSettingResponses(settings)})
+}
+
+// toSettingResponses converts settings to their response form
+func toSettingResponses(settings []models.SystemConfiguration) []*models.SystemConfigResponse {
+	responses := make([]*models.SystemConfigResponse, len(settings))
+	for i := range settings {
+		responses[i] = settings[i].ToResponse()
+	}
+	return responses
+}
@@ -1,9 +1,18 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
+	"backend/internal/auth"
+	"backend/internal/clock"
+	"backend/internal/helpers"
+	"backend/internal/logger"
+	"backend/internal/middleware"
 	"backend/internal/models"
 	"backend/internal/services"
 
@@ -22,6 +31,108 @@ func NewUserHandler(userService *services.UserService) *UserHandler {
 	}
 }
 
+// maxUserImportFileSize limits the size of uploaded user import files
+const maxUserImportFileSize = 5 << 20 // 5 MB
+
+// ImportUsers handles bulk creating users from an uploaded CSV file
+// @Summary Import users from CSV
+// @Tags users
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "CSV file with an email column and optional roles column (role codes separated by ';')"
+// @Success 200 {object} services.UserImportResult
+// @Failure 400 {object} helpers.ErrorEnvelope
+// @Failure 413 {object} helpers.ErrorEnvelope
+// @Router /users/import [post]
+func (h *UserHandler) ImportUsers(c *gin.Context) {
+	// HTTP: Get uploaded file
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		if helpers.BindErrorStatus(err) == http.StatusRequestEntityTooLarge {
+			helpers.RespondBindError(c, err)
+			return
+		}
+		helpers.RespondError(c, http.StatusBadRequest, helpers.CodeUserImportInvalidFile, "file CSV wajib diunggah", nil)
+		return
+	}
+	if fileHeader.Size > maxUserImportFileSize {
+		helpers.RespondError(c, http.StatusBadRequest, helpers.CodeUserImportInvalidFile, "ukuran file maksimal 5 MB", nil)
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		helpers.RespondError(c, http.StatusBadRequest, helpers.CodeUserImportInvalidFile, "gagal membuka file", nil)
+		return
+	}
+	defer file.Close()
+
+	// HTTP: Get authenticated user
+	userID, exists := c.Get("user_id")
+	if !exists {
+		helpers.RespondError(c, http.StatusUnauthorized, helpers.CodeUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	// Business logic: Import users via service
+	result, err := h.userService.ImportUsers(c.Request.Context(), file, auditActorID(c), userID.(string))
+	if err != nil {
+		helpers.RespondError(c, http.StatusBadRequest, helpers.CodeUserImportFailed, err.Error(), nil)
+		return
+	}
+
+	// HTTP: Format response
+	c.JSON(http.StatusOK, result)
+}
+
+// ExportUsers handles streaming the filtered user list as a CSV file
+// @Summary Export users to CSV
+// @Tags users
+// @Produce text/csv
+// @Param search query string false "Search by email or username"
+// @Param role_id query []string false "Filter by role ID; repeat or comma-separate for any of several roles" collectionFormat(multi)
+// @Param position_id query string false "Filter by active position ID"
+// @Param department_id query string false "Filter by department of an active position"
+// @Param has_no_role query bool false "Only users without an active role"
+// @Param is_active query bool false "Filter by active status"
+// @Param sort_by query string false "Sort by field" default(email)
+// @Param sort_order query string false "Sort order (asc/desc)" default(asc)
+// @Success 200 {file} file
+// @Failure 400 {object} helpers.ErrorEnvelope
+// @Failure 500 {object} helpers.ErrorEnvelope
+// @Router /users/export [get]
+func (h *UserHandler) ExportUsers(c *gin.Context) {
+	// HTTP: Parse filters (same as GetUsers, without pagination)
+	params, err := parseUserListFilters(c)
+	if err != nil {
+		helpers.RespondError(c, http.StatusBadRequest, helpers.CodeValidationFailed, err.Error(), nil)
+		return
+	}
+
+	// HTTP: Get authenticated user (the real admin when impersonating)
+	actorID := auditActorID(c)
+	if actorID == "" {
+		helpers.RespondError(c, http.StatusUnauthorized, helpers.CodeUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	// HTTP: Stream CSV response
+	filename := fmt.Sprintf("users-%s.csv", time.Now().Format("20060102-150405"))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	// Business logic: Export users via service
+	if _, err := h.userService.ExportUsers(c.Request.Context(), params, c.Writer, actorID, c.ClientIP(), c.Request.UserAgent()); err != nil {
+		if !c.Writer.Written() {
+			c.Header("Content-Disposition", "")
+			helpers.RespondError(c, http.StatusInternalServerError, userErrorCode(err, helpers.CodeInternal), err.Error(), nil)
+			return
+		}
+		// Headers are already sent; the client receives a truncated file
+		logger.FromContext(c.Request.Context()).Warn("user export interrupted", "error", err)
+	}
+}
+
 // GetUsers handles getting list of users with pagination and filters
 // @Summary Get list of users
 // @Tags users
@@ -29,48 +140,59 @@ func NewUserHandler(userService *services.UserService) *UserHandler {
 // @Param page query int false "Page number" default(1)
 // @Param page_size query int false "Page size" default(20)
 // @Param search query string false "Search by email or username"
-// @Param role_id query string false "Filter by role ID"
+// @Param role_id query []string false "Filter by role ID; repeat or comma-separate for any of several roles" collectionFormat(multi)
+// @Param position_id query string false "Filter by active position ID"
+// @Param department_id query string false "Filter by department of an active position"
+// @Param has_no_role query bool false "Only users without an active role"
 // @Param is_active query bool false "Filter by active status"
 // @Param sort_by query string false "Sort by field" default(email)
 // @Param sort_order query string false "Sort order (asc/desc)" default(asc)
+// @Param cursor query string false "Keyset pagination cursor; when present (even empty) page is ignored and next_cursor is returned"
 // @Success 200 {object} services.UserListResult
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} helpers.ErrorEnvelope
+// @Failure 500 {object} helpers.ErrorEnvelope
 // @Router /users [get]
 func (h *UserHandler) GetUsers(c *gin.Context) {
 	// HTTP: Parse query parameters
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
-	search := c.Query("search")
-	roleID := c.Query("role_id")
-	sortBy := c.DefaultQuery("sort_by", "email")
-	sortOrder := c.DefaultQuery("sort_order", "asc")
 
-	// HTTP: Parse is_active filter
-	var isActive *bool
-	if isActiveStr := c.Query("is_active"); isActiveStr != "" {
-		val, _ := strconv.ParseBool(isActiveStr)
-		isActive = &val
+	// HTTP: Parse filters
+	params, err := parseUserListFilters(c)
+	if err != nil {
+		helpers.RespondError(c, http.StatusBadRequest, helpers.CodeValidationFailed, err.Error(), nil)
+		return
 	}
+	params.Page = page
+	params.PageSize = pageSize
 
-	// Build params
-	params := services.UserListParams{
-		Page:      page,
-		PageSize:  pageSize,
-		Search:    search,
-		RoleID:    roleID,
-		IsActive:  isActive,
-		SortBy:    sortBy,
-		SortOrder: sortOrder,
+	// HTTP: Keyset pagination is selected by the presence of cursor
+	if cursor, ok := c.GetQuery("cursor"); ok {
+		params.Cursor = &cursor
 	}
 
 	// Business logic: Get users via service
 	result, err := h.userService.GetUsers(params)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if errors.Is(err, services.ErrInvalidCursor) {
+			helpers.RespondError(c, http.StatusBadRequest, helpers.CodeBadRequest, err.Error(), nil)
+			return
+		}
+		helpers.RespondError(c, http.StatusInternalServerError, userErrorCode(err, helpers.CodeInternal), err.Error(), nil)
 		return
 	}
 
 	// HTTP: Format response
+	if params.Cursor != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"data":        result.Data,
+			"total":       result.Total,
+			"page_size":   result.PageSize,
+			"next_cursor": result.NextCursor,
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"data":        result.Data,
 		"total":       result.Total,
@@ -86,7 +208,7 @@ func (h *UserHandler) GetUsers(c *gin.Context) {
 // @Produce json
 // @Param id path string true "User ID"
 // @Success 200 {object} models.UserResponse
-// @Failure 404 {object} map[string]string
+// @Failure 404 {object} helpers.ErrorEnvelope
 // @Router /users/{id} [get]
 func (h *UserHandler) GetUser(c *gin.Context) {
 	// HTTP: Get ID from URL
@@ -95,7 +217,7 @@ func (h *UserHandler) GetUser(c *gin.Context) {
 	// Business logic: Get user via service
 	user, err := h.userService.GetUserByID(id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		helpers.RespondError(c, http.StatusNotFound, userErrorCode(err, helpers.CodeUserNotFound), err.Error(), nil)
 		return
 	}
 
@@ -111,8 +233,8 @@ func (h *UserHandler) GetUser(c *gin.Context) {
 // @Param id path string true "User ID"
 // @Param request body models.UpdateUserRequest true "User data"
 // @Success 200 {object} models.UserResponse
-// @Failure 400 {object} map[string]string
-// @Failure 404 {object} map[string]string
+// @Failure 400 {object} helpers.ErrorEnvelope
+// @Failure 404 {object} helpers.ErrorEnvelope
 // @Router /users/{id} [put]
 func (h *UserHandler) UpdateUser(c *gin.Context) {
 	// HTTP: Get ID from URL
@@ -121,14 +243,14 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 	// HTTP: Parse and validate request
 	var req models.UpdateUserRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		helpers.RespondBindError(c, err)
 		return
 	}
 
 	// HTTP: Get authenticated user
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		helpers.RespondError(c, http.StatusUnauthorized, helpers.CodeUnauthorized, "Unauthorized", nil)
 		return
 	}
 
@@ -136,9 +258,9 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 	user, err := h.userService.UpdateUser(id, req, userID.(string))
 	if err != nil {
 		if err.Error() == "pengguna tidak ditemukan" {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			helpers.RespondError(c, http.StatusNotFound, userErrorCode(err, helpers.CodeUserNotFound), err.Error(), nil)
 		} else {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			helpers.RespondError(c, http.StatusBadRequest, userErrorCode(err, helpers.CodeBadRequest), err.Error(), nil)
 		}
 		return
 	}
@@ -147,14 +269,435 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 	c.JSON(http.StatusOK, user.ToResponse())
 }
 
+// GetUserPreferencesSchema handles getting the allowed user preference keys
+// @Summary Get user preferences schema
+// @Tags users
+// @Produce json
+// @Success 200 {array} models.UserPreferenceField
+// @Router /users/preferences/schema [get]
+func (h *UserHandler) GetUserPreferencesSchema(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"data": models.UserPreferenceFields})
+}
+
+// DeactivateUser handles offboarding a user without deleting their records
+// @Summary Deactivate a user
+// @Tags users
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} models.UserResponse
+// @Failure 400 {object} helpers.ErrorEnvelope
+// @Failure 404 {object} helpers.ErrorEnvelope
+// @Router /users/{id}/deactivate [post]
+func (h *UserHandler) DeactivateUser(c *gin.Context) {
+	// HTTP: Get ID from URL
+	id := c.Param("id")
+
+	// HTTP: Get authenticated user (prevent self-deactivation)
+	userID, exists := c.Get("user_id")
+	if !exists {
+		helpers.RespondError(c, http.StatusUnauthorized, helpers.CodeUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	// Business rule: Cannot deactivate yourself
+	if id == userID.(string) {
+		helpers.RespondError(c, http.StatusBadRequest, helpers.CodeUserSelfAction, "Tidak dapat menonaktifkan akun sendiri", nil)
+		return
+	}
+
+	// Business logic: Deactivate user via service
+	user, err := h.userService.DeactivateUser(id, userID.(string))
+	if err != nil {
+		if err.Error() == "pengguna tidak ditemukan" {
+			helpers.RespondError(c, http.StatusNotFound, userErrorCode(err, helpers.CodeUserNotFound), err.Error(), nil)
+		} else {
+			helpers.RespondError(c, http.StatusBadRequest, userErrorCode(err, helpers.CodeBadRequest), err.Error(), nil)
+		}
+		return
+	}
+
+	// HTTP: Format response
+	c.JSON(http.StatusOK, user.ToResponse())
+}
+
+// BulkDeactivateUsers handles offboarding many users in one request
+// @Summary Deactivate many users
+// @Description Deactivates the listed users, or every active user holding a position in the given department
+// @Description and/or school (at most 500 per request; repeat while remaining > 0). Each user is deactivated
+// @Description like POST /users/{id}/deactivate and gets its own result; users outside the caller's scope are
+// @Description reported as not found.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body models.BulkDeactivateUsersRequest true "Users or filter"
+// @Success 200 {object} models.BulkDeactivateUsersResponse
+// @Failure 400 {object} helpers.ErrorEnvelope
+// @Failure 401 {object} helpers.ErrorEnvelope
+// @Router /users/deactivate-bulk [post]
+func (h *UserHandler) BulkDeactivateUsers(c *gin.Context) {
+	// HTTP: Parse and validate request
+	var req models.BulkDeactivateUsersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helpers.RespondBindError(c, err)
+		return
+	}
+
+	// HTTP: Get authenticated user (who is deactivating)
+	deactivatedBy := auditActorID(c)
+	if deactivatedBy == "" {
+		helpers.RespondError(c, http.StatusUnauthorized, helpers.CodeUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	// Business logic: Deactivate via service, limited to the users the caller may read
+	result, err := h.userService.BulkDeactivateUsers(c.Request.Context(), req, middleware.DataScopeFromContext(c), deactivatedBy, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		if userErrorCode(err, "") == helpers.CodeValidationFailed {
+			helpers.RespondError(c, http.StatusBadRequest, helpers.CodeValidationFailed, err.Error(), nil)
+		} else {
+			helpers.RespondError(c, http.StatusInternalServerError, userErrorCode(err, helpers.CodeInternal), err.Error(), nil)
+		}
+		return
+	}
+
+	// HTTP: Format response
+	c.JSON(http.StatusOK, result)
+}
+
+// TransferUserAssignments handles moving a user's active roles and positions to another user
+// @Summary Transfer a user's roles and positions to another user
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "Source user ID"
+// @Param request body models.TransferUserAssignmentsRequest true "Target user"
+// @Success 200 {object} models.UserAssignmentTransferResponse
+// @Failure 400 {object} helpers.ErrorEnvelope
+// @Failure 403 {object} helpers.ErrorEnvelope
+// @Failure 404 {object} helpers.ErrorEnvelope
+// @Router /users/{id}/transfer [post]
+func (h *UserHandler) TransferUserAssignments(c *gin.Context) {
+	// HTTP: Get source user ID from URL
+	id := c.Param("id")
+
+	// HTTP: Parse and validate request
+	var req models.TransferUserAssignmentsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helpers.RespondBindError(c, err)
+		return
+	}
+
+	// HTTP: Get authenticated user (who is transferring)
+	transferredBy := auditActorID(c)
+	if transferredBy == "" {
+		helpers.RespondError(c, http.StatusUnauthorized, helpers.CodeUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	// Business logic: Transfer assignments via service
+	result, err := h.userService.TransferUserAssignments(id, req, transferredBy, c.GetString("user_id"))
+	if err != nil {
+		switch {
+		case err.Error() == "pengguna tidak ditemukan" || err.Error() == "pengguna tujuan tidak ditemukan":
+			helpers.RespondError(c, http.StatusNotFound, helpers.CodeUserNotFound, err.Error(), nil)
+		case services.IsEscalationError(err):
+			helpers.RespondError(c, http.StatusForbidden, helpers.CodeForbidden, err.Error(), nil)
+		case err.Error() == "pengguna tujuan harus berbeda dari pengguna asal" || err.Error() == "pengguna tujuan tidak aktif" ||
+			err.Error() == "pengguna tidak memiliki role atau posisi aktif untuk dialihkan":
+			helpers.RespondError(c, http.StatusBadRequest, helpers.CodeBadRequest, err.Error(), nil)
+		default:
+			helpers.RespondError(c, http.StatusInternalServerError, helpers.CodeInternal, err.Error(), nil)
+		}
+		return
+	}
+
+	// HTTP: Format response
+	c.JSON(http.StatusOK, result)
+}
+
+// GetUserActivity handles getting the activity timeline of a user
+// @Summary Get user activity timeline
+// @Tags users
+// @Produce json
+// @Param id path string true "User ID"
+// @Param from query string false "Start of date range (RFC3339 or YYYY-MM-DD)"
+// @Param to query string false "End of date range (RFC3339 or YYYY-MM-DD)"
+// @Param page query int false "Page number"
+// @Param page_size query int false "Page size"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} helpers.ErrorEnvelope
+// @Failure 403 {object} helpers.ErrorEnvelope
+// @Failure 404 {object} helpers.ErrorEnvelope
+// @Router /users/{id}/activity [get]
+func (h *UserHandler) GetUserActivity(c *gin.Context) {
+	// HTTP: Get ID from URL
+	id := c.Param("id")
+
+	// HTTP: Get authenticated user
+	userID, exists := c.Get("user_id")
+	if !exists {
+		helpers.RespondError(c, http.StatusUnauthorized, helpers.CodeUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	// HTTP: Parse query parameters
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	from, err := parseActivityDate(c.Query("from"), false)
+	if err != nil {
+		helpers.RespondError(c, http.StatusBadRequest, helpers.CodeUserInvalidDateRange, "Format tanggal 'from' tidak valid", nil)
+		return
+	}
+	to, err := parseActivityDate(c.Query("to"), true)
+	if err != nil {
+		helpers.RespondError(c, http.StatusBadRequest, helpers.CodeUserInvalidDateRange, "Format tanggal 'to' tidak valid", nil)
+		return
+	}
+
+	// Business rule: Other users' activity requires admin access
+	if err := h.userService.CanViewUserActivity(userID.(string), id); err != nil {
+		helpers.RespondError(c, http.StatusForbidden, userErrorCode(err, helpers.CodeUserActivityForbidden), err.Error(), nil)
+		return
+	}
+
+	// Business logic: Get activity via service
+	result, err := h.userService.GetUserActivity(id, services.UserActivityParams{
+		From:     from,
+		To:       to,
+		Page:     page,
+		PageSize: pageSize,
+	})
+	if err != nil {
+		if err.Error() == "pengguna tidak ditemukan" {
+			helpers.RespondError(c, http.StatusNotFound, userErrorCode(err, helpers.CodeUserNotFound), err.Error(), nil)
+		} else {
+			helpers.RespondError(c, http.StatusInternalServerError, userErrorCode(err, helpers.CodeInternal), err.Error(), nil)
+		}
+		return
+	}
+
+	// HTTP: Format response
+	c.JSON(http.StatusOK, gin.H{
+		"data":        result.Data,
+		"total":       result.Total,
+		"page":        result.Page,
+		"page_size":   result.PageSize,
+		"total_pages": result.TotalPages,
+	})
+}
+
+// parseActivityDate parses an RFC3339 timestamp or a plain date in the application timezone.
+// A plain date used as the end of a range covers the whole local day.
+func parseActivityDate(value string, endOfDay bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+
+	t, err := clock.ParseDate(value)
+	if err != nil {
+		return nil, err
+	}
+	if endOfDay {
+		t = clock.EndOfDay(t)
+	}
+
+	return &t, nil
+}
+
+// ImpersonateUser handles starting an impersonation session as another user
+// @Summary Impersonate a user
+// @Tags users
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} helpers.ErrorEnvelope
+// @Failure 403 {object} helpers.ErrorEnvelope
+// @Failure 404 {object} helpers.ErrorEnvelope
+// @Router /users/{id}/impersonate [post]
+func (h *UserHandler) ImpersonateUser(c *gin.Context) {
+	// HTTP: Get ID from URL
+	id := c.Param("id")
+
+	// HTTP: Get authenticated user
+	adminID := c.GetString("user_id")
+	if adminID == "" {
+		helpers.RespondError(c, http.StatusUnauthorized, helpers.CodeUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	// Business rule: Impersonation sessions cannot be nested
+	if c.GetString("impersonator_id") != "" {
+		helpers.RespondError(c, http.StatusBadRequest, helpers.CodeImpersonationActive, "Hentikan penyamaran saat ini terlebih dahulu", nil)
+		return
+	}
+
+	// Business logic: Validate and audit via service
+	target, err := h.userService.ImpersonateUser(c.Request.Context(), adminID, id, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		switch err.Error() {
+		case "pengguna tidak ditemukan":
+			helpers.RespondError(c, http.StatusNotFound, userErrorCode(err, helpers.CodeUserNotFound), err.Error(), nil)
+		case "tidak dapat menyamar sebagai pengguna dengan level role lebih tinggi":
+			helpers.RespondError(c, http.StatusForbidden, userErrorCode(err, helpers.CodeImpersonationForbidden), err.Error(), nil)
+		default:
+			helpers.RespondError(c, http.StatusBadRequest, userErrorCode(err, helpers.CodeBadRequest), err.Error(), nil)
+		}
+		return
+	}
+
+	// HTTP: Issue short-lived token for the target, carrying the admin as impersonator
+	accessToken, err := auth.GenerateImpersonationToken(target.ID, target.Email, adminID)
+	if err != nil {
+		helpers.RespondError(c, http.StatusInternalServerError, helpers.CodeAuthTokenGenerationFailed, "Gagal membuat token", nil)
+		return
+	}
+	csrfToken, err := auth.GenerateCSRFToken(target.ID)
+	if err != nil {
+		helpers.RespondError(c, http.StatusInternalServerError, helpers.CodeAuthTokenGenerationFailed, "Gagal membuat token", nil)
+		return
+	}
+
+	// HTTP: Only the access token changes; the refresh cookie still belongs to the admin
+	isProduction := gin.Mode() == gin.ReleaseMode
+	helpers.UpdateAccessTokenCookie(c, accessToken, isProduction)
+	helpers.SetCSRFCookie(c, csrfToken, isProduction)
+
+	// HTTP: Format response
+	info := target.ToUserInfo()
+	info.ImpersonatorID = &adminID
+	c.JSON(http.StatusOK, gin.H{
+		"user":       info,
+		"expires_at": time.Now().Add(auth.ImpersonationTokenExpiry),
+		"expires_in": int64(auth.ImpersonationTokenExpiry.Seconds()),
+	})
+}
+
+// UpdateMe handles the authenticated user updating their own username and preferences.
+// Unlike UpdateUser it needs no users:update permission and cannot change account status.
+// @Summary Update own profile
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.UpdateProfileRequest true "Profile data"
+// @Success 200 {object} models.UserInfo
+// @Failure 400 {object} helpers.ErrorEnvelope
+// @Failure 409 {object} helpers.ErrorEnvelope
+// @Router /auth/me [patch]
+func (h *UserHandler) UpdateMe(c *gin.Context) {
+	// HTTP: Get authenticated user
+	userID := c.GetString("user_id")
+	if userID == "" {
+		helpers.RespondError(c, http.StatusUnauthorized, helpers.CodeUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	// HTTP: Parse and validate request
+	var req models.UpdateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helpers.RespondBindError(c, err)
+		return
+	}
+
+	// Business logic: Update profile via service (audited as the real actor when impersonating)
+	if err := h.userService.UpdateOwnProfile(userID, req, auditActorID(c)); err != nil {
+		switch {
+		case err.Error() == "pengguna tidak ditemukan":
+			helpers.RespondError(c, http.StatusNotFound, helpers.CodeUserNotFound, err.Error(), nil)
+		case err.Error() == "username sudah digunakan":
+			helpers.RespondError(c, http.StatusConflict, helpers.CodeUsernameTaken, err.Error(), nil)
+		case strings.HasPrefix(err.Error(), "gagal"):
+			helpers.RespondError(c, http.StatusInternalServerError, helpers.CodeInternal, err.Error(), nil)
+		default:
+			helpers.RespondError(c, http.StatusBadRequest, helpers.CodeValidationFailed, err.Error(), nil)
+		}
+		return
+	}
+
+	// HTTP: Format response (same shape as GET /auth/me)
+	info, err := currentUserInfo(c, userID)
+	if err != nil {
+		helpers.RespondError(c, http.StatusNotFound, helpers.CodeUserNotFound, "pengguna tidak ditemukan", nil)
+		return
+	}
+	helpers.DataResponse(c, http.StatusOK, info)
+}
+
+// StopImpersonation handles ending an impersonation session and restoring the admin's own session
+// @Summary Stop impersonating a user
+// @Tags auth
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} helpers.ErrorEnvelope
+// @Router /auth/stop-impersonation [post]
+func (h *UserHandler) StopImpersonation(c *gin.Context) {
+	// HTTP: Get impersonation context
+	impersonatorID := c.GetString("impersonator_id")
+	if impersonatorID == "" {
+		helpers.RespondError(c, http.StatusBadRequest, helpers.CodeImpersonationNotActive, "Tidak sedang menyamar sebagai pengguna lain", nil)
+		return
+	}
+	targetID := c.GetString("user_id")
+
+	// Business logic: Audit and load the impersonator via service
+	impersonator, err := h.userService.StopImpersonation(c.Request.Context(), impersonatorID, targetID, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		helpers.RespondError(c, http.StatusBadRequest, helpers.CodeImpersonationNotActive, err.Error(), nil)
+		return
+	}
+
+	// HTTP: Issue a regular access token for the impersonator
+	accessToken, err := auth.GenerateAccessToken(impersonator.ID, impersonator.Email)
+	if err != nil {
+		helpers.RespondError(c, http.StatusInternalServerError, helpers.CodeAuthTokenGenerationFailed, "Gagal membuat token", nil)
+		return
+	}
+	csrfToken, err := auth.GenerateCSRFToken(impersonator.ID)
+	if err != nil {
+		helpers.RespondError(c, http.StatusInternalServerError, helpers.CodeAuthTokenGenerationFailed, "Gagal membuat token", nil)
+		return
+	}
+
+	isProduction := gin.Mode() == gin.ReleaseMode
+	helpers.UpdateAccessTokenCookie(c, accessToken, isProduction)
+	helpers.SetCSRFCookie(c, csrfToken, isProduction)
+
+	// HTTP: Format response
+	c.JSON(http.StatusOK, gin.H{
+		"user":       impersonator.ToUserInfo(),
+		"expires_in": int64(auth.AccessTokenTTL().Seconds()),
+	})
+}
+
+// auditActorID returns the user to record as actor in audit logs:
+// the impersonating admin when present, otherwise the authenticated user.
+// Authorization, including escalation prevention, keeps using user_id: while
+// impersonating, the admin acts with the target's privileges.
+func auditActorID(c *gin.Context) string {
+	if impersonatorID := c.GetString("impersonator_id"); impersonatorID != "" {
+		return impersonatorID
+	}
+	return c.GetString("user_id")
+}
+
 // DeleteUser handles deleting a user
 // @Summary Delete a user
 // @Tags users
 // @Produce json
 // @Param id path string true "User ID"
 // @Success 200 {object} map[string]string
-// @Failure 400 {object} map[string]string
-// @Failure 404 {object} map[string]string
+// @Failure 400 {object} helpers.ErrorEnvelope
+// @Failure 404 {object} helpers.ErrorEnvelope
 // @Router /users/{id} [delete]
 func (h *UserHandler) DeleteUser(c *gin.Context) {
 	// HTTP: Get ID from URL
@@ -163,13 +706,13 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 	// HTTP: Get authenticated user (prevent self-deletion)
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		helpers.RespondError(c, http.StatusUnauthorized, helpers.CodeUnauthorized, "Unauthorized", nil)
 		return
 	}
 
 	// Business rule: Cannot delete yourself
 	if id == userID.(string) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Tidak dapat menghapus akun sendiri"})
+		helpers.RespondError(c, http.StatusBadRequest, helpers.CodeUserSelfAction, "Tidak dapat menghapus akun sendiri", nil)
 		return
 	}
 
@@ -177,9 +720,9 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 	err := h.userService.DeleteUser(id)
 	if err != nil {
 		if err.Error() == "pengguna tidak ditemukan" {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			helpers.RespondError(c, http.StatusNotFound, userErrorCode(err, helpers.CodeUserNotFound), err.Error(), nil)
 		} else {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			helpers.RespondError(c, http.StatusBadRequest, userErrorCode(err, helpers.CodeBadRequest), err.Error(), nil)
 		}
 		return
 	}
@@ -194,7 +737,7 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 // @Produce json
 // @Param id path string true "User ID"
 // @Success 200 {array} models.UserRoleResponse
-// @Failure 404 {object} map[string]string
+// @Failure 404 {object} helpers.ErrorEnvelope
 // @Router /users/{id}/roles [get]
 func (h *UserHandler) GetUserRoles(c *gin.Context) {
 	// HTTP: Get user ID from URL
@@ -204,9 +747,9 @@ func (h *UserHandler) GetUserRoles(c *gin.Context) {
 	roles, err := h.userService.GetUserRoles(userID)
 	if err != nil {
 		if err.Error() == "pengguna tidak ditemukan" {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			helpers.RespondError(c, http.StatusNotFound, userErrorCode(err, helpers.CodeUserNotFound), err.Error(), nil)
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			helpers.RespondError(c, http.StatusInternalServerError, userErrorCode(err, helpers.CodeInternal), err.Error(), nil)
 		}
 		return
 	}
@@ -215,6 +758,53 @@ func (h *UserHandler) GetUserRoles(c *gin.Context) {
 	c.JSON(http.StatusOK, roles)
 }
 
+// GetScheduledUserRoles handles listing role assignments that take effect in the future
+// @Summary Get scheduled user roles
+// @Tags users
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {array} models.UserRoleResponse
+// @Failure 404 {object} helpers.ErrorEnvelope
+// @Router /users/{id}/roles/scheduled [get]
+func (h *UserHandler) GetScheduledUserRoles(c *gin.Context) {
+	// HTTP: Get user ID from URL
+	userID := c.Param("id")
+
+	// Business logic: Get scheduled roles via service
+	roles, err := h.userService.GetScheduledUserRoles(userID)
+	if err != nil {
+		if err.Error() == "pengguna tidak ditemukan" {
+			helpers.RespondError(c, http.StatusNotFound, userErrorCode(err, helpers.CodeUserNotFound), err.Error(), nil)
+		} else {
+			helpers.RespondError(c, http.StatusInternalServerError, userErrorCode(err, helpers.CodeInternal), err.Error(), nil)
+		}
+		return
+	}
+
+	// HTTP: Format response
+	c.JSON(http.StatusOK, roles)
+}
+
+// GetOutstandingPasswordResets handles listing password reset requests that are still usable
+// @Summary Get outstanding password reset requests
+// @Description Lists users whose forgot-password link is unused and unexpired (no tokens are returned)
+// @Tags users
+// @Produce json
+// @Success 200 {array} models.PasswordResetRequestResponse
+// @Failure 500 {object} helpers.ErrorEnvelope
+// @Router /users/password-resets [get]
+func (h *UserHandler) GetOutstandingPasswordResets(c *gin.Context) {
+	// Business logic: Get outstanding reset requests via service
+	requests, err := h.userService.GetOutstandingPasswordResets()
+	if err != nil {
+		helpers.RespondError(c, http.StatusInternalServerError, userErrorCode(err, helpers.CodeInternal), err.Error(), nil)
+		return
+	}
+
+	// HTTP: Format response
+	c.JSON(http.StatusOK, requests)
+}
+
 // AssignRoleToUser handles assigning a role to a user
 // @Summary Assign role to user
 // @Tags users
@@ -223,8 +813,8 @@ func (h *UserHandler) GetUserRoles(c *gin.Context) {
 // @Param id path string true "User ID"
 // @Param request body models.AssignRoleToUserRequest true "Role assignment data"
 // @Success 201 {object} models.UserRoleResponse
-// @Failure 400 {object} map[string]string
-// @Failure 404 {object} map[string]string
+// @Failure 400 {object} helpers.ErrorEnvelope
+// @Failure 404 {object} helpers.ErrorEnvelope
 // @Router /users/{id}/roles [post]
 func (h *UserHandler) AssignRoleToUser(c *gin.Context) {
 	// HTTP: Get user ID from URL
@@ -233,26 +823,27 @@ func (h *UserHandler) AssignRoleToUser(c *gin.Context) {
 	// HTTP: Parse and validate request
 	var req models.AssignRoleToUserRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		helpers.RespondBindError(c, err)
 		return
 	}
 
 	// HTTP: Get authenticated user (who is assigning the role)
-	assignedBy, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+	assignedBy := auditActorID(c)
+	if assignedBy == "" {
+		helpers.RespondError(c, http.StatusUnauthorized, helpers.CodeUnauthorized, "Unauthorized", nil)
 		return
 	}
 
 	// Business logic: Assign role to user via service
-	roleResponse, err := h.userService.AssignRoleToUser(userID, req, assignedBy.(string))
+	roleResponse, err := h.userService.AssignRoleToUser(userID, req, assignedBy, c.GetString("user_id"))
 	if err != nil {
 		if err.Error() == "pengguna tidak ditemukan" || err.Error() == "role tidak ditemukan" {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-		} else if err.Error() == "role sudah di-assign ke pengguna ini" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			helpers.RespondError(c, http.StatusNotFound, userErrorCode(err, helpers.CodeUserNotFound), err.Error(), nil)
+		} else if err.Error() == "role sudah di-assign ke pengguna ini" || err.Error() == "role sudah dijadwalkan untuk pengguna ini" ||
+			err.Error() == "effective_until harus setelah effective_from" {
+			helpers.RespondError(c, http.StatusBadRequest, userErrorCode(err, helpers.CodeBadRequest), err.Error(), nil)
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			helpers.RespondError(c, http.StatusInternalServerError, userErrorCode(err, helpers.CodeInternal), err.Error(), nil)
 		}
 		return
 	}
@@ -261,6 +852,50 @@ func (h *UserHandler) AssignRoleToUser(c *gin.Context) {
 	c.JSON(http.StatusCreated, roleResponse)
 }
 
+// BulkAssignRolesToUser handles assigning several roles to a user in one request
+// @Summary Assign multiple roles to user
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param request body models.BulkAssignRolesToUserRequest true "Role assignments"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} helpers.ErrorEnvelope
+// @Failure 404 {object} helpers.ErrorEnvelope
+// @Router /users/{id}/roles/bulk [post]
+func (h *UserHandler) BulkAssignRolesToUser(c *gin.Context) {
+	// HTTP: Get user ID from URL
+	userID := c.Param("id")
+
+	// HTTP: Parse and validate request
+	var req models.BulkAssignRolesToUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helpers.RespondBindError(c, err)
+		return
+	}
+
+	// HTTP: Get authenticated user (who is assigning the roles)
+	assignedBy := auditActorID(c)
+	if assignedBy == "" {
+		helpers.RespondError(c, http.StatusUnauthorized, helpers.CodeUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	// Business logic: Assign roles via service
+	results, err := h.userService.BulkAssignRolesToUser(userID, req, assignedBy, c.GetString("user_id"))
+	if err != nil {
+		if err.Error() == "pengguna tidak ditemukan" {
+			helpers.RespondError(c, http.StatusNotFound, userErrorCode(err, helpers.CodeUserNotFound), err.Error(), nil)
+		} else {
+			helpers.RespondError(c, http.StatusInternalServerError, userErrorCode(err, helpers.CodeInternal), err.Error(), nil)
+		}
+		return
+	}
+
+	// HTTP: Format response
+	c.JSON(http.StatusOK, gin.H{"data": results})
+}
+
 // RevokeRoleFromUser handles revoking a role from a user
 // @Summary Revoke role from user
 // @Tags users
@@ -268,7 +903,7 @@ func (h *UserHandler) AssignRoleToUser(c *gin.Context) {
 // @Param id path string true "User ID"
 // @Param role_id path string true "Role Assignment ID"
 // @Success 200 {object} map[string]string
-// @Failure 404 {object} map[string]string
+// @Failure 404 {object} helpers.ErrorEnvelope
 // @Router /users/{id}/roles/{role_id} [delete]
 func (h *UserHandler) RevokeRoleFromUser(c *gin.Context) {
 	// HTTP: Get IDs from URL
@@ -276,12 +911,12 @@ func (h *UserHandler) RevokeRoleFromUser(c *gin.Context) {
 	roleAssignmentID := c.Param("role_id")
 
 	// Business logic: Revoke role from user via service
-	err := h.userService.RevokeRoleFromUser(userID, roleAssignmentID)
+	err := h.userService.RevokeRoleFromUser(userID, roleAssignmentID, auditActorID(c))
 	if err != nil {
 		if err.Error() == "pengguna tidak ditemukan" || err.Error() == "role assignment tidak ditemukan" {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			helpers.RespondError(c, http.StatusNotFound, userErrorCode(err, helpers.CodeUserNotFound), err.Error(), nil)
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			helpers.RespondError(c, http.StatusInternalServerError, userErrorCode(err, helpers.CodeInternal), err.Error(), nil)
 		}
 		return
 	}
@@ -296,7 +931,7 @@ func (h *UserHandler) RevokeRoleFromUser(c *gin.Context) {
 // @Produce json
 // @Param id path string true "User ID"
 // @Success 200 {array} models.UserPositionResponse
-// @Failure 404 {object} map[string]string
+// @Failure 404 {object} helpers.ErrorEnvelope
 // @Router /users/{id}/positions [get]
 func (h *UserHandler) GetUserPositions(c *gin.Context) {
 	// HTTP: Get user ID from URL
@@ -306,9 +941,9 @@ func (h *UserHandler) GetUserPositions(c *gin.Context) {
 	positions, err := h.userService.GetUserPositions(userID)
 	if err != nil {
 		if err.Error() == "pengguna tidak ditemukan" {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			helpers.RespondError(c, http.StatusNotFound, userErrorCode(err, helpers.CodeUserNotFound), err.Error(), nil)
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			helpers.RespondError(c, http.StatusInternalServerError, userErrorCode(err, helpers.CodeInternal), err.Error(), nil)
 		}
 		return
 	}
@@ -317,6 +952,34 @@ func (h *UserHandler) GetUserPositions(c *gin.Context) {
 	c.JSON(http.StatusOK, positions)
 }
 
+// GetUserPositionHistory handles getting a user's full position history
+// @Summary Get user position history
+// @Description Lists every position assignment, ended ones included, oldest first with status and end reason, plus gaps between assignments
+// @Tags users
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} models.UserPositionHistoryResponse
+// @Failure 404 {object} helpers.ErrorEnvelope
+// @Router /users/{id}/positions/history [get]
+func (h *UserHandler) GetUserPositionHistory(c *gin.Context) {
+	// HTTP: Get user ID from URL
+	userID := c.Param("id")
+
+	// Business logic: Get position history via service
+	history, err := h.userService.GetUserPositionHistory(userID)
+	if err != nil {
+		if err.Error() == "pengguna tidak ditemukan" {
+			helpers.RespondError(c, http.StatusNotFound, userErrorCode(err, helpers.CodeUserNotFound), err.Error(), nil)
+		} else {
+			helpers.RespondError(c, http.StatusInternalServerError, userErrorCode(err, helpers.CodeInternal), err.Error(), nil)
+		}
+		return
+	}
+
+	// HTTP: Format response
+	c.JSON(http.StatusOK, history)
+}
+
 // AssignPositionToUser handles assigning a position to a user
 // @Summary Assign position to user
 // @Tags users
@@ -325,8 +988,9 @@ func (h *UserHandler) GetUserPositions(c *gin.Context) {
 // @Param id path string true "User ID"
 // @Param request body models.AssignPositionToUserRequest true "Position assignment data"
 // @Success 201 {object} models.UserPositionResponse
-// @Failure 400 {object} map[string]string
-// @Failure 404 {object} map[string]string
+// @Failure 400 {object} helpers.ErrorEnvelope
+// @Failure 404 {object} helpers.ErrorEnvelope
+// @Failure 409 {object} helpers.ErrorEnvelope
 // @Router /users/{id}/positions [post]
 func (h *UserHandler) AssignPositionToUser(c *gin.Context) {
 	// HTTP: Get user ID from URL
@@ -335,26 +999,28 @@ func (h *UserHandler) AssignPositionToUser(c *gin.Context) {
 	// HTTP: Parse and validate request
 	var req models.AssignPositionToUserRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		helpers.RespondBindError(c, err)
 		return
 	}
 
 	// HTTP: Get authenticated user (who is assigning the position)
-	appointedBy, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+	appointedBy := auditActorID(c)
+	if appointedBy == "" {
+		helpers.RespondError(c, http.StatusUnauthorized, helpers.CodeUnauthorized, "Unauthorized", nil)
 		return
 	}
 
 	// Business logic: Assign position to user via service
-	positionResponse, err := h.userService.AssignPositionToUser(userID, req, appointedBy.(string))
+	positionResponse, err := h.userService.AssignPositionToUser(userID, req, appointedBy, c.GetString("user_id"))
 	if err != nil {
 		if err.Error() == "pengguna tidak ditemukan" || err.Error() == "posisi tidak ditemukan" {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-		} else if err.Error() == "posisi sudah di-assign ke pengguna ini" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			helpers.RespondError(c, http.StatusNotFound, userErrorCode(err, helpers.CodeUserNotFound), err.Error(), nil)
+		} else if err.Error() == "posisi sudah di-assign ke pengguna ini pada periode yang beririsan" {
+			helpers.RespondError(c, http.StatusConflict, userErrorCode(err, helpers.CodeBadRequest), err.Error(), nil)
+		} else if err.Error() == "end_date harus setelah start_date" || strings.HasPrefix(err.Error(), "permission scope tidak valid") {
+			helpers.RespondError(c, http.StatusBadRequest, userErrorCode(err, helpers.CodeBadRequest), err.Error(), nil)
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			helpers.RespondError(c, http.StatusInternalServerError, userErrorCode(err, helpers.CodeInternal), err.Error(), nil)
 		}
 		return
 	}
@@ -370,7 +1036,7 @@ func (h *UserHandler) AssignPositionToUser(c *gin.Context) {
 // @Param id path string true "User ID"
 // @Param position_id path string true "Position Assignment ID"
 // @Success 200 {object} map[string]string
-// @Failure 404 {object} map[string]string
+// @Failure 404 {object} helpers.ErrorEnvelope
 // @Router /users/{id}/positions/{position_id} [delete]
 func (h *UserHandler) RevokePositionFromUser(c *gin.Context) {
 	// HTTP: Get IDs from URL
@@ -378,12 +1044,12 @@ func (h *UserHandler) RevokePositionFromUser(c *gin.Context) {
 	positionAssignmentID := c.Param("position_id")
 
 	// Business logic: Revoke position from user via service
-	err := h.userService.RevokePositionFromUser(userID, positionAssignmentID)
+	err := h.userService.RevokePositionFromUser(userID, positionAssignmentID, auditActorID(c))
 	if err != nil {
 		if err.Error() == "pengguna tidak ditemukan" || err.Error() == "position assignment tidak ditemukan" {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			helpers.RespondError(c, http.StatusNotFound, userErrorCode(err, helpers.CodeUserNotFound), err.Error(), nil)
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			helpers.RespondError(c, http.StatusInternalServerError, userErrorCode(err, helpers.CodeInternal), err.Error(), nil)
 		}
 		return
 	}
@@ -398,7 +1064,7 @@ func (h *UserHandler) RevokePositionFromUser(c *gin.Context) {
 // @Produce json
 // @Param id path string true "User ID"
 // @Success 200 {array} models.UserPermissionResponse
-// @Failure 404 {object} map[string]string
+// @Failure 404 {object} helpers.ErrorEnvelope
 // @Router /users/{id}/permissions [get]
 func (h *UserHandler) GetUserPermissions(c *gin.Context) {
 	// HTTP: Get user ID from URL
@@ -408,9 +1074,9 @@ func (h *UserHandler) GetUserPermissions(c *gin.Context) {
 	permissions, err := h.userService.GetUserPermissions(userID)
 	if err != nil {
 		if err.Error() == "pengguna tidak ditemukan" {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			helpers.RespondError(c, http.StatusNotFound, userErrorCode(err, helpers.CodeUserNotFound), err.Error(), nil)
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			helpers.RespondError(c, http.StatusInternalServerError, userErrorCode(err, helpers.CodeInternal), err.Error(), nil)
 		}
 		return
 	}
@@ -419,6 +1085,35 @@ func (h *UserHandler) GetUserPermissions(c *gin.Context) {
 	c.JSON(http.StatusOK, permissions)
 }
 
+// GetUserPermissionConflicts handles listing ambiguous allow/deny pairs among a user's direct permissions
+// @Summary Get conflicting user permissions
+// @Description Pairs of direct permissions matching the same resource and action with equal priority
+// @Description but opposite grants. The resolver lets the deny win such a tie.
+// @Tags users
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {array} models.UserPermissionConflict
+// @Failure 404 {object} helpers.ErrorEnvelope
+// @Router /users/{id}/permissions/conflicts [get]
+func (h *UserHandler) GetUserPermissionConflicts(c *gin.Context) {
+	// HTTP: Get user ID from URL
+	userID := c.Param("id")
+
+	// Business logic: Detect conflicts via service
+	conflicts, err := h.userService.GetUserPermissionConflicts(userID)
+	if err != nil {
+		if err.Error() == "pengguna tidak ditemukan" {
+			helpers.RespondError(c, http.StatusNotFound, userErrorCode(err, helpers.CodeUserNotFound), err.Error(), nil)
+		} else {
+			helpers.RespondError(c, http.StatusInternalServerError, userErrorCode(err, helpers.CodeInternal), err.Error(), nil)
+		}
+		return
+	}
+
+	// HTTP: Format response
+	c.JSON(http.StatusOK, conflicts)
+}
+
 // AssignPermissionToUser handles assigning a direct permission to a user
 // @Summary Assign permission to user
 // @Tags users
@@ -427,8 +1122,9 @@ func (h *UserHandler) GetUserPermissions(c *gin.Context) {
 // @Param id path string true "User ID"
 // @Param request body models.AssignPermissionToUserRequest true "Permission assignment data"
 // @Success 201 {object} models.UserPermissionResponse
-// @Failure 400 {object} map[string]string
-// @Failure 404 {object} map[string]string
+// @Failure 400 {object} helpers.ErrorEnvelope
+// @Failure 404 {object} helpers.ErrorEnvelope
+// @Failure 409 {object} helpers.ErrorEnvelope
 // @Router /users/{id}/permissions [post]
 func (h *UserHandler) AssignPermissionToUser(c *gin.Context) {
 	// HTTP: Get user ID from URL
@@ -437,24 +1133,26 @@ func (h *UserHandler) AssignPermissionToUser(c *gin.Context) {
 	// HTTP: Parse and validate request
 	var req models.AssignPermissionToUserRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		helpers.RespondBindError(c, err)
 		return
 	}
 
 	// HTTP: Get authenticated user (who is granting the permission)
-	grantedBy, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+	grantedBy := auditActorID(c)
+	if grantedBy == "" {
+		helpers.RespondError(c, http.StatusUnauthorized, helpers.CodeUnauthorized, "Unauthorized", nil)
 		return
 	}
 
 	// Business logic: Assign permission to user via service
-	permissionResponse, err := h.userService.AssignPermissionToUser(userID, req, grantedBy.(string))
+	permissionResponse, err := h.userService.AssignPermissionToUser(userID, req, grantedBy, c.GetString("user_id"))
 	if err != nil {
 		if err.Error() == "pengguna tidak ditemukan" || err.Error() == "permission tidak ditemukan" {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			helpers.RespondError(c, http.StatusNotFound, userErrorCode(err, helpers.CodeUserNotFound), err.Error(), nil)
+		} else if strings.HasPrefix(err.Error(), "permission bertentangan") {
+			helpers.RespondError(c, http.StatusConflict, helpers.CodePermissionConflict, err.Error(), nil)
 		} else {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			helpers.RespondError(c, http.StatusBadRequest, userErrorCode(err, helpers.CodeBadRequest), err.Error(), nil)
 		}
 		return
 	}
@@ -470,7 +1168,7 @@ func (h *UserHandler) AssignPermissionToUser(c *gin.Context) {
 // @Param id path string true "User ID"
 // @Param permission_id path string true "Permission Assignment ID"
 // @Success 200 {object} map[string]string
-// @Failure 404 {object} map[string]string
+// @Failure 404 {object} helpers.ErrorEnvelope
 // @Router /users/{id}/permissions/{permission_id} [delete]
 func (h *UserHandler) RevokePermissionFromUser(c *gin.Context) {
 	// HTTP: Get IDs from URL
@@ -478,12 +1176,12 @@ func (h *UserHandler) RevokePermissionFromUser(c *gin.Context) {
 	permissionAssignmentID := c.Param("permission_id")
 
 	// Business logic: Revoke permission from user via service
-	err := h.userService.RevokePermissionFromUser(userID, permissionAssignmentID)
+	err := h.userService.RevokePermissionFromUser(userID, permissionAssignmentID, auditActorID(c))
 	if err != nil {
 		if err.Error() == "pengguna tidak ditemukan" || err.Error() == "permission assignment tidak ditemukan" {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			helpers.RespondError(c, http.StatusNotFound, userErrorCode(err, helpers.CodeUserNotFound), err.Error(), nil)
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			helpers.RespondError(c, http.StatusInternalServerError, userErrorCode(err, helpers.CodeInternal), err.Error(), nil)
 		}
 		return
 	}
@@ -491,3 +1189,68 @@ func (h *UserHandler) RevokePermissionFromUser(c *gin.Context) {
 	// HTTP: Format response
 	c.JSON(http.StatusOK, gin.H{"message": "Permission berhasil di-revoke dari pengguna"})
 }
+
+// parseUserListFilters reads the filter and sort query parameters shared by GetUsers and
+// ExportUsers. role_id may be repeated or comma-separated.
+func parseUserListFilters(c *gin.Context) (services.UserListParams, error) {
+	params := services.UserListParams{
+		Search:       c.Query("search"),
+		PositionID:   c.Query("position_id"),
+		DepartmentID: c.Query("department_id"),
+		SortBy:       c.DefaultQuery("sort_by", "email"),
+		SortOrder:    c.DefaultQuery("sort_order", "asc"),
+	}
+
+	for _, value := range c.QueryArray("role_id") {
+		for _, roleID := range strings.Split(value, ",") {
+			if roleID = strings.TrimSpace(roleID); roleID != "" {
+				params.RoleIDs = append(params.RoleIDs, roleID)
+			}
+		}
+	}
+
+	// HTTP: Parse is_active filter
+	if isActiveStr := c.Query("is_active"); isActiveStr != "" {
+		val, _ := strconv.ParseBool(isActiveStr)
+		params.IsActive = &val
+	}
+
+	params.HasNoRole, _ = strconv.ParseBool(c.Query("has_no_role"))
+	if params.HasNoRole && len(params.RoleIDs) > 0 {
+		return params, errors.New("has_no_role tidak dapat digabung dengan role_id")
+	}
+
+	return params, nil
+}
+
+// userErrorCode maps known user service error messages to error codes, falling back
+// to the given code for anything else
+func userErrorCode(err error, fallback string) string {
+	msg := err.Error()
+	switch {
+	case msg == "pengguna tidak ditemukan":
+		return helpers.CodeUserNotFound
+	case msg == "role tidak ditemukan":
+		return helpers.CodeRoleNotFound
+	case msg == "posisi tidak ditemukan":
+		return helpers.CodePositionNotFound
+	case msg == "permission tidak ditemukan":
+		return helpers.CodePermissionNotFound
+	case strings.HasSuffix(msg, "assignment tidak ditemukan"):
+		return helpers.CodeAssignmentNotFound
+	case msg == "role sudah di-assign ke pengguna ini":
+		return helpers.CodeRoleAlreadyAssigned
+	case msg == "role sudah dijadwalkan untuk pengguna ini":
+		return helpers.CodeRoleAlreadyScheduled
+	case msg == "effective_until harus setelah effective_from", msg == "end_date harus setelah start_date",
+		msg == "isi user_ids atau filter department_id/school_id, tidak keduanya":
+		return helpers.CodeValidationFailed
+	case msg == "posisi sudah di-assign ke pengguna ini pada periode yang beririsan":
+		return helpers.CodePositionAlreadyAssigned
+	case strings.HasPrefix(msg, "permission bertentangan"):
+		return helpers.CodePermissionConflict
+	case strings.HasPrefix(msg, "permission scope tidak valid"):
+		return helpers.CodePositionScopeInvalid
+	}
+	return fallback
+}
@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"net/http"
+
+	"backend/internal/helpers"
+	"backend/internal/models"
+	"backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WorkflowInstanceHandler handles HTTP requests for workflow instances
+type WorkflowInstanceHandler struct {
+	workflowInstanceService *services.WorkflowInstanceService
+}
+
+// NewWorkflowInstanceHandler creates a new WorkflowInstanceHandler instance
+func NewWorkflowInstanceHandler(workflowInstanceService *services.WorkflowInstanceService) *WorkflowInstanceHandler {
+	return &WorkflowInstanceHandler{
+		workflowInstanceService: workflowInstanceService,
+	}
+}
+
+// StartWorkflowInstance handles starting a new workflow instance
+// @Summary Start a new workflow instance
+// @Tags workflow-instances
+// @Accept json
+// @Produce json
+// @Param request body models.StartWorkflowInstanceRequest true "Workflow instance data"
+// @Success 201 {object} models.WorkflowInstanceResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /workflow-instances [post]
+func (h *WorkflowInstanceHandler) StartWorkflowInstance(c *gin.Context) {
+	var req models.StartWorkflowInstanceRequest
+
+	// HTTP: Parse and validate request
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(helpers.BindErrorStatus(err), helpers.BindErrorBody(c, err))
+		return
+	}
+
+	// HTTP: Get authenticated user
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// Business logic: Start workflow instance via service
+	instance, err := h.workflowInstanceService.Start(c.Request.Context(), req.WorkflowType, req.PositionID, req.Payload, userID.(string))
+	if err != nil {
+		switch err.Error() {
+		case "aturan workflow tidak ditemukan untuk posisi dan tipe ini":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case "anda tidak memegang posisi ini":
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	// HTTP: Format response
+	c.JSON(http.StatusCreated, instance.ToResponse())
+}
+
+// GetWorkflowInstanceByID handles getting the current state of a workflow instance
+// @Summary Get workflow instance by ID
+// @Tags workflow-instances
+// @Produce json
+// @Param id path string true "Workflow Instance ID"
+// @Success 200 {object} models.WorkflowInstanceResponse
+// @Failure 404 {object} map[string]string
+// @Router /workflow-instances/{id} [get]
+func (h *WorkflowInstanceHandler) GetWorkflowInstanceByID(c *gin.Context) {
+	// HTTP: Get ID from URL
+	id := c.Param("id")
+
+	// Business logic: Get workflow instance via service
+	instance, err := h.workflowInstanceService.GetWorkflowInstanceByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	// HTTP: Format response
+	c.JSON(http.StatusOK, instance.ToResponse())
+}
+
+// ApproveWorkflowInstance handles approving the current step of a workflow instance
+// @Summary Approve the current step of a workflow instance
+// @Tags workflow-instances
+// @Accept json
+// @Produce json
+// @Param id path string true "Workflow Instance ID"
+// @Param request body models.ApproveWorkflowStepRequest false "Approval data"
+// @Success 200 {object} models.WorkflowInstanceResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /workflow-instances/{id}/approve [post]
+func (h *WorkflowInstanceHandler) ApproveWorkflowInstance(c *gin.Context) {
+	// HTTP: Get ID from URL
+	id := c.Param("id")
+
+	// HTTP: Parse request (body is optional)
+	var req models.ApproveWorkflowStepRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(helpers.BindErrorStatus(err), helpers.BindErrorBody(c, err))
+			return
+		}
+	}
+
+	// HTTP: Get authenticated user
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// Business logic: Approve via service
+	instance, err := h.workflowInstanceService.Approve(c.Request.Context(), id, userID.(string), req.Comment)
+	if err != nil {
+		respondWorkflowInstanceError(c, err)
+		return
+	}
+
+	// HTTP: Format response
+	c.JSON(http.StatusOK, instance.ToResponse())
+}
+
+// RejectWorkflowInstance handles rejecting the current step of a workflow instance
+// @Summary Reject a workflow instance at its current step
+// @Tags workflow-instances
+// @Accept json
+// @Produce json
+// @Param id path string true "Workflow Instance ID"
+// @Param request body models.RejectWorkflowStepRequest true "Rejection data"
+// @Success 200 {object} models.WorkflowInstanceResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /workflow-instances/{id}/reject [post]
+func (h *WorkflowInstanceHandler) RejectWorkflowInstance(c *gin.Context) {
+	// HTTP: Get ID from URL
+	id := c.Param("id")
+
+	// HTTP: Parse and validate request
+	var req models.RejectWorkflowStepRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(helpers.BindErrorStatus(err), helpers.BindErrorBody(c, err))
+		return
+	}
+
+	// HTTP: Get authenticated user
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// Business logic: Reject via service
+	instance, err := h.workflowInstanceService.Reject(id, userID.(string), req.Reason)
+	if err != nil {
+		respondWorkflowInstanceError(c, err)
+		return
+	}
+
+	// HTTP: Format response
+	c.JSON(http.StatusOK, instance.ToResponse())
+}
+
+// respondWorkflowInstanceError maps workflow instance service errors to HTTP status codes
+func respondWorkflowInstanceError(c *gin.Context, err error) {
+	switch err.Error() {
+	case "workflow instance tidak ditemukan":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case "anda tidak berwenang memproses step ini":
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"strconv"
 
+	"backend/internal/helpers"
 	"backend/internal/models"
 	"backend/internal/services"
 
@@ -37,7 +38,7 @@ func (h *WorkflowRuleHandler) CreateWorkflowRule(c *gin.Context) {
 
 	// HTTP: Parse and validate request
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(helpers.BindErrorStatus(err), helpers.BindErrorBody(c, err))
 		return
 	}
 
@@ -143,6 +144,34 @@ func (h *WorkflowRuleHandler) GetWorkflowRuleByID(c *gin.Context) {
 	c.JSON(http.StatusOK, workflowRule.ToResponse())
 }
 
+// GetWorkflowRuleVersions handles getting the version history of a workflow rule
+// @Summary Get workflow rule version history
+// @Tags workflow-rules
+// @Produce json
+// @Param id path string true "Workflow Rule ID"
+// @Success 200 {array} models.WorkflowRuleVersionResponse
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /workflow-rules/{id}/versions [get]
+func (h *WorkflowRuleHandler) GetWorkflowRuleVersions(c *gin.Context) {
+	// HTTP: Get ID from URL
+	id := c.Param("id")
+
+	// Business logic: Get versions via service
+	versions, err := h.workflowRuleService.GetWorkflowRuleVersions(id)
+	if err != nil {
+		if err.Error() == "aturan workflow tidak ditemukan" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	// HTTP: Format response
+	c.JSON(http.StatusOK, versions)
+}
+
 // GetWorkflowRuleByPositionAndType handles getting workflow rule by position and type
 // @Summary Get workflow rule by position ID and workflow type
 // @Tags workflow-rules
@@ -173,6 +202,38 @@ func (h *WorkflowRuleHandler) GetWorkflowRuleByPositionAndType(c *gin.Context) {
 	c.JSON(http.StatusOK, workflowRule.ToResponse())
 }
 
+// GetApprovalChain handles previewing who would approve a new request
+// @Summary Preview the approval chain for a position and workflow type
+// @Description Returns the ordered approval step groups of the matching active rule, with the
+// @Description users currently holding each approver position. rule_found is false when no rule matches.
+// @Tags workflow-rules
+// @Produce json
+// @Param position_id query string true "Requester position ID"
+// @Param workflow_type query string true "Workflow Type"
+// @Success 200 {object} models.WorkflowApprovalChainResponse
+// @Failure 400 {object} map[string]string
+// @Router /workflow-rules/approval-chain [get]
+func (h *WorkflowRuleHandler) GetApprovalChain(c *gin.Context) {
+	// HTTP: Get query parameters
+	positionID := c.Query("position_id")
+	workflowType := c.Query("workflow_type")
+
+	if positionID == "" || workflowType == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "position_id dan workflow_type harus diisi"})
+		return
+	}
+
+	// Business logic: Resolve approval chain via service
+	chain, err := h.workflowRuleService.GetApprovalChain(positionID, workflowType)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// HTTP: Format response
+	c.JSON(http.StatusOK, chain)
+}
+
 // UpdateWorkflowRule handles updating a workflow rule
 // @Summary Update a workflow rule
 // @Tags workflow-rules
@@ -191,7 +252,7 @@ func (h *WorkflowRuleHandler) UpdateWorkflowRule(c *gin.Context) {
 	// HTTP: Parse and validate request
 	var req models.UpdateWorkflowRuleRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(helpers.BindErrorStatus(err), helpers.BindErrorBody(c, err))
 		return
 	}
 
@@ -271,7 +332,7 @@ func (h *WorkflowRuleHandler) BulkCreateWorkflowRules(c *gin.Context) {
 
 	// HTTP: Parse and validate request
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(helpers.BindErrorStatus(err), helpers.BindErrorBody(c, err))
 		return
 	}
 
@@ -292,3 +353,40 @@ func (h *WorkflowRuleHandler) BulkCreateWorkflowRules(c *gin.Context) {
 	// HTTP: Format response
 	c.JSON(http.StatusOK, result)
 }
+
+// CopyWorkflowRules handles copying every workflow rule of one school to other schools
+// @Summary Copy workflow rules from one school to other schools
+// @Tags workflow-rules
+// @Accept json
+// @Produce json
+// @Param request body services.CopyWorkflowRulesRequest true "Copy request"
+// @Success 200 {object} services.BulkCreateResult
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /workflow-rules/copy [post]
+func (h *WorkflowRuleHandler) CopyWorkflowRules(c *gin.Context) {
+	var req services.CopyWorkflowRulesRequest
+
+	// HTTP: Parse and validate request
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(helpers.BindErrorStatus(err), helpers.BindErrorBody(c, err))
+		return
+	}
+
+	// HTTP: Get authenticated user
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// Business logic: Copy workflow rules via service
+	result, err := h.workflowRuleService.CopyWorkflowRules(req, userID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// HTTP: Format response
+	c.JSON(http.StatusOK, result)
+}
@@ -1,57 +1,59 @@
 package helpers
 
 import (
+	"net/http"
+	"time"
+
+	"backend/internal/auth"
+
 	"github.com/gin-gonic/gin"
 )
 
-// SetAuthCookies sets both access and refresh token cookies
-func SetAuthCookies(c *gin.Context, accessToken, refreshToken string, isProduction bool) {
-	// Access token cookie (1 hour expiry)
-	c.SetCookie(
-		"gloria_access_token", // name
-		accessToken,           // value
-		3600,                  // maxAge in seconds (1 hour)
-		"/",                   // path
-		"",                    // domain (empty = current domain)
-		isProduction,          // secure (HTTPS only in production)
-		true,                  // httpOnly
-	)
-
-	// Refresh token cookie (7 days expiry)
-	c.SetCookie(
-		"gloria_refresh_token", // name
-		refreshToken,           // value
-		604800,                 // maxAge in seconds (7 days)
-		"/",                    // path
-		"",                     // domain
-		isProduction,           // secure
-		true,                   // httpOnly
-	)
+// CookieOptions are the attributes applied to every auth and CSRF cookie
+type CookieOptions struct {
+	Domain   string        // empty = host-only cookie for the API host
+	SameSite http.SameSite // http.SameSiteDefaultMode omits the attribute
+	Secure   *bool         // nil = secure only in production
+}
+
+var cookieOptions CookieOptions
+
+// ConfigureCookies sets the cookie attributes used from now on. Call it once at startup,
+// before any request is served.
+func ConfigureCookies(opts CookieOptions) {
+	cookieOptions = opts
+}
+
+// setCookie sets a cookie with the configured domain, SameSite and Secure attributes.
+// Cookies are cleared through it too, since a browser only removes a cookie when the
+// domain matches the one it was set with.
+func setCookie(c *gin.Context, name, value string, maxAge int, isProduction, httpOnly bool) {
+	secure := isProduction
+	if cookieOptions.Secure != nil {
+		secure = *cookieOptions.Secure
+	}
+
+	c.SetSameSite(cookieOptions.SameSite)
+	c.SetCookie(name, value, maxAge, "/", cookieOptions.Domain, secure, httpOnly)
+}
+
+// SetAuthCookies sets both access and refresh token cookies.
+// refreshLifetime is the lifetime of the refresh token, so the cookie expires with it.
+func SetAuthCookies(c *gin.Context, accessToken, refreshToken string, refreshLifetime time.Duration, isProduction bool) {
+	// Access token cookie (expires with the access token)
+	setCookie(c, "gloria_access_token", accessToken, int(auth.AccessTokenTTL().Seconds()), isProduction, true)
+
+	// Refresh token cookie (expires with the refresh token)
+	setCookie(c, "gloria_refresh_token", refreshToken, int(refreshLifetime.Seconds()), isProduction, true)
 }
 
 // ClearAuthCookies removes both access and refresh token cookies, and CSRF token cookie
 func ClearAuthCookies(c *gin.Context) {
 	// Clear access token cookie (negative maxAge deletes the cookie)
-	c.SetCookie(
-		"gloria_access_token",
-		"",
-		-1, // maxAge: -1 deletes the cookie
-		"/",
-		"",
-		false,
-		true,
-	)
+	setCookie(c, "gloria_access_token", "", -1, false, true)
 
 	// Clear refresh token cookie
-	c.SetCookie(
-		"gloria_refresh_token",
-		"",
-		-1,
-		"/",
-		"",
-		false,
-		true,
-	)
+	setCookie(c, "gloria_refresh_token", "", -1, false, true)
 
 	// Clear CSRF token cookie
 	ClearCSRFCookie(c)
@@ -59,39 +61,15 @@ func ClearAuthCookies(c *gin.Context) {
 
 // UpdateAccessTokenCookie updates only the access token cookie (used after refresh)
 func UpdateAccessTokenCookie(c *gin.Context, accessToken string, isProduction bool) {
-	c.SetCookie(
-		"gloria_access_token",
-		accessToken,
-		3600, // 1 hour
-		"/",
-		"",
-		isProduction,
-		true,
-	)
+	setCookie(c, "gloria_access_token", accessToken, int(auth.AccessTokenTTL().Seconds()), isProduction, true)
 }
 
 // SetCSRFCookie sets the CSRF token cookie (NOT httpOnly - JavaScript needs to read it)
 func SetCSRFCookie(c *gin.Context, csrfToken string, isProduction bool) {
-	c.SetCookie(
-		"gloria_csrf_token", // name
-		csrfToken,           // value
-		86400,               // maxAge in seconds (24 hours)
-		"/",                 // path
-		"",                  // domain
-		isProduction,        // secure (HTTPS only in production)
-		false,               // httpOnly: FALSE - JavaScript needs to read this
-	)
+	setCookie(c, "gloria_csrf_token", csrfToken, 86400, isProduction, false)
 }
 
 // ClearCSRFCookie removes the CSRF token cookie
 func ClearCSRFCookie(c *gin.Context) {
-	c.SetCookie(
-		"gloria_csrf_token",
-		"",
-		-1, // maxAge: -1 deletes the cookie
-		"/",
-		"",
-		false,
-		false,
-	)
+	setCookie(c, "gloria_csrf_token", "", -1, false, false)
 }
@@ -0,0 +1,83 @@
+package helpers
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// Machine-readable error codes used in error envelopes. The frontend branches on and
+// localizes by these codes, so existing values must never change.
+const (
+	// Generic
+	CodeValidationFailed = "VALIDATION_FAILED"
+	CodeBadRequest       = "BAD_REQUEST"
+	CodeUnauthorized     = "UNAUTHORIZED"
+	CodeForbidden        = "FORBIDDEN"
+	CodeInternal         = "INTERNAL_ERROR"
+	CodeRequestTooLarge  = "REQUEST_TOO_LARGE"
+
+	// Authentication
+	CodeAuthInvalidCredentials    = "AUTH_INVALID_CREDENTIALS"
+	CodeAuthEmailNotRegistered    = "AUTH_EMAIL_NOT_REGISTERED"
+	CodeAuthEmailAlreadyExists    = "AUTH_EMAIL_ALREADY_EXISTS"
+	CodeAuthAccountInactive       = "AUTH_ACCOUNT_INACTIVE"
+	CodeAuthAccountLocked         = "AUTH_ACCOUNT_LOCKED"
+	CodeAuthEmployeeInactive      = "AUTH_EMPLOYEE_INACTIVE"
+	CodeAuthTokenInvalid          = "AUTH_TOKEN_INVALID"
+	CodeAuthTokenExpired          = "AUTH_TOKEN_EXPIRED"
+	CodeAuthTokenGenerationFailed = "AUTH_TOKEN_GENERATION_FAILED"
+	CodeAuthRefreshFailed         = "AUTH_REFRESH_FAILED"
+	CodeAuthPasswordHashFailed    = "AUTH_PASSWORD_HASH_FAILED"
+	CodeAuthOldPasswordIncorrect  = "AUTH_OLD_PASSWORD_INCORRECT"
+	CodeAuthPasswordResetInvalid  = "AUTH_PASSWORD_RESET_INVALID"
+	CodeAuthPasswordResetExpired  = "AUTH_PASSWORD_RESET_EXPIRED"
+	CodeAuthCSRFRequired          = "AUTH_CSRF_REQUIRED"
+	CodeAuthCSRFInvalid           = "AUTH_CSRF_INVALID"
+
+	// Users
+	CodeUserNotFound            = "USER_NOT_FOUND"
+	CodeUsernameTaken           = "USERNAME_TAKEN"
+	CodeUserSelfAction          = "USER_SELF_ACTION_NOT_ALLOWED"
+	CodeUserImportInvalidFile   = "USER_IMPORT_INVALID_FILE"
+	CodeUserImportFailed        = "USER_IMPORT_FAILED"
+	CodeUserActivityForbidden   = "USER_ACTIVITY_FORBIDDEN"
+	CodeUserInvalidDateRange    = "USER_INVALID_DATE_RANGE"
+	CodeImpersonationActive     = "IMPERSONATION_ALREADY_ACTIVE"
+	CodeImpersonationNotActive  = "IMPERSONATION_NOT_ACTIVE"
+	CodeImpersonationForbidden  = "IMPERSONATION_FORBIDDEN"
+	CodeRoleNotFound            = "ROLE_NOT_FOUND"
+	CodeRoleAlreadyAssigned     = "ROLE_ALREADY_ASSIGNED"
+	CodeRoleAlreadyScheduled    = "ROLE_ALREADY_SCHEDULED"
+	CodePositionNotFound        = "POSITION_NOT_FOUND"
+	CodePositionAlreadyAssigned = "POSITION_ALREADY_ASSIGNED"
+	CodePositionScopeInvalid    = "POSITION_SCOPE_INVALID"
+	CodePermissionNotFound      = "PERMISSION_NOT_FOUND"
+	CodePermissionConflict      = "PERMISSION_CONFLICT"
+	CodeAssignmentNotFound      = "ASSIGNMENT_NOT_FOUND"
+)
+
+// ErrorBody is the body of an error envelope
+type ErrorBody struct {
+	Code    string      `json:"code"`
+	Message string      `json:"message"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+// ErrorEnvelope is the JSON shape of every error response sent through RespondError
+type ErrorEnvelope struct {
+	Error ErrorBody `json:"error"`
+}
+
+// RespondError sends an error envelope. details may be nil and is omitted when it is.
+//
+// Response format:
+//
+//	{
+//	    "error": {
+//	        "code": "AUTH_INVALID_CREDENTIALS",
+//	        "message": "Email atau password salah",
+//	        "details": {...}
+//	    }
+//	}
+func RespondError(c *gin.Context, status int, code, message string, details interface{}) {
+	c.JSON(status, ErrorEnvelope{Error: ErrorBody{Code: code, Message: message, Details: details}})
+}
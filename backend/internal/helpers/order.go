@@ -0,0 +1,17 @@
+package helpers
+
+import "strings"
+
+// SafeOrder builds an ORDER BY clause from user-supplied sort parameters.
+// column must be a key of allowed; an unknown column returns "" so the caller can
+// fall back to its default ordering. direction is case-insensitive and anything
+// other than "desc" sorts ascending, so it is never interpolated into the query.
+func SafeOrder(column, direction string, allowed map[string]bool) string {
+	if !allowed[column] {
+		return ""
+	}
+	if strings.EqualFold(strings.TrimSpace(direction), "desc") {
+		return column + " DESC"
+	}
+	return column + " ASC"
+}
@@ -0,0 +1,47 @@
+package helpers
+
+import "testing"
+
+func TestSafeOrder(t *testing.T) {
+	allowed := map[string]bool{"name": true, "created_at": true}
+
+	tests := []struct {
+		name      string
+		column    string
+		direction string
+		want      string
+	}{
+		{"ascending", "name", "asc", "name ASC"},
+		{"descending", "name", "desc", "name DESC"},
+		{"direction is case-insensitive", "created_at", "DeSc", "created_at DESC"},
+		{"direction is trimmed", "name", "  desc\n", "name DESC"},
+		{"empty direction sorts ascending", "name", "", "name ASC"},
+		{"unknown direction sorts ascending", "name", "sideways", "name ASC"},
+		{"unknown column falls back to the caller default", "password", "asc", ""},
+		{"empty column falls back to the caller default", "", "desc", ""},
+		{"column lookup is exact", "Name", "asc", ""},
+
+		// Injection payloads in sort_order never reach the clause
+		{"stacked query in direction", "name", "desc; DROP TABLE users; --", "name ASC"},
+		{"comment in direction", "name", "desc--", "name ASC"},
+		{"extra ORDER BY term in direction", "name", "desc, (SELECT password FROM users LIMIT 1)", "name ASC"},
+		{"boolean subquery in direction", "name", "ASC, CASE WHEN (SELECT 1)=1 THEN name END", "name ASC"},
+		{"NULLS clause in direction", "name", "desc nulls first", "name ASC"},
+		{"quote in direction", "name", "desc'", "name ASC"},
+
+		// Injection payloads in sort_by are rejected outright
+		{"stacked query in column", "name; DROP TABLE users; --", "asc", ""},
+		{"subquery in column", "(SELECT password FROM users LIMIT 1)", "asc", ""},
+		{"allowed column with suffix", "name DESC, id", "asc", ""},
+		{"allowed column with comment", "name--", "asc", ""},
+		{"quoted identifier", `"name"`, "asc", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SafeOrder(tt.column, tt.direction, allowed); got != tt.want {
+				t.Errorf("SafeOrder(%q, %q) = %q, want %q", tt.column, tt.direction, got, tt.want)
+			}
+		})
+	}
+}
@@ -9,54 +9,6 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// ErrorResponse sends a JSON error response with translated message.
-// The response includes both the translated message and the message key for debugging.
-//
-// Response format:
-//
-//	{
-//	    "error": "Translated error message",
-//	    "code": "error.key"
-//	}
-func ErrorResponse(c *gin.Context, status int, key string) {
-	c.JSON(status, gin.H{
-		"error": i18n.T(c, key),
-		"code":  key,
-	})
-}
-
-// ErrorResponseF sends a JSON error response with formatted translated message.
-// Use this when the message requires format arguments.
-//
-// Example:
-//
-//	ErrorResponseF(c, 400, "validation.min_length", "Password", 8)
-//	// Result: {"error": "Password minimal 8 karakter", "code": "validation.min_length"}
-func ErrorResponseF(c *gin.Context, status int, key string, args ...interface{}) {
-	c.JSON(status, gin.H{
-		"error": i18n.TF(c, key, args...),
-		"code":  key,
-	})
-}
-
-// ErrorResponseWithDetails sends a JSON error response with additional details.
-// Useful for validation errors or when more context is needed.
-//
-// Response format:
-//
-//	{
-//	    "error": "Translated error message",
-//	    "code": "error.key",
-//	    "details": {...}
-//	}
-func ErrorResponseWithDetails(c *gin.Context, status int, key string, details interface{}) {
-	c.JSON(status, gin.H{
-		"error":   i18n.T(c, key),
-		"code":    key,
-		"details": details,
-	})
-}
-
 // SuccessResponse sends a JSON success response with translated message and data.
 //
 // Response format:
@@ -137,42 +89,3 @@ func MessageOnlyResponseF(c *gin.Context, status int, key string, args ...interf
 		"message": i18n.TF(c, key, args...),
 	})
 }
-
-// =============================================================================
-// Common error responses (shortcuts)
-// =============================================================================
-
-// BadRequest sends a 400 Bad Request response
-func BadRequest(c *gin.Context, key string) {
-	ErrorResponse(c, http.StatusBadRequest, key)
-}
-
-// Unauthorized sends a 401 Unauthorized response
-func Unauthorized(c *gin.Context, key string) {
-	ErrorResponse(c, http.StatusUnauthorized, key)
-}
-
-// Forbidden sends a 403 Forbidden response
-func Forbidden(c *gin.Context, key string) {
-	ErrorResponse(c, http.StatusForbidden, key)
-}
-
-// NotFound sends a 404 Not Found response
-func NotFound(c *gin.Context, key string) {
-	ErrorResponse(c, http.StatusNotFound, key)
-}
-
-// Conflict sends a 409 Conflict response
-func Conflict(c *gin.Context, key string) {
-	ErrorResponse(c, http.StatusConflict, key)
-}
-
-// InternalError sends a 500 Internal Server Error response
-func InternalError(c *gin.Context, key string) {
-	ErrorResponse(c, http.StatusInternalServerError, key)
-}
-
-// ValidationError sends a 400 Bad Request response with validation details
-func ValidationError(c *gin.Context, details interface{}) {
-	ErrorResponseWithDetails(c, http.StatusBadRequest, i18n.MsgErrorBadRequest, details)
-}
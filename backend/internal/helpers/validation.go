@@ -0,0 +1,183 @@
+package helpers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"backend/internal/i18n"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// RegisterValidationFieldNames makes binding errors name fields by their json (or form) tag
+// instead of the Go field name, so field errors match what clients send. Call it once at
+// startup, before any request is bound.
+func RegisterValidationFieldNames() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		for _, tag := range []string{"json", "form"} {
+			if name := strings.Split(field.Tag.Get(tag), ",")[0]; name != "" && name != "-" {
+				return name
+			}
+		}
+		return field.Name
+	})
+}
+
+// ValidationFieldErrors translates a binding error into a field path → localized message map,
+// e.g. {"grant_reason": "grant_reason minimal 5 karakter"}. Nested fields use dotted paths
+// such as "steps[0].step_order". It returns nil when err is not tied to specific fields.
+func ValidationFieldErrors(c *gin.Context, err error) map[string]string {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		fields := make(map[string]string, len(validationErrs))
+		for _, fe := range validationErrs {
+			path := fe.Namespace()
+			// Drop the request struct name that prefixes every namespace
+			if i := strings.Index(path, "."); i >= 0 {
+				path = path[i+1:]
+			}
+			if _, exists := fields[path]; !exists {
+				fields[path] = validationMessage(c, fe)
+			}
+		}
+		return fields
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return map[string]string{typeErr.Field: i18n.TF(c, i18n.MsgValidationInvalidType, typeErr.Field)}
+	}
+
+	return nil
+}
+
+// BindErrorStatus is the status for a binding error: 413 when the body ran past the
+// BodySizeLimit cap mid-read (chunked uploads have no Content-Length to reject up front),
+// 400 otherwise
+func BindErrorStatus(err error) int {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+// BindErrorMessage returns a single localized message for a binding error: the field
+// messages joined together, a generic message for malformed JSON, or err itself otherwise
+func BindErrorMessage(c *gin.Context, err error) string {
+	if BindErrorStatus(err) == http.StatusRequestEntityTooLarge {
+		return "request body too large"
+	}
+	if fields := ValidationFieldErrors(c, err); len(fields) > 0 {
+		paths := make([]string, 0, len(fields))
+		for path := range fields {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+
+		messages := make([]string, len(paths))
+		for i, path := range paths {
+			messages[i] = fields[path]
+		}
+		return strings.Join(messages, "; ")
+	}
+
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return i18n.T(c, i18n.MsgValidationInvalidJSON)
+	}
+
+	return err.Error()
+}
+
+// BindErrorBody is the {"error": "..."} body used by handlers that have not moved to error
+// envelopes, extended with a "fields" map when the error names specific fields
+func BindErrorBody(c *gin.Context, err error) gin.H {
+	body := gin.H{"error": BindErrorMessage(c, err)}
+	if fields := ValidationFieldErrors(c, err); len(fields) > 0 {
+		body["fields"] = fields
+	}
+	return body
+}
+
+// RespondBindError sends a 400 VALIDATION_FAILED envelope for a binding error, with the field
+// messages under details.fields. An over-limit body gets 413 REQUEST_TOO_LARGE instead.
+//
+// Response format:
+//
+//	{
+//	    "error": {
+//	        "code": "VALIDATION_FAILED",
+//	        "message": "Data yang dikirim tidak valid",
+//	        "details": {"fields": {"grant_reason": "grant_reason minimal 5 karakter"}}
+//	    }
+//	}
+func RespondBindError(c *gin.Context, err error) {
+	if status := BindErrorStatus(err); status != http.StatusBadRequest {
+		RespondError(c, status, CodeRequestTooLarge, BindErrorMessage(c, err), nil)
+		return
+	}
+	fields := ValidationFieldErrors(c, err)
+	if len(fields) == 0 {
+		RespondError(c, http.StatusBadRequest, CodeValidationFailed, BindErrorMessage(c, err), nil)
+		return
+	}
+	RespondError(c, http.StatusBadRequest, CodeValidationFailed, i18n.T(c, i18n.MsgValidationFailed), gin.H{"fields": fields})
+}
+
+// validationMessage localizes one failed validation rule, naming the field by its own tag
+func validationMessage(c *gin.Context, fe validator.FieldError) string {
+	field := fe.Field()
+	param := fe.Param()
+
+	switch fe.Tag() {
+	case "required", "required_if", "required_unless", "required_with", "required_without":
+		return i18n.TF(c, i18n.MsgValidationRequired, field)
+	case "email":
+		return i18n.T(c, i18n.MsgValidationInvalidEmail)
+	case "uuid", "uuid4":
+		return i18n.T(c, i18n.MsgValidationInvalidUUID)
+	case "oneof":
+		return i18n.TF(c, i18n.MsgValidationOneOf, field, strings.Join(strings.Fields(param), ", "))
+	case "len":
+		if fe.Kind() == reflect.String {
+			return i18n.TF(c, i18n.MsgValidationExactLength, field, param)
+		}
+	case "min", "gte":
+		return boundMessage(c, fe, i18n.MsgValidationMinLength, i18n.MsgValidationMinItems, i18n.MsgValidationMinValue)
+	case "max", "lte":
+		return boundMessage(c, fe, i18n.MsgValidationMaxLength, i18n.MsgValidationMaxItems, i18n.MsgValidationMaxValue)
+	case "gt":
+		return i18n.TF(c, i18n.MsgValidationGreaterThan, field, param)
+	case "lt":
+		return i18n.TF(c, i18n.MsgValidationLessThan, field, param)
+	}
+
+	return i18n.TF(c, i18n.MsgValidationInvalid, field)
+}
+
+// boundMessage picks the length, item-count or value wording of a min/max rule by field kind
+func boundMessage(c *gin.Context, fe validator.FieldError, lengthKey, itemsKey, valueKey string) string {
+	switch fe.Kind() {
+	case reflect.String:
+		// The length keys take the bound as %d
+		if n, err := strconv.Atoi(fe.Param()); err == nil {
+			return i18n.TF(c, lengthKey, fe.Field(), n)
+		}
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return i18n.TF(c, itemsKey, fe.Field(), fe.Param())
+	}
+	return i18n.TF(c, valueKey, fe.Field(), fe.Param())
+}
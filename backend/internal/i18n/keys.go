@@ -36,6 +36,17 @@ const (
 	MsgValidationInvalidEmail = "validation.invalid_email"
 	MsgValidationInvalidJSON  = "validation.invalid_json"
 	MsgValidationInvalidUUID  = "validation.invalid_uuid"
+	MsgValidationFailed       = "validation.failed"
+	MsgValidationMinValue     = "validation.min_value"
+	MsgValidationMaxValue     = "validation.max_value"
+	MsgValidationMinItems     = "validation.min_items"
+	MsgValidationMaxItems     = "validation.max_items"
+	MsgValidationExactLength  = "validation.exact_length"
+	MsgValidationOneOf        = "validation.one_of"
+	MsgValidationGreaterThan  = "validation.greater_than"
+	MsgValidationLessThan     = "validation.less_than"
+	MsgValidationInvalidType  = "validation.invalid_type"
+	MsgValidationInvalid      = "validation.invalid"
 
 	// ============================================================
 	// CRUD Operation Messages
@@ -110,6 +121,19 @@ const (
 	MsgPositionAssigned   = "position.assigned"
 	MsgPositionRevoked    = "position.revoked"
 
+	// ============================================================
+	// Access Change Messages (format: name, actor, time)
+	// ============================================================
+	MsgAccessChangeRoleAssigned      = "access_change.role_assigned"
+	MsgAccessChangeRoleRevoked       = "access_change.role_revoked"
+	MsgAccessChangePositionAssigned  = "access_change.position_assigned"
+	MsgAccessChangePositionRevoked   = "access_change.position_revoked"
+	MsgAccessChangePermissionGranted = "access_change.permission_granted"
+	MsgAccessChangePermissionDenied  = "access_change.permission_denied"
+	MsgAccessChangePermissionUpdated = "access_change.permission_updated"
+	MsgAccessChangePermissionRevoked = "access_change.permission_revoked"
+	MsgAccessChangeSystemActor       = "access_change.system_actor"
+
 	// ============================================================
 	// Generic Error Messages
 	// ============================================================
@@ -35,6 +35,17 @@ var TranslationsEN = map[string]string{
 	"validation.invalid_email": "Invalid email format",
 	"validation.invalid_json":  "Invalid JSON format",
 	"validation.invalid_uuid":  "Invalid ID format",
+	"validation.failed":        "The submitted data is invalid",
+	"validation.min_value":     "%s must be at least %s",
+	"validation.max_value":     "%s must not exceed %s",
+	"validation.min_items":     "%s must have at least %s items",
+	"validation.max_items":     "%s must not have more than %s items",
+	"validation.exact_length":  "%s must be exactly %s characters",
+	"validation.one_of":        "%s must be one of: %s",
+	"validation.greater_than":  "%s must be greater than %s",
+	"validation.less_than":     "%s must be less than %s",
+	"validation.invalid_type":  "%s has an invalid type",
+	"validation.invalid":       "%s is invalid",
 
 	// ============================================================
 	// CRUD Operation Messages
@@ -109,6 +120,19 @@ var TranslationsEN = map[string]string{
 	"position.assigned":    "Position assigned successfully",
 	"position.revoked":     "Position revoked successfully",
 
+	// ============================================================
+	// Access Change Messages (format: name, actor, time)
+	// ============================================================
+	"access_change.role_assigned":      "Role %s was added by %s on %s",
+	"access_change.role_revoked":       "Role %s was revoked by %s on %s",
+	"access_change.position_assigned":  "Position %s was assigned by %s on %s",
+	"access_change.position_revoked":   "Position %s was revoked by %s on %s",
+	"access_change.permission_granted": "Permission %s was granted by %s on %s",
+	"access_change.permission_denied":  "Permission %s was explicitly denied by %s on %s",
+	"access_change.permission_updated": "Permission %s was changed by %s on %s",
+	"access_change.permission_revoked": "Permission %s was revoked by %s on %s",
+	"access_change.system_actor":       "the system",
+
 	// ============================================================
 	// Generic Error Messages
 	// ============================================================
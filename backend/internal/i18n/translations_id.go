@@ -35,6 +35,17 @@ var TranslationsID = map[string]string{
 	"validation.invalid_email": "Format email tidak valid",
 	"validation.invalid_json":  "Format JSON tidak valid",
 	"validation.invalid_uuid":  "Format ID tidak valid",
+	"validation.failed":        "Data yang dikirim tidak valid",
+	"validation.min_value":     "%s minimal %s",
+	"validation.max_value":     "%s maksimal %s",
+	"validation.min_items":     "%s minimal %s item",
+	"validation.max_items":     "%s maksimal %s item",
+	"validation.exact_length":  "%s harus %s karakter",
+	"validation.one_of":        "%s harus salah satu dari: %s",
+	"validation.greater_than":  "%s harus lebih dari %s",
+	"validation.less_than":     "%s harus kurang dari %s",
+	"validation.invalid_type":  "%s memiliki tipe data yang tidak valid",
+	"validation.invalid":       "%s tidak valid",
 
 	// ============================================================
 	// CRUD Operation Messages
@@ -109,6 +120,19 @@ var TranslationsID = map[string]string{
 	"position.assigned":    "Posisi berhasil diberikan",
 	"position.revoked":     "Posisi berhasil dicabut",
 
+	// ============================================================
+	// Access Change Messages (format: name, actor, time)
+	// ============================================================
+	"access_change.role_assigned":      "Role %s ditambahkan oleh %s pada %s",
+	"access_change.role_revoked":       "Role %s dicabut oleh %s pada %s",
+	"access_change.position_assigned":  "Posisi %s diberikan oleh %s pada %s",
+	"access_change.position_revoked":   "Posisi %s dicabut oleh %s pada %s",
+	"access_change.permission_granted": "Permission %s diberikan oleh %s pada %s",
+	"access_change.permission_denied":  "Permission %s ditolak secara eksplisit oleh %s pada %s",
+	"access_change.permission_updated": "Permission %s diubah oleh %s pada %s",
+	"access_change.permission_revoked": "Permission %s dicabut oleh %s pada %s",
+	"access_change.system_actor":       "sistem",
+
 	// ============================================================
 	// Generic Error Messages
 	// ============================================================
@@ -0,0 +1,57 @@
+// Package logger configures structured JSON logging and carries request-scoped loggers.
+package logger
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// requestIDKey is the context key for the current request ID
+type requestIDKey struct{}
+
+// Init installs a JSON logger writing to stdout at the given level
+// ("debug", "info", "warn" or "error"; anything else means info).
+// Output from the standard log package is routed through it as well.
+func Init(level string) {
+	handler := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: parseLevel(level)})
+	slog.SetDefault(slog.New(handler))
+	log.SetFlags(0)
+}
+
+// parseLevel converts a level name to a slog level
+func parseLevel(level string) slog.Level {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// WithRequestID returns a copy of ctx carrying the request ID
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestID returns the request ID stored in ctx, or an empty string
+func RequestID(ctx context.Context) string {
+	if requestID, ok := ctx.Value(requestIDKey{}).(string); ok {
+		return requestID
+	}
+	return ""
+}
+
+// FromContext returns the default logger, tagged with the request ID when ctx has one
+func FromContext(ctx context.Context) *slog.Logger {
+	if requestID := RequestID(ctx); requestID != "" {
+		return slog.Default().With("request_id", requestID)
+	}
+	return slog.Default()
+}
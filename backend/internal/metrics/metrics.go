@@ -0,0 +1,184 @@
+// Package metrics collects service metrics and renders them in the Prometheus
+// text exposition format (version 0.0.4).
+package metrics
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ContentType is the content type of the Prometheus text format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// requestDurationBuckets are the upper bounds (seconds) of the request latency histogram
+var requestDurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// histogram is a cumulative latency histogram for one label set
+type histogram struct {
+	counts []uint64 // per bucket, non-cumulative
+	count  uint64
+	sum    float64
+}
+
+var (
+	mu               sync.Mutex
+	requestCounts    = make(map[string]uint64)     // key: method, route, status
+	requestDurations = make(map[string]*histogram) // key: method, route
+	loginCounts      = make(map[string]uint64)     // key: result, reason
+)
+
+// CacheCounter reports permission cache hits and misses
+type CacheCounter interface {
+	CacheCounters() (hits, misses uint64)
+}
+
+// ObserveRequest records a handled HTTP request. route is the route template
+// (e.g. /api/v1/users/:id), not the raw path, to keep label cardinality bounded.
+func ObserveRequest(method, route string, status int, duration time.Duration) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	requestCounts[labels("method", method, "route", route, "status", strconv.Itoa(status))]++
+
+	key := labels("method", method, "route", route)
+	h, ok := requestDurations[key]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(requestDurationBuckets))}
+		requestDurations[key] = h
+	}
+	seconds := duration.Seconds()
+	for i, bound := range requestDurationBuckets {
+		if seconds <= bound {
+			h.counts[i]++
+			break
+		}
+	}
+	h.count++
+	h.sum += seconds
+}
+
+// RecordLogin records a login attempt and, for failures, the reason
+func RecordLogin(success bool, reason string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if success {
+		loginCounts[labels("result", "success", "reason", "")]++
+		return
+	}
+	loginCounts[labels("result", "failure", "reason", reason)]++
+}
+
+// Write renders all metrics to w. db and cache may be nil.
+func Write(w io.Writer, db *sql.DB, cache CacheCounter) error {
+	var b strings.Builder
+
+	mu.Lock()
+	writeHeader(&b, "http_requests_total", "counter", "Total HTTP requests by method, route and status.")
+	for _, key := range sortedKeys(requestCounts) {
+		fmt.Fprintf(&b, "http_requests_total{%s} %d\n", key, requestCounts[key])
+	}
+
+	writeHeader(&b, "http_request_duration_seconds", "histogram", "HTTP request latency by method and route.")
+	for _, key := range sortedHistogramKeys(requestDurations) {
+		h := requestDurations[key]
+		var cumulative uint64
+		for i, bound := range requestDurationBuckets {
+			cumulative += h.counts[i]
+			fmt.Fprintf(&b, "http_request_duration_seconds_bucket{%s,le=\"%s\"} %d\n", key, formatFloat(bound), cumulative)
+		}
+		fmt.Fprintf(&b, "http_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", key, h.count)
+		fmt.Fprintf(&b, "http_request_duration_seconds_sum{%s} %s\n", key, formatFloat(h.sum))
+		fmt.Fprintf(&b, "http_request_duration_seconds_count{%s} %d\n", key, h.count)
+	}
+
+	writeHeader(&b, "auth_login_attempts_total", "counter", "Login attempts by result and failure reason.")
+	for _, key := range sortedKeys(loginCounts) {
+		fmt.Fprintf(&b, "auth_login_attempts_total{%s} %d\n", key, loginCounts[key])
+	}
+	mu.Unlock()
+
+	if cache != nil {
+		hits, misses := cache.CacheCounters()
+		writeHeader(&b, "permission_cache_hits_total", "counter", "Permission checks answered from the cache.")
+		fmt.Fprintf(&b, "permission_cache_hits_total %d\n", hits)
+		writeHeader(&b, "permission_cache_misses_total", "counter", "Permission checks resolved from the database.")
+		fmt.Fprintf(&b, "permission_cache_misses_total %d\n", misses)
+	}
+
+	if db != nil {
+		stats := db.Stats()
+		writeGauge(&b, "db_pool_max_open_connections", "Maximum number of open connections to the database.", float64(stats.MaxOpenConnections))
+		writeGauge(&b, "db_pool_open_connections", "Number of established connections, in use and idle.", float64(stats.OpenConnections))
+		writeGauge(&b, "db_pool_in_use_connections", "Number of connections currently in use.", float64(stats.InUse))
+		writeGauge(&b, "db_pool_idle_connections", "Number of idle connections.", float64(stats.Idle))
+		writeCounter(&b, "db_pool_wait_count_total", "Total number of connections waited for.", float64(stats.WaitCount))
+		writeCounter(&b, "db_pool_wait_duration_seconds_total", "Total time blocked waiting for a new connection.", stats.WaitDuration.Seconds())
+		writeCounter(&b, "db_pool_max_idle_closed_total", "Connections closed due to SetMaxIdleConns.", float64(stats.MaxIdleClosed))
+		writeCounter(&b, "db_pool_max_lifetime_closed_total", "Connections closed due to SetConnMaxLifetime.", float64(stats.MaxLifetimeClosed))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// labels renders name/value pairs as a Prometheus label list
+func labels(pairs ...string) string {
+	parts := make([]string, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		parts = append(parts, fmt.Sprintf("%s=%q", pairs[i], escapeLabelValue(pairs[i+1])))
+	}
+	return strings.Join(parts, ",")
+}
+
+// escapeLabelValue drops characters that %q would escape differently from Prometheus
+func escapeLabelValue(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r > 0x7e {
+			return -1
+		}
+		return r
+	}, value)
+}
+
+func writeHeader(b *strings.Builder, name, metricType, help string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+}
+
+func writeGauge(b *strings.Builder, name, help string, value float64) {
+	writeHeader(b, name, "gauge", help)
+	fmt.Fprintf(b, "%s %s\n", name, formatFloat(value))
+}
+
+func writeCounter(b *strings.Builder, name, help string, value float64) {
+	writeHeader(b, name, "counter", help)
+	fmt.Fprintf(b, "%s %s\n", name, formatFloat(value))
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+func sortedKeys(m map[string]uint64) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedHistogramKeys(m map[string]*histogram) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
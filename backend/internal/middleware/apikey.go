@@ -1,10 +1,10 @@
 package middleware
 
 import (
-	"log"
 	"strings"
 
 	"backend/internal/database"
+	"backend/internal/logger"
 	"backend/internal/services"
 
 	"github.com/gin-gonic/gin"
@@ -70,8 +70,8 @@ func ApiKeyAuth() gin.HandlerFunc {
 		clientIP := c.ClientIP()
 		if len(key.AllowedIPs) > 0 {
 			if !apiKeyService.IsIPAllowed(clientIP, key.AllowedIPs) {
-				log.Printf("[API_KEY_AUTH] IP not allowed: key=%s ip=%s allowed=%v",
-					key.DisplayKey(), clientIP, key.AllowedIPs)
+				logger.FromContext(c.Request.Context()).Warn("api key auth: ip not allowed",
+					"key", key.DisplayKey(), "ip", clientIP, "allowed_ips", key.AllowedIPs)
 				c.JSON(403, gin.H{
 					"error":   "IP not allowed",
 					"message": "Your IP address is not in the allowed list for this API key",
@@ -82,15 +82,16 @@ func ApiKeyAuth() gin.HandlerFunc {
 		}
 
 		// Update usage statistics asynchronously (don't block the request)
+		reqLog := logger.FromContext(c.Request.Context())
 		go func() {
 			if err := apiKeyService.UpdateApiKeyUsage(key.ID, clientIP); err != nil {
-				log.Printf("[API_KEY_AUTH] Failed to update usage stats: %v", err)
+				reqLog.Warn("api key auth: failed to update usage stats", "error", err)
 			}
 		}()
 
 		// Log successful authentication
-		log.Printf("[API_KEY_AUTH] Authenticated: key=%s user_id=%s ip=%s",
-			key.DisplayKey(), key.UserID, clientIP)
+		logger.FromContext(c.Request.Context()).Info("api key auth: authenticated",
+			"key", key.DisplayKey(), "user_id", key.UserID, "ip", clientIP)
 
 		// Set context values for downstream handlers
 		c.Set("api_key_id", key.ID)
@@ -191,9 +192,10 @@ func ApiKeyOptional() gin.HandlerFunc {
 		}
 
 		// Update usage statistics asynchronously
+		reqLog := logger.FromContext(c.Request.Context())
 		go func() {
 			if err := apiKeyService.UpdateApiKeyUsage(key.ID, clientIP); err != nil {
-				log.Printf("[API_KEY_AUTH] Failed to update usage stats: %v", err)
+				reqLog.Warn("api key auth: failed to update usage stats", "error", err)
 			}
 		}()
 
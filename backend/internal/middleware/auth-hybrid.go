@@ -45,8 +45,8 @@ func AuthRequiredHybrid() gin.HandlerFunc {
 			return
 		}
 
-		// Verify user exists and is active
-		db := database.GetDB()
+		// Verify user exists and is active, on the primary so a new or just deactivated account counts at once
+		db := database.Primary(database.GetDB())
 		var user models.User
 		if err := db.First(&user, "id = ?", claims.UserID).Error; err != nil {
 			c.JSON(401, gin.H{"error": "user not found"})
@@ -60,9 +60,22 @@ func AuthRequiredHybrid() gin.HandlerFunc {
 			return
 		}
 
+		// Impersonation tokens stop working as soon as the impersonator is deactivated
+		if claims.ImpersonatorID != "" {
+			var impersonator models.User
+			if err := db.First(&impersonator, "id = ?", claims.ImpersonatorID).Error; err != nil || !impersonator.IsActive {
+				c.JSON(401, gin.H{"error": "impersonator is no longer active"})
+				c.Abort()
+				return
+			}
+		}
+
 		// Set user context
 		c.Set("user_id", claims.UserID)
 		c.Set("user_email", claims.Email)
+		if claims.ImpersonatorID != "" {
+			c.Set("impersonator_id", claims.ImpersonatorID)
+		}
 
 		c.Next()
 	}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"net/url"
+	"strings"
+)
+
+// SplitOriginPatterns separates exact origins from wildcard-subdomain patterns
+// (entries containing "*", e.g. https://*.preview.example.com)
+func SplitOriginPatterns(origins []string) (exact, patterns []string) {
+	for _, origin := range origins {
+		if strings.Contains(origin, "*") {
+			patterns = append(patterns, origin)
+		} else {
+			exact = append(exact, origin)
+		}
+	}
+	return exact, patterns
+}
+
+// WildcardOriginMatcher returns a CORS origin matcher for wildcard-subdomain patterns.
+// A pattern of the form scheme://*.domain[:port] matches any origin with the same
+// scheme and port whose host is a subdomain of domain (at any depth), but not domain itself.
+func WildcardOriginMatcher(patterns []string) func(origin string) bool {
+	type rule struct {
+		scheme string
+		suffix string // ".domain[:port]"
+	}
+
+	var rules []rule
+	for _, pattern := range patterns {
+		scheme, host, ok := strings.Cut(pattern, "://")
+		if !ok || !strings.HasPrefix(host, "*.") || strings.Count(host, "*") != 1 {
+			continue
+		}
+		rules = append(rules, rule{scheme: strings.ToLower(scheme), suffix: strings.ToLower(host[1:])})
+	}
+
+	return func(origin string) bool {
+		parsed, err := url.Parse(origin)
+		if err != nil || parsed.Host == "" || parsed.User != nil || (parsed.Path != "" && parsed.Path != "/") {
+			return false
+		}
+		host := strings.ToLower(parsed.Host)
+		for _, r := range rules {
+			if strings.ToLower(parsed.Scheme) == r.scheme && len(host) > len(r.suffix) && strings.HasSuffix(host, r.suffix) {
+				return true
+			}
+		}
+		return false
+	}
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"net/http"
+
+	"backend/internal/models"
+	"backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// dataScopeKey is the context key ResolveDataScope stores the caller's scope under
+const dataScopeKey = "data_scope"
+
+// ResolveDataScope looks up the broadest scope at which the caller holds resource:action and
+// stores it in the context for list handlers to apply with DataScopeFromContext.
+// It only narrows what is returned; pair it with RequirePermission to guard access.
+// Usage: router.GET("/employees", RequirePermission("employees", models.PermissionActionRead), ResolveDataScope("employees", models.PermissionActionRead), handler)
+func ResolveDataScope(resource string, action models.PermissionAction) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if permissionCache == nil {
+			InitPermissionServices()
+		}
+
+		userID := c.GetString("user_id")
+		if userID == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error":   "unauthorized",
+				"message": "user not authenticated",
+			})
+			c.Abort()
+			return
+		}
+
+		scope, err := services.HighestDataScope(permissionCache.ScopeChecker(c.Request.Context()), userID, resource, action)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "permission_check_failed",
+				"message": "failed to check permission",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Set(dataScopeKey, &services.DataScope{UserID: userID, Scope: scope})
+		c.Next()
+	}
+}
+
+// DataScopeFromContext returns the scope stored by ResolveDataScope, or nil when the route
+// does not resolve one (e.g. API key routes), meaning the list is not restricted
+func DataScopeFromContext(c *gin.Context) *services.DataScope {
+	if value, exists := c.Get(dataScopeKey); exists {
+		if scope, ok := value.(*services.DataScope); ok {
+			return scope
+		}
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IdempotencyKeyHeader is the request header clients set to make a mutation safe to retry
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// maxIdempotencyKeyLength bounds client-supplied keys (UUIDs are 36 characters)
+const maxIdempotencyKeyLength = 255
+
+// replayedHeaders are the response headers restored on a replay; per-request headers
+// such as X-Request-ID or cookies belong to the retry, not the original
+var replayedHeaders = []string{"Content-Type", "Location"}
+
+// idempotentResponse is the first response recorded for an idempotency key.
+// done is closed once the original request has finished.
+type idempotentResponse struct {
+	fingerprint [sha256.Size]byte
+	done        chan struct{}
+	status      int
+	header      http.Header
+	body        []byte
+	expiresAt   time.Time
+}
+
+// IdempotencyStore keeps the first response per (user, method, path, key) in memory
+// for a limited window. It is per process: behind several instances a retry that
+// lands on another instance executes again.
+type IdempotencyStore struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	entries   map[string]*idempotentResponse
+	lastSweep time.Time
+}
+
+// NewIdempotencyStore creates a store that forgets keys ttl after their first use
+func NewIdempotencyStore(ttl time.Duration) *IdempotencyStore {
+	return &IdempotencyStore{
+		ttl:     ttl,
+		entries: make(map[string]*idempotentResponse),
+	}
+}
+
+// begin returns the existing entry for key, or registers a new in-progress entry and
+// returns it with created set. The caller must finish or discard a created entry.
+func (s *IdempotencyStore) begin(key string, fingerprint [sha256.Size]byte) (entry *idempotentResponse, created bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweepLocked(now)
+
+	if existing, ok := s.entries[key]; ok && now.Before(existing.expiresAt) {
+		return existing, false
+	}
+
+	entry = &idempotentResponse{
+		fingerprint: fingerprint,
+		done:        make(chan struct{}),
+		expiresAt:   now.Add(s.ttl),
+	}
+	s.entries[key] = entry
+	return entry, true
+}
+
+// finish stores the response of a completed request and releases waiting retries
+func (s *IdempotencyStore) finish(entry *idempotentResponse, status int, header http.Header, body []byte) {
+	s.mu.Lock()
+	entry.status, entry.header, entry.body = status, header, body
+	s.mu.Unlock()
+	close(entry.done)
+}
+
+// discard forgets an entry whose request failed server-side, so a retry executes again
+func (s *IdempotencyStore) discard(key string, entry *idempotentResponse) {
+	s.mu.Lock()
+	if s.entries[key] == entry {
+		delete(s.entries, key)
+	}
+	s.mu.Unlock()
+	close(entry.done)
+}
+
+// sweepLocked drops expired entries at most once a minute. The caller must hold s.mu.
+func (s *IdempotencyStore) sweepLocked(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+	for key, entry := range s.entries {
+		if now.After(entry.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+}
+
+// recordingWriter copies the response body while passing it through to the client
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotent makes a mutating route safe to retry. When the request carries an
+// Idempotency-Key header, the first response for that key (per user, method and path)
+// is stored and replayed to retries instead of running the handler again; replays carry
+// "Idempotent-Replayed: true". Reusing a key with a different body is rejected with 422,
+// and a retry arriving while the original is still running gets 409. Server errors
+// (5xx) are not stored, so the client can retry them. Requests without the header are
+// unaffected. Register after authentication and permission checks.
+func Idempotent(store *IdempotencyStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		idempotencyKey := c.GetHeader(IdempotencyKeyHeader)
+		if idempotencyKey == "" {
+			c.Next()
+			return
+		}
+		if len(idempotencyKey) > maxIdempotencyKeyLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key is too long"})
+			c.Abort()
+			return
+		}
+
+		// Fingerprint the body so a reused key with a different payload is caught
+		var body []byte
+		if c.Request.Body != nil {
+			var err error
+			body, err = io.ReadAll(c.Request.Body)
+			if err != nil {
+				c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "request body too large"})
+				c.Abort()
+				return
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		fingerprint := sha256.Sum256(body)
+
+		key := c.GetString("user_id") + "\x00" + c.Request.Method + "\x00" + c.Request.URL.Path + "\x00" + idempotencyKey
+		entry, created := store.begin(key, fingerprint)
+
+		if !created {
+			if entry.fingerprint != fingerprint {
+				c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used with a different request body"})
+				c.Abort()
+				return
+			}
+			select {
+			case <-entry.done:
+			default:
+				c.JSON(http.StatusConflict, gin.H{"error": "a request with this Idempotency-Key is still in progress"})
+				c.Abort()
+				return
+			}
+
+			store.mu.Lock()
+			status, header, replay := entry.status, entry.header, entry.body
+			store.mu.Unlock()
+			if status == 0 {
+				// The original failed and was discarded between begin and now
+				c.JSON(http.StatusConflict, gin.H{"error": "the original request with this Idempotency-Key failed, retry it"})
+				c.Abort()
+				return
+			}
+
+			for _, name := range replayedHeaders {
+				if value := header.Get(name); value != "" {
+					c.Header(name, value)
+				}
+			}
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(status, header.Get("Content-Type"), replay)
+			c.Abort()
+			return
+		}
+
+		writer := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		completed := false
+		defer func() {
+			// A panicking handler leaves nothing to replay
+			if !completed {
+				store.discard(key, entry)
+			}
+		}()
+
+		c.Next()
+		completed = true
+
+		if status := writer.Status(); status >= http.StatusInternalServerError {
+			store.discard(key, entry)
+		} else {
+			store.finish(entry, status, writer.Header().Clone(), writer.body.Bytes())
+		}
+	}
+}
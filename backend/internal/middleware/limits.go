@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RouteLimits maps registered route path prefixes (as returned by c.FullPath(), e.g.
+// "/api/v1/users/import" or "/api/v1/auth/") to a limit that replaces the default.
+// The longest matching prefix wins, so a whole group and a single route inside it
+// can be configured independently.
+type RouteLimits[T any] map[string]T
+
+// lookup returns the limit for path, falling back to def when no prefix matches
+func (r RouteLimits[T]) lookup(path string, def T) T {
+	limit, matched := def, -1
+	for prefix, value := range r {
+		if len(prefix) > matched && strings.HasPrefix(path, prefix) {
+			limit, matched = value, len(prefix)
+		}
+	}
+	return limit
+}
+
+// BodySizeLimit rejects request bodies larger than maxBytes with 413. Requests that
+// announce a larger Content-Length are refused before the handler runs; chunked bodies
+// are wrapped in http.MaxBytesReader so reading past the limit fails. A limit of 0
+// disables the cap for a route.
+func BodySizeLimit(maxBytes int64, overrides RouteLimits[int64]) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := overrides.lookup(c.FullPath(), maxBytes)
+		if limit <= 0 || c.Request.Body == nil {
+			c.Next()
+			return
+		}
+
+		if c.Request.ContentLength > limit {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "request body too large"})
+			c.Abort()
+			return
+		}
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		c.Next()
+	}
+}
+
+// RequestTimeout puts a deadline on the request context so work that honours it
+// (outgoing calls, context-aware queries) is cancelled. When the deadline passes
+// before the handler wrote a response, the client gets 408. A timeout of 0 disables
+// the deadline for a route, which streaming endpoints (SSE, exports) need.
+func RequestTimeout(timeout time.Duration, overrides RouteLimits[time.Duration]) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := overrides.lookup(c.FullPath(), timeout)
+		if limit <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), limit)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && !c.Writer.Written() {
+			c.JSON(http.StatusRequestTimeout, gin.H{"error": "request timed out"})
+			c.Abort()
+		}
+	}
+}
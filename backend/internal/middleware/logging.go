@@ -0,0 +1,200 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+
+	"backend/internal/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader is the header used to pass and echo the request ID
+const RequestIDHeader = "X-Request-ID"
+
+// maxLoggedBodyBytes caps how much of a request or response body is captured for logging;
+// larger bodies are left out of the log entirely rather than logged as cut-off JSON
+const maxLoggedBodyBytes = 4096
+
+// redactedFields are lowercase substrings of JSON keys whose values are never logged
+var redactedFields = []string{"password", "token", "secret", "api_key", "apikey", "authorization"}
+
+// RequestID assigns every request a UUID, stores it in the request context and the
+// gin context ("request_id"), and echoes it in the X-Request-ID response header.
+// An incoming X-Request-ID is kept when it is a valid UUID so ids survive proxies.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if _, err := uuid.Parse(requestID); err != nil {
+			requestID = uuid.New().String()
+		}
+
+		c.Set("request_id", requestID)
+		c.Request = c.Request.WithContext(logger.WithRequestID(c.Request.Context(), requestID))
+		c.Header(RequestIDHeader, requestID)
+
+		c.Next()
+	}
+}
+
+// RequestLogger writes one structured log line per request, tagged with its request ID and,
+// once authentication has run, the user ID. With logBodies set, JSON request and response
+// bodies up to maxLoggedBodyBytes are logged with passwords, tokens and secrets redacted.
+// It must run after RequestID.
+func RequestLogger(logBodies bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		var requestBody []byte
+		var responseBody *bodyLogWriter
+		if logBodies {
+			requestBody = captureRequestBody(c)
+			responseBody = &bodyLogWriter{ResponseWriter: c.Writer}
+			c.Writer = responseBody
+		}
+
+		c.Next()
+
+		status := c.Writer.Status()
+		attrs := []any{
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"route", c.FullPath(),
+			"status", status,
+			"duration_ms", time.Since(start).Milliseconds(),
+			"client_ip", c.ClientIP(),
+		}
+		if userID := c.GetString("user_id"); userID != "" {
+			attrs = append(attrs, "user_id", userID)
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, "errors", c.Errors.String())
+		}
+		if logBodies {
+			if body, ok := redactJSONBody(requestBody); ok {
+				attrs = append(attrs, "request_body", body)
+			}
+			if isJSONContentType(c.Writer.Header().Get("Content-Type")) && !responseBody.truncated {
+				if body, ok := redactJSONBody(responseBody.body.Bytes()); ok {
+					attrs = append(attrs, "response_body", body)
+				}
+			}
+		}
+
+		log := logger.FromContext(c.Request.Context())
+		switch {
+		case status >= 500:
+			log.Error("request", attrs...)
+		case status >= 400:
+			log.Warn("request", attrs...)
+		default:
+			log.Info("request", attrs...)
+		}
+	}
+}
+
+// captureRequestBody reads up to maxLoggedBodyBytes of a JSON request body and puts the
+// bytes back so handlers still see the full body. It returns nil for other content types
+// and for bodies over the cap.
+func captureRequestBody(c *gin.Context) []byte {
+	if c.Request.Body == nil || !isJSONContentType(c.ContentType()) {
+		return nil
+	}
+
+	captured, err := io.ReadAll(io.LimitReader(c.Request.Body, maxLoggedBodyBytes+1))
+	c.Request.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(captured), c.Request.Body), c.Request.Body}
+	if err != nil || len(captured) > maxLoggedBodyBytes {
+		return nil
+	}
+	return captured
+}
+
+// bodyLogWriter passes writes through while keeping a copy of the first maxLoggedBodyBytes
+type bodyLogWriter struct {
+	gin.ResponseWriter
+	body      bytes.Buffer
+	truncated bool
+}
+
+func (w *bodyLogWriter) Write(data []byte) (int, error) {
+	w.capture(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *bodyLogWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *bodyLogWriter) capture(data []byte) {
+	if w.truncated {
+		return
+	}
+	if w.body.Len()+len(data) > maxLoggedBodyBytes {
+		w.truncated = true
+		w.body.Reset()
+		return
+	}
+	w.body.Write(data)
+}
+
+// isJSONContentType reports whether a Content-Type header value denotes JSON
+func isJSONContentType(contentType string) bool {
+	return strings.Contains(strings.ToLower(contentType), "json")
+}
+
+// redactJSONBody returns body re-encoded with sensitive fields replaced, or false when
+// body is empty or not valid JSON
+func redactJSONBody(body []byte) (string, bool) {
+	if len(body) == 0 {
+		return "", false
+	}
+
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		return "", false
+	}
+
+	redacted, err := json.Marshal(redactValue(value))
+	if err != nil {
+		return "", false
+	}
+	return string(redacted), true
+}
+
+// redactValue walks decoded JSON and masks the values of sensitive keys at any depth
+func redactValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			if isRedactedField(key) {
+				v[key] = "[REDACTED]"
+			} else {
+				v[key] = redactValue(field)
+			}
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = redactValue(item)
+		}
+	}
+	return value
+}
+
+// isRedactedField reports whether a JSON key names a value that must not be logged
+func isRedactedField(key string) bool {
+	key = strings.ToLower(key)
+	for _, field := range redactedFields {
+		if strings.Contains(key, field) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultMaintenanceMessage is shown when maintenance.message is empty
+const defaultMaintenanceMessage = "Sistem sedang dalam pemeliharaan. Silakan coba lagi nanti."
+
+// MaintenanceMode answers every request with 503 while the maintenance.enabled setting is on,
+// except for callers holding the bypass permission, so administrators can keep working and
+// turn it off again. The body carries the configured message and estimated end; when that end
+// lies in the future a Retry-After header is set as well. Register after authentication; routes
+// outside the group (health checks, login) are not affected.
+func MaintenanceMode(settings *services.SettingsService, bypass PermissionCheck) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !settings.GetBool(services.SettingMaintenanceEnabled) {
+			c.Next()
+			return
+		}
+
+		if userID := c.GetString("user_id"); userID != "" {
+			if permissionCache == nil {
+				InitPermissionServices()
+			}
+			result, err := permissionCache.CheckPermission(c.Request.Context(), userID, services.PermissionCheckRequest{
+				Resource: bypass.Resource,
+				Action:   bypass.Action,
+				Scope:    bypass.Scope,
+			})
+			if err == nil && result.Allowed {
+				c.Next()
+				return
+			}
+		}
+
+		message := settings.GetString(services.SettingMaintenanceMessage)
+		if message == "" {
+			message = defaultMaintenanceMessage
+		}
+
+		var estimatedEnd *time.Time
+		if end, err := time.Parse(time.RFC3339, settings.GetString(services.SettingMaintenanceEstimatedEnd)); err == nil {
+			estimatedEnd = &end
+			if wait := time.Until(end); wait > 0 {
+				c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			}
+		}
+
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error":         "maintenance",
+			"message":       message,
+			"estimated_end": estimatedEnd,
+		})
+		c.Abort()
+	}
+}
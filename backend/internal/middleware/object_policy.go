@@ -0,0 +1,131 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"backend/internal/database"
+	"backend/internal/helpers"
+	"backend/internal/models"
+	"backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Not found vs forbidden policy for object endpoints (routes with an :id):
+//
+//   - A caller who may not read the object gets the same 404 a missing id gets, never a 403,
+//     so existing ids cannot be told apart from missing ones. This applies to every action on
+//     the object, not only reads.
+//   - "May not read" means holding no resource:read grant at all, or, for resources with
+//     row-level scopes such as users, the object lying outside the caller's read scope.
+//   - Once the object is readable, the route's own permission check runs and may still answer
+//     403 for the action itself; that reveals nothing the caller could not already read.
+//
+// Collection endpoints (list, create, lookups) keep answering 403, since they name no object.
+
+// ObjectVisibility reports whether the object id is visible to a caller holding read access
+// at the given scope
+type ObjectVisibility func(scope *services.DataScope, id string) (bool, error)
+
+// HideUnreadableObject enforces the policy above for one resource. notFound must write exactly
+// the response the handler sends for a missing object, so the two cannot be told apart.
+// visible may be nil for resources without row-level scopes.
+// Usage: roles.PUT("/:id", HideUnreadableObject("roles", "id", nil, RoleNotFound), RequirePermission("roles", models.PermissionActionUpdate), handler)
+func HideUnreadableObject(resource, idParam string, visible ObjectVisibility, notFound gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if permissionCache == nil {
+			InitPermissionServices()
+		}
+
+		userID := c.GetString("user_id")
+		if userID == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error":   "unauthorized",
+				"message": "user not authenticated",
+			})
+			c.Abort()
+			return
+		}
+
+		// A grant at any scope satisfies an unscoped check
+		result, err := permissionCache.CheckPermission(c.Request.Context(), userID, services.PermissionCheckRequest{
+			Resource: resource,
+			Action:   models.PermissionActionRead,
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "permission_check_failed",
+				"message": "failed to check permission",
+			})
+			c.Abort()
+			return
+		}
+		if !result.Allowed {
+			notFound(c)
+			c.Abort()
+			return
+		}
+
+		if visible != nil {
+			ok, err := objectVisible(c.Request.Context(), userID, resource, c.Param(idParam), visible)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error":   "permission_check_failed",
+					"message": "failed to check permission",
+				})
+				c.Abort()
+				return
+			}
+			if !ok {
+				notFound(c)
+				c.Abort()
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// objectVisible resolves the caller's read scope on resource and checks id against it
+func objectVisible(ctx context.Context, userID, resource, id string, visible ObjectVisibility) (bool, error) {
+	scope, err := services.HighestDataScope(permissionCache.ScopeChecker(ctx), userID, resource, models.PermissionActionRead)
+	if err != nil {
+		return false, err
+	}
+	return visible(&services.DataScope{UserID: userID, Scope: scope}, id)
+}
+
+// UserVisibleInScope is the ObjectVisibility of users: a user outside the caller's read scope
+// (own record, department or school) is hidden
+func UserVisibleInScope(scope *services.DataScope, id string) (bool, error) {
+	return scope.CanSeeUser(database.GetDB(), id)
+}
+
+// WorkflowInstanceVisibleInScope is the ObjectVisibility of workflow instances: an instance is
+// hidden unless its initiator lies in the caller's read scope or the caller approves one of its
+// steps, directly or as a delegate
+func WorkflowInstanceVisibleInScope(scope *services.DataScope, id string) (bool, error) {
+	return scope.CanSeeWorkflowInstance(database.GetDB(), id)
+}
+
+// UserNotFound writes the 404 user handlers send for a missing user
+func UserNotFound(c *gin.Context) {
+	helpers.RespondError(c, http.StatusNotFound, helpers.CodeUserNotFound, "pengguna tidak ditemukan", nil)
+}
+
+// RoleNotFound writes the 404 role handlers send for a missing role
+func RoleNotFound(c *gin.Context) {
+	c.JSON(http.StatusNotFound, gin.H{"error": "role tidak ditemukan"})
+}
+
+// WorkflowInstanceNotFound writes the 404 workflow instance handlers send for a missing instance
+func WorkflowInstanceNotFound(c *gin.Context) {
+	c.JSON(http.StatusNotFound, gin.H{"error": "workflow instance tidak ditemukan"})
+}
+
+// PermissionNotFound writes the 404 permission handlers send for a missing permission
+func PermissionNotFound(c *gin.Context) {
+	c.JSON(http.StatusNotFound, gin.H{"error": "permission tidak ditemukan"})
+}
@@ -24,16 +24,29 @@ var (
 	permissionCache      *services.PermissionCacheService
 	escalationPrevention *services.EscalationPreventionService
 	initOnce             sync.Once
+
+	// Cache settings used by InitPermissionServices; see ConfigurePermissionCache
+	permissionCacheConfig = services.DefaultCacheConfig()
 )
 
+// ConfigurePermissionCache overrides the permission cache settings.
+// Must be called before InitPermissionServices to take effect.
+func ConfigurePermissionCache(config services.CacheConfig) {
+	permissionCacheConfig = config
+}
+
 // InitPermissionServices initializes the permission services
 // Should be called once during application startup
 func InitPermissionServices() {
 	initOnce.Do(func() {
 		db := database.GetDB()
+		// Resolution only reads, so it can be served by the read replica when configured
 		permissionResolver = services.NewPermissionResolverService(db)
-		permissionCache = services.NewPermissionCacheService(db, permissionResolver, services.DefaultCacheConfig())
-		escalationPrevention = services.NewEscalationPreventionService(db, permissionResolver)
+		permissionCache = services.NewPermissionCacheService(db, permissionResolver, permissionCacheConfig)
+		// Escalation checks guard grants, so they read the primary: a permission revoked a
+		// moment ago must not still count
+		primary := database.Primary(db)
+		escalationPrevention = services.NewEscalationPreventionService(primary, services.NewPermissionResolverService(primary))
 	})
 }
 
@@ -79,7 +92,7 @@ func RequirePermission(resource string, action models.PermissionAction) gin.Hand
 			return
 		}
 
-		result, err := permissionCache.CheckPermission(userID.(string), services.PermissionCheckRequest{
+		result, err := permissionCache.CheckPermission(c.Request.Context(), userID.(string), services.PermissionCheckRequest{
 			Resource: resource,
 			Action:   action,
 		})
@@ -131,7 +144,7 @@ func RequirePermissionWithScope(resource string, action models.PermissionAction,
 			return
 		}
 
-		result, err := permissionCache.CheckPermission(userID.(string), services.PermissionCheckRequest{
+		result, err := permissionCache.CheckPermission(c.Request.Context(), userID.(string), services.PermissionCheckRequest{
 			Resource: resource,
 			Action:   action,
 			Scope:    &scope,
@@ -191,7 +204,7 @@ func RequireAnyPermission(permissions ...PermissionCheck) gin.HandlerFunc {
 
 		// Check if user has ANY of the permissions
 		for _, perm := range permissions {
-			result, err := permissionCache.CheckPermission(userID.(string), services.PermissionCheckRequest{
+			result, err := permissionCache.CheckPermission(c.Request.Context(), userID.(string), services.PermissionCheckRequest{
 				Resource: perm.Resource,
 				Action:   perm.Action,
 				Scope:    perm.Scope,
@@ -257,7 +270,7 @@ func RequireAllPermissions(permissions ...PermissionCheck) gin.HandlerFunc {
 		// Check if user has ALL permissions
 		var missingPermissions []gin.H
 		for _, perm := range permissions {
-			result, err := permissionCache.CheckPermission(userID.(string), services.PermissionCheckRequest{
+			result, err := permissionCache.CheckPermission(c.Request.Context(), userID.(string), services.PermissionCheckRequest{
 				Resource: perm.Resource,
 				Action:   perm.Action,
 				Scope:    perm.Scope,
@@ -307,7 +320,7 @@ func RequireModuleAccess(moduleCode string) gin.HandlerFunc {
 		}
 
 		// Check if user has READ access to the module
-		result, err := permissionCache.CheckPermission(userID.(string), services.PermissionCheckRequest{
+		result, err := permissionCache.CheckPermission(c.Request.Context(), userID.(string), services.PermissionCheckRequest{
 			Resource: moduleCode,
 			Action:   models.PermissionActionRead,
 		})
@@ -368,7 +381,7 @@ func DynamicPermissionCheck(checkFunc func(*gin.Context) PermissionCheck) gin.Ha
 		// Get the permission check from the provided function
 		perm := checkFunc(c)
 
-		result, err := permissionCache.CheckPermission(userID.(string), services.PermissionCheckRequest{
+		result, err := permissionCache.CheckPermission(c.Request.Context(), userID.(string), services.PermissionCheckRequest{
 			Resource: perm.Resource,
 			Action:   perm.Action,
 			Scope:    perm.Scope,
@@ -425,7 +438,7 @@ func ResourceOwnerOrPermission(userIDParam, resource string, action models.Permi
 		// Check if user is accessing their own resource
 		if userID.(string) == targetUserID {
 			// Check for OWN scope permission
-			result, err := permissionCache.CheckPermission(userID.(string), services.PermissionCheckRequest{
+			result, err := permissionCache.CheckPermission(c.Request.Context(), userID.(string), services.PermissionCheckRequest{
 				Resource: resource,
 				Action:   action,
 				Scope:    ptrScope(models.PermissionScopeOwn),
@@ -439,7 +452,7 @@ func ResourceOwnerOrPermission(userIDParam, resource string, action models.Permi
 		}
 
 		// Not own resource, check for broader permission
-		result, err := permissionCache.CheckPermission(userID.(string), services.PermissionCheckRequest{
+		result, err := permissionCache.CheckPermission(c.Request.Context(), userID.(string), services.PermissionCheckRequest{
 			Resource: resource,
 			Action:   action,
 			Scope:    &requiredScope,
@@ -491,7 +504,7 @@ func CheckPermissionInHandler(c *gin.Context, resource string, action models.Per
 		return false, fmt.Errorf("user not authenticated")
 	}
 
-	result, err := permissionCache.CheckPermission(userID.(string), services.PermissionCheckRequest{
+	result, err := permissionCache.CheckPermission(c.Request.Context(), userID.(string), services.PermissionCheckRequest{
 		Resource: resource,
 		Action:   action,
 	})
@@ -513,7 +526,7 @@ func CheckPermissionWithScopeInHandler(c *gin.Context, resource string, action m
 		return false, fmt.Errorf("user not authenticated")
 	}
 
-	result, err := permissionCache.CheckPermission(userID.(string), services.PermissionCheckRequest{
+	result, err := permissionCache.CheckPermission(c.Request.Context(), userID.(string), services.PermissionCheckRequest{
 		Resource: resource,
 		Action:   action,
 		Scope:    &scope,
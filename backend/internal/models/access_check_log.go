@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// AccessCheckLog is an append-only record of a permission check on a sensitive resource.
+// Rows are only ever inserted; allowed and denied checks are both recorded.
+type AccessCheckLog struct {
+	ID         string           `json:"id" gorm:"type:varchar(36);primaryKey"`
+	UserID     string           `json:"user_id" gorm:"column:user_id;type:varchar(36);not null;index"`
+	Resource   string           `json:"resource" gorm:"type:varchar(100);not null;index"`
+	Action     PermissionAction `json:"action" gorm:"type:varchar(20);not null"`
+	Scope      *PermissionScope `json:"scope,omitempty" gorm:"type:varchar(20)"`
+	Allowed    bool             `json:"allowed" gorm:"not null"`
+	Source     string           `json:"source" gorm:"type:varchar(30)"`
+	SourceID   string           `json:"source_id,omitempty" gorm:"column:source_id;type:varchar(36)"`
+	SourceName string           `json:"source_name,omitempty" gorm:"column:source_name;type:varchar(255)"`
+	CheckedAt  time.Time        `json:"checked_at" gorm:"column:checked_at;not null;index"`
+}
+
+// TableName specifies the table name for AccessCheckLog
+func (AccessCheckLog) TableName() string {
+	return "public.access_check_logs"
+}
+
+// AccessCheckLogFilter represents filters for querying access check logs
+type AccessCheckLogFilter struct {
+	UserID    *string           `form:"user_id"`
+	Resource  *string           `form:"resource"`
+	Action    *PermissionAction `form:"action"`
+	Allowed   *bool             `form:"allowed"`
+	StartDate *time.Time        `form:"start_date"`
+	EndDate   *time.Time        `form:"end_date"`
+	Page      int               `form:"page,default=1"`
+	Limit     int               `form:"limit,default=50"`
+}
@@ -36,6 +36,33 @@ func (AuditLog) TableName() string {
 	return "public.audit_logs"
 }
 
+// AuditLogArchive holds audit log entries moved out of audit_logs by the retention job
+type AuditLogArchive struct {
+	ID             string          `json:"id" gorm:"type:varchar(36);primaryKey"`
+	ActorID        string          `json:"actor_id" gorm:"column:actor_id;type:varchar(100);not null"`
+	ActorProfileID *string         `json:"actor_profile_id,omitempty" gorm:"column:actor_profile_id;type:varchar(36)"`
+	Action         AuditAction     `json:"action" gorm:"type:varchar(20);not null"`
+	Module         string          `json:"module" gorm:"type:varchar(100);not null"`
+	EntityType     string          `json:"entity_type" gorm:"column:entity_type;type:varchar(100);not null"`
+	EntityID       string          `json:"entity_id" gorm:"column:entity_id;type:varchar(100);not null"`
+	EntityDisplay  *string         `json:"entity_display,omitempty" gorm:"column:entity_display;type:varchar(255)"`
+	OldValues      *datatypes.JSON `json:"old_values,omitempty" gorm:"column:old_values;type:jsonb"`
+	NewValues      *datatypes.JSON `json:"new_values,omitempty" gorm:"column:new_values;type:jsonb"`
+	ChangedFields  *datatypes.JSON `json:"changed_fields,omitempty" gorm:"column:changed_fields;type:jsonb"`
+	TargetUserID   *string         `json:"target_user_id,omitempty" gorm:"column:target_user_id;type:varchar(36)"`
+	Metadata       *datatypes.JSON `json:"metadata,omitempty" gorm:"type:jsonb"`
+	IPAddress      *string         `json:"ip_address,omitempty" gorm:"column:ip_address;type:varchar(45)"`
+	UserAgent      *string         `json:"user_agent,omitempty" gorm:"column:user_agent;type:text"`
+	CreatedAt      time.Time       `json:"created_at" gorm:"index"`
+	Category       *AuditCategory  `json:"category,omitempty" gorm:"type:varchar(30)"`
+	ArchivedAt     time.Time       `json:"archived_at" gorm:"column:archived_at;not null"`
+}
+
+// TableName specifies the table name for AuditLogArchive
+func (AuditLogArchive) TableName() string {
+	return "public.audit_log_archives"
+}
+
 // AuditLogResponse represents the response body for audit log data
 type AuditLogResponse struct {
 	ID             string                   `json:"id"`
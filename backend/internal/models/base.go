@@ -3,6 +3,8 @@ package models
 import (
 	"time"
 
+	"backend/internal/clock"
+
 	"gorm.io/gorm"
 )
 
@@ -56,7 +58,7 @@ type EffectiveDateModel struct {
 
 // IsEffective checks if the record is currently effective
 func (e *EffectiveDateModel) IsEffective() bool {
-	now := time.Now()
+	now := clock.Now()
 	if now.Before(e.EffectiveFrom) {
 		return false
 	}
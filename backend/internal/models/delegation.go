@@ -3,6 +3,9 @@ package models
 import (
 	"time"
 
+	"backend/internal/clock"
+
+	"github.com/lib/pq"
 	"gorm.io/datatypes"
 )
 
@@ -17,6 +20,10 @@ type Delegation struct {
 	EffectiveUntil *time.Time      `json:"effective_until,omitempty" gorm:"column:effective_until"`
 	IsActive       bool            `json:"is_active" gorm:"column:is_active;default:true"`
 	Context        *datatypes.JSON `json:"context,omitempty" gorm:"type:jsonb"`
+	RoleID         *string         `json:"role_id,omitempty" gorm:"column:role_id;type:varchar(36)"`
+	PermissionIDs  pq.StringArray  `json:"permission_ids,omitempty" gorm:"column:permission_ids;type:text[]"`
+	RevokedAt      *time.Time      `json:"revoked_at,omitempty" gorm:"column:revoked_at"`
+	RevokedBy      *string         `json:"revoked_by,omitempty" gorm:"column:revoked_by;type:varchar(36)"`
 	CreatedAt      time.Time       `json:"created_at"`
 	UpdatedAt      time.Time       `json:"updated_at"`
 	CreatedBy      *string         `json:"created_by,omitempty" gorm:"column:created_by;type:varchar(36)"`
@@ -24,6 +31,7 @@ type Delegation struct {
 	// Relations
 	Delegator *User `json:"delegator,omitempty" gorm:"foreignKey:DelegatorID"`
 	Delegate  *User `json:"delegate,omitempty" gorm:"foreignKey:DelegateID"`
+	Role      *Role `json:"role,omitempty" gorm:"foreignKey:RoleID"`
 }
 
 // TableName specifies the table name for Delegation
@@ -39,6 +47,8 @@ type CreateDelegationRequest struct {
 	EffectiveFrom  *time.Time      `json:"effective_from,omitempty"`
 	EffectiveUntil *time.Time      `json:"effective_until,omitempty"`
 	Context        *datatypes.JSON `json:"context,omitempty"`
+	RoleID         *string         `json:"role_id,omitempty" binding:"omitempty,len=36"`
+	PermissionIDs  []string        `json:"permission_ids,omitempty" binding:"omitempty,max=100,dive,len=36"`
 }
 
 // UpdateDelegationRequest represents the request body for updating a delegation
@@ -52,20 +62,26 @@ type UpdateDelegationRequest struct {
 
 // DelegationResponse represents the response body for delegation data
 type DelegationResponse struct {
-	ID             string                   `json:"id"`
-	Type           DelegationType           `json:"type"`
-	DelegatorID    string                   `json:"delegator_id"`
+	ID             string            `json:"id"`
+	Type           DelegationType    `json:"type"`
+	DelegatorID    string            `json:"delegator_id"`
 	Delegator      *UserListResponse `json:"delegator,omitempty"`
-	DelegateID     string                   `json:"delegate_id"`
+	DelegateID     string            `json:"delegate_id"`
 	Delegate       *UserListResponse `json:"delegate,omitempty"`
-	Reason         *string                  `json:"reason,omitempty"`
-	EffectiveFrom  time.Time                `json:"effective_from"`
-	EffectiveUntil *time.Time               `json:"effective_until,omitempty"`
-	IsActive       bool                     `json:"is_active"`
-	Context        *datatypes.JSON          `json:"context,omitempty"`
-	CreatedAt      time.Time                `json:"created_at"`
-	UpdatedAt      time.Time                `json:"updated_at"`
-	CreatedBy      *string                  `json:"created_by,omitempty"`
+	Reason         *string           `json:"reason,omitempty"`
+	EffectiveFrom  time.Time         `json:"effective_from"`
+	EffectiveUntil *time.Time        `json:"effective_until,omitempty"`
+	IsActive       bool              `json:"is_active"`
+	Context        *datatypes.JSON   `json:"context,omitempty"`
+	RoleID         *string           `json:"role_id,omitempty"`
+	Role           *RoleListResponse `json:"role,omitempty"`
+	PermissionIDs  []string          `json:"permission_ids,omitempty"`
+	IsEffective    bool              `json:"is_effective"`
+	RevokedAt      *time.Time        `json:"revoked_at,omitempty"`
+	RevokedBy      *string           `json:"revoked_by,omitempty"`
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+	CreatedBy      *string           `json:"created_by,omitempty"`
 }
 
 // DelegationListResponse represents the response for listing delegations
@@ -77,6 +93,7 @@ type DelegationListResponse struct {
 	EffectiveFrom  time.Time      `json:"effective_from"`
 	EffectiveUntil *time.Time     `json:"effective_until,omitempty"`
 	IsActive       bool           `json:"is_active"`
+	IsEffective    bool           `json:"is_effective"`
 }
 
 // ToResponse converts Delegation to DelegationResponse
@@ -91,6 +108,11 @@ func (d *Delegation) ToResponse() *DelegationResponse {
 		EffectiveUntil: d.EffectiveUntil,
 		IsActive:       d.IsActive,
 		Context:        d.Context,
+		RoleID:         d.RoleID,
+		PermissionIDs:  d.PermissionIDs,
+		IsEffective:    d.IsEffective(),
+		RevokedAt:      d.RevokedAt,
+		RevokedBy:      d.RevokedBy,
 		CreatedAt:      d.CreatedAt,
 		UpdatedAt:      d.UpdatedAt,
 		CreatedBy:      d.CreatedBy,
@@ -104,6 +126,10 @@ func (d *Delegation) ToResponse() *DelegationResponse {
 		resp.Delegate = d.Delegate.ToListResponse()
 	}
 
+	if d.Role != nil {
+		resp.Role = d.Role.ToListResponse()
+	}
+
 	return resp
 }
 
@@ -115,6 +141,7 @@ func (d *Delegation) ToListResponse() *DelegationListResponse {
 		EffectiveFrom:  d.EffectiveFrom,
 		EffectiveUntil: d.EffectiveUntil,
 		IsActive:       d.IsActive,
+		IsEffective:    d.IsEffective(),
 	}
 
 	if d.Delegator != nil {
@@ -140,10 +167,14 @@ func (d *Delegation) ToListResponse() *DelegationListResponse {
 
 // IsEffective checks if the delegation is currently effective
 func (d *Delegation) IsEffective() bool {
+	return d.IsEffectiveAt(clock.Now())
+}
+
+// IsEffectiveAt checks if the delegation is effective at now; both ends of the window are inclusive
+func (d *Delegation) IsEffectiveAt(now time.Time) bool {
 	if !d.IsActive {
 		return false
 	}
-	now := time.Now()
 	if now.Before(d.EffectiveFrom) {
 		return false
 	}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDelegationIsEffectiveAtWindowBoundaries(t *testing.T) {
+	from := time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+	until := time.Date(2026, 3, 6, 17, 0, 0, 0, time.UTC)
+	window := Delegation{IsActive: true, EffectiveFrom: from, EffectiveUntil: &until}
+	openEnded := Delegation{IsActive: true, EffectiveFrom: from}
+	inactive := Delegation{IsActive: false, EffectiveFrom: from, EffectiveUntil: &until}
+
+	tests := []struct {
+		name       string
+		delegation Delegation
+		at         time.Time
+		want       bool
+	}{
+		{"just before start", window, from.Add(-time.Nanosecond), false},
+		{"at start", window, from, true},
+		{"inside window", window, from.Add(24 * time.Hour), true},
+		{"at end", window, until, true},
+		{"just after end", window, until.Add(time.Nanosecond), false},
+		{"open-ended, long after start", openEnded, from.AddDate(5, 0, 0), true},
+		{"open-ended, before start", openEnded, from.Add(-time.Nanosecond), false},
+		{"inactive inside window", inactive, from.Add(time.Hour), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.delegation.IsEffectiveAt(tt.at); got != tt.want {
+				t.Errorf("IsEffectiveAt(%s) = %v, want %v", tt.at, got, tt.want)
+			}
+		})
+	}
+}
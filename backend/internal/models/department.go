@@ -50,6 +50,12 @@ type UpdateDepartmentRequest struct {
 	IsActive    *bool   `json:"is_active,omitempty"`
 }
 
+// MoveDepartmentRequest represents the request body for moving a department subtree
+// A null or empty parent_id moves the department to the root
+type MoveDepartmentRequest struct {
+	ParentID *string `json:"parent_id"`
+}
+
 // DepartmentResponse represents the response body for department data
 type DepartmentResponse struct {
 	ID          string              `json:"id"`
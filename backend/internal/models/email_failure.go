@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// EmailFailure records an email that could not be delivered after all retry attempts.
+// Bodies are not stored because they may contain password reset or setup tokens.
+type EmailFailure struct {
+	ID        string    `json:"id" gorm:"type:varchar(36);primaryKey"`
+	Recipient string    `json:"recipient" gorm:"type:varchar(255);not null;index"`
+	Subject   string    `json:"subject" gorm:"type:varchar(255);not null"`
+	Template  string    `json:"template" gorm:"type:varchar(50);not null"`
+	Attempts  int       `json:"attempts" gorm:"not null"`
+	LastError string    `json:"last_error" gorm:"column:last_error;type:text;not null"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
+
+// TableName specifies the table name for EmailFailure
+func (EmailFailure) TableName() string {
+	return "public.email_failures"
+}
+
+// EmailFailureFilter represents query filters for listing email failures
+type EmailFailureFilter struct {
+	Recipient *string `form:"recipient"`
+	Template  *string `form:"template"`
+	Page      int     `form:"page,default=1"`
+	Limit     int     `form:"limit,default=50"`
+}
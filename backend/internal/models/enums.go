@@ -4,20 +4,21 @@ package models
 type AuditAction string
 
 const (
-	AuditActionCreate   AuditAction = "CREATE"
-	AuditActionRead     AuditAction = "READ"
-	AuditActionUpdate   AuditAction = "UPDATE"
-	AuditActionDelete   AuditAction = "DELETE"
-	AuditActionApprove  AuditAction = "APPROVE"
-	AuditActionReject   AuditAction = "REJECT"
-	AuditActionLogin    AuditAction = "LOGIN"
-	AuditActionLogout   AuditAction = "LOGOUT"
-	AuditActionExport   AuditAction = "EXPORT"
-	AuditActionImport   AuditAction = "IMPORT"
-	AuditActionAssign   AuditAction = "ASSIGN"
-	AuditActionGrant    AuditAction = "GRANT"
-	AuditActionRevoke   AuditAction = "REVOKE"
-	AuditActionDelegate AuditAction = "DELEGATE"
+	AuditActionCreate      AuditAction = "CREATE"
+	AuditActionRead        AuditAction = "READ"
+	AuditActionUpdate      AuditAction = "UPDATE"
+	AuditActionDelete      AuditAction = "DELETE"
+	AuditActionApprove     AuditAction = "APPROVE"
+	AuditActionReject      AuditAction = "REJECT"
+	AuditActionLogin       AuditAction = "LOGIN"
+	AuditActionLogout      AuditAction = "LOGOUT"
+	AuditActionExport      AuditAction = "EXPORT"
+	AuditActionImport      AuditAction = "IMPORT"
+	AuditActionAssign      AuditAction = "ASSIGN"
+	AuditActionGrant       AuditAction = "GRANT"
+	AuditActionRevoke      AuditAction = "REVOKE"
+	AuditActionDelegate    AuditAction = "DELEGATE"
+	AuditActionImpersonate AuditAction = "IMPERSONATE"
 )
 
 func (a AuditAction) IsValid() bool {
@@ -25,7 +26,7 @@ func (a AuditAction) IsValid() bool {
 	case AuditActionCreate, AuditActionRead, AuditActionUpdate, AuditActionDelete,
 		AuditActionApprove, AuditActionReject, AuditActionLogin, AuditActionLogout,
 		AuditActionExport, AuditActionImport, AuditActionAssign, AuditActionGrant,
-		AuditActionRevoke, AuditActionDelegate:
+		AuditActionRevoke, AuditActionDelegate, AuditActionImpersonate:
 		return true
 	}
 	return false
@@ -112,16 +113,17 @@ func (m ModuleCategory) String() string {
 type PermissionAction string
 
 const (
-	PermissionActionCreate  PermissionAction = "CREATE"
-	PermissionActionRead    PermissionAction = "READ"
-	PermissionActionUpdate  PermissionAction = "UPDATE"
-	PermissionActionDelete  PermissionAction = "DELETE"
-	PermissionActionApprove PermissionAction = "APPROVE"
-	PermissionActionExport  PermissionAction = "EXPORT"
-	PermissionActionImport  PermissionAction = "IMPORT"
-	PermissionActionPrint   PermissionAction = "PRINT"
-	PermissionActionAssign  PermissionAction = "ASSIGN"
-	PermissionActionClose   PermissionAction = "CLOSE"
+	PermissionActionCreate      PermissionAction = "CREATE"
+	PermissionActionRead        PermissionAction = "READ"
+	PermissionActionUpdate      PermissionAction = "UPDATE"
+	PermissionActionDelete      PermissionAction = "DELETE"
+	PermissionActionApprove     PermissionAction = "APPROVE"
+	PermissionActionExport      PermissionAction = "EXPORT"
+	PermissionActionImport      PermissionAction = "IMPORT"
+	PermissionActionPrint       PermissionAction = "PRINT"
+	PermissionActionAssign      PermissionAction = "ASSIGN"
+	PermissionActionClose       PermissionAction = "CLOSE"
+	PermissionActionImpersonate PermissionAction = "IMPERSONATE"
 )
 
 func (p PermissionAction) IsValid() bool {
@@ -129,7 +131,7 @@ func (p PermissionAction) IsValid() bool {
 	case PermissionActionCreate, PermissionActionRead, PermissionActionUpdate,
 		PermissionActionDelete, PermissionActionApprove, PermissionActionExport,
 		PermissionActionImport, PermissionActionPrint, PermissionActionAssign,
-		PermissionActionClose:
+		PermissionActionClose, PermissionActionImpersonate:
 		return true
 	}
 	return false
@@ -190,7 +192,7 @@ func AllAuditActions() []AuditAction {
 		AuditActionCreate, AuditActionRead, AuditActionUpdate, AuditActionDelete,
 		AuditActionApprove, AuditActionReject, AuditActionLogin, AuditActionLogout,
 		AuditActionExport, AuditActionImport, AuditActionAssign, AuditActionGrant,
-		AuditActionRevoke, AuditActionDelegate,
+		AuditActionRevoke, AuditActionDelegate, AuditActionImpersonate,
 	}
 }
 
@@ -224,7 +226,7 @@ func AllPermissionActions() []PermissionAction {
 		PermissionActionCreate, PermissionActionRead, PermissionActionUpdate,
 		PermissionActionDelete, PermissionActionApprove, PermissionActionExport,
 		PermissionActionImport, PermissionActionPrint, PermissionActionAssign,
-		PermissionActionClose,
+		PermissionActionClose, PermissionActionImpersonate,
 	}
 }
 
@@ -3,6 +3,8 @@ package models
 import (
 	"time"
 
+	"backend/internal/clock"
+
 	"github.com/lib/pq"
 	"gorm.io/datatypes"
 )
@@ -167,7 +169,7 @@ func (f *FeatureFlag) IsActiveNow() bool {
 	if !f.Enabled {
 		return false
 	}
-	now := time.Now()
+	now := clock.Now()
 	if f.StartDate != nil && now.Before(*f.StartDate) {
 		return false
 	}
@@ -3,6 +3,8 @@ package models
 import (
 	"time"
 
+	"backend/internal/clock"
+
 	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
@@ -55,6 +57,10 @@ type RoleModuleAccess struct {
 	CreatedBy   *string        `json:"created_by,omitempty" gorm:"column:created_by;type:varchar(36)"`
 	Version     int            `json:"version" gorm:"default:0"`
 
+	// Access outside [EffectiveFrom, EffectiveUntil] is ignored, like other time-bounded grants
+	EffectiveFrom  time.Time  `json:"effective_from" gorm:"column:effective_from;not null;default:CURRENT_TIMESTAMP"`
+	EffectiveUntil *time.Time `json:"effective_until,omitempty" gorm:"column:effective_until"`
+
 	// Relations
 	Role     *Role     `json:"role,omitempty" gorm:"foreignKey:RoleID;constraint:OnDelete:CASCADE"`
 	Module   *Module   `json:"module,omitempty" gorm:"foreignKey:ModuleID;constraint:OnDelete:CASCADE"`
@@ -116,6 +122,8 @@ type UpdateModuleRequest struct {
 	SortOrder   *int            `json:"sort_order,omitempty"`
 	IsActive    *bool           `json:"is_active,omitempty"`
 	IsVisible   *bool           `json:"is_visible,omitempty"`
+	// Version the client last read; a mismatch means someone else saved first (409)
+	Version *int `json:"version,omitempty"`
 }
 
 // ModuleResponse represents the response body for module data
@@ -174,6 +182,9 @@ type AssignModuleAccessToRoleRequest struct {
 	PositionID  *string        `json:"position_id,omitempty" binding:"omitempty,len=36"`
 	Permissions datatypes.JSON `json:"permissions" binding:"required"`
 	IsActive    *bool          `json:"is_active,omitempty"`
+
+	EffectiveFrom  *time.Time `json:"effective_from,omitempty"`
+	EffectiveUntil *time.Time `json:"effective_until,omitempty"`
 }
 
 // AssignModuleAccessToUserRequest represents the request for assigning module access to user
@@ -194,6 +205,9 @@ type RoleModuleAccessResponse struct {
 	PositionID  *string             `json:"position_id,omitempty"`
 	Permissions datatypes.JSON      `json:"permissions"`
 	IsActive    bool                `json:"is_active"`
+
+	EffectiveFrom  time.Time  `json:"effective_from"`
+	EffectiveUntil *time.Time `json:"effective_until,omitempty"`
 }
 
 // ToResponse converts RoleModuleAccess to RoleModuleAccessResponse
@@ -205,6 +219,9 @@ func (rma *RoleModuleAccess) ToResponse() *RoleModuleAccessResponse {
 		PositionID:  rma.PositionID,
 		Permissions: rma.Permissions,
 		IsActive:    rma.IsActive,
+
+		EffectiveFrom:  rma.EffectiveFrom,
+		EffectiveUntil: rma.EffectiveUntil,
 	}
 
 	if rma.Module != nil {
@@ -300,7 +317,7 @@ func (uma *UserModuleAccess) IsEffective() bool {
 	if !uma.IsActive {
 		return false
 	}
-	now := time.Now()
+	now := clock.Now()
 	if now.Before(uma.EffectiveFrom) {
 		return false
 	}
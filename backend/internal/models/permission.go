@@ -17,6 +17,7 @@ type Permission struct {
 	Metadata           *string          `json:"metadata,omitempty" gorm:"type:jsonb"`
 	IsSystemPermission bool             `json:"is_system_permission" gorm:"column:is_system_permission;default:false"`
 	IsActive           bool             `json:"is_active" gorm:"column:is_active;default:true"`
+	IsSensitive        bool             `json:"is_sensitive" gorm:"column:is_sensitive;default:false"` // every check is recorded in access_check_logs
 	CreatedAt          time.Time        `json:"created_at"`
 	UpdatedAt          time.Time        `json:"updated_at"`
 	CreatedBy          *string          `json:"created_by,omitempty" gorm:"column:created_by;type:varchar(36)"`
@@ -63,6 +64,7 @@ type CreatePermissionRequest struct {
 	Conditions         *string          `json:"conditions,omitempty"`
 	Metadata           *string          `json:"metadata,omitempty"`
 	IsSystemPermission *bool            `json:"is_system_permission,omitempty"`
+	IsSensitive        *bool            `json:"is_sensitive,omitempty"`
 	Category           *ModuleCategory  `json:"category,omitempty"`
 	GroupIcon          *string          `json:"group_icon,omitempty"`
 	GroupName          *string          `json:"group_name,omitempty"`
@@ -71,19 +73,37 @@ type CreatePermissionRequest struct {
 
 // UpdatePermissionRequest represents the request body for updating a permission
 type UpdatePermissionRequest struct {
-	Code           *string          `json:"code,omitempty" binding:"omitempty,min=2,max=100"`
-	Name           *string          `json:"name,omitempty" binding:"omitempty,min=2,max=255"`
-	Description    *string          `json:"description,omitempty"`
-	Resource       *string          `json:"resource,omitempty" binding:"omitempty,min=2,max=100"`
+	Code           *string           `json:"code,omitempty" binding:"omitempty,min=2,max=100"`
+	Name           *string           `json:"name,omitempty" binding:"omitempty,min=2,max=255"`
+	Description    *string           `json:"description,omitempty"`
+	Resource       *string           `json:"resource,omitempty" binding:"omitempty,min=2,max=100"`
 	Action         *PermissionAction `json:"action,omitempty"`
-	Scope          *PermissionScope `json:"scope,omitempty"`
-	Conditions     *string          `json:"conditions,omitempty"`
-	Metadata       *string          `json:"metadata,omitempty"`
-	IsActive       *bool            `json:"is_active,omitempty"`
-	Category       *ModuleCategory  `json:"category,omitempty"`
-	GroupIcon      *string          `json:"group_icon,omitempty"`
-	GroupName      *string          `json:"group_name,omitempty"`
-	GroupSortOrder *int             `json:"group_sort_order,omitempty"`
+	Scope          *PermissionScope  `json:"scope,omitempty"`
+	Conditions     *string           `json:"conditions,omitempty"`
+	Metadata       *string           `json:"metadata,omitempty"`
+	IsActive       *bool             `json:"is_active,omitempty"`
+	IsSensitive    *bool             `json:"is_sensitive,omitempty"`
+	Category       *ModuleCategory   `json:"category,omitempty"`
+	GroupIcon      *string           `json:"group_icon,omitempty"`
+	GroupName      *string           `json:"group_name,omitempty"`
+	GroupSortOrder *int              `json:"group_sort_order,omitempty"`
+}
+
+// UpdatePermissionGroupRequest represents the request body for renaming, re-iconing or
+// reordering a permission group; the changes apply to every permission in the group
+type UpdatePermissionGroupRequest struct {
+	Name      *string `json:"name,omitempty" binding:"omitempty,min=1,max=100"`
+	Icon      *string `json:"icon,omitempty" binding:"omitempty,max=50"`
+	SortOrder *int    `json:"sort_order,omitempty" binding:"omitempty,min=0"`
+}
+
+// MovePermissionGroupRequest represents the request body for moving a permission to another
+// group. An empty group name removes the permission from its group. Icon and sort order are
+// only used when the target group has no permissions yet; otherwise the group's own are kept.
+type MovePermissionGroupRequest struct {
+	GroupName      string  `json:"group_name" binding:"max=100"`
+	GroupIcon      *string `json:"group_icon,omitempty" binding:"omitempty,max=50"`
+	GroupSortOrder *int    `json:"group_sort_order,omitempty" binding:"omitempty,min=0"`
 }
 
 // PermissionResponse represents the response body for permission data
@@ -99,6 +119,7 @@ type PermissionResponse struct {
 	Metadata           *string          `json:"metadata,omitempty"`
 	IsSystemPermission bool             `json:"is_system_permission"`
 	IsActive           bool             `json:"is_active"`
+	IsSensitive        bool             `json:"is_sensitive"`
 	CreatedAt          time.Time        `json:"created_at"`
 	UpdatedAt          time.Time        `json:"updated_at"`
 	CreatedBy          *string          `json:"created_by,omitempty"`
@@ -128,6 +149,33 @@ type PermissionGroupResponse struct {
 	Permissions []PermissionListResponse `json:"permissions"`
 }
 
+// PermissionUsageRole is a role that carries a permission, with the number of users holding it
+type PermissionUsageRole struct {
+	ID        string `json:"id"`
+	Code      string `json:"code"`
+	Name      string `json:"name"`
+	IsGranted bool   `json:"is_granted"`
+	UserCount int64  `json:"user_count"`
+}
+
+// PermissionUsageUser is a user with a direct grant (or explicit deny) of a permission
+type PermissionUsageUser struct {
+	ID             string     `json:"id"`
+	Email          string     `json:"email"`
+	Username       *string    `json:"username,omitempty"`
+	IsGranted      bool       `json:"is_granted"`
+	EffectiveUntil *time.Time `json:"effective_until,omitempty"`
+}
+
+// PermissionUsageResponse describes who is affected by a permission before it is edited or deleted
+type PermissionUsageResponse struct {
+	PermissionID       string                `json:"permission_id"`
+	Code               string                `json:"code"`
+	Roles              []PermissionUsageRole `json:"roles"`
+	Users              []PermissionUsageUser `json:"users"`
+	EffectiveUserCount int                   `json:"effective_user_count"`
+}
+
 // ToResponse converts Permission to PermissionResponse
 func (p *Permission) ToResponse() *PermissionResponse {
 	return &PermissionResponse{
@@ -142,6 +190,7 @@ func (p *Permission) ToResponse() *PermissionResponse {
 		Metadata:           p.Metadata,
 		IsSystemPermission: p.IsSystemPermission,
 		IsActive:           p.IsActive,
+		IsSensitive:        p.IsSensitive,
 		CreatedAt:          p.CreatedAt,
 		UpdatedAt:          p.UpdatedAt,
 		CreatedBy:          p.CreatedBy,
@@ -127,3 +127,61 @@ func (p *Position) ToListResponse() *PositionListResponse {
 		IsActive:       p.IsActive,
 	}
 }
+
+// PositionHolderResponse represents a user assigned to a position, with the employee data and
+// the position's department
+type PositionHolderResponse struct {
+	UserPositionID string                  `json:"user_position_id"`
+	UserID         string                  `json:"user_id"`
+	Email          string                  `json:"email"`
+	Username       *string                 `json:"username,omitempty"`
+	Name           *string                 `json:"name,omitempty"`
+	NIP            *string                 `json:"nip,omitempty"`
+	BagianKerja    *string                 `json:"bagian_kerja,omitempty"`
+	Department     *DepartmentListResponse `json:"department,omitempty"`
+	StartDate      time.Time               `json:"start_date"`
+	EndDate        *time.Time              `json:"end_date,omitempty"`
+	IsActive       bool                    `json:"is_active"`
+	IsPlt          bool                    `json:"is_plt"`
+	IsCurrent      bool                    `json:"is_current"`
+	SKNumber       *string                 `json:"sk_number,omitempty"`
+	EndReason      *string                 `json:"end_reason,omitempty"`
+}
+
+// OrgChartHolder represents a user currently holding a position in the org chart
+type OrgChartHolder struct {
+	UserPositionID string    `json:"user_position_id"`
+	UserID         string    `json:"user_id"`
+	Email          string    `json:"email"`
+	Name           *string   `json:"name,omitempty"`
+	IsPlt          bool      `json:"is_plt"`
+	StartDate      time.Time `json:"start_date"`
+}
+
+// OrgChartPosition represents a position and its current holders in the org chart
+type OrgChartPosition struct {
+	ID             string            `json:"id"`
+	Code           string            `json:"code"`
+	Name           string            `json:"name"`
+	SchoolID       *string           `json:"school_id,omitempty"`
+	HierarchyLevel int               `json:"hierarchy_level"`
+	MaxHolders     int               `json:"max_holders"`
+	Holders        []*OrgChartHolder `json:"holders"`
+}
+
+// OrgChartDepartment represents a department with its positions and sub-departments
+type OrgChartDepartment struct {
+	ID        string                `json:"id"`
+	Code      string                `json:"code"`
+	Name      string                `json:"name"`
+	SchoolID  *string               `json:"school_id,omitempty"`
+	Positions []*OrgChartPosition   `json:"positions"`
+	Children  []*OrgChartDepartment `json:"children,omitempty"`
+}
+
+// OrgChartResponse represents the organizational chart.
+// Positions outside any department (e.g. foundation or school leadership) are listed separately.
+type OrgChartResponse struct {
+	Departments []*OrgChartDepartment `json:"departments"`
+	Positions   []*OrgChartPosition   `json:"positions"`
+}
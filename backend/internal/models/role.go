@@ -2,6 +2,10 @@ package models
 
 import (
 	"time"
+
+	"backend/internal/clock"
+
+	"gorm.io/datatypes"
 )
 
 // Role represents a user role with hierarchical structure
@@ -13,6 +17,7 @@ type Role struct {
 	HierarchyLevel int       `json:"hierarchy_level" gorm:"column:hierarchy_level;not null;index"`
 	IsSystemRole   bool      `json:"is_system_role" gorm:"column:is_system_role;default:false"`
 	IsActive       bool      `json:"is_active" gorm:"column:is_active;default:true"`
+	Version        int       `json:"version" gorm:"default:0"` // bumped on every update, for optimistic locking
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
 	CreatedBy      *string   `json:"created_by,omitempty" gorm:"column:created_by;type:varchar(36)"`
@@ -89,6 +94,8 @@ type UpdateRoleRequest struct {
 	Description    *string `json:"description,omitempty"`
 	HierarchyLevel *int    `json:"hierarchy_level,omitempty" binding:"omitempty,min=1,max=10"`
 	IsActive       *bool   `json:"is_active,omitempty"`
+	// Version the client last read; a mismatch means someone else saved first (409)
+	Version *int `json:"version,omitempty"`
 }
 
 // RoleResponse represents the response body for role data
@@ -100,6 +107,7 @@ type RoleResponse struct {
 	HierarchyLevel int       `json:"hierarchy_level"`
 	IsSystemRole   bool      `json:"is_system_role"`
 	IsActive       bool      `json:"is_active"`
+	Version        int       `json:"version"`
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
 	CreatedBy      *string   `json:"created_by,omitempty"`
@@ -113,6 +121,10 @@ type RoleListResponse struct {
 	HierarchyLevel int    `json:"hierarchy_level"`
 	IsSystemRole   bool   `json:"is_system_role"`
 	IsActive       bool   `json:"is_active"`
+
+	// Set only when listing with ?with_counts=true: distinct users holding the role
+	// directly or through a role that inherits from it
+	EffectiveUserCount *int64 `json:"effective_user_count,omitempty"`
 }
 
 // AssignedPermissionResponse represents a permission assigned to a role with assignment_id
@@ -154,6 +166,7 @@ func (r *Role) ToResponse() *RoleResponse {
 		HierarchyLevel: r.HierarchyLevel,
 		IsSystemRole:   r.IsSystemRole,
 		IsActive:       r.IsActive,
+		Version:        r.Version,
 		CreatedAt:      r.CreatedAt,
 		UpdatedAt:      r.UpdatedAt,
 		CreatedBy:      r.CreatedBy,
@@ -174,7 +187,7 @@ func (r *Role) ToListResponse() *RoleListResponse {
 
 // IsEffective checks if the role permission is currently effective
 func (rp *RolePermission) IsEffective() bool {
-	now := time.Now()
+	now := clock.Now()
 	if now.Before(rp.EffectiveFrom) {
 		return false
 	}
@@ -183,3 +196,66 @@ func (rp *RolePermission) IsEffective() bool {
 	}
 	return rp.IsGranted
 }
+
+// RoleExportFormatVersion is bumped whenever the RoleExport document changes incompatibly
+const RoleExportFormatVersion = 1
+
+// RoleExport is a portable definition of a role for moving RBAC configuration between
+// environments. Permissions, modules, positions and parent roles are referenced by code,
+// since ids differ from one environment to the next.
+type RoleExport struct {
+	FormatVersion int                      `json:"format_version" binding:"required"`
+	ExportedAt    *time.Time               `json:"exported_at,omitempty"`
+	Role          RoleExportMetadata       `json:"role" binding:"required"`
+	Permissions   []RoleExportPermission   `json:"permissions" binding:"dive"`
+	ModuleAccess  []RoleExportModuleAccess `json:"module_access" binding:"dive"`
+	ParentRoles   []RoleExportParentRole   `json:"parent_roles" binding:"dive"`
+}
+
+// RoleExportMetadata holds the role's own fields
+type RoleExportMetadata struct {
+	Code           string  `json:"code" binding:"required,min=2,max=50"`
+	Name           string  `json:"name" binding:"required,min=2,max=255"`
+	Description    *string `json:"description,omitempty"`
+	HierarchyLevel int     `json:"hierarchy_level" binding:"required,min=1,max=10"`
+	IsSystemRole   bool    `json:"is_system_role"`
+	IsActive       bool    `json:"is_active"`
+}
+
+// RoleExportPermission is a permission assignment of the role, by permission code
+type RoleExportPermission struct {
+	Code           string     `json:"code" binding:"required"`
+	IsGranted      bool       `json:"is_granted"`
+	Conditions     *string    `json:"conditions,omitempty"`
+	GrantReason    *string    `json:"grant_reason,omitempty"`
+	EffectiveFrom  time.Time  `json:"effective_from"`
+	EffectiveUntil *time.Time `json:"effective_until,omitempty"`
+}
+
+// RoleExportModuleAccess is a module access entry of the role, by module and position code
+type RoleExportModuleAccess struct {
+	ModuleCode     string         `json:"module_code" binding:"required"`
+	PositionCode   *string        `json:"position_code,omitempty"`
+	Permissions    datatypes.JSON `json:"permissions" binding:"required"`
+	IsActive       bool           `json:"is_active"`
+	EffectiveFrom  time.Time      `json:"effective_from"`
+	EffectiveUntil *time.Time     `json:"effective_until,omitempty"`
+}
+
+// RoleExportParentRole is a role the exported role inherits from, by role code
+type RoleExportParentRole struct {
+	Code               string `json:"code" binding:"required"`
+	InheritPermissions bool   `json:"inherit_permissions"`
+}
+
+// RoleImportReference is a code in an imported role that does not exist in this environment
+type RoleImportReference struct {
+	Type string `json:"type"` // permission, module, position or parent_role
+	Code string `json:"code"`
+}
+
+// RoleImportResult is the outcome of importing a role
+type RoleImportResult struct {
+	Role       *RoleResponse         `json:"role,omitempty"`
+	Unresolved []RoleImportReference `json:"unresolved"`
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
 	"gorm.io/datatypes"
@@ -51,6 +52,12 @@ type UpdateSystemConfigRequest struct {
 	ValidationRules *datatypes.JSON `json:"validation_rules,omitempty"`
 }
 
+// UpdateSettingsRequest represents the request body for changing runtime settings,
+// keyed by setting key, e.g. {"settings": {"auth.require_2fa": true}}
+type UpdateSettingsRequest struct {
+	Settings map[string]json.RawMessage `json:"settings" binding:"required,min=1"`
+}
+
 // SystemConfigResponse represents the response body for system config data
 type SystemConfigResponse struct {
 	ID              string          `json:"id"`
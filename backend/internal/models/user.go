@@ -3,6 +3,8 @@ package models
 import (
 	"time"
 
+	"backend/internal/clock"
+
 	"gorm.io/datatypes"
 )
 
@@ -11,16 +13,22 @@ type User struct {
 	ID string `json:"id" gorm:"type:varchar(36);primaryKey"`
 
 	// Authentication fields
-	Email                  string     `json:"email" gorm:"column:email;type:varchar(255);uniqueIndex;not null"`
-	Username               *string    `json:"username,omitempty" gorm:"column:username;type:varchar(50);uniqueIndex"`
-	PasswordHash           string     `json:"-" gorm:"column:password_hash;type:varchar(255);not null"`
-	PasswordResetToken     *string    `json:"-" gorm:"column:password_reset_token;type:varchar(255)"`
+	Email              string  `json:"email" gorm:"column:email;type:varchar(255);uniqueIndex;not null"`
+	Username           *string `json:"username,omitempty" gorm:"column:username;type:varchar(50);uniqueIndex"`
+	PasswordHash       string  `json:"-" gorm:"column:password_hash;type:varchar(255);not null"`
+	PasswordResetToken *string `json:"-" gorm:"column:password_reset_token;type:varchar(255)"` // hash of the token's verifier
+	// Public half of the reset token, indexed so a reset finds its user with one lookup
+	PasswordResetSelector  *string    `json:"-" gorm:"column:password_reset_selector;type:varchar(64);uniqueIndex"`
 	PasswordResetExpiresAt *time.Time `json:"-" gorm:"column:password_reset_expires_at"`
-	LastPasswordChange     *time.Time `json:"last_password_change,omitempty" gorm:"column:last_password_change"`
+	// When the outstanding reset token was issued, for the outstanding-requests report
+	PasswordResetRequestedAt *time.Time `json:"-" gorm:"column:password_reset_requested_at"`
+	LastPasswordChange       *time.Time `json:"last_password_change,omitempty" gorm:"column:last_password_change"`
 
 	// Security fields
 	FailedLoginAttempts int        `json:"-" gorm:"column:failed_login_attempts;default:0"`
 	LockedUntil         *time.Time `json:"locked_until,omitempty" gorm:"column:locked_until"`
+	// Set when the employee sync deactivated the account because the employee left
+	AutoDeactivatedAt *time.Time `json:"auto_deactivated_at,omitempty" gorm:"column:auto_deactivated_at"`
 
 	IsActive    bool            `json:"is_active" gorm:"column:is_active;default:true"`
 	LastActive  *time.Time      `json:"last_active,omitempty" gorm:"column:last_active"`
@@ -82,6 +90,7 @@ type UserPosition struct {
 	SKNumber        *string    `json:"sk_number,omitempty" gorm:"column:sk_number;type:varchar(100)"`
 	Notes           *string    `json:"notes,omitempty" gorm:"type:text"`
 	PermissionScope *string    `json:"permission_scope,omitempty" gorm:"column:permission_scope;type:varchar(50)"`
+	EndReason       *string    `json:"end_reason,omitempty" gorm:"column:end_reason;type:varchar(50)"` // set when the system ends the assignment
 	CreatedAt       time.Time  `json:"created_at"`
 	UpdatedAt       time.Time  `json:"updated_at"`
 
@@ -188,6 +197,52 @@ type UserPositionResponse struct {
 	PermissionScope *string               `json:"permission_scope,omitempty"`
 }
 
+// Position assignment end reasons. UserDeactivated and Transferred are stored on the row;
+// the others are derived for rows ended without a recorded reason.
+const (
+	PositionEndReasonUserDeactivated = "user_deactivated"
+	PositionEndReasonTransferred     = "transferred" // moved to another user
+	PositionEndReasonTermEnded       = "term_ended"  // end_date reached
+	PositionEndReasonInactivated     = "inactivated" // marked inactive without an end date
+)
+
+// Position history statuses
+const (
+	PositionHistoryStatusActive    = "active"
+	PositionHistoryStatusScheduled = "scheduled"
+	PositionHistoryStatusEnded     = "ended"
+)
+
+// UserPositionHistoryEntry represents one position assignment, current or past
+type UserPositionHistoryEntry struct {
+	ID             string     `json:"id"`
+	PositionID     string     `json:"position_id"`
+	PositionCode   string     `json:"position_code"`
+	PositionName   string     `json:"position_name"`
+	DepartmentName *string    `json:"department_name,omitempty"`
+	SchoolName     *string    `json:"school_name,omitempty"`
+	StartDate      time.Time  `json:"start_date"`
+	EndDate        *time.Time `json:"end_date,omitempty"`
+	IsPlt          bool       `json:"is_plt"`
+	SKNumber       *string    `json:"sk_number,omitempty"`
+	Status         string     `json:"status"`
+	EndReason      *string    `json:"end_reason,omitempty"`
+}
+
+// PositionHistoryGap is a period between assignments in which the user held no position
+type PositionHistoryGap struct {
+	From  time.Time `json:"from"`
+	Until time.Time `json:"until"`
+	Days  int       `json:"days"`
+}
+
+// UserPositionHistoryResponse represents a user's full position history, oldest first
+type UserPositionHistoryResponse struct {
+	UserID      string                      `json:"user_id"`
+	Assignments []*UserPositionHistoryEntry `json:"assignments"`
+	Gaps        []PositionHistoryGap        `json:"gaps"`
+}
+
 // AssignRoleToUserRequest represents the request for assigning role to user
 type AssignRoleToUserRequest struct {
 	RoleID         string     `json:"role_id" binding:"required,len=36"`
@@ -195,6 +250,102 @@ type AssignRoleToUserRequest struct {
 	EffectiveUntil *time.Time `json:"effective_until,omitempty"`
 }
 
+// BulkAssignRolesToUserRequest represents the request for assigning several roles to a user at once
+type BulkAssignRolesToUserRequest struct {
+	Roles []AssignRoleToUserRequest `json:"roles" binding:"required,min=1,max=50,dive"`
+}
+
+// Bulk role assignment result statuses
+const (
+	BulkRoleAssignmentAssigned = "assigned"
+	BulkRoleAssignmentSkipped  = "skipped"
+	BulkRoleAssignmentFailed   = "failed"
+)
+
+// BulkRoleAssignmentResult represents the outcome of a single role in a bulk assignment
+type BulkRoleAssignmentResult struct {
+	RoleID     string            `json:"role_id"`
+	Status     string            `json:"status"`
+	Error      *string           `json:"error,omitempty"`
+	Assignment *UserRoleResponse `json:"assignment,omitempty"`
+}
+
+// MaxBulkDeactivateUsers caps how many users one bulk deactivation handles
+const MaxBulkDeactivateUsers = 500
+
+// BulkDeactivateUsersRequest represents the request for deactivating many users at once: either
+// the listed users, or every active user holding a position in the department and/or school
+type BulkDeactivateUsersRequest struct {
+	UserIDs      []string `json:"user_ids" binding:"omitempty,max=500,dive,len=36"`
+	DepartmentID *string  `json:"department_id" binding:"omitempty,len=36"`
+	SchoolID     *string  `json:"school_id" binding:"omitempty,len=36"`
+}
+
+// Bulk deactivation result statuses
+const (
+	BulkDeactivationDeactivated = "deactivated"
+	BulkDeactivationSkipped     = "skipped"
+	BulkDeactivationFailed      = "failed"
+)
+
+// BulkDeactivationResult represents the outcome for a single user in a bulk deactivation
+type BulkDeactivationResult struct {
+	UserID string  `json:"user_id"`
+	Status string  `json:"status"`
+	Error  *string `json:"error,omitempty"`
+}
+
+// BulkDeactivateUsersResponse represents the outcome of a bulk deactivation
+type BulkDeactivateUsersResponse struct {
+	Results     []BulkDeactivationResult `json:"results"`
+	Deactivated int                      `json:"deactivated"`
+	Skipped     int                      `json:"skipped"`
+	Failed      int                      `json:"failed"`
+	// Remaining is how many more active users match the filter beyond this request's cap;
+	// repeating the request continues with them
+	Remaining int64 `json:"remaining"`
+}
+
+// TransferUserAssignmentsRequest represents the request for moving a user's roles and positions to another user
+type TransferUserAssignmentsRequest struct {
+	TargetUserID string `json:"target_user_id" binding:"required,len=36"`
+}
+
+// Transfer item statuses
+const (
+	TransferItemTransferred = "transferred"  // ended on the source, created on the target
+	TransferItemAlreadyHeld = "already_held" // ended on the source; the target already had it
+)
+
+// TransferredRole represents one role assignment moved by a transfer
+type TransferredRole struct {
+	SourceAssignmentID string  `json:"source_assignment_id"`
+	TargetAssignmentID *string `json:"target_assignment_id,omitempty"`
+	RoleID             string  `json:"role_id"`
+	RoleCode           string  `json:"role_code"`
+	RoleName           string  `json:"role_name"`
+	Status             string  `json:"status"`
+}
+
+// TransferredPosition represents one position assignment moved by a transfer
+type TransferredPosition struct {
+	SourceAssignmentID string  `json:"source_assignment_id"`
+	TargetAssignmentID *string `json:"target_assignment_id,omitempty"`
+	PositionID         string  `json:"position_id"`
+	PositionCode       string  `json:"position_code"`
+	PositionName       string  `json:"position_name"`
+	Status             string  `json:"status"`
+}
+
+// UserAssignmentTransferResponse summarises a transfer of assignments between two users
+type UserAssignmentTransferResponse struct {
+	SourceUserID  string                `json:"source_user_id"`
+	TargetUserID  string                `json:"target_user_id"`
+	Roles         []TransferredRole     `json:"roles"`
+	Positions     []TransferredPosition `json:"positions"`
+	TransferredAt time.Time             `json:"transferred_at"`
+}
+
 // AssignPositionToUserRequest represents the request for assigning position to user
 type AssignPositionToUserRequest struct {
 	PositionID      string     `json:"position_id" binding:"required,len=36"`
@@ -264,6 +415,16 @@ func (up *UserPermission) ToResponse() *UserPermissionResponse {
 	return resp
 }
 
+// UserPermissionConflict is a pair of direct user permissions that match the same resource
+// and action with equal priority but opposite grants. The resolver lets the deny win the tie.
+type UserPermissionConflict struct {
+	Resource string                  `json:"resource"`
+	Action   PermissionAction        `json:"action"`
+	Priority int                     `json:"priority"`
+	Grant    *UserPermissionResponse `json:"grant"`
+	Deny     *UserPermissionResponse `json:"deny"`
+}
+
 // ToResponse converts User to UserResponse
 func (u *User) ToResponse() *UserResponse {
 	resp := &UserResponse{
@@ -381,7 +542,7 @@ func (ur *UserRole) IsEffective() bool {
 	if !ur.IsActive {
 		return false
 	}
-	now := time.Now()
+	now := clock.Now()
 	if now.Before(ur.EffectiveFrom) {
 		return false
 	}
@@ -396,7 +557,7 @@ func (up *UserPosition) IsEffective() bool {
 	if !up.IsActive {
 		return false
 	}
-	now := time.Now()
+	now := clock.Now()
 	if now.Before(up.StartDate) {
 		return false
 	}
@@ -408,7 +569,11 @@ func (up *UserPosition) IsEffective() bool {
 
 // IsEffective checks if the user permission is currently effective
 func (up *UserPermission) IsEffective() bool {
-	now := time.Now()
+	return up.IsEffectiveAt(clock.Now())
+}
+
+// IsEffectiveAt checks if the user permission grants access at now; both ends of its period are inclusive
+func (up *UserPermission) IsEffectiveAt(now time.Time) bool {
 	if now.Before(up.EffectiveFrom) {
 		return false
 	}
@@ -421,10 +586,11 @@ func (up *UserPermission) IsEffective() bool {
 // ToUserInfo converts User to UserInfo with optional DataKaryawan
 func (u *User) ToUserInfo() *UserInfo {
 	userInfo := &UserInfo{
-		ID:       u.ID,
-		Email:    u.Email,
-		Username: u.Username,
-		IsActive: u.IsActive,
+		ID:          u.ID,
+		Email:       u.Email,
+		Username:    u.Username,
+		IsActive:    u.IsActive,
+		Preferences: u.Preferences,
 	}
 
 	// Add DataKaryawan if present
@@ -466,6 +632,9 @@ type RefreshToken struct {
 	UserAgent     *string         `json:"user_agent,omitempty" gorm:"type:text"`
 	IPAddress     *string         `json:"ip_address,omitempty" gorm:"column:ip_address;type:varchar(45)"`
 	DeviceInfo    *datatypes.JSON `json:"device_info,omitempty" gorm:"column:device_info;type:jsonb"`
+	// LifetimeSeconds is the lifetime chosen at login (longer with remember me); rotations
+	// keep it. Zero on rows created before it was stored, meaning the default lifetime.
+	LifetimeSeconds int64 `json:"lifetime_seconds" gorm:"column:lifetime_seconds;not null;default:0"`
 
 	// Relations
 	User *User `json:"user,omitempty" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
@@ -488,7 +657,7 @@ func (rt *RefreshToken) IsValid() bool {
 type LoginAttempt struct {
 	ID            string     `json:"id" gorm:"type:varchar(36);primaryKey"`
 	Email         string     `json:"email" gorm:"column:email;type:varchar(255);not null;index"`
-	IPAddress     string     `json:"ip_address" gorm:"column:ip_address;type:varchar(45);not null"`
+	IPAddress     string     `json:"ip_address" gorm:"column:ip_address;type:varchar(45);not null;index"`
 	UserAgent     *string    `json:"user_agent,omitempty" gorm:"type:text"`
 	Success       bool       `json:"success" gorm:"column:success;not null;index"`
 	FailureReason *string    `json:"failure_reason,omitempty" gorm:"column:failure_reason;type:varchar(100)"`
@@ -500,6 +669,42 @@ func (LoginAttempt) TableName() string {
 	return "public.login_attempts"
 }
 
+// LoginAttemptFilter represents query parameters for listing login attempts
+type LoginAttemptFilter struct {
+	Email     *string    `form:"email"`
+	IPAddress *string    `form:"ip_address"`
+	Success   *bool      `form:"success"`
+	StartDate *time.Time `form:"start_date"`
+	EndDate   *time.Time `form:"end_date"`
+	Page      int        `form:"page,default=1"`
+	Limit     int        `form:"limit,default=50"`
+}
+
+// LoginAttemptSummaryFilter represents query parameters for the failed-login summary
+type LoginAttemptSummaryFilter struct {
+	WindowHours int `form:"window_hours,default=24"`
+	Limit       int `form:"limit,default=20"`
+}
+
+// LoginFailureCount is the number of failed logins for one IP address or email in a window.
+// DistinctTargets counts the emails tried from an IP, or the IPs an email was tried from.
+type LoginFailureCount struct {
+	Key             string    `json:"key"`
+	Failures        int64     `json:"failures"`
+	DistinctTargets int64     `json:"distinct_targets"`
+	LastAttemptAt   time.Time `json:"last_attempt_at"`
+}
+
+// LoginAttemptSummary aggregates failed logins over a window to spot brute-force patterns
+type LoginAttemptSummary struct {
+	WindowStart   time.Time           `json:"window_start"`
+	WindowEnd     time.Time           `json:"window_end"`
+	TotalAttempts int64               `json:"total_attempts"`
+	TotalFailures int64               `json:"total_failures"`
+	ByIP          []LoginFailureCount `json:"by_ip"`
+	ByEmail       []LoginFailureCount `json:"by_email"`
+}
+
 // Authentication DTOs
 
 // RegisterRequest represents the request body for user registration
@@ -511,8 +716,9 @@ type RegisterRequest struct {
 
 // LoginRequest represents the request body for user login
 type LoginRequest struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required"`
+	Email      string `json:"email" binding:"required,email"`
+	Password   string `json:"password" binding:"required"`
+	RememberMe bool   `json:"remember_me"`
 }
 
 // RefreshTokenRequest represents the request body for token refresh
@@ -520,6 +726,13 @@ type RefreshTokenRequest struct {
 	RefreshToken string `json:"refresh_token" binding:"required"`
 }
 
+// UpdateProfileRequest represents the request body for users updating their own profile.
+// Account status stays with admins (UpdateUserRequest).
+type UpdateProfileRequest struct {
+	Username    *string         `json:"username,omitempty" binding:"omitempty,min=3,max=50"`
+	Preferences *datatypes.JSON `json:"preferences,omitempty"`
+}
+
 // ChangePasswordRequest represents the request body for password change
 type ChangePasswordRequest struct {
 	CurrentPassword string `json:"current_password" binding:"required"`
@@ -541,7 +754,10 @@ type UserInfo struct {
 	Email        string                    `json:"email"`
 	Username     *string                   `json:"username,omitempty"`
 	IsActive     bool                      `json:"is_active"`
+	Preferences  *datatypes.JSON           `json:"preferences,omitempty"`
 	DataKaryawan *DataKaryawanInfoResponse `json:"data_karyawan,omitempty"`
+	// ImpersonatorID is set when an admin is acting as this user
+	ImpersonatorID *string `json:"impersonator_id,omitempty"`
 }
 
 // DataKaryawanInfoResponse represents simplified employee data for auth response
@@ -554,3 +770,37 @@ type DataKaryawanInfoResponse struct {
 	Jabatan       *string `json:"jabatan,omitempty"`
 	JenisKaryawan *string `json:"jenis_karyawan,omitempty"`
 }
+
+// PasswordResetRequestResponse represents an outstanding (unused, unexpired) password reset
+type PasswordResetRequestResponse struct {
+	UserID      string     `json:"user_id"`
+	Email       string     `json:"email"`
+	Username    *string    `json:"username,omitempty"`
+	RequestedAt *time.Time `json:"requested_at,omitempty"` // unknown for tokens issued before it was recorded
+	ExpiresAt   time.Time  `json:"expires_at"`
+}
+
+// UserActivityEvent type constants
+const (
+	UserActivityLoginSuccess      = "login_success"
+	UserActivityLoginFailed       = "login_failed"
+	UserActivitySessionCreated    = "session_created"
+	UserActivitySessionRevoked    = "session_revoked"
+	UserActivityAudit             = "audit"
+	UserActivityRoleAssigned      = "role_assigned"
+	UserActivityRoleEnded         = "role_ended"
+	UserActivityPositionAssigned  = "position_assigned"
+	UserActivityPositionEnded     = "position_ended"
+	UserActivityPermissionGranted = "permission_granted"
+	UserActivityPermissionEnded   = "permission_ended"
+	UserActivityPasswordChanged   = "password_changed"
+)
+
+// UserActivityEvent represents a single entry in a user's activity timeline
+type UserActivityEvent struct {
+	Type        string                 `json:"type"`
+	OccurredAt  time.Time              `json:"occurred_at"`
+	Description string                 `json:"description"`
+	ReferenceID *string                `json:"reference_id,omitempty"`
+	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+}
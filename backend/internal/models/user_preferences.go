@@ -0,0 +1,112 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gorm.io/datatypes"
+)
+
+// Preference value types
+const (
+	PreferenceTypeString  = "string"
+	PreferenceTypeBoolean = "boolean"
+)
+
+// UserPreferenceField describes a single allowed key in User.Preferences
+type UserPreferenceField struct {
+	Key         string      `json:"key"`
+	Type        string      `json:"type"`
+	Enum        []string    `json:"enum,omitempty"`
+	Default     interface{} `json:"default"`
+	Description string      `json:"description"`
+}
+
+// UserPreferenceFields is the central list of allowed preference keys.
+// Add an entry here to allow a new key; anything not listed is rejected.
+var UserPreferenceFields = []UserPreferenceField{
+	{
+		Key:         "theme",
+		Type:        PreferenceTypeString,
+		Enum:        []string{"light", "dark", "system"},
+		Default:     "system",
+		Description: "Tema tampilan aplikasi",
+	},
+	{
+		Key:         "language",
+		Type:        PreferenceTypeString,
+		Enum:        []string{"id", "en"},
+		Default:     "id",
+		Description: "Bahasa antarmuka",
+	},
+	{
+		Key:         "notifications_enabled",
+		Type:        PreferenceTypeBoolean,
+		Default:     true,
+		Description: "Aktifkan notifikasi",
+	},
+	{
+		Key:         "dashboard_layout",
+		Type:        PreferenceTypeString,
+		Enum:        []string{"grid", "list", "compact"},
+		Default:     "grid",
+		Description: "Tata letak dashboard",
+	},
+}
+
+// ValidateUserPreferences checks that preferences is a JSON object containing only
+// keys from UserPreferenceFields with values of the declared type
+func ValidateUserPreferences(preferences datatypes.JSON) error {
+	var values map[string]interface{}
+	if err := json.Unmarshal(preferences, &values); err != nil {
+		return fmt.Errorf("preferensi harus berupa objek JSON")
+	}
+
+	fields := make(map[string]UserPreferenceField, len(UserPreferenceFields))
+	for _, field := range UserPreferenceFields {
+		fields[field.Key] = field
+	}
+
+	// Sort keys so the reported error is deterministic
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		field, ok := fields[key]
+		if !ok {
+			return fmt.Errorf("preferensi '%s' tidak dikenal", key)
+		}
+
+		switch field.Type {
+		case PreferenceTypeBoolean:
+			if _, ok := values[key].(bool); !ok {
+				return fmt.Errorf("preferensi '%s' harus bernilai boolean", key)
+			}
+		case PreferenceTypeString:
+			value, ok := values[key].(string)
+			if !ok {
+				return fmt.Errorf("preferensi '%s' harus bernilai string", key)
+			}
+			if len(field.Enum) > 0 && !containsString(field.Enum, value) {
+				return fmt.Errorf("preferensi '%s' harus salah satu dari: %s", key, strings.Join(field.Enum, ", "))
+			}
+		}
+	}
+
+	return nil
+}
+
+// containsString checks whether value is in list
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
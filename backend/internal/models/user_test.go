@@ -0,0 +1,56 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"backend/internal/clock"
+)
+
+// TestUserPermissionExpiringEndOfLocalDay grants access until the end of 6 March in
+// Jakarta (UTC+7) while the clock is read in UTC: the grant must hold until local
+// midnight and not a moment past it, which is seven hours before UTC midnight.
+func TestUserPermissionExpiringEndOfLocalDay(t *testing.T) {
+	previous := clock.Location()
+	if err := clock.SetTimezone("Asia/Jakarta"); err != nil {
+		t.Fatalf("SetTimezone: %v", err)
+	}
+	t.Cleanup(func() { clock.SetTimezone(previous.String()) })
+
+	lastDay, err := clock.ParseDate("2026-03-06")
+	if err != nil {
+		t.Fatalf("ParseDate: %v", err)
+	}
+	until := clock.EndOfDay(lastDay)
+	grant := UserPermission{
+		IsGranted:      true,
+		EffectiveFrom:  time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+		EffectiveUntil: &until,
+	}
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want bool
+	}{
+		{"morning of the last day", time.Date(2026, 3, 6, 1, 0, 0, 0, time.UTC), true},
+		{"last second of the local day", time.Date(2026, 3, 6, 16, 59, 59, 0, time.UTC), true},
+		{"last instant of the local day", time.Date(2026, 3, 6, 16, 59, 59, 999999999, time.UTC), true},
+		{"local midnight", time.Date(2026, 3, 6, 17, 0, 0, 0, time.UTC), false},
+		{"still 6 March in UTC", time.Date(2026, 3, 6, 20, 0, 0, 0, time.UTC), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := grant.IsEffectiveAt(tt.at); got != tt.want {
+				t.Errorf("IsEffectiveAt(%s) = %v, want %v", tt.at, got, tt.want)
+			}
+		})
+	}
+
+	denied := grant
+	denied.IsGranted = false
+	if denied.IsEffectiveAt(time.Date(2026, 3, 6, 1, 0, 0, 0, time.UTC)) {
+		t.Error("a deny row inside its period reported as effective")
+	}
+}
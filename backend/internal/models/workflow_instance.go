@@ -0,0 +1,274 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// WorkflowInstance represents a running approval process created from a WorkflowRule
+type WorkflowInstance struct {
+	ID               string          `json:"id" gorm:"type:varchar(36);primaryKey"`
+	WorkflowRuleID   string          `json:"workflow_rule_id" gorm:"column:workflow_rule_id;type:varchar(36);not null;index"`
+	RuleVersion      int             `json:"rule_version" gorm:"column:rule_version;not null;default:1"`
+	WorkflowType     string          `json:"workflow_type" gorm:"column:workflow_type;type:varchar(50);not null;index"`
+	PositionID       string          `json:"position_id" gorm:"column:position_id;type:varchar(36);not null;index"`
+	InitiatorUserID  string          `json:"initiator_user_id" gorm:"column:initiator_user_id;type:varchar(36);not null;index"`
+	Status           string          `json:"status" gorm:"type:varchar(20);not null;index"`
+	CurrentStepGroup *int            `json:"current_step_group,omitempty" gorm:"column:current_step_group"`
+	RejectionReason  *string         `json:"rejection_reason,omitempty" gorm:"column:rejection_reason;type:text"`
+	Payload          *datatypes.JSON `json:"payload,omitempty" gorm:"type:jsonb"`
+	StartedAt        time.Time       `json:"started_at" gorm:"column:started_at;not null;default:CURRENT_TIMESTAMP"`
+	CompletedAt      *time.Time      `json:"completed_at,omitempty" gorm:"column:completed_at"`
+	CreatedAt        time.Time       `json:"created_at"`
+	UpdatedAt        time.Time       `json:"updated_at"`
+
+	// Relations
+	WorkflowRule *WorkflowRule          `json:"-" gorm:"foreignKey:WorkflowRuleID"`
+	Position     *Position              `json:"position,omitempty" gorm:"foreignKey:PositionID"`
+	Initiator    *User                  `json:"initiator,omitempty" gorm:"foreignKey:InitiatorUserID"`
+	Steps        []WorkflowInstanceStep `json:"steps,omitempty" gorm:"foreignKey:WorkflowInstanceID;constraint:OnDelete:CASCADE"`
+}
+
+// TableName specifies the table name for WorkflowInstance
+func (WorkflowInstance) TableName() string {
+	return "public.workflow_instances"
+}
+
+// WorkflowInstanceStep is a per-instance snapshot of a WorkflowRuleStep
+type WorkflowInstanceStep struct {
+	ID                 string     `json:"id" gorm:"type:varchar(36);primaryKey"`
+	WorkflowInstanceID string     `json:"workflow_instance_id" gorm:"column:workflow_instance_id;type:varchar(36);not null;index"`
+	WorkflowRuleStepID *string    `json:"workflow_rule_step_id,omitempty" gorm:"column:workflow_rule_step_id;type:varchar(36)"`
+	StepOrder          int        `json:"step_order" gorm:"column:step_order;not null"`
+	ApproverPositionID string     `json:"approver_position_id" gorm:"column:approver_position_id;type:varchar(36);not null"`
+	StepName           *string    `json:"step_name,omitempty" gorm:"column:step_name;type:varchar(100)"`
+	IsOptional         bool       `json:"is_optional" gorm:"column:is_optional;default:false"`
+	StepGroup          int        `json:"step_group" gorm:"column:step_group;not null;default:0"`
+	ApprovalMode       string     `json:"approval_mode" gorm:"column:approval_mode;type:varchar(10);default:all"`
+	TimeoutHours       *int       `json:"timeout_hours,omitempty" gorm:"column:timeout_hours"`
+	TimeoutAction      string     `json:"timeout_action" gorm:"column:timeout_action;type:varchar(20);default:escalate"`
+	EscalationCount    int        `json:"escalation_count" gorm:"column:escalation_count;default:0"`
+	Status             string     `json:"status" gorm:"type:varchar(20);not null;index"`
+	ActivatedAt        *time.Time `json:"activated_at,omitempty" gorm:"column:activated_at"`
+	ActedBy            *string    `json:"acted_by,omitempty" gorm:"column:acted_by;type:varchar(36)"`
+	ActedAt            *time.Time `json:"acted_at,omitempty" gorm:"column:acted_at"`
+	OnBehalfOfUserID   *string    `json:"on_behalf_of_user_id,omitempty" gorm:"column:on_behalf_of_user_id;type:varchar(36)"`
+	Comment            *string    `json:"comment,omitempty" gorm:"column:comment;type:text"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+
+	// Relations
+	WorkflowInstance *WorkflowInstance             `json:"-" gorm:"foreignKey:WorkflowInstanceID"`
+	ApproverPosition *Position                     `json:"approver_position,omitempty" gorm:"foreignKey:ApproverPositionID"`
+	Actor            *User                         `json:"actor,omitempty" gorm:"foreignKey:ActedBy"`
+	OnBehalfOf       *User                         `json:"on_behalf_of,omitempty" gorm:"foreignKey:OnBehalfOfUserID"`
+	History          []WorkflowInstanceStepHistory `json:"history,omitempty" gorm:"foreignKey:WorkflowInstanceStepID;constraint:OnDelete:CASCADE"`
+}
+
+// TableName specifies the table name for WorkflowInstanceStep
+func (WorkflowInstanceStep) TableName() string {
+	return "public.workflow_instance_steps"
+}
+
+// WorkflowInstanceStepHistory records system actions taken on a step, such as SLA escalations
+type WorkflowInstanceStepHistory struct {
+	ID                     string    `json:"id" gorm:"type:varchar(36);primaryKey"`
+	WorkflowInstanceStepID string    `json:"workflow_instance_step_id" gorm:"column:workflow_instance_step_id;type:varchar(36);not null;index"`
+	Action                 string    `json:"action" gorm:"type:varchar(30);not null"`
+	FromPositionID         *string   `json:"from_position_id,omitempty" gorm:"column:from_position_id;type:varchar(36)"`
+	ToPositionID           *string   `json:"to_position_id,omitempty" gorm:"column:to_position_id;type:varchar(36)"`
+	Note                   *string   `json:"note,omitempty" gorm:"type:text"`
+	CreatedAt              time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name for WorkflowInstanceStepHistory
+func (WorkflowInstanceStepHistory) TableName() string {
+	return "public.workflow_instance_step_histories"
+}
+
+// WorkflowInstanceStepHistory action constants
+const (
+	WorkflowStepHistoryEscalated        = "ESCALATED"
+	WorkflowStepHistoryEscalationFailed = "ESCALATION_FAILED"
+	WorkflowStepHistoryAutoApproved     = "AUTO_APPROVED"
+	WorkflowStepHistoryAutoRejected     = "AUTO_REJECTED"
+)
+
+// WorkflowInstance status constants
+const (
+	WorkflowInstanceStatusPending  = "PENDING"
+	WorkflowInstanceStatusApproved = "APPROVED"
+	WorkflowInstanceStatusRejected = "REJECTED"
+)
+
+// WorkflowInstanceStep status constants
+const (
+	WorkflowStepStatusWaiting  = "WAITING"
+	WorkflowStepStatusPending  = "PENDING"
+	WorkflowStepStatusApproved = "APPROVED"
+	WorkflowStepStatusRejected = "REJECTED"
+	WorkflowStepStatusSkipped  = "SKIPPED"
+)
+
+// StartWorkflowInstanceRequest represents the request body for starting a workflow instance
+type StartWorkflowInstanceRequest struct {
+	WorkflowType string          `json:"workflow_type" binding:"required,max=50"`
+	PositionID   string          `json:"position_id" binding:"required,len=36"`
+	Payload      *datatypes.JSON `json:"payload,omitempty"`
+}
+
+// ApproveWorkflowStepRequest represents the request body for approving the current step
+type ApproveWorkflowStepRequest struct {
+	Comment *string `json:"comment,omitempty"`
+}
+
+// RejectWorkflowStepRequest represents the request body for rejecting the current step
+type RejectWorkflowStepRequest struct {
+	Reason string `json:"reason" binding:"required,min=5"`
+}
+
+// WorkflowInstanceStepResponse represents a step in the workflow instance response
+type WorkflowInstanceStepResponse struct {
+	ID                   string                        `json:"id"`
+	StepOrder            int                           `json:"step_order"`
+	ApproverPositionID   string                        `json:"approver_position_id"`
+	ApproverPosition     *PositionListResponse         `json:"approver_position,omitempty"`
+	ApproverPositionName *string                       `json:"approver_position_name,omitempty"`
+	StepName             *string                       `json:"step_name,omitempty"`
+	IsOptional           bool                          `json:"is_optional"`
+	StepGroup            int                           `json:"step_group"`
+	ApprovalMode         string                        `json:"approval_mode"`
+	TimeoutHours         *int                          `json:"timeout_hours,omitempty"`
+	TimeoutAction        *string                       `json:"timeout_action,omitempty"`
+	EscalationCount      int                           `json:"escalation_count"`
+	Status               string                        `json:"status"`
+	ActivatedAt          *time.Time                    `json:"activated_at,omitempty"`
+	ActedBy              *string                       `json:"acted_by,omitempty"`
+	ActedByName          *string                       `json:"acted_by_name,omitempty"`
+	ActedAt              *time.Time                    `json:"acted_at,omitempty"`
+	OnBehalfOfUserID     *string                       `json:"on_behalf_of_user_id,omitempty"`
+	OnBehalfOfName       *string                       `json:"on_behalf_of_name,omitempty"`
+	ActedOnBehalfNote    *string                       `json:"acted_on_behalf_note,omitempty"`
+	Comment              *string                       `json:"comment,omitempty"`
+	History              []WorkflowInstanceStepHistory `json:"history,omitempty"`
+}
+
+// WorkflowInstanceResponse represents the response body for workflow instance data
+type WorkflowInstanceResponse struct {
+	ID               string                         `json:"id"`
+	WorkflowRuleID   string                         `json:"workflow_rule_id"`
+	RuleVersion      int                            `json:"rule_version"`
+	WorkflowType     string                         `json:"workflow_type"`
+	PositionID       string                         `json:"position_id"`
+	Position         *PositionListResponse          `json:"position,omitempty"`
+	InitiatorUserID  string                         `json:"initiator_user_id"`
+	Initiator        *UserListResponse              `json:"initiator,omitempty"`
+	Status           string                         `json:"status"`
+	CurrentStepGroup *int                           `json:"current_step_group,omitempty"`
+	RejectionReason  *string                        `json:"rejection_reason,omitempty"`
+	Payload          *datatypes.JSON                `json:"payload,omitempty"`
+	StartedAt        time.Time                      `json:"started_at"`
+	CompletedAt      *time.Time                     `json:"completed_at,omitempty"`
+	CreatedAt        time.Time                      `json:"created_at"`
+	UpdatedAt        time.Time                      `json:"updated_at"`
+	Steps            []WorkflowInstanceStepResponse `json:"steps,omitempty"`
+	TotalSteps       int                            `json:"total_steps"`
+}
+
+// ToStepResponse converts WorkflowInstanceStep to WorkflowInstanceStepResponse
+func (s *WorkflowInstanceStep) ToStepResponse() *WorkflowInstanceStepResponse {
+	resp := &WorkflowInstanceStepResponse{
+		ID:                 s.ID,
+		StepOrder:          s.StepOrder,
+		ApproverPositionID: s.ApproverPositionID,
+		StepName:           s.StepName,
+		IsOptional:         s.IsOptional,
+		StepGroup:          s.StepGroup,
+		ApprovalMode:       s.ApprovalMode,
+		EscalationCount:    s.EscalationCount,
+		Status:             s.Status,
+		ActivatedAt:        s.ActivatedAt,
+		ActedBy:            s.ActedBy,
+		ActedAt:            s.ActedAt,
+		OnBehalfOfUserID:   s.OnBehalfOfUserID,
+		Comment:            s.Comment,
+		History:            s.History,
+	}
+
+	if s.TimeoutHours != nil {
+		resp.TimeoutHours = s.TimeoutHours
+		action := s.TimeoutAction
+		resp.TimeoutAction = &action
+	}
+
+	if s.ApproverPosition != nil {
+		resp.ApproverPosition = s.ApproverPosition.ToListResponse()
+		resp.ApproverPositionName = &s.ApproverPosition.Name
+	}
+
+	if s.Actor != nil {
+		resp.ActedByName = displayName(s.Actor)
+	}
+
+	// Actions taken through a delegation are recorded "atas nama" the delegator
+	if s.OnBehalfOf != nil {
+		resp.OnBehalfOfName = displayName(s.OnBehalfOf)
+		note := "atas nama " + *resp.OnBehalfOfName
+		resp.ActedOnBehalfNote = &note
+	}
+
+	return resp
+}
+
+// ToResponse converts WorkflowInstance to WorkflowInstanceResponse
+func (w *WorkflowInstance) ToResponse() *WorkflowInstanceResponse {
+	resp := &WorkflowInstanceResponse{
+		ID:               w.ID,
+		WorkflowRuleID:   w.WorkflowRuleID,
+		RuleVersion:      w.RuleVersion,
+		WorkflowType:     w.WorkflowType,
+		PositionID:       w.PositionID,
+		InitiatorUserID:  w.InitiatorUserID,
+		Status:           w.Status,
+		CurrentStepGroup: w.CurrentStepGroup,
+		RejectionReason:  w.RejectionReason,
+		Payload:          w.Payload,
+		StartedAt:        w.StartedAt,
+		CompletedAt:      w.CompletedAt,
+		CreatedAt:        w.CreatedAt,
+		UpdatedAt:        w.UpdatedAt,
+		TotalSteps:       len(w.Steps),
+	}
+
+	if w.Position != nil {
+		resp.Position = w.Position.ToListResponse()
+	}
+
+	if w.Initiator != nil {
+		resp.Initiator = w.Initiator.ToListResponse()
+	}
+
+	if len(w.Steps) > 0 {
+		resp.Steps = make([]WorkflowInstanceStepResponse, len(w.Steps))
+		for i, step := range w.Steps {
+			resp.Steps[i] = *step.ToStepResponse()
+		}
+	}
+
+	return resp
+}
+
+// IsComplete checks if the workflow instance has reached a terminal status
+func (w *WorkflowInstance) IsComplete() bool {
+	return w.Status == WorkflowInstanceStatusApproved || w.Status == WorkflowInstanceStatusRejected
+}
+
+// displayName returns the username of a user, falling back to the email address
+func displayName(u *User) *string {
+	if u.Username != nil {
+		return u.Username
+	}
+	email := u.Email
+	return &email
+}
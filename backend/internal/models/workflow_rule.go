@@ -14,6 +14,7 @@ type WorkflowRule struct {
 	Description       *string   `json:"description,omitempty" gorm:"column:description;type:text"`
 	Priority          int       `json:"priority" gorm:"column:priority;default:1"`
 	IsActive          bool      `json:"is_active" gorm:"column:is_active;default:true"`
+	Version           int       `json:"version" gorm:"column:version;not null;default:1"`
 	CreatedAt         time.Time `json:"created_at"`
 	UpdatedAt         time.Time `json:"updated_at"`
 	CreatedBy         *string   `json:"created_by,omitempty" gorm:"column:created_by;type:varchar(36)"`
@@ -35,10 +36,15 @@ func (WorkflowRule) TableName() string {
 type WorkflowRuleStep struct {
 	ID                 string    `json:"id" gorm:"type:varchar(36);primaryKey"`
 	WorkflowRuleID     string    `json:"workflow_rule_id" gorm:"column:workflow_rule_id;type:varchar(36);not null;index"`
+	RuleVersion        int       `json:"rule_version" gorm:"column:rule_version;not null;default:1;index"`
 	StepOrder          int       `json:"step_order" gorm:"column:step_order;not null"`
 	ApproverPositionID string    `json:"approver_position_id" gorm:"column:approver_position_id;type:varchar(36);not null"`
 	StepName           *string   `json:"step_name,omitempty" gorm:"column:step_name;type:varchar(100)"`
 	IsOptional         bool      `json:"is_optional" gorm:"column:is_optional;default:false"`
+	StepGroup          *int      `json:"step_group,omitempty" gorm:"column:step_group"`
+	ApprovalMode       string    `json:"approval_mode" gorm:"column:approval_mode;type:varchar(10);default:all"`
+	TimeoutHours       *int      `json:"timeout_hours,omitempty" gorm:"column:timeout_hours"`
+	TimeoutAction      string    `json:"timeout_action" gorm:"column:timeout_action;type:varchar(20);default:escalate"`
 	CreatedAt          time.Time `json:"created_at"`
 	UpdatedAt          time.Time `json:"updated_at"`
 
@@ -52,6 +58,64 @@ func (WorkflowRuleStep) TableName() string {
 	return "public.workflow_rule_steps"
 }
 
+// WorkflowRuleVersion is a snapshot of a workflow rule's settings at a given version.
+// Steps of every version are kept in workflow_rule_steps keyed by rule_version.
+type WorkflowRuleVersion struct {
+	ID                string    `json:"id" gorm:"type:varchar(36);primaryKey"`
+	WorkflowRuleID    string    `json:"workflow_rule_id" gorm:"column:workflow_rule_id;type:varchar(36);not null;uniqueIndex:idx_workflow_rule_version"`
+	Version           int       `json:"version" gorm:"column:version;not null;uniqueIndex:idx_workflow_rule_version"`
+	WorkflowType      string    `json:"workflow_type" gorm:"column:workflow_type;type:varchar(50);not null"`
+	PositionID        string    `json:"position_id" gorm:"column:position_id;type:varchar(36);not null"`
+	SchoolID          *string   `json:"school_id,omitempty" gorm:"column:school_id;type:varchar(36)"`
+	CreatorPositionID *string   `json:"creator_position_id,omitempty" gorm:"column:creator_position_id;type:varchar(36)"`
+	Description       *string   `json:"description,omitempty" gorm:"column:description;type:text"`
+	Priority          int       `json:"priority" gorm:"column:priority"`
+	IsActive          bool      `json:"is_active" gorm:"column:is_active"`
+	CreatedAt         time.Time `json:"created_at"`
+	CreatedBy         *string   `json:"created_by,omitempty" gorm:"column:created_by;type:varchar(36)"`
+
+	// Relations
+	WorkflowRule *WorkflowRule `json:"-" gorm:"foreignKey:WorkflowRuleID;constraint:OnDelete:CASCADE"`
+}
+
+// TableName specifies the table name for WorkflowRuleVersion
+func (WorkflowRuleVersion) TableName() string {
+	return "public.workflow_rule_versions"
+}
+
+// EffectiveStepGroup returns the parallel group of the step.
+// Steps without an explicit group form their own group keyed by StepOrder, so that order
+// must not also be used as an explicit group number.
+func (s *WorkflowRuleStep) EffectiveStepGroup() int {
+	if s.StepGroup != nil {
+		return *s.StepGroup
+	}
+	return s.StepOrder
+}
+
+// ApprovalMode constants for parallel step groups
+const (
+	ApprovalModeAll = "all" // every approver in the group must approve
+	ApprovalModeAny = "any" // one approval completes the group
+)
+
+// IsValidApprovalMode checks if the approval mode is supported
+func IsValidApprovalMode(mode string) bool {
+	return mode == ApprovalModeAll || mode == ApprovalModeAny
+}
+
+// TimeoutAction constants define what happens when a step exceeds its TimeoutHours
+const (
+	TimeoutActionEscalate    = "escalate"     // reassign the step to the approver's parent position
+	TimeoutActionAutoApprove = "auto_approve" // approve the step on behalf of the approver
+	TimeoutActionAutoReject  = "auto_reject"  // reject the step and terminate the instance
+)
+
+// IsValidTimeoutAction checks if the timeout action is supported
+func IsValidTimeoutAction(action string) bool {
+	return action == TimeoutActionEscalate || action == TimeoutActionAutoApprove || action == TimeoutActionAutoReject
+}
+
 // WorkflowType constants
 const (
 	WorkflowTypeKPI       = "KPI"
@@ -80,6 +144,10 @@ type CreateWorkflowRuleStepRequest struct {
 	ApproverPositionID string  `json:"approver_position_id" binding:"required,len=36"`
 	StepName           *string `json:"step_name,omitempty" binding:"omitempty,max=100"`
 	IsOptional         *bool   `json:"is_optional,omitempty"`
+	StepGroup          *int    `json:"step_group,omitempty" binding:"omitempty,min=1"`
+	ApprovalMode       *string `json:"approval_mode,omitempty" binding:"omitempty,oneof=all any"`
+	TimeoutHours       *int    `json:"timeout_hours,omitempty" binding:"omitempty,min=1"`
+	TimeoutAction      *string `json:"timeout_action,omitempty" binding:"omitempty,oneof=escalate auto_approve auto_reject"`
 }
 
 // CreateWorkflowRuleRequest represents the request body for creating a workflow rule
@@ -91,6 +159,11 @@ type CreateWorkflowRuleRequest struct {
 	Description       *string                         `json:"description,omitempty"`
 	Priority          *int                            `json:"priority,omitempty" binding:"omitempty,min=1,max=100"`
 	Steps             []CreateWorkflowRuleStepRequest `json:"steps,omitempty" binding:"omitempty,dive"`
+	// NormalizeStepOrder rewrites step orders to 1..N instead of rejecting duplicates.
+	// Not allowed together with an explicit step_group.
+	NormalizeStepOrder bool `json:"normalize_step_order,omitempty"`
+	// RequireContiguousStepOrder rejects gaps: step orders must run 1..N
+	RequireContiguousStepOrder bool `json:"require_contiguous_step_order,omitempty"`
 }
 
 // UpdateWorkflowRuleStepRequest represents a step in the update request
@@ -100,6 +173,24 @@ type UpdateWorkflowRuleStepRequest struct {
 	ApproverPositionID string  `json:"approver_position_id" binding:"required,len=36"`
 	StepName           *string `json:"step_name,omitempty" binding:"omitempty,max=100"`
 	IsOptional         *bool   `json:"is_optional,omitempty"`
+	StepGroup          *int    `json:"step_group,omitempty" binding:"omitempty,min=1"`
+	ApprovalMode       *string `json:"approval_mode,omitempty" binding:"omitempty,oneof=all any"`
+	TimeoutHours       *int    `json:"timeout_hours,omitempty" binding:"omitempty,min=1"`
+	TimeoutAction      *string `json:"timeout_action,omitempty" binding:"omitempty,oneof=escalate auto_approve auto_reject"`
+}
+
+// ToCreateRequest converts the step fields of an update request into a create step request
+func (r *UpdateWorkflowRuleStepRequest) ToCreateRequest() CreateWorkflowRuleStepRequest {
+	return CreateWorkflowRuleStepRequest{
+		StepOrder:          r.StepOrder,
+		ApproverPositionID: r.ApproverPositionID,
+		StepName:           r.StepName,
+		IsOptional:         r.IsOptional,
+		StepGroup:          r.StepGroup,
+		ApprovalMode:       r.ApprovalMode,
+		TimeoutHours:       r.TimeoutHours,
+		TimeoutAction:      r.TimeoutAction,
+	}
 }
 
 // UpdateWorkflowRuleRequest represents the request body for updating a workflow rule
@@ -112,6 +203,11 @@ type UpdateWorkflowRuleRequest struct {
 	Priority          *int                            `json:"priority,omitempty" binding:"omitempty,min=1,max=100"`
 	IsActive          *bool                           `json:"is_active,omitempty"`
 	Steps             []UpdateWorkflowRuleStepRequest `json:"steps,omitempty" binding:"omitempty,dive"`
+	// NormalizeStepOrder rewrites step orders to 1..N instead of rejecting duplicates.
+	// Not allowed together with an explicit step_group.
+	NormalizeStepOrder bool `json:"normalize_step_order,omitempty"`
+	// RequireContiguousStepOrder rejects gaps: step orders must run 1..N
+	RequireContiguousStepOrder bool `json:"require_contiguous_step_order,omitempty"`
 }
 
 // WorkflowRuleStepResponse represents a step in the response
@@ -123,6 +219,42 @@ type WorkflowRuleStepResponse struct {
 	ApproverPositionName *string               `json:"approver_position_name,omitempty"`
 	StepName             *string               `json:"step_name,omitempty"`
 	IsOptional           bool                  `json:"is_optional"`
+	StepGroup            int                   `json:"step_group"`
+	ApprovalMode         string                `json:"approval_mode"`
+	TimeoutHours         *int                  `json:"timeout_hours,omitempty"`
+	TimeoutAction        *string               `json:"timeout_action,omitempty"`
+}
+
+// WorkflowApprover represents a user currently holding an approver position
+type WorkflowApprover struct {
+	UserID   string  `json:"user_id"`
+	Username *string `json:"username,omitempty"`
+	Name     *string `json:"name,omitempty"`
+	IsPlt    bool    `json:"is_plt"`
+}
+
+// WorkflowApprovalChainStep represents a rule step with the users who would approve it now
+type WorkflowApprovalChainStep struct {
+	WorkflowRuleStepResponse
+	Approvers []WorkflowApprover `json:"approvers"`
+}
+
+// WorkflowApprovalGroupResponse represents a group of steps that are approved in parallel
+type WorkflowApprovalGroupResponse struct {
+	StepGroup    int                         `json:"step_group"`
+	ApprovalMode string                      `json:"approval_mode"`
+	Steps        []WorkflowApprovalChainStep `json:"steps"`
+}
+
+// WorkflowApprovalChainResponse represents the approval chain a new request would follow.
+// RuleFound is false, with no groups, when no active rule matches.
+type WorkflowApprovalChainResponse struct {
+	PositionID   string                          `json:"position_id"`
+	WorkflowType string                          `json:"workflow_type"`
+	RuleFound    bool                            `json:"rule_found"`
+	RuleID       *string                         `json:"rule_id,omitempty"`
+	RuleVersion  *int                            `json:"rule_version,omitempty"`
+	Groups       []WorkflowApprovalGroupResponse `json:"groups"`
 }
 
 // WorkflowRuleResponse represents the response body for workflow rule data
@@ -138,6 +270,7 @@ type WorkflowRuleResponse struct {
 	Description       *string                    `json:"description,omitempty"`
 	Priority          int                        `json:"priority"`
 	IsActive          bool                       `json:"is_active"`
+	Version           int                        `json:"version"`
 	CreatedAt         time.Time                  `json:"created_at"`
 	UpdatedAt         time.Time                  `json:"updated_at"`
 	CreatedBy         *string                    `json:"created_by,omitempty"`
@@ -159,9 +292,27 @@ type WorkflowRuleListResponse struct {
 	Description         *string `json:"description,omitempty"`
 	Priority            int     `json:"priority"`
 	IsActive            bool    `json:"is_active"`
+	Version             int     `json:"version"`
 	TotalSteps          int     `json:"total_steps"`
 }
 
+// WorkflowRuleVersionResponse represents a historical version of a workflow rule
+type WorkflowRuleVersionResponse struct {
+	Version           int                        `json:"version"`
+	IsCurrent         bool                       `json:"is_current"`
+	WorkflowType      string                     `json:"workflow_type"`
+	PositionID        string                     `json:"position_id"`
+	SchoolID          *string                    `json:"school_id,omitempty"`
+	CreatorPositionID *string                    `json:"creator_position_id,omitempty"`
+	Description       *string                    `json:"description,omitempty"`
+	Priority          int                        `json:"priority"`
+	IsActive          bool                       `json:"is_active"`
+	CreatedAt         time.Time                  `json:"created_at"`
+	CreatedBy         *string                    `json:"created_by,omitempty"`
+	Steps             []WorkflowRuleStepResponse `json:"steps"`
+	TotalSteps        int                        `json:"total_steps"`
+}
+
 // ToStepResponse converts WorkflowRuleStep to WorkflowRuleStepResponse
 func (s *WorkflowRuleStep) ToStepResponse() *WorkflowRuleStepResponse {
 	resp := &WorkflowRuleStepResponse{
@@ -170,6 +321,22 @@ func (s *WorkflowRuleStep) ToStepResponse() *WorkflowRuleStepResponse {
 		ApproverPositionID: s.ApproverPositionID,
 		StepName:           s.StepName,
 		IsOptional:         s.IsOptional,
+		StepGroup:          s.EffectiveStepGroup(),
+		ApprovalMode:       s.ApprovalMode,
+	}
+
+	if resp.ApprovalMode == "" {
+		resp.ApprovalMode = ApprovalModeAll
+	}
+
+	// Timeout action is only meaningful when a timeout is configured
+	if s.TimeoutHours != nil {
+		resp.TimeoutHours = s.TimeoutHours
+		action := s.TimeoutAction
+		if action == "" {
+			action = TimeoutActionEscalate
+		}
+		resp.TimeoutAction = &action
 	}
 
 	if s.ApproverPosition != nil {
@@ -191,6 +358,7 @@ func (w *WorkflowRule) ToResponse() *WorkflowRuleResponse {
 		Description:       w.Description,
 		Priority:          w.Priority,
 		IsActive:          w.IsActive,
+		Version:           w.Version,
 		CreatedAt:         w.CreatedAt,
 		UpdatedAt:         w.UpdatedAt,
 		CreatedBy:         w.CreatedBy,
@@ -232,6 +400,7 @@ func (w *WorkflowRule) ToListResponse() *WorkflowRuleListResponse {
 		Description:       w.Description,
 		Priority:          w.Priority,
 		IsActive:          w.IsActive,
+		Version:           w.Version,
 		TotalSteps:        len(w.Steps),
 	}
 
@@ -249,3 +418,28 @@ func (w *WorkflowRule) ToListResponse() *WorkflowRuleListResponse {
 
 	return resp
 }
+
+// ToVersionResponse converts WorkflowRuleVersion to WorkflowRuleVersionResponse with the given steps
+func (v *WorkflowRuleVersion) ToVersionResponse(steps []WorkflowRuleStep, currentVersion int) *WorkflowRuleVersionResponse {
+	resp := &WorkflowRuleVersionResponse{
+		Version:           v.Version,
+		IsCurrent:         v.Version == currentVersion,
+		WorkflowType:      v.WorkflowType,
+		PositionID:        v.PositionID,
+		SchoolID:          v.SchoolID,
+		CreatorPositionID: v.CreatorPositionID,
+		Description:       v.Description,
+		Priority:          v.Priority,
+		IsActive:          v.IsActive,
+		CreatedAt:         v.CreatedAt,
+		CreatedBy:         v.CreatedBy,
+		Steps:             make([]WorkflowRuleStepResponse, len(steps)),
+		TotalSteps:        len(steps),
+	}
+
+	for i, step := range steps {
+		resp.Steps[i] = *step.ToStepResponse()
+	}
+
+	return resp
+}
@@ -0,0 +1,247 @@
+// Package seed parses SQL seed files into statements and executes them transactionally.
+package seed
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Statement is a single SQL statement from a seed file
+type Statement struct {
+	SQL  string // statement text without the terminating semicolon
+	Line int    // 1-based line where the statement starts
+}
+
+// Keyword returns the upper-cased first word of the statement (e.g. INSERT, DELETE)
+func (s Statement) Keyword() string {
+	fields := strings.Fields(s.SQL)
+	if len(fields) == 0 {
+		return ""
+	}
+	return strings.ToUpper(fields[0])
+}
+
+// StatementError reports a statement that failed to execute
+type StatementError struct {
+	Index     int // 1-based position among the executed statements
+	Statement Statement
+	Err       error
+}
+
+func (e *StatementError) Error() string {
+	sql := e.Statement.SQL
+	if len(sql) > 200 {
+		sql = sql[:200] + "..."
+	}
+	return fmt.Sprintf("statement %d (line %d) failed: %v\n%s", e.Index, e.Statement.Line, e.Err, sql)
+}
+
+func (e *StatementError) Unwrap() error {
+	return e.Err
+}
+
+// ParseFile reads and parses a SQL seed file
+func ParseFile(path string) ([]Statement, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read seed file: %w", err)
+	}
+	return Parse(string(content))
+}
+
+// Parse splits SQL into statements on semicolons, ignoring semicolons inside
+// string literals, quoted identifiers, dollar-quoted blocks and comments.
+// Comments before a statement are dropped; empty statements are skipped.
+func Parse(content string) ([]Statement, error) {
+	var statements []Statement
+
+	start := -1 // byte offset of the current statement, -1 between statements
+	startLine := 0
+	line := 1
+
+	for i := 0; i < len(content); {
+		c := content[i]
+
+		switch {
+		case c == '\n':
+			line++
+			i++
+
+		case c == '-' && i+1 < len(content) && content[i+1] == '-':
+			// Line comment runs until the newline, which the loop counts
+			for i < len(content) && content[i] != '\n' {
+				i++
+			}
+
+		case c == '/' && i+1 < len(content) && content[i+1] == '*':
+			end, lines, err := skipBlockComment(content, i)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			i, line = end, line+lines
+
+		case c == ';':
+			if start >= 0 {
+				statements = append(statements, Statement{SQL: strings.TrimSpace(content[start:i]), Line: startLine})
+				start = -1
+			}
+			i++
+
+		default:
+			if start < 0 && !isSpace(c) {
+				start, startLine = i, line
+			}
+
+			var end, lines int
+			var err error
+			switch {
+			case c == '\'':
+				end, lines, err = skipQuoted(content, i, '\'', isEscapeString(content, i))
+			case c == '"':
+				end, lines, err = skipQuoted(content, i, '"', false)
+			case c == '$':
+				if tag, ok := dollarTag(content, i); ok {
+					end, lines, err = skipDollarQuoted(content, i, tag)
+				} else {
+					end = i + 1
+				}
+			default:
+				end = i + 1
+			}
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			i, line = end, line+lines
+		}
+	}
+
+	// A final statement without a terminating semicolon
+	if start >= 0 {
+		if sql := strings.TrimSpace(content[start:]); sql != "" {
+			statements = append(statements, Statement{SQL: sql, Line: startLine})
+		}
+	}
+
+	return statements, nil
+}
+
+// Execute runs the statements in a single transaction, rolling back on the first error.
+// It returns the number of statements executed.
+func Execute(db *gorm.DB, statements []Statement) (int, error) {
+	executed := 0
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		executed, err = ExecuteTx(tx, statements)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return executed, nil
+}
+
+// ExecuteTx runs the statements in the caller's transaction, stopping at the first error,
+// which is returned as a *StatementError
+func ExecuteTx(tx *gorm.DB, statements []Statement) (int, error) {
+	for i, stmt := range statements {
+		if err := tx.Exec(stmt.SQL).Error; err != nil {
+			return i, &StatementError{Index: i + 1, Statement: stmt, Err: err}
+		}
+	}
+	return len(statements), nil
+}
+
+// skipQuoted returns the offset just past the quoted text starting at i and the number of
+// newlines inside it. A doubled quote is an escaped quote; backslash escapes apply to E'...' strings.
+func skipQuoted(content string, i int, quote byte, backslashEscapes bool) (int, int, error) {
+	lines := 0
+	for j := i + 1; j < len(content); j++ {
+		switch content[j] {
+		case '\n':
+			lines++
+		case '\\':
+			if backslashEscapes {
+				j++
+				if j < len(content) && content[j] == '\n' {
+					lines++
+				}
+			}
+		case quote:
+			if j+1 < len(content) && content[j+1] == quote {
+				j++
+				continue
+			}
+			return j + 1, lines, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("unterminated %c quote", quote)
+}
+
+// skipBlockComment returns the offset just past the (possibly nested) block comment at i
+// and the number of newlines inside it
+func skipBlockComment(content string, i int) (int, int, error) {
+	depth, lines := 0, 0
+	for j := i; j < len(content); j++ {
+		switch {
+		case content[j] == '\n':
+			lines++
+		case content[j] == '/' && j+1 < len(content) && content[j+1] == '*':
+			depth++
+			j++
+		case content[j] == '*' && j+1 < len(content) && content[j+1] == '/':
+			depth--
+			j++
+			if depth == 0 {
+				return j + 1, lines, nil
+			}
+		}
+	}
+	return 0, 0, fmt.Errorf("unterminated block comment")
+}
+
+// dollarTag returns the dollar-quote opening tag ($$ or $name$) at i, if any.
+// Positional parameters such as $1 are not dollar quotes.
+func dollarTag(content string, i int) (string, bool) {
+	for j := i + 1; j < len(content); j++ {
+		c := content[j]
+		switch {
+		case c == '$':
+			return content[i : j+1], true
+		case c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80:
+		case c >= '0' && c <= '9' && j > i+1:
+		default:
+			return "", false
+		}
+	}
+	return "", false
+}
+
+// skipDollarQuoted returns the offset just past the dollar-quoted block opened by tag at i
+// and the number of newlines inside it
+func skipDollarQuoted(content string, i int, tag string) (int, int, error) {
+	body := content[i+len(tag):]
+	end := strings.Index(body, tag)
+	if end < 0 {
+		return 0, 0, fmt.Errorf("unterminated dollar-quoted block %s", tag)
+	}
+	return i + len(tag) + end + len(tag), strings.Count(body[:end], "\n"), nil
+}
+
+// isEscapeString reports whether the quote at i opens an E'...' string
+func isEscapeString(content string, i int) bool {
+	if i == 0 || (content[i-1] != 'E' && content[i-1] != 'e') {
+		return false
+	}
+	return i == 1 || !isIdentChar(content[i-2])
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v'
+}
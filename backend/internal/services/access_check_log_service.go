@@ -0,0 +1,180 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"backend/internal/logger"
+	"backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// sensitiveSetTTL bounds how long a change to Permission.IsSensitive takes to apply
+// when it was not made through PermissionService
+const sensitiveSetTTL = time.Minute
+
+// AccessCheckLogService records permission checks on sensitive resources and lists them.
+// A check is sensitive when an active permission with the same resource and action is
+// flagged IsSensitive.
+type AccessCheckLogService struct {
+	db *gorm.DB
+
+	mu        sync.RWMutex
+	sensitive map[string]bool // "resource:action" keys
+	loadedAt  time.Time
+}
+
+// NewAccessCheckLogService creates a new AccessCheckLogService instance
+func NewAccessCheckLogService(db *gorm.DB) *AccessCheckLogService {
+	return &AccessCheckLogService{db: db}
+}
+
+// AccessCheckLogListResult represents a page of access check logs
+type AccessCheckLogListResult struct {
+	Data       []models.AccessCheckLog
+	Total      int64
+	Page       int
+	PageSize   int
+	TotalPages int
+}
+
+func sensitiveKey(resource string, action models.PermissionAction) string {
+	return resource + ":" + string(action)
+}
+
+// IsSensitive reports whether checks on resource/action must be recorded
+func (s *AccessCheckLogService) IsSensitive(ctx context.Context, resource string, action models.PermissionAction) bool {
+	s.mu.RLock()
+	if s.sensitive != nil && time.Since(s.loadedAt) < sensitiveSetTTL {
+		sensitive := s.sensitive[sensitiveKey(resource, action)]
+		s.mu.RUnlock()
+		return sensitive
+	}
+	s.mu.RUnlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.sensitive == nil || time.Since(s.loadedAt) >= sensitiveSetTTL {
+		if err := s.loadSensitive(); err != nil {
+			logger.FromContext(ctx).Warn("failed to load sensitive permissions", "error", err)
+			if s.sensitive == nil {
+				return false
+			}
+		}
+	}
+	return s.sensitive[sensitiveKey(resource, action)]
+}
+
+// loadSensitive reloads the sensitive resource/action set; s.mu must be held
+func (s *AccessCheckLogService) loadSensitive() error {
+	var permissions []models.Permission
+	if err := s.db.Select("resource", "action").
+		Where("is_sensitive = ? AND is_active = ?", true, true).
+		Find(&permissions).Error; err != nil {
+		return err
+	}
+
+	sensitive := make(map[string]bool, len(permissions))
+	for _, p := range permissions {
+		sensitive[sensitiveKey(p.Resource, p.Action)] = true
+	}
+	s.sensitive = sensitive
+	s.loadedAt = time.Now()
+	return nil
+}
+
+// InvalidateSensitive forces the sensitive set to be reloaded on the next check
+func (s *AccessCheckLogService) InvalidateSensitive() {
+	s.mu.Lock()
+	s.sensitive = nil
+	s.mu.Unlock()
+}
+
+// Record stores the outcome of a check when the resource is sensitive. A failed write
+// is logged and does not affect the check itself.
+func (s *AccessCheckLogService) Record(ctx context.Context, userID string, req PermissionCheckRequest, result *PermissionCheckResult) {
+	if result == nil || !s.IsSensitive(ctx, req.Resource, req.Action) {
+		return
+	}
+
+	entry := models.AccessCheckLog{
+		ID:         uuid.New().String(),
+		UserID:     userID,
+		Resource:   req.Resource,
+		Action:     req.Action,
+		Scope:      req.Scope,
+		Allowed:    result.Allowed,
+		Source:     result.Source,
+		SourceID:   result.SourceID,
+		SourceName: result.SourceName,
+		CheckedAt:  time.Now(),
+	}
+	if err := s.db.Create(&entry).Error; err != nil {
+		logger.FromContext(ctx).Warn("failed to record access check",
+			"user_id", userID, "resource", req.Resource, "action", req.Action, "error", err)
+	}
+}
+
+// GetAccessCheckLogs lists recorded access checks, newest first
+func (s *AccessCheckLogService) GetAccessCheckLogs(filter models.AccessCheckLogFilter) (*AccessCheckLogListResult, error) {
+	query := s.db.Model(&models.AccessCheckLog{})
+	if filter.UserID != nil && *filter.UserID != "" {
+		query = query.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.Resource != nil && *filter.Resource != "" {
+		query = query.Where("resource = ?", strings.TrimSpace(*filter.Resource))
+	}
+	if filter.Action != nil && *filter.Action != "" {
+		query = query.Where("action = ?", *filter.Action)
+	}
+	if filter.Allowed != nil {
+		query = query.Where("allowed = ?", *filter.Allowed)
+	}
+	if filter.StartDate != nil {
+		query = query.Where("checked_at >= ?", *filter.StartDate)
+	}
+	if filter.EndDate != nil {
+		query = query.Where("checked_at <= ?", *filter.EndDate)
+	}
+
+	// Count total
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("gagal menghitung log pemeriksaan akses: %w", err)
+	}
+
+	// Apply pagination
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 || filter.Limit > 200 {
+		filter.Limit = 50
+	}
+
+	var logs []models.AccessCheckLog
+	if err := query.
+		Order("checked_at DESC").
+		Offset((filter.Page - 1) * filter.Limit).
+		Limit(filter.Limit).
+		Find(&logs).Error; err != nil {
+		return nil, fmt.Errorf("gagal mengambil log pemeriksaan akses: %w", err)
+	}
+
+	totalPages := int(total) / filter.Limit
+	if int(total)%filter.Limit > 0 {
+		totalPages++
+	}
+
+	return &AccessCheckLogListResult{
+		Data:       logs,
+		Total:      total,
+		Page:       filter.Page,
+		PageSize:   filter.Limit,
+		TotalPages: totalPages,
+	}, nil
+}
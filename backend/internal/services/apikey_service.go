@@ -9,6 +9,8 @@ import (
 	"time"
 
 	"backend/internal/auth"
+	"backend/internal/database"
+	"backend/internal/helpers"
 	"backend/internal/models"
 
 	"github.com/google/uuid"
@@ -137,9 +139,9 @@ func (s *ApiKeyService) ValidateApiKey(plainKey string) (*models.ApiKey, error)
 	}
 	prefix := parts[0]
 
-	// Find all active keys with this prefix
+	// Find all active keys with this prefix, on the primary so a revoked key stops at once
 	var keys []models.ApiKey
-	if err := s.db.Where("prefix = ? AND is_active = ?", prefix, true).Find(&keys).Error; err != nil {
+	if err := s.db.Clauses(database.Write).Where("prefix = ? AND is_active = ?", prefix, true).Find(&keys).Error; err != nil {
 		return nil, fmt.Errorf("gagal mencari API key: %w", err)
 	}
 
@@ -198,12 +200,8 @@ func (s *ApiKeyService) GetApiKeys(userID string, params ApiKeyListParams) (*Api
 			"name": true, "created_at": true, "last_used_at": true,
 			"usage_count": true, "expires_at": true, "is_active": true,
 		}
-		if validSortColumns[params.SortBy] {
-			direction := "ASC"
-			if strings.ToLower(params.SortOrder) == "desc" {
-				direction = "DESC"
-			}
-			query = query.Order(fmt.Sprintf("%s %s", params.SortBy, direction))
+		if order := helpers.SafeOrder(params.SortBy, params.SortOrder, validSortColumns); order != "" {
+			query = query.Order(order)
 		}
 	} else {
 		query = query.Order("created_at DESC")
@@ -240,10 +238,11 @@ func (s *ApiKeyService) GetApiKeys(userID string, params ApiKeyListParams) (*Api
 	}, nil
 }
 
-// GetApiKeyByID retrieves an API key by ID (must belong to user)
+// GetApiKeyByID retrieves an API key by ID (must belong to user). It reads the primary
+// since revoke and delete act on the key it returns.
 func (s *ApiKeyService) GetApiKeyByID(id string, userID string) (*models.ApiKey, error) {
 	var key models.ApiKey
-	if err := s.db.Where("id = ? AND user_id = ?", id, userID).First(&key).Error; err != nil {
+	if err := s.db.Clauses(database.Write).Where("id = ? AND user_id = ?", id, userID).First(&key).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("API key tidak ditemukan")
 		}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"backend/internal/models"
+
+	"gorm.io/datatypes"
+)
+
+// Access change kinds
+const (
+	AccessChangeRole       = "role"
+	AccessChangePosition   = "position"
+	AccessChangePermission = "permission"
+)
+
+// accessChangeTargets maps the audited assignment types to their change kind and the field
+// holding the assigned role, position or permission
+var accessChangeTargets = map[string]struct{ kind, refField string }{
+	"user_role":       {kind: AccessChangeRole, refField: "role_id"},
+	"user_position":   {kind: AccessChangePosition, refField: "position_id"},
+	"user_permission": {kind: AccessChangePermission, refField: "permission_id"},
+}
+
+// AccessChange is one change to a user's roles, positions or direct permissions
+type AccessChange struct {
+	ID     string
+	Action models.AuditAction
+	Kind   string
+	// Name is the role, position or permission name, or its id when it no longer exists
+	Name string
+	// IsGranted is set for direct permissions; false means the assignment denies
+	IsGranted *bool
+	// ActorName is empty when the change was made by the system
+	ActorName string
+	CreatedAt time.Time
+}
+
+// GetAccessChanges returns the most recent changes since the given time to the roles, positions
+// and direct permissions of userID, newest first
+func (s *AuditService) GetAccessChanges(userID string, since time.Time, limit int) ([]AccessChange, error) {
+	entityTypes := make([]string, 0, len(accessChangeTargets))
+	for entityType := range accessChangeTargets {
+		entityTypes = append(entityTypes, entityType)
+	}
+
+	var logs []models.AuditLog
+	if err := s.db.
+		Preload("Actor").
+		Where("target_user_id = ? AND entity_type IN ? AND created_at >= ?", userID, entityTypes, since).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&logs).Error; err != nil {
+		return nil, fmt.Errorf("gagal mengambil riwayat perubahan akses: %w", err)
+	}
+
+	changes := make([]AccessChange, len(logs))
+	refIDs := make(map[string][]string)
+	refs := make([]string, len(logs))
+	for i, entry := range logs {
+		target := accessChangeTargets[entry.EntityType]
+		values := accessChangeValues(entry)
+
+		changes[i] = AccessChange{
+			ID:        entry.ID,
+			Action:    entry.Action,
+			Kind:      target.kind,
+			CreatedAt: entry.CreatedAt,
+		}
+		if isGranted, ok := values["is_granted"].(bool); ok && target.kind == AccessChangePermission {
+			changes[i].IsGranted = &isGranted
+		}
+		if entry.Actor != nil {
+			if entry.Actor.Username != nil {
+				changes[i].ActorName = *entry.Actor.Username
+			} else {
+				changes[i].ActorName = entry.Actor.Email
+			}
+		}
+		if refID, ok := values[target.refField].(string); ok && refID != "" {
+			refs[i] = refID
+			refIDs[target.kind] = append(refIDs[target.kind], refID)
+		}
+	}
+
+	names, err := s.accessChangeNames(refIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range changes {
+		changes[i].Name = refs[i]
+		if name, ok := names[changes[i].Kind][refs[i]]; ok {
+			changes[i].Name = name
+		}
+	}
+
+	return changes, nil
+}
+
+// accessChangeValues returns the assignment recorded in an audit entry: the new state, or the
+// old one for a revocation that only recorded what was removed
+func accessChangeValues(entry models.AuditLog) map[string]interface{} {
+	for _, raw := range []*datatypes.JSON{entry.NewValues, entry.OldValues} {
+		if raw == nil {
+			continue
+		}
+		var values map[string]interface{}
+		if err := json.Unmarshal(*raw, &values); err == nil && len(values) > 0 {
+			return values
+		}
+	}
+	return nil
+}
+
+// accessChangeNames looks up the names of the referenced roles, positions and permissions,
+// keyed by change kind and id
+func (s *AuditService) accessChangeNames(refIDs map[string][]string) (map[string]map[string]string, error) {
+	tables := map[string]interface{}{
+		AccessChangeRole:       &models.Role{},
+		AccessChangePosition:   &models.Position{},
+		AccessChangePermission: &models.Permission{},
+	}
+
+	names := make(map[string]map[string]string, len(refIDs))
+	for kind, ids := range refIDs {
+		var rows []struct {
+			ID   string
+			Name string
+		}
+		if err := s.db.Model(tables[kind]).Select("id", "name").Where("id IN ?", ids).Scan(&rows).Error; err != nil {
+			return nil, fmt.Errorf("gagal mengambil nama %s: %w", kind, err)
+		}
+		names[kind] = make(map[string]string, len(rows))
+		for _, row := range rows {
+			names[kind][row.ID] = row.Name
+		}
+	}
+	return names, nil
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// DefaultAuditRetentionInterval is how often expired audit logs are purged
+const DefaultAuditRetentionInterval = time.Hour
+
+// auditRetentionBatchSize bounds how many rows are archived per transaction
+const auditRetentionBatchSize = 5000
+
+// auditPurgeLockKey is the Postgres advisory lock shared by audit exports and taken
+// exclusively by the retention purge, so a purge never removes rows an export is reading
+const auditPurgeLockKey int64 = 7428150301
+
+// Audit retention modes
+const (
+	AuditRetentionModeArchive = "archive"
+	AuditRetentionModeDelete  = "delete"
+)
+
+// AuditRetentionService removes audit logs older than the retention window
+type AuditRetentionService struct {
+	db        *gorm.DB
+	retention time.Duration
+	mode      string
+	periodicTask
+}
+
+// NewAuditRetentionService creates a new AuditRetentionService instance.
+// retentionDays <= 0 disables purging; an unknown mode falls back to archive.
+func NewAuditRetentionService(db *gorm.DB, retentionDays int, mode string) *AuditRetentionService {
+	if mode != AuditRetentionModeDelete {
+		mode = AuditRetentionModeArchive
+	}
+	return &AuditRetentionService{
+		db:        db,
+		retention: time.Duration(retentionDays) * 24 * time.Hour,
+		mode:      mode,
+	}
+}
+
+// Start runs the retention purge periodically in a background goroutine until Stop is called
+func (s *AuditRetentionService) Start(interval time.Duration) {
+	if s.retention <= 0 {
+		slog.Info("audit retention disabled")
+		return
+	}
+
+	s.start(interval, func() {
+		if _, err := s.Purge(time.Now()); err != nil {
+			slog.Warn("audit retention run failed", "error", err)
+		}
+	})
+}
+
+// Purge archives or deletes audit logs created before now minus the retention window.
+// It returns the number of rows removed from audit_logs.
+func (s *AuditRetentionService) Purge(now time.Time) (int, error) {
+	cutoff := now.Add(-s.retention)
+	total := 0
+
+	for {
+		removed, err := s.purgeBatch(cutoff, now)
+		if err != nil {
+			return total, err
+		}
+		total += removed
+		if removed < auditRetentionBatchSize {
+			break
+		}
+	}
+
+	verb := "archived"
+	if s.mode == AuditRetentionModeDelete {
+		verb = "deleted"
+	}
+	slog.Info("audit retention finished", "mode", verb, "rows", total, "cutoff", cutoff.Format(time.RFC3339))
+
+	return total, nil
+}
+
+// purgeBatch removes one batch of expired rows while holding the purge lock exclusively
+func (s *AuditRetentionService) purgeBatch(cutoff, now time.Time) (int, error) {
+	removed := 0
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// Wait for running exports to finish and block new ones until this batch commits;
+		// the wait is as long as the slowest export, so it must not hit the statement timeout
+		if err := liftStatementTimeout(tx); err != nil {
+			return fmt.Errorf("gagal mengunci audit log: %w", err)
+		}
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", auditPurgeLockKey).Error; err != nil {
+			return fmt.Errorf("gagal mengunci audit log: %w", err)
+		}
+
+		var ids []string
+		if err := tx.Model(&models.AuditLog{}).
+			Where("created_at < ?", cutoff).
+			Order("created_at ASC").
+			Limit(auditRetentionBatchSize).
+			Pluck("id", &ids).Error; err != nil {
+			return fmt.Errorf("gagal mengambil audit log kedaluwarsa: %w", err)
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		if s.mode == AuditRetentionModeArchive {
+			if err := tx.Exec(`INSERT INTO public.audit_log_archives
+				(id, actor_id, actor_profile_id, action, module, entity_type, entity_id, entity_display,
+				 old_values, new_values, changed_fields, target_user_id, metadata, ip_address, user_agent,
+				 created_at, category, archived_at)
+				SELECT id, actor_id, actor_profile_id, action, module, entity_type, entity_id, entity_display,
+				 old_values, new_values, changed_fields, target_user_id, metadata, ip_address, user_agent,
+				 created_at, category, ?
+				FROM public.audit_logs WHERE id IN ?
+				ON CONFLICT (id) DO NOTHING`, now, ids).Error; err != nil {
+				return fmt.Errorf("gagal mengarsipkan audit log: %w", err)
+			}
+		}
+
+		if err := tx.Where("id IN ?", ids).Delete(&models.AuditLog{}).Error; err != nil {
+			return fmt.Errorf("gagal menghapus audit log: %w", err)
+		}
+
+		removed = len(ids)
+		return nil
+	})
+
+	return removed, err
+}
@@ -0,0 +1,534 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"reflect"
+	"strings"
+	"time"
+
+	"backend/internal/logger"
+	"backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// AuditService handles writing and querying audit log entries
+type AuditService struct {
+	db *gorm.DB
+}
+
+// NewAuditService creates a new AuditService instance
+func NewAuditService(db *gorm.DB) *AuditService {
+	return &AuditService{db: db}
+}
+
+// AuditEntry describes a single audited action
+type AuditEntry struct {
+	ActorID      string
+	Action       models.AuditAction
+	TargetType   string
+	TargetID     string
+	TargetUserID *string
+	Before       interface{}
+	After        interface{}
+	Metadata     map[string]interface{}
+	IPAddress    string
+	UserAgent    string
+	RequestID    string // logged with a failed write, not stored
+}
+
+// auditTarget maps a target type to the module and category it is logged under
+type auditTarget struct {
+	module   string
+	category models.AuditCategory
+}
+
+var auditTargets = map[string]auditTarget{
+	"user":               {module: "users", category: models.AuditCategoryUserManagement},
+	"user_role":          {module: "users", category: models.AuditCategoryPermission},
+	"user_position":      {module: "users", category: models.AuditCategoryPermission},
+	"user_permission":    {module: "users", category: models.AuditCategoryPermission},
+	"delegation":         {module: "delegations", category: models.AuditCategoryPermission},
+	"role_permission":    {module: "roles", category: models.AuditCategoryPermission},
+	"role_module_access": {module: "modules", category: models.AuditCategoryModule},
+	"auth":               {module: "auth", category: models.AuditCategorySecurity},
+	"employee":           {module: "employees", category: models.AuditCategoryDataChange},
+}
+
+// redactedAuditKeySuffixes marks keys whose values never reach the audit trail
+var redactedAuditKeySuffixes = []string{"password", "password_hash", "token", "token_hash", "secret", "api_key"}
+
+// Record writes an audit entry for actorID performing action on a target, storing the
+// before/after state and a per-field diff. Failures are logged and returned but
+// callers normally don't abort the audited operation because of them.
+func (s *AuditService) Record(actorID string, action models.AuditAction, targetType, targetID string, before, after interface{}) error {
+	return s.RecordEntry(AuditEntry{
+		ActorID:    actorID,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Before:     before,
+		After:      after,
+	})
+}
+
+// RecordEntry writes an audit entry with request details and metadata
+func (s *AuditService) RecordEntry(entry AuditEntry) error {
+	beforeValues, err := toAuditValues(entry.Before)
+	if err != nil {
+		return s.recordFailed(entry, err)
+	}
+	afterValues, err := toAuditValues(entry.After)
+	if err != nil {
+		return s.recordFailed(entry, err)
+	}
+
+	target, ok := auditTargets[entry.TargetType]
+	if !ok {
+		target = auditTarget{module: entry.TargetType, category: models.AuditCategoryDataChange}
+	}
+
+	auditLog := models.AuditLog{
+		ID:           uuid.New().String(),
+		ActorID:      entry.ActorID,
+		Action:       entry.Action,
+		Module:       target.module,
+		EntityType:   entry.TargetType,
+		EntityID:     entry.TargetID,
+		TargetUserID: entry.TargetUserID,
+		Category:     &target.category,
+	}
+	if entry.ActorID != "" {
+		actorID := entry.ActorID
+		auditLog.ActorProfileID = &actorID
+	}
+	if entry.IPAddress != "" {
+		auditLog.IPAddress = &entry.IPAddress
+	}
+	if entry.UserAgent != "" {
+		auditLog.UserAgent = &entry.UserAgent
+	}
+
+	// Assignment records carry the affected user in their user_id field
+	if auditLog.TargetUserID == nil {
+		auditLog.TargetUserID = auditUserID(afterValues)
+	}
+	if auditLog.TargetUserID == nil {
+		auditLog.TargetUserID = auditUserID(beforeValues)
+	}
+
+	redactAuditValues(beforeValues)
+	redactAuditValues(afterValues)
+	redactAuditValues(entry.Metadata)
+
+	if auditLog.OldValues, err = toAuditJSON(beforeValues); err != nil {
+		return s.recordFailed(entry, err)
+	}
+	if auditLog.NewValues, err = toAuditJSON(afterValues); err != nil {
+		return s.recordFailed(entry, err)
+	}
+	if changes := diffAuditValues(beforeValues, afterValues); len(changes) > 0 {
+		if auditLog.ChangedFields, err = toAuditJSON(changes); err != nil {
+			return s.recordFailed(entry, err)
+		}
+	}
+	if len(entry.Metadata) > 0 {
+		if auditLog.Metadata, err = toAuditJSON(entry.Metadata); err != nil {
+			return s.recordFailed(entry, err)
+		}
+	}
+
+	if err := s.db.Create(&auditLog).Error; err != nil {
+		return s.recordFailed(entry, err)
+	}
+
+	return nil
+}
+
+// recordFailed logs a failed audit write and returns it as an error
+func (s *AuditService) recordFailed(entry AuditEntry, err error) error {
+	log := slog.Default()
+	if entry.RequestID != "" {
+		log = log.With("request_id", entry.RequestID)
+	}
+	log.Warn("failed to write audit log",
+		"action", entry.Action, "target_type", entry.TargetType, "target_id", entry.TargetID, "actor_id", entry.ActorID, "error", err)
+	return fmt.Errorf("gagal mencatat audit log: %w", err)
+}
+
+// toAuditValues converts a value into a flat JSON object, or nil when v is nil
+func toAuditValues(v interface{}) (map[string]interface{}, error) {
+	if v == nil || (reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil()) {
+		return nil, nil
+	}
+
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var values map[string]interface{}
+	if err := json.Unmarshal(raw, &values); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// redactAuditValues replaces secret-looking values (passwords, tokens, keys) in place
+func redactAuditValues(values map[string]interface{}) {
+	for key := range values {
+		lower := strings.ToLower(key)
+		for _, suffix := range redactedAuditKeySuffixes {
+			if strings.HasSuffix(lower, suffix) {
+				values[key] = "[REDACTED]"
+				break
+			}
+		}
+	}
+}
+
+// toAuditJSON marshals v into a jsonb value, or nil when v is empty
+func toAuditJSON(v interface{}) (*datatypes.JSON, error) {
+	if v == nil || (reflect.ValueOf(v).Kind() == reflect.Map && reflect.ValueOf(v).Len() == 0) {
+		return nil, nil
+	}
+
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	value := datatypes.JSON(raw)
+	return &value, nil
+}
+
+// diffAuditValues returns {"field": {"old": ..., "new": ...}} for every field that differs
+func diffAuditValues(before, after map[string]interface{}) map[string]interface{} {
+	changes := make(map[string]interface{})
+
+	for key, oldValue := range before {
+		newValue, ok := after[key]
+		if !ok || !reflect.DeepEqual(oldValue, newValue) {
+			changes[key] = map[string]interface{}{"old": oldValue, "new": newValue}
+		}
+	}
+	for key, newValue := range after {
+		if _, ok := before[key]; !ok {
+			changes[key] = map[string]interface{}{"old": nil, "new": newValue}
+		}
+	}
+
+	return changes
+}
+
+// auditUserID extracts a user_id string field from audit values
+func auditUserID(values map[string]interface{}) *string {
+	if userID, ok := values["user_id"].(string); ok && userID != "" {
+		return &userID
+	}
+	return nil
+}
+
+// AuditLogListResult represents a page of audit log entries
+type AuditLogListResult struct {
+	Data       []*models.AuditLogResponse
+	Total      int64
+	Page       int
+	PageSize   int
+	TotalPages int
+}
+
+// GetAuditLogs lists audit entries visible to viewerID, honoring the viewer's audit:read scope:
+// ALL sees every entry, SCHOOL and DEPARTMENT see entries whose actor or affected user holds a
+// position in one of the viewer's schools or departments, and OWN sees entries the viewer made
+// or was affected by
+func (s *AuditService) GetAuditLogs(viewerID string, filter models.AuditLogFilter) (*AuditLogListResult, error) {
+	query, err := s.filteredAuditQuery(s.db, viewerID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	// Count total
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("gagal menghitung audit log: %w", err)
+	}
+
+	// Apply pagination
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 || filter.Limit > 200 {
+		filter.Limit = 50
+	}
+
+	var logs []models.AuditLog
+	if err := query.
+		Preload("Actor").
+		Preload("TargetUser").
+		Order("created_at DESC").
+		Offset((filter.Page - 1) * filter.Limit).
+		Limit(filter.Limit).
+		Find(&logs).Error; err != nil {
+		return nil, fmt.Errorf("gagal mengambil audit log: %w", err)
+	}
+
+	data := make([]*models.AuditLogResponse, len(logs))
+	for i := range logs {
+		data[i] = logs[i].ToResponse()
+	}
+
+	totalPages := int(total) / filter.Limit
+	if int(total)%filter.Limit > 0 {
+		totalPages++
+	}
+
+	return &AuditLogListResult{
+		Data:       data,
+		Total:      total,
+		Page:       filter.Page,
+		PageSize:   filter.Limit,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// Audit export formats
+const (
+	AuditExportFormatCSV  = "csv"
+	AuditExportFormatJSON = "json"
+)
+
+// ExportAuditLogs streams every audit entry visible to viewerID and matching filter to w,
+// oldest first, as CSV or as a JSON array. The export holds the purge lock in shared mode
+// so the retention job cannot archive rows while they are being read.
+// It returns the number of entries written.
+func (s *AuditService) ExportAuditLogs(ctx context.Context, viewerID string, filter models.AuditLogFilter, format string, w io.Writer, exportedBy, ipAddress, userAgent string) (int, error) {
+	if format != AuditExportFormatCSV && format != AuditExportFormatJSON {
+		return 0, fmt.Errorf("format export tidak valid: %s", format)
+	}
+
+	count := 0
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// The export streams for as long as the client keeps reading
+		if err := liftStatementTimeout(tx); err != nil {
+			return fmt.Errorf("gagal mengambil audit log: %w", err)
+		}
+		if err := tx.Exec("SELECT pg_advisory_xact_lock_shared(?)", auditPurgeLockKey).Error; err != nil {
+			return fmt.Errorf("gagal mengunci audit log: %w", err)
+		}
+
+		query, err := s.filteredAuditQuery(tx, viewerID, filter)
+		if err != nil {
+			return err
+		}
+
+		rows, err := query.Order("created_at ASC").Rows()
+		if err != nil {
+			return fmt.Errorf("gagal mengambil audit log: %w", err)
+		}
+		defer rows.Close()
+
+		if format == AuditExportFormatJSON {
+			count, err = writeAuditJSON(tx, rows, w)
+		} else {
+			count, err = writeAuditCSV(tx, rows, w)
+		}
+		if err != nil {
+			return err
+		}
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("gagal membaca audit log: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return count, err
+	}
+
+	// Record who exported and with which filters
+	s.RecordEntry(AuditEntry{
+		ActorID:    exportedBy,
+		Action:     models.AuditActionExport,
+		TargetType: "audit_log",
+		TargetID:   "export",
+		Metadata: map[string]interface{}{
+			"format":    format,
+			"filter":    filter,
+			"row_count": count,
+		},
+		IPAddress: ipAddress,
+		UserAgent: userAgent,
+		RequestID: logger.RequestID(ctx),
+	})
+
+	return count, nil
+}
+
+// writeAuditCSV writes audit rows as CSV with a header line
+func writeAuditCSV(tx *gorm.DB, rows *sql.Rows, w io.Writer) (int, error) {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{
+		"id", "created_at", "actor_id", "actor_profile_id", "action", "module", "category",
+		"entity_type", "entity_id", "target_user_id", "ip_address", "changed_fields", "metadata",
+	}); err != nil {
+		return 0, fmt.Errorf("gagal menulis CSV: %w", err)
+	}
+
+	count := 0
+	for rows.Next() {
+		var entry models.AuditLog
+		if err := tx.ScanRows(rows, &entry); err != nil {
+			return count, fmt.Errorf("gagal membaca audit log: %w", err)
+		}
+
+		category := ""
+		if entry.Category != nil {
+			category = string(*entry.Category)
+		}
+		if err := writer.Write([]string{
+			entry.ID,
+			entry.CreatedAt.Format(time.RFC3339),
+			entry.ActorID,
+			stringValue(entry.ActorProfileID),
+			string(entry.Action),
+			entry.Module,
+			category,
+			entry.EntityType,
+			entry.EntityID,
+			stringValue(entry.TargetUserID),
+			stringValue(entry.IPAddress),
+			auditJSONString(entry.ChangedFields),
+			auditJSONString(entry.Metadata),
+		}); err != nil {
+			return count, fmt.Errorf("gagal menulis CSV: %w", err)
+		}
+		count++
+
+		// Flush periodically so the client receives data while the export runs
+		if count%500 == 0 {
+			writer.Flush()
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return count, fmt.Errorf("gagal menulis CSV: %w", err)
+	}
+
+	return count, nil
+}
+
+// writeAuditJSON writes audit rows as a JSON array, one entry at a time
+func writeAuditJSON(tx *gorm.DB, rows *sql.Rows, w io.Writer) (int, error) {
+	if _, err := io.WriteString(w, "["); err != nil {
+		return 0, fmt.Errorf("gagal menulis JSON: %w", err)
+	}
+
+	count := 0
+	for rows.Next() {
+		var entry models.AuditLog
+		if err := tx.ScanRows(rows, &entry); err != nil {
+			return count, fmt.Errorf("gagal membaca audit log: %w", err)
+		}
+
+		raw, err := json.Marshal(entry.ToResponse())
+		if err != nil {
+			return count, fmt.Errorf("gagal menulis JSON: %w", err)
+		}
+		if count > 0 {
+			raw = append([]byte(","), raw...)
+		}
+		if _, err := w.Write(raw); err != nil {
+			return count, fmt.Errorf("gagal menulis JSON: %w", err)
+		}
+		count++
+	}
+
+	if _, err := io.WriteString(w, "]"); err != nil {
+		return count, fmt.Errorf("gagal menulis JSON: %w", err)
+	}
+
+	return count, nil
+}
+
+// auditJSONString returns a jsonb value as a string, or an empty string when nil
+func auditJSONString(value *datatypes.JSON) string {
+	if value == nil {
+		return ""
+	}
+	return string(*value)
+}
+
+// filteredAuditQuery builds an audit log query restricted to what viewerID may see and narrowed by filter
+func (s *AuditService) filteredAuditQuery(db *gorm.DB, viewerID string, filter models.AuditLogFilter) (*gorm.DB, error) {
+	query := db.Model(&models.AuditLog{})
+
+	scope, err := s.auditReadScope(viewerID)
+	if err != nil {
+		return nil, err
+	}
+	dataScope := &DataScope{UserID: viewerID, Scope: scope}
+	if visible := dataScope.VisibleUsers(s.db); visible != nil {
+		query = query.Where("(actor_profile_id IN (?) OR target_user_id IN (?))",
+			visible.Select("u.id"), dataScope.VisibleUsers(s.db).Select("u.id"))
+	}
+
+	// Apply filters
+	if filter.ActorProfileID != nil && *filter.ActorProfileID != "" {
+		query = query.Where("actor_profile_id = ?", *filter.ActorProfileID)
+	}
+	if filter.Action != nil && *filter.Action != "" {
+		query = query.Where("action = ?", *filter.Action)
+	}
+	if filter.Module != nil && *filter.Module != "" {
+		query = query.Where("module = ?", *filter.Module)
+	}
+	if filter.EntityType != nil && *filter.EntityType != "" {
+		query = query.Where("entity_type = ?", *filter.EntityType)
+	}
+	if filter.EntityID != nil && *filter.EntityID != "" {
+		query = query.Where("entity_id = ?", *filter.EntityID)
+	}
+	if filter.Category != nil && *filter.Category != "" {
+		query = query.Where("category = ?", *filter.Category)
+	}
+	if filter.TargetUserID != nil && *filter.TargetUserID != "" {
+		query = query.Where("target_user_id = ?", *filter.TargetUserID)
+	}
+	if filter.StartDate != nil {
+		query = query.Where("created_at >= ?", *filter.StartDate)
+	}
+	if filter.EndDate != nil {
+		query = query.Where("created_at <= ?", *filter.EndDate)
+	}
+
+	return query, nil
+}
+
+// auditReadScope returns the broadest audit:read scope the viewer holds
+func (s *AuditService) auditReadScope(viewerID string) (models.PermissionScope, error) {
+	resolver := NewPermissionResolverService(s.db)
+
+	level, err := resolver.GetUserHighestRoleLevel(viewerID)
+	if err != nil {
+		return "", fmt.Errorf("gagal memeriksa role pengguna: %w", err)
+	}
+	if level == 0 {
+		return models.PermissionScopeAll, nil
+	}
+
+	scope, err := HighestDataScope(resolver, viewerID, "audit", models.PermissionActionRead)
+	if err != nil {
+		return "", fmt.Errorf("gagal memeriksa permission pengguna: %w", err)
+	}
+	return scope, nil
+}
@@ -0,0 +1,329 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+
+	"backend/internal/clock"
+	"backend/internal/database"
+	"backend/internal/models"
+)
+
+// CapableUser is a user who currently holds a permission, with the layer that grants it
+type CapableUser struct {
+	UserID     string  `json:"user_id"`
+	Email      string  `json:"email"`
+	Username   *string `json:"username,omitempty"`
+	Source     string  `json:"source"`
+	SourceID   string  `json:"source_id"`
+	SourceName string  `json:"source_name"`
+}
+
+// CapableUserListResult represents a page of capable users
+type CapableUserListResult struct {
+	Data       []CapableUser
+	Total      int64
+	Page       int
+	PageSize   int
+	TotalPages int
+}
+
+// GetCapableUsers answers "who can do X": the active users for whom CheckPermission(req) currently
+// allows, each with the source CheckPermission would report. Rather than resolving every user, it
+// loads the grants for the request and expands them to users one layer at a time, applying the
+// resolver's precedence: a direct user permission (grant or deny) decides first, then positions,
+// then delegations, then roles including inherited ones. Results are ordered by email.
+func (s *PermissionResolverService) GetCapableUsers(req PermissionCheckRequest, page, limit int) (*CapableUserListResult, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 200 {
+		limit = 50
+	}
+
+	// Permissions that satisfy the request
+	var candidates []models.Permission
+	if err := s.db.Where("resource = ? AND action = ? AND is_active = ?", req.Resource, req.Action, true).
+		Find(&candidates).Error; err != nil {
+		return nil, fmt.Errorf("failed to load permissions: %w", err)
+	}
+	var permissionIDs []string
+	for i := range candidates {
+		if req.Scope != nil && !s.isScopeCompatible(candidates[i].Scope, req.Scope) {
+			continue
+		}
+		permissionIDs = append(permissionIDs, candidates[i].ID)
+	}
+
+	direct, err := s.capableByUserPermission(permissionIDs)
+	if err != nil {
+		return nil, err
+	}
+	positions, err := s.capableByPosition(req)
+	if err != nil {
+		return nil, err
+	}
+	roles, err := s.capableByRole(permissionIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// A delegator must hold the permission without delegations, as in checkDelegationPermission
+	holdsWithoutDelegation := func(userID string) bool {
+		if result, ok := direct[userID]; ok {
+			return result.Allowed
+		}
+		return positions[userID] != nil || roles[userID] != nil
+	}
+	delegations, err := s.capableByDelegation(req, holdsWithoutDelegation)
+	if err != nil {
+		return nil, err
+	}
+
+	capable := make(map[string]*PermissionCheckResult)
+	for _, layer := range []map[string]*PermissionCheckResult{direct, positions, delegations, roles} {
+		for userID, result := range layer {
+			if _, decided := capable[userID]; !decided {
+				capable[userID] = result
+			}
+		}
+	}
+	userIDs := make([]string, 0, len(capable))
+	for userID, result := range capable {
+		if result.Allowed {
+			userIDs = append(userIDs, userID)
+		}
+	}
+
+	result := &CapableUserListResult{Data: []CapableUser{}, Page: page, PageSize: limit}
+	if len(userIDs) == 0 {
+		return result, nil
+	}
+
+	query := s.db.Model(&models.User{}).Where("id IN ? AND is_active = ?", userIDs, true)
+	if err := query.Count(&result.Total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count capable users: %w", err)
+	}
+
+	var users []models.User
+	if err := query.Order("email ASC").Offset((page - 1) * limit).Limit(limit).Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("failed to load capable users: %w", err)
+	}
+	for _, u := range users {
+		source := capable[u.ID]
+		result.Data = append(result.Data, CapableUser{
+			UserID:     u.ID,
+			Email:      u.Email,
+			Username:   u.Username,
+			Source:     source.Source,
+			SourceID:   source.SourceID,
+			SourceName: source.SourceName,
+		})
+	}
+
+	result.TotalPages = int(result.Total) / limit
+	if int(result.Total)%limit > 0 {
+		result.TotalPages++
+	}
+
+	return result, nil
+}
+
+// capableByUserPermission returns the deciding direct permission per user, grants and denies
+// alike, picked with the same precedence as checkUserPermission
+func (s *PermissionResolverService) capableByUserPermission(permissionIDs []string) (map[string]*PermissionCheckResult, error) {
+	decided := make(map[string]*PermissionCheckResult)
+	if len(permissionIDs) == 0 {
+		return decided, nil
+	}
+
+	now := clock.Now()
+	var userPermissions []models.UserPermission
+	if err := s.db.Preload("Permission").
+		Where("permission_id IN ?", permissionIDs).
+		Where("effective_from <= ?", now).
+		Where("(effective_until IS NULL OR effective_until >= ?)", now).
+		Find(&userPermissions).Error; err != nil {
+		return nil, fmt.Errorf("failed to load user permissions: %w", err)
+	}
+	sort.SliceStable(userPermissions, func(i, j int) bool {
+		return precedesDirectPermission(userPermissions[i].Priority, userPermissions[i].IsGranted,
+			userPermissions[j].Priority, userPermissions[j].IsGranted)
+	})
+
+	for _, up := range userPermissions {
+		if _, ok := decided[up.UserID]; ok {
+			continue
+		}
+		decided[up.UserID] = &PermissionCheckResult{
+			Allowed:    up.IsGranted,
+			Source:     PermissionSourceUserPermission,
+			SourceID:   up.ID,
+			SourceName: fmt.Sprintf("Direct: %s", up.Permission.Name),
+		}
+	}
+
+	return decided, nil
+}
+
+// capableByPosition returns users whose current position has module access granting the request,
+// evaluated like checkPositionPermission
+func (s *PermissionResolverService) capableByPosition(req PermissionCheckRequest) (map[string]*PermissionCheckResult, error) {
+	granted := make(map[string]*PermissionCheckResult)
+
+	now := clock.Now()
+	var accesses []models.RoleModuleAccess
+	if err := s.db.
+		Where("position_id IS NOT NULL AND is_active = ?", true).
+		Where("effective_from <= ?", now).
+		Where("(effective_until IS NULL OR effective_until >= ?)", now).
+		Where("module_id IN (?)", s.db.Model(&models.Module{}).Select("id").Where("code = ? AND is_active = ?", req.Resource, true)).
+		Find(&accesses).Error; err != nil {
+		return nil, fmt.Errorf("failed to load position module access: %w", err)
+	}
+
+	var positionIDs []string
+	for _, rma := range accesses {
+		if ok, err := s.checkModulePermissions(rma.Permissions, req.Action); err == nil && ok {
+			positionIDs = append(positionIDs, *rma.PositionID)
+		}
+	}
+	if len(positionIDs) == 0 {
+		return granted, nil
+	}
+
+	var userPositions []models.UserPosition
+	if err := s.db.Preload("Position").
+		Where("position_id IN ?", positionIDs).
+		Where("is_active = ?", true).
+		Where("start_date <= ?", now).
+		Where("(end_date IS NULL OR end_date >= ?)", now).
+		Find(&userPositions).Error; err != nil {
+		return nil, fmt.Errorf("failed to load user positions: %w", err)
+	}
+
+	for _, up := range userPositions {
+		if _, ok := granted[up.UserID]; ok {
+			continue
+		}
+		positionName := up.PositionID
+		if up.Position != nil {
+			positionName = up.Position.Name
+		}
+		granted[up.UserID] = &PermissionCheckResult{
+			Allowed:    true,
+			Source:     PermissionSourcePosition,
+			SourceID:   up.PositionID,
+			SourceName: fmt.Sprintf("Position: %s", positionName),
+		}
+	}
+
+	return granted, nil
+}
+
+// capableByRole returns users holding a role that grants one of permissionIDs, directly or
+// through a parent role it inherits permissions from, with the granting role as source
+func (s *PermissionResolverService) capableByRole(permissionIDs []string) (map[string]*PermissionCheckResult, error) {
+	granted := make(map[string]*PermissionCheckResult)
+	if len(permissionIDs) == 0 {
+		return granted, nil
+	}
+
+	now := clock.Now()
+	query := `
+		WITH RECURSIVE granting_roles AS (
+			SELECT rp.role_id, rp.role_id AS source_role_id, 0 AS depth
+			FROM public.role_permissions rp
+			WHERE rp.permission_id IN ?
+			AND rp.is_granted = true
+			AND rp.effective_from <= ?
+			AND (rp.effective_until IS NULL OR rp.effective_until >= ?)
+
+			UNION
+
+			SELECT rh.role_id, gr.source_role_id, gr.depth + 1
+			FROM public.role_hierarchy rh
+			INNER JOIN granting_roles gr ON rh.parent_role_id = gr.role_id
+			WHERE rh.inherit_permissions = true
+			AND gr.depth < 10
+		)
+		SELECT ur.user_id, gr.source_role_id, r.name AS source_role_name
+		FROM public.user_roles ur
+		INNER JOIN granting_roles gr ON gr.role_id = ur.role_id
+		INNER JOIN public.roles r ON r.id = gr.source_role_id
+		WHERE ur.is_active = true
+		AND ur.effective_from <= ?
+		AND (ur.effective_until IS NULL OR ur.effective_until >= ?)
+		ORDER BY ur.user_id, gr.depth
+	`
+
+	var rows []struct {
+		UserID         string
+		SourceRoleID   string
+		SourceRoleName string
+	}
+	if err := s.db.Clauses(database.Read).Raw(query, permissionIDs, now, now, now, now).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to expand role grants: %w", err)
+	}
+
+	for _, row := range rows {
+		if _, ok := granted[row.UserID]; ok {
+			continue
+		}
+		granted[row.UserID] = &PermissionCheckResult{
+			Allowed:    true,
+			Source:     PermissionSourceRole,
+			SourceID:   row.SourceRoleID,
+			SourceName: fmt.Sprintf("Role: %s", row.SourceRoleName),
+		}
+	}
+
+	return granted, nil
+}
+
+// capableByDelegation returns delegates of current permission delegations that cover the request
+// and whose delegator holds it without delegations, evaluated like checkDelegationPermission
+func (s *PermissionResolverService) capableByDelegation(req PermissionCheckRequest, delegatorHolds func(userID string) bool) (map[string]*PermissionCheckResult, error) {
+	granted := make(map[string]*PermissionCheckResult)
+
+	now := clock.Now()
+	var delegations []models.Delegation
+	if err := s.db.Preload("Delegator").
+		Where("type = ?", models.DelegationTypePermission).
+		Where("is_active = ?", true).
+		Where("effective_from <= ?", now).
+		Where("(effective_until IS NULL OR effective_until >= ?)", now).
+		Order("effective_from ASC").
+		Find(&delegations).Error; err != nil {
+		return nil, fmt.Errorf("failed to load delegations: %w", err)
+	}
+
+	for i := range delegations {
+		d := &delegations[i]
+		if _, ok := granted[d.DelegateID]; ok || !delegatorHolds(d.DelegatorID) {
+			continue
+		}
+
+		permissions, err := s.getDelegatedPermissions(d)
+		if err != nil {
+			return nil, err
+		}
+		for _, perm := range permissions {
+			if !s.permissionMatches(perm, req) {
+				continue
+			}
+			if req.Scope != nil && !s.isScopeCompatible(perm.Scope, req.Scope) {
+				continue
+			}
+			granted[d.DelegateID] = &PermissionCheckResult{
+				Allowed:    true,
+				Source:     PermissionSourceDelegation,
+				SourceID:   d.ID,
+				SourceName: fmt.Sprintf("Delegation from %s", delegatorName(d)),
+			}
+			break
+		}
+	}
+
+	return granted, nil
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultCodeSuggestions = 5
+	maxCodeSuggestions     = 20
+	maxCodeLength          = 50 // matches the varchar(50) code columns of roles and positions
+)
+
+var (
+	codePrefixInvalidChars = regexp.MustCompile(`[^A-Z0-9_-]+`)
+	likeEscaper            = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+)
+
+// normalizeCodePrefix upper-cases a prefix and turns spaces and other separators into
+// underscores, e.g. "wali kelas" -> "WALI_KELAS"
+func normalizeCodePrefix(prefix string) string {
+	prefix = strings.ToUpper(strings.TrimSpace(prefix))
+	return strings.Trim(codePrefixInvalidChars.ReplaceAllString(prefix, "_"), "_-")
+}
+
+// suggestAvailableCodes returns up to count codes for table that are not taken yet. The bare
+// prefix comes first when it is free, followed by PREFIX_<n> numbered after the highest
+// existing suffix, so suggestions never fill gaps left by deleted records.
+func suggestAvailableCodes(db *gorm.DB, table, prefix string, count int) ([]string, error) {
+	prefix = normalizeCodePrefix(prefix)
+	if len(prefix) < 2 {
+		return nil, errors.New("prefix kode minimal 2 karakter")
+	}
+	if count < 1 || count > maxCodeSuggestions {
+		count = defaultCodeSuggestions
+	}
+
+	var existing []string
+	if err := db.Table(table).
+		Where("code = ? OR code LIKE ? ESCAPE '\\'", prefix, likeEscaper.Replace(prefix+"_")+"%").
+		Pluck("code", &existing).Error; err != nil {
+		return nil, fmt.Errorf("gagal mengambil kode yang sudah digunakan: %w", err)
+	}
+
+	taken := make(map[string]bool, len(existing))
+	highest := 0
+	for _, code := range existing {
+		taken[code] = true
+		if n, err := strconv.Atoi(strings.TrimPrefix(code, prefix+"_")); err == nil && n > highest {
+			highest = n
+		}
+	}
+
+	codes := make([]string, 0, count)
+	if !taken[prefix] {
+		codes = append(codes, prefix)
+	}
+	for n := highest + 1; len(codes) < count; n++ {
+		code := fmt.Sprintf("%s_%d", prefix, n)
+		if len(code) > maxCodeLength {
+			break
+		}
+		if !taken[code] {
+			codes = append(codes, code)
+		}
+	}
+
+	return codes, nil
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// DataScope restricts a list query to the rows a caller may see under the scope of
+// their permission on the listed resource. A nil *DataScope means no restriction.
+type DataScope struct {
+	UserID string
+	Scope  models.PermissionScope
+}
+
+// ScopeChecker is implemented by PermissionResolverService and by PermissionCacheService.ScopeChecker
+type ScopeChecker interface {
+	HasPermissionWithScope(userID, resource string, action models.PermissionAction, scope models.PermissionScope) (bool, error)
+}
+
+// dataScopesBroadestFirst is the order scopes are tried in by HighestDataScope
+var dataScopesBroadestFirst = []models.PermissionScope{
+	models.PermissionScopeAll,
+	models.PermissionScopeSchool,
+	models.PermissionScopeDepartment,
+	models.PermissionScopeOwn,
+}
+
+// HighestDataScope returns the broadest scope at which userID holds resource:action.
+// A grant without a scope satisfies every scope, so it resolves to ALL. A user holding
+// the permission at no scope at all gets OWN, the narrowest view.
+func HighestDataScope(checker ScopeChecker, userID, resource string, action models.PermissionAction) (models.PermissionScope, error) {
+	for _, scope := range dataScopesBroadestFirst {
+		allowed, err := checker.HasPermissionWithScope(userID, resource, action, scope)
+		if err != nil {
+			return "", err
+		}
+		if allowed {
+			return scope, nil
+		}
+	}
+	return models.PermissionScopeOwn, nil
+}
+
+// VisibleUsers returns a query over public.users (aliased u) restricted to the users visible
+// under the scope, or nil when the scope is ALL and nothing needs to be filtered. Callers pick
+// the column they join on, e.g. VisibleUsers(db).Select("u.email").
+//   - OWN: only the caller
+//   - DEPARTMENT: the caller and users holding an active position in one of the caller's departments
+//   - SCHOOL: the caller and users holding an active position in one of the caller's schools
+func (d *DataScope) VisibleUsers(db *gorm.DB) *gorm.DB {
+	if d == nil || d.Scope == models.PermissionScopeAll {
+		return nil
+	}
+
+	users := db.Table("public.users u")
+
+	var column string
+	switch d.Scope {
+	case models.PermissionScopeDepartment:
+		column = "department_id"
+	case models.PermissionScopeSchool:
+		column = "school_id"
+	default:
+		return users.Where("u.id = ?", d.UserID)
+	}
+
+	callerUnits := db.Table("public.user_positions up").
+		Select("p."+column).
+		Joins("JOIN public.positions p ON p.id = up.position_id").
+		Where("up.user_id = ? AND up.is_active = ? AND p."+column+" IS NOT NULL", d.UserID, true).
+		Where("(up.end_date IS NULL OR up.end_date >= NOW())")
+
+	colleagues := db.Table("public.user_positions up2").
+		Select("up2.user_id").
+		Joins("JOIN public.positions p2 ON p2.id = up2.position_id").
+		Where("up2.is_active = ? AND p2."+column+" IN (?)", true, callerUnits).
+		Where("(up2.end_date IS NULL OR up2.end_date >= NOW())")
+
+	return users.Where("(u.id = ? OR u.id IN (?))", d.UserID, colleagues)
+}
+
+// CanSeeUser reports whether userID is an existing user visible under the scope. Under ALL
+// every id is reported visible without a lookup, leaving not-found handling to the caller.
+func (d *DataScope) CanSeeUser(db *gorm.DB, userID string) (bool, error) {
+	users := d.VisibleUsers(db)
+	if users == nil {
+		return true, nil
+	}
+
+	var count int64
+	if err := users.Where("u.id = ?", userID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
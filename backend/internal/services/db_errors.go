@@ -0,0 +1,27 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/lib/pq"
+)
+
+// pgUniqueViolation is the Postgres SQLSTATE for a unique constraint violation
+const pgUniqueViolation = "23505"
+
+// isUniqueViolation reports whether err is a Postgres unique constraint violation,
+// from either the pgx driver used by GORM or lib/pq
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == pgUniqueViolation
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == pgUniqueViolation
+	}
+
+	return false
+}
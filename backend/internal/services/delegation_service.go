@@ -0,0 +1,422 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"backend/internal/clock"
+	"backend/internal/database"
+	"backend/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+// DelegationService handles business logic for delegations
+type DelegationService struct {
+	db              *gorm.DB
+	audit           *AuditService
+	permissionCache *PermissionCacheService
+}
+
+// NewDelegationService creates a new DelegationService instance
+func NewDelegationService(db *gorm.DB) *DelegationService {
+	return &DelegationService{db: db, audit: NewAuditService(db)}
+}
+
+// SetRBACServices sets the RBAC services (for dependency injection after creation)
+func (s *DelegationService) SetRBACServices(cache *PermissionCacheService) {
+	s.permissionCache = cache
+}
+
+// invalidateDelegate clears the cached permissions of a delegation's delegate.
+// Windows that open or close on their own are picked up when cached entries expire.
+func (s *DelegationService) invalidateDelegate(delegateID string) {
+	if s.permissionCache != nil {
+		s.permissionCache.InvalidateUser(delegateID)
+	}
+}
+
+// DelegationListParams represents parameters for listing delegations
+type DelegationListParams struct {
+	Page        int
+	PageSize    int
+	Type        string
+	DelegatorID string
+	DelegateID  string
+	IsActive    *bool
+	Effective   *bool
+	SortBy      string
+	SortOrder   string
+}
+
+// DelegationListResult represents the result of listing delegations
+type DelegationListResult struct {
+	Data       []*models.DelegationListResponse
+	Total      int64
+	Page       int
+	PageSize   int
+	TotalPages int
+}
+
+// CreateDelegation creates a new delegation from the authenticated user to another user
+func (s *DelegationService) CreateDelegation(req models.CreateDelegationRequest, delegatorID string) (*models.Delegation, error) {
+	// Validate delegation type
+	if !req.Type.IsValid() {
+		return nil, errors.New("tipe delegasi tidak valid")
+	}
+
+	// Business rule: Cannot delegate to yourself
+	if req.DelegateID == delegatorID {
+		return nil, errors.New("tidak dapat mendelegasikan wewenang kepada diri sendiri")
+	}
+
+	// Validate delegate exists and is active
+	if err := s.validateDelegate(req.DelegateID); err != nil {
+		return nil, err
+	}
+
+	effectiveFrom := clock.Now()
+	if req.EffectiveFrom != nil {
+		effectiveFrom = *req.EffectiveFrom
+	}
+	if req.EffectiveUntil != nil && !req.EffectiveUntil.After(effectiveFrom) {
+		return nil, errors.New("tanggal berakhir harus setelah tanggal mulai")
+	}
+
+	// Business rule: Permission delegations must say what is delegated, and the
+	// delegator can only delegate a role or permissions they hold themselves
+	if req.Type == models.DelegationTypePermission && req.RoleID == nil && len(req.PermissionIDs) == 0 {
+		return nil, errors.New("delegasi permission harus menyertakan role atau permission yang didelegasikan")
+	}
+	if err := s.validateDelegatedAccess(delegatorID, req.DelegateID, req.RoleID, req.PermissionIDs); err != nil {
+		return nil, err
+	}
+
+	var permissionIDs pq.StringArray
+	if len(req.PermissionIDs) > 0 {
+		permissionIDs = pq.StringArray(req.PermissionIDs)
+	}
+
+	delegation := models.Delegation{
+		ID:             uuid.New().String(),
+		Type:           req.Type,
+		DelegatorID:    delegatorID,
+		DelegateID:     req.DelegateID,
+		Reason:         req.Reason,
+		EffectiveFrom:  effectiveFrom,
+		EffectiveUntil: req.EffectiveUntil,
+		IsActive:       true,
+		Context:        req.Context,
+		RoleID:         req.RoleID,
+		PermissionIDs:  permissionIDs,
+		CreatedBy:      &delegatorID,
+	}
+
+	if err := s.db.Create(&delegation).Error; err != nil {
+		return nil, fmt.Errorf("gagal membuat delegasi: %w", err)
+	}
+
+	s.invalidateDelegate(delegation.DelegateID)
+	s.recordDelegation(delegatorID, models.AuditActionDelegate, nil, &delegation)
+
+	return s.GetDelegationByID(delegation.ID)
+}
+
+// GetDelegations retrieves list of delegations with pagination and filters
+func (s *DelegationService) GetDelegations(params DelegationListParams) (*DelegationListResult, error) {
+	query := s.db.Model(&models.Delegation{})
+
+	// Apply type filter
+	if params.Type != "" {
+		query = query.Where("type = ?", params.Type)
+	}
+
+	// Apply delegator filter
+	if params.DelegatorID != "" {
+		query = query.Where("delegator_id = ?", params.DelegatorID)
+	}
+
+	// Apply delegate filter
+	if params.DelegateID != "" {
+		query = query.Where("delegate_id = ?", params.DelegateID)
+	}
+
+	// Apply active filter
+	if params.IsActive != nil {
+		query = query.Where("is_active = ?", *params.IsActive)
+	}
+
+	// Apply effective filter (active and within the effective date range right now)
+	if params.Effective != nil {
+		now := clock.Now()
+		effective := "is_active = ? AND effective_from <= ? AND (effective_until IS NULL OR effective_until >= ?)"
+		if *params.Effective {
+			query = query.Where(effective, true, now, now)
+		} else {
+			query = query.Not(effective, true, now, now)
+		}
+	}
+
+	page, err := Paginate[models.Delegation](query, PageParams{
+		Page:      params.Page,
+		PageSize:  params.PageSize,
+		SortBy:    params.SortBy,
+		SortOrder: params.SortOrder,
+	}, ListQuery{
+		Noun: "delegasi",
+		SortColumns: map[string]bool{
+			"type": true, "effective_from": true, "effective_until": true,
+			"is_active": true, "created_at": true,
+		},
+		DefaultOrder: "effective_from DESC",
+		Preload: func(db *gorm.DB) *gorm.DB {
+			return db.Preload("Delegator").Preload("Delegate")
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Convert to list response
+	delegationList := make([]*models.DelegationListResponse, len(page.Data))
+	for i, delegation := range page.Data {
+		delegationList[i] = delegation.ToListResponse()
+	}
+
+	return &DelegationListResult{
+		Data:       delegationList,
+		Total:      page.Total,
+		Page:       page.Page,
+		PageSize:   page.PageSize,
+		TotalPages: page.TotalPages,
+	}, nil
+}
+
+// GetDelegationByID retrieves a delegation by ID with relations. It reads the primary so
+// the writes below can return what they stored.
+func (s *DelegationService) GetDelegationByID(id string) (*models.Delegation, error) {
+	var delegation models.Delegation
+	if err := s.db.Clauses(database.Write).Preload("Delegator").
+		Preload("Delegate").
+		Preload("Role").
+		First(&delegation, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("delegasi tidak ditemukan")
+		}
+		return nil, fmt.Errorf("gagal mengambil data delegasi: %w", err)
+	}
+
+	return &delegation, nil
+}
+
+// UpdateDelegation updates a delegation on behalf of its delegator. A revoked delegation
+// cannot be reactivated; an active result is validated like a new delegation.
+func (s *DelegationService) UpdateDelegation(id string, req models.UpdateDelegationRequest, userID string) (*models.Delegation, error) {
+	var delegation models.Delegation
+	if err := s.db.Clauses(database.Write).First(&delegation, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("delegasi tidak ditemukan")
+		}
+		return nil, fmt.Errorf("gagal mengambil data delegasi: %w", err)
+	}
+
+	// Business rule: Only the delegator may change what they delegated
+	if delegation.DelegatorID != userID {
+		return nil, errors.New("hanya pemberi delegasi yang dapat mengubah delegasi ini")
+	}
+
+	before := delegation
+
+	// Business rule: Revocation is final
+	if delegation.RevokedAt != nil && req.IsActive != nil && *req.IsActive {
+		return nil, errors.New("delegasi yang sudah dicabut tidak dapat diaktifkan kembali")
+	}
+
+	// Update fields
+	if req.Reason != nil {
+		delegation.Reason = req.Reason
+	}
+	if req.EffectiveFrom != nil {
+		delegation.EffectiveFrom = *req.EffectiveFrom
+	}
+	if req.EffectiveUntil != nil {
+		delegation.EffectiveUntil = req.EffectiveUntil
+	}
+	if req.IsActive != nil {
+		delegation.IsActive = *req.IsActive
+	}
+	if req.Context != nil {
+		delegation.Context = req.Context
+	}
+
+	if delegation.EffectiveUntil != nil && !delegation.EffectiveUntil.After(delegation.EffectiveFrom) {
+		return nil, errors.New("tanggal berakhir harus setelah tanggal mulai")
+	}
+
+	// Business rule: An active delegation must still be valid, as checked on creation
+	if delegation.IsActive {
+		if err := s.validateDelegate(delegation.DelegateID); err != nil {
+			return nil, err
+		}
+		if err := s.validateDelegatedAccess(delegation.DelegatorID, delegation.DelegateID, delegation.RoleID, delegation.PermissionIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.db.Save(&delegation).Error; err != nil {
+		return nil, fmt.Errorf("gagal memperbarui delegasi: %w", err)
+	}
+
+	s.invalidateDelegate(delegation.DelegateID)
+	s.recordDelegation(userID, models.AuditActionUpdate, &before, &delegation)
+
+	return s.GetDelegationByID(id)
+}
+
+// DeleteDelegation withdraws a delegation on behalf of its delegator, or of an admin holding
+// delegations:delete at ALL scope. The row is kept and revoked rather than deleted, since
+// workflow step history points at it for actions taken on someone's behalf. Deleting a
+// delegation that is no longer active changes nothing.
+func (s *DelegationService) DeleteDelegation(id, deletedBy string, scope *DataScope) error {
+	delegation, err := s.manageableDelegation(id, deletedBy, scope)
+	if err != nil {
+		return err
+	}
+
+	if !delegation.IsActive {
+		return nil
+	}
+	return s.revoke(delegation, deletedBy)
+}
+
+// RevokeDelegation ends a delegation immediately, keeping it for history. Only its delegator,
+// or an admin holding delegations:update at ALL scope, may revoke it.
+func (s *DelegationService) RevokeDelegation(id, revokedBy string, scope *DataScope) (*models.Delegation, error) {
+	delegation, err := s.manageableDelegation(id, revokedBy, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	if !delegation.IsActive {
+		return nil, errors.New("delegasi sudah tidak aktif")
+	}
+
+	if err := s.revoke(delegation, revokedBy); err != nil {
+		return nil, err
+	}
+
+	return s.GetDelegationByID(id)
+}
+
+// manageableDelegation loads a delegation for revocation by userID: its delegator, or a caller
+// whose scope on the action is ALL. A nil scope is not restricted, as for list queries.
+func (s *DelegationService) manageableDelegation(id, userID string, scope *DataScope) (*models.Delegation, error) {
+	var delegation models.Delegation
+	if err := s.db.Clauses(database.Write).First(&delegation, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("delegasi tidak ditemukan")
+		}
+		return nil, fmt.Errorf("gagal mengambil data delegasi: %w", err)
+	}
+
+	if delegation.DelegatorID != userID && scope != nil && scope.Scope != models.PermissionScopeAll {
+		return nil, errors.New("hanya pemberi delegasi yang dapat mencabut delegasi ini")
+	}
+
+	return &delegation, nil
+}
+
+// revoke deactivates a delegation, cuts its effective window short at now and records it
+// in the audit log
+func (s *DelegationService) revoke(delegation *models.Delegation, revokedBy string) error {
+	before := *delegation
+	now := clock.Now()
+	updates := map[string]interface{}{
+		"is_active":  false,
+		"revoked_at": now,
+		"revoked_by": revokedBy,
+	}
+	// Cut the effective window short so date-based checks agree with is_active
+	if delegation.EffectiveUntil == nil || delegation.EffectiveUntil.After(now) {
+		updates["effective_until"] = now
+	}
+
+	if err := s.db.Model(delegation).Updates(updates).Error; err != nil {
+		return fmt.Errorf("gagal mencabut delegasi: %w", err)
+	}
+
+	after := before
+	after.IsActive = false
+	after.RevokedAt = &now
+	after.RevokedBy = &revokedBy
+	if _, cut := updates["effective_until"]; cut {
+		after.EffectiveUntil = &now
+	}
+
+	s.invalidateDelegate(delegation.DelegateID)
+	s.recordDelegation(revokedBy, models.AuditActionRevoke, &before, &after)
+
+	return nil
+}
+
+// recordDelegation writes an audit entry for a delegation change, filed under its delegate
+// since that is whose access changes
+func (s *DelegationService) recordDelegation(actorID string, action models.AuditAction, before, after *models.Delegation) {
+	entry := AuditEntry{
+		ActorID:    actorID,
+		Action:     action,
+		TargetType: "delegation",
+	}
+	if before != nil {
+		entry.TargetID, entry.TargetUserID, entry.Before = before.ID, &before.DelegateID, before
+	}
+	if after != nil {
+		entry.TargetID, entry.TargetUserID, entry.After = after.ID, &after.DelegateID, after
+	}
+	s.audit.RecordEntry(entry)
+}
+
+// validateDelegate checks that the delegate exists and is active
+func (s *DelegationService) validateDelegate(delegateID string) error {
+	var delegate models.User
+	if err := s.db.Clauses(database.Write).First(&delegate, "id = ?", delegateID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("penerima delegasi tidak ditemukan")
+		}
+		return fmt.Errorf("gagal mengambil data penerima delegasi: %w", err)
+	}
+	if !delegate.IsActive {
+		return errors.New("penerima delegasi tidak aktif")
+	}
+	return nil
+}
+
+// validateDelegatedAccess checks that the delegator currently holds the delegated role
+// and may grant every delegated permission
+func (s *DelegationService) validateDelegatedAccess(delegatorID, delegateID string, roleID *string, permissionIDs []string) error {
+	if roleID != nil {
+		var count int64
+		now := clock.Now()
+		if err := s.db.Clauses(database.Write).Model(&models.UserRole{}).
+			Where("user_id = ? AND role_id = ? AND is_active = ?", delegatorID, *roleID, true).
+			Where("effective_from <= ?", now).
+			Where("(effective_until IS NULL OR effective_until >= ?)", now).
+			Count(&count).Error; err != nil {
+			return fmt.Errorf("gagal memeriksa role pemberi delegasi: %w", err)
+		}
+		if count == 0 {
+			return errors.New("tidak dapat mendelegasikan role yang tidak anda miliki")
+		}
+	}
+
+	resolver := NewPermissionResolverService(database.Primary(s.db))
+	for _, permissionID := range permissionIDs {
+		if err := resolver.CanGrantPermission(delegatorID, delegateID, permissionID); err != nil {
+			return fmt.Errorf("tidak dapat mendelegasikan permission: %w", err)
+		}
+	}
+
+	return nil
+}
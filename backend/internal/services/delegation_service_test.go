@@ -0,0 +1,77 @@
+package services
+
+import (
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"backend/internal/models"
+)
+
+// TestRevokeDelegationOwnership checks that a delegation can only be revoked or deleted by
+// its delegator or by a caller holding the action at ALL scope, and that deleting keeps the
+// row: it is revoked with an UPDATE, never removed, and recorded in the audit log.
+func TestRevokeDelegationOwnership(t *testing.T) {
+	tests := []struct {
+		name    string
+		caller  string
+		scope   models.PermissionScope
+		allowed bool
+	}{
+		{"delegator", "kepsek", models.PermissionScopeOwn, true},
+		{"other user", "guru", models.PermissionScopeOwn, false},
+		{"department admin", "guru", models.PermissionScopeDepartment, false},
+		{"admin at ALL scope", "admin", models.PermissionScopeAll, true},
+	}
+
+	for _, tt := range tests {
+		for _, del := range []bool{false, true} {
+			name := tt.name + " revokes"
+			if del {
+				name = tt.name + " deletes"
+			}
+			t.Run(name, func(t *testing.T) {
+				db := newFakeDB(t, func(query string, _ []driver.NamedValue) fakeRows {
+					if strings.HasPrefix(query, "SELECT") && fromTable(query, "delegations") {
+						return fakeRows{
+							columns: []string{"id", "delegator_id", "delegate_id", "is_active"},
+							values:  [][]driver.Value{{"d-1", "kepsek", "wakasek", true}},
+						}
+					}
+					return fakeRows{}
+				})
+				s := NewDelegationService(db.DB)
+				scope := &DataScope{UserID: tt.caller, Scope: tt.scope}
+
+				var err error
+				if del {
+					err = s.DeleteDelegation("d-1", tt.caller, scope)
+				} else {
+					_, err = s.RevokeDelegation("d-1", tt.caller, scope)
+				}
+
+				if !tt.allowed {
+					if err == nil || err.Error() != "hanya pemberi delegasi yang dapat mencabut delegasi ini" {
+						t.Fatalf("got %v, want the delegator-only refusal", err)
+					}
+					if db.count("UPDATE") > 0 {
+						t.Error("a refused caller still revoked the delegation")
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("got %v, want the delegation revoked", err)
+				}
+				if db.count(`UPDATE "public"."delegations" SET`) != 1 {
+					t.Error("delegation was not revoked with one UPDATE")
+				}
+				if db.count("DELETE") > 0 {
+					t.Error("delegation row was deleted instead of kept for history")
+				}
+				if db.count(`INSERT INTO "public"."audit_logs"`) != 1 {
+					t.Error("revocation was not recorded in the audit log")
+				}
+			})
+		}
+	}
+}
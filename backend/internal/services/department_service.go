@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"strings"
 
+	"backend/internal/database"
+	"backend/internal/helpers"
 	"backend/internal/models"
 
 	"github.com/google/uuid"
@@ -65,9 +67,11 @@ func (s *DepartmentService) getUsername(userID string) string {
 
 // CreateDepartment creates a new department with validation
 func (s *DepartmentService) CreateDepartment(req models.CreateDepartmentRequest, userID string) (*models.Department, error) {
+	db := database.Primary(s.db)
+
 	// Business rule: Check if code already exists
 	var existing models.Department
-	if err := s.db.Where("code = ?", req.Code).First(&existing).Error; err == nil {
+	if err := db.Where("code = ?", req.Code).First(&existing).Error; err == nil {
 		return nil, errors.New("kode departemen sudah digunakan")
 	}
 
@@ -102,12 +106,12 @@ func (s *DepartmentService) CreateDepartment(req models.CreateDepartmentRequest,
 	}
 
 	// Persist to database
-	if err := s.db.Create(&department).Error; err != nil {
+	if err := db.Create(&department).Error; err != nil {
 		return nil, fmt.Errorf("gagal membuat departemen: %w", err)
 	}
 
 	// Load relations for response
-	s.db.Preload("School").Preload("Parent").First(&department, "id = ?", department.ID)
+	db.Preload("School").Preload("Parent").First(&department, "id = ?", department.ID)
 
 	return &department, nil
 }
@@ -152,12 +156,8 @@ func (s *DepartmentService) GetDepartments(params DepartmentListParams) (*Depart
 			"code": true, "name": true, "level": true,
 			"created_at": true, "is_active": true, "parent_id": true,
 		}
-		if validSortColumns[params.SortBy] {
-			direction := "ASC"
-			if strings.ToLower(params.SortOrder) == "desc" {
-				direction = "DESC"
-			}
-			query = query.Order(fmt.Sprintf("%s %s", params.SortBy, direction))
+		if order := helpers.SafeOrder(params.SortBy, params.SortOrder, validSortColumns); order != "" {
+			query = query.Order(order)
 		}
 	}
 
@@ -192,10 +192,11 @@ func (s *DepartmentService) GetDepartments(params DepartmentListParams) (*Depart
 	}, nil
 }
 
-// GetDepartmentByID retrieves a department by ID with relations
+// GetDepartmentByID retrieves a department by ID with relations. It reads the primary since
+// updates act on the row it returns.
 func (s *DepartmentService) GetDepartmentByID(id string) (*models.Department, error) {
 	var department models.Department
-	if err := s.db.Preload("School").Preload("Parent").First(&department, "id = ?", id).Error; err != nil {
+	if err := s.db.Clauses(database.Write).Preload("School").Preload("Parent").First(&department, "id = ?", id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("departemen tidak ditemukan")
 		}
@@ -245,6 +246,8 @@ func (s *DepartmentService) GetDepartmentTree() ([]*models.DepartmentTreeRespons
 
 // UpdateDepartment updates a department with validation
 func (s *DepartmentService) UpdateDepartment(id string, req models.UpdateDepartmentRequest, userID string) (*models.Department, error) {
+	db := database.Primary(s.db)
+
 	// Find existing department
 	department, err := s.GetDepartmentByID(id)
 	if err != nil {
@@ -254,7 +257,7 @@ func (s *DepartmentService) UpdateDepartment(id string, req models.UpdateDepartm
 	// Business rule: Check if code is being changed and already in use
 	if req.Code != nil && *req.Code != department.Code {
 		var existing models.Department
-		if err := s.db.Where("code = ? AND id != ?", *req.Code, id).First(&existing).Error; err == nil {
+		if err := db.Where("code = ? AND id != ?", *req.Code, id).First(&existing).Error; err == nil {
 			return nil, errors.New("kode departemen sudah digunakan")
 		}
 	}
@@ -351,18 +354,75 @@ func (s *DepartmentService) UpdateDepartment(id string, req models.UpdateDepartm
 	}
 
 	// Use Select + Updates to force update of specified fields
-	if err := s.db.Model(&department).Select(selectFields).Updates(updateMap).Error; err != nil {
+	if err := db.Model(&department).Select(selectFields).Updates(updateMap).Error; err != nil {
 		return nil, fmt.Errorf("gagal memperbarui departemen: %w", err)
 	}
 
 	// Load relations for response
-	s.db.Preload("School").Preload("Parent").First(&department, "id = ?", department.ID)
+	db.Preload("School").Preload("Parent").First(&department, "id = ?", department.ID)
+
+	return department, nil
+}
+
+// MoveDepartment reparents a department together with its whole subtree. The new parent
+// must exist, must not be the department itself or one of its descendants, and must belong
+// to the same school when both departments have one.
+func (s *DepartmentService) MoveDepartment(id string, req models.MoveDepartmentRequest, userID string) (*models.Department, error) {
+	db := database.Primary(s.db)
+
+	department, err := s.GetDepartmentByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var newParentID *string
+	if req.ParentID != nil && *req.ParentID != "" {
+		newParentID = req.ParentID
+	}
+
+	if newParentID != nil {
+		var parent models.Department
+		if err := db.First(&parent, "id = ?", *newParentID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, errors.New("parent departemen tidak ditemukan")
+			}
+			return nil, fmt.Errorf("gagal mengambil data parent departemen: %w", err)
+		}
+
+		// Business rule: The new parent cannot be inside the subtree being moved
+		if err := s.checkCircularReference(id, *newParentID); err != nil {
+			return nil, err
+		}
+
+		// Business rule: A subtree stays within its school
+		if department.SchoolID != nil && parent.SchoolID != nil && *department.SchoolID != *parent.SchoolID {
+			return nil, errors.New("parent departemen harus berada di sekolah yang sama")
+		}
+	}
+
+	// Nothing to do when the parent does not change
+	if (newParentID == nil && department.ParentID == nil) ||
+		(newParentID != nil && department.ParentID != nil && *newParentID == *department.ParentID) {
+		return department, nil
+	}
+
+	username := s.getUsername(userID)
+	if err := db.Model(department).
+		Select("parent_id", "modified_by").
+		Updates(map[string]interface{}{"parent_id": newParentID, "modified_by": &username}).Error; err != nil {
+		return nil, fmt.Errorf("gagal memindahkan departemen: %w", err)
+	}
+
+	// Load relations for response
+	db.Preload("School").Preload("Parent").First(department, "id = ?", department.ID)
 
 	return department, nil
 }
 
 // DeleteDepartment deletes a department with validation
 func (s *DepartmentService) DeleteDepartment(id string) error {
+	db := database.Primary(s.db)
+
 	// Check if department exists
 	department, err := s.GetDepartmentByID(id)
 	if err != nil {
@@ -371,20 +431,24 @@ func (s *DepartmentService) DeleteDepartment(id string) error {
 
 	// Business rule: Check if department has children
 	var childCount int64
-	s.db.Model(&models.Department{}).Where("parent_id = ?", id).Count(&childCount)
+	if err := db.Model(&models.Department{}).Where("parent_id = ?", id).Count(&childCount).Error; err != nil {
+		return fmt.Errorf("gagal memeriksa sub-departemen: %w", err)
+	}
 	if childCount > 0 {
 		return errors.New("tidak dapat menghapus departemen yang memiliki sub-departemen")
 	}
 
 	// Business rule: Check if department has positions
 	var positionCount int64
-	s.db.Model(&models.Position{}).Where("department_id = ?", id).Count(&positionCount)
+	if err := db.Model(&models.Position{}).Where("department_id = ?", id).Count(&positionCount).Error; err != nil {
+		return fmt.Errorf("gagal memeriksa posisi departemen: %w", err)
+	}
 	if positionCount > 0 {
 		return errors.New("tidak dapat menghapus departemen yang memiliki posisi")
 	}
 
 	// Delete department
-	if err := s.db.Delete(&department).Error; err != nil {
+	if err := db.Delete(&department).Error; err != nil {
 		return fmt.Errorf("gagal menghapus departemen: %w", err)
 	}
 
@@ -395,7 +459,7 @@ func (s *DepartmentService) DeleteDepartment(id string) error {
 
 func (s *DepartmentService) validateSchoolExists(id string) error {
 	var school models.School
-	if err := s.db.First(&school, "id = ?", id).Error; err != nil {
+	if err := s.db.Clauses(database.Write).First(&school, "id = ?", id).Error; err != nil {
 		return errors.New("sekolah tidak ditemukan")
 	}
 	return nil
@@ -403,7 +467,7 @@ func (s *DepartmentService) validateSchoolExists(id string) error {
 
 func (s *DepartmentService) validateDepartmentExists(id string) error {
 	var department models.Department
-	if err := s.db.First(&department, "id = ?", id).Error; err != nil {
+	if err := s.db.Clauses(database.Write).First(&department, "id = ?", id).Error; err != nil {
 		return err
 	}
 	return nil
@@ -438,7 +502,7 @@ func (s *DepartmentService) checkCircularReference(departmentID, parentID string
 
 	// Load all departments for circular reference check
 	var allDepartments []models.Department
-	if err := s.db.Find(&allDepartments).Error; err != nil {
+	if err := s.db.Clauses(database.Write).Find(&allDepartments).Error; err != nil {
 		return fmt.Errorf("gagal memeriksa referensi circular: %w", err)
 	}
 
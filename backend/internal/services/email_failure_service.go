@@ -0,0 +1,75 @@
+package services
+
+import (
+	"fmt"
+
+	"backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// EmailFailureService handles business logic for undeliverable emails
+type EmailFailureService struct {
+	db *gorm.DB
+}
+
+// NewEmailFailureService creates a new EmailFailureService instance
+func NewEmailFailureService(db *gorm.DB) *EmailFailureService {
+	return &EmailFailureService{db: db}
+}
+
+// EmailFailureListResult represents a page of email failures
+type EmailFailureListResult struct {
+	Data       []models.EmailFailure
+	Total      int64
+	Page       int
+	PageSize   int
+	TotalPages int
+}
+
+// GetEmailFailures lists emails that failed permanently, newest first
+func (s *EmailFailureService) GetEmailFailures(filter models.EmailFailureFilter) (*EmailFailureListResult, error) {
+	query := s.db.Model(&models.EmailFailure{})
+	if filter.Recipient != nil && *filter.Recipient != "" {
+		query = query.Where("recipient ILIKE ?", "%"+*filter.Recipient+"%")
+	}
+	if filter.Template != nil && *filter.Template != "" {
+		query = query.Where("template = ?", *filter.Template)
+	}
+
+	// Count total
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("gagal menghitung email gagal: %w", err)
+	}
+
+	// Apply pagination
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 || filter.Limit > 200 {
+		filter.Limit = 50
+	}
+
+	var failures []models.EmailFailure
+	if err := query.
+		Order("created_at DESC").
+		Offset((filter.Page - 1) * filter.Limit).
+		Limit(filter.Limit).
+		Find(&failures).Error; err != nil {
+		return nil, fmt.Errorf("gagal mengambil email gagal: %w", err)
+	}
+
+	totalPages := int(total) / filter.Limit
+	if int(total)%filter.Limit > 0 {
+		totalPages++
+	}
+
+	return &EmailFailureListResult{
+		Data:       failures,
+		Total:      total,
+		Page:       filter.Page,
+		PageSize:   filter.Limit,
+		TotalPages: totalPages,
+	}, nil
+}
@@ -4,6 +4,7 @@ import (
 	"backend/internal/models"
 	"errors"
 	"fmt"
+	"log/slog"
 
 	"gorm.io/gorm"
 )
@@ -352,12 +353,10 @@ func (s *EscalationPreventionService) ValidateRolePermissionAssignment(assignerI
 	// 0. SUPERADMIN bypass - users with hierarchy_level = 0 can manage all permissions
 	assignerLevel, err := s.resolver.GetUserHighestRoleLevel(assignerID)
 	if err != nil {
-		fmt.Printf("[DEBUG] ValidateRolePermissionAssignment: failed to get assigner role level for userID=%s, error=%v\n", assignerID, err)
 		return fmt.Errorf("failed to get assigner role level: %w", err)
 	}
-	fmt.Printf("[DEBUG] ValidateRolePermissionAssignment: userID=%s, assignerLevel=%d\n", assignerID, assignerLevel)
 	if assignerLevel == 0 {
-		fmt.Printf("[DEBUG] ValidateRolePermissionAssignment: SUPERADMIN bypass activated for userID=%s\n", assignerID)
+		slog.Debug("escalation prevention: superadmin bypass", "user_id", assignerID, "role_id", roleID, "permission_id", permissionID)
 		return nil // SUPERADMIN bypasses all escalation checks
 	}
 
@@ -466,6 +465,51 @@ func (s *EscalationPreventionService) ValidateRoleModification(modifierID, roleI
 	return nil
 }
 
+// ValidateRoleImport validates if user can create an imported role. The role does not exist
+// yet, so the checks of ValidateRoleModification and ValidateRolePermissionAssignment are run
+// against its definition: its level and its parents' levels must not be above the importer's,
+// and the importer must hold every permission it grants.
+func (s *EscalationPreventionService) ValidateRoleImport(importerID string, role models.Role, parents []models.Role, permissions []models.Permission) error {
+	// 0. SUPERADMIN bypass - users with hierarchy_level = 0 can import any role
+	importerLevel, err := s.resolver.GetUserHighestRoleLevel(importerID)
+	if err != nil {
+		return fmt.Errorf("failed to get importer role level: %w", err)
+	}
+	if importerLevel == 0 {
+		return nil // SUPERADMIN bypasses all escalation checks
+	}
+
+	// 1. Check hierarchy - cannot create a role with higher privilege, directly or inherited
+	for _, r := range append([]models.Role{role}, parents...) {
+		if r.HierarchyLevel < importerLevel {
+			return &EscalationError{
+				Message:  fmt.Sprintf("privilege escalation denied: cannot import role with hierarchy level %d (your level: %d)", r.HierarchyLevel, importerLevel),
+				UserID:   importerID,
+				TargetID: r.Code,
+				Action:   "role_hierarchy_violation",
+			}
+		}
+	}
+
+	// 2. Check if importer has every permission the role grants
+	for _, permission := range permissions {
+		hasPermission, err := s.resolver.HasPermission(importerID, permission.Resource, permission.Action)
+		if err != nil {
+			return fmt.Errorf("failed to check importer permission: %w", err)
+		}
+		if !hasPermission {
+			return &EscalationError{
+				Message:  fmt.Sprintf("privilege escalation denied: cannot import role with permission '%s' that you don't have", permission.Code),
+				UserID:   importerID,
+				TargetID: role.Code,
+				Action:   "role_permission_escalation",
+			}
+		}
+	}
+
+	return nil
+}
+
 // ValidateSelfEscalation checks if a user is trying to escalate their own privileges
 func (s *EscalationPreventionService) ValidateSelfEscalation(userID, targetUserID string) error {
 	if userID == targetUserID {
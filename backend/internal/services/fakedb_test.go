@@ -0,0 +1,150 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// fakeRows is the result a fakeHandler returns for one statement; a non-nil err fails it
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+	err     error
+}
+
+// fakeHandler answers a statement; queries it does not care about get an empty fakeRows
+type fakeHandler func(query string, args []driver.NamedValue) fakeRows
+
+// fakeDB records every statement sent through a gorm.DB backed by a fakeHandler, so service
+// code can run against canned rows without Postgres
+type fakeDB struct {
+	*gorm.DB
+
+	mu      sync.Mutex
+	queries []string
+}
+
+// newFakeDB opens a Postgres-dialect gorm.DB whose statements are answered by handler
+func newFakeDB(t testing.TB, handler fakeHandler) *fakeDB {
+	t.Helper()
+
+	f := &fakeDB{}
+	conn := sql.OpenDB(fakeConnector{db: f, handler: handler})
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: conn}), &gorm.Config{
+		Logger:                 logger.Discard,
+		SkipDefaultTransaction: true,
+	})
+	if err != nil {
+		t.Fatalf("open fake db: %v", err)
+	}
+	f.DB = db
+	t.Cleanup(func() { conn.Close() })
+	return f
+}
+
+// count returns how many recorded statements contain substr
+func (f *fakeDB) count(substr string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	n := 0
+	for _, q := range f.queries {
+		if strings.Contains(q, substr) {
+			n++
+		}
+	}
+	return n
+}
+
+func (f *fakeDB) record(query string) {
+	f.mu.Lock()
+	f.queries = append(f.queries, query)
+	f.mu.Unlock()
+}
+
+// fromTable reports whether query selects from the given table (without schema)
+func fromTable(query, table string) bool {
+	return strings.Contains(query, `FROM "public"."`+table+`"`)
+}
+
+type fakeConnector struct {
+	db      *fakeDB
+	handler fakeHandler
+}
+
+func (c fakeConnector) Connect(context.Context) (driver.Conn, error) {
+	return &fakeConn{fakeConnector: c}, nil
+}
+
+func (c fakeConnector) Driver() driver.Driver { return fakeDriver{} }
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) {
+	return nil, errors.New("fake driver: open through the connector")
+}
+
+type fakeConn struct {
+	fakeConnector
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("fake driver: prepared statements are not supported")
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) { return fakeTx{}, nil }
+
+// CheckNamedValue accepts every argument as is, like pgx does for slices
+func (c *fakeConn) CheckNamedValue(*driver.NamedValue) error { return nil }
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.db.record(query)
+	rows := c.handler(query, args)
+	if rows.err != nil {
+		return nil, rows.err
+	}
+	return &fakeCursor{rows: rows}, nil
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.db.record(query)
+	if rows := c.handler(query, args); rows.err != nil {
+		return nil, rows.err
+	}
+	return driver.RowsAffected(1), nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeCursor struct {
+	rows fakeRows
+	next int
+}
+
+func (r *fakeCursor) Columns() []string { return r.rows.columns }
+
+func (r *fakeCursor) Close() error { return nil }
+
+func (r *fakeCursor) Next(dest []driver.Value) error {
+	if r.next >= len(r.rows.values) {
+		return io.EOF
+	}
+	copy(dest, r.rows.values[r.next])
+	r.next++
+	return nil
+}
@@ -1,9 +1,14 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
+	"strings"
 
+	"backend/internal/clock"
+	"backend/internal/logger"
 	"backend/internal/models"
 
 	"gorm.io/gorm"
@@ -11,22 +16,29 @@ import (
 
 // KaryawanService handles business logic for employees
 type KaryawanService struct {
-	db *gorm.DB
+	db    *gorm.DB
+	audit *AuditService
 }
 
 // NewKaryawanService creates a new KaryawanService instance
 func NewKaryawanService(db *gorm.DB) *KaryawanService {
-	return &KaryawanService{db: db}
+	return &KaryawanService{db: db, audit: NewAuditService(db)}
 }
 
 // KaryawanListParams represents parameters for listing employees
 type KaryawanListParams struct {
-	Page           int
-	Limit          int
-	Search         string
-	BagianKerja    string
-	JenisKaryawan  string
-	StatusAktif    string
+	Page          int
+	Limit         int
+	Search        string
+	BagianKerja   string
+	BidangKerja   string
+	JenisKaryawan string
+	StatusAktif   string
+	IsActive      *bool
+	// Cursor selects keyset pagination when non-nil; an empty cursor requests the first page
+	Cursor *string
+	// Scope limits the list to employees linked to users the caller may see; nil lists everyone
+	Scope *DataScope
 }
 
 // KaryawanListResult represents the result of listing employees
@@ -36,35 +48,15 @@ type KaryawanListResult struct {
 	Page       int                                `json:"page"`
 	Limit      int                                `json:"limit"`
 	TotalPages int                                `json:"total_pages"`
+	NextCursor string                             `json:"next_cursor,omitempty"`
 }
 
+// karyawanKeysetColumn orders employees by name; nip breaks ties between equal names
+var karyawanKeysetColumn = keysetColumn{expr: "COALESCE(nama, '')", sqlType: "text"}
+
 // GetKaryawans retrieves list of employees with pagination and filters
 func (s *KaryawanService) GetKaryawans(params KaryawanListParams) (*KaryawanListResult, error) {
-	query := s.db.Model(&models.DataKaryawan{})
-
-	// Apply search filter (search by name, email, or NIP)
-	if params.Search != "" {
-		query = query.Where("nama ILIKE ? OR email ILIKE ? OR nip ILIKE ?",
-			"%"+params.Search+"%", "%"+params.Search+"%", "%"+params.Search+"%")
-	}
-
-	// Apply bagian_kerja filter
-	if params.BagianKerja != "" {
-		query = query.Where("bagian_kerja = ?", params.BagianKerja)
-	}
-
-	// Apply jenis_karyawan filter
-	if params.JenisKaryawan != "" {
-		query = query.Where("jenis_karyawan = ?", params.JenisKaryawan)
-	}
-
-	// Apply status_aktif filter (default to 'Aktif' if not specified)
-	if params.StatusAktif != "" {
-		query = query.Where("status_aktif = ?", params.StatusAktif)
-	} else {
-		// Default filter: only return active employees
-		query = query.Where("status_aktif = ?", "Aktif")
-	}
+	query := s.applyKaryawanListFilters(s.db.Model(&models.DataKaryawan{}), params)
 
 	// Count total records
 	var total int64
@@ -80,6 +72,10 @@ func (s *KaryawanService) GetKaryawans(params KaryawanListParams) (*KaryawanList
 		params.Limit = 10
 	}
 
+	if params.Cursor != nil {
+		return s.getKaryawansByCursor(query, params, total)
+	}
+
 	// Apply pagination
 	offset := (params.Page - 1) * params.Limit
 	query = query.Offset(offset).Limit(params.Limit)
@@ -114,6 +110,179 @@ func (s *KaryawanService) GetKaryawans(params KaryawanListParams) (*KaryawanList
 	}, nil
 }
 
+// applyKaryawanListFilters applies the list filters and data scope shared by GetKaryawans and ExportKaryawans
+func (s *KaryawanService) applyKaryawanListFilters(query *gorm.DB, params KaryawanListParams) *gorm.DB {
+	// Apply search filter (search by name, email, or NIP)
+	if search := strings.TrimSpace(params.Search); search != "" {
+		pattern := "%" + search + "%"
+		query = query.Where("(nama ILIKE ? OR email ILIKE ? OR nip ILIKE ?)", pattern, pattern, pattern)
+	}
+
+	// Apply bagian_kerja filter
+	if params.BagianKerja != "" {
+		query = query.Where("bagian_kerja = ?", params.BagianKerja)
+	}
+
+	// Apply bidang_kerja filter
+	if params.BidangKerja != "" {
+		query = query.Where("bidang_kerja = ?", params.BidangKerja)
+	}
+
+	// Apply jenis_karyawan filter
+	if params.JenisKaryawan != "" {
+		query = query.Where("jenis_karyawan = ?", params.JenisKaryawan)
+	}
+
+	// Apply status filter: an explicit status_aktif wins, then is_active,
+	// otherwise only active employees are returned
+	switch {
+	case params.StatusAktif != "":
+		query = query.Where("status_aktif = ?", params.StatusAktif)
+	case params.IsActive != nil && !*params.IsActive:
+		query = query.Where("(status_aktif IS NULL OR LOWER(status_aktif) != ?)", "aktif")
+	default:
+		query = query.Where("LOWER(status_aktif) = ?", "aktif")
+	}
+
+	// Apply data scope: employees are matched to users by email
+	if visible := params.Scope.VisibleUsers(s.db); visible != nil {
+		query = query.Where("email IN (?)", visible.Select("u.email"))
+	}
+
+	return query
+}
+
+// getKaryawansByCursor fetches the page after params.Cursor using keyset pagination,
+// so scrolling far into the directory does not make Postgres skip earlier rows
+func (s *KaryawanService) getKaryawansByCursor(query *gorm.DB, params KaryawanListParams, total int64) (*KaryawanListResult, error) {
+	cursor, err := decodeCursor(*params.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fetch one extra row to learn whether another page follows
+	var karyawans []models.DataKaryawan
+	if err := applyKeyset(query, karyawanKeysetColumn, "nip", false, cursor).
+		Limit(params.Limit + 1).
+		Find(&karyawans).Error; err != nil {
+		return nil, fmt.Errorf("gagal mengambil data karyawan: %w", err)
+	}
+
+	var nextCursor string
+	if len(karyawans) > params.Limit {
+		karyawans = karyawans[:params.Limit]
+		last := karyawans[len(karyawans)-1]
+		name := ""
+		if last.Nama != nil {
+			name = *last.Nama
+		}
+		nextCursor = encodeCursor(name, last.NIP)
+	}
+
+	karyawanList := make([]*models.DataKaryawanListResponse, len(karyawans))
+	for i, karyawan := range karyawans {
+		karyawanList[i] = karyawan.ToListResponse()
+	}
+
+	return &KaryawanListResult{
+		Data:       karyawanList,
+		Total:      total,
+		Limit:      params.Limit,
+		NextCursor: nextCursor,
+	}, nil
+}
+
+// karyawanExportBatchSize is how many employees an export reads per query
+const karyawanExportBatchSize = 500
+
+// karyawanExportColumns are the directory columns an export selects, in file order
+var karyawanExportColumns = []string{
+	"nip", "nama", "email", "no_ponsel", "bagian_kerja", "bidang_kerja",
+	"lokasi", "jenis_karyawan", "status_aktif", "tgl_mulai_bekerja",
+}
+
+// ExportKaryawans streams the employees matching the list filters and data scope to w as CSV or
+// XLSX, without pagination. Employees are read in batches ordered by NIP, so the whole directory is
+// never held in memory. The export is recorded in the audit log. It returns the number of rows exported.
+func (s *KaryawanService) ExportKaryawans(ctx context.Context, params KaryawanListParams, format string, w io.Writer, exportedBy, ipAddress, userAgent string) (int, error) {
+	writer, err := newExportWriter(format, w)
+	if err != nil {
+		return 0, err
+	}
+	if err := writer.WriteRow(karyawanExportColumns); err != nil {
+		return 0, fmt.Errorf("gagal menulis export: %w", err)
+	}
+
+	count := 0
+	var writeErr error
+	var batch []models.DataKaryawan
+	result := s.applyKaryawanListFilters(s.db.Model(&models.DataKaryawan{}), params).
+		Select(karyawanExportColumns).
+		FindInBatches(&batch, karyawanExportBatchSize, func(tx *gorm.DB, _ int) error {
+			for _, k := range batch {
+				startDate := ""
+				if k.TglMulaiBekerja != nil {
+					startDate = k.TglMulaiBekerja.In(clock.Location()).Format("2006-01-02")
+				}
+				if err := writer.WriteRow([]string{
+					k.NIP,
+					stringValue(k.Nama),
+					stringValue(k.Email),
+					stringValue(k.NoPonsel),
+					stringValue(k.BagianKerja),
+					stringValue(k.BidangKerja),
+					stringValue(k.Lokasi),
+					stringValue(k.JenisKaryawan),
+					stringValue(k.StatusAktif),
+					startDate,
+				}); err != nil {
+					writeErr = fmt.Errorf("gagal menulis export: %w", err)
+					return writeErr
+				}
+				count++
+			}
+
+			// Flush after every batch so the client receives data while the export runs
+			if err := writer.Flush(); err != nil {
+				writeErr = fmt.Errorf("gagal menulis export: %w", err)
+				return writeErr
+			}
+			return nil
+		})
+	if writeErr != nil {
+		return count, writeErr
+	}
+	if result.Error != nil {
+		return count, fmt.Errorf("gagal mengambil data karyawan: %w", result.Error)
+	}
+	if err := writer.Close(); err != nil {
+		return count, fmt.Errorf("gagal menulis export: %w", err)
+	}
+
+	// Record who exported and with which filters
+	s.audit.RecordEntry(AuditEntry{
+		ActorID:    exportedBy,
+		Action:     models.AuditActionExport,
+		TargetType: "employee",
+		TargetID:   "export",
+		Metadata: map[string]interface{}{
+			"format":         format,
+			"search":         params.Search,
+			"bagian_kerja":   params.BagianKerja,
+			"bidang_kerja":   params.BidangKerja,
+			"jenis_karyawan": params.JenisKaryawan,
+			"status_aktif":   params.StatusAktif,
+			"is_active":      params.IsActive,
+			"row_count":      count,
+		},
+		IPAddress: ipAddress,
+		UserAgent: userAgent,
+		RequestID: logger.RequestID(ctx),
+	})
+
+	return count, nil
+}
+
 // GetKaryawanByNIP retrieves an employee by NIP
 func (s *KaryawanService) GetKaryawanByNIP(nip string) (*models.DataKaryawan, error) {
 	var karyawan models.DataKaryawan
@@ -153,6 +322,17 @@ func (s *KaryawanService) GetFilterOptions() (map[string][]string, error) {
 	}
 	options["jenis_karyawan"] = jenisKaryawanList
 
+	// Get unique bidang_kerja values
+	var bidangKerjaList []string
+	if err := s.db.Model(&models.DataKaryawan{}).
+		Distinct("bidang_kerja").
+		Where("bidang_kerja IS NOT NULL AND bidang_kerja != ''").
+		Order("bidang_kerja").
+		Pluck("bidang_kerja", &bidangKerjaList).Error; err != nil {
+		return nil, fmt.Errorf("gagal mengambil opsi bidang kerja: %w", err)
+	}
+	options["bidang_kerja"] = bidangKerjaList
+
 	// Get unique status_aktif values
 	var statusAktifList []string
 	if err := s.db.Model(&models.DataKaryawan{}).
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"backend/internal/logger"
+	"backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// DefaultKaryawanSyncInterval is how often user accounts are synced with employee statuses
+const DefaultKaryawanSyncInterval = time.Hour
+
+// KaryawanSyncService keeps user account status in line with the linked employee record
+type KaryawanSyncService struct {
+	db         *gorm.DB
+	reactivate bool
+	periodicTask
+}
+
+// NewKaryawanSyncService creates a new KaryawanSyncService instance.
+// When reactivate is true, users deactivated by the sync are reactivated once their
+// employee record is active again; users deactivated manually are never touched.
+func NewKaryawanSyncService(db *gorm.DB, reactivate bool) *KaryawanSyncService {
+	return &KaryawanSyncService{db: db, reactivate: reactivate}
+}
+
+// KaryawanSyncResult represents the outcome of a sync run
+type KaryawanSyncResult struct {
+	Deactivated int `json:"deactivated"`
+	Reactivated int `json:"reactivated"`
+}
+
+// Start runs the status sync periodically in a background goroutine until Stop is called
+func (s *KaryawanSyncService) Start(interval time.Duration) {
+	s.start(interval, func() {
+		if _, err := s.SyncStatuses(context.Background()); err != nil {
+			slog.Warn("employee status sync failed", "error", err)
+		}
+	})
+}
+
+// SyncStatuses deactivates active users whose employee record is no longer active,
+// revoking their sessions, and optionally reactivates users it deactivated earlier.
+// Users without a linked employee record are left alone. Changes are logged under ctx's request ID.
+func (s *KaryawanSyncService) SyncStatuses(ctx context.Context) (*KaryawanSyncResult, error) {
+	result := &KaryawanSyncResult{}
+	log := logger.FromContext(ctx)
+
+	var activeUsers []models.User
+	if err := s.db.Preload("DataKaryawan").
+		Joins("JOIN public.data_karyawan dk ON dk.email = users.email").
+		Where("users.is_active = ?", true).
+		Find(&activeUsers).Error; err != nil {
+		return nil, fmt.Errorf("gagal mengambil pengguna aktif: %w", err)
+	}
+
+	for i := range activeUsers {
+		user := &activeUsers[i]
+		if user.DataKaryawan == nil || user.DataKaryawan.IsActiveEmployee() {
+			continue
+		}
+
+		deactivated, err := s.deactivate(user.ID)
+		if err != nil {
+			log.Warn("failed to deactivate user after employee status change", "user_id", user.ID, "error", err)
+			continue
+		}
+		if deactivated {
+			result.Deactivated++
+			log.Info("employee sync deactivated user",
+				"user_id", user.ID, "nip", user.DataKaryawan.NIP, "status_aktif", stringValue(user.DataKaryawan.StatusAktif))
+		}
+	}
+
+	if s.reactivate {
+		var inactiveUsers []models.User
+		if err := s.db.Preload("DataKaryawan").
+			Joins("JOIN public.data_karyawan dk ON dk.email = users.email").
+			Where("users.is_active = ? AND users.auto_deactivated_at IS NOT NULL", false).
+			Find(&inactiveUsers).Error; err != nil {
+			return result, fmt.Errorf("gagal mengambil pengguna nonaktif: %w", err)
+		}
+
+		for i := range inactiveUsers {
+			user := &inactiveUsers[i]
+			if user.DataKaryawan == nil || !user.DataKaryawan.IsActiveEmployee() {
+				continue
+			}
+
+			update := s.db.Model(&models.User{}).
+				Where("id = ? AND is_active = ? AND auto_deactivated_at IS NOT NULL", user.ID, false).
+				Updates(map[string]interface{}{"is_active": true, "auto_deactivated_at": nil})
+			if update.Error != nil {
+				log.Warn("failed to reactivate user after employee status change", "user_id", user.ID, "error", update.Error)
+				continue
+			}
+			if update.RowsAffected > 0 {
+				result.Reactivated++
+				log.Info("employee sync reactivated user", "user_id", user.ID, "nip", user.DataKaryawan.NIP)
+			}
+		}
+	}
+
+	log.Info("employee sync finished", "deactivated", result.Deactivated, "reactivated", result.Reactivated)
+
+	return result, nil
+}
+
+// deactivate marks a user inactive and revokes all refresh tokens in one transaction.
+// It returns false when the user was already inactive.
+func (s *KaryawanSyncService) deactivate(userID string) (bool, error) {
+	deactivated := false
+	now := time.Now()
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		update := tx.Model(&models.User{}).
+			Where("id = ? AND is_active = ?", userID, true).
+			Updates(map[string]interface{}{"is_active": false, "auto_deactivated_at": now})
+		if update.Error != nil {
+			return fmt.Errorf("gagal menonaktifkan pengguna: %w", update.Error)
+		}
+		if update.RowsAffected == 0 {
+			return nil
+		}
+
+		if err := tx.Model(&models.RefreshToken{}).
+			Where("user_id = ? AND revoked_at IS NULL", userID).
+			Update("revoked_at", now).Error; err != nil {
+			return fmt.Errorf("gagal mencabut sesi pengguna: %w", err)
+		}
+
+		deactivated = true
+		return nil
+	})
+
+	return deactivated, err
+}
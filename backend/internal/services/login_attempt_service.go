@@ -0,0 +1,149 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// maxLoginAttemptSummaryWindow caps the summary window so the aggregation stays cheap
+const maxLoginAttemptSummaryWindow = 30 * 24 * time.Hour
+
+// LoginAttemptService handles read access to recorded login attempts
+type LoginAttemptService struct {
+	db *gorm.DB
+}
+
+// NewLoginAttemptService creates a new LoginAttemptService instance
+func NewLoginAttemptService(db *gorm.DB) *LoginAttemptService {
+	return &LoginAttemptService{db: db}
+}
+
+// LoginAttemptListResult represents a page of login attempts
+type LoginAttemptListResult struct {
+	Data       []models.LoginAttempt
+	Total      int64
+	Page       int
+	PageSize   int
+	TotalPages int
+}
+
+// GetLoginAttempts lists login attempts, newest first
+func (s *LoginAttemptService) GetLoginAttempts(filter models.LoginAttemptFilter) (*LoginAttemptListResult, error) {
+	query := s.db.Model(&models.LoginAttempt{})
+	if filter.Email != nil && *filter.Email != "" {
+		query = query.Where("LOWER(email) = ?", strings.ToLower(strings.TrimSpace(*filter.Email)))
+	}
+	if filter.IPAddress != nil && *filter.IPAddress != "" {
+		query = query.Where("ip_address = ?", strings.TrimSpace(*filter.IPAddress))
+	}
+	if filter.Success != nil {
+		query = query.Where("success = ?", *filter.Success)
+	}
+	if filter.StartDate != nil {
+		query = query.Where("attempted_at >= ?", *filter.StartDate)
+	}
+	if filter.EndDate != nil {
+		query = query.Where("attempted_at <= ?", *filter.EndDate)
+	}
+
+	// Count total
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("gagal menghitung percobaan login: %w", err)
+	}
+
+	// Apply pagination
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 || filter.Limit > 200 {
+		filter.Limit = 50
+	}
+
+	var attempts []models.LoginAttempt
+	if err := query.
+		Order("attempted_at DESC").
+		Offset((filter.Page - 1) * filter.Limit).
+		Limit(filter.Limit).
+		Find(&attempts).Error; err != nil {
+		return nil, fmt.Errorf("gagal mengambil percobaan login: %w", err)
+	}
+
+	totalPages := int(total) / filter.Limit
+	if int(total)%filter.Limit > 0 {
+		totalPages++
+	}
+
+	return &LoginAttemptListResult{
+		Data:       attempts,
+		Total:      total,
+		Page:       filter.Page,
+		PageSize:   filter.Limit,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// GetLoginAttemptSummary counts failed logins per IP address and per email over the last
+// WindowHours, busiest first. Many failures from one IP across many emails suggests
+// credential stuffing; many failures for one email across many IPs suggests a targeted attack.
+func (s *LoginAttemptService) GetLoginAttemptSummary(filter models.LoginAttemptSummaryFilter) (*models.LoginAttemptSummary, error) {
+	window := time.Duration(filter.WindowHours) * time.Hour
+	if window <= 0 {
+		window = 24 * time.Hour
+	}
+	if window > maxLoginAttemptSummaryWindow {
+		window = maxLoginAttemptSummaryWindow
+	}
+	if filter.Limit < 1 || filter.Limit > 100 {
+		filter.Limit = 20
+	}
+
+	end := time.Now()
+	summary := &models.LoginAttemptSummary{
+		WindowStart: end.Add(-window),
+		WindowEnd:   end,
+		ByIP:        []models.LoginFailureCount{},
+		ByEmail:     []models.LoginFailureCount{},
+	}
+
+	inWindow := s.db.Model(&models.LoginAttempt{}).Where("attempted_at >= ?", summary.WindowStart)
+
+	var totals struct {
+		TotalAttempts int64
+		TotalFailures int64
+	}
+	if err := inWindow.Session(&gorm.Session{}).
+		Select("COUNT(*) AS total_attempts, COUNT(*) FILTER (WHERE NOT success) AS total_failures").
+		Scan(&totals).Error; err != nil {
+		return nil, fmt.Errorf("gagal menghitung percobaan login: %w", err)
+	}
+	summary.TotalAttempts = totals.TotalAttempts
+	summary.TotalFailures = totals.TotalFailures
+
+	failures := inWindow.Where("success = ?", false)
+
+	if err := failures.Session(&gorm.Session{}).
+		Select("ip_address AS key, COUNT(*) AS failures, COUNT(DISTINCT LOWER(email)) AS distinct_targets, MAX(attempted_at) AS last_attempt_at").
+		Group("ip_address").
+		Order("failures DESC, last_attempt_at DESC").
+		Limit(filter.Limit).
+		Scan(&summary.ByIP).Error; err != nil {
+		return nil, fmt.Errorf("gagal merangkum percobaan login per IP: %w", err)
+	}
+
+	if err := failures.Session(&gorm.Session{}).
+		Select("LOWER(email) AS key, COUNT(*) AS failures, COUNT(DISTINCT ip_address) AS distinct_targets, MAX(attempted_at) AS last_attempt_at").
+		Group("LOWER(email)").
+		Order("failures DESC, last_attempt_at DESC").
+		Limit(filter.Limit).
+		Scan(&summary.ByEmail).Error; err != nil {
+		return nil, fmt.Errorf("gagal merangkum percobaan login per email: %w", err)
+	}
+
+	return summary, nil
+}
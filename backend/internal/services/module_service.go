@@ -1,13 +1,17 @@
 package services
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
 
+	"backend/internal/clock"
+	"backend/internal/database"
 	"backend/internal/models"
 
 	"github.com/google/uuid"
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
 
@@ -16,11 +20,12 @@ type ModuleService struct {
 	db                   *gorm.DB
 	permissionCache      *PermissionCacheService
 	escalationPrevention *EscalationPreventionService
+	audit                *AuditService
 }
 
 // NewModuleService creates a new ModuleService instance
 func NewModuleService(db *gorm.DB) *ModuleService {
-	return &ModuleService{db: db}
+	return &ModuleService{db: db, audit: NewAuditService(db)}
 }
 
 // NewModuleServiceWithRBAC creates a new ModuleService with RBAC services
@@ -29,6 +34,7 @@ func NewModuleServiceWithRBAC(db *gorm.DB, cache *PermissionCacheService, escala
 		db:                   db,
 		permissionCache:      cache,
 		escalationPrevention: escalation,
+		audit:                NewAuditService(db),
 	}
 }
 
@@ -84,7 +90,7 @@ func (s *ModuleService) getUsername(userID string) string {
 // validateModuleExists checks if a module exists
 func (s *ModuleService) validateModuleExists(moduleID string) error {
 	var module models.Module
-	if err := s.db.First(&module, "id = ?", moduleID).Error; err != nil {
+	if err := s.db.Clauses(database.Write).First(&module, "id = ?", moduleID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return errors.New("module tidak ditemukan")
 		}
@@ -107,9 +113,11 @@ func normalizePath(path *string) *string {
 
 // CreateModule creates a new module with validation
 func (s *ModuleService) CreateModule(req models.CreateModuleRequest, userID string) (*models.Module, error) {
+	db := database.Primary(s.db)
+
 	// Business rule: Check if code already exists
 	var existing models.Module
-	if err := s.db.Where("code = ?", req.Code).First(&existing).Error; err == nil {
+	if err := db.Where("code = ?", req.Code).First(&existing).Error; err == nil {
 		return nil, errors.New("kode module sudah digunakan")
 	}
 
@@ -156,12 +164,16 @@ func (s *ModuleService) CreateModule(req models.CreateModuleRequest, userID stri
 	}
 
 	// Persist to database
-	if err := s.db.Create(&module).Error; err != nil {
+	if err := db.Create(&module).Error; err != nil {
+		// A concurrent create can pass the check above; the unique index catches it
+		if isUniqueViolation(err) {
+			return nil, errors.New("kode module sudah digunakan")
+		}
 		return nil, fmt.Errorf("gagal membuat module: %w", err)
 	}
 
 	// Load relations for response
-	if err := s.db.Preload("Parent").First(&module, "id = ?", module.ID).Error; err != nil {
+	if err := db.Preload("Parent").First(&module, "id = ?", module.ID).Error; err != nil {
 		// Module was created successfully, but failed to reload with relations
 		// Return the module as-is without parent relation
 		return &module, nil
@@ -203,15 +215,14 @@ func (s *ModuleService) GetModules(params ModuleListParams) (*ModuleListResult,
 		query = query.Where("is_visible = ?", *params.IsVisible)
 	}
 
-	// Count total records
-	var total int64
-	if err := query.Count(&total).Error; err != nil {
-		return nil, fmt.Errorf("gagal menghitung total module: %w", err)
-	}
-
-	// Apply sorting with validation to prevent SQL injection
-	if params.SortBy != "" {
-		validSortColumns := map[string]bool{
+	page, err := Paginate[models.Module](query, PageParams{
+		Page:      params.Page,
+		PageSize:  params.PageSize,
+		SortBy:    params.SortBy,
+		SortOrder: params.SortOrder,
+	}, ListQuery{
+		Noun: "module",
+		SortColumns: map[string]bool{
 			"code":       true,
 			"name":       true,
 			"category":   true,
@@ -219,47 +230,25 @@ func (s *ModuleService) GetModules(params ModuleListParams) (*ModuleListResult,
 			"created_at": true,
 			"is_active":  true,
 			"is_visible": true,
-		}
-		if validSortColumns[params.SortBy] {
-			direction := "ASC"
-			if strings.ToUpper(params.SortOrder) == "DESC" {
-				direction = "DESC"
-			}
-			query = query.Order(fmt.Sprintf("%s %s", params.SortBy, direction))
-		}
-	} else {
-		// Default sort by sort_order and name
-		query = query.Order("sort_order ASC, name ASC")
-	}
-
-	// Apply pagination
-	offset := (params.Page - 1) * params.PageSize
-	query = query.Offset(offset).Limit(params.PageSize)
-
-	// Fetch modules
-	var modules []models.Module
-	if err := query.Find(&modules).Error; err != nil {
-		return nil, fmt.Errorf("gagal mengambil data module: %w", err)
+		},
+		DefaultOrder: "sort_order ASC, name ASC",
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// Convert to list response
-	moduleList := make([]*models.ModuleListResponse, len(modules))
-	for i, mod := range modules {
+	moduleList := make([]*models.ModuleListResponse, len(page.Data))
+	for i, mod := range page.Data {
 		moduleList[i] = mod.ToListResponse()
 	}
 
-	// Calculate total pages
-	totalPages := int(total) / params.PageSize
-	if int(total)%params.PageSize > 0 {
-		totalPages++
-	}
-
 	return &ModuleListResult{
 		Data:       moduleList,
-		Total:      total,
-		Page:       params.Page,
-		PageSize:   params.PageSize,
-		TotalPages: totalPages,
+		Total:      page.Total,
+		Page:       page.Page,
+		PageSize:   page.PageSize,
+		TotalPages: page.TotalPages,
 	}, nil
 }
 
@@ -316,21 +305,31 @@ func (s *ModuleService) GetModuleTree() ([]*models.ModuleTreeResponse, error) {
 	return tree, nil
 }
 
+// ErrModuleVersionConflict is returned when a module changed after the client read it
+var ErrModuleVersionConflict = errors.New("module telah diubah oleh pengguna lain, muat ulang lalu coba lagi")
+
 // UpdateModule updates a module with validation
 func (s *ModuleService) UpdateModule(id string, req models.UpdateModuleRequest, userID string) (*models.Module, error) {
+	db := database.Primary(s.db)
+
 	// Find existing module
 	var module models.Module
-	if err := s.db.First(&module, "id = ?", id).Error; err != nil {
+	if err := db.First(&module, "id = ?", id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("module tidak ditemukan")
 		}
 		return nil, fmt.Errorf("gagal mengambil data module: %w", err)
 	}
 
+	// Optimistic locking: refuse to overwrite a version the client has not seen
+	if req.Version != nil && *req.Version != module.Version {
+		return nil, ErrModuleVersionConflict
+	}
+
 	// Business rule: Check if code already exists (if code is being changed)
 	if req.Code != nil && *req.Code != module.Code {
 		var existing models.Module
-		if err := s.db.Where("code = ? AND id != ?", *req.Code, id).First(&existing).Error; err == nil {
+		if err := db.Where("code = ? AND id != ?", *req.Code, id).First(&existing).Error; err == nil {
 			return nil, errors.New("kode module sudah digunakan")
 		}
 	}
@@ -389,9 +388,22 @@ func (s *ModuleService) UpdateModule(id string, req models.UpdateModuleRequest,
 
 	module.UpdatedBy = &username
 
-	// Save to database
-	if err := s.db.Save(&module).Error; err != nil {
-		return nil, fmt.Errorf("gagal memperbarui module: %w", err)
+	// Save only if nobody else saved since the read; the version guard makes the
+	// check-and-write atomic
+	readVersion := module.Version
+	module.Version++
+	result := db.Model(&models.Module{}).
+		Where("id = ? AND version = ?", id, readVersion).
+		Select("*").
+		Updates(&module)
+	if result.Error != nil {
+		if isUniqueViolation(result.Error) {
+			return nil, errors.New("kode module sudah digunakan")
+		}
+		return nil, fmt.Errorf("gagal memperbarui module: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrModuleVersionConflict
 	}
 
 	// Invalidate cache for all users who have access to this module
@@ -400,7 +412,7 @@ func (s *ModuleService) UpdateModule(id string, req models.UpdateModuleRequest,
 	}
 
 	// Load relations for response
-	if err := s.db.Preload("Parent").First(&module, "id = ?", module.ID).Error; err != nil {
+	if err := db.Preload("Parent").First(&module, "id = ?", module.ID).Error; err != nil {
 		// Module was updated successfully, but failed to reload with relations
 		// Return the module as-is without parent relation
 		return &module, nil
@@ -411,9 +423,11 @@ func (s *ModuleService) UpdateModule(id string, req models.UpdateModuleRequest,
 
 // DeleteModule soft deletes a module
 func (s *ModuleService) DeleteModule(id string) error {
+	db := database.Primary(s.db)
+
 	// Find module
 	var module models.Module
-	if err := s.db.First(&module, "id = ?", id).Error; err != nil {
+	if err := db.First(&module, "id = ?", id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return errors.New("module tidak ditemukan")
 		}
@@ -422,7 +436,7 @@ func (s *ModuleService) DeleteModule(id string) error {
 
 	// Business rule: Check if module has children
 	var childCount int64
-	if err := s.db.Model(&models.Module{}).Where("parent_id = ?", id).Count(&childCount).Error; err != nil {
+	if err := db.Model(&models.Module{}).Where("parent_id = ?", id).Count(&childCount).Error; err != nil {
 		return fmt.Errorf("gagal memeriksa child module: %w", err)
 	}
 
@@ -436,7 +450,7 @@ func (s *ModuleService) DeleteModule(id string) error {
 	}
 
 	// Soft delete
-	if err := s.db.Delete(&module).Error; err != nil {
+	if err := db.Delete(&module).Error; err != nil {
 		return fmt.Errorf("gagal menghapus module: %w", err)
 	}
 
@@ -477,11 +491,14 @@ func (s *ModuleService) GetRoleModuleAccesses(roleID string) ([]*models.RoleModu
 	return result, nil
 }
 
-// AssignModuleToRole assigns a module to a role
-func (s *ModuleService) AssignModuleToRole(roleID string, req models.AssignModuleAccessToRoleRequest, userID string) (*models.RoleModuleAccess, error) {
+// AssignModuleToRole assigns a module to a role. subjectID authorizes the change; userID is
+// recorded as the granting actor.
+func (s *ModuleService) AssignModuleToRole(roleID string, req models.AssignModuleAccessToRoleRequest, userID, subjectID string) (*models.RoleModuleAccess, error) {
+	db := database.Primary(s.db)
+
 	// Validate role exists
 	var role models.Role
-	if err := s.db.First(&role, "id = ?", roleID).Error; err != nil {
+	if err := db.First(&role, "id = ?", roleID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("role tidak ditemukan")
 		}
@@ -490,7 +507,7 @@ func (s *ModuleService) AssignModuleToRole(roleID string, req models.AssignModul
 
 	// Validate module exists and is active
 	var module models.Module
-	if err := s.db.First(&module, "id = ?", req.ModuleID).Error; err != nil {
+	if err := db.First(&module, "id = ?", req.ModuleID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("module tidak ditemukan")
 		}
@@ -503,7 +520,7 @@ func (s *ModuleService) AssignModuleToRole(roleID string, req models.AssignModul
 	// Validate position if provided
 	if req.PositionID != nil {
 		var position models.Position
-		if err := s.db.First(&position, "id = ?", *req.PositionID).Error; err != nil {
+		if err := db.First(&position, "id = ?", *req.PositionID).Error; err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				return nil, errors.New("position tidak ditemukan")
 			}
@@ -511,17 +528,32 @@ func (s *ModuleService) AssignModuleToRole(roleID string, req models.AssignModul
 		}
 	}
 
-	// Escalation Prevention: Validate that userID can modify this role's module access
+	// Business rule: The access window must not end before it starts
+	effectiveFrom := clock.Now()
+	if req.EffectiveFrom != nil {
+		effectiveFrom = *req.EffectiveFrom
+	}
+	if req.EffectiveUntil != nil && !req.EffectiveUntil.After(effectiveFrom) {
+		return nil, errors.New("effective_until harus setelah effective_from")
+	}
+
+	// Business rule: Every granted action must be a known PermissionAction
+	permissions, err := normalizeModuleAccessPermissions(req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	// Escalation Prevention: Validate that subjectID can modify this role's module access
 	// User must have at least the same hierarchy level or higher to assign modules to a role
 	if s.escalationPrevention != nil {
-		if err := s.escalationPrevention.ValidateRoleModification(userID, roleID); err != nil {
+		if err := s.escalationPrevention.ValidateRoleModification(subjectID, roleID); err != nil {
 			return nil, fmt.Errorf("escalation prevention: %w", err)
 		}
 	}
 
 	// Check if access already exists
 	var existing models.RoleModuleAccess
-	query := s.db.Where("role_id = ? AND module_id = ?", roleID, req.ModuleID)
+	query := db.Where("role_id = ? AND module_id = ?", roleID, req.ModuleID)
 	if req.PositionID != nil {
 		query = query.Where("position_id = ?", *req.PositionID)
 	} else {
@@ -546,14 +578,18 @@ func (s *ModuleService) AssignModuleToRole(roleID string, req models.AssignModul
 		RoleID:      roleID,
 		ModuleID:    req.ModuleID,
 		PositionID:  req.PositionID,
-		Permissions: req.Permissions,
+		Permissions: permissions,
 		IsActive:    isActive,
 		CreatedBy:   &username,
+
+		EffectiveFrom:  effectiveFrom,
+		EffectiveUntil: req.EffectiveUntil,
 	}
 
-	if err := s.db.Create(&access).Error; err != nil {
+	if err := db.Create(&access).Error; err != nil {
 		return nil, fmt.Errorf("gagal assign module ke role: %w", err)
 	}
+	s.audit.Record(userID, models.AuditActionAssign, "role_module_access", access.ID, nil, access)
 
 	// Invalidate cache for all users with this role
 	if s.permissionCache != nil {
@@ -561,33 +597,83 @@ func (s *ModuleService) AssignModuleToRole(roleID string, req models.AssignModul
 	}
 
 	// Load module relation for response
-	s.db.Preload("Module").First(&access, "id = ?", access.ID)
+	db.Preload("Module").First(&access, "id = ?", access.ID)
 
 	return &access, nil
 }
 
-// RevokeModuleFromRole revokes a module access from a role
-func (s *ModuleService) RevokeModuleFromRole(roleID string, accessID string, userID string) error {
+// normalizeModuleAccessPermissions validates a RoleModuleAccess permissions document and
+// upper-cases its actions. Both accepted shapes are kept: an object of action -> granted
+// ({"READ": true}) or an array of actions (["READ"]). Unknown actions are rejected so a
+// typo cannot silently grant nothing.
+func normalizeModuleAccessPermissions(raw datatypes.JSON) (datatypes.JSON, error) {
+	normalize := func(action string) (string, error) {
+		normalized := models.PermissionAction(strings.ToUpper(strings.TrimSpace(action)))
+		if !normalized.IsValid() {
+			return "", fmt.Errorf("action permission tidak dikenal: %q", action)
+		}
+		return string(normalized), nil
+	}
+
+	var permMap map[string]bool
+	if err := json.Unmarshal(raw, &permMap); err == nil {
+		normalized := make(map[string]bool, len(permMap))
+		for action, granted := range permMap {
+			key, err := normalize(action)
+			if err != nil {
+				return nil, err
+			}
+			normalized[key] = normalized[key] || granted
+		}
+		return json.Marshal(normalized)
+	}
+
+	var permArray []string
+	if err := json.Unmarshal(raw, &permArray); err == nil {
+		seen := make(map[string]bool, len(permArray))
+		normalized := make([]string, 0, len(permArray))
+		for _, action := range permArray {
+			key, err := normalize(action)
+			if err != nil {
+				return nil, err
+			}
+			if !seen[key] {
+				seen[key] = true
+				normalized = append(normalized, key)
+			}
+		}
+		return json.Marshal(normalized)
+	}
+
+	return nil, errors.New("format permissions tidak valid: gunakan objek {\"READ\": true} atau array [\"READ\"]")
+}
+
+// RevokeModuleFromRole revokes a module access from a role, authorized as subjectID and
+// audited as userID
+func (s *ModuleService) RevokeModuleFromRole(roleID string, accessID string, userID, subjectID string) error {
+	db := database.Primary(s.db)
+
 	// Find the access
 	var access models.RoleModuleAccess
-	if err := s.db.Where("id = ? AND role_id = ?", accessID, roleID).First(&access).Error; err != nil {
+	if err := db.Where("id = ? AND role_id = ?", accessID, roleID).First(&access).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return errors.New("module access tidak ditemukan")
 		}
 		return fmt.Errorf("gagal mengambil data module access: %w", err)
 	}
 
-	// Escalation Prevention: Validate that userID can modify this role's module access
+	// Escalation Prevention: Validate that subjectID can modify this role's module access
 	if s.escalationPrevention != nil {
-		if err := s.escalationPrevention.ValidateRoleModification(userID, roleID); err != nil {
+		if err := s.escalationPrevention.ValidateRoleModification(subjectID, roleID); err != nil {
 			return fmt.Errorf("escalation prevention: %w", err)
 		}
 	}
 
 	// Delete the access
-	if err := s.db.Delete(&access).Error; err != nil {
+	if err := db.Delete(&access).Error; err != nil {
 		return fmt.Errorf("gagal mencabut module dari role: %w", err)
 	}
+	s.audit.Record(userID, models.AuditActionRevoke, "role_module_access", access.ID, access, nil)
 
 	// Invalidate cache for all users with this role
 	if s.permissionCache != nil {
@@ -601,7 +687,7 @@ func (s *ModuleService) RevokeModuleFromRole(roleID string, accessID string, use
 func (s *ModuleService) invalidateCacheForRoleUsers(roleID string) {
 	// Find all users with this role
 	var userRoles []models.UserRole
-	if err := s.db.Where("role_id = ? AND is_active = true", roleID).Find(&userRoles).Error; err != nil {
+	if err := s.db.Clauses(database.Write).Where("role_id = ? AND is_active = true", roleID).Find(&userRoles).Error; err != nil {
 		return // Silently fail - cache will eventually expire
 	}
 
@@ -615,7 +701,7 @@ func (s *ModuleService) invalidateCacheForRoleUsers(roleID string) {
 func (s *ModuleService) invalidateCacheForModuleUsers(moduleID string) {
 	// Find all roles that have access to this module
 	var moduleAccesses []models.RoleModuleAccess
-	if err := s.db.Where("module_id = ? AND is_active = true", moduleID).Find(&moduleAccesses).Error; err != nil {
+	if err := s.db.Clauses(database.Write).Where("module_id = ? AND is_active = true", moduleID).Find(&moduleAccesses).Error; err != nil {
 		return // Silently fail - cache will eventually expire
 	}
 
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"fmt"
+
+	"backend/internal/helpers"
+
+	"gorm.io/gorm"
+)
+
+// defaultPageSize is used when a caller passes a page size below 1
+const defaultPageSize = 10
+
+// PageParams are the paging and sorting inputs shared by the offset-paginated list endpoints
+type PageParams struct {
+	Page      int
+	PageSize  int
+	SortBy    string
+	SortOrder string
+}
+
+// ListQuery describes how Paginate sorts and loads one kind of record
+type ListQuery struct {
+	Noun         string                  // record name used in error messages, e.g. "role"
+	SortColumns  map[string]bool         // columns clients may sort by
+	DefaultOrder string                  // ORDER BY used when sort_by is empty or not allowed
+	Preload      func(*gorm.DB) *gorm.DB // optional; applied to the fetch only, not the count
+}
+
+// PageResult is one page of records with its pagination metadata
+type PageResult[T any] struct {
+	Data       []T
+	Total      int64
+	Page       int
+	PageSize   int
+	TotalPages int
+}
+
+// Paginate counts the rows matched by query, orders them by the requested column (falling
+// back to list.DefaultOrder when the column is empty or not allowed) and fetches one page.
+// query must carry the model and all filters. Page numbers below 1 mean the first page.
+func Paginate[T any](query *gorm.DB, params PageParams, list ListQuery) (*PageResult[T], error) {
+	if params.Page < 1 {
+		params.Page = 1
+	}
+	if params.PageSize < 1 {
+		params.PageSize = defaultPageSize
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("gagal menghitung total %s: %w", list.Noun, err)
+	}
+
+	// SafeOrder only returns columns from the allow-list, preventing SQL injection
+	if order := helpers.SafeOrder(params.SortBy, params.SortOrder, list.SortColumns); order != "" {
+		query = query.Order(order)
+	} else if list.DefaultOrder != "" {
+		query = query.Order(list.DefaultOrder)
+	}
+
+	if list.Preload != nil {
+		query = list.Preload(query)
+	}
+
+	var rows []T
+	offset := (params.Page - 1) * params.PageSize
+	if err := query.Offset(offset).Limit(params.PageSize).Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("gagal mengambil data %s: %w", list.Noun, err)
+	}
+
+	totalPages := int(total) / params.PageSize
+	if int(total)%params.PageSize > 0 {
+		totalPages++
+	}
+
+	return &PageResult[T]{
+		Data:       rows,
+		Total:      total,
+		Page:       params.Page,
+		PageSize:   params.PageSize,
+		TotalPages: totalPages,
+	}, nil
+}
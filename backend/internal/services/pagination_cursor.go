@@ -0,0 +1,68 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded
+var ErrInvalidCursor = errors.New("cursor tidak valid")
+
+// listCursor is a keyset pagination position: the sort key and id of the last row
+// of the previous page. Clients treat the encoded form as opaque.
+type listCursor struct {
+	Key string `json:"k"`
+	ID  string `json:"id"`
+}
+
+// encodeCursor encodes a keyset position as an opaque URL-safe string
+func encodeCursor(key, id string) string {
+	raw, _ := json.Marshal(listCursor{Key: key, ID: id})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// decodeCursor decodes a cursor produced by encodeCursor. An empty cursor means the first page.
+func decodeCursor(cursor string) (*listCursor, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c listCursor
+	if err := json.Unmarshal(raw, &c); err != nil || c.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// keysetColumn is a sort expression usable for keyset pagination
+type keysetColumn struct {
+	expr    string // SQL expression rows are ordered by; must not be NULL
+	sqlType string // Postgres type the cursor key is cast to when compared
+}
+
+// applyKeyset orders the query by the column and the id tie-breaker and, when a cursor is
+// given, restricts it to rows after that position
+func applyKeyset(query *gorm.DB, column keysetColumn, idColumn string, desc bool, cursor *listCursor) *gorm.DB {
+	direction, comparison := "ASC", ">"
+	if desc {
+		direction, comparison = "DESC", "<"
+	}
+
+	if cursor != nil {
+		query = query.Where(
+			fmt.Sprintf("(%s, %s) %s (CAST(? AS %s), ?)", column.expr, idColumn, comparison, column.sqlType),
+			cursor.Key, cursor.ID,
+		)
+	}
+
+	return query.Order(column.expr + " " + direction).Order(idColumn + " " + direction)
+}
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"sync"
+	"time"
+)
+
+// periodicTask runs a function on a ticker in a background goroutine and can be
+// stopped cleanly. Background services embed it to get a Stop method.
+type periodicTask struct {
+	mu   sync.Mutex
+	stop chan struct{}
+	done chan struct{}
+}
+
+// start launches run every interval until Stop is called. Calling start on a task
+// that is already running does nothing.
+func (t *periodicTask) start(interval time.Duration, run func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.stop != nil {
+		return
+	}
+	t.stop = make(chan struct{})
+	t.done = make(chan struct{})
+
+	go func(stop <-chan struct{}, done chan<- struct{}) {
+		defer close(done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				run()
+			}
+		}
+	}(t.stop, t.done)
+}
+
+// Stop signals the background goroutine to exit and waits for a run that is in
+// progress to finish. It is safe to call on a task that was never started.
+func (t *periodicTask) Stop() {
+	t.mu.Lock()
+	stop, done := t.stop, t.done
+	t.stop, t.done = nil, nil
+	t.mu.Unlock()
+
+	if stop == nil {
+		return
+	}
+	close(stop)
+	<-done
+}
@@ -1,9 +1,13 @@
 package services
 
 import (
+	"backend/internal/database"
+	"backend/internal/logger"
 	"backend/internal/models"
+	"context"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"gorm.io/gorm"
@@ -22,29 +26,81 @@ type PermissionCacheService struct {
 	ttl      time.Duration
 	db       *gorm.DB
 	resolver *PermissionResolverService
+	hits     uint64
+	misses   uint64
+	events   *PermissionEventBroker
+
+	// Stale-while-revalidate: expired entries younger than staleWindow are served
+	// while one background refresh per key recomputes them
+	staleWindow time.Duration
+	staleHits   uint64
+	refreshing  map[string]bool // guarded by mu
+	refreshWG   sync.WaitGroup
+	generation  uint64 // bumped under mu on every invalidation
+	checks      *AccessCheckLogService
+
+	// Read-after-invalidate: for primaryWindow after a user's cache is invalidated, their
+	// checks resolve on the primary so a lagging read replica cannot re-cache old access
+	primary          *PermissionResolverService
+	primaryWindow    time.Duration
+	invalidatedAt    map[string]time.Time // guarded by mu
+	allInvalidatedAt time.Time            // guarded by mu
+
+	// Startup warmup (see permission_cache_warmup.go)
+	warmupInterval time.Duration
+	warmup         warmupProgress
+	warmedEntries  uint64
+	warmupWG       sync.WaitGroup
+	stopWarmup     chan struct{}
+	stopped        bool // guarded by warmup.mu
 }
 
-// CacheConfig holds cache configuration
+// CacheConfig holds cache configuration.
+//
+// TTL bounds how long a result can outlive a missed invalidation; explicit invalidations
+// (role, position, permission changes) take effect immediately regardless. With
+// StaleWhileRevalidate > 0, a result up to TTL+StaleWhileRevalidate old may still be
+// served once while its refresh runs: lower latency and no stampede on expiry, at the
+// cost of a longer worst-case window of outdated access.
+//
+// With a read replica configured, checks resolve on the replica. For PrimaryAfterInvalidation
+// after a user's cache is invalidated, their checks resolve on the primary instead, so a
+// revocation cannot be undone by re-caching the replica's older rows. Replica lag longer than
+// that window can still re-cache a revoked grant, for up to TTL.
 type CacheConfig struct {
-	TTL             time.Duration
-	CleanupInterval time.Duration
+	TTL                      time.Duration
+	StaleWhileRevalidate     time.Duration // 0 disables serving expired entries
+	CleanupInterval          time.Duration
+	WarmupInterval           time.Duration // pause between users during a warmup
+	PrimaryAfterInvalidation time.Duration // 0 always resolves through the given resolver
 }
 
 // DefaultCacheConfig returns default cache configuration
 func DefaultCacheConfig() CacheConfig {
 	return CacheConfig{
-		TTL:             5 * time.Minute,
-		CleanupInterval: 10 * time.Minute,
+		TTL:                      5 * time.Minute,
+		CleanupInterval:          10 * time.Minute,
+		WarmupInterval:           200 * time.Millisecond,
+		PrimaryAfterInvalidation: 30 * time.Second,
 	}
 }
 
 // NewPermissionCacheService creates a new permission cache service
 func NewPermissionCacheService(db *gorm.DB, resolver *PermissionResolverService, config CacheConfig) *PermissionCacheService {
 	service := &PermissionCacheService{
-		cache:    make(map[string]*PermissionCacheEntry),
-		ttl:      config.TTL,
-		db:       db,
-		resolver: resolver,
+		cache:          make(map[string]*PermissionCacheEntry),
+		ttl:            config.TTL,
+		staleWindow:    config.StaleWhileRevalidate,
+		refreshing:     make(map[string]bool),
+		db:             db,
+		resolver:       resolver,
+		events:         NewPermissionEventBroker(),
+		checks:         NewAccessCheckLogService(db),
+		primary:        NewPermissionResolverService(database.Primary(resolver.db)),
+		primaryWindow:  config.PrimaryAfterInvalidation,
+		invalidatedAt:  make(map[string]time.Time),
+		warmupInterval: config.WarmupInterval,
+		stopWarmup:     make(chan struct{}),
 	}
 
 	// Start background cleanup goroutine
@@ -70,10 +126,33 @@ func (s *PermissionCacheService) cleanup() {
 
 	now := time.Now()
 	for key, entry := range s.cache {
-		if now.After(entry.ExpiresAt) {
+		// Keep expired entries while they can still be served stale
+		if now.After(entry.ExpiresAt.Add(s.staleWindow)) {
 			delete(s.cache, key)
 		}
 	}
+	for userID, at := range s.invalidatedAt {
+		if now.Sub(at) >= s.primaryWindow {
+			delete(s.invalidatedAt, userID)
+		}
+	}
+}
+
+// resolverLocked returns the resolver a check for userID runs on: the primary within
+// primaryWindow of the user's (or everyone's) last invalidation, otherwise the configured
+// one. The caller must hold s.mu.
+func (s *PermissionCacheService) resolverLocked(userID string, now time.Time) *PermissionResolverService {
+	if s.primaryWindow <= 0 {
+		return s.resolver
+	}
+	at := s.invalidatedAt[userID]
+	if s.allInvalidatedAt.After(at) {
+		at = s.allInvalidatedAt
+	}
+	if !at.IsZero() && now.Sub(at) < s.primaryWindow {
+		return s.primary
+	}
+	return s.resolver
 }
 
 // buildCacheKey creates a unique cache key for a permission check
@@ -85,85 +164,180 @@ func buildCacheKey(userID string, req PermissionCheckRequest) string {
 	return key
 }
 
-// CheckPermission checks permission with caching
-func (s *PermissionCacheService) CheckPermission(userID string, req PermissionCheckRequest) (*PermissionCheckResult, error) {
+// CheckPermission checks permission with caching. ctx is the request the check is made
+// for; log lines written on its behalf carry its request ID.
+func (s *PermissionCacheService) CheckPermission(ctx context.Context, userID string, req PermissionCheckRequest) (*PermissionCheckResult, error) {
 	cacheKey := buildCacheKey(userID, req)
 
 	// Try to get from cache
+	now := time.Now()
 	s.mu.RLock()
-	if entry, ok := s.cache[cacheKey]; ok {
-		if time.Now().Before(entry.ExpiresAt) {
-			s.mu.RUnlock()
-			return entry.Result, nil
+	cached, stale, ok := s.lookup(cacheKey, now)
+	generation := s.generation
+	resolver := s.resolverLocked(userID, now)
+	s.mu.RUnlock()
+
+	if ok {
+		if stale {
+			atomic.AddUint64(&s.staleHits, 1)
+			s.refreshAsync(ctx, userID, req)
+		} else {
+			atomic.AddUint64(&s.hits, 1)
 		}
+		s.checks.Record(ctx, userID, req, cached)
+		return cached, nil
 	}
-	s.mu.RUnlock()
 
 	// Cache miss or expired - resolve permission
-	result, err := s.resolver.CheckPermission(userID, req)
+	atomic.AddUint64(&s.misses, 1)
+	result, err := resolver.CheckPermission(userID, req)
 	if err != nil {
 		return nil, err
 	}
 
 	// Store in cache
 	s.mu.Lock()
-	s.cache[cacheKey] = &PermissionCacheEntry{
-		Result:    result,
-		ExpiresAt: time.Now().Add(s.ttl),
-	}
+	s.storeLocked(cacheKey, result, generation)
 	s.mu.Unlock()
 
+	s.checks.Record(ctx, userID, req, result)
 	return result, nil
 }
 
 // CheckPermissionBatch checks multiple permissions with caching
-func (s *PermissionCacheService) CheckPermissionBatch(userID string, requests []PermissionCheckRequest) (map[string]*PermissionCheckResult, error) {
+func (s *PermissionCacheService) CheckPermissionBatch(ctx context.Context, userID string, requests []PermissionCheckRequest) (map[string]*PermissionCheckResult, error) {
 	results := make(map[string]*PermissionCheckResult)
-	var uncached []PermissionCheckRequest
+	var uncached, stale []PermissionCheckRequest
 
 	// First pass: check cache
+	now := time.Now()
 	s.mu.RLock()
+	generation := s.generation
+	resolver := s.resolverLocked(userID, now)
 	for _, req := range requests {
 		cacheKey := buildCacheKey(userID, req)
 		resultKey := buildPermissionKey(req)
 
-		if entry, ok := s.cache[cacheKey]; ok {
-			if time.Now().Before(entry.ExpiresAt) {
-				results[resultKey] = entry.Result
-				continue
+		if cached, isStale, ok := s.lookup(cacheKey, now); ok {
+			results[resultKey] = cached
+			if isStale {
+				stale = append(stale, req)
 			}
+			continue
 		}
 		uncached = append(uncached, req)
 	}
 	s.mu.RUnlock()
 
-	// Resolve uncached permissions
-	for _, req := range uncached {
-		result, err := s.resolver.CheckPermission(userID, req)
-		if err != nil {
-			return nil, fmt.Errorf("failed to check permission: %w", err)
-		}
+	atomic.AddUint64(&s.hits, uint64(len(requests)-len(uncached)-len(stale)))
+	atomic.AddUint64(&s.staleHits, uint64(len(stale)))
+	atomic.AddUint64(&s.misses, uint64(len(uncached)))
+	for _, req := range stale {
+		s.refreshAsync(ctx, userID, req)
+	}
 
+	if len(uncached) == 0 {
+		s.recordBatch(ctx, userID, requests, results)
+		return results, nil
+	}
+
+	// Resolve uncached permissions in one batch so the role hierarchy is walked once
+	resolved, err := resolver.CheckPermissionBatch(userID, uncached)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check permission: %w", err)
+	}
+
+	for _, req := range uncached {
 		cacheKey := buildCacheKey(userID, req)
 		resultKey := buildPermissionKey(req)
+		result := resolved[resultKey]
 
 		// Store in cache
 		s.mu.Lock()
-		s.cache[cacheKey] = &PermissionCacheEntry{
-			Result:    result,
-			ExpiresAt: time.Now().Add(s.ttl),
-		}
+		s.storeLocked(cacheKey, result, generation)
 		s.mu.Unlock()
 
 		results[resultKey] = result
 	}
 
+	s.recordBatch(ctx, userID, requests, results)
 	return results, nil
 }
 
+// lookup returns the cached result for key and whether it is past its TTL. ok is false
+// when there is no usable entry: none at all, or expired beyond the stale window.
+// The caller must hold s.mu.
+func (s *PermissionCacheService) lookup(key string, now time.Time) (result *PermissionCheckResult, stale, ok bool) {
+	entry, found := s.cache[key]
+	if !found {
+		return nil, false, false
+	}
+	if now.Before(entry.ExpiresAt) {
+		return entry.Result, false, true
+	}
+	if s.staleWindow > 0 && now.Before(entry.ExpiresAt.Add(s.staleWindow)) {
+		return entry.Result, true, true
+	}
+	return nil, false, false
+}
+
+// storeLocked caches a result resolved while the cache was at the given generation.
+// A result resolved before an invalidation is dropped rather than stored, so it cannot
+// resurrect the access the invalidation removed. The caller must hold s.mu for writing.
+func (s *PermissionCacheService) storeLocked(key string, result *PermissionCheckResult, generation uint64) {
+	if s.generation != generation {
+		return
+	}
+	s.cache[key] = &PermissionCacheEntry{
+		Result:    result,
+		ExpiresAt: time.Now().Add(s.ttl),
+	}
+}
+
+// refreshAsync recomputes a stale entry in the background. Concurrent requests for the
+// same key share one refresh, so an expiring hot entry does not stampede the database.
+func (s *PermissionCacheService) refreshAsync(ctx context.Context, userID string, req PermissionCheckRequest) {
+	cacheKey := buildCacheKey(userID, req)
+
+	s.mu.Lock()
+	if s.refreshing[cacheKey] {
+		s.mu.Unlock()
+		return
+	}
+	s.refreshing[cacheKey] = true
+	generation := s.generation
+	resolver := s.resolverLocked(userID, time.Now())
+	s.refreshWG.Add(1)
+	s.mu.Unlock()
+
+	log := logger.FromContext(ctx)
+	go func() {
+		defer s.refreshWG.Done()
+		result, err := resolver.CheckPermission(userID, req)
+
+		s.mu.Lock()
+		delete(s.refreshing, cacheKey)
+		if err == nil {
+			s.storeLocked(cacheKey, result, generation)
+		}
+		s.mu.Unlock()
+
+		if err != nil {
+			log.Warn("permission cache refresh failed", "cache_key", cacheKey, "error", err)
+		}
+	}()
+}
+
+// recordBatch records the sensitive checks of a batch, cached or freshly resolved
+func (s *PermissionCacheService) recordBatch(ctx context.Context, userID string, requests []PermissionCheckRequest, results map[string]*PermissionCheckResult) {
+	for _, req := range requests {
+		s.checks.Record(ctx, userID, req, results[buildPermissionKey(req)])
+	}
+}
+
 // HasPermission is a convenience method with caching
-func (s *PermissionCacheService) HasPermission(userID, resource string, action models.PermissionAction) (bool, error) {
-	result, err := s.CheckPermission(userID, PermissionCheckRequest{
+func (s *PermissionCacheService) HasPermission(ctx context.Context, userID, resource string, action models.PermissionAction) (bool, error) {
+	result, err := s.CheckPermission(ctx, userID, PermissionCheckRequest{
 		Resource: resource,
 		Action:   action,
 	})
@@ -174,8 +348,8 @@ func (s *PermissionCacheService) HasPermission(userID, resource string, action m
 }
 
 // HasPermissionWithScope checks permission with scope and caching
-func (s *PermissionCacheService) HasPermissionWithScope(userID, resource string, action models.PermissionAction, scope models.PermissionScope) (bool, error) {
-	result, err := s.CheckPermission(userID, PermissionCheckRequest{
+func (s *PermissionCacheService) HasPermissionWithScope(ctx context.Context, userID, resource string, action models.PermissionAction, scope models.PermissionScope) (bool, error) {
+	result, err := s.CheckPermission(ctx, userID, PermissionCheckRequest{
 		Resource: resource,
 		Action:   action,
 		Scope:    &scope,
@@ -186,25 +360,57 @@ func (s *PermissionCacheService) HasPermissionWithScope(userID, resource string,
 	return result.Allowed, nil
 }
 
-// InvalidateUser invalidates all cached permissions for a user
+// ScopeChecker returns the cache as a ScopeChecker whose checks are made for ctx
+func (s *PermissionCacheService) ScopeChecker(ctx context.Context) ScopeChecker {
+	return requestScopeChecker{cache: s, ctx: ctx}
+}
+
+// requestScopeChecker binds a request context to the cache's scope checks
+type requestScopeChecker struct {
+	cache *PermissionCacheService
+	ctx   context.Context
+}
+
+func (c requestScopeChecker) HasPermissionWithScope(userID, resource string, action models.PermissionAction, scope models.PermissionScope) (bool, error) {
+	return c.cache.HasPermissionWithScope(c.ctx, userID, resource, action, scope)
+}
+
+// InvalidateUser invalidates all cached permissions for a user and notifies the
+// user's open event streams so their client can re-fetch menus
 func (s *PermissionCacheService) InvalidateUser(userID string) {
 	s.mu.Lock()
-	defer s.mu.Unlock()
-
+	s.generation++
+	s.invalidatedAt[userID] = time.Now()
 	prefix := fmt.Sprintf("perm:%s:", userID)
 	for key := range s.cache {
 		if len(key) >= len(prefix) && key[:len(prefix)] == prefix {
 			delete(s.cache, key)
 		}
 	}
+	s.mu.Unlock()
+
+	s.events.Publish(userID)
 }
 
-// InvalidateAll clears the entire cache
+// InvalidateAll clears the entire cache and notifies every open event stream
 func (s *PermissionCacheService) InvalidateAll() {
 	s.mu.Lock()
-	defer s.mu.Unlock()
-
+	s.generation++
+	s.allInvalidatedAt = time.Now()
 	s.cache = make(map[string]*PermissionCacheEntry)
+	s.mu.Unlock()
+
+	s.events.PublishAll()
+}
+
+// Events returns the broker that publishes permission-change events on invalidation
+func (s *PermissionCacheService) Events() *PermissionEventBroker {
+	return s.events
+}
+
+// AccessChecks returns the service recording checks on sensitive resources
+func (s *PermissionCacheService) AccessChecks() *AccessCheckLogService {
+	return s.checks
 }
 
 // CacheInvalidationService handles cache invalidation triggers
@@ -336,9 +542,22 @@ func (s *PermissionCacheService) GetCacheStats() map[string]interface{} {
 	}
 
 	return map[string]interface{}{
-		"total_entries":   total,
-		"expired_entries": expired,
-		"active_entries":  total - expired,
-		"ttl_seconds":     s.ttl.Seconds(),
+		"total_entries":        total,
+		"expired_entries":      expired,
+		"active_entries":       total - expired,
+		"ttl_seconds":          s.ttl.Seconds(),
+		"stale_window_seconds": s.staleWindow.Seconds(),
+		"hits":                 atomic.LoadUint64(&s.hits),
+		"stale_hits":           atomic.LoadUint64(&s.staleHits),
+		"misses":               atomic.LoadUint64(&s.misses),
+		"refreshing":           len(s.refreshing),
+		"warmed_entries":       atomic.LoadUint64(&s.warmedEntries),
+		"warmup":               s.warmup.snapshot(),
+		"subscribers":          s.events.SubscriberCount(),
 	}
 }
+
+// CacheCounters returns the number of cache hits and misses since startup
+func (s *PermissionCacheService) CacheCounters() (hits, misses uint64) {
+	return atomic.LoadUint64(&s.hits), atomic.LoadUint64(&s.misses)
+}
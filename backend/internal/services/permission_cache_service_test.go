@@ -0,0 +1,184 @@
+package services
+
+import (
+	"context"
+	"database/sql/driver"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// grantSwitchDB answers the resolver with one direct users:READ permission whose grant is
+// read from granted on every query, so a test can revoke access between checks
+func grantSwitchDB(t *testing.T, granted *atomic.Bool) *fakeDB {
+	return newFakeDB(t, func(query string, _ []driver.NamedValue) fakeRows {
+		switch {
+		case fromTable(query, "user_permissions"):
+			return fakeRows{
+				columns: []string{"id", "user_id", "permission_id", "is_granted", "priority"},
+				values:  [][]driver.Value{{"up-1", "user-1", "perm-1", granted.Load(), int64(100)}},
+			}
+		case fromTable(query, "permissions"):
+			return fakeRows{
+				columns: []string{"id", "code", "name", "resource", "action", "is_active"},
+				values:  [][]driver.Value{{"perm-1", "users:read", "Read users", "users", "READ", true}},
+			}
+		}
+		return fakeRows{}
+	})
+}
+
+func newTestPermissionCache(db *fakeDB, config CacheConfig) *PermissionCacheService {
+	return NewPermissionCacheService(db.DB, NewPermissionResolverService(db.DB), config)
+}
+
+var readUsers = PermissionCheckRequest{Resource: "users", Action: models.PermissionActionRead}
+
+// TestPermissionCacheConcurrentAccess is meant for go test -race: checks, batches and
+// invalidations run against the same keys at once, with a stale window so background
+// refreshes join in. After the last revoke and invalidation no old grant may survive.
+func TestPermissionCacheConcurrentAccess(t *testing.T) {
+	var granted atomic.Bool
+	granted.Store(true)
+	config := DefaultCacheConfig()
+	config.TTL = time.Millisecond
+	config.StaleWhileRevalidate = time.Minute
+	cache := newTestPermissionCache(grantSwitchDB(t, &granted), config)
+
+	const workers, rounds = 8, 50
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < rounds; i++ {
+				if _, err := cache.CheckPermission(context.Background(), "user-1", readUsers); err != nil {
+					t.Errorf("CheckPermission: %v", err)
+					return
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := 0; i < rounds; i++ {
+				if _, err := cache.CheckPermissionBatch(context.Background(), "user-1", []PermissionCheckRequest{readUsers}); err != nil {
+					t.Errorf("CheckPermissionBatch: %v", err)
+					return
+				}
+			}
+		}()
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < rounds; i++ {
+				granted.Store(i%2 == 0)
+				if w%2 == 0 {
+					cache.InvalidateUser("user-1")
+				} else {
+					cache.InvalidateAll()
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	cache.refreshWG.Wait()
+
+	granted.Store(false)
+	cache.InvalidateUser("user-1")
+
+	result, err := cache.CheckPermission(context.Background(), "user-1", readUsers)
+	if err != nil {
+		t.Fatalf("CheckPermission: %v", err)
+	}
+	if result.Allowed {
+		t.Error("users:READ still allowed after the grant was revoked and the user invalidated")
+	}
+}
+
+// TestPermissionCacheDropsResultResolvedBeforeInvalidation pins the generation check: a
+// check that resolved before an invalidation must not store its now outdated result.
+func TestPermissionCacheDropsResultResolvedBeforeInvalidation(t *testing.T) {
+	var granted atomic.Bool
+	granted.Store(true)
+	inQuery := make(chan struct{})
+	release := make(chan struct{})
+	var blockOnce sync.Once
+
+	db := grantSwitchDB(t, &granted)
+	cache := newTestPermissionCache(db, DefaultCacheConfig())
+	// Hold the first resolver query, once it has read the grant, until the revoke is invalidated
+	if err := db.Callback().Query().After("gorm:query").Register("test:hold_first_query", func(*gorm.DB) {
+		blockOnce.Do(func() {
+			close(inQuery)
+			<-release
+		})
+	}); err != nil {
+		t.Fatalf("register callback: %v", err)
+	}
+
+	done := make(chan *PermissionCheckResult)
+	go func() {
+		result, err := cache.CheckPermission(context.Background(), "user-1", readUsers)
+		if err != nil {
+			t.Errorf("CheckPermission: %v", err)
+		}
+		done <- result
+	}()
+
+	<-inQuery
+	granted.Store(false)
+	cache.InvalidateUser("user-1")
+	close(release)
+
+	if first := <-done; first == nil || !first.Allowed {
+		t.Fatalf("in-flight check got %+v, want the grant it read before the revoke", first)
+	}
+	result, err := cache.CheckPermission(context.Background(), "user-1", readUsers)
+	if err != nil {
+		t.Fatalf("CheckPermission: %v", err)
+	}
+	if result.Allowed {
+		t.Error("result resolved before the invalidation was cached and still allows users:READ")
+	}
+}
+
+// TestPermissionCacheResolvesOnPrimaryAfterInvalidation pins the read-after-invalidate
+// window: checks for an invalidated user skip the replica until the window has passed.
+func TestPermissionCacheResolvesOnPrimaryAfterInvalidation(t *testing.T) {
+	var granted atomic.Bool
+	config := DefaultCacheConfig()
+	config.PrimaryAfterInvalidation = time.Minute
+	cache := newTestPermissionCache(grantSwitchDB(t, &granted), config)
+
+	resolverFor := func(userID string, now time.Time) *PermissionResolverService {
+		cache.mu.RLock()
+		defer cache.mu.RUnlock()
+		return cache.resolverLocked(userID, now)
+	}
+
+	now := time.Now()
+	if resolverFor("user-1", now) != cache.resolver {
+		t.Fatal("user never invalidated resolves on the primary")
+	}
+
+	cache.InvalidateUser("user-1")
+	now = time.Now()
+	if resolverFor("user-1", now) != cache.primary {
+		t.Error("invalidated user does not resolve on the primary")
+	}
+	if resolverFor("user-2", now) != cache.resolver {
+		t.Error("invalidating one user moved another onto the primary")
+	}
+	if resolverFor("user-1", now.Add(config.PrimaryAfterInvalidation)) != cache.resolver {
+		t.Error("invalidated user still resolves on the primary after the window")
+	}
+
+	cache.InvalidateAll()
+	if resolverFor("user-2", time.Now()) != cache.primary {
+		t.Error("InvalidateAll did not move other users onto the primary")
+	}
+}
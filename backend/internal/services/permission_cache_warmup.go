@@ -0,0 +1,171 @@
+package services
+
+import (
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"backend/internal/models"
+)
+
+// warmupProgress tracks the most recent permission cache warmup
+type warmupProgress struct {
+	mu         sync.Mutex
+	running    bool
+	total      int
+	warmed     int
+	failed     int
+	startedAt  time.Time
+	finishedAt time.Time
+}
+
+// snapshot returns the progress in the shape reported by GetCacheStats
+func (p *warmupProgress) snapshot() map[string]interface{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stats := map[string]interface{}{
+		"running": p.running,
+		"total":   p.total,
+		"warmed":  p.warmed,
+		"failed":  p.failed,
+	}
+	if !p.startedAt.IsZero() {
+		stats["started_at"] = p.startedAt
+	}
+	if !p.finishedAt.IsZero() {
+		stats["finished_at"] = p.finishedAt
+	}
+	return stats
+}
+
+// RecentlyActiveUserIDs returns the IDs of active users seen within the given duration
+func (s *PermissionCacheService) RecentlyActiveUserIDs(within time.Duration) ([]string, error) {
+	var userIDs []string
+	if err := s.db.Model(&models.User{}).
+		Where("is_active = ?", true).
+		Where("last_active >= ?", time.Now().Add(-within)).
+		Pluck("id", &userIDs).Error; err != nil {
+		return nil, err
+	}
+	return userIDs, nil
+}
+
+// Warmup resolves and caches the permissions checked by route middleware (every active
+// resource/action pair, unscoped) for the given users in the background. Users are
+// processed one at a time, WarmupInterval apart, so warmup does not hammer the database.
+// A warmup that is already running is left alone.
+func (s *PermissionCacheService) Warmup(userIDs []string) {
+	s.warmup.mu.Lock()
+	if s.warmup.running || s.stopped {
+		s.warmup.mu.Unlock()
+		return
+	}
+	s.warmup.running = true
+	s.warmup.total = len(userIDs)
+	s.warmup.warmed, s.warmup.failed = 0, 0
+	s.warmup.startedAt, s.warmup.finishedAt = time.Now(), time.Time{}
+	s.warmupWG.Add(1)
+	s.warmup.mu.Unlock()
+
+	go func() {
+		defer s.warmupWG.Done()
+		defer func() {
+			s.warmup.mu.Lock()
+			s.warmup.running = false
+			s.warmup.finishedAt = time.Now()
+			s.warmup.mu.Unlock()
+		}()
+
+		requests, err := s.warmupRequests()
+		if err != nil {
+			slog.Warn("permission cache warmup skipped", "error", err)
+			return
+		}
+
+		ticker := time.NewTicker(s.warmupInterval)
+		defer ticker.Stop()
+
+		for _, userID := range userIDs {
+			select {
+			case <-s.stopWarmup:
+				return
+			case <-ticker.C:
+			}
+
+			err := s.warmUser(userID, requests)
+
+			s.warmup.mu.Lock()
+			if err != nil {
+				s.warmup.failed++
+			} else {
+				s.warmup.warmed++
+			}
+			s.warmup.mu.Unlock()
+			if err != nil {
+				slog.Warn("permission cache warmup failed for user", "user_id", userID, "error", err)
+			}
+		}
+
+		slog.Info("permission cache warmup finished", "users", len(userIDs))
+	}()
+}
+
+// Stop cancels a running warmup and waits for it and any in-flight stale refreshes to exit
+func (s *PermissionCacheService) Stop() {
+	s.warmup.mu.Lock()
+	if !s.stopped {
+		s.stopped = true
+		close(s.stopWarmup)
+	}
+	s.warmup.mu.Unlock()
+
+	s.warmupWG.Wait()
+	s.refreshWG.Wait()
+}
+
+// warmupRequests returns one unscoped check per active resource/action pair
+func (s *PermissionCacheService) warmupRequests() ([]PermissionCheckRequest, error) {
+	var pairs []struct {
+		Resource string
+		Action   models.PermissionAction
+	}
+	if err := s.db.Model(&models.Permission{}).
+		Distinct("resource", "action").
+		Where("is_active = ?", true).
+		Scan(&pairs).Error; err != nil {
+		return nil, err
+	}
+
+	requests := make([]PermissionCheckRequest, len(pairs))
+	for i, p := range pairs {
+		requests[i] = PermissionCheckRequest{Resource: p.Resource, Action: p.Action}
+	}
+	return requests, nil
+}
+
+// warmUser resolves the requests for a user and stores the results. It bypasses the
+// hit/miss counters so warmup does not skew the cache hit ratio.
+func (s *PermissionCacheService) warmUser(userID string, requests []PermissionCheckRequest) error {
+	s.mu.RLock()
+	resolver := s.resolverLocked(userID, time.Now())
+	s.mu.RUnlock()
+
+	results, err := resolver.CheckPermissionBatch(userID, requests)
+	if err != nil {
+		return err
+	}
+
+	expiresAt := time.Now().Add(s.ttl)
+	s.mu.Lock()
+	for _, req := range requests {
+		if result, ok := results[buildPermissionKey(req)]; ok {
+			s.cache[buildCacheKey(userID, req)] = &PermissionCacheEntry{Result: result, ExpiresAt: expiresAt}
+		}
+	}
+	s.mu.Unlock()
+
+	atomic.AddUint64(&s.warmedEntries, uint64(len(results)))
+	return nil
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"sync"
+	"time"
+)
+
+// PermissionChangeEvent tells a subscriber that their cached permissions were invalidated
+// and that anything derived from them (menus, module access) should be re-fetched
+type PermissionChangeEvent struct {
+	UserID    string    `json:"user_id,omitempty"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// PermissionEventBroker fans permission-change events out to per-user subscribers
+type PermissionEventBroker struct {
+	mu          sync.RWMutex
+	subscribers map[string]map[chan PermissionChangeEvent]struct{}
+	closed      bool
+}
+
+// NewPermissionEventBroker creates an empty broker
+func NewPermissionEventBroker() *PermissionEventBroker {
+	return &PermissionEventBroker{
+		subscribers: make(map[string]map[chan PermissionChangeEvent]struct{}),
+	}
+}
+
+// Subscribe registers a channel for userID's events. The returned function removes the
+// subscription and must be called when the subscriber goes away (e.g. client disconnect).
+func (b *PermissionEventBroker) Subscribe(userID string) (<-chan PermissionChangeEvent, func()) {
+	// Buffer one event: a pending event already means "re-fetch", so more can be dropped
+	ch := make(chan PermissionChangeEvent, 1)
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		close(ch)
+		return ch, func() {}
+	}
+	if b.subscribers[userID] == nil {
+		b.subscribers[userID] = make(map[chan PermissionChangeEvent]struct{})
+	}
+	b.subscribers[userID][ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			if _, ok := b.subscribers[userID][ch]; !ok {
+				return // already closed by Close
+			}
+			delete(b.subscribers[userID], ch)
+			if len(b.subscribers[userID]) == 0 {
+				delete(b.subscribers, userID)
+			}
+		})
+	}
+}
+
+// Publish notifies every subscriber of userID without blocking
+func (b *PermissionEventBroker) Publish(userID string) {
+	event := PermissionChangeEvent{UserID: userID, ChangedAt: time.Now()}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for ch := range b.subscribers[userID] {
+		sendPermissionEvent(ch, event)
+	}
+}
+
+// PublishAll notifies every subscriber, used when the whole cache is cleared
+func (b *PermissionEventBroker) PublishAll() {
+	now := time.Now()
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for userID, channels := range b.subscribers {
+		for ch := range channels {
+			sendPermissionEvent(ch, PermissionChangeEvent{UserID: userID, ChangedAt: now})
+		}
+	}
+}
+
+// Close closes every subscriber channel so open streams end, e.g. on server shutdown.
+// Later subscriptions receive an already closed channel.
+func (b *PermissionEventBroker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for userID, channels := range b.subscribers {
+		for ch := range channels {
+			close(ch)
+		}
+		delete(b.subscribers, userID)
+	}
+}
+
+// SubscriberCount returns the number of open subscriptions
+func (b *PermissionEventBroker) SubscriberCount() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	count := 0
+	for _, channels := range b.subscribers {
+		count += len(channels)
+	}
+	return count
+}
+
+func sendPermissionEvent(ch chan PermissionChangeEvent, event PermissionChangeEvent) {
+	select {
+	case ch <- event:
+	default:
+	}
+}
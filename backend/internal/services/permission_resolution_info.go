@@ -0,0 +1,85 @@
+package services
+
+import (
+	"backend/internal/models"
+)
+
+// PermissionResolutionLayer describes one layer the resolver consults
+type PermissionResolutionLayer struct {
+	Order       int    `json:"order"`
+	Source      string `json:"source"`
+	Description string `json:"description"`
+	// CanDeny is true when a match in this layer can refuse access; other layers only grant
+	CanDeny bool `json:"can_deny"`
+	// ListPriority is the priority the layer's grants get in effective-permission lists;
+	// nil when every grant carries its own priority
+	ListPriority *int `json:"list_priority"`
+}
+
+// PermissionResolutionInfo is a machine-readable description of how CheckPermission decides
+type PermissionResolutionInfo struct {
+	// Layers are consulted in order; the first layer with a matching grant decides
+	Layers []PermissionResolutionLayer `json:"layers"`
+	// DefaultSource is reported when no layer matches; access is then refused
+	DefaultSource string `json:"default_source"`
+	// ScopeHierarchy ranks scopes, higher = broader. A grant satisfies a request whose scope
+	// ranks at or below its own; a grant or request without a scope matches any scope.
+	ScopeHierarchy map[models.PermissionScope]int `json:"scope_hierarchy"`
+	// DirectPriorityOrder is how direct user permissions are ordered: "ascending" means a lower
+	// priority number is evaluated first
+	DirectPriorityOrder string `json:"direct_priority_order"`
+	// DirectTieBreak is which direct permission wins between equal priorities
+	DirectTieBreak string `json:"direct_tie_break"`
+	// DefaultDirectPriority is the priority a direct permission gets when none is given
+	DefaultDirectPriority int `json:"default_direct_priority"`
+}
+
+// defaultDirectPermissionPriority is the priority of a direct user permission assigned without one
+const defaultDirectPermissionPriority = 100
+
+// GetResolutionInfo describes the resolution order, scope hierarchy and priority semantics
+// implemented by CheckPermission, built from the same constants the resolver uses
+func GetResolutionInfo() PermissionResolutionInfo {
+	positionPriority := positionPermissionPriority
+	delegationPriority := delegationPermissionPriority
+	rolePriority := rolePermissionPriority
+
+	hierarchy := make(map[models.PermissionScope]int, len(scopeHierarchy))
+	for scope, level := range scopeHierarchy {
+		hierarchy[scope] = level
+	}
+
+	return PermissionResolutionInfo{
+		Layers: []PermissionResolutionLayer{
+			{
+				Order:       1,
+				Source:      PermissionSourceUserPermission,
+				Description: "Permissions granted or denied directly on the user; the first matching one decides, so an explicit deny refuses access regardless of the other layers",
+				CanDeny:     true,
+			},
+			{
+				Order:        2,
+				Source:       PermissionSourcePosition,
+				Description:  "Module access attached to the user's effective positions",
+				ListPriority: &positionPriority,
+			},
+			{
+				Order:        3,
+				Source:       PermissionSourceDelegation,
+				Description:  "Permissions delegated to the user; only granted while the delegator still holds them, and never passed on further",
+				ListPriority: &delegationPriority,
+			},
+			{
+				Order:        4,
+				Source:       PermissionSourceRole,
+				Description:  "Permissions of the user's effective roles, including roles inherited through the role hierarchy",
+				ListPriority: &rolePriority,
+			},
+		},
+		DefaultSource:         PermissionSourceDenied,
+		ScopeHierarchy:        hierarchy,
+		DirectPriorityOrder:   "ascending",
+		DirectTieBreak:        "deny",
+		DefaultDirectPriority: defaultDirectPermissionPriority,
+	}
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"testing"
+
+	"backend/internal/models"
+)
+
+func TestGetResolutionInfoMatchesResolver(t *testing.T) {
+	info := GetResolutionInfo()
+
+	wantSources := []string{
+		PermissionSourceUserPermission,
+		PermissionSourcePosition,
+		PermissionSourceDelegation,
+		PermissionSourceRole,
+	}
+	if len(info.Layers) != len(wantSources) {
+		t.Fatalf("got %d layers, want %d", len(info.Layers), len(wantSources))
+	}
+	previousPriority := 0
+	for i, layer := range info.Layers {
+		if layer.Order != i+1 || layer.Source != wantSources[i] {
+			t.Errorf("layer %d is %d/%s, want %d/%s", i, layer.Order, layer.Source, i+1, wantSources[i])
+		}
+		// Only direct user permissions can deny, and only they carry per-row priorities
+		if wantDirect := layer.Source == PermissionSourceUserPermission; layer.CanDeny != wantDirect || (layer.ListPriority == nil) != wantDirect {
+			t.Errorf("layer %s: can_deny=%v list_priority=%v", layer.Source, layer.CanDeny, layer.ListPriority)
+		}
+		if layer.ListPriority != nil {
+			if *layer.ListPriority <= previousPriority {
+				t.Errorf("layer %s list priority %d does not follow the layer order", layer.Source, *layer.ListPriority)
+			}
+			previousPriority = *layer.ListPriority
+		}
+	}
+	for source, want := range map[string]int{
+		PermissionSourcePosition:   positionPermissionPriority,
+		PermissionSourceDelegation: delegationPermissionPriority,
+		PermissionSourceRole:       rolePermissionPriority,
+	} {
+		for _, layer := range info.Layers {
+			if layer.Source == source && (layer.ListPriority == nil || *layer.ListPriority != want) {
+				t.Errorf("layer %s list priority %v, want %d", source, layer.ListPriority, want)
+			}
+		}
+	}
+
+	if info.DefaultSource != PermissionSourceDenied {
+		t.Errorf("default source %q, want %q", info.DefaultSource, PermissionSourceDenied)
+	}
+	if info.DefaultDirectPriority != defaultDirectPermissionPriority {
+		t.Errorf("default direct priority %d, want %d", info.DefaultDirectPriority, defaultDirectPermissionPriority)
+	}
+}
+
+func TestGetResolutionInfoPrioritySemantics(t *testing.T) {
+	info := GetResolutionInfo()
+
+	lowerFirst := precedesDirectPermission(1, true, 2, false) && !precedesDirectPermission(2, false, 1, true)
+	if got := map[bool]string{true: "ascending", false: "descending"}[lowerFirst]; info.DirectPriorityOrder != got {
+		t.Errorf("direct_priority_order %q, but the resolver orders %s", info.DirectPriorityOrder, got)
+	}
+
+	denyFirst := precedesDirectPermission(100, false, 100, true) && !precedesDirectPermission(100, true, 100, false)
+	if got := map[bool]string{true: "deny", false: "allow"}[denyFirst]; info.DirectTieBreak != got {
+		t.Errorf("direct_tie_break %q, but the resolver lets %s win", info.DirectTieBreak, got)
+	}
+}
+
+func TestGetResolutionInfoScopeHierarchy(t *testing.T) {
+	info := GetResolutionInfo()
+	s := NewPermissionResolverService(nil)
+
+	scopes := models.AllPermissionScopes()
+	if len(info.ScopeHierarchy) != len(scopes) {
+		t.Errorf("scope hierarchy has %d scopes, want all %d", len(info.ScopeHierarchy), len(scopes))
+	}
+	// The documented rule: a grant satisfies requests ranked at or below its own scope
+	for _, granted := range scopes {
+		for _, requested := range scopes {
+			want := info.ScopeHierarchy[granted] >= info.ScopeHierarchy[requested]
+			if got := s.isScopeCompatible(&granted, &requested); got != want {
+				t.Errorf("grant %s for request %s: resolver says %v, info implies %v", granted, requested, got, want)
+			}
+		}
+	}
+
+	// The response must not expose the resolver's own map
+	info.ScopeHierarchy[models.PermissionScopeOwn] = 99
+	if scopeHierarchy[models.PermissionScopeOwn] == 99 {
+		t.Error("changing the returned scope hierarchy changed the resolver's")
+	}
+}
+
+// TestResolutionInfoDirectDenyOverridesRole checks the documented layer behaviour on the
+// real resolver: a direct deny refuses access a role would grant, and a role grant applies
+// when the user has no direct permission.
+func TestResolutionInfoDirectDenyOverridesRole(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		directDeny bool
+		want       string
+	}{
+		{"direct deny", true, PermissionSourceUserPermission},
+		{"role grant", false, PermissionSourceRole},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newFakeDB(t, func(query string, _ []driver.NamedValue) fakeRows {
+				switch {
+				case fromTable(query, "user_permissions"):
+					if !tt.directDeny {
+						return fakeRows{}
+					}
+					return fakeRows{columns: []string{"id", "user_id", "permission_id", "is_granted", "priority"}, values: [][]driver.Value{{"up-1", "user-1", "perm-1", false, int64(100)}}}
+				case fromTable(query, "user_roles"):
+					return fakeRows{columns: []string{"id", "user_id", "role_id", "is_active"}, values: [][]driver.Value{{"ur-1", "user-1", "role-1", true}}}
+				case fromTable(query, "role_permissions"):
+					return fakeRows{columns: []string{"id", "role_id", "permission_id", "is_granted"}, values: [][]driver.Value{{"rp-1", "role-1", "perm-1", true}}}
+				case fromTable(query, "permissions"):
+					return fakeRows{columns: []string{"id", "code", "name", "resource", "action", "is_active"}, values: [][]driver.Value{{"perm-1", "users:read", "Read users", "users", "READ", true}}}
+				}
+				return fakeRows{}
+			})
+
+			result, err := NewPermissionResolverService(db.DB).CheckPermission("user-1", readUsers)
+			if err != nil {
+				t.Fatalf("CheckPermission: %v", err)
+			}
+			if result.Source != tt.want || result.Allowed == tt.directDeny {
+				t.Errorf("got allowed=%v from %s, want allowed=%v from %s", result.Allowed, result.Source, !tt.directDeny, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolutionInfoJSONFields(t *testing.T) {
+	body, err := json.Marshal(GetResolutionInfo())
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var decoded map[string]json.RawMessage
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	// Field names the frontend's PermissionResolutionInfo type reads
+	for _, field := range []string{"layers", "default_source", "scope_hierarchy", "direct_priority_order", "direct_tie_break", "default_direct_priority"} {
+		if _, ok := decoded[field]; !ok {
+			t.Errorf("response has no %q field", field)
+		}
+	}
+}
@@ -1,17 +1,18 @@
 package services
 
 import (
+	"backend/internal/clock"
+	"backend/internal/database"
 	"backend/internal/models"
 	"errors"
 	"fmt"
 	"sort"
-	"time"
 
 	"gorm.io/gorm"
 )
 
 // PermissionResolverService handles multi-layer permission resolution
-// Priority: UserPermission (highest) → Position → Role (lowest)
+// Priority: UserPermission (highest) → Position → Delegation → Role (lowest)
 type PermissionResolverService struct {
 	db *gorm.DB
 }
@@ -31,8 +32,8 @@ type PermissionCheckRequest struct {
 // PermissionCheckResult represents the result of a permission check
 type PermissionCheckResult struct {
 	Allowed    bool   `json:"allowed"`
-	Source     string `json:"source"`      // "user_permission", "position", "role", "denied"
-	SourceID   string `json:"source_id"`   // ID of the source (permission, position, or role)
+	Source     string `json:"source"`      // "user_permission", "position", "delegation", "role", "denied"
+	SourceID   string `json:"source_id"`   // ID of the source (permission, position, delegation, or role)
 	SourceName string `json:"source_name"` // Name for display
 }
 
@@ -40,13 +41,31 @@ type PermissionCheckResult struct {
 type ResolvedPermission struct {
 	Permission *models.Permission
 	IsGranted  bool
-	Source     string // "user_permission", "position", "role"
+	Source     string // "user_permission", "position", "delegation", "role"
 	SourceID   string
 	SourceName string
 	Priority   int
 	Scope      *models.PermissionScope
 }
 
+// Permission sources reported in check results, in resolution order; PermissionSourceDenied
+// means no layer matched
+const (
+	PermissionSourceUserPermission = "user_permission"
+	PermissionSourcePosition       = "position"
+	PermissionSourceDelegation     = "delegation"
+	PermissionSourceRole           = "role"
+	PermissionSourceDenied         = "denied"
+)
+
+// Priorities given to layered grants in effective-permission lists (lower = higher priority).
+// Direct user permissions carry their own per-row priority instead.
+const (
+	positionPermissionPriority   = 50
+	delegationPermissionPriority = 75
+	rolePermissionPriority       = 100
+)
+
 // scopeHierarchy defines the scope hierarchy (higher value = broader scope)
 var scopeHierarchy = map[models.PermissionScope]int{
 	models.PermissionScopeOwn:        1,
@@ -56,8 +75,33 @@ var scopeHierarchy = map[models.PermissionScope]int{
 }
 
 // CheckPermission checks if a user has a specific permission
-// Resolution order: UserPermission (explicit deny wins) → Position → Role
+// Resolution order: UserPermission (explicit deny wins) → Position → Delegation → Role
 func (s *PermissionResolverService) CheckPermission(userID string, req PermissionCheckRequest) (*PermissionCheckResult, error) {
+	return s.resolvePermission(userID, req, true, roleIDMemo{})
+}
+
+// roleIDMemo caches each user's full role-id set (direct + inherited) for the
+// duration of one resolver call, so the role hierarchy is walked once per user
+type roleIDMemo map[string][]string
+
+// roleIDsFor returns the user's full role-id set, loading it on first use
+func (s *PermissionResolverService) roleIDsFor(userID string, memo roleIDMemo) ([]string, error) {
+	if roleIDs, ok := memo[userID]; ok {
+		return roleIDs, nil
+	}
+
+	roleIDs, err := s.getAllUserRoleIDs(userID)
+	if err != nil {
+		return nil, err
+	}
+	memo[userID] = roleIDs
+	return roleIDs, nil
+}
+
+// resolvePermission runs the resolution steps for a user. Delegated permissions are only
+// followed one level deep: a delegator's own access is resolved with includeDelegations
+// false, so delegations cannot be chained or loop back on each other.
+func (s *PermissionResolverService) resolvePermission(userID string, req PermissionCheckRequest, includeDelegations bool, memo roleIDMemo) (*PermissionCheckResult, error) {
 	// Step 1: Check UserPermission (highest priority)
 	userPermResult, err := s.checkUserPermission(userID, req)
 	if err != nil {
@@ -76,8 +120,19 @@ func (s *PermissionResolverService) CheckPermission(userID string, req Permissio
 		return positionResult, nil
 	}
 
-	// Step 3: Check Role permissions (with hierarchy)
-	roleResult, err := s.checkRolePermission(userID, req)
+	// Step 3: Check permissions delegated to the user
+	if includeDelegations {
+		delegationResult, err := s.checkDelegationPermission(userID, req, memo)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check delegation permission: %w", err)
+		}
+		if delegationResult != nil {
+			return delegationResult, nil
+		}
+	}
+
+	// Step 4: Check Role permissions (with hierarchy)
+	roleResult, err := s.checkRolePermission(userID, req, memo)
 	if err != nil {
 		return nil, fmt.Errorf("failed to check role permission: %w", err)
 	}
@@ -88,19 +143,21 @@ func (s *PermissionResolverService) CheckPermission(userID string, req Permissio
 	// No permission found
 	return &PermissionCheckResult{
 		Allowed:    false,
-		Source:     "denied",
+		Source:     PermissionSourceDenied,
 		SourceID:   "",
 		SourceName: "No matching permission found",
 	}, nil
 }
 
-// CheckPermissionBatch checks multiple permissions at once
+// CheckPermissionBatch checks multiple permissions at once. Role hierarchies are
+// resolved once per user for the whole batch.
 func (s *PermissionResolverService) CheckPermissionBatch(userID string, requests []PermissionCheckRequest) (map[string]*PermissionCheckResult, error) {
 	results := make(map[string]*PermissionCheckResult)
+	memo := roleIDMemo{}
 
 	for _, req := range requests {
 		key := buildPermissionKey(req)
-		result, err := s.CheckPermission(userID, req)
+		result, err := s.resolvePermission(userID, req, true, memo)
 		if err != nil {
 			return nil, fmt.Errorf("failed to check permission %s: %w", key, err)
 		}
@@ -121,7 +178,7 @@ func buildPermissionKey(req PermissionCheckRequest) string {
 
 // checkUserPermission checks direct user permissions (highest priority)
 func (s *PermissionResolverService) checkUserPermission(userID string, req PermissionCheckRequest) (*PermissionCheckResult, error) {
-	now := time.Now()
+	now := clock.Now()
 
 	var userPermissions []models.UserPermission
 	query := s.db.Preload("Permission").
@@ -133,9 +190,10 @@ func (s *PermissionResolverService) checkUserPermission(userID string, req Permi
 		return nil, err
 	}
 
-	// Sort by priority (lower number = higher priority)
-	sort.Slice(userPermissions, func(i, j int) bool {
-		return userPermissions[i].Priority < userPermissions[j].Priority
+	// Sort by priority (lower number = higher priority); on a tie an explicit deny wins
+	sort.SliceStable(userPermissions, func(i, j int) bool {
+		return precedesDirectPermission(userPermissions[i].Priority, userPermissions[i].IsGranted,
+			userPermissions[j].Priority, userPermissions[j].IsGranted)
 	})
 
 	for _, up := range userPermissions {
@@ -156,7 +214,7 @@ func (s *PermissionResolverService) checkUserPermission(userID string, req Permi
 		// Found matching permission
 		return &PermissionCheckResult{
 			Allowed:    up.IsGranted,
-			Source:     "user_permission",
+			Source:     PermissionSourceUserPermission,
 			SourceID:   up.ID,
 			SourceName: fmt.Sprintf("Direct: %s", up.Permission.Name),
 		}, nil
@@ -173,12 +231,15 @@ func (s *PermissionResolverService) checkPositionPermission(userID string, req P
 		return nil, err
 	}
 
+	now := clock.Now()
 	for _, up := range positions {
 		// Check RoleModuleAccess with this position
 		var roleModuleAccess []models.RoleModuleAccess
 		if err := s.db.Preload("Module").
 			Where("position_id = ?", up.PositionID).
 			Where("is_active = ?", true).
+			Where("effective_from <= ?", now).
+			Where("(effective_until IS NULL OR effective_until >= ?)", now).
 			Find(&roleModuleAccess).Error; err != nil {
 			return nil, err
 		}
@@ -203,7 +264,7 @@ func (s *PermissionResolverService) checkPositionPermission(userID string, req P
 			if hasPermission {
 				return &PermissionCheckResult{
 					Allowed:    true,
-					Source:     "position",
+					Source:     PermissionSourcePosition,
 					SourceID:   up.PositionID,
 					SourceName: fmt.Sprintf("Position: %s", up.Position.Name),
 				}, nil
@@ -238,9 +299,9 @@ func (s *PermissionResolverService) checkModulePermissions(permissions interface
 }
 
 // checkRolePermission checks permissions via user's roles with hierarchy
-func (s *PermissionResolverService) checkRolePermission(userID string, req PermissionCheckRequest) (*PermissionCheckResult, error) {
+func (s *PermissionResolverService) checkRolePermission(userID string, req PermissionCheckRequest, memo roleIDMemo) (*PermissionCheckResult, error) {
 	// Get all role IDs (including inherited) for the user
-	allRoleIDs, err := s.getAllUserRoleIDs(userID)
+	allRoleIDs, err := s.roleIDsFor(userID, memo)
 	if err != nil {
 		return nil, err
 	}
@@ -249,7 +310,7 @@ func (s *PermissionResolverService) checkRolePermission(userID string, req Permi
 		return nil, nil
 	}
 
-	now := time.Now()
+	now := clock.Now()
 
 	// Find matching role permissions
 	var rolePermissions []models.RolePermission
@@ -282,7 +343,7 @@ func (s *PermissionResolverService) checkRolePermission(userID string, req Permi
 
 		return &PermissionCheckResult{
 			Allowed:    true,
-			Source:     "role",
+			Source:     PermissionSourceRole,
 			SourceID:   rp.RoleID,
 			SourceName: fmt.Sprintf("Role: %s", roleName),
 		}, nil
@@ -291,6 +352,130 @@ func (s *PermissionResolverService) checkRolePermission(userID string, req Permi
 	return nil, nil
 }
 
+// checkDelegationPermission checks permissions delegated to the user by others.
+// A delegation grants the request when it covers the permission (directly or via the
+// delegated role) and the delegator still holds the permission themselves.
+func (s *PermissionResolverService) checkDelegationPermission(userID string, req PermissionCheckRequest, memo roleIDMemo) (*PermissionCheckResult, error) {
+	delegations, err := s.getEffectiveDelegations(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, d := range delegations {
+		permissions, err := s.getDelegatedPermissions(&d)
+		if err != nil {
+			return nil, err
+		}
+
+		covered := false
+		for _, perm := range permissions {
+			if !s.permissionMatches(perm, req) {
+				continue
+			}
+			if req.Scope != nil && !s.isScopeCompatible(perm.Scope, req.Scope) {
+				continue
+			}
+			covered = true
+			break
+		}
+		if !covered {
+			continue
+		}
+
+		// The delegate never gets more than the delegator currently has
+		delegatorResult, err := s.resolvePermission(d.DelegatorID, req, false, memo)
+		if err != nil {
+			return nil, err
+		}
+		if !delegatorResult.Allowed {
+			continue
+		}
+
+		return &PermissionCheckResult{
+			Allowed:    true,
+			Source:     PermissionSourceDelegation,
+			SourceID:   d.ID,
+			SourceName: fmt.Sprintf("Delegation from %s", delegatorName(&d)),
+		}, nil
+	}
+
+	return nil, nil
+}
+
+// getEffectiveDelegations returns the permission delegations currently in effect for a delegate
+func (s *PermissionResolverService) getEffectiveDelegations(delegateID string) ([]models.Delegation, error) {
+	now := clock.Now()
+
+	var delegations []models.Delegation
+	if err := s.db.Preload("Delegator").
+		Where("delegate_id = ?", delegateID).
+		Where("type = ?", models.DelegationTypePermission).
+		Where("is_active = ?", true).
+		Where("effective_from <= ?", now).
+		Where("(effective_until IS NULL OR effective_until >= ?)", now).
+		Order("effective_from ASC").
+		Find(&delegations).Error; err != nil {
+		return nil, err
+	}
+
+	return delegations, nil
+}
+
+// getDelegatedPermissions returns the active permissions a delegation covers:
+// its explicit permission set plus everything granted to the delegated role and its parents
+func (s *PermissionResolverService) getDelegatedPermissions(d *models.Delegation) ([]*models.Permission, error) {
+	var permissions []*models.Permission
+
+	if len(d.PermissionIDs) > 0 {
+		var explicit []models.Permission
+		if err := s.db.Where("id IN ?", []string(d.PermissionIDs)).
+			Where("is_active = ?", true).
+			Find(&explicit).Error; err != nil {
+			return nil, err
+		}
+		for i := range explicit {
+			permissions = append(permissions, &explicit[i])
+		}
+	}
+
+	if d.RoleID != nil {
+		roleIDs, err := s.GetParentRolesWithCTE([]string{*d.RoleID}, true, 10)
+		if err != nil {
+			roleIDs = s.getParentRolesRecursive([]string{*d.RoleID}, true, make(map[string]bool))
+		}
+		roleIDs = append(roleIDs, *d.RoleID)
+
+		now := clock.Now()
+		var rolePermissions []models.RolePermission
+		if err := s.db.Preload("Permission").
+			Where("role_id IN ?", roleIDs).
+			Where("is_granted = ?", true).
+			Where("effective_from <= ?", now).
+			Where("(effective_until IS NULL OR effective_until >= ?)", now).
+			Find(&rolePermissions).Error; err != nil {
+			return nil, err
+		}
+		for _, rp := range rolePermissions {
+			if rp.Permission != nil && rp.Permission.IsActive {
+				permissions = append(permissions, rp.Permission)
+			}
+		}
+	}
+
+	return permissions, nil
+}
+
+// delegatorName returns a display name for the delegator of a delegation
+func delegatorName(d *models.Delegation) string {
+	if d.Delegator == nil {
+		return d.DelegatorID
+	}
+	if d.Delegator.Username != nil && *d.Delegator.Username != "" {
+		return *d.Delegator.Username
+	}
+	return d.Delegator.Email
+}
+
 // getAllUserRoleIDs returns all role IDs for a user including inherited roles
 func (s *PermissionResolverService) getAllUserRoleIDs(userID string) ([]string, error) {
 	// Get direct effective roles
@@ -329,7 +514,7 @@ func (s *PermissionResolverService) getAllUserRoleIDs(userID string) ([]string,
 
 // getEffectiveUserRoleIDs returns IDs of user's effective direct roles
 func (s *PermissionResolverService) getEffectiveUserRoleIDs(userID string) ([]string, error) {
-	now := time.Now()
+	now := clock.Now()
 
 	var userRoles []models.UserRole
 	if err := s.db.Where("user_id = ?", userID).
@@ -396,7 +581,7 @@ func (s *PermissionResolverService) GetParentRolesWithCTE(roleIDs []string, inhe
 	args = append(args, roleIDs, maxDepth)
 
 	var parentRoleIDs []string
-	if err := s.db.Raw(query, args...).Scan(&parentRoleIDs).Error; err != nil {
+	if err := s.db.Clauses(database.Read).Raw(query, args...).Scan(&parentRoleIDs).Error; err != nil {
 		return nil, fmt.Errorf("failed to get parent roles with CTE: %w", err)
 	}
 
@@ -462,26 +647,36 @@ func (s *PermissionResolverService) isScopeCompatible(grantedScope, requestedSco
 	return grantedLevel >= requestedLevel
 }
 
-// GetEffectiveUserPermissions returns all effective permissions for a user
-func (s *PermissionResolverService) GetEffectiveUserPermissions(userID string) ([]ResolvedPermission, error) {
+// GetEffectiveUserPermissions returns all effective permissions for a user. A non-empty
+// resource limits the result to that resource (position entries: that module code), and
+// each layer then only loads and resolves the matching rows.
+func (s *PermissionResolverService) GetEffectiveUserPermissions(userID, resource string) ([]ResolvedPermission, error) {
 	var resolved []ResolvedPermission
 
 	// 1. Get direct user permissions
-	userPerms, err := s.getUserPermissions(userID)
+	userPerms, err := s.getUserPermissions(userID, resource)
 	if err != nil {
 		return nil, err
 	}
 	resolved = append(resolved, userPerms...)
 
 	// 2. Get position-based permissions
-	positionPerms, err := s.getPositionPermissions(userID)
+	positionPerms, err := s.getPositionPermissions(userID, resource)
 	if err != nil {
 		return nil, err
 	}
 	resolved = append(resolved, positionPerms...)
 
-	// 3. Get role permissions
-	rolePerms, err := s.getRolePermissions(userID)
+	// 3. Get delegated permissions
+	memo := roleIDMemo{}
+	delegationPerms, err := s.getDelegationPermissions(userID, resource, memo)
+	if err != nil {
+		return nil, err
+	}
+	resolved = append(resolved, delegationPerms...)
+
+	// 4. Get role permissions
+	rolePerms, err := s.getRolePermissions(userID, resource, memo)
 	if err != nil {
 		return nil, err
 	}
@@ -490,12 +685,77 @@ func (s *PermissionResolverService) GetEffectiveUserPermissions(userID string) (
 	return resolved, nil
 }
 
-// getUserPermissions retrieves direct user permissions
-func (s *PermissionResolverService) getUserPermissions(userID string) ([]ResolvedPermission, error) {
-	now := time.Now()
+// IndexAllowedActions indexes effective permissions by resource, resolving each action
+// with the same precedence as CheckPermission: the highest-priority direct user permission
+// decides (an explicit deny wins, also on a priority tie), otherwise any granted delegation or role permission allows it.
+// Position entries carry no action and are ignored, as in CheckPermission.
+func IndexAllowedActions(resolved []ResolvedPermission) map[string]map[models.PermissionAction]bool {
+	type resourceAction struct {
+		resource string
+		action   models.PermissionAction
+	}
+
+	var direct []ResolvedPermission
+	for _, rp := range resolved {
+		if rp.Source == PermissionSourceUserPermission && rp.Permission != nil {
+			direct = append(direct, rp)
+		}
+	}
+	sort.SliceStable(direct, func(i, j int) bool {
+		return precedesDirectPermission(direct[i].Priority, direct[i].IsGranted, direct[j].Priority, direct[j].IsGranted)
+	})
+
+	allowed := make(map[string]map[models.PermissionAction]bool)
+	allow := func(resource string, action models.PermissionAction) {
+		if allowed[resource] == nil {
+			allowed[resource] = make(map[models.PermissionAction]bool)
+		}
+		allowed[resource][action] = true
+	}
+
+	decided := make(map[resourceAction]bool)
+	for _, rp := range direct {
+		key := resourceAction{rp.Permission.Resource, rp.Permission.Action}
+		if decided[key] {
+			continue
+		}
+		decided[key] = true
+		if rp.IsGranted {
+			allow(key.resource, key.action)
+		}
+	}
+
+	for _, rp := range resolved {
+		if rp.Permission == nil || !rp.IsGranted {
+			continue
+		}
+		if rp.Source != PermissionSourceDelegation && rp.Source != PermissionSourceRole {
+			continue
+		}
+		if decided[resourceAction{rp.Permission.Resource, rp.Permission.Action}] {
+			continue
+		}
+		allow(rp.Permission.Resource, rp.Permission.Action)
+	}
+
+	return allowed
+}
+
+// permissionsOnResource narrows a query on a table with a permission_id column to permissions
+// on resource; an empty resource leaves it unchanged
+func (s *PermissionResolverService) permissionsOnResource(query *gorm.DB, resource string) *gorm.DB {
+	if resource == "" {
+		return query
+	}
+	return query.Where("permission_id IN (?)", s.db.Model(&models.Permission{}).Select("id").Where("resource = ?", resource))
+}
+
+// getUserPermissions retrieves direct user permissions, optionally only those on resource
+func (s *PermissionResolverService) getUserPermissions(userID, resource string) ([]ResolvedPermission, error) {
+	now := clock.Now()
 
 	var userPermissions []models.UserPermission
-	if err := s.db.Preload("Permission").
+	if err := s.permissionsOnResource(s.db.Preload("Permission"), resource).
 		Where("user_id = ?", userID).
 		Where("effective_from <= ?", now).
 		Where("(effective_until IS NULL OR effective_until >= ?)", now).
@@ -512,7 +772,7 @@ func (s *PermissionResolverService) getUserPermissions(userID string) ([]Resolve
 		resolved = append(resolved, ResolvedPermission{
 			Permission: up.Permission,
 			IsGranted:  up.IsGranted,
-			Source:     "user_permission",
+			Source:     PermissionSourceUserPermission,
 			SourceID:   up.ID,
 			SourceName: "Direct Permission",
 			Priority:   up.Priority,
@@ -523,8 +783,9 @@ func (s *PermissionResolverService) getUserPermissions(userID string) ([]Resolve
 	return resolved, nil
 }
 
-// getPositionPermissions retrieves permissions from user's positions
-func (s *PermissionResolverService) getPositionPermissions(userID string) ([]ResolvedPermission, error) {
+// getPositionPermissions retrieves permissions from user's positions, optionally only the
+// module whose code is resource
+func (s *PermissionResolverService) getPositionPermissions(userID, resource string) ([]ResolvedPermission, error) {
 	positions, err := s.GetEffectiveUserPositions(userID)
 	if err != nil {
 		return nil, err
@@ -532,12 +793,20 @@ func (s *PermissionResolverService) getPositionPermissions(userID string) ([]Res
 
 	var resolved []ResolvedPermission
 
+	now := clock.Now()
 	for _, up := range positions {
 		// Get permissions linked to this position via RoleModuleAccess
+		query := s.db.Preload("Module")
+		if resource != "" {
+			query = query.Where("module_id IN (?)", s.db.Model(&models.Module{}).Select("id").Where("code = ?", resource))
+		}
+
 		var roleModuleAccess []models.RoleModuleAccess
-		if err := s.db.Preload("Module").
+		if err := query.
 			Where("position_id = ?", up.PositionID).
 			Where("is_active = ?", true).
+			Where("effective_from <= ?", now).
+			Where("(effective_until IS NULL OR effective_until >= ?)", now).
 			Find(&roleModuleAccess).Error; err != nil {
 			continue
 		}
@@ -561,10 +830,60 @@ func (s *PermissionResolverService) getPositionPermissions(userID string) ([]Res
 					Resource: rma.Module.Code,
 				},
 				IsGranted:  true,
-				Source:     "position",
+				Source:     PermissionSourcePosition,
 				SourceID:   up.PositionID,
 				SourceName: positionName,
-				Priority:   50, // Position priority between user (higher) and role (lower)
+				Priority:   positionPermissionPriority,
+			})
+		}
+	}
+
+	return resolved, nil
+}
+
+// getDelegationPermissions retrieves permissions delegated to the user that the delegator still holds,
+// optionally only those on resource
+func (s *PermissionResolverService) getDelegationPermissions(userID, resource string, memo roleIDMemo) ([]ResolvedPermission, error) {
+	delegations, err := s.getEffectiveDelegations(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var resolved []ResolvedPermission
+
+	for i := range delegations {
+		d := &delegations[i]
+		permissions, err := s.getDelegatedPermissions(d)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, perm := range permissions {
+			// Skip before the costly check of the delegator's own access
+			if resource != "" && perm.Resource != resource {
+				continue
+			}
+
+			delegatorResult, err := s.resolvePermission(d.DelegatorID, PermissionCheckRequest{
+				Resource: perm.Resource,
+				Action:   perm.Action,
+				Scope:    perm.Scope,
+			}, false, memo)
+			if err != nil {
+				return nil, err
+			}
+			if !delegatorResult.Allowed {
+				continue
+			}
+
+			resolved = append(resolved, ResolvedPermission{
+				Permission: perm,
+				IsGranted:  true,
+				Source:     PermissionSourceDelegation,
+				SourceID:   d.ID,
+				SourceName: fmt.Sprintf("Delegation from %s", delegatorName(d)),
+				Priority:   delegationPermissionPriority,
+				Scope:      perm.Scope,
 			})
 		}
 	}
@@ -572,21 +891,46 @@ func (s *PermissionResolverService) getPositionPermissions(userID string) ([]Res
 	return resolved, nil
 }
 
-// getRolePermissions retrieves permissions from user's roles
-func (s *PermissionResolverService) getRolePermissions(userID string) ([]ResolvedPermission, error) {
-	allRoleIDs, err := s.getAllUserRoleIDs(userID)
+// getRolePermissions retrieves permissions from user's roles, optionally only those on resource
+func (s *PermissionResolverService) getRolePermissions(userID, resource string, memo roleIDMemo) ([]ResolvedPermission, error) {
+	allRoleIDs, err := s.roleIDsFor(userID, memo)
 	if err != nil {
 		return nil, err
 	}
 
+	return s.resolveRolePermissions(allRoleIDs, resource)
+}
+
+// GetEffectiveRolePermissions returns the permissions a user would get from holding only
+// this role, including permissions inherited from its parent roles
+func (s *PermissionResolverService) GetEffectiveRolePermissions(roleID string) ([]ResolvedPermission, error) {
+	parentIDs, err := s.GetParentRolesWithCTE([]string{roleID}, true, 10)
+	if err != nil {
+		// Fallback to recursive method if CTE fails
+		parentIDs = s.getParentRolesRecursive([]string{roleID}, true, make(map[string]bool))
+	}
+
+	roleIDs := []string{roleID}
+	for _, id := range parentIDs {
+		if id != roleID {
+			roleIDs = append(roleIDs, id)
+		}
+	}
+
+	return s.resolveRolePermissions(roleIDs, "")
+}
+
+// resolveRolePermissions loads the currently effective permissions of the given roles,
+// optionally only those on resource
+func (s *PermissionResolverService) resolveRolePermissions(allRoleIDs []string, resource string) ([]ResolvedPermission, error) {
 	if len(allRoleIDs) == 0 {
 		return []ResolvedPermission{}, nil
 	}
 
-	now := time.Now()
+	now := clock.Now()
 
 	var rolePermissions []models.RolePermission
-	if err := s.db.Preload("Permission").Preload("Role").
+	if err := s.permissionsOnResource(s.db.Preload("Permission").Preload("Role"), resource).
 		Where("role_id IN ?", allRoleIDs).
 		Where("effective_from <= ?", now).
 		Where("(effective_until IS NULL OR effective_until >= ?)", now).
@@ -608,10 +952,10 @@ func (s *PermissionResolverService) getRolePermissions(userID string) ([]Resolve
 		resolved = append(resolved, ResolvedPermission{
 			Permission: rp.Permission,
 			IsGranted:  rp.IsGranted,
-			Source:     "role",
+			Source:     PermissionSourceRole,
 			SourceID:   rp.RoleID,
 			SourceName: roleName,
-			Priority:   100, // Role has lowest priority
+			Priority:   rolePermissionPriority,
 			Scope:      rp.Permission.Scope,
 		})
 	}
@@ -621,7 +965,7 @@ func (s *PermissionResolverService) getRolePermissions(userID string) ([]Resolve
 
 // GetEffectiveUserRoles returns all effective roles for a user
 func (s *PermissionResolverService) GetEffectiveUserRoles(userID string) ([]models.UserRole, error) {
-	now := time.Now()
+	now := clock.Now()
 
 	var userRoles []models.UserRole
 	if err := s.db.Preload("Role").
@@ -638,7 +982,7 @@ func (s *PermissionResolverService) GetEffectiveUserRoles(userID string) ([]mode
 
 // GetEffectiveUserPositions returns all effective positions for a user
 func (s *PermissionResolverService) GetEffectiveUserPositions(userID string) ([]models.UserPosition, error) {
-	now := time.Now()
+	now := clock.Now()
 
 	var userPositions []models.UserPosition
 	if err := s.db.Preload("Position").Preload("Position.Department").Preload("Position.School").
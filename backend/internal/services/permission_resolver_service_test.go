@@ -0,0 +1,232 @@
+package services
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"testing"
+
+	"backend/internal/models"
+)
+
+// directPermissionRow is a user_permissions row joined with its permission for fake queries
+type directPermissionRow struct {
+	id       string
+	priority int
+	granted  bool
+}
+
+// directPermissionsDB answers the resolver's user_permissions query (and its Permission
+// preload) with rows, all on users:READ; every other layer comes back empty
+func directPermissionsDB(t *testing.T, rows []directPermissionRow) *fakeDB {
+	return newFakeDB(t, func(query string, _ []driver.NamedValue) fakeRows {
+		switch {
+		case fromTable(query, "user_permissions"):
+			result := fakeRows{columns: []string{"id", "user_id", "permission_id", "is_granted", "priority"}}
+			for _, r := range rows {
+				result.values = append(result.values, []driver.Value{r.id, "user-1", "perm-" + r.id, r.granted, int64(r.priority)})
+			}
+			return result
+		case fromTable(query, "permissions"):
+			result := fakeRows{columns: []string{"id", "code", "name", "resource", "action", "is_active"}}
+			for _, r := range rows {
+				result.values = append(result.values, []driver.Value{"perm-" + r.id, "users:read:" + r.id, "Read users " + r.id, "users", "READ", true})
+			}
+			return result
+		}
+		return fakeRows{}
+	})
+}
+
+func TestCheckPermissionDirectPermissionPrecedence(t *testing.T) {
+	tests := []struct {
+		name        string
+		rows        []directPermissionRow
+		wantAllowed bool
+		wantSource  string
+	}{
+		{
+			name:        "deny wins a tie listed after the allow",
+			rows:        []directPermissionRow{{"allow", 100, true}, {"deny", 100, false}},
+			wantAllowed: false,
+			wantSource:  "deny",
+		},
+		{
+			name:        "deny wins a tie listed before the allow",
+			rows:        []directPermissionRow{{"deny", 100, false}, {"allow", 100, true}},
+			wantAllowed: false,
+			wantSource:  "deny",
+		},
+		{
+			name:        "higher-priority allow beats a deny",
+			rows:        []directPermissionRow{{"deny", 100, false}, {"allow", 10, true}},
+			wantAllowed: true,
+			wantSource:  "allow",
+		},
+		{
+			name:        "higher-priority deny beats an allow",
+			rows:        []directPermissionRow{{"allow", 100, true}, {"deny", 10, false}},
+			wantAllowed: false,
+			wantSource:  "deny",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewPermissionResolverService(directPermissionsDB(t, tt.rows).DB)
+
+			result, err := s.CheckPermission("user-1", PermissionCheckRequest{Resource: "users", Action: models.PermissionActionRead})
+			if err != nil {
+				t.Fatalf("CheckPermission: %v", err)
+			}
+			if result.Allowed != tt.wantAllowed || result.Source != PermissionSourceUserPermission || result.SourceID != tt.wantSource {
+				t.Errorf("got allowed=%v source=%s/%s, want allowed=%v source=%s/%s",
+					result.Allowed, result.Source, result.SourceID, tt.wantAllowed, PermissionSourceUserPermission, tt.wantSource)
+			}
+		})
+	}
+}
+
+func TestIndexAllowedActionsDenyWinsTie(t *testing.T) {
+	read := &models.Permission{Resource: "users", Action: models.PermissionActionRead}
+	allow := ResolvedPermission{Permission: read, IsGranted: true, Source: PermissionSourceUserPermission, Priority: 100}
+	deny := ResolvedPermission{Permission: read, IsGranted: false, Source: PermissionSourceUserPermission, Priority: 100}
+	role := ResolvedPermission{Permission: read, IsGranted: true, Source: PermissionSourceRole, Priority: rolePermissionPriority}
+
+	for name, resolved := range map[string][]ResolvedPermission{
+		"allow first": {allow, deny, role},
+		"deny first":  {deny, allow, role},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if IndexAllowedActions(resolved)["users"][models.PermissionActionRead] {
+				t.Error("users:READ allowed, want the tied direct deny to win over the allow and the role grant")
+			}
+		})
+	}
+}
+
+func TestCheckPermissionBatchWalksRoleHierarchyOnce(t *testing.T) {
+	db := newFakeDB(t, func(query string, _ []driver.NamedValue) fakeRows {
+		switch {
+		case fromTable(query, "user_roles"):
+			return fakeRows{columns: []string{"id", "user_id", "role_id", "is_active"}, values: [][]driver.Value{{"ur-1", "user-1", "role-child", true}}}
+		case strings.Contains(query, "WITH RECURSIVE"):
+			return fakeRows{columns: []string{"parent_role_id"}, values: [][]driver.Value{{"role-parent"}}}
+		}
+		return fakeRows{}
+	})
+	s := NewPermissionResolverService(db.DB)
+
+	requests := make([]PermissionCheckRequest, 50)
+	for i := range requests {
+		requests[i] = PermissionCheckRequest{Resource: fmt.Sprintf("resource-%d", i), Action: models.PermissionActionRead}
+	}
+
+	results, err := s.CheckPermissionBatch("user-1", requests)
+	if err != nil {
+		t.Fatalf("CheckPermissionBatch: %v", err)
+	}
+	if len(results) != len(requests) {
+		t.Fatalf("got %d results, want %d", len(results), len(requests))
+	}
+
+	if n := db.count("WITH RECURSIVE"); n > 1 {
+		t.Errorf("role hierarchy CTE ran %d times for one batch, want at most once", n)
+	}
+	if n := db.count(`FROM "public"."user_roles"`); n > 1 {
+		t.Errorf("user roles loaded %d times for one batch, want at most once", n)
+	}
+	if n := db.count(`FROM "public"."role_permissions"`); n != len(requests) {
+		t.Errorf("role permissions queried %d times, want once per request (%d)", n, len(requests))
+	}
+}
+
+// moduleActions are the actions the module menu reports per module
+var moduleActions = []models.PermissionAction{
+	models.PermissionActionRead, models.PermissionActionCreate, models.PermissionActionUpdate,
+	models.PermissionActionDelete, models.PermissionActionApprove, models.PermissionActionExport,
+	models.PermissionActionImport,
+}
+
+// BenchmarkModulePermissions compares resolving a user's module menu with one CheckPermission
+// per module and action against loading the effective permission set once and indexing it,
+// as GetUserModules does. queries/op shows the N+1 the index removes.
+func BenchmarkModulePermissions(b *testing.B) {
+	const modules = 30
+
+	var rolePermissions, permissions [][]driver.Value
+	for i := 0; i < modules; i++ {
+		for _, action := range []models.PermissionAction{models.PermissionActionRead, models.PermissionActionUpdate} {
+			id := fmt.Sprintf("perm-%d-%s", i, action)
+			rolePermissions = append(rolePermissions, []driver.Value{"rp-" + id, "role-1", id, true})
+			permissions = append(permissions, []driver.Value{id, id, id, fmt.Sprintf("module-%d", i), string(action), true})
+		}
+	}
+	db := newFakeDB(b, func(query string, _ []driver.NamedValue) fakeRows {
+		switch {
+		case fromTable(query, "user_roles"):
+			return fakeRows{columns: []string{"id", "user_id", "role_id", "is_active"}, values: [][]driver.Value{{"ur-1", "user-1", "role-1", true}}}
+		case fromTable(query, "role_permissions"):
+			return fakeRows{columns: []string{"id", "role_id", "permission_id", "is_granted"}, values: rolePermissions}
+		case fromTable(query, "permissions"):
+			return fakeRows{columns: []string{"id", "code", "name", "resource", "action", "is_active"}, values: permissions}
+		case fromTable(query, "roles"):
+			return fakeRows{columns: []string{"id", "code", "name"}, values: [][]driver.Value{{"role-1", "GURU", "Guru"}}}
+		}
+		return fakeRows{}
+	})
+	s := NewPermissionResolverService(db.DB)
+
+	run := func(b *testing.B, menu func() (int, error)) {
+		start := db.count("")
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			allowed, err := menu()
+			if err != nil {
+				b.Fatal(err)
+			}
+			if allowed != modules*2 {
+				b.Fatalf("got %d allowed module actions, want %d", allowed, modules*2)
+			}
+		}
+		b.StopTimer()
+		b.ReportMetric(float64(db.count("")-start)/float64(b.N), "queries/op")
+	}
+
+	b.Run("CheckPermission per action", func(b *testing.B) {
+		run(b, func() (int, error) {
+			allowed := 0
+			for i := 0; i < modules; i++ {
+				for _, action := range moduleActions {
+					result, err := s.CheckPermission("user-1", PermissionCheckRequest{Resource: fmt.Sprintf("module-%d", i), Action: action})
+					if err != nil {
+						return 0, err
+					}
+					if result.Allowed {
+						allowed++
+					}
+				}
+			}
+			return allowed, nil
+		})
+	})
+
+	b.Run("IndexAllowedActions", func(b *testing.B) {
+		run(b, func() (int, error) {
+			resolved, err := s.GetEffectiveUserPermissions("user-1", "")
+			if err != nil {
+				return 0, err
+			}
+			index := IndexAllowedActions(resolved)
+			allowed := 0
+			for i := 0; i < modules; i++ {
+				for _, action := range moduleActions {
+					if index[fmt.Sprintf("module-%d", i)][action] {
+						allowed++
+					}
+				}
+			}
+			return allowed, nil
+		})
+	})
+}
@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"strings"
 
+	"backend/internal/clock"
+	"backend/internal/database"
+	"backend/internal/helpers"
 	"backend/internal/models"
 
 	"github.com/google/uuid"
@@ -36,6 +39,7 @@ type PermissionListParams struct {
 	Action             string
 	Scope              string
 	Category           string
+	GroupName          string
 	IsActive           *bool
 	IsSystemPermission *bool
 	SortBy             string
@@ -104,6 +108,10 @@ func (s *PermissionService) GetPermissions(params PermissionListParams) (*Permis
 		query = query.Where("category = ?", params.Category)
 	}
 
+	if params.GroupName != "" {
+		query = query.Where("group_name = ?", params.GroupName)
+	}
+
 	if params.IsActive != nil {
 		query = query.Where("is_active = ?", *params.IsActive)
 	}
@@ -121,15 +129,11 @@ func (s *PermissionService) GetPermissions(params PermissionListParams) (*Permis
 	orderClause := "code ASC" // default
 	if params.SortBy != "" {
 		allowedSorts := map[string]bool{
-			"code": true, "name": true, "resource": true,
-			"created_at": true, "is_active": true,
+			"code": true, "name": true, "resource": true, "action": true,
+			"category": true, "group_name": true, "created_at": true, "is_active": true,
 		}
-		if allowedSorts[params.SortBy] {
-			direction := "ASC"
-			if strings.ToUpper(params.SortOrder) == "DESC" {
-				direction = "DESC"
-			}
-			orderClause = fmt.Sprintf("%s %s", params.SortBy, direction)
+		if order := helpers.SafeOrder(params.SortBy, params.SortOrder, allowedSorts); order != "" {
+			orderClause = order
 		}
 	}
 	query = query.Order(orderClause)
@@ -166,10 +170,11 @@ func (s *PermissionService) GetPermissions(params PermissionListParams) (*Permis
 	}, nil
 }
 
-// GetPermissionByID retrieves a permission by ID
+// GetPermissionByID retrieves a permission by ID. It reads the primary since updates act on
+// the row it returns.
 func (s *PermissionService) GetPermissionByID(id string) (*models.Permission, error) {
 	var permission models.Permission
-	if err := s.db.Where("id = ?", id).First(&permission).Error; err != nil {
+	if err := s.db.Clauses(database.Write).Where("id = ?", id).First(&permission).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("permission tidak ditemukan")
 		}
@@ -192,9 +197,11 @@ func (s *PermissionService) GetPermissionByCode(code string) (*models.Permission
 
 // CreatePermission creates a new permission with validation
 func (s *PermissionService) CreatePermission(req models.CreatePermissionRequest, userID string) (*models.Permission, error) {
+	db := database.Primary(s.db)
+
 	// Business rule: Check if code already exists
 	var existing models.Permission
-	if err := s.db.Where("code = ?", req.Code).First(&existing).Error; err == nil {
+	if err := db.Where("code = ?", req.Code).First(&existing).Error; err == nil {
 		return nil, errors.New("kode permission sudah digunakan")
 	}
 
@@ -206,6 +213,7 @@ func (s *PermissionService) CreatePermission(req models.CreatePermissionRequest,
 	if req.IsSystemPermission != nil {
 		isSystemPermission = *req.IsSystemPermission
 	}
+	isSensitive := req.IsSensitive != nil && *req.IsSensitive
 
 	// Create permission entity
 	permission := models.Permission{
@@ -220,6 +228,7 @@ func (s *PermissionService) CreatePermission(req models.CreatePermissionRequest,
 		Metadata:           req.Metadata,
 		IsSystemPermission: isSystemPermission,
 		IsActive:           true,
+		IsSensitive:        isSensitive,
 		CreatedBy:          &username,
 		Category:           req.Category,
 		GroupIcon:          req.GroupIcon,
@@ -228,15 +237,21 @@ func (s *PermissionService) CreatePermission(req models.CreatePermissionRequest,
 	}
 
 	// Persist to database
-	if err := s.db.Create(&permission).Error; err != nil {
+	if err := db.Create(&permission).Error; err != nil {
 		return nil, fmt.Errorf("gagal membuat permission: %w", err)
 	}
 
+	if isSensitive && s.permissionCache != nil {
+		s.permissionCache.AccessChecks().InvalidateSensitive()
+	}
+
 	return &permission, nil
 }
 
 // UpdatePermission updates an existing permission with validation
 func (s *PermissionService) UpdatePermission(id string, req models.UpdatePermissionRequest) (*models.Permission, error) {
+	db := database.Primary(s.db)
+
 	// Get existing permission
 	permission, err := s.GetPermissionByID(id)
 	if err != nil {
@@ -251,7 +266,7 @@ func (s *PermissionService) UpdatePermission(id string, req models.UpdatePermiss
 	// Check if code already exists (if being updated)
 	if req.Code != nil && *req.Code != permission.Code {
 		var existing models.Permission
-		if err := s.db.Where("code = ? AND id != ?", *req.Code, id).First(&existing).Error; err == nil {
+		if err := db.Where("code = ? AND id != ?", *req.Code, id).First(&existing).Error; err == nil {
 			return nil, errors.New("kode permission sudah digunakan")
 		}
 	}
@@ -285,6 +300,9 @@ func (s *PermissionService) UpdatePermission(id string, req models.UpdatePermiss
 	if req.IsActive != nil {
 		updates["is_active"] = *req.IsActive
 	}
+	if req.IsSensitive != nil {
+		updates["is_sensitive"] = *req.IsSensitive
+	}
 	if req.Category != nil {
 		updates["category"] = req.Category
 	}
@@ -299,25 +317,30 @@ func (s *PermissionService) UpdatePermission(id string, req models.UpdatePermiss
 	}
 
 	// Execute update
-	if err := s.db.Model(&permission).Updates(updates).Error; err != nil {
+	if err := db.Model(&permission).Updates(updates).Error; err != nil {
 		return nil, fmt.Errorf("gagal mengupdate permission: %w", err)
 	}
 
 	// Invalidate cache for all affected users
 	if s.permissionCache != nil {
 		s.invalidateCacheForPermissionUsers(id)
+		s.permissionCache.AccessChecks().InvalidateSensitive()
 	}
 
 	// Reload permission to get updated data
-	if err := s.db.First(&permission, "id = ?", id).Error; err != nil {
+	if err := db.First(&permission, "id = ?", id).Error; err != nil {
 		return nil, fmt.Errorf("gagal mengambil permission yang diupdate: %w", err)
 	}
 
 	return permission, nil
 }
 
-// DeletePermission deletes a permission with validation
-func (s *PermissionService) DeletePermission(id string) error {
+// DeletePermission deletes a permission with validation.
+// A permission still granted to roles or users is only deleted when force is set, in which
+// case those grants are revoked in the same transaction.
+func (s *PermissionService) DeletePermission(id string, force bool) error {
+	db := database.Primary(s.db)
+
 	// Get existing permission
 	permission, err := s.GetPermissionByID(id)
 	if err != nil {
@@ -331,30 +354,181 @@ func (s *PermissionService) DeletePermission(id string) error {
 
 	// Business rule: Check if permission is used by roles or users
 	var roleCount, userCount int64
-	if err := s.db.Model(&models.RolePermission{}).Where("permission_id = ?", id).Count(&roleCount).Error; err != nil {
+	if err := db.Model(&models.RolePermission{}).Where("permission_id = ?", id).Count(&roleCount).Error; err != nil {
 		return fmt.Errorf("gagal memeriksa penggunaan permission pada role: %w", err)
 	}
-	if err := s.db.Model(&models.UserPermission{}).Where("permission_id = ?", id).Count(&userCount).Error; err != nil {
+	if err := db.Model(&models.UserPermission{}).Where("permission_id = ?", id).Count(&userCount).Error; err != nil {
 		return fmt.Errorf("gagal memeriksa penggunaan permission pada user: %w", err)
 	}
 
-	if roleCount > 0 || userCount > 0 {
+	if (roleCount > 0 || userCount > 0) && !force {
 		return fmt.Errorf("tidak dapat menghapus permission: masih digunakan oleh %d role(s) dan %d user(s)", roleCount, userCount)
 	}
 
-	// Invalidate cache for all affected users before deletion
-	if s.permissionCache != nil {
-		s.invalidateCacheForPermissionUsers(id)
+	// Collect affected users before the grants disappear so their cache can be invalidated
+	affectedUserIDs, err := s.affectedUserIDs(id)
+	if err != nil {
+		return err
 	}
 
-	// Delete permission
-	if err := s.db.Delete(&permission).Error; err != nil {
-		return fmt.Errorf("gagal menghapus permission: %w", err)
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("permission_id = ?", id).Delete(&models.RolePermission{}).Error; err != nil {
+			return fmt.Errorf("gagal mencabut permission dari role: %w", err)
+		}
+		if err := tx.Where("permission_id = ?", id).Delete(&models.UserPermission{}).Error; err != nil {
+			return fmt.Errorf("gagal mencabut permission dari user: %w", err)
+		}
+		if err := tx.Delete(&permission).Error; err != nil {
+			return fmt.Errorf("gagal menghapus permission: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if s.permissionCache != nil {
+		for _, userID := range affectedUserIDs {
+			s.permissionCache.InvalidateUser(userID)
+		}
+		if permission.IsSensitive {
+			s.permissionCache.AccessChecks().InvalidateSensitive()
+		}
 	}
 
 	return nil
 }
 
+// GetPermissionUsage returns the roles and users that hold a permission, and how many users
+// would be affected by changing or deleting it.
+func (s *PermissionService) GetPermissionUsage(id string) (*models.PermissionUsageResponse, error) {
+	permission, err := s.GetPermissionByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := &models.PermissionUsageResponse{
+		PermissionID: permission.ID,
+		Code:         permission.Code,
+		Roles:        []models.PermissionUsageRole{},
+		Users:        []models.PermissionUsageUser{},
+	}
+
+	// Roles carrying the permission
+	if err := s.db.Table("public.role_permissions rp").
+		Select("r.id, r.code, r.name, rp.is_granted").
+		Joins("INNER JOIN public.roles r ON r.id = rp.role_id").
+		Where("rp.permission_id = ?", id).
+		Order("r.name ASC").
+		Scan(&usage.Roles).Error; err != nil {
+		return nil, fmt.Errorf("gagal mengambil role pengguna permission: %w", err)
+	}
+
+	// User counts for all of those roles in one query
+	if len(usage.Roles) > 0 {
+		roleIDs := make([]string, len(usage.Roles))
+		for i, role := range usage.Roles {
+			roleIDs[i] = role.ID
+		}
+
+		var counts []struct {
+			RoleID    string
+			UserCount int64
+		}
+		if err := s.db.Model(&models.UserRole{}).
+			Select("role_id, COUNT(DISTINCT user_id) AS user_count").
+			Where("role_id IN ? AND is_active = ?", roleIDs, true).
+			Group("role_id").
+			Scan(&counts).Error; err != nil {
+			return nil, fmt.Errorf("gagal menghitung user per role: %w", err)
+		}
+
+		countByRole := make(map[string]int64, len(counts))
+		for _, c := range counts {
+			countByRole[c.RoleID] = c.UserCount
+		}
+		for i := range usage.Roles {
+			usage.Roles[i].UserCount = countByRole[usage.Roles[i].ID]
+		}
+	}
+
+	// Users with a direct grant or deny
+	if err := s.db.Table("public.user_permissions up").
+		Select("u.id, u.email, u.username, up.is_granted, up.effective_until").
+		Joins("INNER JOIN public.users u ON u.id = up.user_id").
+		Where("up.permission_id = ?", id).
+		Order("u.email ASC").
+		Scan(&usage.Users).Error; err != nil {
+		return nil, fmt.Errorf("gagal mengambil user pengguna permission: %w", err)
+	}
+
+	affected, err := s.affectedUserIDs(id)
+	if err != nil {
+		return nil, err
+	}
+	usage.EffectiveUserCount = len(affected)
+
+	return usage, nil
+}
+
+// affectedUserIDs returns the distinct users whose access depends on a permission: holders of
+// a role carrying it (including roles that inherit it through the hierarchy), holders of a
+// position such a role grants module access to, delegates of a permission delegation that
+// covers it (listed directly or through the delegated role), and users with a direct
+// assignment.
+func (s *PermissionService) affectedUserIDs(permissionID string) ([]string, error) {
+	query := `
+		WITH RECURSIVE permission_roles AS (
+			SELECT rp.role_id, 0 AS depth
+			FROM public.role_permissions rp
+			WHERE rp.permission_id = $1
+
+			UNION
+
+			SELECT rh.role_id, pr.depth + 1
+			FROM public.role_hierarchy rh
+			INNER JOIN permission_roles pr ON rh.parent_role_id = pr.role_id
+			WHERE rh.inherit_permissions = true
+			AND pr.depth < 10
+		)
+		SELECT ur.user_id
+		FROM public.user_roles ur
+		WHERE ur.role_id IN (SELECT role_id FROM permission_roles)
+		AND ur.is_active = true
+
+		UNION
+
+		SELECT upos.user_id
+		FROM public.user_positions upos
+		INNER JOIN public.role_module_access rma ON rma.position_id = upos.position_id
+		WHERE rma.role_id IN (SELECT role_id FROM permission_roles)
+		AND rma.is_active = true
+		AND upos.is_active = true
+		AND (upos.end_date IS NULL OR upos.end_date >= $2)
+
+		UNION
+
+		SELECT d.delegate_id
+		FROM public.delegations d
+		WHERE d.type = $3
+		AND d.is_active = true
+		AND (d.effective_until IS NULL OR d.effective_until >= $2)
+		AND ($1 = ANY(d.permission_ids) OR d.role_id IN (SELECT role_id FROM permission_roles))
+
+		UNION
+
+		SELECT up.user_id
+		FROM public.user_permissions up
+		WHERE up.permission_id = $1
+	`
+
+	var userIDs []string
+	if err := s.db.Raw(query, permissionID, clock.Now(), models.DelegationTypePermission).Scan(&userIDs).Error; err != nil {
+		return nil, fmt.Errorf("gagal menghitung user terdampak permission: %w", err)
+	}
+	return userIDs, nil
+}
+
 // GetPermissionGroups retrieves permissions grouped by group_name
 func (s *PermissionService) GetPermissionGroups() ([]models.PermissionGroupResponse, error) {
 	var permissions []models.Permission
@@ -367,7 +541,7 @@ func (s *PermissionService) GetPermissionGroups() ([]models.PermissionGroupRespo
 	// Group permissions by group_name
 	groupMap := make(map[string]*models.PermissionGroupResponse)
 	for _, p := range permissions {
-		groupName := "Uncategorized"
+		groupName := uncategorizedPermissionGroup
 		if p.GroupName != nil && *p.GroupName != "" {
 			groupName = *p.GroupName
 		}
@@ -400,36 +574,159 @@ func (s *PermissionService) GetPermissionGroups() ([]models.PermissionGroupRespo
 	return groups, nil
 }
 
-// invalidateCacheForPermissionUsers invalidates permission cache for all users who have a specific permission
-// This includes users who have the permission directly or through roles
-func (s *PermissionService) invalidateCacheForPermissionUsers(permissionID string) {
-	// Track unique user IDs to invalidate
-	userIDs := make(map[string]bool)
-
-	// Find users with direct permission assignment
-	var userPermissions []models.UserPermission
-	if err := s.db.Where("permission_id = ? AND is_active = true", permissionID).Find(&userPermissions).Error; err == nil {
-		for _, up := range userPermissions {
-			userIDs[up.UserID] = true
+// uncategorizedPermissionGroup is the group shown for permissions without a group name
+const uncategorizedPermissionGroup = "Uncategorized"
+
+// permissionGroupMembers restricts a query to the permissions of the named group
+func permissionGroupMembers(name string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if name == uncategorizedPermissionGroup {
+			return db.Where("group_name IS NULL OR group_name = ''")
+		}
+		return db.Where("group_name = ?", name)
+	}
+}
+
+// UpdatePermissionGroup renames, re-icons or reorders a permission group by updating every
+// permission in it. Grouping only affects how permissions are displayed, so system
+// permissions are included.
+func (s *PermissionService) UpdatePermissionGroup(name string, req models.UpdatePermissionGroupRequest) (*models.PermissionGroupResponse, error) {
+	db := database.Primary(s.db)
+
+	newName := name
+	if req.Name != nil {
+		newName = strings.TrimSpace(*req.Name)
+		if newName == "" || newName == uncategorizedPermissionGroup {
+			return nil, errors.New("nama grup permission tidak valid")
 		}
 	}
 
-	// Find roles that have this permission
-	var rolePermissions []models.RolePermission
-	if err := s.db.Where("permission_id = ?", permissionID).Find(&rolePermissions).Error; err == nil {
-		for _, rp := range rolePermissions {
-			// Find users with this role
-			var userRoles []models.UserRole
-			if err := s.db.Where("role_id = ? AND is_active = true", rp.RoleID).Find(&userRoles).Error; err == nil {
-				for _, ur := range userRoles {
-					userIDs[ur.UserID] = true
-				}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.Permission{}).Scopes(permissionGroupMembers(name)).Count(&count).Error; err != nil {
+			return fmt.Errorf("gagal memeriksa grup permission: %w", err)
+		}
+		if count == 0 {
+			return errors.New("grup permission tidak ditemukan")
+		}
+
+		// Business rule: Renaming must not silently merge two groups
+		if newName != name {
+			var existing int64
+			if err := tx.Model(&models.Permission{}).Where("group_name = ?", newName).Count(&existing).Error; err != nil {
+				return fmt.Errorf("gagal memeriksa grup permission: %w", err)
+			}
+			if existing > 0 {
+				return fmt.Errorf("grup permission %s sudah ada", newName)
 			}
 		}
+
+		updates := make(map[string]interface{})
+		if req.Name != nil {
+			updates["group_name"] = newName
+		}
+		if req.Icon != nil {
+			updates["group_icon"] = req.Icon
+		}
+		if req.SortOrder != nil {
+			updates["group_sort_order"] = *req.SortOrder
+		}
+		if len(updates) == 0 {
+			return nil
+		}
+
+		if err := tx.Model(&models.Permission{}).Scopes(permissionGroupMembers(name)).Updates(updates).Error; err != nil {
+			return fmt.Errorf("gagal mengupdate grup permission: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var permissions []models.Permission
+	if err := db.Scopes(permissionGroupMembers(newName)).Order("code ASC").Find(&permissions).Error; err != nil {
+		return nil, fmt.Errorf("gagal mengambil grup permission: %w", err)
+	}
+
+	group := &models.PermissionGroupResponse{
+		GroupName:   newName,
+		SortOrder:   999,
+		Permissions: make([]models.PermissionListResponse, 0, len(permissions)),
+	}
+	for i, p := range permissions {
+		if i == 0 {
+			group.GroupIcon = p.GroupIcon
+			if p.GroupSortOrder != nil {
+				group.SortOrder = *p.GroupSortOrder
+			}
+		}
+		group.Permissions = append(group.Permissions, *p.ToListResponse())
+	}
+
+	return group, nil
+}
+
+// MovePermissionToGroup moves a permission into another group, taking over that group's icon
+// and sort order so it renders with its new neighbours. An empty group name ungroups it.
+func (s *PermissionService) MovePermissionToGroup(id string, req models.MovePermissionGroupRequest) (*models.Permission, error) {
+	db := database.Primary(s.db)
+
+	permission, err := s.GetPermissionByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	groupName := strings.TrimSpace(req.GroupName)
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		updates := map[string]interface{}{
+			"group_name":       nil,
+			"group_icon":       nil,
+			"group_sort_order": nil,
+		}
+
+		if groupName != "" && groupName != uncategorizedPermissionGroup {
+			updates["group_name"] = groupName
+			updates["group_icon"] = req.GroupIcon
+			updates["group_sort_order"] = req.GroupSortOrder
+
+			// An existing group keeps its icon and position
+			var member models.Permission
+			err := tx.Where("group_name = ? AND id != ?", groupName, id).First(&member).Error
+			if err == nil {
+				updates["group_icon"] = member.GroupIcon
+				updates["group_sort_order"] = member.GroupSortOrder
+			} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("gagal memeriksa grup permission: %w", err)
+			}
+		}
+
+		if err := tx.Model(permission).Updates(updates).Error; err != nil {
+			return fmt.Errorf("gagal memindahkan permission ke grup: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.First(permission, "id = ?", id).Error; err != nil {
+		return nil, fmt.Errorf("gagal mengambil permission yang diupdate: %w", err)
+	}
+
+	return permission, nil
+}
+
+// invalidateCacheForPermissionUsers invalidates permission cache for all users who have a specific permission
+// This includes users who have the permission directly or through roles, inherited ones included
+func (s *PermissionService) invalidateCacheForPermissionUsers(permissionID string) {
+	userIDs, err := s.affectedUserIDs(permissionID)
+	if err != nil {
+		return
 	}
 
-	// Invalidate cache for each unique user
-	for userID := range userIDs {
+	for _, userID := range userIDs {
 		s.permissionCache.InvalidateUser(userID)
 	}
 }
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"backend/internal/models"
+)
+
+// Organizational levels a position can belong to
+const (
+	PositionOrgLevelFoundation = "foundation" // neither department nor school
+	PositionOrgLevelSchool     = "school"     // school without department
+	PositionOrgLevelDepartment = "department" // department (optionally within a school)
+)
+
+// PositionScopeRule maps positions at an organizational level, up to a hierarchy level
+// (lower number = more senior), to a default permission scope
+type PositionScopeRule struct {
+	OrgLevel          string
+	MaxHierarchyLevel int
+	Scope             models.PermissionScope
+}
+
+// DefaultPositionScopeRules returns the default scope rules.
+// Rules are evaluated in order; the first match wins. Positions matching no rule get OWN.
+func DefaultPositionScopeRules() []PositionScopeRule {
+	return []PositionScopeRule{
+		// Foundation leadership sees everything
+		{OrgLevel: PositionOrgLevelFoundation, MaxHierarchyLevel: 2, Scope: models.PermissionScopeAll},
+		// Principal and vice principal see their school
+		{OrgLevel: PositionOrgLevelSchool, MaxHierarchyLevel: 2, Scope: models.PermissionScopeSchool},
+		// Department head and deputy see their department
+		{OrgLevel: PositionOrgLevelDepartment, MaxHierarchyLevel: 2, Scope: models.PermissionScopeDepartment},
+	}
+}
+
+// ParsePositionScopeRules parses rules written as org_level:max_hierarchy_level:SCOPE,
+// e.g. "school:2:SCHOOL", keeping their order. The scope is case-insensitive.
+func ParsePositionScopeRules(specs []string) ([]PositionScopeRule, error) {
+	rules := make([]PositionScopeRule, 0, len(specs))
+	for _, spec := range specs {
+		parts := strings.Split(spec, ":")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("position scope rule %q: want org_level:max_hierarchy_level:SCOPE", spec)
+		}
+
+		orgLevel := strings.ToLower(strings.TrimSpace(parts[0]))
+		switch orgLevel {
+		case PositionOrgLevelFoundation, PositionOrgLevelSchool, PositionOrgLevelDepartment:
+		default:
+			return nil, fmt.Errorf("position scope rule %q: unknown org level %q", spec, parts[0])
+		}
+
+		maxLevel, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, fmt.Errorf("position scope rule %q: invalid hierarchy level %q", spec, parts[1])
+		}
+
+		scope, ok := normalizePermissionScope(parts[2])
+		if !ok {
+			return nil, fmt.Errorf("position scope rule %q: unknown scope %q", spec, parts[2])
+		}
+
+		rules = append(rules, PositionScopeRule{OrgLevel: orgLevel, MaxHierarchyLevel: maxLevel, Scope: scope})
+	}
+	return rules, nil
+}
+
+// positionOrgLevel returns the organizational level of a position
+func positionOrgLevel(position *models.Position) string {
+	switch {
+	case position.DepartmentID != nil && *position.DepartmentID != "":
+		return PositionOrgLevelDepartment
+	case position.SchoolID != nil && *position.SchoolID != "":
+		return PositionOrgLevelSchool
+	default:
+		return PositionOrgLevelFoundation
+	}
+}
+
+// derivePositionScope returns the default permission scope for a position according to rules
+func derivePositionScope(position *models.Position, rules []PositionScopeRule) models.PermissionScope {
+	orgLevel := positionOrgLevel(position)
+	for _, rule := range rules {
+		if rule.OrgLevel == orgLevel && position.HierarchyLevel <= rule.MaxHierarchyLevel {
+			return rule.Scope
+		}
+	}
+	return models.PermissionScopeOwn
+}
+
+// normalizePermissionScope validates an explicitly provided scope, accepting any letter case
+func normalizePermissionScope(scope string) (models.PermissionScope, bool) {
+	normalized := models.PermissionScope(strings.ToUpper(strings.TrimSpace(scope)))
+	return normalized, normalized.IsValid()
+}
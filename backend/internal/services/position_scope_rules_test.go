@@ -0,0 +1,67 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"backend/internal/models"
+)
+
+func TestParsePositionScopeRules(t *testing.T) {
+	rules, err := ParsePositionScopeRules([]string{"foundation:2:ALL", " School : 3 : school ", "department:1:DEPARTMENT"})
+	if err != nil {
+		t.Fatalf("ParsePositionScopeRules: %v", err)
+	}
+	want := []PositionScopeRule{
+		{OrgLevel: PositionOrgLevelFoundation, MaxHierarchyLevel: 2, Scope: models.PermissionScopeAll},
+		{OrgLevel: PositionOrgLevelSchool, MaxHierarchyLevel: 3, Scope: models.PermissionScopeSchool},
+		{OrgLevel: PositionOrgLevelDepartment, MaxHierarchyLevel: 1, Scope: models.PermissionScopeDepartment},
+	}
+	if !reflect.DeepEqual(rules, want) {
+		t.Errorf("got %+v, want %+v", rules, want)
+	}
+
+	defaults := []string{"foundation:2:ALL", "school:2:SCHOOL", "department:2:DEPARTMENT"}
+	if rules, err := ParsePositionScopeRules(defaults); err != nil || !reflect.DeepEqual(rules, DefaultPositionScopeRules()) {
+		t.Errorf("the documented defaults parse to %+v (%v), want DefaultPositionScopeRules()", rules, err)
+	}
+
+	for _, spec := range []string{"school:2", "school:2:SCHOOL:extra", "campus:2:SCHOOL", "school:two:SCHOOL", "school:2:EVERYTHING", ""} {
+		if _, err := ParsePositionScopeRules([]string{spec}); err == nil {
+			t.Errorf("ParsePositionScopeRules accepted %q", spec)
+		}
+	}
+}
+
+func TestDerivePositionScope(t *testing.T) {
+	school, department := "school-1", "dept-1"
+	rules := DefaultPositionScopeRules()
+
+	tests := []struct {
+		name     string
+		position models.Position
+		want     models.PermissionScope
+	}{
+		{"foundation chair", models.Position{HierarchyLevel: 1}, models.PermissionScopeAll},
+		{"foundation staff", models.Position{HierarchyLevel: 5}, models.PermissionScopeOwn},
+		{"principal", models.Position{SchoolID: &school, HierarchyLevel: 1}, models.PermissionScopeSchool},
+		{"vice principal", models.Position{SchoolID: &school, HierarchyLevel: 2}, models.PermissionScopeSchool},
+		{"teacher", models.Position{SchoolID: &school, HierarchyLevel: 3}, models.PermissionScopeOwn},
+		{"department head", models.Position{SchoolID: &school, DepartmentID: &department, HierarchyLevel: 1}, models.PermissionScopeDepartment},
+		{"department staff", models.Position{DepartmentID: &department, HierarchyLevel: 4}, models.PermissionScopeOwn},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := derivePositionScope(&tt.position, rules); got != tt.want {
+				t.Errorf("derivePositionScope = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	// Configured rules replace the defaults
+	custom := []PositionScopeRule{{OrgLevel: PositionOrgLevelSchool, MaxHierarchyLevel: 3, Scope: models.PermissionScopeSchool}}
+	if got := derivePositionScope(&models.Position{SchoolID: &school, HierarchyLevel: 3}, custom); got != models.PermissionScopeSchool {
+		t.Errorf("teacher under custom rules got %s, want SCHOOL", got)
+	}
+}
@@ -3,8 +3,10 @@ package services
 import (
 	"errors"
 	"fmt"
-	"strings"
 
+	"backend/internal/clock"
+	"backend/internal/database"
+	"backend/internal/helpers"
 	"backend/internal/models"
 
 	"github.com/google/uuid"
@@ -45,9 +47,11 @@ type PositionListResult struct {
 
 // CreatePosition creates a new position with validation
 func (s *PositionService) CreatePosition(req models.CreatePositionRequest, userID string) (*models.Position, error) {
+	db := database.Primary(s.db)
+
 	// Business rule: Check if code already exists
 	var existing models.Position
-	if err := s.db.Where("code = ?", req.Code).First(&existing).Error; err == nil {
+	if err := db.Where("code = ?", req.Code).First(&existing).Error; err == nil {
 		return nil, errors.New("kode posisi sudah digunakan")
 	}
 
@@ -91,12 +95,12 @@ func (s *PositionService) CreatePosition(req models.CreatePositionRequest, userI
 		ModifiedBy:     &userID,
 	}
 
-	if err := s.db.Create(&position).Error; err != nil {
+	if err := db.Create(&position).Error; err != nil {
 		return nil, fmt.Errorf("gagal membuat posisi: %w", err)
 	}
 
 	// Load relations for response
-	s.db.Preload("Department").Preload("School").
+	db.Preload("Department").Preload("School").
 		First(&position, "id = ?", position.ID)
 
 	return &position, nil
@@ -144,12 +148,8 @@ func (s *PositionService) GetPositions(params PositionListParams) (*PositionList
 			"created_at": true, "is_active": true, "school_id": true,
 			"department_id": true,
 		}
-		if validSortColumns[params.SortBy] {
-			direction := "ASC"
-			if strings.ToLower(params.SortOrder) == "desc" {
-				direction = "DESC"
-			}
-			query = query.Order(fmt.Sprintf("%s %s", params.SortBy, direction))
+		if order := helpers.SafeOrder(params.SortBy, params.SortOrder, validSortColumns); order != "" {
+			query = query.Order(order)
 		}
 	}
 
@@ -198,11 +198,75 @@ func (s *PositionService) GetPositionByID(id string) (*models.Position, error) {
 	return &position, nil
 }
 
+// GetPositionHolders lists the users assigned to a position. By default only currently effective
+// assignments are returned (active, started and not yet ended); includeEnded also returns past
+// and inactive assignments so the position's history can be shown.
+func (s *PositionService) GetPositionHolders(positionID string, includeEnded bool) ([]*models.PositionHolderResponse, error) {
+	var position models.Position
+	if err := s.db.Preload("Department").First(&position, "id = ?", positionID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("posisi tidak ditemukan")
+		}
+		return nil, fmt.Errorf("gagal mengambil data posisi: %w", err)
+	}
+
+	now := clock.Now()
+	query := s.db.Preload("User").Preload("User.DataKaryawan").
+		Where("position_id = ?", positionID)
+	if !includeEnded {
+		query = query.Where("is_active = ?", true).
+			Where("start_date <= ?", now).
+			Where("(end_date IS NULL OR end_date >= ?)", now)
+	}
+
+	var userPositions []models.UserPosition
+	if err := query.Order("is_plt ASC, start_date DESC").Find(&userPositions).Error; err != nil {
+		return nil, fmt.Errorf("gagal mengambil data pemegang posisi: %w", err)
+	}
+
+	var department *models.DepartmentListResponse
+	if position.Department != nil {
+		department = position.Department.ToListResponse()
+	}
+
+	holders := make([]*models.PositionHolderResponse, 0, len(userPositions))
+	for _, up := range userPositions {
+		if up.User == nil {
+			continue
+		}
+		holder := &models.PositionHolderResponse{
+			UserPositionID: up.ID,
+			UserID:         up.UserID,
+			Email:          up.User.Email,
+			Username:       up.User.Username,
+			Name:           orgChartHolderName(up.User),
+			Department:     department,
+			StartDate:      up.StartDate,
+			EndDate:        up.EndDate,
+			IsActive:       up.IsActive,
+			IsPlt:          up.IsPlt,
+			IsCurrent:      up.IsActive && !up.StartDate.After(now) && (up.EndDate == nil || !up.EndDate.Before(now)),
+			SKNumber:       up.SKNumber,
+			EndReason:      up.EndReason,
+		}
+		if up.User.DataKaryawan != nil {
+			nip := up.User.DataKaryawan.NIP
+			holder.NIP = &nip
+			holder.BagianKerja = up.User.DataKaryawan.BagianKerja
+		}
+		holders = append(holders, holder)
+	}
+
+	return holders, nil
+}
+
 // UpdatePosition updates a position with validation
 func (s *PositionService) UpdatePosition(id string, req models.UpdatePositionRequest, userID string) (*models.Position, error) {
+	db := database.Primary(s.db)
+
 	// Find existing position
 	var position models.Position
-	if err := s.db.First(&position, "id = ?", id).Error; err != nil {
+	if err := db.First(&position, "id = ?", id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("posisi tidak ditemukan")
 		}
@@ -212,7 +276,7 @@ func (s *PositionService) UpdatePosition(id string, req models.UpdatePositionReq
 	// Business rule: Check if code is being changed and already in use
 	if req.Code != nil && *req.Code != position.Code {
 		var existing models.Position
-		if err := s.db.Where("code = ? AND id != ?", *req.Code, id).First(&existing).Error; err == nil {
+		if err := db.Where("code = ? AND id != ?", *req.Code, id).First(&existing).Error; err == nil {
 			return nil, errors.New("kode posisi sudah digunakan")
 		}
 	}
@@ -308,12 +372,12 @@ func (s *PositionService) UpdatePosition(id string, req models.UpdatePositionReq
 	}
 
 	// Use Select + Updates to force update of specified fields
-	if err := s.db.Model(&position).Select(selectFields).Updates(updateMap).Error; err != nil {
+	if err := db.Model(&position).Select(selectFields).Updates(updateMap).Error; err != nil {
 		return nil, fmt.Errorf("gagal memperbarui posisi: %w", err)
 	}
 
 	// Load relations for response
-	s.db.Preload("Department").Preload("School").
+	db.Preload("Department").Preload("School").
 		First(&position, "id = ?", position.ID)
 
 	return &position, nil
@@ -321,9 +385,11 @@ func (s *PositionService) UpdatePosition(id string, req models.UpdatePositionReq
 
 // DeletePosition deletes a position with validation
 func (s *PositionService) DeletePosition(id string) error {
+	db := database.Primary(s.db)
+
 	// Check if position exists
 	var position models.Position
-	if err := s.db.First(&position, "id = ?", id).Error; err != nil {
+	if err := db.First(&position, "id = ?", id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return errors.New("posisi tidak ditemukan")
 		}
@@ -332,30 +398,170 @@ func (s *PositionService) DeletePosition(id string) error {
 
 	// Business rule: Check if position has user assignments
 	var userPositionCount int64
-	s.db.Model(&models.UserPosition{}).Where("position_id = ?", id).Count(&userPositionCount)
+	db.Model(&models.UserPosition{}).Where("position_id = ?", id).Count(&userPositionCount)
 	if userPositionCount > 0 {
 		return errors.New("tidak dapat menghapus posisi yang masih memiliki pemegang jabatan")
 	}
 
 	// Business rule: Check if position is used in workflow rules
 	var workflowRuleCount int64
-	s.db.Model(&models.WorkflowRule{}).Where("position_id = ? OR creator_position_id = ? OR approver_position_id = ?", id, id, id).Count(&workflowRuleCount)
+	db.Model(&models.WorkflowRule{}).Where("position_id = ? OR creator_position_id = ? OR approver_position_id = ?", id, id, id).Count(&workflowRuleCount)
 	if workflowRuleCount > 0 {
 		return errors.New("tidak dapat menghapus posisi yang digunakan dalam aturan workflow")
 	}
 
-	if err := s.db.Delete(&position).Error; err != nil {
+	if err := db.Delete(&position).Error; err != nil {
 		return fmt.Errorf("gagal menghapus posisi: %w", err)
 	}
 
 	return nil
 }
 
+// GetOrgChart builds the organizational chart of active departments, their positions, and
+// the users currently holding each position. When schoolID is set only that school's
+// GetAvailablePositionCodes suggests position codes starting with prefix that are not taken yet
+func (s *PositionService) GetAvailablePositionCodes(prefix string, count int) ([]string, error) {
+	return suggestAvailableCodes(s.db, models.Position{}.TableName(), prefix, count)
+}
+
+// departments and positions are included. Data is loaded in a fixed number of queries.
+func (s *PositionService) GetOrgChart(schoolID string) (*models.OrgChartResponse, error) {
+	// Get departments
+	deptQuery := s.db.Where("is_active = ?", true)
+	if schoolID != "" {
+		deptQuery = deptQuery.Where("school_id = ?", schoolID)
+	}
+	var departments []models.Department
+	if err := deptQuery.Order("name ASC").Find(&departments).Error; err != nil {
+		return nil, fmt.Errorf("gagal mengambil data departemen: %w", err)
+	}
+
+	departmentIDs := make([]string, len(departments))
+	for i := range departments {
+		departmentIDs[i] = departments[i].ID
+	}
+
+	// Get positions in those departments plus positions outside any department
+	posQuery := s.db.Where("is_active = ?", true)
+	if schoolID != "" {
+		posQuery = posQuery.Where("(department_id IN ? OR (department_id IS NULL AND school_id = ?))", departmentIDs, schoolID)
+	} else {
+		posQuery = posQuery.Where("(department_id IN ? OR department_id IS NULL)", departmentIDs)
+	}
+	var positions []models.Position
+	if err := posQuery.Order("hierarchy_level ASC, name ASC").Find(&positions).Error; err != nil {
+		return nil, fmt.Errorf("gagal mengambil data posisi: %w", err)
+	}
+
+	positionIDs := make([]string, len(positions))
+	for i := range positions {
+		positionIDs[i] = positions[i].ID
+	}
+
+	// Get current holders of those positions
+	var userPositions []models.UserPosition
+	if len(positionIDs) > 0 {
+		now := clock.Now()
+		if err := s.db.Preload("User").Preload("User.DataKaryawan").
+			Where("position_id IN ?", positionIDs).
+			Where("is_active = ?", true).
+			Where("start_date <= ?", now).
+			Where("(end_date IS NULL OR end_date >= ?)", now).
+			Order("is_plt ASC, start_date ASC").
+			Find(&userPositions).Error; err != nil {
+			return nil, fmt.Errorf("gagal mengambil data pemegang posisi: %w", err)
+		}
+	}
+
+	// Build position nodes with their holders
+	positionNodes := make(map[string]*models.OrgChartPosition, len(positions))
+	for i := range positions {
+		p := &positions[i]
+		positionNodes[p.ID] = &models.OrgChartPosition{
+			ID:             p.ID,
+			Code:           p.Code,
+			Name:           p.Name,
+			SchoolID:       p.SchoolID,
+			HierarchyLevel: p.HierarchyLevel,
+			MaxHolders:     p.MaxHolders,
+			Holders:        []*models.OrgChartHolder{},
+		}
+	}
+	for _, up := range userPositions {
+		node, ok := positionNodes[up.PositionID]
+		if !ok || up.User == nil {
+			continue
+		}
+		node.Holders = append(node.Holders, &models.OrgChartHolder{
+			UserPositionID: up.ID,
+			UserID:         up.UserID,
+			Email:          up.User.Email,
+			Name:           orgChartHolderName(up.User),
+			IsPlt:          up.IsPlt,
+			StartDate:      up.StartDate,
+		})
+	}
+
+	// Build department nodes
+	departmentNodes := make(map[string]*models.OrgChartDepartment, len(departments))
+	for i := range departments {
+		d := &departments[i]
+		departmentNodes[d.ID] = &models.OrgChartDepartment{
+			ID:        d.ID,
+			Code:      d.Code,
+			Name:      d.Name,
+			SchoolID:  d.SchoolID,
+			Positions: []*models.OrgChartPosition{},
+		}
+	}
+
+	chart := &models.OrgChartResponse{
+		Departments: []*models.OrgChartDepartment{},
+		Positions:   []*models.OrgChartPosition{},
+	}
+
+	// Attach positions (already ordered by hierarchy level) to their department
+	for i := range positions {
+		p := &positions[i]
+		node := positionNodes[p.ID]
+		if p.DepartmentID != nil {
+			if dept, ok := departmentNodes[*p.DepartmentID]; ok {
+				dept.Positions = append(dept.Positions, node)
+				continue
+			}
+		}
+		chart.Positions = append(chart.Positions, node)
+	}
+
+	// Nest departments; a department whose parent is not in the chart becomes a root
+	for i := range departments {
+		d := &departments[i]
+		node := departmentNodes[d.ID]
+		if d.ParentID != nil {
+			if parent, ok := departmentNodes[*d.ParentID]; ok {
+				parent.Children = append(parent.Children, node)
+				continue
+			}
+		}
+		chart.Departments = append(chart.Departments, node)
+	}
+
+	return chart, nil
+}
+
+// orgChartHolderName returns the employee name of a user, falling back to the username
+func orgChartHolderName(user *models.User) *string {
+	if user.DataKaryawan != nil && user.DataKaryawan.Nama != nil {
+		return user.DataKaryawan.Nama
+	}
+	return user.Username
+}
+
 // Helper methods for validation
 
 func (s *PositionService) validateDepartmentExists(id string) error {
 	var department models.Department
-	if err := s.db.First(&department, "id = ?", id).Error; err != nil {
+	if err := s.db.Clauses(database.Write).First(&department, "id = ?", id).Error; err != nil {
 		return errors.New("departemen tidak ditemukan")
 	}
 	return nil
@@ -363,7 +569,7 @@ func (s *PositionService) validateDepartmentExists(id string) error {
 
 func (s *PositionService) validateSchoolExists(id string) error {
 	var school models.School
-	if err := s.db.First(&school, "id = ?", id).Error; err != nil {
+	if err := s.db.Clauses(database.Write).First(&school, "id = ?", id).Error; err != nil {
 		return errors.New("sekolah tidak ditemukan")
 	}
 	return nil
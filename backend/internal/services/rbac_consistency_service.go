@@ -0,0 +1,212 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// rbacConsistencySampleSize is how many offending row ids a report lists per check
+const rbacConsistencySampleSize = 20
+
+// errRBACDryRun rolls back a dry-run repair
+var errRBACDryRun = errors.New("dry run")
+
+// RBAC consistency repair actions
+const (
+	RBACRepairDelete = "delete" // remove the dangling row
+	RBACRepairEnd    = "end"    // deactivate and end-date the assignment, like DeleteRole does
+)
+
+// rbacConsistencyCheck finds rows of one table whose references no longer resolve.
+// orphaned is a condition on the table aliased t.
+type rbacConsistencyCheck struct {
+	name        string
+	description string
+	table       string
+	orphaned    string
+	repair      string
+}
+
+// rbacConsistencyChecks are the dangling references the resolver skips silently.
+// Each one only leaves clutter behind, so repairing them does not change effective access.
+var rbacConsistencyChecks = []rbacConsistencyCheck{
+	{
+		name:        "role_permission_missing_permission",
+		description: "Permission role menunjuk ke permission yang sudah dihapus",
+		table:       "public.role_permissions",
+		orphaned:    "NOT EXISTS (SELECT 1 FROM public.permissions p WHERE p.id = t.permission_id)",
+		repair:      RBACRepairDelete,
+	},
+	{
+		name:        "role_permission_missing_role",
+		description: "Permission role menunjuk ke role yang sudah dihapus",
+		table:       "public.role_permissions",
+		orphaned:    "NOT EXISTS (SELECT 1 FROM public.roles r WHERE r.id = t.role_id)",
+		repair:      RBACRepairDelete,
+	},
+	{
+		name:        "user_permission_missing_permission",
+		description: "Permission langsung user menunjuk ke permission yang sudah dihapus",
+		table:       "public.user_permissions",
+		orphaned:    "NOT EXISTS (SELECT 1 FROM public.permissions p WHERE p.id = t.permission_id)",
+		repair:      RBACRepairDelete,
+	},
+	{
+		name:        "user_role_inactive_role",
+		description: "Assignment role aktif menunjuk ke role yang tidak aktif atau sudah dihapus",
+		table:       "public.user_roles",
+		orphaned:    "t.is_active = true AND NOT EXISTS (SELECT 1 FROM public.roles r WHERE r.id = t.role_id AND r.is_active = true)",
+		repair:      RBACRepairEnd,
+	},
+	{
+		name:        "role_module_access_deleted_module",
+		description: "Akses module role menunjuk ke module yang sudah dihapus",
+		table:       "public.role_module_access",
+		orphaned:    "NOT EXISTS (SELECT 1 FROM public.modules m WHERE m.id = t.module_id AND m.deleted_at IS NULL)",
+		repair:      RBACRepairDelete,
+	},
+	{
+		name:        "role_module_access_missing_role",
+		description: "Akses module role menunjuk ke role yang sudah dihapus",
+		table:       "public.role_module_access",
+		orphaned:    "NOT EXISTS (SELECT 1 FROM public.roles r WHERE r.id = t.role_id)",
+		repair:      RBACRepairDelete,
+	},
+	{
+		name:        "user_module_access_deleted_module",
+		description: "Akses module user menunjuk ke module yang sudah dihapus",
+		table:       "public.user_module_access",
+		orphaned:    "NOT EXISTS (SELECT 1 FROM public.modules m WHERE m.id = t.module_id AND m.deleted_at IS NULL)",
+		repair:      RBACRepairDelete,
+	},
+	{
+		name:        "role_hierarchy_missing_role",
+		description: "Hierarchy role menunjuk ke role atau parent role yang sudah dihapus",
+		table:       "public.role_hierarchy",
+		orphaned: "(NOT EXISTS (SELECT 1 FROM public.roles r WHERE r.id = t.role_id)" +
+			" OR NOT EXISTS (SELECT 1 FROM public.roles r WHERE r.id = t.parent_role_id))",
+		repair: RBACRepairDelete,
+	},
+}
+
+// RBACConsistencyIssue is the result of one consistency check
+type RBACConsistencyIssue struct {
+	Check       string   `json:"check"`
+	Description string   `json:"description"`
+	Table       string   `json:"table"`
+	Repair      string   `json:"repair"`
+	Count       int64    `json:"count"`
+	SampleIDs   []string `json:"sample_ids"`
+}
+
+// RBACConsistencyReport lists every check with the rows it found; after a repair the counts
+// are the rows that were (or, in a dry run, would have been) fixed
+type RBACConsistencyReport struct {
+	CheckedAt   time.Time              `json:"checked_at"`
+	Issues      []RBACConsistencyIssue `json:"issues"`
+	TotalIssues int64                  `json:"total_issues"`
+	DryRun      bool                   `json:"dry_run"`
+	Repaired    bool                   `json:"repaired"`
+}
+
+// RBACConsistencyService finds and cleans up RBAC rows with dangling references
+type RBACConsistencyService struct {
+	db              *gorm.DB
+	audit           *AuditService
+	permissionCache *PermissionCacheService
+}
+
+// NewRBACConsistencyService creates a new RBACConsistencyService instance
+func NewRBACConsistencyService(db *gorm.DB, cache *PermissionCacheService) *RBACConsistencyService {
+	return &RBACConsistencyService{db: db, audit: NewAuditService(db), permissionCache: cache}
+}
+
+// Check runs every consistency check without changing anything
+func (s *RBACConsistencyService) Check() (*RBACConsistencyReport, error) {
+	return s.run(s.db)
+}
+
+// Repair runs every check and fixes what it finds in one transaction. A dry run reports the
+// same rows and rolls back, so it shows exactly what a real repair would do.
+func (s *RBACConsistencyService) Repair(dryRun bool, repairedBy string) (*RBACConsistencyReport, error) {
+	var report *RBACConsistencyReport
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if report, err = s.run(tx); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for _, check := range rbacConsistencyChecks {
+			orphans := "SELECT t.id FROM " + check.table + " t WHERE " + check.orphaned
+			var repair *gorm.DB
+			switch check.repair {
+			case RBACRepairEnd:
+				repair = tx.Exec("UPDATE "+check.table+" SET is_active = false, effective_until = ? WHERE id IN ("+orphans+")", now)
+			default:
+				repair = tx.Exec("DELETE FROM " + check.table + " WHERE id IN (" + orphans + ")")
+			}
+			if err := repair.Error; err != nil {
+				return fmt.Errorf("gagal memperbaiki %s: %w", check.name, err)
+			}
+		}
+
+		if dryRun {
+			return errRBACDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errRBACDryRun) {
+		return nil, err
+	}
+
+	report.DryRun = dryRun
+	report.Repaired = !dryRun && report.TotalIssues > 0
+	if report.Repaired {
+		s.audit.Record(repairedBy, models.AuditActionUpdate, "rbac_consistency", "repair", nil, report)
+		if s.permissionCache != nil {
+			s.permissionCache.InvalidateAll()
+		}
+	}
+	return report, nil
+}
+
+// run counts and samples the offending rows of every check
+func (s *RBACConsistencyService) run(db *gorm.DB) (*RBACConsistencyReport, error) {
+	report := &RBACConsistencyReport{
+		CheckedAt: time.Now(),
+		Issues:    make([]RBACConsistencyIssue, 0, len(rbacConsistencyChecks)),
+	}
+
+	for _, check := range rbacConsistencyChecks {
+		issue := RBACConsistencyIssue{
+			Check:       check.name,
+			Description: check.description,
+			Table:       check.table,
+			Repair:      check.repair,
+			SampleIDs:   []string{},
+		}
+
+		orphans := func() *gorm.DB {
+			return db.Table(check.table + " t").Where(check.orphaned)
+		}
+		if err := orphans().Count(&issue.Count).Error; err != nil {
+			return nil, fmt.Errorf("gagal menjalankan pemeriksaan %s: %w", check.name, err)
+		}
+		if issue.Count > 0 {
+			if err := orphans().Order("t.id").Limit(rbacConsistencySampleSize).Pluck("t.id", &issue.SampleIDs).Error; err != nil {
+				return nil, fmt.Errorf("gagal menjalankan pemeriksaan %s: %w", check.name, err)
+			}
+		}
+
+		report.Issues = append(report.Issues, issue)
+		report.TotalIssues += issue.Count
+	}
+
+	return report, nil
+}
@@ -0,0 +1,444 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"backend/internal/clock"
+	"backend/internal/database"
+	"backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrRoleImportUnresolved is returned when an imported role references codes that do not
+// exist in this environment and the import was not asked to skip them
+var ErrRoleImportUnresolved = errors.New("role mereferensikan kode yang tidak ditemukan")
+
+// Reference types reported in RoleImportResult.Unresolved
+const (
+	roleImportRefPermission = "permission"
+	roleImportRefModule     = "module"
+	roleImportRefPosition   = "position"
+	roleImportRefParentRole = "parent_role"
+)
+
+// ExportRole returns the complete definition of a role with every reference expressed as a
+// code, ready to be imported into another environment
+func (s *RoleService) ExportRole(id string) (*models.RoleExport, error) {
+	var role models.Role
+	if err := s.db.First(&role, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("role tidak ditemukan")
+		}
+		return nil, fmt.Errorf("gagal mengambil data role: %w", err)
+	}
+
+	var rolePermissions []models.RolePermission
+	if err := s.db.Preload("Permission").Where("role_id = ?", id).Find(&rolePermissions).Error; err != nil {
+		return nil, fmt.Errorf("gagal mengambil permission role: %w", err)
+	}
+
+	var moduleAccesses []models.RoleModuleAccess
+	if err := s.db.Preload("Module").Preload("Position").Where("role_id = ?", id).Find(&moduleAccesses).Error; err != nil {
+		return nil, fmt.Errorf("gagal mengambil akses module role: %w", err)
+	}
+
+	var parents []models.RoleHierarchy
+	if err := s.db.Preload("ParentRole").Where("role_id = ?", id).Find(&parents).Error; err != nil {
+		return nil, fmt.Errorf("gagal mengambil parent role: %w", err)
+	}
+
+	exportedAt := time.Now()
+	export := &models.RoleExport{
+		FormatVersion: models.RoleExportFormatVersion,
+		ExportedAt:    &exportedAt,
+		Role: models.RoleExportMetadata{
+			Code:           role.Code,
+			Name:           role.Name,
+			Description:    role.Description,
+			HierarchyLevel: role.HierarchyLevel,
+			IsSystemRole:   role.IsSystemRole,
+			IsActive:       role.IsActive,
+		},
+		Permissions:  []models.RoleExportPermission{},
+		ModuleAccess: []models.RoleExportModuleAccess{},
+		ParentRoles:  []models.RoleExportParentRole{},
+	}
+
+	for _, rp := range rolePermissions {
+		if rp.Permission == nil {
+			continue
+		}
+		export.Permissions = append(export.Permissions, models.RoleExportPermission{
+			Code:           rp.Permission.Code,
+			IsGranted:      rp.IsGranted,
+			Conditions:     rp.Conditions,
+			GrantReason:    rp.GrantReason,
+			EffectiveFrom:  rp.EffectiveFrom,
+			EffectiveUntil: rp.EffectiveUntil,
+		})
+	}
+	sort.Slice(export.Permissions, func(i, j int) bool {
+		return export.Permissions[i].Code < export.Permissions[j].Code
+	})
+
+	for _, access := range moduleAccesses {
+		if access.Module == nil {
+			continue
+		}
+		entry := models.RoleExportModuleAccess{
+			ModuleCode:     access.Module.Code,
+			Permissions:    access.Permissions,
+			IsActive:       access.IsActive,
+			EffectiveFrom:  access.EffectiveFrom,
+			EffectiveUntil: access.EffectiveUntil,
+		}
+		if access.Position != nil {
+			entry.PositionCode = &access.Position.Code
+		}
+		export.ModuleAccess = append(export.ModuleAccess, entry)
+	}
+	sort.Slice(export.ModuleAccess, func(i, j int) bool {
+		return export.ModuleAccess[i].ModuleCode < export.ModuleAccess[j].ModuleCode
+	})
+
+	for _, parent := range parents {
+		if parent.ParentRole == nil {
+			continue
+		}
+		export.ParentRoles = append(export.ParentRoles, models.RoleExportParentRole{
+			Code:               parent.ParentRole.Code,
+			InheritPermissions: parent.InheritPermissions,
+		})
+	}
+	sort.Slice(export.ParentRoles, func(i, j int) bool {
+		return export.ParentRoles[i].Code < export.ParentRoles[j].Code
+	})
+
+	return export, nil
+}
+
+// roleImportRefs holds the ids the codes of an imported role resolve to in this environment
+type roleImportRefs struct {
+	permissions map[string]models.Permission
+	modules     map[string]models.Module
+	positions   map[string]models.Position
+	parents     map[string]models.Role
+	unresolved  []models.RoleImportReference
+}
+
+// ImportRole recreates an exported role, resolving its codes to the ids of this environment.
+// Codes that do not resolve fail the import with ErrRoleImportUnresolved, and are listed in
+// the result; with skipUnresolved the entries using them are left out instead. The role and
+// all of its assignments are created in one transaction. Escalation prevention runs for
+// subjectID; userID is recorded as creator and audit actor.
+func (s *RoleService) ImportRole(doc models.RoleExport, skipUnresolved bool, userID, subjectID string) (*models.RoleImportResult, error) {
+	db := database.Primary(s.db)
+
+	if doc.FormatVersion != models.RoleExportFormatVersion {
+		return nil, fmt.Errorf("versi format export role %d tidak didukung", doc.FormatVersion)
+	}
+
+	// Business rule: Check if code already exists
+	var existing models.Role
+	if err := db.Where("code = ?", doc.Role.Code).First(&existing).Error; err == nil {
+		return nil, errors.New("kode role sudah digunakan")
+	}
+
+	refs, err := s.resolveRoleImportRefs(doc)
+	if err != nil {
+		return nil, err
+	}
+	result := &models.RoleImportResult{Unresolved: refs.unresolved}
+	if len(refs.unresolved) > 0 && !skipUnresolved {
+		return result, ErrRoleImportUnresolved
+	}
+
+	username := s.getUsername(userID)
+	role := models.Role{
+		ID:             uuid.New().String(),
+		Code:           doc.Role.Code,
+		Name:           doc.Role.Name,
+		Description:    doc.Role.Description,
+		HierarchyLevel: doc.Role.HierarchyLevel,
+		IsSystemRole:   doc.Role.IsSystemRole,
+		IsActive:       doc.Role.IsActive,
+		CreatedBy:      &username,
+	}
+
+	// Build the assignments, dropping entries whose references did not resolve and repeats
+	var rolePermissions []models.RolePermission
+	var grantedPermissions []models.Permission
+	seenPermissions := make(map[string]bool)
+	for _, entry := range doc.Permissions {
+		permission, ok := refs.permissions[entry.Code]
+		if !ok || seenPermissions[permission.ID] {
+			continue
+		}
+		seenPermissions[permission.ID] = true
+
+		effectiveFrom := entry.EffectiveFrom
+		if effectiveFrom.IsZero() {
+			effectiveFrom = clock.Now()
+		}
+		rolePermissions = append(rolePermissions, models.RolePermission{
+			ID:             uuid.New().String(),
+			RoleID:         role.ID,
+			PermissionID:   permission.ID,
+			IsGranted:      entry.IsGranted,
+			Conditions:     entry.Conditions,
+			GrantedBy:      &userID,
+			GrantReason:    entry.GrantReason,
+			EffectiveFrom:  effectiveFrom,
+			EffectiveUntil: entry.EffectiveUntil,
+		})
+		if entry.IsGranted {
+			grantedPermissions = append(grantedPermissions, permission)
+		}
+	}
+
+	var moduleAccesses []models.RoleModuleAccess
+	seenModuleAccess := make(map[string]bool)
+	for _, entry := range doc.ModuleAccess {
+		module, ok := refs.modules[entry.ModuleCode]
+		if !ok {
+			continue
+		}
+		var positionID *string
+		if entry.PositionCode != nil {
+			position, ok := refs.positions[*entry.PositionCode]
+			if !ok {
+				continue
+			}
+			positionID = &position.ID
+		}
+		key := module.ID
+		if positionID != nil {
+			key += ":" + *positionID
+		}
+		if seenModuleAccess[key] {
+			continue
+		}
+		seenModuleAccess[key] = true
+
+		// Business rule: Every granted action must be a known PermissionAction
+		permissions, err := normalizeModuleAccessPermissions(entry.Permissions)
+		if err != nil {
+			return nil, fmt.Errorf("module %s: %w", entry.ModuleCode, err)
+		}
+		effectiveFrom := entry.EffectiveFrom
+		if effectiveFrom.IsZero() {
+			effectiveFrom = clock.Now()
+		}
+		moduleAccesses = append(moduleAccesses, models.RoleModuleAccess{
+			ID:             uuid.New().String(),
+			RoleID:         role.ID,
+			ModuleID:       module.ID,
+			PositionID:     positionID,
+			Permissions:    permissions,
+			IsActive:       entry.IsActive,
+			CreatedBy:      &username,
+			EffectiveFrom:  effectiveFrom,
+			EffectiveUntil: entry.EffectiveUntil,
+		})
+	}
+
+	var hierarchy []models.RoleHierarchy
+	var parentRoles []models.Role
+	seenParents := make(map[string]bool)
+	for _, entry := range doc.ParentRoles {
+		parent, ok := refs.parents[entry.Code]
+		if !ok || seenParents[parent.ID] {
+			continue
+		}
+		seenParents[parent.ID] = true
+		hierarchy = append(hierarchy, models.RoleHierarchy{
+			ID:                 uuid.New().String(),
+			RoleID:             role.ID,
+			ParentRoleID:       parent.ID,
+			InheritPermissions: entry.InheritPermissions,
+		})
+		parentRoles = append(parentRoles, parent)
+	}
+
+	// Escalation Prevention: The importer must be able to create this role and grant everything it holds
+	if s.escalationPrevention != nil {
+		if err := s.escalationPrevention.ValidateRoleImport(subjectID, role, parentRoles, grantedPermissions); err != nil {
+			return nil, fmt.Errorf("escalation prevention: %w", err)
+		}
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&role).Error; err != nil {
+			// A concurrent create can pass the check above; the unique index catches it
+			if isUniqueViolation(err) {
+				return errors.New("kode role sudah digunakan")
+			}
+			return fmt.Errorf("gagal membuat role: %w", err)
+		}
+		if len(rolePermissions) > 0 {
+			if err := tx.Create(&rolePermissions).Error; err != nil {
+				return fmt.Errorf("gagal menambahkan permission ke role: %w", err)
+			}
+		}
+		if len(moduleAccesses) > 0 {
+			if err := tx.Create(&moduleAccesses).Error; err != nil {
+				return fmt.Errorf("gagal assign module ke role: %w", err)
+			}
+		}
+		if len(hierarchy) > 0 {
+			if err := tx.Create(&hierarchy).Error; err != nil {
+				return fmt.Errorf("gagal menambahkan parent role: %w", err)
+			}
+		}
+		return clearImportedRoleFlags(tx, role, rolePermissions, moduleAccesses, hierarchy)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(userID, models.AuditActionImport, "role", role.ID, nil, doc)
+
+	result.Role = role.ToResponse()
+	return result, nil
+}
+
+// clearImportedRoleFlags writes the false flags of an imported role. GORM fills a zero bool
+// with the column's default:true on create, which would turn an exported denial, inactive
+// entry or non-inheriting parent into an active one.
+func clearImportedRoleFlags(tx *gorm.DB, role models.Role, rolePermissions []models.RolePermission, moduleAccesses []models.RoleModuleAccess, hierarchy []models.RoleHierarchy) error {
+	if !role.IsActive {
+		if err := tx.Model(&models.Role{}).Where("id = ?", role.ID).Update("is_active", false).Error; err != nil {
+			return fmt.Errorf("gagal membuat role: %w", err)
+		}
+	}
+
+	var deniedIDs, inactiveIDs, nonInheritingIDs []string
+	for _, rp := range rolePermissions {
+		if !rp.IsGranted {
+			deniedIDs = append(deniedIDs, rp.ID)
+		}
+	}
+	for _, access := range moduleAccesses {
+		if !access.IsActive {
+			inactiveIDs = append(inactiveIDs, access.ID)
+		}
+	}
+	for _, h := range hierarchy {
+		if !h.InheritPermissions {
+			nonInheritingIDs = append(nonInheritingIDs, h.ID)
+		}
+	}
+
+	if len(deniedIDs) > 0 {
+		if err := tx.Model(&models.RolePermission{}).Where("id IN ?", deniedIDs).Update("is_granted", false).Error; err != nil {
+			return fmt.Errorf("gagal menambahkan permission ke role: %w", err)
+		}
+	}
+	if len(inactiveIDs) > 0 {
+		if err := tx.Model(&models.RoleModuleAccess{}).Where("id IN ?", inactiveIDs).Update("is_active", false).Error; err != nil {
+			return fmt.Errorf("gagal assign module ke role: %w", err)
+		}
+	}
+	if len(nonInheritingIDs) > 0 {
+		if err := tx.Model(&models.RoleHierarchy{}).Where("id IN ?", nonInheritingIDs).Update("inherit_permissions", false).Error; err != nil {
+			return fmt.Errorf("gagal menambahkan parent role: %w", err)
+		}
+	}
+	return nil
+}
+
+// resolveRoleImportRefs looks up every code an imported role references and lists the ones
+// that do not exist, each once, in document order
+func (s *RoleService) resolveRoleImportRefs(doc models.RoleExport) (*roleImportRefs, error) {
+	db := database.Primary(s.db)
+
+	refs := &roleImportRefs{
+		permissions: make(map[string]models.Permission),
+		modules:     make(map[string]models.Module),
+		positions:   make(map[string]models.Position),
+		parents:     make(map[string]models.Role),
+		unresolved:  []models.RoleImportReference{},
+	}
+
+	var permissionCodes, moduleCodes, positionCodes, parentCodes []string
+	for _, entry := range doc.Permissions {
+		permissionCodes = append(permissionCodes, entry.Code)
+	}
+	for _, entry := range doc.ModuleAccess {
+		moduleCodes = append(moduleCodes, entry.ModuleCode)
+		if entry.PositionCode != nil {
+			positionCodes = append(positionCodes, *entry.PositionCode)
+		}
+	}
+	for _, entry := range doc.ParentRoles {
+		parentCodes = append(parentCodes, entry.Code)
+	}
+
+	if len(permissionCodes) > 0 {
+		var permissions []models.Permission
+		if err := db.Where("code IN ?", permissionCodes).Find(&permissions).Error; err != nil {
+			return nil, fmt.Errorf("gagal mengambil data permission: %w", err)
+		}
+		for _, p := range permissions {
+			refs.permissions[p.Code] = p
+		}
+	}
+	if len(moduleCodes) > 0 {
+		var modules []models.Module
+		if err := db.Where("code IN ?", moduleCodes).Find(&modules).Error; err != nil {
+			return nil, fmt.Errorf("gagal mengambil data module: %w", err)
+		}
+		for _, m := range modules {
+			refs.modules[m.Code] = m
+		}
+	}
+	if len(positionCodes) > 0 {
+		var positions []models.Position
+		if err := db.Where("code IN ?", positionCodes).Find(&positions).Error; err != nil {
+			return nil, fmt.Errorf("gagal mengambil data posisi: %w", err)
+		}
+		for _, p := range positions {
+			refs.positions[p.Code] = p
+		}
+	}
+	if len(parentCodes) > 0 {
+		var parents []models.Role
+		if err := db.Where("code IN ?", parentCodes).Find(&parents).Error; err != nil {
+			return nil, fmt.Errorf("gagal mengambil data role: %w", err)
+		}
+		for _, r := range parents {
+			refs.parents[r.Code] = r
+		}
+	}
+
+	seen := make(map[models.RoleImportReference]bool)
+	report := func(refType, code string, found bool) {
+		ref := models.RoleImportReference{Type: refType, Code: code}
+		if !found && !seen[ref] {
+			seen[ref] = true
+			refs.unresolved = append(refs.unresolved, ref)
+		}
+	}
+	for _, code := range permissionCodes {
+		_, ok := refs.permissions[code]
+		report(roleImportRefPermission, code, ok)
+	}
+	for _, code := range moduleCodes {
+		_, ok := refs.modules[code]
+		report(roleImportRefModule, code, ok)
+	}
+	for _, code := range positionCodes {
+		_, ok := refs.positions[code]
+		report(roleImportRefPosition, code, ok)
+	}
+	for _, code := range parentCodes {
+		_, ok := refs.parents[code]
+		report(roleImportRefParentRole, code, ok)
+	}
+
+	return refs, nil
+}
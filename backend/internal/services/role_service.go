@@ -3,9 +3,11 @@ package services
 import (
 	"errors"
 	"fmt"
+	"log/slog"
 	"strings"
-	"time"
 
+	"backend/internal/clock"
+	"backend/internal/database"
 	"backend/internal/models"
 
 	"github.com/google/uuid"
@@ -17,11 +19,12 @@ type RoleService struct {
 	db                   *gorm.DB
 	escalationPrevention *EscalationPreventionService
 	permissionCache      *PermissionCacheService
+	audit                *AuditService
 }
 
 // NewRoleService creates a new RoleService instance
 func NewRoleService(db *gorm.DB) *RoleService {
-	return &RoleService{db: db}
+	return &RoleService{db: db, audit: NewAuditService(db)}
 }
 
 // NewRoleServiceWithRBAC creates a new RoleService instance with RBAC services
@@ -30,6 +33,7 @@ func NewRoleServiceWithRBAC(db *gorm.DB, escalation *EscalationPreventionService
 		db:                   db,
 		escalationPrevention: escalation,
 		permissionCache:      cache,
+		audit:                NewAuditService(db),
 	}
 }
 
@@ -49,6 +53,7 @@ type RoleListParams struct {
 	HierarchyLevel *int
 	SortBy         string
 	SortOrder      string
+	WithCounts     bool // include effective_user_count per role
 }
 
 // RoleListResult represents the result of listing roles
@@ -83,9 +88,11 @@ func (s *RoleService) getUsername(userID string) string {
 
 // CreateRole creates a new role with validation
 func (s *RoleService) CreateRole(req models.CreateRoleRequest, userID string) (*models.Role, error) {
+	db := database.Primary(s.db)
+
 	// Business rule: Check if code already exists
 	var existing models.Role
-	if err := s.db.Where("code = ?", req.Code).First(&existing).Error; err == nil {
+	if err := db.Where("code = ?", req.Code).First(&existing).Error; err == nil {
 		return nil, errors.New("kode role sudah digunakan")
 	}
 
@@ -111,7 +118,11 @@ func (s *RoleService) CreateRole(req models.CreateRoleRequest, userID string) (*
 	}
 
 	// Persist to database
-	if err := s.db.Create(&role).Error; err != nil {
+	if err := db.Create(&role).Error; err != nil {
+		// A concurrent create can pass the check above; the unique index catches it
+		if isUniqueViolation(err) {
+			return nil, errors.New("kode role sudah digunakan")
+		}
 		return nil, fmt.Errorf("gagal membuat role: %w", err)
 	}
 
@@ -142,42 +153,27 @@ func (s *RoleService) GetRoles(params RoleListParams) (*RoleListResult, error) {
 		query = query.Where("hierarchy_level = ?", *params.HierarchyLevel)
 	}
 
-	// Count total records
-	var total int64
-	if err := query.Count(&total).Error; err != nil {
-		return nil, fmt.Errorf("gagal menghitung total role: %w", err)
-	}
-
-	// Apply sorting with validation to prevent SQL injection
-	if params.SortBy != "" {
-		validSortColumns := map[string]bool{
+	page, err := Paginate[models.Role](query, PageParams{
+		Page:      params.Page,
+		PageSize:  params.PageSize,
+		SortBy:    params.SortBy,
+		SortOrder: params.SortOrder,
+	}, ListQuery{
+		Noun: "role",
+		SortColumns: map[string]bool{
 			"code":            true,
 			"name":            true,
 			"hierarchy_level": true,
 			"created_at":      true,
 			"is_active":       true,
 			"is_system_role":  true,
-		}
-		if validSortColumns[params.SortBy] {
-			direction := "ASC"
-			if strings.ToLower(params.SortOrder) == "desc" {
-				direction = "DESC"
-			}
-			query = query.Order(fmt.Sprintf("%s %s", params.SortBy, direction))
-		}
-	} else {
-		query = query.Order("created_at DESC")
-	}
-
-	// Apply pagination
-	offset := (params.Page - 1) * params.PageSize
-	query = query.Offset(offset).Limit(params.PageSize)
-
-	// Execute query
-	var roles []models.Role
-	if err := query.Find(&roles).Error; err != nil {
-		return nil, fmt.Errorf("gagal mengambil data role: %w", err)
+		},
+		DefaultOrder: "created_at DESC",
+	})
+	if err != nil {
+		return nil, err
 	}
+	roles := page.Data
 
 	// Convert to list response
 	data := make([]*models.RoleListResponse, len(roles))
@@ -185,21 +181,77 @@ func (s *RoleService) GetRoles(params RoleListParams) (*RoleListResult, error) {
 		data[i] = role.ToListResponse()
 	}
 
-	// Calculate total pages
-	totalPages := int(total) / params.PageSize
-	if int(total)%params.PageSize > 0 {
-		totalPages++
+	if params.WithCounts && len(roles) > 0 {
+		roleIDs := make([]string, len(roles))
+		for i, role := range roles {
+			roleIDs[i] = role.ID
+		}
+		counts, err := s.effectiveUserCounts(roleIDs)
+		if err != nil {
+			return nil, err
+		}
+		for i, role := range roles {
+			count := counts[role.ID]
+			data[i].EffectiveUserCount = &count
+		}
 	}
 
 	return &RoleListResult{
 		Data:       data,
-		Total:      total,
-		Page:       params.Page,
-		PageSize:   params.PageSize,
-		TotalPages: totalPages,
+		Total:      page.Total,
+		Page:       page.Page,
+		PageSize:   page.PageSize,
+		TotalPages: page.TotalPages,
 	}, nil
 }
 
+// effectiveUserCounts counts, per role, the distinct users it currently affects: holders of
+// the role itself plus holders of roles inheriting its permissions, in one grouped query
+func (s *RoleService) effectiveUserCounts(roleIDs []string) (map[string]int64, error) {
+	query := `
+		WITH RECURSIVE role_descendants AS (
+			SELECT r.id AS ancestor_id, r.id AS role_id, 0 AS depth
+			FROM public.roles r
+			WHERE r.id IN ?
+
+			UNION
+
+			SELECT rd.ancestor_id, rh.role_id, rd.depth + 1
+			FROM public.role_hierarchy rh
+			INNER JOIN role_descendants rd ON rh.parent_role_id = rd.role_id
+			WHERE rh.inherit_permissions = true
+			AND rd.depth < 10
+		)
+		SELECT rd.ancestor_id AS role_id, COUNT(DISTINCT ur.user_id) AS user_count
+		FROM role_descendants rd
+		INNER JOIN public.user_roles ur ON ur.role_id = rd.role_id
+		WHERE ur.is_active = true
+		AND ur.effective_from <= ?
+		AND (ur.effective_until IS NULL OR ur.effective_until >= ?)
+		GROUP BY rd.ancestor_id
+	`
+
+	var rows []struct {
+		RoleID    string
+		UserCount int64
+	}
+	now := clock.Now()
+	if err := s.db.Clauses(database.Read).Raw(query, roleIDs, now, now).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("gagal menghitung user terdampak role: %w", err)
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.RoleID] = row.UserCount
+	}
+	return counts, nil
+}
+
+// GetAvailableRoleCodes suggests role codes starting with prefix that are not taken yet
+func (s *RoleService) GetAvailableRoleCodes(prefix string, count int) ([]string, error) {
+	return suggestAvailableCodes(s.db, models.Role{}.TableName(), prefix, count)
+}
+
 // GetRoleByID retrieves a single role by ID
 func (s *RoleService) GetRoleByID(id string) (*models.Role, error) {
 	var role models.Role
@@ -233,7 +285,7 @@ func (s *RoleService) GetRoleWithPermissions(id string) (*models.RoleWithPermiss
 
 	// Convert to assigned permission response with assignment_id
 	permissions := make([]models.AssignedPermissionResponse, 0)
-	now := time.Now()
+	now := clock.Now()
 	for _, rp := range rolePermissions {
 		// Check if permission is currently effective
 		if rp.EffectiveFrom.After(now) {
@@ -266,11 +318,16 @@ func (s *RoleService) GetRoleWithPermissions(id string) (*models.RoleWithPermiss
 	return response, nil
 }
 
+// ErrRoleVersionConflict is returned when a role changed after the client read it
+var ErrRoleVersionConflict = errors.New("role telah diubah oleh pengguna lain, muat ulang lalu coba lagi")
+
 // UpdateRole updates an existing role
 func (s *RoleService) UpdateRole(id string, req models.UpdateRoleRequest) (*models.Role, error) {
+	db := database.Primary(s.db)
+
 	// Get existing role
 	var role models.Role
-	if err := s.db.First(&role, "id = ?", id).Error; err != nil {
+	if err := db.First(&role, "id = ?", id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("role tidak ditemukan")
 		}
@@ -285,10 +342,15 @@ func (s *RoleService) UpdateRole(id string, req models.UpdateRoleRequest) (*mode
 		}
 	}
 
+	// Optimistic locking: refuse to overwrite a version the client has not seen
+	if req.Version != nil && *req.Version != role.Version {
+		return nil, ErrRoleVersionConflict
+	}
+
 	// Check if new code already exists (if code is being changed)
 	if req.Code != nil && *req.Code != role.Code {
 		var existing models.Role
-		if err := s.db.Where("code = ? AND id != ?", *req.Code, id).First(&existing).Error; err == nil {
+		if err := db.Where("code = ? AND id != ?", *req.Code, id).First(&existing).Error; err == nil {
 			return nil, errors.New("kode role sudah digunakan")
 		}
 	}
@@ -310,9 +372,22 @@ func (s *RoleService) UpdateRole(id string, req models.UpdateRoleRequest) (*mode
 		role.IsActive = *req.IsActive
 	}
 
-	// Save changes
-	if err := s.db.Save(&role).Error; err != nil {
-		return nil, fmt.Errorf("gagal mengupdate role: %w", err)
+	// Save only if nobody else saved since the read; the version guard makes the
+	// check-and-write atomic
+	readVersion := role.Version
+	role.Version++
+	result := db.Model(&models.Role{}).
+		Where("id = ? AND version = ?", id, readVersion).
+		Select("*").
+		Updates(&role)
+	if result.Error != nil {
+		if isUniqueViolation(result.Error) {
+			return nil, errors.New("kode role sudah digunakan")
+		}
+		return nil, fmt.Errorf("gagal mengupdate role: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrRoleVersionConflict
 	}
 
 	// Invalidate cache for all users with this role
@@ -323,11 +398,15 @@ func (s *RoleService) UpdateRole(id string, req models.UpdateRoleRequest) (*mode
 	return &role, nil
 }
 
-// DeleteRole deletes a role (soft delete by setting is_active to false)
-func (s *RoleService) DeleteRole(id string) error {
+// DeleteRole deletes a role (soft delete by setting is_active to false).
+// A role still assigned to users is only deactivated when endAssignments is set; those
+// assignments are then end-dated in the same transaction. Use RestoreRole to reactivate.
+func (s *RoleService) DeleteRole(id string, endAssignments bool, deletedBy string) error {
+	db := database.Primary(s.db)
+
 	// Get existing role
 	var role models.Role
-	if err := s.db.First(&role, "id = ?", id).Error; err != nil {
+	if err := db.First(&role, "id = ?", id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return errors.New("role tidak ditemukan")
 		}
@@ -339,19 +418,30 @@ func (s *RoleService) DeleteRole(id string) error {
 		return errors.New("role sistem tidak dapat dihapus")
 	}
 
-	// Business rule: Check if role is still assigned to users
-	var userRoleCount int64
-	if err := s.db.Model(&models.UserRole{}).Where("role_id = ?", id).Count(&userRoleCount).Error; err != nil {
+	if !role.IsActive {
+		return errors.New("role sudah tidak aktif")
+	}
+
+	// Business rule: Check if role is still assigned to users. Ended assignments are history
+	// and do not block deletion.
+	now := clock.Now()
+	activeAssignments := func(db *gorm.DB) *gorm.DB {
+		return db.Model(&models.UserRole{}).
+			Where("role_id = ? AND is_active = true", id).
+			Where("(effective_until IS NULL OR effective_until > ?)", now)
+	}
+	var assignments []models.UserRole
+	if err := activeAssignments(db).Find(&assignments).Error; err != nil {
 		return fmt.Errorf("gagal memeriksa assignment role: %w", err)
 	}
 
-	if userRoleCount > 0 {
+	if len(assignments) > 0 && !endAssignments {
 		return errors.New("role masih digunakan oleh user, tidak dapat dihapus")
 	}
 
 	// Business rule: Check if role is a parent in role hierarchy
 	var childRoleCount int64
-	if err := s.db.Model(&models.RoleHierarchy{}).Where("parent_role_id = ?", id).Count(&childRoleCount).Error; err != nil {
+	if err := db.Model(&models.RoleHierarchy{}).Where("parent_role_id = ?", id).Count(&childRoleCount).Error; err != nil {
 		return fmt.Errorf("gagal memeriksa hierarchy role: %w", err)
 	}
 
@@ -359,60 +449,120 @@ func (s *RoleService) DeleteRole(id string) error {
 		return errors.New("role masih memiliki child roles dalam hierarchy, tidak dapat dihapus")
 	}
 
-	// Invalidate cache for all users with this role before deletion
-	if s.permissionCache != nil {
-		s.invalidateCacheForRoleUsers(id)
+	// End-date remaining assignments and soft delete the role together
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if len(assignments) > 0 {
+			if err := activeAssignments(tx).Updates(map[string]interface{}{
+				"is_active":       false,
+				"effective_until": now,
+			}).Error; err != nil {
+				return fmt.Errorf("gagal mengakhiri assignment role: %w", err)
+			}
+		}
+		if err := tx.Model(&role).Updates(map[string]interface{}{"is_active": false, "version": gorm.Expr("version + 1")}).Error; err != nil {
+			return fmt.Errorf("gagal menghapus role: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, ur := range assignments {
+		after := ur
+		after.IsActive = false
+		after.EffectiveUntil = &now
+		s.audit.Record(deletedBy, models.AuditActionRevoke, "user_role", ur.ID, ur, after)
 	}
+	s.audit.Record(deletedBy, models.AuditActionDelete, "role", role.ID, role, nil)
 
-	// Soft delete: set is_active to false
-	if err := s.db.Model(&role).Update("is_active", false).Error; err != nil {
-		return fmt.Errorf("gagal menghapus role: %w", err)
+	// Invalidate cache for the users whose assignments were ended
+	if s.permissionCache != nil {
+		for _, ur := range assignments {
+			s.permissionCache.InvalidateUser(ur.UserID)
+		}
 	}
 
 	return nil
 }
 
-// AssignPermissionToRole assigns a permission to a role
-func (s *RoleService) AssignPermissionToRole(roleID string, req models.AssignPermissionToRoleRequest, userID string) (*models.RolePermission, error) {
-	fmt.Printf("[DEBUG] RoleService.AssignPermissionToRole: roleID=%s, permissionID=%s, userID=%s\n", roleID, req.PermissionID, userID)
+// RestoreRole reactivates a soft-deleted role. Assignments ended by DeleteRole stay ended.
+// subjectID must be allowed to modify the role; restoredBy is audited as the actor.
+func (s *RoleService) RestoreRole(id string, restoredBy, subjectID string) (*models.Role, error) {
+	db := database.Primary(s.db)
+
+	var role models.Role
+	if err := db.First(&role, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("role tidak ditemukan")
+		}
+		return nil, fmt.Errorf("gagal mengambil data role: %w", err)
+	}
+
+	if role.IsActive {
+		return nil, errors.New("role masih aktif")
+	}
+
+	// Escalation Prevention: Validate that the subject can modify this role
+	if s.escalationPrevention != nil {
+		if err := s.escalationPrevention.ValidateRoleModification(subjectID, id); err != nil {
+			return nil, fmt.Errorf("escalation prevention: %w", err)
+		}
+	}
+
+	before := role
+	if err := db.Model(&role).Updates(map[string]interface{}{"is_active": true, "version": gorm.Expr("version + 1")}).Error; err != nil {
+		return nil, fmt.Errorf("gagal memulihkan role: %w", err)
+	}
+	role.Version++ // mirror the SQL increment so the response carries the new version
+	s.audit.Record(restoredBy, models.AuditActionUpdate, "role", role.ID, before, role)
+
+	// Invalidate cache for all users with this role
+	if s.permissionCache != nil {
+		s.invalidateCacheForRoleUsers(id)
+	}
+
+	return &role, nil
+}
+
+// AssignPermissionToRole assigns a permission to a role. Escalation prevention runs for
+// subjectID, the authorizing user; userID is recorded as the actor.
+func (s *RoleService) AssignPermissionToRole(roleID string, req models.AssignPermissionToRoleRequest, userID, subjectID string) (*models.RolePermission, error) {
+	db := database.Primary(s.db)
 
 	// Validate role exists
 	var role models.Role
-	if err := s.db.First(&role, "id = ?", roleID).Error; err != nil {
+	if err := db.First(&role, "id = ?", roleID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			fmt.Printf("[DEBUG] RoleService: role not found\n")
 			return nil, errors.New("role tidak ditemukan")
 		}
 		return nil, fmt.Errorf("gagal mengambil data role: %w", err)
 	}
-	fmt.Printf("[DEBUG] RoleService: role found, name=%s\n", role.Name)
 
 	// Validate permission exists
 	var permission models.Permission
-	if err := s.db.First(&permission, "id = ?", req.PermissionID).Error; err != nil {
+	if err := db.First(&permission, "id = ?", req.PermissionID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			fmt.Printf("[DEBUG] RoleService: permission not found\n")
 			return nil, errors.New("permission tidak ditemukan")
 		}
 		return nil, fmt.Errorf("gagal mengambil data permission: %w", err)
 	}
-	fmt.Printf("[DEBUG] RoleService: permission found, code=%s\n", permission.Code)
 
-	// Escalation Prevention: Validate that userID can grant this permission to the role
-	fmt.Printf("[DEBUG] RoleService: escalationPrevention is nil? %v\n", s.escalationPrevention == nil)
+	// Escalation Prevention: Validate that the subject can grant this permission to the role
 	if s.escalationPrevention != nil {
-		fmt.Printf("[DEBUG] RoleService: calling ValidateRolePermissionAssignment\n")
-		if err := s.escalationPrevention.ValidateRolePermissionAssignment(userID, roleID, req.PermissionID); err != nil {
-			fmt.Printf("[DEBUG] RoleService: escalation prevention error=%v\n", err)
+		if err := s.escalationPrevention.ValidateRolePermissionAssignment(subjectID, roleID, req.PermissionID); err != nil {
+			slog.Debug("role permission assignment blocked by escalation prevention",
+				"role_id", roleID, "permission_id", req.PermissionID, "user_id", subjectID, "error", err)
 			return nil, fmt.Errorf("escalation prevention: %w", err)
 		}
-		fmt.Printf("[DEBUG] RoleService: escalation prevention passed\n")
 	}
 
 	// Check if permission is already assigned
 	var existing models.RolePermission
-	err := s.db.Where("role_id = ? AND permission_id = ?", roleID, req.PermissionID).First(&existing).Error
+	err := db.Where("role_id = ? AND permission_id = ?", roleID, req.PermissionID).First(&existing).Error
 	if err == nil {
+		before := existing
+
 		// Update existing assignment
 		if req.IsGranted != nil {
 			existing.IsGranted = *req.IsGranted
@@ -430,9 +580,10 @@ func (s *RoleService) AssignPermissionToRole(roleID string, req models.AssignPer
 			existing.EffectiveUntil = req.EffectiveUntil
 		}
 
-		if err := s.db.Save(&existing).Error; err != nil {
+		if err := db.Save(&existing).Error; err != nil {
 			return nil, fmt.Errorf("gagal mengupdate permission role: %w", err)
 		}
+		s.audit.Record(userID, models.AuditActionUpdate, "role_permission", existing.ID, before, existing)
 
 		// Invalidate cache for all users with this role
 		if s.permissionCache != nil {
@@ -448,7 +599,7 @@ func (s *RoleService) AssignPermissionToRole(roleID string, req models.AssignPer
 		isGranted = *req.IsGranted
 	}
 
-	effectiveFrom := time.Now()
+	effectiveFrom := clock.Now()
 	if req.EffectiveFrom != nil {
 		effectiveFrom = *req.EffectiveFrom
 	}
@@ -465,9 +616,10 @@ func (s *RoleService) AssignPermissionToRole(roleID string, req models.AssignPer
 		EffectiveUntil: req.EffectiveUntil,
 	}
 
-	if err := s.db.Create(&rolePermission).Error; err != nil {
+	if err := db.Create(&rolePermission).Error; err != nil {
 		return nil, fmt.Errorf("gagal menambahkan permission ke role: %w", err)
 	}
+	s.audit.Record(userID, models.AuditActionGrant, "role_permission", rolePermission.ID, nil, rolePermission)
 
 	// Invalidate cache for all users with this role
 	if s.permissionCache != nil {
@@ -478,10 +630,12 @@ func (s *RoleService) AssignPermissionToRole(roleID string, req models.AssignPer
 }
 
 // RevokePermissionFromRole removes a permission from a role
-func (s *RoleService) RevokePermissionFromRole(roleID, permissionAssignmentID string) error {
+func (s *RoleService) RevokePermissionFromRole(roleID, permissionAssignmentID, revokedBy string) error {
+	db := database.Primary(s.db)
+
 	// Get the role permission assignment
 	var rolePermission models.RolePermission
-	if err := s.db.Where("id = ? AND role_id = ?", permissionAssignmentID, roleID).First(&rolePermission).Error; err != nil {
+	if err := db.Where("id = ? AND role_id = ?", permissionAssignmentID, roleID).First(&rolePermission).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return errors.New("permission assignment tidak ditemukan")
 		}
@@ -489,9 +643,10 @@ func (s *RoleService) RevokePermissionFromRole(roleID, permissionAssignmentID st
 	}
 
 	// Delete the assignment
-	if err := s.db.Delete(&rolePermission).Error; err != nil {
+	if err := db.Delete(&rolePermission).Error; err != nil {
 		return fmt.Errorf("gagal menghapus permission dari role: %w", err)
 	}
+	s.audit.Record(revokedBy, models.AuditActionRevoke, "role_permission", rolePermission.ID, rolePermission, nil)
 
 	// Invalidate cache for all users with this role
 	if s.permissionCache != nil {
@@ -501,11 +656,62 @@ func (s *RoleService) RevokePermissionFromRole(roleID, permissionAssignmentID st
 	return nil
 }
 
+// RevokeAllPermissionsFromRole removes every permission from a role in one transaction and
+// returns how many were revoked. Users holding the role have their cache invalidated once.
+// The role modification check runs for subjectID; revokedBy is audited as the actor.
+func (s *RoleService) RevokeAllPermissionsFromRole(roleID, revokedBy, subjectID string) (int64, error) {
+	db := database.Primary(s.db)
+
+	// Validate role exists
+	var role models.Role
+	if err := db.First(&role, "id = ?", roleID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, errors.New("role tidak ditemukan")
+		}
+		return 0, fmt.Errorf("gagal mengambil data role: %w", err)
+	}
+
+	// Escalation Prevention: Validate that the subject can modify this role
+	if s.escalationPrevention != nil {
+		if err := s.escalationPrevention.ValidateRoleModification(subjectID, roleID); err != nil {
+			return 0, fmt.Errorf("escalation prevention: %w", err)
+		}
+	}
+
+	var revoked []models.RolePermission
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("role_id = ?", roleID).Find(&revoked).Error; err != nil {
+			return fmt.Errorf("gagal mengambil permission role: %w", err)
+		}
+		if len(revoked) == 0 {
+			return nil
+		}
+		if err := tx.Where("role_id = ?", roleID).Delete(&models.RolePermission{}).Error; err != nil {
+			return fmt.Errorf("gagal menghapus permission dari role: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, rolePermission := range revoked {
+		s.audit.Record(revokedBy, models.AuditActionRevoke, "role_permission", rolePermission.ID, rolePermission, nil)
+	}
+
+	// Invalidate cache for all users with this role, once for the whole batch
+	if len(revoked) > 0 && s.permissionCache != nil {
+		s.invalidateCacheForRoleUsers(roleID)
+	}
+
+	return int64(len(revoked)), nil
+}
+
 // invalidateCacheForRoleUsers invalidates permission cache for all users who have a specific role
 func (s *RoleService) invalidateCacheForRoleUsers(roleID string) {
 	// Find all users with this role
 	var userRoles []models.UserRole
-	if err := s.db.Where("role_id = ? AND is_active = true", roleID).Find(&userRoles).Error; err != nil {
+	if err := s.db.Clauses(database.Write).Where("role_id = ? AND is_active = true", roleID).Find(&userRoles).Error; err != nil {
 		return // Silently fail - cache will eventually expire
 	}
 
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"database/sql/driver"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"backend/internal/models"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// TestCreateRoleConcurrentDuplicateCode races creates of one code past the SELECT
+// pre-check: the database accepts the first insert and rejects the rest on the unique
+// index, and every loser must get the friendly message instead of a raw driver error.
+func TestCreateRoleConcurrentDuplicateCode(t *testing.T) {
+	var inserted atomic.Bool
+	db := newFakeDB(t, func(query string, _ []driver.NamedValue) fakeRows {
+		if strings.HasPrefix(query, `INSERT INTO "public"."roles"`) && !inserted.CompareAndSwap(false, true) {
+			return fakeRows{err: &pgconn.PgError{Code: pgUniqueViolation, ConstraintName: "idx_roles_code"}}
+		}
+		return fakeRows{}
+	})
+	s := NewRoleService(db.DB)
+
+	const creates = 8
+	errs := make([]error, creates)
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = s.CreateRole(models.CreateRoleRequest{Code: "KEPSEK", Name: "Kepala Sekolah"}, "user-1")
+		}(i)
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case err.Error() != "kode role sudah digunakan":
+			t.Errorf("duplicate create returned %q, want the friendly duplicate-code message", err)
+		}
+	}
+	if succeeded != 1 {
+		t.Errorf("%d creates succeeded, want exactly 1", succeeded)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"strings"
 
+	"backend/internal/database"
+	"backend/internal/helpers"
 	"backend/internal/models"
 
 	"github.com/google/uuid"
@@ -43,9 +45,11 @@ type SchoolListResult struct {
 
 // CreateSchool creates a new school with validation
 func (s *SchoolService) CreateSchool(req models.CreateSchoolRequest, userID string) (*models.School, error) {
+	db := database.Primary(s.db)
+
 	// Business rule: Check if code already exists
 	var existing models.School
-	if err := s.db.Where("code = ?", req.Code).First(&existing).Error; err == nil {
+	if err := db.Where("code = ?", req.Code).First(&existing).Error; err == nil {
 		return nil, errors.New("kode sekolah sudah digunakan")
 	}
 
@@ -68,7 +72,7 @@ func (s *SchoolService) CreateSchool(req models.CreateSchoolRequest, userID stri
 	}
 
 	// Persist to database
-	if err := s.db.Create(&school).Error; err != nil {
+	if err := db.Create(&school).Error; err != nil {
 		return nil, fmt.Errorf("gagal membuat sekolah: %w", err)
 	}
 
@@ -106,12 +110,8 @@ func (s *SchoolService) GetSchools(params SchoolListParams) (*SchoolListResult,
 			"code": true, "name": true, "address": true,
 			"created_at": true, "is_active": true, "school_level": true,
 		}
-		if validSortColumns[params.SortBy] {
-			direction := "ASC"
-			if strings.ToLower(params.SortOrder) == "desc" {
-				direction = "DESC"
-			}
-			query = query.Order(fmt.Sprintf("%s %s", params.SortBy, direction))
+		if order := helpers.SafeOrder(params.SortBy, params.SortOrder, validSortColumns); order != "" {
+			query = query.Order(order)
 		}
 	}
 
@@ -146,10 +146,11 @@ func (s *SchoolService) GetSchools(params SchoolListParams) (*SchoolListResult,
 	}, nil
 }
 
-// GetSchoolByID retrieves a school by ID
+// GetSchoolByID retrieves a school by ID. It reads the primary since updates act on the row
+// it returns.
 func (s *SchoolService) GetSchoolByID(id string) (*models.School, error) {
 	var school models.School
-	if err := s.db.First(&school, "id = ?", id).Error; err != nil {
+	if err := s.db.Clauses(database.Write).First(&school, "id = ?", id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("sekolah tidak ditemukan")
 		}
@@ -182,6 +183,8 @@ func (s *SchoolService) getUsername(userID string) string {
 
 // UpdateSchool updates a school with validation
 func (s *SchoolService) UpdateSchool(id string, req models.UpdateSchoolRequest, userID string) (*models.School, error) {
+	db := database.Primary(s.db)
+
 	// Find existing school
 	school, err := s.GetSchoolByID(id)
 	if err != nil {
@@ -191,7 +194,7 @@ func (s *SchoolService) UpdateSchool(id string, req models.UpdateSchoolRequest,
 	// Business rule: Check if code is being changed and already in use
 	if req.Code != nil && *req.Code != school.Code {
 		var existing models.School
-		if err := s.db.Where("code = ? AND id != ?", *req.Code, id).First(&existing).Error; err == nil {
+		if err := db.Where("code = ? AND id != ?", *req.Code, id).First(&existing).Error; err == nil {
 			return nil, errors.New("kode sekolah sudah digunakan")
 		}
 	}
@@ -227,7 +230,7 @@ func (s *SchoolService) UpdateSchool(id string, req models.UpdateSchoolRequest,
 	school.ModifiedBy = &username
 
 	// Persist changes
-	if err := s.db.Save(&school).Error; err != nil {
+	if err := db.Save(&school).Error; err != nil {
 		return nil, fmt.Errorf("gagal memperbarui sekolah: %w", err)
 	}
 
@@ -236,6 +239,8 @@ func (s *SchoolService) UpdateSchool(id string, req models.UpdateSchoolRequest,
 
 // DeleteSchool deletes a school with validation
 func (s *SchoolService) DeleteSchool(id string) error {
+	db := database.Primary(s.db)
+
 	// Check if school exists
 	school, err := s.GetSchoolByID(id)
 	if err != nil {
@@ -244,20 +249,20 @@ func (s *SchoolService) DeleteSchool(id string) error {
 
 	// Business rule: Check if school has departments
 	var departmentCount int64
-	s.db.Model(&models.Department{}).Where("school_id = ?", id).Count(&departmentCount)
+	db.Model(&models.Department{}).Where("school_id = ?", id).Count(&departmentCount)
 	if departmentCount > 0 {
 		return errors.New("tidak dapat menghapus sekolah yang memiliki departemen")
 	}
 
 	// Business rule: Check if school has positions
 	var positionCount int64
-	s.db.Model(&models.Position{}).Where("school_id = ?", id).Count(&positionCount)
+	db.Model(&models.Position{}).Where("school_id = ?", id).Count(&positionCount)
 	if positionCount > 0 {
 		return errors.New("tidak dapat menghapus sekolah yang memiliki posisi")
 	}
 
 	// Delete school
-	if err := s.db.Delete(&school).Error; err != nil {
+	if err := db.Delete(&school).Error; err != nil {
 		return fmt.Errorf("gagal menghapus sekolah: %w", err)
 	}
 
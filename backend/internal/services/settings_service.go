@@ -0,0 +1,314 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"backend/internal/database"
+	"backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Setting value types stored in SystemConfiguration.Type
+const (
+	SettingTypeBoolean = "boolean"
+	SettingTypeInteger = "integer"
+	SettingTypeString  = "string"
+)
+
+// Runtime settings keys. Features read these through SettingsService instead of env vars so
+// operators can flip them without a redeploy.
+const (
+	SettingAuthRequire2FA               = "auth.require_2fa"
+	SettingAuthRequireEmailVerification = "auth.require_email_verification"
+	SettingAuthPwnedPasswordCheck       = "auth.pwned_password_check"
+	SettingMaintenanceEnabled           = "maintenance.enabled"
+	SettingMaintenanceMessage           = "maintenance.message"
+	SettingMaintenanceEstimatedEnd      = "maintenance.estimated_end"
+)
+
+// DefaultSettingsCacheTTL is how long settings are served from memory before being reloaded,
+// bounding how long other instances take to see a change
+const DefaultSettingsCacheTTL = 30 * time.Second
+
+// SettingDefinition describes a known runtime setting and its default value
+type SettingDefinition struct {
+	Key         string
+	Type        string
+	Category    string
+	Description string
+	Default     interface{}
+	// Validate optionally checks a value beyond its type
+	Validate func(value json.RawMessage) error
+}
+
+// DefaultSettings are the settings seeded on startup; only these keys can be changed via the API
+var DefaultSettings = []SettingDefinition{
+	{
+		Key:         SettingAuthRequire2FA,
+		Type:        SettingTypeBoolean,
+		Category:    "auth",
+		Description: "Wajibkan autentikasi dua faktor untuk semua pengguna",
+		Default:     false,
+	},
+	{
+		Key:         SettingAuthRequireEmailVerification,
+		Type:        SettingTypeBoolean,
+		Category:    "auth",
+		Description: "Wajibkan verifikasi email sebelum pengguna dapat login",
+		Default:     false,
+	},
+	{
+		Key:         SettingAuthPwnedPasswordCheck,
+		Type:        SettingTypeBoolean,
+		Category:    "auth",
+		Description: "Tolak password yang pernah bocor saat membuat atau mengganti password",
+		Default:     false,
+	},
+	{
+		Key:         SettingMaintenanceEnabled,
+		Type:        SettingTypeBoolean,
+		Category:    "maintenance",
+		Description: "Mode pemeliharaan: semua permintaan selain admin dijawab 503",
+		Default:     false,
+	},
+	{
+		Key:         SettingMaintenanceMessage,
+		Type:        SettingTypeString,
+		Category:    "maintenance",
+		Description: "Pesan yang ditampilkan kepada pengguna selama pemeliharaan; kosong memakai pesan bawaan",
+		Default:     "",
+	},
+	{
+		Key:         SettingMaintenanceEstimatedEnd,
+		Type:        SettingTypeString,
+		Category:    "maintenance",
+		Description: "Perkiraan waktu selesai pemeliharaan (RFC3339), atau kosong bila belum diketahui",
+		Default:     "",
+		Validate:    validateOptionalTimestamp,
+	},
+}
+
+// SettingsService reads and updates runtime settings stored as system configurations.
+// Reads are served from an in-memory snapshot that is reloaded after ttl or on update.
+type SettingsService struct {
+	db          *gorm.DB
+	audit       *AuditService
+	ttl         time.Duration
+	definitions map[string]SettingDefinition
+
+	mu       sync.RWMutex
+	values   map[string]datatypes.JSON
+	loadedAt time.Time
+}
+
+// NewSettingsService creates a new SettingsService instance
+func NewSettingsService(db *gorm.DB, ttl time.Duration) *SettingsService {
+	definitions := make(map[string]SettingDefinition, len(DefaultSettings))
+	for _, def := range DefaultSettings {
+		definitions[def.Key] = def
+	}
+	return &SettingsService{db: db, audit: NewAuditService(db), ttl: ttl, definitions: definitions}
+}
+
+// SeedDefaults inserts every known setting that does not exist yet, leaving existing values alone
+func (s *SettingsService) SeedDefaults() error {
+	for _, def := range DefaultSettings {
+		value, err := json.Marshal(def.Default)
+		if err != nil {
+			return fmt.Errorf("gagal menyiapkan pengaturan %s: %w", def.Key, err)
+		}
+
+		description := def.Description
+		setting := models.SystemConfiguration{
+			ID:          uuid.New().String(),
+			Key:         def.Key,
+			Value:       datatypes.JSON(value),
+			Type:        def.Type,
+			Category:    def.Category,
+			Description: &description,
+		}
+		if err := s.db.Clauses(database.Write).Where("key = ?", def.Key).FirstOrCreate(&setting).Error; err != nil {
+			return fmt.Errorf("gagal menyimpan pengaturan %s: %w", def.Key, err)
+		}
+	}
+
+	s.invalidate()
+	return nil
+}
+
+// GetSettings returns the known settings ordered by key
+func (s *SettingsService) GetSettings() ([]models.SystemConfiguration, error) {
+	keys := make([]string, 0, len(s.definitions))
+	for key := range s.definitions {
+		keys = append(keys, key)
+	}
+
+	var settings []models.SystemConfiguration
+	if err := s.db.Where("key IN ?", keys).Order("key ASC").Find(&settings).Error; err != nil {
+		return nil, fmt.Errorf("gagal mengambil pengaturan: %w", err)
+	}
+	return settings, nil
+}
+
+// UpdateSettings sets the given settings in one transaction. Every key must be a known setting
+// and every value must match its type; nothing is saved otherwise.
+func (s *SettingsService) UpdateSettings(values map[string]json.RawMessage, updatedBy string) ([]models.SystemConfiguration, error) {
+	keys := make([]string, 0, len(values))
+	for key, value := range values {
+		def, ok := s.definitions[key]
+		if !ok {
+			return nil, fmt.Errorf("pengaturan %s tidak dikenal", key)
+		}
+		if !validSettingValue(def.Type, value) {
+			return nil, fmt.Errorf("nilai pengaturan %s harus bertipe %s", key, def.Type)
+		}
+		if def.Validate != nil {
+			if err := def.Validate(value); err != nil {
+				return nil, fmt.Errorf("nilai pengaturan %s tidak valid: %w", key, err)
+			}
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var before, after []models.SystemConfiguration
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		for _, key := range keys {
+			var setting models.SystemConfiguration
+			if err := tx.Where("key = ?", key).First(&setting).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return fmt.Errorf("pengaturan %s belum tersedia", key)
+				}
+				return fmt.Errorf("gagal mengambil pengaturan %s: %w", key, err)
+			}
+
+			before = append(before, setting)
+			setting.Value = datatypes.JSON(values[key])
+			setting.UpdatedBy = &updatedBy
+			if err := tx.Model(&setting).Updates(map[string]interface{}{
+				"value":      setting.Value,
+				"updated_by": updatedBy,
+			}).Error; err != nil {
+				return fmt.Errorf("gagal mengupdate pengaturan %s: %w", key, err)
+			}
+			after = append(after, setting)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.invalidate()
+	for i := range after {
+		s.audit.Record(updatedBy, models.AuditActionUpdate, "system_setting", after[i].ID, before[i], after[i])
+	}
+
+	return s.GetSettings()
+}
+
+// GetBool returns a boolean setting, or its default when it is missing or not a boolean
+func (s *SettingsService) GetBool(key string) bool {
+	var value bool
+	if !s.decode(key, &value) {
+		value, _ = s.definitions[key].Default.(bool)
+	}
+	return value
+}
+
+// GetInt returns an integer setting, or its default when it is missing or not an integer
+func (s *SettingsService) GetInt(key string) int {
+	var value int
+	if !s.decode(key, &value) {
+		value, _ = s.definitions[key].Default.(int)
+	}
+	return value
+}
+
+// GetString returns a string setting, or its default when it is missing or not a string
+func (s *SettingsService) GetString(key string) string {
+	var value string
+	if !s.decode(key, &value) {
+		value, _ = s.definitions[key].Default.(string)
+	}
+	return value
+}
+
+// decode unmarshals the cached value of key into target, reporting whether it succeeded
+func (s *SettingsService) decode(key string, target interface{}) bool {
+	raw, ok := s.snapshot()[key]
+	if !ok {
+		return false
+	}
+	return json.Unmarshal(raw, target) == nil
+}
+
+// snapshot returns the cached settings, reloading them once the cache has expired. A failed
+// reload keeps serving the previous values so a database hiccup does not flip every flag.
+func (s *SettingsService) snapshot() map[string]datatypes.JSON {
+	s.mu.RLock()
+	values, fresh := s.values, !s.loadedAt.IsZero() && time.Since(s.loadedAt) < s.ttl
+	s.mu.RUnlock()
+	if fresh {
+		return values
+	}
+
+	settings, err := s.GetSettings()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err == nil {
+		s.values = make(map[string]datatypes.JSON, len(settings))
+		for _, setting := range settings {
+			s.values[setting.Key] = setting.Value
+		}
+	}
+	s.loadedAt = time.Now()
+	return s.values
+}
+
+// invalidate forces the next read to reload settings from the database
+func (s *SettingsService) invalidate() {
+	s.mu.Lock()
+	s.loadedAt = time.Time{}
+	s.mu.Unlock()
+}
+
+// validSettingValue reports whether a raw JSON value matches a setting type
+func validSettingValue(settingType string, value json.RawMessage) bool {
+	switch settingType {
+	case SettingTypeBoolean:
+		var v bool
+		return json.Unmarshal(value, &v) == nil
+	case SettingTypeInteger:
+		var v int
+		return json.Unmarshal(value, &v) == nil
+	case SettingTypeString:
+		var v string
+		return json.Unmarshal(value, &v) == nil
+	}
+	return false
+}
+
+// validateOptionalTimestamp accepts an empty string or an RFC3339 timestamp
+func validateOptionalTimestamp(value json.RawMessage) error {
+	var v string
+	if err := json.Unmarshal(value, &v); err != nil {
+		return err
+	}
+	if v == "" {
+		return nil
+	}
+	if _, err := time.Parse(time.RFC3339, v); err != nil {
+		return errors.New("harus berformat RFC3339")
+	}
+	return nil
+}
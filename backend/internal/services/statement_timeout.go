@@ -0,0 +1,10 @@
+package services
+
+import "gorm.io/gorm"
+
+// liftStatementTimeout disables the connection's statement_timeout until tx ends. Streaming
+// exports, which are exempt from the request timeout, call it first; every other query keeps
+// the default so one that outlives its request is still cancelled.
+func liftStatementTimeout(tx *gorm.DB) error {
+	return tx.Exec("SET LOCAL statement_timeout = 0").Error
+}
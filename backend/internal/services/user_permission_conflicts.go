@@ -0,0 +1,132 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"backend/internal/clock"
+	"backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Direct user permissions are resolved by priority (lower number first). Two rows that match
+// the same resource and action with equal priority but opposite grants are ambiguous; the
+// resolver settles the tie by letting the explicit deny win (see precedesDirectPermission),
+// but such pairs are rejected on assignment and reported by GetUserPermissionConflicts.
+
+// precedesDirectPermission reports whether a direct permission with priority pa and grant ga is
+// evaluated before one with priority pb and grant gb. On equal priority a deny comes first, so
+// the outcome does not depend on row order.
+func precedesDirectPermission(pa int, ga bool, pb int, gb bool) bool {
+	if pa != pb {
+		return pa < pb
+	}
+	return !ga && gb
+}
+
+// userPermissionsConflict reports whether a and b match the same resource and action with equal
+// priority and opposite grants during an overlapping period. Both must have Permission loaded.
+func userPermissionsConflict(a, b *models.UserPermission) bool {
+	if a.Permission == nil || b.Permission == nil {
+		return false
+	}
+	if a.IsGranted == b.IsGranted || a.Priority != b.Priority {
+		return false
+	}
+	if a.Permission.Resource != b.Permission.Resource || a.Permission.Action != b.Permission.Action {
+		return false
+	}
+	return effectivePeriodsOverlap(a.EffectiveFrom, a.EffectiveUntil, b.EffectiveFrom, b.EffectiveUntil)
+}
+
+// effectivePeriodsOverlap reports whether two effective periods share a moment.
+// A nil end means the period is open-ended.
+func effectivePeriodsOverlap(fromA time.Time, untilA *time.Time, fromB time.Time, untilB *time.Time) bool {
+	if untilB != nil && fromA.After(*untilB) {
+		return false
+	}
+	if untilA != nil && fromB.After(*untilA) {
+		return false
+	}
+	return true
+}
+
+// findConflictingUserPermission returns another direct permission of the candidate's user that
+// would conflict with the candidate, or nil. candidate.Permission must be set.
+func (s *UserService) findConflictingUserPermission(candidate *models.UserPermission) (*models.UserPermission, error) {
+	var others []models.UserPermission
+	if err := s.db.Preload("Permission").
+		Where("user_id = ? AND id <> ?", candidate.UserID, candidate.ID).
+		Where("is_granted <> ? AND priority = ?", candidate.IsGranted, candidate.Priority).
+		Find(&others).Error; err != nil {
+		return nil, fmt.Errorf("gagal memeriksa konflik permission: %w", err)
+	}
+
+	for i := range others {
+		if userPermissionsConflict(candidate, &others[i]) {
+			return &others[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// GetUserPermissionConflicts reports every pair of the user's current or future direct
+// permissions that match the same resource and action with equal priority but opposite grants
+func (s *UserService) GetUserPermissionConflicts(userID string) ([]models.UserPermissionConflict, error) {
+	// Check if user exists
+	var user models.User
+	if err := s.db.First(&user, "id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("pengguna tidak ditemukan")
+		}
+		return nil, fmt.Errorf("gagal mengambil data pengguna: %w", err)
+	}
+
+	var userPermissions []models.UserPermission
+	if err := s.db.Preload("Permission").
+		Where("user_id = ?", userID).
+		Where("(effective_until IS NULL OR effective_until >= ?)", clock.Now()).
+		Order("priority ASC, created_at ASC").
+		Find(&userPermissions).Error; err != nil {
+		return nil, fmt.Errorf("gagal mengambil permissions pengguna: %w", err)
+	}
+
+	conflicts := []models.UserPermissionConflict{}
+	for i := range userPermissions {
+		for j := i + 1; j < len(userPermissions); j++ {
+			a, b := &userPermissions[i], &userPermissions[j]
+			if !userPermissionsConflict(a, b) {
+				continue
+			}
+			grant, deny := a, b
+			if !a.IsGranted {
+				grant, deny = b, a
+			}
+			conflicts = append(conflicts, models.UserPermissionConflict{
+				Resource: a.Permission.Resource,
+				Action:   a.Permission.Action,
+				Priority: a.Priority,
+				Grant:    grant.ToResponse(),
+				Deny:     deny.ToResponse(),
+			})
+		}
+	}
+
+	return conflicts, nil
+}
+
+// rejectConflictingUserPermission returns an error naming the existing permission that the
+// candidate would conflict with, or nil when there is none
+func (s *UserService) rejectConflictingUserPermission(candidate *models.UserPermission) error {
+	conflict, err := s.findConflictingUserPermission(candidate)
+	if err != nil {
+		return err
+	}
+	if conflict != nil {
+		return fmt.Errorf("permission bertentangan dengan %s (prioritas %d sama, is_granted berlawanan); ubah prioritas salah satunya",
+			conflict.Permission.Code, candidate.Priority)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+)
+
+func day(d int) time.Time {
+	return time.Date(2026, 1, d, 0, 0, 0, 0, time.UTC)
+}
+
+func dayPtr(d int) *time.Time {
+	t := day(d)
+	return &t
+}
+
+func TestPeriodsOverlap(t *testing.T) {
+	tests := []struct {
+		name         string
+		aStart       time.Time
+		aEnd         *time.Time
+		bStart       time.Time
+		bEnd         *time.Time
+		wantOverlaps bool
+	}{
+		// Adjacent: one period ends exactly when the other starts
+		{"adjacent, b after a", day(1), dayPtr(10), day(10), dayPtr(20), false},
+		{"adjacent, b before a", day(10), dayPtr(20), day(1), dayPtr(10), false},
+		{"adjacent to an open-ended period", day(10), nil, day(1), dayPtr(10), false},
+
+		// Overlapping
+		{"b starts inside a", day(1), dayPtr(10), day(5), dayPtr(20), true},
+		{"b ends inside a", day(5), dayPtr(20), day(1), dayPtr(10), true},
+		{"b inside a", day(1), dayPtr(20), day(5), dayPtr(10), true},
+		{"identical periods", day(1), dayPtr(10), day(1), dayPtr(10), true},
+		{"one nanosecond of overlap", day(1), dayPtr(10), day(10).Add(-time.Nanosecond), dayPtr(20), true},
+		{"open-ended a, b later", day(1), nil, day(100), dayPtr(120), true},
+		{"open-ended b, a later", day(100), dayPtr(120), day(1), nil, true},
+		{"both open-ended", day(1), nil, day(100), nil, true},
+
+		// Disjoint
+		{"b well after a", day(1), dayPtr(10), day(15), dayPtr(20), false},
+		{"b well before a", day(15), dayPtr(20), day(1), dayPtr(10), false},
+		{"open-ended b after a ended", day(1), dayPtr(10), day(15), nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := periodsOverlap(tt.aStart, tt.aEnd, tt.bStart, tt.bEnd); got != tt.wantOverlaps {
+				t.Errorf("periodsOverlap = %v, want %v", got, tt.wantOverlaps)
+			}
+			// The relation is symmetric
+			if got := periodsOverlap(tt.bStart, tt.bEnd, tt.aStart, tt.aEnd); got != tt.wantOverlaps {
+				t.Errorf("periodsOverlap with the periods swapped = %v, want %v", got, tt.wantOverlaps)
+			}
+		})
+	}
+}
+
+// TestPositionAssignmentOverlaps checks a new assignment against a user who held the
+// position from 1 to 10 January and holds it again, open-ended, from 20 January
+func TestPositionAssignmentOverlaps(t *testing.T) {
+	db := newFakeDB(t, func(query string, _ []driver.NamedValue) fakeRows {
+		if !fromTable(query, "user_positions") {
+			return fakeRows{}
+		}
+		return fakeRows{
+			columns: []string{"start_date", "end_date"},
+			values:  [][]driver.Value{{day(1), day(10)}, {day(20), nil}},
+		}
+	})
+
+	tests := []struct {
+		name  string
+		start time.Time
+		end   *time.Time
+		want  bool
+	}{
+		{"gap between the assignments", day(10), dayPtr(20), false},
+		{"overlaps the first assignment", day(5), dayPtr(15), true},
+		{"overlaps the open-ended assignment", day(15), dayPtr(25), true},
+		{"before both", time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC), dayPtr(1), false},
+		{"open-ended from the gap", day(12), nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := positionAssignmentOverlaps(db.DB, "user-1", "pos-1", tt.start, tt.end)
+			if err != nil {
+				t.Fatalf("positionAssignmentOverlaps: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("positionAssignmentOverlaps = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestDeactivateUserAudit checks that deactivating a user records the account change and
+// every role and position it ends, each under the admin who deactivated the user
+func TestDeactivateUserAudit(t *testing.T) {
+	db := newFakeDB(t, func(query string, _ []driver.NamedValue) fakeRows {
+		if !strings.HasPrefix(query, "SELECT") {
+			return fakeRows{}
+		}
+		switch {
+		case fromTable(query, "users"):
+			return fakeRows{columns: []string{"id", "email", "is_active"}, values: [][]driver.Value{{"guru", "guru@example.com", true}}}
+		case fromTable(query, "user_roles"):
+			return fakeRows{columns: []string{"id", "user_id", "role_id", "is_active"}, values: [][]driver.Value{{"ur-1", "guru", "role-guru", true}}}
+		case fromTable(query, "user_positions"):
+			return fakeRows{
+				columns: []string{"id", "user_id", "position_id", "is_active"},
+				values:  [][]driver.Value{{"up-1", "guru", "pos-guru", true}, {"up-2", "guru", "pos-wali", true}},
+			}
+		}
+		return fakeRows{}
+	})
+	s := NewUserService(db.DB)
+
+	if _, err := s.DeactivateUser("guru", "admin"); err != nil {
+		t.Fatalf("DeactivateUser: %v", err)
+	}
+
+	// One entry for the account, one for the role and one per position
+	if got := db.count(`INSERT INTO "public"."audit_logs"`); got != 4 {
+		t.Errorf("recorded %d audit entries, want 4", got)
+	}
+}
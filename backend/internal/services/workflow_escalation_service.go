@@ -0,0 +1,311 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"backend/internal/email"
+	"backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DefaultEscalationInterval is how often pending steps are checked against their SLA timeout
+const DefaultEscalationInterval = 15 * time.Minute
+
+// WorkflowEscalationService handles pending workflow steps that exceed their SLA timeout
+type WorkflowEscalationService struct {
+	db              *gorm.DB
+	instanceService *WorkflowInstanceService
+	emailSender     *email.EmailSender
+	periodicTask
+}
+
+// NewWorkflowEscalationService creates a new WorkflowEscalationService instance
+func NewWorkflowEscalationService(db *gorm.DB, instanceService *WorkflowInstanceService) *WorkflowEscalationService {
+	return &WorkflowEscalationService{
+		db:              db,
+		instanceService: instanceService,
+		emailSender:     email.NewEmailSender(),
+	}
+}
+
+// escalationNotice describes the email sent after a timed out step has been handled
+type escalationNotice struct {
+	recipients   []string
+	workflowType string
+	stepName     string
+	note         string
+}
+
+// Start runs the timeout scan periodically in a background goroutine until Stop is called.
+// Each run also skips optional steps whose grace period has passed.
+func (s *WorkflowEscalationService) Start(interval time.Duration) {
+	s.start(interval, func() {
+		now := time.Now()
+		if _, err := s.instanceService.AdvanceOverdueOptionalSteps(now); err != nil {
+			slog.Warn("optional workflow step scan failed", "error", err)
+		}
+		if _, err := s.ProcessTimeouts(now); err != nil {
+			slog.Warn("workflow escalation scan failed", "error", err)
+		}
+	})
+}
+
+// ProcessTimeouts applies the timeout policy of every pending step whose timeout has passed.
+// It returns the number of steps that were handled.
+func (s *WorkflowEscalationService) ProcessTimeouts(now time.Time) (int, error) {
+	var stepIDs []string
+	if err := s.db.Model(&models.WorkflowInstanceStep{}).
+		Joins("JOIN public.workflow_instances wi ON wi.id = workflow_instance_steps.workflow_instance_id").
+		Where("wi.status = ?", models.WorkflowInstanceStatusPending).
+		Where("workflow_instance_steps.status = ?", models.WorkflowStepStatusPending).
+		Where("workflow_instance_steps.timeout_hours IS NOT NULL").
+		Where("workflow_instance_steps.activated_at + workflow_instance_steps.timeout_hours * INTERVAL '1 hour' <= ?", now).
+		Pluck("workflow_instance_steps.id", &stepIDs).Error; err != nil {
+		return 0, fmt.Errorf("gagal mengambil step yang melewati batas waktu: %w", err)
+	}
+
+	handled := 0
+	for _, stepID := range stepIDs {
+		notice, err := s.handleTimeout(stepID, now)
+		if err != nil {
+			slog.Warn("failed to handle timeout for workflow step", "step_id", stepID, "error", err)
+			continue
+		}
+		if notice == nil {
+			continue
+		}
+
+		handled++
+		s.notify(notice)
+	}
+
+	return handled, nil
+}
+
+// handleTimeout applies the step's timeout action inside a transaction that locks its instance.
+// It returns nil when the step was already acted on by the time the lock was acquired.
+func (s *WorkflowEscalationService) handleTimeout(stepID string, now time.Time) (*escalationNotice, error) {
+	var notice *escalationNotice
+	var instanceID, workflowType string
+	var activated []string
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var target models.WorkflowInstanceStep
+		if err := tx.First(&target, "id = ?", stepID).Error; err != nil {
+			return fmt.Errorf("gagal mengambil step workflow instance: %w", err)
+		}
+
+		instance, steps, err := s.instanceService.lockInstance(tx, target.WorkflowInstanceID)
+		if err != nil {
+			return err
+		}
+
+		idx := -1
+		for i := range steps {
+			if steps[i].ID == stepID {
+				idx = i
+				break
+			}
+		}
+
+		// Re-check under lock: an approver may have acted since the scan
+		if idx == -1 || instance.IsComplete() || !isStepTimedOut(&steps[idx], now) {
+			return nil
+		}
+
+		step := &steps[idx]
+		stepName := step.ApproverPositionID
+		if step.StepName != nil {
+			stepName = *step.StepName
+		}
+
+		switch step.TimeoutAction {
+		case models.TimeoutActionAutoApprove:
+			note := fmt.Sprintf("Disetujui otomatis karena melewati batas waktu %d jam", *step.TimeoutHours)
+			step.Status = models.WorkflowStepStatusApproved
+			step.ActedAt = &now
+			step.Comment = &note
+			if err := tx.Omit(clause.Associations).Save(step).Error; err != nil {
+				return fmt.Errorf("gagal menyimpan persetujuan otomatis: %w", err)
+			}
+			if err := s.recordHistory(tx, step.ID, models.WorkflowStepHistoryAutoApproved, nil, nil, note); err != nil {
+				return err
+			}
+			if err := s.instanceService.advanceCurrentGroup(tx, instance, steps, now); err != nil {
+				return err
+			}
+			instanceID, workflowType = instance.ID, instance.WorkflowType
+			activated = activatedApproverPositions(steps, now)
+			notice = s.initiatorNotice(tx, instance, stepName, note)
+
+		case models.TimeoutActionAutoReject:
+			note := fmt.Sprintf("Ditolak otomatis karena melewati batas waktu %d jam", *step.TimeoutHours)
+			if err := s.recordHistory(tx, step.ID, models.WorkflowStepHistoryAutoRejected, nil, nil, note); err != nil {
+				return err
+			}
+			if err := s.instanceService.rejectStep(tx, instance, steps, idx, note, now); err != nil {
+				return err
+			}
+			notice = s.initiatorNotice(tx, instance, stepName, note)
+
+		default:
+			fromPositionID := step.ApproverPositionID
+			parent, err := s.findParentPosition(tx, fromPositionID)
+			if err != nil {
+				return err
+			}
+
+			// Restart the timer either way so the step is not re-processed on every scan
+			step.ActivatedAt = &now
+
+			if parent == nil {
+				note := "Eskalasi gagal: posisi atasan tidak ditemukan"
+				if err := tx.Omit(clause.Associations).Save(step).Error; err != nil {
+					return fmt.Errorf("gagal memperbarui step workflow instance: %w", err)
+				}
+				if err := s.recordHistory(tx, step.ID, models.WorkflowStepHistoryEscalationFailed, &fromPositionID, nil, note); err != nil {
+					return err
+				}
+				notice = s.initiatorNotice(tx, instance, stepName, note)
+				return nil
+			}
+
+			note := fmt.Sprintf("Dieskalasi ke posisi %s karena melewati batas waktu %d jam", parent.Name, *step.TimeoutHours)
+			step.ApproverPositionID = parent.ID
+			step.EscalationCount++
+			if err := tx.Omit(clause.Associations).Save(step).Error; err != nil {
+				return fmt.Errorf("gagal memperbarui step workflow instance: %w", err)
+			}
+			if err := s.recordHistory(tx, step.ID, models.WorkflowStepHistoryEscalated, &fromPositionID, &parent.ID, note); err != nil {
+				return err
+			}
+
+			recipients, err := positionHolderEmails(tx, []string{parent.ID}, now)
+			if err != nil {
+				return err
+			}
+			notice = &escalationNotice{recipients: recipients, workflowType: instance.WorkflowType, stepName: stepName, note: note}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Auto-approval may have moved the instance to its next group
+	s.instanceService.notifyPendingApprovers(slog.Default(), instanceID, workflowType, activated)
+
+	return notice, nil
+}
+
+// findParentPosition resolves the position a timed out step escalates to:
+// a higher-ranked position in the same department, then the head of the parent
+// department, then a higher-ranked position in the same school.
+// It returns nil when no such position exists.
+func (s *WorkflowEscalationService) findParentPosition(tx *gorm.DB, positionID string) (*models.Position, error) {
+	var position models.Position
+	if err := tx.First(&position, "id = ?", positionID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("posisi penyetuju tidak ditemukan")
+		}
+		return nil, fmt.Errorf("gagal mengambil data posisi: %w", err)
+	}
+
+	var candidates []models.Position
+
+	if position.DepartmentID != nil {
+		if err := tx.Where("department_id = ? AND is_active = ? AND hierarchy_level < ?", *position.DepartmentID, true, position.HierarchyLevel).
+			Order("hierarchy_level DESC").
+			Limit(1).
+			Find(&candidates).Error; err != nil {
+			return nil, fmt.Errorf("gagal mencari posisi atasan: %w", err)
+		}
+		if len(candidates) > 0 {
+			return &candidates[0], nil
+		}
+
+		var department models.Department
+		if err := tx.First(&department, "id = ?", *position.DepartmentID).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("gagal mengambil data departemen: %w", err)
+		}
+		if department.ParentID != nil {
+			if err := tx.Where("department_id = ? AND is_active = ?", *department.ParentID, true).
+				Order("hierarchy_level ASC").
+				Limit(1).
+				Find(&candidates).Error; err != nil {
+				return nil, fmt.Errorf("gagal mencari posisi atasan: %w", err)
+			}
+			if len(candidates) > 0 {
+				return &candidates[0], nil
+			}
+		}
+	}
+
+	if position.SchoolID != nil {
+		if err := tx.Where("school_id = ? AND is_active = ? AND hierarchy_level < ?", *position.SchoolID, true, position.HierarchyLevel).
+			Order("hierarchy_level DESC").
+			Limit(1).
+			Find(&candidates).Error; err != nil {
+			return nil, fmt.Errorf("gagal mencari posisi atasan: %w", err)
+		}
+		if len(candidates) > 0 {
+			return &candidates[0], nil
+		}
+	}
+
+	return nil, nil
+}
+
+// initiatorNotice builds a notice addressed to the user who started the instance
+func (s *WorkflowEscalationService) initiatorNotice(tx *gorm.DB, instance *models.WorkflowInstance, stepName, note string) *escalationNotice {
+	notice := &escalationNotice{workflowType: instance.WorkflowType, stepName: stepName, note: note}
+
+	var initiator models.User
+	if err := tx.First(&initiator, "id = ?", instance.InitiatorUserID).Error; err == nil {
+		notice.recipients = []string{initiator.Email}
+	}
+
+	return notice
+}
+
+// recordHistory appends an entry to the step's history
+func (s *WorkflowEscalationService) recordHistory(tx *gorm.DB, stepID, action string, fromPositionID, toPositionID *string, note string) error {
+	history := models.WorkflowInstanceStepHistory{
+		ID:                     uuid.New().String(),
+		WorkflowInstanceStepID: stepID,
+		Action:                 action,
+		FromPositionID:         fromPositionID,
+		ToPositionID:           toPositionID,
+		Note:                   &note,
+	}
+
+	if err := tx.Create(&history).Error; err != nil {
+		return fmt.Errorf("gagal mencatat riwayat step: %w", err)
+	}
+
+	return nil
+}
+
+// notify sends the escalation email to every recipient, logging failures
+func (s *WorkflowEscalationService) notify(notice *escalationNotice) {
+	for _, recipient := range notice.recipients {
+		if err := s.emailSender.SendWorkflowEscalationEmail(recipient, notice.workflowType, notice.stepName, notice.note); err != nil {
+			slog.Warn("failed to send workflow escalation email", "recipient", recipient, "error", err)
+		}
+	}
+}
+
+// isStepTimedOut checks whether a pending step has been waiting longer than its timeout
+func isStepTimedOut(step *models.WorkflowInstanceStep, now time.Time) bool {
+	if step.Status != models.WorkflowStepStatusPending || step.TimeoutHours == nil || step.ActivatedAt == nil {
+		return false
+	}
+	return !now.Before(step.ActivatedAt.Add(time.Duration(*step.TimeoutHours) * time.Hour))
+}
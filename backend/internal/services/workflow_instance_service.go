@@ -0,0 +1,630 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"backend/internal/clock"
+	"backend/internal/database"
+	"backend/internal/email"
+	"backend/internal/logger"
+	"backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DefaultOptionalStepGracePeriod is how long an optional step waits for an approver before it is skipped
+const DefaultOptionalStepGracePeriod = 72 * time.Hour
+
+// WorkflowInstanceService handles the runtime execution of workflow rules
+type WorkflowInstanceService struct {
+	db                      *gorm.DB
+	workflowRuleService     *WorkflowRuleService
+	permissionResolver      *PermissionResolverService
+	emailSender             *email.EmailSender
+	optionalStepGracePeriod time.Duration
+}
+
+// NewWorkflowInstanceService creates a new WorkflowInstanceService instance
+func NewWorkflowInstanceService(db *gorm.DB, workflowRuleService *WorkflowRuleService) *WorkflowInstanceService {
+	return &WorkflowInstanceService{
+		db:                      db,
+		workflowRuleService:     workflowRuleService,
+		permissionResolver:      NewPermissionResolverService(database.Primary(db)),
+		emailSender:             email.NewEmailSender(),
+		optionalStepGracePeriod: DefaultOptionalStepGracePeriod,
+	}
+}
+
+// Start creates a new workflow instance from the active rule matching the workflow type and position.
+// The rule's steps are snapshotted so later edits to the rule do not affect this instance.
+// The initiator must currently hold the position, so nobody can file under another
+// position's approval chain.
+func (s *WorkflowInstanceService) Start(ctx context.Context, workflowType, positionID string, payload *datatypes.JSON, initiatorUserID string) (*models.WorkflowInstance, error) {
+	positions, err := s.permissionResolver.GetEffectiveUserPositions(initiatorUserID)
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil posisi pengguna: %w", err)
+	}
+	holdsPosition := false
+	for _, up := range positions {
+		if up.PositionID == positionID {
+			holdsPosition = true
+			break
+		}
+	}
+	if !holdsPosition {
+		return nil, errors.New("anda tidak memegang posisi ini")
+	}
+
+	rule, err := s.workflowRuleService.GetWorkflowRuleByPositionAndType(positionID, workflowType)
+	if err != nil {
+		return nil, err
+	}
+
+	now := clock.Now()
+	instance := models.WorkflowInstance{
+		ID:              uuid.New().String(),
+		WorkflowRuleID:  rule.ID,
+		RuleVersion:     rule.Version,
+		WorkflowType:    rule.WorkflowType,
+		PositionID:      rule.PositionID,
+		InitiatorUserID: initiatorUserID,
+		Status:          models.WorkflowInstanceStatusPending,
+		Payload:         payload,
+		StartedAt:       now,
+	}
+
+	// Snapshot rule steps
+	steps := make([]models.WorkflowInstanceStep, len(rule.Steps))
+	for i, ruleStep := range rule.Steps {
+		ruleStepID := ruleStep.ID
+		approvalMode := ruleStep.ApprovalMode
+		if approvalMode == "" {
+			approvalMode = models.ApprovalModeAll
+		}
+		timeoutAction := ruleStep.TimeoutAction
+		if timeoutAction == "" {
+			timeoutAction = models.TimeoutActionEscalate
+		}
+		steps[i] = models.WorkflowInstanceStep{
+			ID:                 uuid.New().String(),
+			WorkflowInstanceID: instance.ID,
+			WorkflowRuleStepID: &ruleStepID,
+			StepOrder:          ruleStep.StepOrder,
+			ApproverPositionID: ruleStep.ApproverPositionID,
+			StepName:           ruleStep.StepName,
+			IsOptional:         ruleStep.IsOptional,
+			StepGroup:          ruleStep.EffectiveStepGroup(),
+			ApprovalMode:       approvalMode,
+			TimeoutHours:       ruleStep.TimeoutHours,
+			TimeoutAction:      timeoutAction,
+			Status:             models.WorkflowStepStatusWaiting,
+		}
+	}
+	sortInstanceSteps(steps)
+
+	// Groups made up only of optional steps that come before the first mandatory
+	// group are skipped so the instance starts with an approver.
+	firstGroup := -1
+	for _, step := range steps {
+		if !step.IsOptional {
+			firstGroup = step.StepGroup
+			break
+		}
+	}
+
+	for i := range steps {
+		switch {
+		case firstGroup == -1 || steps[i].StepGroup < firstGroup:
+			steps[i].Status = models.WorkflowStepStatusSkipped
+		case steps[i].StepGroup == firstGroup:
+			steps[i].Status = models.WorkflowStepStatusPending
+			steps[i].ActivatedAt = &now
+		}
+	}
+
+	if firstGroup == -1 {
+		// No mandatory approver: nothing to wait for
+		instance.Status = models.WorkflowInstanceStatusApproved
+		instance.CompletedAt = &now
+	} else {
+		instance.CurrentStepGroup = &firstGroup
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&instance).Error; err != nil {
+			return fmt.Errorf("gagal membuat workflow instance: %w", err)
+		}
+
+		if len(steps) > 0 {
+			if err := tx.Create(&steps).Error; err != nil {
+				return fmt.Errorf("gagal membuat step workflow instance: %w", err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.notifyPendingApprovers(logger.FromContext(ctx), instance.ID, instance.WorkflowType, activatedApproverPositions(steps, now))
+
+	return s.GetWorkflowInstanceByID(instance.ID)
+}
+
+// GetWorkflowInstanceByID retrieves a workflow instance by ID with its steps. It only reads:
+// optional steps past their grace period are skipped by AdvanceOverdueOptionalSteps or by
+// the next approve/reject on the instance. It reads the primary so Start, Approve and Reject
+// return the instance as they left it.
+func (s *WorkflowInstanceService) GetWorkflowInstanceByID(id string) (*models.WorkflowInstance, error) {
+	var instance models.WorkflowInstance
+	if err := s.db.Clauses(database.Write).Preload("Position").
+		Preload("Initiator").
+		Preload("Steps", func(db *gorm.DB) *gorm.DB {
+			return db.Order("step_group ASC, step_order ASC")
+		}).
+		Preload("Steps.ApproverPosition").
+		Preload("Steps.Actor").
+		Preload("Steps.OnBehalfOf").
+		Preload("Steps.History", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at ASC")
+		}).
+		First(&instance, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("workflow instance tidak ditemukan")
+		}
+		return nil, fmt.Errorf("gagal mengambil data workflow instance: %w", err)
+	}
+
+	return &instance, nil
+}
+
+// CanSeeWorkflowInstance reports whether the instance is visible under the scope: its
+// initiator is a user visible under the scope (see VisibleUsers), or the caller approves one
+// of its steps, holds an approver position or an APPROVAL/WORKFLOW delegation from someone who
+// does, or acted on a step. Under ALL every id is reported visible without a lookup, leaving
+// not-found handling to the caller.
+func (d *DataScope) CanSeeWorkflowInstance(db *gorm.DB, instanceID string) (bool, error) {
+	users := d.VisibleUsers(db)
+	if users == nil {
+		return true, nil
+	}
+
+	now := clock.Now()
+	callerPositions := db.Table("public.user_positions up").
+		Select("up.position_id").
+		Where("up.user_id = ? AND up.is_active = ?", d.UserID, true).
+		Where("up.start_date <= ? AND (up.end_date IS NULL OR up.end_date >= ?)", now, now)
+	delegatedPositions := db.Table("public.delegations dl").
+		Select("dp.position_id").
+		Joins("JOIN public.user_positions dp ON dp.user_id = dl.delegator_id").
+		Where("dl.delegate_id = ? AND dl.is_active = ?", d.UserID, true).
+		Where("dl.type IN ?", []models.DelegationType{models.DelegationTypeApproval, models.DelegationTypeWorkflow}).
+		Where("dl.effective_from <= ? AND (dl.effective_until IS NULL OR dl.effective_until >= ?)", now, now).
+		Where("dp.is_active = ? AND dp.start_date <= ? AND (dp.end_date IS NULL OR dp.end_date >= ?)", true, now, now)
+	approvedInstances := db.Table("public.workflow_instance_steps ws").
+		Select("ws.workflow_instance_id").
+		Where("(ws.acted_by = ? OR ws.on_behalf_of_user_id = ? OR ws.approver_position_id IN (?) OR ws.approver_position_id IN (?))",
+			d.UserID, d.UserID, callerPositions, delegatedPositions)
+
+	var count int64
+	if err := db.Model(&models.WorkflowInstance{}).
+		Where("id = ?", instanceID).
+		Where("(initiator_user_id IN (?) OR id IN (?))", users.Select("u.id"), approvedInstances).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// AdvanceOverdueOptionalSteps skips pending optional steps whose grace period has passed
+// without an approver acting, advances their instances and notifies the approvers of any
+// group activated as a result. It returns the number of instances checked.
+func (s *WorkflowInstanceService) AdvanceOverdueOptionalSteps(now time.Time) (int, error) {
+	var instanceIDs []string
+	if err := s.db.Model(&models.WorkflowInstanceStep{}).
+		Joins("JOIN public.workflow_instances wi ON wi.id = workflow_instance_steps.workflow_instance_id").
+		Where("wi.status = ?", models.WorkflowInstanceStatusPending).
+		Where("workflow_instance_steps.status = ?", models.WorkflowStepStatusPending).
+		Where("workflow_instance_steps.is_optional = ?", true).
+		Where("workflow_instance_steps.activated_at <= ?", now.Add(-s.optionalStepGracePeriod)).
+		Distinct().
+		Pluck("workflow_instance_steps.workflow_instance_id", &instanceIDs).Error; err != nil {
+		return 0, fmt.Errorf("gagal mengambil step opsional yang melewati masa tunggu: %w", err)
+	}
+
+	for _, id := range instanceIDs {
+		var workflowType string
+		var activated []string
+
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			instance, steps, err := s.lockInstance(tx, id)
+			if err != nil {
+				return err
+			}
+			if err := s.advanceCurrentGroup(tx, instance, steps, now); err != nil {
+				return err
+			}
+
+			workflowType = instance.WorkflowType
+			activated = activatedApproverPositions(steps, now)
+			return nil
+		})
+		if err != nil {
+			slog.Warn("failed to advance workflow instance past optional steps", "instance_id", id, "error", err)
+			continue
+		}
+
+		s.notifyPendingApprovers(slog.Default(), id, workflowType, activated)
+	}
+
+	return len(instanceIDs), nil
+}
+
+// Approve approves a pending step in the instance's current group on behalf of userID.
+// The instance advances once the group's approval mode is satisfied.
+func (s *WorkflowInstanceService) Approve(ctx context.Context, instanceID, userID string, comment *string) (*models.WorkflowInstance, error) {
+	var workflowType string
+	var activated []string
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		instance, steps, idx, now, err := s.prepareStepAction(tx, instanceID, userID)
+		if err != nil {
+			return err
+		}
+
+		step := &steps[idx]
+		step.Status = models.WorkflowStepStatusApproved
+		step.ActedBy = &userID
+		step.ActedAt = &now
+		step.Comment = comment
+		if err := tx.Omit(clause.Associations).Save(step).Error; err != nil {
+			return fmt.Errorf("gagal menyimpan persetujuan step: %w", err)
+		}
+
+		if err := s.advanceCurrentGroup(tx, instance, steps, now); err != nil {
+			return err
+		}
+
+		workflowType = instance.WorkflowType
+		activated = activatedApproverPositions(steps, now)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.notifyPendingApprovers(logger.FromContext(ctx), instanceID, workflowType, activated)
+
+	return s.GetWorkflowInstanceByID(instanceID)
+}
+
+// Reject rejects a pending step in the instance's current group and terminates the instance
+func (s *WorkflowInstanceService) Reject(instanceID, userID, reason string) (*models.WorkflowInstance, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		instance, steps, idx, now, err := s.prepareStepAction(tx, instanceID, userID)
+		if err != nil {
+			return err
+		}
+
+		steps[idx].ActedBy = &userID
+		return s.rejectStep(tx, instance, steps, idx, reason, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetWorkflowInstanceByID(instanceID)
+}
+
+// rejectStep marks the step at idx as rejected, skips every step that has not been
+// decided yet and terminates the instance with the given reason
+func (s *WorkflowInstanceService) rejectStep(tx *gorm.DB, instance *models.WorkflowInstance, steps []models.WorkflowInstanceStep, idx int, reason string, now time.Time) error {
+	step := &steps[idx]
+	step.Status = models.WorkflowStepStatusRejected
+	step.ActedAt = &now
+	step.Comment = &reason
+	if err := tx.Omit(clause.Associations).Save(step).Error; err != nil {
+		return fmt.Errorf("gagal menyimpan penolakan step: %w", err)
+	}
+
+	// Remaining steps will never be reached
+	for i := range steps {
+		if steps[i].Status != models.WorkflowStepStatusWaiting && steps[i].Status != models.WorkflowStepStatusPending {
+			continue
+		}
+		steps[i].Status = models.WorkflowStepStatusSkipped
+		if err := tx.Omit(clause.Associations).Save(&steps[i]).Error; err != nil {
+			return fmt.Errorf("gagal memperbarui step workflow instance: %w", err)
+		}
+	}
+
+	instance.Status = models.WorkflowInstanceStatusRejected
+	instance.RejectionReason = &reason
+	instance.CurrentStepGroup = nil
+	instance.CompletedAt = &now
+	if err := tx.Omit(clause.Associations).Save(instance).Error; err != nil {
+		return fmt.Errorf("gagal memperbarui workflow instance: %w", err)
+	}
+
+	return nil
+}
+
+// prepareStepAction locks the instance, applies pending grace-period skips and finds a
+// pending step in the current group that userID may act on. It returns the index of that
+// step, with OnBehalfOfUserID set when the authority comes from a delegation.
+func (s *WorkflowInstanceService) prepareStepAction(tx *gorm.DB, instanceID, userID string) (*models.WorkflowInstance, []models.WorkflowInstanceStep, int, time.Time, error) {
+	now := clock.Now()
+
+	instance, steps, err := s.lockInstance(tx, instanceID)
+	if err != nil {
+		return nil, nil, -1, now, err
+	}
+
+	if err := s.advanceCurrentGroup(tx, instance, steps, now); err != nil {
+		return nil, nil, -1, now, err
+	}
+
+	if instance.IsComplete() {
+		return nil, nil, -1, now, errors.New("workflow instance sudah selesai")
+	}
+
+	pending := pendingStepIndexes(instance, steps)
+	if len(pending) == 0 {
+		return nil, nil, -1, now, errors.New("tidak ada step yang menunggu persetujuan")
+	}
+
+	authErr := errors.New("anda tidak berwenang memproses step ini")
+	for _, idx := range pending {
+		onBehalfOf, err := s.resolveApprovalAuthority(userID, steps[idx].ApproverPositionID, now)
+		if err != nil {
+			authErr = err
+			continue
+		}
+		steps[idx].OnBehalfOfUserID = onBehalfOf
+		return instance, steps, idx, now, nil
+	}
+
+	return nil, nil, -1, now, authErr
+}
+
+// lockInstance loads an instance and its ordered steps with a row lock on the instance
+func (s *WorkflowInstanceService) lockInstance(tx *gorm.DB, id string) (*models.WorkflowInstance, []models.WorkflowInstanceStep, error) {
+	var instance models.WorkflowInstance
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&instance, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, errors.New("workflow instance tidak ditemukan")
+		}
+		return nil, nil, fmt.Errorf("gagal mengambil data workflow instance: %w", err)
+	}
+
+	var steps []models.WorkflowInstanceStep
+	if err := tx.Where("workflow_instance_id = ?", id).
+		Order("step_group ASC, step_order ASC").
+		Find(&steps).Error; err != nil {
+		return nil, nil, fmt.Errorf("gagal mengambil step workflow instance: %w", err)
+	}
+
+	return &instance, steps, nil
+}
+
+// advanceCurrentGroup skips optional steps in the current group whose grace period has
+// passed, then moves the instance forward while the current group's approval mode is met
+func (s *WorkflowInstanceService) advanceCurrentGroup(tx *gorm.DB, instance *models.WorkflowInstance, steps []models.WorkflowInstanceStep, now time.Time) error {
+	for !instance.IsComplete() && instance.CurrentStepGroup != nil {
+		group := *instance.CurrentStepGroup
+
+		for _, idx := range pendingStepIndexes(instance, steps) {
+			step := &steps[idx]
+			if !step.IsOptional || step.ActivatedAt == nil || now.Sub(*step.ActivatedAt) < s.optionalStepGracePeriod {
+				continue
+			}
+			step.Status = models.WorkflowStepStatusSkipped
+			if err := tx.Omit(clause.Associations).Save(step).Error; err != nil {
+				return fmt.Errorf("gagal melewati step opsional: %w", err)
+			}
+		}
+
+		if !isGroupComplete(steps, group) {
+			return nil
+		}
+
+		// Approvers who did not act in a satisfied "any" group are no longer needed
+		for _, idx := range pendingStepIndexes(instance, steps) {
+			steps[idx].Status = models.WorkflowStepStatusSkipped
+			if err := tx.Omit(clause.Associations).Save(&steps[idx]).Error; err != nil {
+				return fmt.Errorf("gagal memperbarui step workflow instance: %w", err)
+			}
+		}
+
+		if err := s.activateNextGroup(tx, instance, steps, group, now); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// activateNextGroup marks every step of the group after the given one as pending,
+// or completes the instance as approved when no groups remain
+func (s *WorkflowInstanceService) activateNextGroup(tx *gorm.DB, instance *models.WorkflowInstance, steps []models.WorkflowInstanceStep, group int, now time.Time) error {
+	next := -1
+	for i := range steps {
+		if steps[i].StepGroup > group && steps[i].Status == models.WorkflowStepStatusWaiting {
+			next = steps[i].StepGroup
+			break
+		}
+	}
+
+	if next == -1 {
+		instance.Status = models.WorkflowInstanceStatusApproved
+		instance.CurrentStepGroup = nil
+		instance.CompletedAt = &now
+	} else {
+		for i := range steps {
+			if steps[i].StepGroup != next {
+				continue
+			}
+			steps[i].Status = models.WorkflowStepStatusPending
+			steps[i].ActivatedAt = &now
+			if err := tx.Omit(clause.Associations).Save(&steps[i]).Error; err != nil {
+				return fmt.Errorf("gagal mengaktifkan step berikutnya: %w", err)
+			}
+		}
+		instance.CurrentStepGroup = &next
+	}
+
+	if err := tx.Omit(clause.Associations).Save(instance).Error; err != nil {
+		return fmt.Errorf("gagal memperbarui workflow instance: %w", err)
+	}
+
+	return nil
+}
+
+// notifyPendingApprovers emails every current holder of the given approver positions in the
+// background so engine transitions are not slowed down by SMTP round trips. Failures are
+// logged to log, which carries the request ID when a request triggered the transition.
+func (s *WorkflowInstanceService) notifyPendingApprovers(log *slog.Logger, instanceID, workflowType string, positionIDs []string) {
+	if len(positionIDs) == 0 {
+		return
+	}
+
+	go func() {
+		recipients, err := positionHolderEmails(s.db, positionIDs, clock.Now())
+		if err != nil {
+			log.Warn("failed to resolve approvers for workflow instance", "instance_id", instanceID, "error", err)
+			return
+		}
+
+		for _, recipient := range recipients {
+			if err := s.emailSender.SendApprovalPendingEmail(recipient, workflowType, instanceID); err != nil {
+				log.Warn("failed to send approval pending email", "instance_id", instanceID, "recipient", recipient, "error", err)
+			}
+		}
+	}()
+}
+
+// resolveApprovalAuthority checks whether userID may act for the given approver position.
+// The user qualifies by holding the position, or through an effective APPROVAL/WORKFLOW
+// delegation from someone who holds it; in the latter case the delegator's ID is returned.
+func (s *WorkflowInstanceService) resolveApprovalAuthority(userID, positionID string, now time.Time) (*string, error) {
+	positions, err := s.permissionResolver.GetEffectiveUserPositions(userID)
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil posisi pengguna: %w", err)
+	}
+
+	for _, up := range positions {
+		if up.PositionID == positionID {
+			return nil, nil
+		}
+	}
+
+	var delegatorIDs []string
+	if err := s.db.Clauses(database.Write).Model(&models.Delegation{}).
+		Joins("JOIN public.user_positions up ON up.user_id = delegations.delegator_id").
+		Where("delegations.delegate_id = ?", userID).
+		Where("delegations.type IN ?", []models.DelegationType{models.DelegationTypeApproval, models.DelegationTypeWorkflow}).
+		Where("delegations.is_active = ?", true).
+		Where("delegations.effective_from <= ?", now).
+		Where("(delegations.effective_until IS NULL OR delegations.effective_until >= ?)", now).
+		Where("up.position_id = ? AND up.is_active = ?", positionID, true).
+		Where("up.start_date <= ?", now).
+		Where("(up.end_date IS NULL OR up.end_date >= ?)", now).
+		Order("delegations.effective_from ASC").
+		Limit(1).
+		Pluck("delegations.delegator_id", &delegatorIDs).Error; err != nil {
+		return nil, fmt.Errorf("gagal memeriksa delegasi: %w", err)
+	}
+
+	if len(delegatorIDs) == 0 {
+		return nil, errors.New("anda tidak berwenang memproses step ini")
+	}
+
+	return &delegatorIDs[0], nil
+}
+
+// pendingStepIndexes returns the indexes of pending steps in the instance's current group
+func pendingStepIndexes(instance *models.WorkflowInstance, steps []models.WorkflowInstanceStep) []int {
+	if instance.CurrentStepGroup == nil {
+		return nil
+	}
+
+	var indexes []int
+	for i := range steps {
+		if steps[i].StepGroup == *instance.CurrentStepGroup && steps[i].Status == models.WorkflowStepStatusPending {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+// isGroupComplete checks whether a step group satisfies its approval mode.
+// An "any" group completes on the first approval; otherwise the group completes
+// once no step is left pending.
+func isGroupComplete(steps []models.WorkflowInstanceStep, group int) bool {
+	hasPending := false
+	for _, step := range steps {
+		if step.StepGroup != group {
+			continue
+		}
+		if step.ApprovalMode == models.ApprovalModeAny && step.Status == models.WorkflowStepStatusApproved {
+			return true
+		}
+		if step.Status == models.WorkflowStepStatusPending {
+			hasPending = true
+		}
+	}
+	return !hasPending
+}
+
+// sortInstanceSteps orders steps by group, then by step order within the group
+func sortInstanceSteps(steps []models.WorkflowInstanceStep) {
+	sort.SliceStable(steps, func(i, j int) bool {
+		if steps[i].StepGroup != steps[j].StepGroup {
+			return steps[i].StepGroup < steps[j].StepGroup
+		}
+		return steps[i].StepOrder < steps[j].StepOrder
+	})
+}
+
+// activatedApproverPositions returns the distinct approver positions of steps that became pending at now
+func activatedApproverPositions(steps []models.WorkflowInstanceStep, now time.Time) []string {
+	seen := make(map[string]bool)
+	var positionIDs []string
+	for _, step := range steps {
+		if step.Status != models.WorkflowStepStatusPending || step.ActivatedAt == nil || !step.ActivatedAt.Equal(now) {
+			continue
+		}
+		if !seen[step.ApproverPositionID] {
+			seen[step.ApproverPositionID] = true
+			positionIDs = append(positionIDs, step.ApproverPositionID)
+		}
+	}
+	return positionIDs
+}
+
+// positionHolderEmails returns the email addresses of active users currently holding any of the positions
+func positionHolderEmails(db *gorm.DB, positionIDs []string, now time.Time) ([]string, error) {
+	var emails []string
+	if err := db.Model(&models.User{}).
+		Joins("JOIN public.user_positions up ON up.user_id = users.id").
+		Where("up.position_id IN ? AND up.is_active = ?", positionIDs, true).
+		Where("up.start_date <= ?", now).
+		Where("(up.end_date IS NULL OR up.end_date >= ?)", now).
+		Where("users.is_active = ?", true).
+		Distinct().
+		Pluck("users.email", &emails).Error; err != nil {
+		return nil, fmt.Errorf("gagal mengambil pemegang posisi: %w", err)
+	}
+
+	return emails, nil
+}
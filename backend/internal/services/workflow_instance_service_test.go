@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"backend/internal/models"
+)
+
+// TestResolveApprovalAuthorityDelegationWindow checks that a delegate may act for the
+// delegator's approver position exactly while the delegation window is open, both ends
+// included. The fake database applies the delegation's window to the time the service
+// passes in, as the SQL does with effective_from <= now and effective_until >= now.
+func TestResolveApprovalAuthorityDelegationWindow(t *testing.T) {
+	from := time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+	until := time.Date(2026, 3, 6, 17, 0, 0, 0, time.UTC)
+	delegation := models.Delegation{
+		DelegatorID:    "kepsek",
+		DelegateID:     "wakasek",
+		Type:           models.DelegationTypeApproval,
+		IsActive:       true,
+		EffectiveFrom:  from,
+		EffectiveUntil: &until,
+	}
+
+	db := newFakeDB(t, func(query string, args []driver.NamedValue) fakeRows {
+		if !fromTable(query, "delegations") {
+			return fakeRows{}
+		}
+		for _, arg := range args {
+			if at, ok := arg.Value.(time.Time); ok && !delegation.IsEffectiveAt(at) {
+				return fakeRows{}
+			}
+		}
+		return fakeRows{columns: []string{"delegator_id"}, values: [][]driver.Value{{delegation.DelegatorID}}}
+	})
+	s := &WorkflowInstanceService{db: db.DB, permissionResolver: NewPermissionResolverService(db.DB)}
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want bool
+	}{
+		{"before the window opens", from.Add(-time.Nanosecond), false},
+		{"as the window opens", from, true},
+		{"while the window is open", from.Add(48 * time.Hour), true},
+		{"as the window closes", until, true},
+		{"after the window closed", until.Add(time.Nanosecond), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delegatorID, err := s.resolveApprovalAuthority("wakasek", "pos-kepsek", tt.at)
+			if !tt.want {
+				if err == nil {
+					t.Fatalf("delegate may approve at %s, want the delegation to be out of effect", tt.at)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveApprovalAuthority at %s: %v", tt.at, err)
+			}
+			if delegatorID == nil || *delegatorID != delegation.DelegatorID {
+				t.Errorf("acting for %v, want delegator %s", delegatorID, delegation.DelegatorID)
+			}
+		})
+	}
+
+	if db.count("delegations.effective_from <= ") == 0 || db.count("delegations.effective_until >= ") == 0 {
+		t.Error("delegation query does not bound the window inclusively on both ends")
+	}
+}
+
+// TestStartRequiresInitiatorPosition checks that a user cannot file a request under a
+// position they do not hold: Start refuses before looking up the rule or writing anything.
+func TestStartRequiresInitiatorPosition(t *testing.T) {
+	db := newFakeDB(t, func(query string, _ []driver.NamedValue) fakeRows {
+		if fromTable(query, "user_positions") {
+			return fakeRows{columns: []string{"id", "user_id", "position_id"}, values: [][]driver.Value{{"up-1", "guru", "pos-guru"}}}
+		}
+		return fakeRows{}
+	})
+	s := &WorkflowInstanceService{db: db.DB, permissionResolver: NewPermissionResolverService(db.DB)}
+
+	_, err := s.Start(context.Background(), "LEAVE_REQUEST", "pos-kepsek", nil, "guru")
+	if err == nil || err.Error() != "anda tidak memegang posisi ini" {
+		t.Fatalf("Start under a position the initiator does not hold returned %v, want a refusal", err)
+	}
+	if db.count("workflow_rules") > 0 || db.count("INSERT") > 0 {
+		t.Error("Start looked up the rule or wrote an instance before checking the initiator's position")
+	}
+}
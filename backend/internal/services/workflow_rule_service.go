@@ -4,8 +4,10 @@ import (
 	"errors"
 	"fmt"
 	"sort"
-	"strings"
+	"time"
 
+	"backend/internal/clock"
+	"backend/internal/database"
 	"backend/internal/models"
 
 	"github.com/google/uuid"
@@ -45,9 +47,11 @@ type WorkflowRuleListResult struct {
 
 // CreateWorkflowRule creates a new workflow rule with validation
 func (s *WorkflowRuleService) CreateWorkflowRule(req models.CreateWorkflowRuleRequest, userID string) (*models.WorkflowRule, error) {
+	db := database.Primary(s.db)
+
 	// Business rule: Check if rule already exists for this position, workflow type, and school
 	var existing models.WorkflowRule
-	query := s.db.Where("position_id = ? AND workflow_type = ?", req.PositionID, req.WorkflowType)
+	query := db.Where("position_id = ? AND workflow_type = ?", req.PositionID, req.WorkflowType)
 	if req.SchoolID != nil && *req.SchoolID != "" {
 		query = query.Where("school_id = ?", *req.SchoolID)
 	} else {
@@ -89,8 +93,22 @@ func (s *WorkflowRuleService) CreateWorkflowRule(req models.CreateWorkflowRuleRe
 		priority = *req.Priority
 	}
 
+	ruleID := uuid.New().String()
+
+	// Build and validate steps, including parallel group configuration
+	steps := make([]models.WorkflowRuleStep, len(req.Steps))
+	for i, stepReq := range req.Steps {
+		steps[i] = newWorkflowRuleStep(ruleID, stepReq)
+	}
+	if err := checkStepOrders(steps, req.NormalizeStepOrder, req.RequireContiguousStepOrder); err != nil {
+		return nil, err
+	}
+	if err := validateStepGroups(steps); err != nil {
+		return nil, err
+	}
+
 	// Start transaction
-	tx := s.db.Begin()
+	tx := db.Begin()
 	defer func() {
 		if r := recover(); r != nil {
 			tx.Rollback()
@@ -99,7 +117,7 @@ func (s *WorkflowRuleService) CreateWorkflowRule(req models.CreateWorkflowRuleRe
 
 	// Create workflow rule entity
 	workflowRule := models.WorkflowRule{
-		ID:                uuid.New().String(),
+		ID:                ruleID,
 		WorkflowType:      req.WorkflowType,
 		PositionID:        req.PositionID,
 		SchoolID:          req.SchoolID,
@@ -117,38 +135,27 @@ func (s *WorkflowRuleService) CreateWorkflowRule(req models.CreateWorkflowRuleRe
 	}
 
 	// Create steps
-	for _, stepReq := range req.Steps {
-		isOptional := false
-		if stepReq.IsOptional != nil {
-			isOptional = *stepReq.IsOptional
-		}
-
-		step := models.WorkflowRuleStep{
-			ID:                 uuid.New().String(),
-			WorkflowRuleID:     workflowRule.ID,
-			StepOrder:          stepReq.StepOrder,
-			ApproverPositionID: stepReq.ApproverPositionID,
-			StepName:           stepReq.StepName,
-			IsOptional:         isOptional,
-		}
-
-		if err := tx.Create(&step).Error; err != nil {
+	for i := range steps {
+		if err := tx.Create(&steps[i]).Error; err != nil {
 			tx.Rollback()
 			return nil, fmt.Errorf("gagal membuat step workflow: %w", err)
 		}
 	}
 
+	if err := recordWorkflowRuleVersion(tx, &workflowRule, &userID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
 	if err := tx.Commit().Error; err != nil {
 		return nil, fmt.Errorf("gagal menyimpan aturan workflow: %w", err)
 	}
 
 	// Load relations for response
-	s.db.Preload("Position").
+	db.Preload("Position").
 		Preload("School").
 		Preload("CreatorPosition").
-		Preload("Steps", func(db *gorm.DB) *gorm.DB {
-			return db.Order("step_order ASC")
-		}).
+		Preload("Steps", currentVersionSteps).
 		Preload("Steps.ApproverPosition").
 		First(&workflowRule, "id = ?", workflowRule.ID)
 
@@ -183,64 +190,42 @@ func (s *WorkflowRuleService) GetWorkflowRules(params WorkflowRuleListParams) (*
 		query = query.Where("is_active = ?", *params.IsActive)
 	}
 
-	// Count total records
-	var total int64
-	if err := query.Count(&total).Error; err != nil {
-		return nil, fmt.Errorf("gagal menghitung total aturan workflow: %w", err)
-	}
-
-	// Apply sorting with SQL injection prevention
-	if params.SortBy != "" {
-		validSortColumns := map[string]bool{
+	page, err := Paginate[models.WorkflowRule](query, PageParams{
+		Page:      params.Page,
+		PageSize:  params.PageSize,
+		SortBy:    params.SortBy,
+		SortOrder: params.SortOrder,
+	}, ListQuery{
+		Noun: "aturan workflow",
+		SortColumns: map[string]bool{
 			"workflow_type": true, "name": true, "priority": true,
 			"created_at": true, "is_active": true, "position_id": true,
 			"school_id": true, "creator_position_id": true,
-		}
-		if validSortColumns[params.SortBy] {
-			direction := "ASC"
-			if strings.ToLower(params.SortOrder) == "desc" {
-				direction = "DESC"
-			}
-			query = query.Order(fmt.Sprintf("%s %s", params.SortBy, direction))
-		} else {
-			query = query.Order("workflow_type ASC, created_at DESC")
-		}
-	} else {
-		query = query.Order("workflow_type ASC, created_at DESC")
-	}
-
-	// Apply pagination
-	offset := (params.Page - 1) * params.PageSize
-	query = query.Offset(offset).Limit(params.PageSize)
-
-	// Fetch workflow rules with relations
-	var workflowRules []models.WorkflowRule
-	if err := query.Preload("Position").
-		Preload("School").
-		Preload("CreatorPosition").
-		Preload("Steps").
-		Find(&workflowRules).Error; err != nil {
-		return nil, fmt.Errorf("gagal mengambil data aturan workflow: %w", err)
+		},
+		DefaultOrder: "workflow_type ASC, created_at DESC",
+		Preload: func(db *gorm.DB) *gorm.DB {
+			return db.Preload("Position").
+				Preload("School").
+				Preload("CreatorPosition").
+				Preload("Steps", currentVersionSteps)
+		},
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// Convert to list response
-	ruleList := make([]*models.WorkflowRuleListResponse, len(workflowRules))
-	for i, rule := range workflowRules {
+	ruleList := make([]*models.WorkflowRuleListResponse, len(page.Data))
+	for i, rule := range page.Data {
 		ruleList[i] = rule.ToListResponse()
 	}
 
-	// Calculate total pages
-	totalPages := int(total) / params.PageSize
-	if int(total)%params.PageSize > 0 {
-		totalPages++
-	}
-
 	return &WorkflowRuleListResult{
 		Data:       ruleList,
-		Total:      total,
-		Page:       params.Page,
-		PageSize:   params.PageSize,
-		TotalPages: totalPages,
+		Total:      page.Total,
+		Page:       page.Page,
+		PageSize:   page.PageSize,
+		TotalPages: page.TotalPages,
 	}, nil
 }
 
@@ -250,9 +235,7 @@ func (s *WorkflowRuleService) GetWorkflowRuleByID(id string) (*models.WorkflowRu
 	if err := s.db.Preload("Position").
 		Preload("School").
 		Preload("CreatorPosition").
-		Preload("Steps", func(db *gorm.DB) *gorm.DB {
-			return db.Order("step_order ASC")
-		}).
+		Preload("Steps", currentVersionSteps).
 		Preload("Steps.ApproverPosition").
 		First(&workflowRule, "id = ?", id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -270,9 +253,7 @@ func (s *WorkflowRuleService) GetWorkflowRuleByPositionAndType(positionID, workf
 	if err := s.db.Preload("Position").
 		Preload("School").
 		Preload("CreatorPosition").
-		Preload("Steps", func(db *gorm.DB) *gorm.DB {
-			return db.Order("step_order ASC")
-		}).
+		Preload("Steps", currentVersionSteps).
 		Preload("Steps.ApproverPosition").
 		Where("position_id = ? AND workflow_type = ? AND is_active = ?", positionID, workflowType, true).
 		First(&workflowRule).Error; err != nil {
@@ -287,9 +268,11 @@ func (s *WorkflowRuleService) GetWorkflowRuleByPositionAndType(positionID, workf
 
 // UpdateWorkflowRule updates a workflow rule with validation
 func (s *WorkflowRuleService) UpdateWorkflowRule(id string, req models.UpdateWorkflowRuleRequest, userID string) (*models.WorkflowRule, error) {
+	db := database.Primary(s.db)
+
 	// Find existing workflow rule
 	var workflowRule models.WorkflowRule
-	if err := s.db.First(&workflowRule, "id = ?", id).Error; err != nil {
+	if err := db.First(&workflowRule, "id = ?", id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("aturan workflow tidak ditemukan")
 		}
@@ -321,7 +304,7 @@ func (s *WorkflowRuleService) UpdateWorkflowRule(id string, req models.UpdateWor
 		}
 
 		var existing models.WorkflowRule
-		query := s.db.Where("position_id = ? AND workflow_type = ? AND id != ?", newPositionID, newWorkflowType, id)
+		query := db.Where("position_id = ? AND workflow_type = ? AND id != ?", newPositionID, newWorkflowType, id)
 		if newSchoolID != nil && *newSchoolID != "" {
 			query = query.Where("school_id = ?", *newSchoolID)
 		} else {
@@ -360,14 +343,33 @@ func (s *WorkflowRuleService) UpdateWorkflowRule(id string, req models.UpdateWor
 		}
 	}
 
+	// Build and validate replacement steps, including parallel group configuration
+	steps := make([]models.WorkflowRuleStep, len(req.Steps))
+	for i, stepReq := range req.Steps {
+		steps[i] = newWorkflowRuleStep(workflowRule.ID, stepReq.ToCreateRequest())
+	}
+	if err := checkStepOrders(steps, req.NormalizeStepOrder, req.RequireContiguousStepOrder); err != nil {
+		return nil, err
+	}
+	if err := validateStepGroups(steps); err != nil {
+		return nil, err
+	}
+
 	// Start transaction
-	tx := s.db.Begin()
+	tx := db.Begin()
 	defer func() {
 		if r := recover(); r != nil {
 			tx.Rollback()
 		}
 	}()
 
+	// Rules created before versioning have no snapshot of their current version yet
+	if err := ensureWorkflowRuleVersionRecorded(tx, &workflowRule); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	previousVersion := workflowRule.Version
+
 	// Update fields
 	if req.WorkflowType != nil {
 		workflowRule.WorkflowType = *req.WorkflowType
@@ -399,54 +401,55 @@ func (s *WorkflowRuleService) UpdateWorkflowRule(id string, req models.UpdateWor
 		workflowRule.IsActive = *req.IsActive
 	}
 	workflowRule.ModifiedBy = &userID
+	workflowRule.Version = previousVersion + 1
 
 	if err := tx.Save(&workflowRule).Error; err != nil {
 		tx.Rollback()
 		return nil, fmt.Errorf("gagal memperbarui aturan workflow: %w", err)
 	}
 
-	// Update steps if provided
-	if req.Steps != nil {
-		// Delete existing steps
-		if err := tx.Where("workflow_rule_id = ?", id).Delete(&models.WorkflowRuleStep{}).Error; err != nil {
+	// Steps of previous versions are kept for instances that started on them,
+	// so the new version gets its own step rows
+	if req.Steps == nil {
+		var previousSteps []models.WorkflowRuleStep
+		if err := tx.Where("workflow_rule_id = ? AND rule_version = ?", id, previousVersion).
+			Order("step_order ASC").
+			Find(&previousSteps).Error; err != nil {
 			tx.Rollback()
-			return nil, fmt.Errorf("gagal menghapus step lama: %w", err)
+			return nil, fmt.Errorf("gagal mengambil step workflow: %w", err)
 		}
 
-		// Create new steps
-		for _, stepReq := range req.Steps {
-			isOptional := false
-			if stepReq.IsOptional != nil {
-				isOptional = *stepReq.IsOptional
-			}
-
-			step := models.WorkflowRuleStep{
-				ID:                 uuid.New().String(),
-				WorkflowRuleID:     workflowRule.ID,
-				StepOrder:          stepReq.StepOrder,
-				ApproverPositionID: stepReq.ApproverPositionID,
-				StepName:           stepReq.StepName,
-				IsOptional:         isOptional,
-			}
+		steps = make([]models.WorkflowRuleStep, len(previousSteps))
+		for i, step := range previousSteps {
+			step.ID = uuid.New().String()
+			step.CreatedAt = time.Time{}
+			step.UpdatedAt = time.Time{}
+			steps[i] = step
+		}
+	}
 
-			if err := tx.Create(&step).Error; err != nil {
-				tx.Rollback()
-				return nil, fmt.Errorf("gagal membuat step workflow: %w", err)
-			}
+	for i := range steps {
+		steps[i].RuleVersion = workflowRule.Version
+		if err := tx.Create(&steps[i]).Error; err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("gagal membuat step workflow: %w", err)
 		}
 	}
 
+	if err := recordWorkflowRuleVersion(tx, &workflowRule, &userID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
 	if err := tx.Commit().Error; err != nil {
 		return nil, fmt.Errorf("gagal menyimpan perubahan: %w", err)
 	}
 
 	// Load relations for response
-	s.db.Preload("Position").
+	db.Preload("Position").
 		Preload("School").
 		Preload("CreatorPosition").
-		Preload("Steps", func(db *gorm.DB) *gorm.DB {
-			return db.Order("step_order ASC")
-		}).
+		Preload("Steps", currentVersionSteps).
 		Preload("Steps.ApproverPosition").
 		First(&workflowRule, "id = ?", workflowRule.ID)
 
@@ -455,55 +458,192 @@ func (s *WorkflowRuleService) UpdateWorkflowRule(id string, req models.UpdateWor
 
 // DeleteWorkflowRule deletes a workflow rule and its steps
 func (s *WorkflowRuleService) DeleteWorkflowRule(id string) error {
+	db := database.Primary(s.db)
+
 	// Check if workflow rule exists
 	var workflowRule models.WorkflowRule
-	if err := s.db.First(&workflowRule, "id = ?", id).Error; err != nil {
+	if err := db.First(&workflowRule, "id = ?", id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return errors.New("aturan workflow tidak ditemukan")
 		}
 		return fmt.Errorf("gagal mengambil data aturan workflow: %w", err)
 	}
 
+	// Business rule: Instances keep referencing the rule and its versioned steps, so a rule
+	// that was ever used can only be deactivated
+	var instanceCount int64
+	if err := db.Model(&models.WorkflowInstance{}).Where("workflow_rule_id = ?", id).Count(&instanceCount).Error; err != nil {
+		return fmt.Errorf("gagal memeriksa workflow instance: %w", err)
+	}
+
+	if instanceCount > 0 {
+		return errors.New("aturan workflow sudah digunakan oleh workflow instance, nonaktifkan aturan ini sebagai gantinya")
+	}
+
 	// Delete will cascade to steps due to foreign key constraint
-	if err := s.db.Delete(&workflowRule).Error; err != nil {
+	if err := db.Delete(&workflowRule).Error; err != nil {
 		return fmt.Errorf("gagal menghapus aturan workflow: %w", err)
 	}
 
 	return nil
 }
 
+// GetWorkflowRuleVersions retrieves the version history of a workflow rule, newest first
+func (s *WorkflowRuleService) GetWorkflowRuleVersions(id string) ([]models.WorkflowRuleVersionResponse, error) {
+	var workflowRule models.WorkflowRule
+	if err := s.db.First(&workflowRule, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("aturan workflow tidak ditemukan")
+		}
+		return nil, fmt.Errorf("gagal mengambil data aturan workflow: %w", err)
+	}
+
+	var versions []models.WorkflowRuleVersion
+	if err := s.db.Where("workflow_rule_id = ?", id).
+		Order("version DESC").
+		Find(&versions).Error; err != nil {
+		return nil, fmt.Errorf("gagal mengambil versi aturan workflow: %w", err)
+	}
+
+	// Rules that were never updated since versioning was introduced only have their live state
+	if len(versions) == 0 {
+		versions = append(versions, newWorkflowRuleVersion(&workflowRule, workflowRule.ModifiedBy))
+		versions[0].CreatedAt = workflowRule.UpdatedAt
+	}
+
+	var steps []models.WorkflowRuleStep
+	if err := s.db.Preload("ApproverPosition").
+		Where("workflow_rule_id = ?", id).
+		Order("step_order ASC").
+		Find(&steps).Error; err != nil {
+		return nil, fmt.Errorf("gagal mengambil step workflow: %w", err)
+	}
+
+	stepsByVersion := make(map[int][]models.WorkflowRuleStep)
+	for _, step := range steps {
+		stepsByVersion[step.RuleVersion] = append(stepsByVersion[step.RuleVersion], step)
+	}
+
+	result := make([]models.WorkflowRuleVersionResponse, len(versions))
+	for i := range versions {
+		result[i] = *versions[i].ToVersionResponse(stepsByVersion[versions[i].Version], workflowRule.Version)
+	}
+
+	return result, nil
+}
+
 // GetWorkflowTypes returns all available workflow types
 func (s *WorkflowRuleService) GetWorkflowTypes() []string {
 	return models.AllWorkflowTypes()
 }
 
-// GetApprovalChain returns the ordered list of approvers for a given position and workflow type
-func (s *WorkflowRuleService) GetApprovalChain(positionID, workflowType string) ([]models.WorkflowRuleStepResponse, error) {
+// GetApprovalChain returns the approval steps a new request for the position and workflow
+// type would follow, grouped into parallel step groups in execution order, with the users
+// currently holding each approver position. When no active rule matches, the response has
+// RuleFound false and no groups rather than an error.
+func (s *WorkflowRuleService) GetApprovalChain(positionID, workflowType string) (*models.WorkflowApprovalChainResponse, error) {
+	result := &models.WorkflowApprovalChainResponse{
+		PositionID:   positionID,
+		WorkflowType: workflowType,
+		Groups:       []models.WorkflowApprovalGroupResponse{},
+	}
+
 	rule, err := s.GetWorkflowRuleByPositionAndType(positionID, workflowType)
 	if err != nil {
+		if err.Error() == "aturan workflow tidak ditemukan untuk posisi dan tipe ini" {
+			return result, nil
+		}
 		return nil, err
 	}
+	result.RuleFound = true
+	result.RuleID = &rule.ID
+	result.RuleVersion = &rule.Version
 
-	// Sort steps by order
+	// Sort steps by group, then by order within the group
 	steps := rule.Steps
-	sort.Slice(steps, func(i, j int) bool {
+	sort.SliceStable(steps, func(i, j int) bool {
+		gi, gj := steps[i].EffectiveStepGroup(), steps[j].EffectiveStepGroup()
+		if gi != gj {
+			return gi < gj
+		}
 		return steps[i].StepOrder < steps[j].StepOrder
 	})
 
-	// Convert to response
-	result := make([]models.WorkflowRuleStepResponse, len(steps))
-	for i, step := range steps {
-		result[i] = *step.ToStepResponse()
+	positionIDs := make([]string, 0, len(steps))
+	for _, step := range steps {
+		positionIDs = append(positionIDs, step.ApproverPositionID)
+	}
+	approvers, err := s.currentApprovers(positionIDs, clock.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	// Convert to grouped response
+	for _, step := range steps {
+		stepResp := models.WorkflowApprovalChainStep{
+			WorkflowRuleStepResponse: *step.ToStepResponse(),
+			Approvers:                approvers[step.ApproverPositionID],
+		}
+		if stepResp.Approvers == nil {
+			stepResp.Approvers = []models.WorkflowApprover{}
+		}
+		last := len(result.Groups) - 1
+		if last >= 0 && result.Groups[last].StepGroup == stepResp.StepGroup {
+			result.Groups[last].Steps = append(result.Groups[last].Steps, stepResp)
+			continue
+		}
+		result.Groups = append(result.Groups, models.WorkflowApprovalGroupResponse{
+			StepGroup:    stepResp.StepGroup,
+			ApprovalMode: stepResp.ApprovalMode,
+			Steps:        []models.WorkflowApprovalChainStep{stepResp},
+		})
 	}
 
 	return result, nil
 }
 
+// currentApprovers returns, per position, the active users holding it at now. It uses the
+// same rules as the approval notifications (positionHolderEmails).
+func (s *WorkflowRuleService) currentApprovers(positionIDs []string, now time.Time) (map[string][]models.WorkflowApprover, error) {
+	approvers := make(map[string][]models.WorkflowApprover)
+	if len(positionIDs) == 0 {
+		return approvers, nil
+	}
+
+	var holders []models.UserPosition
+	if err := s.db.Preload("User.DataKaryawan").
+		Joins("JOIN public.users u ON u.id = user_positions.user_id").
+		Where("user_positions.position_id IN ? AND user_positions.is_active = ?", positionIDs, true).
+		Where("user_positions.start_date <= ?", now).
+		Where("(user_positions.end_date IS NULL OR user_positions.end_date >= ?)", now).
+		Where("u.is_active = ?", true).
+		Order("user_positions.is_plt ASC, user_positions.start_date ASC").
+		Find(&holders).Error; err != nil {
+		return nil, fmt.Errorf("gagal mengambil pemegang posisi: %w", err)
+	}
+
+	for _, holder := range holders {
+		if holder.User == nil {
+			continue
+		}
+		approver := models.WorkflowApprover{
+			UserID:   holder.UserID,
+			Username: holder.User.Username,
+			IsPlt:    holder.IsPlt,
+		}
+		if holder.User.DataKaryawan != nil {
+			approver.Name = holder.User.DataKaryawan.Nama
+		}
+		approvers[holder.PositionID] = append(approvers[holder.PositionID], approver)
+	}
+	return approvers, nil
+}
+
 // Helper methods for validation
 
 func (s *WorkflowRuleService) validatePositionExists(id string) error {
 	var position models.Position
-	if err := s.db.First(&position, "id = ?", id).Error; err != nil {
+	if err := s.db.Clauses(database.Write).First(&position, "id = ?", id).Error; err != nil {
 		return err
 	}
 	return nil
@@ -511,12 +651,156 @@ func (s *WorkflowRuleService) validatePositionExists(id string) error {
 
 func (s *WorkflowRuleService) validateSchoolExists(id string) error {
 	var school models.School
-	if err := s.db.First(&school, "id = ?", id).Error; err != nil {
+	if err := s.db.Clauses(database.Write).First(&school, "id = ?", id).Error; err != nil {
 		return err
 	}
 	return nil
 }
 
+// currentVersionSteps limits a Steps preload to the steps of each rule's current version
+func currentVersionSteps(db *gorm.DB) *gorm.DB {
+	return db.Where("rule_version = (SELECT wr.version FROM public.workflow_rules wr WHERE wr.id = workflow_rule_steps.workflow_rule_id)").
+		Order("step_order ASC")
+}
+
+// newWorkflowRuleVersion builds a version snapshot of the rule's current settings
+func newWorkflowRuleVersion(rule *models.WorkflowRule, userID *string) models.WorkflowRuleVersion {
+	return models.WorkflowRuleVersion{
+		ID:                uuid.New().String(),
+		WorkflowRuleID:    rule.ID,
+		Version:           rule.Version,
+		WorkflowType:      rule.WorkflowType,
+		PositionID:        rule.PositionID,
+		SchoolID:          rule.SchoolID,
+		CreatorPositionID: rule.CreatorPositionID,
+		Description:       rule.Description,
+		Priority:          rule.Priority,
+		IsActive:          rule.IsActive,
+		CreatedBy:         userID,
+	}
+}
+
+// recordWorkflowRuleVersion stores a snapshot of the rule at its current version
+func recordWorkflowRuleVersion(tx *gorm.DB, rule *models.WorkflowRule, userID *string) error {
+	version := newWorkflowRuleVersion(rule, userID)
+	if err := tx.Create(&version).Error; err != nil {
+		return fmt.Errorf("gagal mencatat versi aturan workflow: %w", err)
+	}
+	return nil
+}
+
+// ensureWorkflowRuleVersionRecorded snapshots the rule's current version if it has not been recorded yet
+func ensureWorkflowRuleVersionRecorded(tx *gorm.DB, rule *models.WorkflowRule) error {
+	var count int64
+	if err := tx.Model(&models.WorkflowRuleVersion{}).
+		Where("workflow_rule_id = ? AND version = ?", rule.ID, rule.Version).
+		Count(&count).Error; err != nil {
+		return fmt.Errorf("gagal memeriksa versi aturan workflow: %w", err)
+	}
+	if count > 0 {
+		return nil
+	}
+	return recordWorkflowRuleVersion(tx, rule, rule.ModifiedBy)
+}
+
+// newWorkflowRuleStep builds a step entity from request fields, applying defaults
+func newWorkflowRuleStep(ruleID string, req models.CreateWorkflowRuleStepRequest) models.WorkflowRuleStep {
+	step := models.WorkflowRuleStep{
+		ID:                 uuid.New().String(),
+		WorkflowRuleID:     ruleID,
+		StepOrder:          req.StepOrder,
+		ApproverPositionID: req.ApproverPositionID,
+		StepName:           req.StepName,
+		StepGroup:          req.StepGroup,
+		ApprovalMode:       models.ApprovalModeAll,
+		TimeoutHours:       req.TimeoutHours,
+		TimeoutAction:      models.TimeoutActionEscalate,
+		RuleVersion:        1,
+	}
+	if req.IsOptional != nil {
+		step.IsOptional = *req.IsOptional
+	}
+	if req.ApprovalMode != nil && *req.ApprovalMode != "" {
+		step.ApprovalMode = *req.ApprovalMode
+	}
+	if req.TimeoutAction != nil && *req.TimeoutAction != "" {
+		step.TimeoutAction = *req.TimeoutAction
+	}
+	return step
+}
+
+// checkStepOrders requires step orders to be unique, and with contiguous also to run 1..N
+// without gaps. When normalize is set, orders are instead rewritten to 1..N following the
+// submitted sequence. Normalizing is refused when a step has an explicit group, since
+// renumbering would move the implicit groups (keyed by order) relative to the explicit ones.
+func checkStepOrders(steps []models.WorkflowRuleStep, normalize, contiguous bool) error {
+	if normalize {
+		for _, step := range steps {
+			if step.StepGroup != nil {
+				return errors.New("normalize_step_order tidak dapat digunakan bersama step_group")
+			}
+		}
+		// Stable sort keeps request order for steps submitted with the same order
+		sort.SliceStable(steps, func(i, j int) bool {
+			return steps[i].StepOrder < steps[j].StepOrder
+		})
+		for i := range steps {
+			steps[i].StepOrder = i + 1
+		}
+		return nil
+	}
+
+	seen := make(map[int]bool, len(steps))
+	for _, step := range steps {
+		if seen[step.StepOrder] {
+			return fmt.Errorf("urutan step %d duplikat", step.StepOrder)
+		}
+		seen[step.StepOrder] = true
+	}
+	if !contiguous {
+		return nil
+	}
+	for order := 1; order <= len(steps); order++ {
+		if !seen[order] {
+			return fmt.Errorf("urutan step harus berurutan mulai dari 1, step %d tidak ada", order)
+		}
+	}
+	return nil
+}
+
+// validateStepGroups ensures all steps in a group agree on the approval mode, and that a step
+// without a group (which forms its own group keyed by its order) does not share its number with
+// an explicit group it was never meant to join
+func validateStepGroups(steps []models.WorkflowRuleStep) error {
+	groupModes := make(map[int]string)
+	explicitGroups := make(map[int]bool)
+	for _, step := range steps {
+		if step.StepGroup != nil {
+			explicitGroups[*step.StepGroup] = true
+		}
+	}
+
+	for _, step := range steps {
+		group := step.EffectiveStepGroup()
+
+		if step.StepGroup == nil && explicitGroups[group] {
+			return fmt.Errorf("step %d tanpa step_group bentrok dengan grup %d, tetapkan step_group secara eksplisit", step.StepOrder, group)
+		}
+		if !models.IsValidApprovalMode(step.ApprovalMode) {
+			return fmt.Errorf("mode persetujuan pada grup %d tidak valid", group)
+		}
+		if !models.IsValidTimeoutAction(step.TimeoutAction) {
+			return fmt.Errorf("aksi batas waktu pada step %d tidak valid", step.StepOrder)
+		}
+		if mode, ok := groupModes[group]; ok && mode != step.ApprovalMode {
+			return fmt.Errorf("mode persetujuan pada grup %d tidak konsisten", group)
+		}
+		groupModes[group] = step.ApprovalMode
+	}
+
+	return nil
+}
+
 // BulkCreateWorkflowRulesRequest represents request for bulk creating workflow rules
 type BulkCreateWorkflowRulesRequest struct {
 	WorkflowType      string                                `json:"workflow_type" binding:"required"`
@@ -526,6 +810,11 @@ type BulkCreateWorkflowRulesRequest struct {
 	Description       *string                               `json:"description,omitempty"`
 	Priority          *int                                  `json:"priority,omitempty"`
 	Steps             []models.CreateWorkflowRuleStepRequest `json:"steps,omitempty"`
+	// NormalizeStepOrder rewrites step orders to 1..N instead of rejecting duplicates.
+	// Not allowed together with an explicit step_group.
+	NormalizeStepOrder bool `json:"normalize_step_order,omitempty"`
+	// RequireContiguousStepOrder rejects gaps: step orders must run 1..N
+	RequireContiguousStepOrder bool `json:"require_contiguous_step_order,omitempty"`
 }
 
 // BulkCreateResult represents the result of bulk create operation
@@ -538,6 +827,8 @@ type BulkCreateResult struct {
 
 // BulkCreateWorkflowRules creates workflow rules for multiple schools at once
 func (s *WorkflowRuleService) BulkCreateWorkflowRules(req BulkCreateWorkflowRulesRequest, userID string) (*BulkCreateResult, error) {
+	db := database.Primary(s.db)
+
 	result := &BulkCreateResult{
 		Created:  0,
 		Skipped:  0,
@@ -564,6 +855,18 @@ func (s *WorkflowRuleService) BulkCreateWorkflowRules(req BulkCreateWorkflowRule
 		}
 	}
 
+	// Validate parallel group configuration once for all schools
+	templateSteps := make([]models.WorkflowRuleStep, len(req.Steps))
+	for i, stepReq := range req.Steps {
+		templateSteps[i] = newWorkflowRuleStep("", stepReq)
+	}
+	if err := checkStepOrders(templateSteps, req.NormalizeStepOrder, req.RequireContiguousStepOrder); err != nil {
+		return nil, err
+	}
+	if err := validateStepGroups(templateSteps); err != nil {
+		return nil, err
+	}
+
 	// Set default priority if not provided
 	priority := 1
 	if req.Priority != nil {
@@ -581,7 +884,7 @@ func (s *WorkflowRuleService) BulkCreateWorkflowRules(req BulkCreateWorkflowRule
 
 		// Check if rule already exists for this position, workflow type, and school
 		var existing models.WorkflowRule
-		if err := s.db.Where("position_id = ? AND workflow_type = ? AND school_id = ?",
+		if err := db.Where("position_id = ? AND workflow_type = ? AND school_id = ?",
 			req.PositionID, req.WorkflowType, schoolID).First(&existing).Error; err == nil {
 			result.Skipped++
 			result.Errors = append(result.Errors, fmt.Sprintf("Aturan untuk sekolah ID %s sudah ada", schoolID))
@@ -589,7 +892,7 @@ func (s *WorkflowRuleService) BulkCreateWorkflowRules(req BulkCreateWorkflowRule
 		}
 
 		// Start transaction for each rule
-		tx := s.db.Begin()
+		tx := db.Begin()
 
 		// Create workflow rule entity
 		workflowRule := models.WorkflowRule{
@@ -612,22 +915,12 @@ func (s *WorkflowRuleService) BulkCreateWorkflowRules(req BulkCreateWorkflowRule
 			continue
 		}
 
-		// Create steps
+		// Create steps from the validated (and possibly normalized) template
 		stepCreateFailed := false
-		for _, stepReq := range req.Steps {
-			isOptional := false
-			if stepReq.IsOptional != nil {
-				isOptional = *stepReq.IsOptional
-			}
-
-			step := models.WorkflowRuleStep{
-				ID:                 uuid.New().String(),
-				WorkflowRuleID:     workflowRule.ID,
-				StepOrder:          stepReq.StepOrder,
-				ApproverPositionID: stepReq.ApproverPositionID,
-				StepName:           stepReq.StepName,
-				IsOptional:         isOptional,
-			}
+		for _, template := range templateSteps {
+			step := template
+			step.ID = uuid.New().String()
+			step.WorkflowRuleID = workflowRule.ID
 
 			if err := tx.Create(&step).Error; err != nil {
 				tx.Rollback()
@@ -642,6 +935,13 @@ func (s *WorkflowRuleService) BulkCreateWorkflowRules(req BulkCreateWorkflowRule
 			continue
 		}
 
+		if err := recordWorkflowRuleVersion(tx, &workflowRule, &userID); err != nil {
+			tx.Rollback()
+			result.Skipped++
+			result.Errors = append(result.Errors, fmt.Sprintf("Gagal mencatat versi untuk sekolah %s: %v", schoolID, err))
+			continue
+		}
+
 		if err := tx.Commit().Error; err != nil {
 			result.Skipped++
 			result.Errors = append(result.Errors, fmt.Sprintf("Gagal commit untuk sekolah %s: %v", schoolID, err))
@@ -654,3 +954,134 @@ func (s *WorkflowRuleService) BulkCreateWorkflowRules(req BulkCreateWorkflowRule
 
 	return result, nil
 }
+
+// CopyWorkflowRulesRequest represents request for copying a school's workflow rules to other schools
+type CopyWorkflowRulesRequest struct {
+	SourceSchoolID  string   `json:"source_school_id" binding:"required,len=36"`
+	TargetSchoolIDs []string `json:"target_school_ids" binding:"required,min=1,dive,len=36"`
+}
+
+// CopyWorkflowRules clones every rule of the source school, with its current steps, to each
+// target school. Rules that already exist at a target are skipped; each clone is saved in
+// its own transaction so one failure does not undo the others.
+func (s *WorkflowRuleService) CopyWorkflowRules(req CopyWorkflowRulesRequest, userID string) (*BulkCreateResult, error) {
+	result := &BulkCreateResult{
+		Errors:  []string{},
+		RuleIDs: []string{},
+	}
+
+	if err := s.validateSchoolExists(req.SourceSchoolID); err != nil {
+		return nil, errors.New("sekolah sumber tidak ditemukan")
+	}
+
+	var sourceRules []models.WorkflowRule
+	if err := s.db.Clauses(database.Write).Where("school_id = ?", req.SourceSchoolID).
+		Preload("Steps", currentVersionSteps).
+		Order("workflow_type ASC, created_at ASC").
+		Find(&sourceRules).Error; err != nil {
+		return nil, fmt.Errorf("gagal mengambil aturan workflow sekolah sumber: %w", err)
+	}
+	if len(sourceRules) == 0 {
+		return nil, errors.New("sekolah sumber tidak memiliki aturan workflow")
+	}
+
+	for _, schoolID := range req.TargetSchoolIDs {
+		if schoolID == req.SourceSchoolID {
+			result.Skipped += len(sourceRules)
+			result.Errors = append(result.Errors, fmt.Sprintf("Sekolah %s adalah sekolah sumber", schoolID))
+			continue
+		}
+
+		if err := s.validateSchoolExists(schoolID); err != nil {
+			result.Skipped += len(sourceRules)
+			result.Errors = append(result.Errors, fmt.Sprintf("Sekolah %s tidak ditemukan", schoolID))
+			continue
+		}
+
+		for _, source := range sourceRules {
+			ruleID, err := s.copyWorkflowRule(source, schoolID, userID)
+			if err != nil {
+				result.Skipped++
+				result.Errors = append(result.Errors, fmt.Sprintf("Aturan %s untuk sekolah %s: %v", source.WorkflowType, schoolID, err))
+				continue
+			}
+
+			result.Created++
+			result.RuleIDs = append(result.RuleIDs, ruleID)
+		}
+	}
+
+	return result, nil
+}
+
+// copyWorkflowRule clones one rule and its steps to the given school in a single transaction
+func (s *WorkflowRuleService) copyWorkflowRule(source models.WorkflowRule, schoolID string, userID string) (string, error) {
+	db := database.Primary(s.db)
+
+	// Business rule: a rule for this position, workflow type, and school must not exist yet
+	var existing models.WorkflowRule
+	if err := db.Where("position_id = ? AND workflow_type = ? AND school_id = ?",
+		source.PositionID, source.WorkflowType, schoolID).First(&existing).Error; err == nil {
+		return "", errors.New("aturan sudah ada")
+	}
+
+	tx := db.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	workflowRule := models.WorkflowRule{
+		ID:                uuid.New().String(),
+		WorkflowType:      source.WorkflowType,
+		PositionID:        source.PositionID,
+		SchoolID:          &schoolID,
+		CreatorPositionID: source.CreatorPositionID,
+		Description:       source.Description,
+		Priority:          source.Priority,
+		IsActive:          true,
+		CreatedBy:         &userID,
+		ModifiedBy:        &userID,
+	}
+
+	if err := tx.Create(&workflowRule).Error; err != nil {
+		tx.Rollback()
+		return "", fmt.Errorf("gagal membuat aturan workflow: %w", err)
+	}
+
+	// is_active has a database default of true, so an inactive source is copied in a second step
+	if !source.IsActive {
+		if err := tx.Model(&workflowRule).Update("is_active", false).Error; err != nil {
+			tx.Rollback()
+			return "", fmt.Errorf("gagal menonaktifkan aturan workflow: %w", err)
+		}
+		workflowRule.IsActive = false
+	}
+
+	for _, sourceStep := range source.Steps {
+		step := sourceStep
+		step.ID = uuid.New().String()
+		step.WorkflowRuleID = workflowRule.ID
+		step.RuleVersion = 1
+		step.CreatedAt = time.Time{}
+		step.UpdatedAt = time.Time{}
+		step.ApproverPosition = nil
+
+		if err := tx.Create(&step).Error; err != nil {
+			tx.Rollback()
+			return "", fmt.Errorf("gagal membuat step workflow: %w", err)
+		}
+	}
+
+	if err := recordWorkflowRuleVersion(tx, &workflowRule, &userID); err != nil {
+		tx.Rollback()
+		return "", err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return "", fmt.Errorf("gagal menyimpan aturan workflow: %w", err)
+	}
+
+	return workflowRule.ID, nil
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"testing"
+
+	"backend/internal/models"
+)
+
+func TestCheckStepOrdersNormalize(t *testing.T) {
+	steps := []models.WorkflowRuleStep{{StepOrder: 20}, {StepOrder: 5}, {StepOrder: 20}}
+	if err := checkStepOrders(steps, true, false); err != nil {
+		t.Fatalf("checkStepOrders: %v", err)
+	}
+	for i, step := range steps {
+		if step.StepOrder != i+1 {
+			t.Errorf("step %d has order %d, want %d", i, step.StepOrder, i+1)
+		}
+	}
+
+	// Renumbering would shift the implicit groups past explicit group 2
+	group := 2
+	steps = []models.WorkflowRuleStep{{StepOrder: 10}, {StepOrder: 20, StepGroup: &group}, {StepOrder: 30, StepGroup: &group}}
+	if err := checkStepOrders(steps, true, false); err == nil {
+		t.Error("normalizing steps with an explicit group was accepted")
+	}
+	if steps[0].StepOrder != 10 {
+		t.Errorf("rejected normalization still rewrote step orders to %+v", steps)
+	}
+}
+
+func TestValidateStepGroupsImplicitCollision(t *testing.T) {
+	step := func(order int, group *int) models.WorkflowRuleStep {
+		return models.WorkflowRuleStep{StepOrder: order, StepGroup: group, ApprovalMode: models.ApprovalModeAll, TimeoutAction: models.TimeoutActionEscalate}
+	}
+	one, three := 1, 3
+
+	if err := validateStepGroups([]models.WorkflowRuleStep{step(1, &three), step(2, &three), step(4, nil)}); err != nil {
+		t.Errorf("separate implicit and explicit groups rejected: %v", err)
+	}
+	if err := validateStepGroups([]models.WorkflowRuleStep{step(1, &one), step(2, &one), step(3, &three)}); err != nil {
+		t.Errorf("explicit group matching its own step order rejected: %v", err)
+	}
+	// Step 3 has no group and would silently join explicit group 3
+	if err := validateStepGroups([]models.WorkflowRuleStep{step(1, &three), step(2, &three), step(3, nil)}); err == nil {
+		t.Error("step without a group colliding with explicit group 3 was accepted")
+	}
+}
@@ -36,7 +36,8 @@ VALUES
     (gen_random_uuid(), 'MOD_AUDIT', 'Audit Log', 'SYSTEM', 'Modul untuk melihat audit log sistem', 'FileText', '/audit', NULL, 60, true, true, 1, NOW(), NOW(), 'system', 'system'),
 
     -- 10. DELEGATIONS (Root Module)
-    (gen_random_uuid(), 'MOD_DELEGATIONS', 'Delegasi', 'SYSTEM', 'Modul untuk manajemen delegasi tugas', 'Share2', '/delegations', NULL, 70, true, true, 1, NOW(), NOW(), 'system', 'system');
+    (gen_random_uuid(), 'MOD_DELEGATIONS', 'Delegasi', 'SYSTEM', 'Modul untuk manajemen delegasi tugas', 'Share2', '/delegations', NULL, 70, true, true, 1, NOW(), NOW(), 'system', 'system')
+ON CONFLICT (code) DO NOTHING;
 
 -- Child Modules (require parent_id reference)
 INSERT INTO public.modules (id, code, name, category, description, icon, path, parent_id, sort_order, is_active, is_visible, version, created_at, updated_at, created_by, updated_by)
@@ -57,7 +58,8 @@ VALUES
     (gen_random_uuid(), 'MOD_WF_BULK', 'Bulk Operations', 'SYSTEM', 'Operasi workflow secara massal', 'Layers', '/workflow/bulk-operations', (SELECT id FROM public.modules WHERE code = 'MOD_WORKFLOW'), 3, true, true, 1, NOW(), NOW(), 'system', 'system'),
 
     -- 14. USER MANAGEMENT - Children (/user/*)
-    (gen_random_uuid(), 'MOD_USER_USERS', 'Users', 'SYSTEM', 'Manajemen akun user', 'Users', '/user/users', (SELECT id FROM public.modules WHERE code = 'MOD_USER'), 1, true, true, 1, NOW(), NOW(), 'system', 'system');
+    (gen_random_uuid(), 'MOD_USER_USERS', 'Users', 'SYSTEM', 'Manajemen akun user', 'Users', '/user/users', (SELECT id FROM public.modules WHERE code = 'MOD_USER'), 1, true, true, 1, NOW(), NOW(), 'system', 'system')
+ON CONFLICT (code) DO NOTHING;
 
 -- Verification
 SELECT code, name, path, (SELECT code FROM public.modules p WHERE p.id = m.parent_id) as parent_code, sort_order
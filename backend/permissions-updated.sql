@@ -144,4 +144,16 @@ VALUES
     (gen_random_uuid(), 'audit-logs.read.department', 'Read Department Audit Logs', 'Melihat log audit departemen', 'audit-logs', 'READ', 'DEPARTMENT', true, true, 'admin', 'Audit Logs', 'FileText', 8, NOW(), NOW()),
     (gen_random_uuid(), 'audit-logs.read.school', 'Read School Audit Logs', 'Melihat log audit sekolah', 'audit-logs', 'READ', 'SCHOOL', true, true, 'admin', 'Audit Logs', 'FileText', 8, NOW(), NOW()),
     (gen_random_uuid(), 'audit-logs.read.all', 'Read All Audit Logs', 'Melihat semua log audit', 'audit-logs', 'READ', 'ALL', true, true, 'admin', 'Audit Logs', 'FileText', 8, NOW(), NOW()),
-    (gen_random_uuid(), 'audit-logs.export.all', 'Export Audit Logs', 'Export log audit', 'audit-logs', 'EXPORT', 'ALL', true, true, 'admin', 'Audit Logs', 'FileText', 8, NOW(), NOW());
+    (gen_random_uuid(), 'audit-logs.export.all', 'Export Audit Logs', 'Export log audit', 'audit-logs', 'EXPORT', 'ALL', true, true, 'admin', 'Audit Logs', 'FileText', 8, NOW(), NOW())
+ON CONFLICT (code) DO UPDATE SET
+    name = EXCLUDED.name,
+    description = EXCLUDED.description,
+    resource = EXCLUDED.resource,
+    action = EXCLUDED.action,
+    scope = EXCLUDED.scope,
+    is_system_permission = EXCLUDED.is_system_permission,
+    category = EXCLUDED.category,
+    group_name = EXCLUDED.group_name,
+    group_icon = EXCLUDED.group_icon,
+    group_sort_order = EXCLUDED.group_sort_order,
+    updated_at = NOW();
@@ -113,6 +113,7 @@ function EditModuleForm({ params }: PageProps) {
         is_visible?: boolean;
         is_active?: boolean;
         description?: string | null;
+        version?: number;
       } = {};
 
       if (data.code !== undefined && data.code !== "") cleanedData.code = data.code;
@@ -136,6 +137,9 @@ function EditModuleForm({ params }: PageProps) {
         cleanedData.parent_id = "";
       }
 
+      // Lets the server reject the save if someone else changed the module meanwhile
+      cleanedData.version = module?.version;
+
       await updateModule({ id, data: cleanedData }).unwrap();
       toast.success("Module berhasil diperbarui");
       router.push(`/access/modules/${id}`);
@@ -86,6 +86,7 @@ function EditRoleForm({ params }: PageProps) {
         hierarchy_level?: number;
         description?: string | null;
         is_active?: boolean;
+        version?: number;
       } = {};
 
       if (data.code !== undefined && data.code !== "") cleanedData.code = data.code;
@@ -94,6 +95,9 @@ function EditRoleForm({ params }: PageProps) {
       if (data.description !== undefined) cleanedData.description = data.description || null;
       if (data.is_active !== undefined) cleanedData.is_active = data.is_active;
 
+      // Lets the server reject the save if someone else changed the role meanwhile
+      cleanedData.version = role?.version;
+
       await updateRole({ id, data: cleanedData }).unwrap();
       toast.success("Role berhasil diperbarui");
       router.push(`/access/roles/${id}`);
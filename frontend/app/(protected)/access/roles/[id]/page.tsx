@@ -62,7 +62,7 @@ export default function RoleDetailPage({ params }: PageProps) {
                     <p className="text-muted-foreground">Kode: {role.code}</p>
                 </div>
                 {/* Client Island - Action Buttons */}
-                <RoleDetailActions roleId={id} roleName={role.name} />
+                <RoleDetailActions roleId={id} roleName={role.name} isActive={role.is_active} />
             </div>
 
             {/* Informasi Dasar */}
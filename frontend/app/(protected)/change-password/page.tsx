@@ -7,6 +7,7 @@ import { Input } from "@/components/ui/input";
 import { Check, X, Eye, EyeOff, KeyRound } from "lucide-react";
 import { useChangePasswordMutation } from "@/lib/store/services/authApi";
 import { toast } from "sonner";
+import { getApiErrorMessage } from "@/lib/utils/apiError";
 
 export default function ChangePasswordPage() {
     const router = useRouter();
@@ -67,7 +68,7 @@ export default function ChangePasswordPage() {
                 router.push("/dashboard");
             }, 2000);
         } catch (err: any) {
-            const errorMessage = err?.data?.error || err?.message || "Failed to change password";
+            const errorMessage = getApiErrorMessage(err?.data) || err?.message || "Failed to change password";
             toast.error(errorMessage);
         }
     };
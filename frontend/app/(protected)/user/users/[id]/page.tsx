@@ -185,6 +185,11 @@ export default function UserDetailPage({ params }: PageProps) {
                                                         Tidak Aktif
                                                     </Badge>
                                                 )}
+                                                {roleAssignment.is_active && new Date(roleAssignment.effective_from) > new Date() && (
+                                                    <Badge variant="secondary" className="text-xs">
+                                                        Terjadwal {new Date(roleAssignment.effective_from).toLocaleDateString("id-ID")}
+                                                    </Badge>
+                                                )}
                                             </div>
                                             <Button variant="ghost" size="sm" onClick={() => openRevokeRoleDialog(roleAssignment.id, roleAssignment.role?.name || "Unknown Role")} disabled={revokingRoleId === roleAssignment.id}>
                                                 <X className="h-4 w-4 text-destructive" />
@@ -6,6 +6,7 @@ import Link from 'next/link';
 import { Button } from '@/components/ui/button';
 import { Input } from '@/components/ui/input';
 import { Alert } from '@/components/ui/alert';
+import { getApiErrorMessage } from '@/lib/utils/apiError';
 
 export default function ForgotPasswordPage() {
   const [email, setEmail] = useState('');
@@ -36,7 +37,7 @@ export default function ForgotPasswordPage() {
         setMessage(data.message || 'If the email exists, a password reset link has been sent');
         setEmail(''); // Clear form
       } else {
-        setError(data.error || 'Failed to send reset email');
+        setError(getApiErrorMessage(data) || 'Failed to send reset email');
       }
     } catch (err: any) {
       setError('Network error. Please check your connection and try again.');
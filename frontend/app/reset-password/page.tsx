@@ -8,6 +8,7 @@ import { Button } from '@/components/ui/button';
 import { Input } from '@/components/ui/input';
 import { Alert } from '@/components/ui/alert';
 import { Eye, EyeOff } from 'lucide-react';
+import { getApiErrorMessage } from '@/lib/utils/apiError';
 
 function ResetPasswordForm() {
   const router = useRouter();
@@ -75,7 +76,7 @@ function ResetPasswordForm() {
           router.push('/login');
         }, 2000);
       } else {
-        setError(data.error || 'Failed to reset password');
+        setError(getApiErrorMessage(data) || 'Failed to reset password');
       }
     } catch (err: any) {
       setError('Network error. Please check your connection and try again.');
@@ -11,9 +11,11 @@ import { setCredentials } from '@/lib/store/features/authSlice';
 import { Button } from '@/components/ui/button';
 import { Input } from '@/components/ui/input';
 import { Alert } from '@/components/ui/alert';
+import { Checkbox } from '@/components/ui/checkbox';
 import { Eye, EyeOff } from 'lucide-react';
 import { toast } from 'sonner';
 import { useMutex } from '@/lib/hooks/useMutex';
+import { getApiErrorDetails, getApiErrorMessage } from '@/lib/utils/apiError';
 
 export default function LoginForm() {
   const router = useRouter();
@@ -23,6 +25,7 @@ export default function LoginForm() {
   const [email, setEmail] = useState('');
   const [password, setPassword] = useState('');
   const [showPassword, setShowPassword] = useState(false);
+  const [rememberMe, setRememberMe] = useState(false);
   const [errorMessage, setErrorMessage] = useState('');
   const [attemptCount, setAttemptCount] = useState(0);
   const [lockedUntil, setLockedUntil] = useState<string | null>(null);
@@ -103,7 +106,7 @@ export default function LoginForm() {
       setAttemptCount(prev => prev + 1);
 
       try {
-        const result = await login({ email, password }).unwrap();
+        const result = await login({ email, password, remember_me: rememberMe }).unwrap();
 
         // Store user info in Redux (tokens handled by httpOnly cookies)
         // Backend returns { message, data } format, where data contains user info
@@ -129,12 +132,12 @@ export default function LoginForm() {
       } catch (err: any) {
         // Handle error and show message to user
         if (err && 'data' in err && err.data) {
-          const errorData = err.data as any;
-          const errorMsg = errorData.error || 'Login failed. Please check your credentials.';
+          const errorMsg = getApiErrorMessage(err.data) || 'Login failed. Please check your credentials.';
+          const lockedUntil = getApiErrorDetails(err.data)?.locked_until as string | undefined;
 
           // Check if account is locked and has locked_until timestamp
-          if (errorData.locked_until) {
-            setLockedUntil(errorData.locked_until);
+          if (lockedUntil) {
+            setLockedUntil(lockedUntil);
             setErrorMessage(errorMsg);
           } else {
             setLockedUntil(null);
@@ -238,6 +241,18 @@ export default function LoginForm() {
         </div>
       </div>
 
+      <div className="flex items-center gap-2">
+        <Checkbox
+          id="remember-me"
+          checked={rememberMe}
+          onCheckedChange={(checked) => setRememberMe(checked === true)}
+          disabled={isLoading}
+        />
+        <label htmlFor="remember-me" className="text-sm text-muted-foreground cursor-pointer">
+          {t('rememberMe')}
+        </label>
+      </div>
+
       <Button
         type="submit"
         disabled={isLoading || isLocked || isRedirecting || !email || !password}
@@ -13,6 +13,7 @@ import { Input } from '@/components/ui/input';
 import { Check, X, Info } from 'lucide-react';
 import { toast } from 'sonner';
 import { useMutex } from '@/lib/hooks/useMutex';
+import { getApiErrorMessage } from '@/lib/utils/apiError';
 
 export default function RegisterForm() {
   const router = useRouter();
@@ -69,7 +70,7 @@ export default function RegisterForm() {
         // Redirect to dashboard
         router.push('/dashboard');
       } catch (err: any) {
-        const errorMessage = err?.data?.error || err?.message || 'Registration failed';
+        const errorMessage = getApiErrorMessage(err?.data) || err?.message || 'Registration failed';
         toast.error(errorMessage);
       }
     });
@@ -5,7 +5,7 @@ import { useRouter } from "next/navigation";
 import { Edit, Trash2, ArrowLeft } from "lucide-react";
 import { toast } from "sonner";
 
-import { useDeletePermissionMutation } from "@/lib/store/services/permissionsApi";
+import { useDeletePermissionMutation, useGetPermissionUsageQuery } from "@/lib/store/services/permissionsApi";
 import { Button } from "@/components/ui/button";
 import { Dialog, DialogContent, DialogDescription, DialogFooter, DialogHeader, DialogTitle } from "@/components/ui/dialog";
 import LoadingSpinner from "@/components/ui/LoadingSpinner";
@@ -21,10 +21,13 @@ export default function PermissionDetailActions({ permissionId, permissionName,
     const router = useRouter();
     const [showDeleteDialog, setShowDeleteDialog] = useState(false);
     const [deletePermission, { isLoading: isDeleting }] = useDeletePermissionMutation();
+    const { data: usage, isFetching: isLoadingUsage } = useGetPermissionUsageQuery(permissionId, { skip: !showDeleteDialog });
+    const isInUse = !!usage && (usage.roles.length > 0 || usage.users.length > 0);
 
     const handleDelete = async () => {
         try {
-            await deletePermission(permissionId).unwrap();
+            // Permission yang masih dipakai dicabut dari role/user terlebih dahulu (force)
+            await deletePermission({ id: permissionId, force: isInUse }).unwrap();
             toast.success("Permission berhasil dihapus");
             router.push("/access/permissions");
         } catch (error: unknown) {
@@ -76,13 +79,22 @@ export default function PermissionDetailActions({ permissionId, permissionName,
                             <br />
                             <br />
                             Tindakan ini tidak dapat dibatalkan. Permission yang sudah dihapus tidak dapat dikembalikan.
+                            {isInUse && (
+                                <>
+                                    <br />
+                                    <br />
+                                    Permission ini masih digunakan oleh <strong>{usage.roles.length} role</strong> dan{" "}
+                                    <strong>{usage.users.length} user</strong> secara langsung, berdampak pada{" "}
+                                    <strong>{usage.effective_user_count} user</strong>. Semua assignment tersebut akan ikut dicabut.
+                                </>
+                            )}
                         </DialogDescription>
                     </DialogHeader>
                     <DialogFooter>
                         <Button variant="outline" onClick={() => setShowDeleteDialog(false)} disabled={isDeleting}>
                             Batal
                         </Button>
-                        <Button variant="destructive" onClick={handleDelete} disabled={isDeleting}>
+                        <Button variant="destructive" onClick={handleDelete} disabled={isDeleting || isLoadingUsage}>
                             {isDeleting ? (
                                 <>
                                     <LoadingSpinner />
@@ -91,7 +103,7 @@ export default function PermissionDetailActions({ permissionId, permissionName,
                             ) : (
                                 <>
                                     <Trash2 className="mr-2 h-4 w-4" />
-                                    Ya, Hapus
+                                    {isInUse ? "Cabut & Hapus" : "Ya, Hapus"}
                                 </>
                             )}
                         </Button>
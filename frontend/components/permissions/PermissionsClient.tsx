@@ -18,7 +18,7 @@ import { Card } from "@/components/ui/card";
 import { Select, SelectContent, SelectItem, SelectTrigger, SelectValue } from "@/components/ui/select";
 import LoadingSpinner from "@/components/ui/LoadingSpinner";
 import { EmptyState } from "@/components/ui/EmptyState";
-import { useGetPermissionsQuery } from "@/lib/store/services/permissionsApi";
+import { useGetPermissionGroupsQuery, useGetPermissionsQuery } from "@/lib/store/services/permissionsApi";
 import PermissionsDataTable from "./PermissionsDataTable";
 import CreatePermissionButton from "./CreatePermissionButton";
 import type { PaginatedPermissionsResponse, PermissionFilter } from "@/lib/types/permission";
@@ -64,6 +64,19 @@ export default function PermissionsClient({ initialData }: PermissionsClientProp
 
     const { data: permissionsData, isLoading, error, refetch } = useGetPermissionsQuery(queryParams);
 
+    // Group and resource options for the filters, derived from the grouped permission list
+    const { data: permissionGroups } = useGetPermissionGroupsQuery();
+    // "Uncategorized" is a display bucket for permissions without a group_name, so it cannot be filtered on
+    const groupOptions = useMemo(
+        () => (permissionGroups || []).map((group) => group.group_name).filter((name) => name !== "Uncategorized"),
+        [permissionGroups]
+    );
+    const resourceOptions = useMemo(() => {
+        const resources = new Set<string>();
+        (permissionGroups || []).forEach((group) => group.permissions.forEach((permission) => resources.add(permission.resource)));
+        return Array.from(resources).sort();
+    }, [permissionGroups]);
+
     // Use initialData as fallback only for first render before query completes
     const displayData = permissionsData || initialData;
 
@@ -115,6 +128,14 @@ export default function PermissionsClient({ initialData }: PermissionsClientProp
         setFilters((prev) => ({ ...prev, page: 1 }));
     };
 
+    const handleGroupFilterChange = (group: string) => {
+        setFilters((prev) => ({ ...prev, group_name: group === "all" ? undefined : group, page: 1 }));
+    };
+
+    const handleResourceFilterChange = (resource: string) => {
+        setFilters((prev) => ({ ...prev, resource: resource === "all" ? undefined : resource, page: 1 }));
+    };
+
     const handleClearFilters = () => {
         setSearch("");
         setDebouncedSearch("");
@@ -150,6 +171,36 @@ export default function PermissionsClient({ initialData }: PermissionsClientProp
                             <Input placeholder="Cari berdasarkan nama atau kode permission..." value={search} onChange={(e) => setSearch(e.target.value)} className="pl-9" />
                         </div>
 
+                        {/* Group Filter */}
+                        <Select value={filters.group_name || "all"} onValueChange={handleGroupFilterChange}>
+                            <SelectTrigger className="w-full sm:w-[180px]">
+                                <SelectValue />
+                            </SelectTrigger>
+                            <SelectContent>
+                                <SelectItem value="all">Semua Grup</SelectItem>
+                                {groupOptions.map((group) => (
+                                    <SelectItem key={group} value={group}>
+                                        {group}
+                                    </SelectItem>
+                                ))}
+                            </SelectContent>
+                        </Select>
+
+                        {/* Resource Filter */}
+                        <Select value={filters.resource || "all"} onValueChange={handleResourceFilterChange}>
+                            <SelectTrigger className="w-full sm:w-[180px]">
+                                <SelectValue />
+                            </SelectTrigger>
+                            <SelectContent>
+                                <SelectItem value="all">Semua Resource</SelectItem>
+                                {resourceOptions.map((resource) => (
+                                    <SelectItem key={resource} value={resource}>
+                                        {resource}
+                                    </SelectItem>
+                                ))}
+                            </SelectContent>
+                        </Select>
+
                         {/* Status Filter */}
                         <Select value={statusFilter === undefined ? "all" : statusFilter ? "active" : "inactive"} onValueChange={handleStatusFilterChange}>
                             <SelectTrigger className="w-full sm:w-[150px]">
@@ -175,7 +226,7 @@ export default function PermissionsClient({ initialData }: PermissionsClientProp
                         </Select>
 
                         {/* Clear Filters Button */}
-                        {(search || statusFilter !== undefined || typeFilter !== undefined) && (
+                        {(search || statusFilter !== undefined || typeFilter !== undefined || filters.group_name || filters.resource) && (
                             <Button variant="outline" size="sm" onClick={handleClearFilters} className="w-full sm:w-auto">
                                 Reset Filter
                             </Button>
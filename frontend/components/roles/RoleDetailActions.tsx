@@ -3,11 +3,13 @@
 
 import { useState } from "react";
 import { useRouter } from "next/navigation";
-import { Edit, Trash2, ArrowLeft, Key, LayoutGrid } from "lucide-react";
+import { Edit, Trash2, ArrowLeft, Key, LayoutGrid, RotateCcw } from "lucide-react";
 import { toast } from "sonner";
 
-import { useDeleteRoleMutation } from "@/lib/store/services/rolesApi";
+import { useDeleteRoleMutation, useRestoreRoleMutation } from "@/lib/store/services/rolesApi";
 import { Button } from "@/components/ui/button";
+import { Checkbox } from "@/components/ui/checkbox";
+import { Label } from "@/components/ui/label";
 import { ActionButton } from "@/components/rbac";
 import {
   Dialog,
@@ -21,16 +23,29 @@ import {
 interface RoleDetailActionsProps {
   roleId: string;
   roleName: string;
+  isActive?: boolean;
 }
 
-export default function RoleDetailActions({ roleId, roleName }: RoleDetailActionsProps) {
+export default function RoleDetailActions({ roleId, roleName, isActive = true }: RoleDetailActionsProps) {
   const router = useRouter();
   const [deleteRole, { isLoading }] = useDeleteRoleMutation();
+  const [restoreRole, { isLoading: isRestoring }] = useRestoreRoleMutation();
   const [showDeleteDialog, setShowDeleteDialog] = useState(false);
+  const [endAssignments, setEndAssignments] = useState(false);
+
+  const handleRestore = async () => {
+    try {
+      await restoreRole(roleId).unwrap();
+      toast.success("Role berhasil dipulihkan");
+    } catch (error: unknown) {
+      const apiError = error as { data?: { message?: string; error?: string } };
+      toast.error(apiError?.data?.error || apiError?.data?.message || "Gagal memulihkan role");
+    }
+  };
 
   const handleDelete = async () => {
     try {
-      await deleteRole(roleId).unwrap();
+      await deleteRole({ id: roleId, force: endAssignments }).unwrap();
       toast.success("Role berhasil dihapus");
       router.push("/access/roles");
     } catch (error: unknown) {
@@ -38,6 +53,7 @@ export default function RoleDetailActions({ roleId, roleName }: RoleDetailAction
       toast.error(apiError?.data?.error || apiError?.data?.message || "Gagal menghapus role");
     } finally {
       setShowDeleteDialog(false);
+      setEndAssignments(false);
     }
   };
 
@@ -78,16 +94,30 @@ export default function RoleDetailActions({ roleId, roleName }: RoleDetailAction
           <Edit className="mr-2 h-4 w-4" />
           Edit
         </ActionButton>
-        <ActionButton
-          resource="roles"
-          action="DELETE"
-          variant="destructive"
-          hideOnDenied
-          onClick={() => setShowDeleteDialog(true)}
-        >
-          <Trash2 className="mr-2 h-4 w-4" />
-          Hapus
-        </ActionButton>
+        {isActive ? (
+          <ActionButton
+            resource="roles"
+            action="DELETE"
+            variant="destructive"
+            hideOnDenied
+            onClick={() => setShowDeleteDialog(true)}
+          >
+            <Trash2 className="mr-2 h-4 w-4" />
+            Hapus
+          </ActionButton>
+        ) : (
+          <ActionButton
+            resource="roles"
+            action="UPDATE"
+            variant="outline"
+            hideOnDenied
+            onClick={handleRestore}
+            disabled={isRestoring}
+          >
+            <RotateCcw className="mr-2 h-4 w-4" />
+            {isRestoring ? "Memulihkan..." : "Pulihkan"}
+          </ActionButton>
+        )}
       </div>
 
       {/* Delete Confirmation Dialog */}
@@ -100,10 +130,20 @@ export default function RoleDetailActions({ roleId, roleName }: RoleDetailAction
               <br />
               <br />
               <span className="text-destructive">
-                Tindakan ini tidak dapat dibatalkan. Role akan dihapus permanen dari sistem.
+                Role akan dinonaktifkan dan dapat dipulihkan kembali dari halaman detail role.
               </span>
             </DialogDescription>
           </DialogHeader>
+          <div className="flex items-start gap-2">
+            <Checkbox
+              id="end-assignments"
+              checked={endAssignments}
+              onCheckedChange={(checked) => setEndAssignments(checked === true)}
+            />
+            <Label htmlFor="end-assignments" className="text-sm font-normal leading-snug">
+              Akhiri assignment user yang masih aktif pada role ini (assignment tidak ikut dipulihkan)
+            </Label>
+          </div>
           <DialogFooter>
             <Button variant="outline" onClick={() => setShowDeleteDialog(false)} disabled={isLoading}>
               Batal
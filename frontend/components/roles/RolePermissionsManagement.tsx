@@ -37,6 +37,7 @@ import { AssignPermissionDialog } from "./AssignPermissionDialog";
 import {
   useGetRoleWithPermissionsQuery,
   useRevokePermissionFromRoleMutation,
+  useRevokeAllPermissionsFromRoleMutation,
 } from "@/lib/store/services/rolesApi";
 import { toast } from "sonner";
 import type { FetchBaseQueryError } from "@reduxjs/toolkit/query";
@@ -83,6 +84,7 @@ export default function RolePermissionsManagement({
 
   // Revoke permission mutation
   const [revokePermission] = useRevokePermissionFromRoleMutation();
+  const [revokeAllPermissions] = useRevokeAllPermissionsFromRoleMutation();
   const [isRevoking, setIsRevoking] = useState(false);
 
   // Get assigned permissions
@@ -202,6 +204,23 @@ export default function RolePermissionsManagement({
 
     setIsRevoking(true);
 
+    // Everything selected: revoke in one request instead of one DELETE per permission
+    if (selectedPermissionIds.length === assignedPermissions.length) {
+      try {
+        const { revoked } = await revokeAllPermissions(roleId).unwrap();
+        toast.success(`${revoked} permission(s) berhasil dicabut dari role`);
+        setRevokeDialogOpen(false);
+        setSelectedPermissionIds([]);
+        refetch();
+      } catch (err) {
+        console.error("Failed to revoke all permissions:", err);
+        toast.error(`Gagal mencabut permissions: ${getErrorMessage(err as FetchBaseQueryError | SerializedError)}`);
+      } finally {
+        setIsRevoking(false);
+      }
+      return;
+    }
+
     try {
       // Create array of promises for all revokes (using assignment_id)
       const revokePromises = selectedPermissionIds.map(assignmentId =>
@@ -26,6 +26,7 @@ import { Alert } from "@/components/ui/alert";
 import { useGetPositionsQuery } from "@/lib/store/services/organizationApi";
 import { useAssignPositionToUserMutation } from "@/lib/store/services/usersApi";
 import { toast } from "sonner";
+import { getApiErrorMessage } from "@/lib/utils/apiError";
 
 interface AssignPositionDialogProps {
   userId: string;
@@ -104,7 +105,7 @@ export function AssignPositionDialog({ userId, assignedPositionIds = [] }: Assig
         <div className="space-y-4 py-4">
           {assignError && (
             <Alert variant="error">
-              {(assignError as any)?.data?.error || "Gagal assign posisi"}
+              {getApiErrorMessage((assignError as any)?.data) || "Gagal assign posisi"}
             </Alert>
           )}
 
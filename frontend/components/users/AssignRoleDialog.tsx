@@ -12,6 +12,7 @@ import {
   DialogTrigger,
 } from "@/components/ui/dialog";
 import { Button } from "@/components/ui/button";
+import { Input } from "@/components/ui/input";
 import { Label } from "@/components/ui/label";
 import {
   Select,
@@ -24,6 +25,7 @@ import { Alert } from "@/components/ui/alert";
 import { useGetRolesQuery } from "@/lib/store/services/rolesApi";
 import { useAssignRoleToUserMutation } from "@/lib/store/services/usersApi";
 import { toast } from "sonner";
+import { getApiErrorMessage } from "@/lib/utils/apiError";
 
 interface AssignRoleDialogProps {
   userId: string;
@@ -33,6 +35,7 @@ interface AssignRoleDialogProps {
 export function AssignRoleDialog({ userId, assignedRoleIds = [] }: AssignRoleDialogProps) {
   const [open, setOpen] = useState(false);
   const [selectedRoleId, setSelectedRoleId] = useState<string>("");
+  const [effectiveFrom, setEffectiveFrom] = useState<string>("");
 
   // Fetch available roles
   const { data: rolesData, isLoading: isLoadingRoles } = useGetRolesQuery();
@@ -57,13 +60,20 @@ export function AssignRoleDialog({ userId, assignedRoleIds = [] }: AssignRoleDia
         userId,
         data: {
           role_id: selectedRoleId,
+          // Empty means effective immediately; a future date schedules the assignment
+          effective_from: effectiveFrom ? new Date(effectiveFrom).toISOString() : undefined,
         },
       }).unwrap();
 
-      toast.success(`Role "${roleName}" berhasil di-assign`);
+      toast.success(
+        effectiveFrom
+          ? `Role "${roleName}" dijadwalkan mulai ${new Date(effectiveFrom).toLocaleDateString("id-ID")}`
+          : `Role "${roleName}" berhasil di-assign`
+      );
 
       // Reset and close dialog
       setSelectedRoleId("");
+      setEffectiveFrom("");
       setOpen(false);
     } catch (err) {
       console.error("Failed to assign role:", err);
@@ -90,7 +100,7 @@ export function AssignRoleDialog({ userId, assignedRoleIds = [] }: AssignRoleDia
         <div className="space-y-4 py-4">
           {assignError && (
             <Alert variant="error">
-              {(assignError as any)?.data?.error || "Gagal assign role"}
+              {getApiErrorMessage((assignError as any)?.data) || "Gagal assign role"}
             </Alert>
           )}
 
@@ -122,6 +132,19 @@ export function AssignRoleDialog({ userId, assignedRoleIds = [] }: AssignRoleDia
               </Select>
             )}
           </div>
+
+          <div className="space-y-2">
+            <Label htmlFor="effectiveFrom">Mulai Berlaku</Label>
+            <Input
+              id="effectiveFrom"
+              type="date"
+              value={effectiveFrom}
+              onChange={(e) => setEffectiveFrom(e.target.value)}
+            />
+            <p className="text-xs text-muted-foreground">
+              Kosongkan agar langsung berlaku, atau pilih tanggal mendatang untuk menjadwalkan role
+            </p>
+          </div>
         </div>
 
         <DialogFooter>
@@ -10,6 +10,7 @@ import {
   useLazyGetUserPermissionsQuery,
 } from '@/lib/store/services/accessApi';
 import { ModuleAccessResponse } from '@/lib/types/access';
+import { usePermissionEvents } from '@/lib/hooks/usePermissionEvents';
 import LoadingSpinner from '@/components/ui/LoadingSpinner';
 
 // Routes that don't require module access check (always accessible when authenticated)
@@ -72,6 +73,9 @@ export default function ProtectedRoute({ children }: ProtectedRouteProps) {
   const [fetchModules, { isLoading: isLoadingModules }] = useLazyGetUserModulesQuery();
   const [fetchPermissions, { isLoading: isLoadingPermissions }] = useLazyGetUserPermissionsQuery();
 
+  // Re-fetch RBAC data as soon as an admin changes this user's access
+  usePermissionEvents(isMounted && isAuthenticated && !isAuthLoading);
+
   // Only render after component is mounted on client to avoid hydration mismatch
   useEffect(() => {
     setIsMounted(true);
@@ -9,6 +9,7 @@
 export { usePermission, usePermissions } from './usePermission';
 export { useModuleAccess, useModulesAccess } from './useModuleAccess';
 export { useRBAC, useInitializeRBAC } from './useRBAC';
+export { usePermissionEvents } from './usePermissionEvents';
 
 // Other hooks
 export { useBreadcrumbs } from './useBreadcrumbs';
@@ -0,0 +1,65 @@
+// lib/hooks/usePermissionEvents.ts
+/**
+ * usePermissionEvents Hook
+ *
+ * Subscribes to the backend permission-change stream (SSE, GET /access/events).
+ * When an admin changes the current user's roles, positions or permissions, the
+ * backend invalidates the user's permission cache and pushes an event; this hook
+ * then invalidates the cached modules/permissions so menus update without a reload.
+ */
+
+'use client';
+
+import { useEffect } from 'react';
+import { useAppDispatch } from '@/lib/store/hooks';
+import { API_BASE_URL } from '@/lib/store/baseApi';
+import { accessApi } from '@/lib/store/services/accessApi';
+
+// Delay before reopening a stream the browser gave up on (e.g. 401 after token expiry)
+const RECONNECT_DELAY_MS = 10_000;
+
+/**
+ * Keep RBAC data in sync with server-side permission changes
+ *
+ * @param enabled - Only subscribe while the user is authenticated
+ */
+export function usePermissionEvents(enabled: boolean) {
+  const dispatch = useAppDispatch();
+
+  useEffect(() => {
+    if (!enabled || typeof window === 'undefined' || typeof EventSource === 'undefined') {
+      return;
+    }
+
+    let source: EventSource | null = null;
+    let reconnectTimer: ReturnType<typeof setTimeout> | null = null;
+    let disposed = false;
+
+    const connect = () => {
+      source = new EventSource(`${API_BASE_URL}/access/events`, { withCredentials: true });
+
+      source.addEventListener('permissions_changed', () => {
+        dispatch(accessApi.util.invalidateTags(['UserModules', 'UserPermissions']));
+      });
+
+      source.onerror = () => {
+        // EventSource retries dropped connections itself; it only closes for good on
+        // an error response, so reopen it later (the session may have been refreshed)
+        if (source?.readyState === EventSource.CLOSED && !disposed) {
+          source.close();
+          reconnectTimer = setTimeout(connect, RECONNECT_DELAY_MS);
+        }
+      };
+    };
+
+    connect();
+
+    return () => {
+      disposed = true;
+      if (reconnectTimer) {
+        clearTimeout(reconnectTimer);
+      }
+      source?.close();
+    };
+  }, [enabled, dispatch]);
+}
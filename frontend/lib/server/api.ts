@@ -5,6 +5,7 @@
  */
 
 import { cookies } from 'next/headers';
+import { getApiErrorMessage } from '@/lib/utils/apiError';
 
 const API_BASE_URL = process.env.NEXT_PUBLIC_API_URL || 'http://localhost:8080/api/v1';
 
@@ -73,7 +74,7 @@ export async function serverFetch<T>(
 
     if (!response.ok) {
       const errorData = await response.json().catch(() => ({ message: response.statusText }));
-      const errorMessage = errorData.message || errorData.error || `HTTP ${response.status}`;
+      const errorMessage = getApiErrorMessage(errorData) || `HTTP ${response.status}`;
 
       // Detect authentication errors for special handling
       const isAuthError =
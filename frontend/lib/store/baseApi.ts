@@ -16,7 +16,7 @@ import type {
   FetchArgs,
   FetchBaseQueryError,
 } from '@reduxjs/toolkit/query';
-import { getCSRFToken } from '@/lib/utils/csrf';
+import { ensureCSRFToken, getCSRFToken } from '@/lib/utils/csrf';
 import { Mutex } from '@/lib/utils/mutex';
 
 // Standardized API Base URL - use this everywhere
@@ -29,6 +29,9 @@ const tokenRefreshMutex = new Mutex();
 // Flag to track if we're currently redirecting to prevent multiple redirects
 let isRedirecting = false;
 
+// Public auth mutations that run without a session, so there is no CSRF token to fetch
+const PUBLIC_AUTH_MUTATIONS = new Set(['login', 'register', 'refreshToken']);
+
 /**
  * Base query with httpOnly cookie support and CSRF protection
  * This is the foundation for all authenticated API calls
@@ -36,10 +39,14 @@ let isRedirecting = false;
 export const baseQuery = fetchBaseQuery({
   baseUrl: API_BASE_URL,
   credentials: 'include', // CRITICAL: Send httpOnly cookies with every request
-  prepareHeaders: (headers) => {
+  prepareHeaders: async (headers, { type, endpoint }) => {
     // Inject CSRF token for state-changing requests (POST, PUT, PATCH, DELETE)
-    // CSRF token is readable from cookie (NOT httpOnly) so JavaScript can access it
-    const csrfToken = getCSRFToken();
+    // CSRF token is readable from cookie (NOT httpOnly) so JavaScript can access it.
+    // Authenticated mutations fetch a fresh token first if the cookie is missing (e.g. after reload)
+    const csrfToken =
+      type === 'mutation' && !PUBLIC_AUTH_MUTATIONS.has(endpoint)
+        ? await ensureCSRFToken(API_BASE_URL)
+        : getCSRFToken();
     if (csrfToken) {
       headers.set('X-CSRF-Token', csrfToken);
     }
@@ -11,10 +11,18 @@ import { baseQueryWithReauth } from '../baseApi';
 import {
   ModuleAccessResponse,
   UserPermissionsResponse,
+  MyAccessResponse,
   PermissionCheckRequest,
   PermissionCheckResponse,
+  UserPermissionCheckResponse,
+  CodePermissionCheckRequest,
+  CodePermissionCheckResponse,
   BatchPermissionCheckRequest,
   BatchPermissionCheckResponse,
+  CapableUsersRequest,
+  CapableUsersResponse,
+  PermissionResolutionInfo,
+  MyAccessChangesResponse,
 } from '@/lib/types/access';
 
 export const accessApi = createApi({
@@ -40,6 +48,15 @@ export const accessApi = createApi({
       providesTags: ['UserPermissions'],
     }),
 
+    /**
+     * Get modules, permissions, roles and positions in one request
+     * The response carries an ETag, so the browser revalidates instead of re-downloading
+     */
+    getMyAccess: builder.query<MyAccessResponse, void>({
+      query: () => '/access/me',
+      providesTags: ['UserModules', 'UserPermissions'],
+    }),
+
     /**
      * Check a single permission for the current user
      */
@@ -51,6 +68,32 @@ export const accessApi = createApi({
       }),
     }),
 
+    /**
+     * Check a single permission for the current user by its seeded code
+     */
+    checkPermissionByCode: builder.mutation<CodePermissionCheckResponse, CodePermissionCheckRequest>({
+      query: (request) => ({
+        url: '/access/check-code',
+        method: 'POST',
+        body: request,
+      }),
+    }),
+
+    /**
+     * Check a single permission for another user (admin only)
+     * Resolves live, without impersonating the user
+     */
+    checkUserPermission: builder.mutation<
+      UserPermissionCheckResponse,
+      { userId: string; check: PermissionCheckRequest }
+    >({
+      query: ({ userId, check }) => ({
+        url: `/users/${userId}/access/check`,
+        method: 'POST',
+        body: check,
+      }),
+    }),
+
     /**
      * Check multiple permissions in a single request
      * More efficient than multiple single checks
@@ -63,6 +106,35 @@ export const accessApi = createApi({
       }),
     }),
 
+    /**
+     * List users who currently hold a permission through any layer (admin only)
+     */
+    getCapableUsers: builder.query<CapableUsersResponse, CapableUsersRequest>({
+      query: (params) => ({
+        url: '/access/capable',
+        params,
+      }),
+    }),
+
+    /**
+     * Get the server's permission resolution rules (layer order, scope hierarchy, priorities)
+     */
+    getResolutionInfo: builder.query<PermissionResolutionInfo, void>({
+      query: () => '/access/resolution-info',
+    }),
+
+    /**
+     * Get recent changes to the current user's access, to explain changed menus
+     * @param days - How many days back to look (1-90, server default 30)
+     */
+    getMyAccessChanges: builder.query<MyAccessChangesResponse, { days?: number } | void>({
+      query: (params) => ({
+        url: '/access/my-changes',
+        params: params ?? undefined,
+      }),
+      providesTags: ['UserPermissions'],
+    }),
+
     /**
      * Get cache statistics (admin only)
      */
@@ -97,12 +169,19 @@ export const accessApi = createApi({
 export const {
   useGetUserModulesQuery,
   useGetUserPermissionsQuery,
+  useGetMyAccessQuery,
   useCheckPermissionMutation,
+  useCheckPermissionByCodeMutation,
+  useCheckUserPermissionMutation,
   useCheckPermissionBatchMutation,
+  useGetCapableUsersQuery,
+  useGetResolutionInfoQuery,
+  useGetMyAccessChangesQuery,
   useGetCacheStatsQuery,
   useInvalidateUserCacheMutation,
   useInvalidateAllCacheMutation,
   // Lazy queries for manual triggering
   useLazyGetUserModulesQuery,
   useLazyGetUserPermissionsQuery,
+  useLazyGetMyAccessQuery,
 } = accessApi;
@@ -25,6 +25,7 @@ import {
   AuthResponse,
   User,
   ChangePasswordRequest,
+  UpdateProfileRequest,
 } from '@/lib/types/auth';
 
 /**
@@ -91,6 +92,16 @@ export const authApi = createApi({
       providesTags: ['User'],
     }),
 
+    // Update own profile mutation (protected endpoint - uses token refresh)
+    updateProfile: builder.mutation<{ data: User }, UpdateProfileRequest>({
+      query: (profile) => ({
+        url: '/auth/me',
+        method: 'PATCH',
+        body: profile,
+      }),
+      invalidatesTags: ['User'],
+    }),
+
     // Change password mutation (protected endpoint - uses token refresh)
     changePassword: builder.mutation<{ message: string }, ChangePasswordRequest>({
       query: (passwords) => ({
@@ -127,5 +138,6 @@ export const {
   useRefreshTokenMutation,
   useGetCurrentUserQuery,
   useChangePasswordMutation,
+  useUpdateProfileMutation,
   useLogoutMutation,
 } = authApi;
@@ -24,6 +24,7 @@ import {
   AssignModuleToRoleRequest,
   GrantModuleAccessToUserRequest,
 } from '@/lib/types/module';
+import { ModuleAccessResponse } from '@/lib/types/access';
 
 export const modulesApi = createApi({
   reducerPath: 'modulesApi',
@@ -115,6 +116,11 @@ export const modulesApi = createApi({
         { type: 'RoleModuleAccess', id: 'LIST' },
       ],
     }),
+    // Sidebar menu a user would get holding only this role
+    previewRoleModules: builder.query<ModuleAccessResponse[], string>({
+      query: (roleId) => `/roles/${roleId}/modules/preview`,
+      providesTags: (result, error, roleId) => [{ type: 'RoleModuleAccess', id: roleId }],
+    }),
     assignModuleToRole: builder.mutation<
       RoleModuleAccess,
       { roleId: string; data: AssignModuleToRoleRequest }
@@ -222,6 +228,7 @@ export const {
   useUpdateModuleMutation,
   useDeleteModuleMutation,
   useGetRoleModuleAccessesQuery,
+  usePreviewRoleModulesQuery,
   useAssignModuleToRoleMutation,
   useRevokeModuleFromRoleMutation,
   useGetUserModuleAccessQuery,
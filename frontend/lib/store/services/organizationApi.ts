@@ -29,6 +29,7 @@ import {
   CreatePositionRequest,
   UpdatePositionRequest,
   PositionFilter,
+  PositionHolder,
   WorkflowRule,
   WorkflowRuleListResponse,
   PaginatedWorkflowRulesResponse,
@@ -37,9 +38,11 @@ import {
   WorkflowRuleFilter,
   WorkflowTypesResponse,
   WorkflowRuleLookupParams,
+  WorkflowApprovalChainResponse,
   WorkflowType,
   BulkCreateWorkflowRulesRequest,
   BulkCreateWorkflowRulesResult,
+  CopyWorkflowRulesRequest,
 } from '@/lib/types/organization';
 
 export const organizationApi = createApi({
@@ -180,6 +183,21 @@ export const organizationApi = createApi({
       ],
     }),
 
+    // Reparent a department with its subtree; parent_id null moves it to the root
+    moveDepartment: builder.mutation<Department, { id: string; parent_id: string | null }>({
+      query: ({ id, parent_id }) => ({
+        url: `/departments/${id}/move`,
+        method: 'PATCH',
+        body: { parent_id },
+      }),
+      invalidatesTags: (result, error, { id }) => [
+        { type: 'Department', id },
+        { type: 'DepartmentDetail', id },
+        { type: 'Department', id: 'LIST' },
+        { type: 'DepartmentTree', id: 'TREE' },
+      ],
+    }),
+
     deleteDepartment: builder.mutation<void, string>({
       query: (id) => ({
         url: `/departments/${id}`,
@@ -227,11 +245,26 @@ export const organizationApi = createApi({
           : [{ type: 'Position', id: 'LIST' }],
     }),
 
+    // Suggests unused position codes for a prefix (PREFIX, PREFIX_1, ...)
+    getAvailablePositionCodes: builder.query<{ codes: string[] }, { prefix: string; count?: number }>({
+      query: (params) => ({ url: '/positions/available-codes', params }),
+      providesTags: [{ type: 'Position', id: 'LIST' }],
+    }),
+
     getPositionById: builder.query<Position, string>({
       query: (id) => `/positions/${id}`,
       providesTags: (result, error, id) => [{ type: 'PositionDetail', id }],
     }),
 
+    // Users assigned to a position; include_ended adds past and inactive assignments
+    getPositionHolders: builder.query<{ data: PositionHolder[] }, { id: string; include_ended?: boolean }>({
+      query: ({ id, include_ended }) => ({
+        url: `/positions/${id}/users`,
+        params: include_ended ? { include_ended } : undefined,
+      }),
+      providesTags: (result, error, { id }) => [{ type: 'PositionDetail', id }],
+    }),
+
     createPosition: builder.mutation<Position, CreatePositionRequest>({
       query: (body) => ({
         url: '/positions',
@@ -313,6 +346,15 @@ export const organizationApi = createApi({
       },
     }),
 
+    getApprovalChain: builder.query<WorkflowApprovalChainResponse, WorkflowRuleLookupParams>({
+      query: ({ position_id, workflow_type }) => {
+        const params = new URLSearchParams();
+        params.append('position_id', position_id);
+        params.append('workflow_type', workflow_type);
+        return `/workflow-rules/approval-chain?${params.toString()}`;
+      },
+    }),
+
     createWorkflowRule: builder.mutation<WorkflowRule, CreateWorkflowRuleRequest>({
       query: (body) => ({
         url: '/workflow-rules',
@@ -348,10 +390,21 @@ export const organizationApi = createApi({
     }),
 
     bulkCreateWorkflowRules: builder.mutation<BulkCreateWorkflowRulesResult, BulkCreateWorkflowRulesRequest>({
+      // One key per submission: the re-sent request after a token refresh replays instead of duplicating
       query: (body) => ({
         url: '/workflow-rules/bulk',
         method: 'POST',
         body,
+        headers: { 'Idempotency-Key': crypto.randomUUID() },
+      }),
+      invalidatesTags: [{ type: 'WorkflowRule', id: 'LIST' }],
+    }),
+    copyWorkflowRules: builder.mutation<BulkCreateWorkflowRulesResult, CopyWorkflowRulesRequest>({
+      query: (body) => ({
+        url: '/workflow-rules/copy',
+        method: 'POST',
+        body,
+        headers: { 'Idempotency-Key': crypto.randomUUID() },
       }),
       invalidatesTags: [{ type: 'WorkflowRule', id: 'LIST' }],
     }),
@@ -372,11 +425,14 @@ export const {
   useGetDepartmentTreeQuery,
   useCreateDepartmentMutation,
   useUpdateDepartmentMutation,
+  useMoveDepartmentMutation,
   useDeleteDepartmentMutation,
   useGetAvailableDepartmentCodesQuery,
   // Positions
   useGetPositionsQuery,
   useGetPositionByIdQuery,
+  useGetPositionHoldersQuery,
+  useGetAvailablePositionCodesQuery,
   useCreatePositionMutation,
   useUpdatePositionMutation,
   useDeletePositionMutation,
@@ -385,8 +441,10 @@ export const {
   useGetWorkflowRuleByIdQuery,
   useGetWorkflowTypesQuery,
   useGetWorkflowRuleLookupQuery,
+  useGetApprovalChainQuery,
   useCreateWorkflowRuleMutation,
   useUpdateWorkflowRuleMutation,
   useDeleteWorkflowRuleMutation,
   useBulkCreateWorkflowRulesMutation,
+  useCopyWorkflowRulesMutation,
 } = organizationApi;
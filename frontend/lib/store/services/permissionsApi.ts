@@ -15,6 +15,10 @@ import {
   UpdatePermissionRequest,
   PermissionFilter,
   PaginatedPermissionsResponse,
+  PermissionGroupResponse,
+  UpdatePermissionGroupRequest,
+  MovePermissionGroupRequest,
+  PermissionUsageResponse,
   EnumOption,
 } from '@/lib/types/permission';
 
@@ -60,13 +64,42 @@ export const permissionsApi = createApi({
         { type: 'PermissionDetail', id },
       ],
     }),
-    deletePermission: builder.mutation<void, string>({
-      query: (id) => ({
+    getPermissionUsage: builder.query<PermissionUsageResponse, string>({
+      query: (id) => `/permissions/${id}/usage`,
+      providesTags: (result, error, id) => [{ type: 'PermissionDetail', id }],
+    }),
+    deletePermission: builder.mutation<void, { id: string; force?: boolean }>({
+      query: ({ id, force }) => ({
         url: `/permissions/${id}`,
         method: 'DELETE',
+        params: force ? { force: true } : undefined,
       }),
       invalidatesTags: ['Permission'],
     }),
+    getPermissionGroups: builder.query<PermissionGroupResponse[], void>({
+      query: () => '/permissions/groups',
+      transformResponse: (response: { data: PermissionGroupResponse[] }) => response.data,
+      providesTags: [{ type: 'Permission', id: 'LIST' }],
+    }),
+    updatePermissionGroup: builder.mutation<PermissionGroupResponse, { name: string; data: UpdatePermissionGroupRequest }>({
+      query: ({ name, data }) => ({
+        url: `/permissions/groups/${encodeURIComponent(name)}`,
+        method: 'PUT',
+        body: data,
+      }),
+      invalidatesTags: ['Permission', 'PermissionDetail'],
+    }),
+    movePermissionToGroup: builder.mutation<Permission, { id: string; data: MovePermissionGroupRequest }>({
+      query: ({ id, data }) => ({
+        url: `/permissions/${id}/group`,
+        method: 'PUT',
+        body: data,
+      }),
+      invalidatesTags: (result, error, { id }) => [
+        { type: 'Permission', id: 'LIST' },
+        { type: 'PermissionDetail', id },
+      ],
+    }),
     // Enum endpoints
     getPermissionScopes: builder.query<EnumOption[], void>({
       query: () => '/permissions/scopes',
@@ -85,6 +118,10 @@ export const {
   useCreatePermissionMutation,
   useUpdatePermissionMutation,
   useDeletePermissionMutation,
+  useGetPermissionUsageQuery,
+  useGetPermissionGroupsQuery,
+  useUpdatePermissionGroupMutation,
+  useMovePermissionToGroupMutation,
   useGetPermissionScopesQuery,
   useGetPermissionActionsQuery,
 } = permissionsApi;
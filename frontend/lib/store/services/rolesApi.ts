@@ -19,6 +19,8 @@ import {
   RoleFilter,
   AssignPermissionToRoleRequest,
   RolePermission,
+  RoleExport,
+  RoleImportResult,
 } from '@/lib/types/role';
 
 export const rolesApi = createApi({
@@ -41,6 +43,7 @@ export const rolesApi = createApi({
         if (filters.hierarchy_level !== undefined) params.append('hierarchy_level', filters.hierarchy_level.toString());
         if (filters.sort_by) params.append('sort_by', filters.sort_by);
         if (filters.sort_order) params.append('sort_order', filters.sort_order);
+        if (filters.with_counts) params.append('with_counts', 'true');
 
         return `/roles${params.toString() ? `?${params.toString()}` : ''}`;
       },
@@ -53,6 +56,12 @@ export const rolesApi = createApi({
           : [{ type: 'Role', id: 'LIST' }],
     }),
 
+    // Suggest unused role codes for a prefix (PREFIX, PREFIX_1, ...)
+    getAvailableRoleCodes: builder.query<{ codes: string[] }, { prefix: string; count?: number }>({
+      query: (params) => ({ url: '/roles/available-codes', params }),
+      providesTags: [{ type: 'Role', id: 'LIST' }],
+    }),
+
     // Get single role by ID
     getRoleById: builder.query<Role, string>({
       query: (id) => `/roles/${id}`,
@@ -68,6 +77,26 @@ export const rolesApi = createApi({
       ],
     }),
 
+    // Export a role's complete definition for another environment
+    exportRole: builder.query<RoleExport, string>({
+      query: (id) => `/roles/${id}/export`,
+      providesTags: (result, error, id) => [
+        { type: 'RoleDetail', id },
+        { type: 'RolePermissions', id },
+      ],
+    }),
+
+    // Recreate an exported role; unresolved codes fail with 422 unless skip_unresolved is set
+    importRole: builder.mutation<RoleImportResult, { data: RoleExport; skip_unresolved?: boolean }>({
+      query: ({ data, skip_unresolved }) => ({
+        url: '/roles/import',
+        method: 'POST',
+        params: skip_unresolved ? { skip_unresolved } : undefined,
+        body: data,
+      }),
+      invalidatesTags: [{ type: 'Role', id: 'LIST' }],
+    }),
+
     // Create new role
     createRole: builder.mutation<Role, CreateRoleRequest>({
       query: (body) => ({
@@ -92,11 +121,25 @@ export const rolesApi = createApi({
       ],
     }),
 
-    // Delete role
-    deleteRole: builder.mutation<void, string>({
-      query: (id) => ({
+    // Delete role (soft delete). force end-dates the role's current user assignments
+    deleteRole: builder.mutation<void, { id: string; force?: boolean }>({
+      query: ({ id, force }) => ({
         url: `/roles/${id}`,
         method: 'DELETE',
+        params: force ? { force: true } : undefined,
+      }),
+      invalidatesTags: (result, error, { id }) => [
+        { type: 'Role', id },
+        { type: 'RoleDetail', id },
+        { type: 'Role', id: 'LIST' },
+      ],
+    }),
+
+    // Restore a soft-deleted role
+    restoreRole: builder.mutation<Role, string>({
+      query: (id) => ({
+        url: `/roles/${id}/restore`,
+        method: 'POST',
       }),
       invalidatesTags: (result, error, id) => [
         { type: 'Role', id },
@@ -147,16 +190,42 @@ export const rolesApi = createApi({
         }
       },
     }),
+
+    // Revoke every permission from role in one request (single cache flush on the server)
+    revokeAllPermissionsFromRole: builder.mutation<{ message: string; revoked: number }, string>({
+      query: (roleId) => ({
+        url: `/roles/${roleId}/permissions`,
+        method: 'DELETE',
+      }),
+      invalidatesTags: (result, error, roleId) => [
+        { type: 'RolePermissions', id: roleId },
+        { type: 'RoleDetail', id: roleId },
+      ],
+      // Invalidate accessApi to refresh user's RBAC cache
+      async onQueryStarted(arg, { dispatch, queryFulfilled }) {
+        try {
+          await queryFulfilled;
+          dispatch(accessApi.util.invalidateTags(['UserModules', 'UserPermissions']));
+        } catch {
+          // Error is handled by invalidatesTags
+        }
+      },
+    }),
   }),
 });
 
 export const {
   useGetRolesQuery,
   useGetRoleByIdQuery,
+  useGetAvailableRoleCodesQuery,
   useGetRoleWithPermissionsQuery,
+  useLazyExportRoleQuery,
   useCreateRoleMutation,
+  useImportRoleMutation,
   useUpdateRoleMutation,
   useDeleteRoleMutation,
+  useRestoreRoleMutation,
   useAssignPermissionToRoleMutation,
   useRevokePermissionFromRoleMutation,
+  useRevokeAllPermissionsFromRoleMutation,
 } = rolesApi;
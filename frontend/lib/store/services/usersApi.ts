@@ -19,6 +19,9 @@ import {
   AssignPositionToUserRequest,
   UserRoleResponse,
   UserPositionResponse,
+  UserPositionHistoryResponse,
+  TransferUserAssignmentsRequest,
+  UserAssignmentTransferResponse,
 } from '@/lib/types/user';
 
 export const usersApi = createApi({
@@ -36,7 +39,13 @@ export const usersApi = createApi({
         if (filters.page) params.append('page', filters.page.toString());
         if (filters.page_size) params.append('page_size', filters.page_size.toString());
         if (filters.search) params.append('search', filters.search);
-        if (filters.role_id) params.append('role_id', filters.role_id);
+        if (filters.role_id) {
+          const roleIds = Array.isArray(filters.role_id) ? filters.role_id : [filters.role_id];
+          roleIds.forEach((roleId) => params.append('role_id', roleId));
+        }
+        if (filters.position_id) params.append('position_id', filters.position_id);
+        if (filters.department_id) params.append('department_id', filters.department_id);
+        if (filters.has_no_role) params.append('has_no_role', 'true');
         if (filters.is_active !== undefined) params.append('is_active', filters.is_active.toString());
         if (filters.sort_by) params.append('sort_by', filters.sort_by);
         if (filters.sort_order) params.append('sort_order', filters.sort_order);
@@ -123,6 +132,12 @@ export const usersApi = createApi({
       providesTags: (result, error, userId) => [{ type: 'UserRoles', id: userId }],
     }),
 
+    // Get role assignments that take effect in the future
+    getScheduledUserRoles: builder.query<UserRoleResponse[], string>({
+      query: (userId) => `/users/${userId}/roles/scheduled`,
+      providesTags: (result, error, userId) => [{ type: 'UserRoles', id: userId }],
+    }),
+
     // Assign role to user
     assignRoleToUser: builder.mutation<UserRoleResponse, { userId: string; data: AssignRoleToUserRequest }>({
       query: ({ userId, data }) => ({
@@ -154,6 +169,12 @@ export const usersApi = createApi({
       providesTags: (result, error, userId) => [{ type: 'UserPositions', id: userId }],
     }),
 
+    // Get full position history (ended assignments and gaps included)
+    getUserPositionHistory: builder.query<UserPositionHistoryResponse, string>({
+      query: (userId) => `/users/${userId}/positions/history`,
+      providesTags: (result, error, userId) => [{ type: 'UserPositions', id: userId }],
+    }),
+
     // Assign position to user
     assignPositionToUser: builder.mutation<UserPositionResponse, { userId: string; data: AssignPositionToUserRequest }>({
       query: ({ userId, data }) => ({
@@ -178,6 +199,23 @@ export const usersApi = createApi({
         { type: 'UserDetail', id: userId },
       ],
     }),
+
+    // Transfer all active roles and positions to another user (offboarding)
+    transferUserAssignments: builder.mutation<UserAssignmentTransferResponse, { userId: string; data: TransferUserAssignmentsRequest }>({
+      query: ({ userId, data }) => ({
+        url: `/users/${userId}/transfer`,
+        method: 'POST',
+        body: data,
+      }),
+      invalidatesTags: (result, error, { userId, data }) => [
+        { type: 'UserRoles', id: userId },
+        { type: 'UserPositions', id: userId },
+        { type: 'UserDetail', id: userId },
+        { type: 'UserRoles', id: data.target_user_id },
+        { type: 'UserPositions', id: data.target_user_id },
+        { type: 'UserDetail', id: data.target_user_id },
+      ],
+    }),
   }),
 });
 
@@ -188,9 +226,12 @@ export const {
   useUpdateUserMutation,
   useDeleteUserMutation,
   useGetUserRolesQuery,
+  useGetScheduledUserRolesQuery,
   useAssignRoleToUserMutation,
   useRevokeRoleFromUserMutation,
   useGetUserPositionsQuery,
+  useGetUserPositionHistoryQuery,
   useAssignPositionToUserMutation,
   useRevokePositionFromUserMutation,
+  useTransferUserAssignmentsMutation,
 } = usersApi;
@@ -44,6 +44,13 @@ export interface PermissionCheckRequest {
   scope?: PermissionScope;
 }
 
+/**
+ * Permission check by seeded permission code (e.g. PERM_EMPLOYEES_EXPORT)
+ */
+export interface CodePermissionCheckRequest {
+  code: string;
+}
+
 /**
  * Batch permission check request
  */
@@ -65,6 +72,23 @@ export interface PermissionCheckResponse {
   source_name?: string;
 }
 
+/**
+ * Permission check run for another user (admin help-desk view)
+ */
+export interface UserPermissionCheckResponse extends PermissionCheckResponse {
+  user_id: string;
+}
+
+/**
+ * Permission check by code, with the resource/action/scope the code resolved to
+ */
+export interface CodePermissionCheckResponse extends PermissionCheckResponse {
+  code: string;
+  resource: string;
+  action: PermissionAction;
+  scope?: PermissionScope;
+}
+
 /**
  * Batch permission check response
  */
@@ -72,6 +96,90 @@ export interface BatchPermissionCheckResponse {
   results: Record<string, PermissionCheckResponse>;
 }
 
+/**
+ * Query for the "who can do X" lookup (/access/capable)
+ */
+export interface CapableUsersRequest {
+  resource: string;
+  action: PermissionAction;
+  scope?: PermissionScope;
+  page?: number;
+  limit?: number;
+}
+
+/**
+ * User who currently holds a permission, with the granting source
+ */
+export interface CapableUser {
+  user_id: string;
+  email: string;
+  username?: string;
+  source: 'user_permission' | 'position' | 'delegation' | 'role';
+  source_id: string;
+  source_name: string;
+}
+
+/**
+ * Paginated response from /access/capable
+ */
+export interface CapableUsersResponse {
+  data: CapableUser[];
+  total: number;
+  page: number;
+  page_size: number;
+  total_pages: number;
+}
+
+/**
+ * One layer of permission resolution, in the order the server consults them
+ */
+export interface PermissionResolutionLayer {
+  order: number;
+  source: 'user_permission' | 'position' | 'delegation' | 'role';
+  description: string;
+  /** Only direct user permissions can deny; the other layers only grant */
+  can_deny: boolean;
+  /** Priority in effective-permission lists; null when each grant has its own */
+  list_priority: number | null;
+}
+
+/**
+ * Resolution rules from /access/resolution-info
+ */
+export interface PermissionResolutionInfo {
+  layers: PermissionResolutionLayer[];
+  default_source: 'denied';
+  /** Higher = broader; a grant satisfies requests at or below its own level */
+  scope_hierarchy: Record<PermissionScope, number>;
+  direct_priority_order: 'ascending';
+  direct_tie_break: 'deny';
+  default_direct_priority: number;
+}
+
+/**
+ * One recent change to the current user's roles, positions or direct permissions
+ */
+export interface AccessChange {
+  id: string;
+  kind: 'role' | 'position' | 'permission';
+  action: 'ASSIGN' | 'REVOKE' | 'GRANT' | 'UPDATE';
+  name: string;
+  /** Only for direct permissions; false when the assignment denies */
+  is_granted?: boolean;
+  actor_name?: string;
+  /** Readable sentence in the request language */
+  description: string;
+  created_at: string;
+}
+
+/**
+ * Response from /access/my-changes (newest first, at most 50)
+ */
+export interface MyAccessChangesResponse {
+  data: AccessChange[];
+  days: number;
+}
+
 /**
  * Module access response from /access/modules
  */
@@ -142,6 +250,15 @@ export interface UserPermissionsResponse {
   checked_at: string;
 }
 
+/**
+ * Combined access payload from /access/me (modules + permissions in one call).
+ * version only changes when the access itself changes.
+ */
+export interface MyAccessResponse extends UserPermissionsResponse {
+  version: string;
+  modules: ModuleAccessResponse[];
+}
+
 // ============================================================================
 // Redux State Types
 // ============================================================================
@@ -20,6 +20,7 @@ export interface User {
   email: string;
   username?: string;
   is_active: boolean;  // Backend uses snake_case
+  preferences?: Record<string, unknown>;
   data_karyawan?: DataKaryawanInfo;
   roles?: Array<{ id: string; name: string }>;
   positions?: Array<{
@@ -44,6 +45,7 @@ export interface AuthResponse {
 export interface LoginRequest {
   email: string;
   password: string;
+  remember_me?: boolean;
 }
 
 export interface RegisterRequest {
@@ -56,3 +58,9 @@ export interface ChangePasswordRequest {
   current_password: string;
   new_password: string;
 }
+
+// Self-service profile update; account status can only be changed by admins
+export interface UpdateProfileRequest {
+  username?: string;
+  preferences?: Record<string, unknown>;
+}
@@ -61,6 +61,8 @@ export interface RoleModuleAccess {
   position_id?: string | null;
   permissions: Record<string, any>;
   is_active: boolean;
+  effective_from: string;
+  effective_until?: string | null;
   created_at: string;
   updated_at: string;
   created_by?: string | null;
@@ -79,6 +81,8 @@ export interface RoleModuleAccessResponse {
   position_id?: string | null;
   permissions: Record<string, any>;
   is_active: boolean;
+  effective_from: string;
+  effective_until?: string | null;
   module?: ModuleListResponse;
 }
 
@@ -142,6 +146,7 @@ export interface UpdateModuleRequest {
   sort_order?: number;
   is_active?: boolean;
   is_visible?: boolean;
+  version?: number; // version last read; the server answers 409 if it changed since
 }
 
 export interface AssignModuleToRoleRequest {
@@ -149,6 +154,8 @@ export interface AssignModuleToRoleRequest {
   position_id?: string | null;
   permissions: Record<string, any>;
   is_active?: boolean;
+  effective_from?: string;
+  effective_until?: string | null;
 }
 
 export interface GrantModuleAccessToUserRequest {
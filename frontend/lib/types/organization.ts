@@ -174,6 +174,24 @@ export interface PositionListResponse {
   is_active: boolean;
 }
 
+export interface PositionHolder {
+  user_position_id: string;
+  user_id: string;
+  email: string;
+  username?: string | null;
+  name?: string | null;
+  nip?: string | null;
+  bagian_kerja?: string | null;
+  department?: DepartmentListResponse;
+  start_date: string;
+  end_date?: string | null;
+  is_active: boolean;
+  is_plt: boolean;
+  is_current: boolean;
+  sk_number?: string | null;
+  end_reason?: string | null;
+}
+
 export interface CreatePositionRequest {
   code: string;
   name: string;
@@ -301,6 +319,8 @@ export interface CreateWorkflowRuleRequest {
   description?: string | null;
   priority?: number;
   steps?: CreateWorkflowRuleStepRequest[];
+  normalize_step_order?: boolean;
+  require_contiguous_step_order?: boolean;
 }
 
 export interface UpdateWorkflowRuleRequest {
@@ -312,6 +332,8 @@ export interface UpdateWorkflowRuleRequest {
   priority?: number;
   is_active?: boolean;
   steps?: UpdateWorkflowRuleStepRequest[];
+  normalize_step_order?: boolean;
+  require_contiguous_step_order?: boolean;
 }
 
 export interface WorkflowRuleFilter {
@@ -342,6 +364,38 @@ export interface WorkflowRuleLookupParams {
   workflow_type: WorkflowType;
 }
 
+// WorkflowApprover is a user currently holding an approver position
+export interface WorkflowApprover {
+  user_id: string;
+  username?: string;
+  name?: string;
+  is_plt: boolean;
+}
+
+export interface WorkflowApprovalChainStep extends WorkflowRuleStep {
+  step_group: number;
+  approval_mode: 'all' | 'any';
+  approvers: WorkflowApprover[];
+}
+
+// Steps in one group are approved in parallel
+export interface WorkflowApprovalGroup {
+  step_group: number;
+  approval_mode: 'all' | 'any';
+  steps: WorkflowApprovalChainStep[];
+}
+
+// WorkflowApprovalChainResponse previews who would approve a new request;
+// rule_found is false (and groups empty) when no active rule matches
+export interface WorkflowApprovalChainResponse {
+  position_id: string;
+  workflow_type: WorkflowType;
+  rule_found: boolean;
+  rule_id?: string;
+  rule_version?: number;
+  groups: WorkflowApprovalGroup[];
+}
+
 // ============================================
 // BULK CREATE WORKFLOW RULES
 // ============================================
@@ -354,6 +408,8 @@ export interface BulkCreateWorkflowRulesRequest {
   description?: string | null;
   priority?: number;
   steps?: CreateWorkflowRuleStepRequest[];
+  normalize_step_order?: boolean;
+  require_contiguous_step_order?: boolean;
 }
 
 export interface BulkCreateWorkflowRulesResult {
@@ -362,3 +418,8 @@ export interface BulkCreateWorkflowRulesResult {
   errors?: string[];
   rule_ids: string[];
 }
+
+export interface CopyWorkflowRulesRequest {
+  source_school_id: string;
+  target_school_ids: string[];
+}
@@ -45,6 +45,7 @@ export interface Permission {
   metadata?: string | null;
   is_system_permission: boolean;
   is_active: boolean;
+  is_sensitive: boolean; // every check on it is recorded (GET /audit/access-checks)
   created_at: string;
   updated_at: string;
   created_by?: string | null;
@@ -79,6 +80,27 @@ export interface PermissionGroupResponse {
   permissions: PermissionListResponse[];
 }
 
+// Roles and users that hold a permission (GET /permissions/:id/usage)
+export interface PermissionUsageResponse {
+  permission_id: string;
+  code: string;
+  roles: {
+    id: string;
+    code: string;
+    name: string;
+    is_granted: boolean;
+    user_count: number;
+  }[];
+  users: {
+    id: string;
+    email: string;
+    username?: string | null;
+    is_granted: boolean;
+    effective_until?: string | null;
+  }[];
+  effective_user_count: number;
+}
+
 // Requests for permission operations
 export interface CreatePermissionRequest {
   code: string;
@@ -90,6 +112,7 @@ export interface CreatePermissionRequest {
   conditions?: string | null;
   metadata?: string | null;
   is_system_permission?: boolean;
+  is_sensitive?: boolean;
   category?: ModuleCategory | null;
   group_icon?: string | null;
   group_name?: string | null;
@@ -106,12 +129,27 @@ export interface UpdatePermissionRequest {
   conditions?: string | null;
   metadata?: string | null;
   is_active?: boolean;
+  is_sensitive?: boolean;
   category?: ModuleCategory | null;
   group_icon?: string | null;
   group_name?: string | null;
   group_sort_order?: number | null;
 }
 
+// Rename, re-icon or reorder a group (PUT /permissions/groups/:name)
+export interface UpdatePermissionGroupRequest {
+  name?: string;
+  icon?: string | null;
+  sort_order?: number;
+}
+
+// Move a permission to another group (PUT /permissions/:id/group); an empty name ungroups it
+export interface MovePermissionGroupRequest {
+  group_name: string;
+  group_icon?: string | null;
+  group_sort_order?: number;
+}
+
 // Permission filter for queries
 export interface PermissionFilter {
   page?: number;
@@ -121,9 +159,10 @@ export interface PermissionFilter {
   action?: PermissionAction;
   scope?: PermissionScope;
   category?: ModuleCategory;
+  group_name?: string;
   is_active?: boolean;
   is_system_permission?: boolean;
-  sort_by?: 'code' | 'name' | 'resource' | 'created_at';
+  sort_by?: 'code' | 'name' | 'resource' | 'action' | 'category' | 'group_name' | 'created_at';
   sort_order?: 'asc' | 'desc';
 }
 
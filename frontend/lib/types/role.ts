@@ -11,6 +11,7 @@ export interface Role {
   hierarchy_level: number;
   is_system_role: boolean;
   is_active: boolean;
+  version: number;
   created_at: string;
   updated_at: string;
   created_by?: string | null;
@@ -24,6 +25,7 @@ export interface RoleListResponse {
   hierarchy_level: number;
   is_system_role: boolean;
   is_active: boolean;
+  effective_user_count?: number; // only present when listed with with_counts
 }
 
 // Role with permissions
@@ -71,6 +73,7 @@ export interface UpdateRoleRequest {
   description?: string | null;
   hierarchy_level?: number;
   is_active?: boolean;
+  version?: number; // version last read; the server answers 409 if it changed since
 }
 
 // Permission assignment to role
@@ -93,6 +96,7 @@ export interface RoleFilter {
   hierarchy_level?: number;
   sort_by?: 'code' | 'name' | 'hierarchy_level' | 'created_at';
   sort_order?: 'asc' | 'desc';
+  with_counts?: boolean;
 }
 
 // Paginated response
@@ -103,3 +107,49 @@ export interface PaginatedRolesResponse {
   page_size: number;
   total_pages: number;
 }
+
+// Portable role definition (GET /roles/:id/export, POST /roles/import).
+// References are codes so the document can move between environments.
+export interface RoleExport {
+  format_version: number;
+  exported_at?: string;
+  role: {
+    code: string;
+    name: string;
+    description?: string | null;
+    hierarchy_level: number;
+    is_system_role: boolean;
+    is_active: boolean;
+  };
+  permissions: {
+    code: string;
+    is_granted: boolean;
+    conditions?: string | null;
+    grant_reason?: string | null;
+    effective_from: string;
+    effective_until?: string | null;
+  }[];
+  module_access: {
+    module_code: string;
+    position_code?: string | null;
+    permissions: Record<string, boolean> | string[];
+    is_active: boolean;
+    effective_from: string;
+    effective_until?: string | null;
+  }[];
+  parent_roles: {
+    code: string;
+    inherit_permissions: boolean;
+  }[];
+}
+
+// A code in an imported role that does not exist in this environment
+export interface RoleImportReference {
+  type: 'permission' | 'module' | 'position' | 'parent_role';
+  code: string;
+}
+
+export interface RoleImportResult {
+  role?: Role;
+  unresolved: RoleImportReference[];
+}
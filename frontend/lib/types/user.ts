@@ -82,6 +82,66 @@ export interface UserPositionResponse {
   permission_scope?: string | null;
 }
 
+// Position history (/users/:id/positions/history), oldest first
+export interface UserPositionHistoryEntry {
+  id: string;
+  position_id: string;
+  position_code: string;
+  position_name: string;
+  department_name?: string | null;
+  school_name?: string | null;
+  start_date: string;
+  end_date?: string | null;
+  is_plt: boolean;
+  sk_number?: string | null;
+  status: 'active' | 'scheduled' | 'ended';
+  end_reason?: 'user_deactivated' | 'term_ended' | 'inactivated' | null;
+}
+
+export interface PositionHistoryGap {
+  from: string;
+  until: string;
+  days: number;
+}
+
+export interface UserPositionHistoryResponse {
+  user_id: string;
+  assignments: UserPositionHistoryEntry[];
+  gaps: PositionHistoryGap[];
+}
+
+export interface TransferUserAssignmentsRequest {
+  target_user_id: string;
+}
+
+export type TransferItemStatus = 'transferred' | 'already_held';
+
+export interface TransferredRole {
+  source_assignment_id: string;
+  target_assignment_id?: string;
+  role_id: string;
+  role_code: string;
+  role_name: string;
+  status: TransferItemStatus;
+}
+
+export interface TransferredPosition {
+  source_assignment_id: string;
+  target_assignment_id?: string;
+  position_id: string;
+  position_code: string;
+  position_name: string;
+  status: TransferItemStatus;
+}
+
+export interface UserAssignmentTransferResponse {
+  source_user_id: string;
+  target_user_id: string;
+  roles: TransferredRole[];
+  positions: TransferredPosition[];
+  transferred_at: string;
+}
+
 // Data Karyawan info for user
 export interface DataKaryawanInfo {
   nip: string;
@@ -134,7 +194,10 @@ export interface UserFilter {
   page?: number;
   page_size?: number;
   search?: string;
-  role_id?: string;
+  role_id?: string | string[]; // several ids match users holding any of them
+  position_id?: string;
+  department_id?: string;
+  has_no_role?: boolean;
   is_active?: boolean;
   sort_by?: 'email' | 'created_at' | 'last_active' | 'username';
   sort_order?: 'asc' | 'desc';
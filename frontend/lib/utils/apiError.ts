@@ -0,0 +1,55 @@
+/**
+ * API Error Utility
+ * Helper functions untuk membaca error response dari backend.
+ *
+ * Format standar: { error: { code, message, details } }
+ * Format lama (endpoint yang belum dimigrasi): { error: "..." } atau { message: "..." }
+ */
+
+interface ApiErrorEnvelope {
+  error?: string | { code?: string; message?: string; details?: Record<string, unknown> };
+  message?: string;
+}
+
+/**
+ * Get pesan error dari body response, atau undefined jika tidak ada
+ */
+export function getApiErrorMessage(data: unknown): string | undefined {
+  const body = data as ApiErrorEnvelope | undefined;
+  if (!body) return undefined;
+
+  if (typeof body.error === 'string') return body.error;
+  return body.error?.message || body.message;
+}
+
+/**
+ * Get kode error (mis. AUTH_INVALID_CREDENTIALS) dari body response
+ */
+export function getApiErrorCode(data: unknown): string | undefined {
+  const body = data as ApiErrorEnvelope | undefined;
+  if (!body || typeof body.error !== 'object') return undefined;
+  return body.error.code;
+}
+
+/**
+ * Get detail error dari body response
+ */
+export function getApiErrorDetails(data: unknown): Record<string, unknown> | undefined {
+  const body = data as ApiErrorEnvelope | undefined;
+  if (!body || typeof body.error !== 'object') return undefined;
+  return body.error.details;
+}
+
+/**
+ * Get pesan error per field (mis. { grant_reason: "grant_reason minimal 5 karakter" })
+ * dari validasi request. Field bertingkat memakai path seperti "steps[0].step_order".
+ */
+export function getApiFieldErrors(data: unknown): Record<string, string> | undefined {
+  const body = data as (ApiErrorEnvelope & { fields?: Record<string, string> }) | undefined;
+  if (!body) return undefined;
+
+  if (typeof body.error === 'object') {
+    return body.error.details?.fields as Record<string, string> | undefined;
+  }
+  return body.fields;
+}
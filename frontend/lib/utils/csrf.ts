@@ -33,3 +33,35 @@ export function hasValidCSRFToken(): boolean {
   const token = getCSRFToken();
   return token !== null && token.length > 0;
 }
+
+// Shared in-flight request so concurrent mutations trigger only one fetch
+let csrfFetch: Promise<string | null> | null = null;
+
+/**
+ * Ensure CSRF token tersedia sebelum request yang mengubah state.
+ * Jika cookie belum ada (mis. setelah reload dengan auth cookie yang masih valid),
+ * token diambil dari GET /auth/csrf yang sekaligus men-set ulang cookie.
+ */
+export async function ensureCSRFToken(apiBaseUrl: string): Promise<string | null> {
+  const existing = getCSRFToken();
+  if (existing) {
+    return existing;
+  }
+
+  if (!csrfFetch) {
+    csrfFetch = fetch(`${apiBaseUrl}/auth/csrf`, { credentials: 'include' })
+      .then(async (response) => {
+        if (!response.ok) {
+          return null;
+        }
+        const body = await response.json();
+        return (body?.data?.csrf_token as string) || null;
+      })
+      .catch(() => null)
+      .finally(() => {
+        csrfFetch = null;
+      });
+  }
+
+  return csrfFetch;
+}